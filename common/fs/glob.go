@@ -0,0 +1,39 @@
+package fs
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GlobRegexp converts the glob pattern to a regular expression matching the whole path. * matches any character except
+// /, ** matches any character including / and ? matches a single character except /.
+func GlobRegexp(pattern string) *regexp.Regexp {
+	sb := strings.Builder{}
+
+	sb.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			switch {
+			case strings.HasPrefix(pattern[i:], "**/"):
+				// **/ matches zero or more directories
+				sb.WriteString("(.*/)?")
+				i += 2
+			case strings.HasPrefix(pattern[i:], "**"):
+				sb.WriteString(".*")
+				i++
+			default:
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	sb.WriteString("$")
+
+	return regexp.MustCompile(sb.String())
+}
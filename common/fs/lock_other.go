@@ -0,0 +1,9 @@
+//go:build !unix
+
+package fs
+
+// WithLock runs the given function. File locks are not supported on this platform, so the function runs without
+// serializing the processes using the same file.
+func WithLock(_ string, fn func() error) error {
+	return fn()
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package fs
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// WithLock runs the given function holding an exclusive lock of the given file. The lock is held on a separate lock
+// file next to the file, <file>.lock, so the file itself could be replaced while the lock is held. Locks are advisory,
+// they only serialize the processes locking the same file, e.g. ghx processes of the concurrent workflow runs sharing
+// the same cache volume.
+func WithLock(file string, fn func() error) error {
+	lock := file + ".lock"
+
+	if err := EnsureFile(lock); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(lock, os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file %s: %w", lock, err)
+	}
+
+	defer f.Close()
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", file, err)
+	}
+
+	//nolint:errcheck // closing the file releases the lock as well
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+
+	return fn()
+}
@@ -0,0 +1,38 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// stdout is the console the loggers print to.
+var stdout = newConsole(os.Stdout)
+
+// console prints the lines of the loggers. Lines are written one at a time, so the lines of the concurrent loggers
+// don't break each other.
+type console struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func newConsole(out io.Writer) *console {
+	return &console{out: out}
+}
+
+// SetOutput sets the writer the lines of the loggers are printed to. Defaults to stdout.
+func SetOutput(out io.Writer) {
+	stdout.mu.Lock()
+	defer stdout.mu.Unlock()
+
+	stdout.out = out
+}
+
+// print prints the line to the output of the console.
+func (c *console) print(line string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintln(c.out, line)
+}
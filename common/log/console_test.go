@@ -0,0 +1,41 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// useConsole replaces the console of the loggers with a console writing to a buffer for the test.
+func useConsole(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	previous := stdout
+
+	stdout = newConsole(&buf)
+
+	t.Cleanup(func() { stdout = previous })
+
+	return &buf
+}
+
+func TestConsole_Groups(t *testing.T) {
+	buf := useConsole(t)
+
+	build, test := NewLogger(), NewLogger()
+
+	build.StartGroup()
+	build.Info("build")
+	build.EndGroup()
+	test.StartGroup()
+	test.Info("test")
+	test.EndGroup()
+
+	want := []string{"┏ ", "┃ build", "┗ ", "┏ ", "┃ test", "┗ "}
+
+	if got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("unexpected output, got %q, want %q", got, want)
+	}
+}
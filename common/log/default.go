@@ -13,6 +13,26 @@ func EndGroup() {
 	logger.EndGroup()
 }
 
+// SetPrefix sets the function returning the prefix of the log lines in the default logger.
+func SetPrefix(fn func() string) {
+	logger.SetPrefix(fn)
+}
+
+// SetSink sets the function receiving the log lines of the default logger in addition to the console.
+func SetSink(fn func(line string)) {
+	logger.SetSink(fn)
+}
+
+// AddMask registers the given value to be masked in the default logger.
+func AddMask(value string) {
+	logger.AddMask(value)
+}
+
+// Mask replaces the registered mask values of the default logger in the given string.
+func Mask(str string) string {
+	return logger.mask(str)
+}
+
 // Info logs an info message in the default logger.
 func Info(message string) {
 	logger.Info(message)
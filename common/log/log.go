@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 )
 
 const (
@@ -15,10 +16,17 @@ const (
 	LevelWarn   = "warn"
 	LevelErr    = "error"
 	LevelNotice = "notice"
+
+	// maskReplacement is the value used to replace masked values in the log output.
+	maskReplacement = "***"
 )
 
 type Logger struct {
 	groups []string
+	masks  []string
+	maskMu sync.RWMutex      // maskMu guards the masks, values could be masked while the other goroutines are logging
+	prefix func() string     // prefix returns the prefix of the lines, e.g. the job and the step producing the output
+	sink   func(line string) // sink receives the lines in addition to the console, e.g. to record them to a file
 }
 
 func NewLogger() *Logger {
@@ -38,6 +46,30 @@ func (l *Logger) EndGroup() {
 	l.log(groupEnd, "", "")
 }
 
+// AddMask registers the given value to be masked in the log output. Empty values are ignored.
+func (l *Logger) AddMask(value string) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+
+	l.maskMu.Lock()
+	defer l.maskMu.Unlock()
+
+	l.masks = append(l.masks, value)
+}
+
+// SetPrefix sets the function returning the prefix of the log lines. The prefix is evaluated for each line, so it could
+// contain the changing information like the elapsed time.
+func (l *Logger) SetPrefix(fn func() string) {
+	l.prefix = fn
+}
+
+// SetSink sets the function receiving the log lines in addition to the console. Lines are masked and don't have the
+// prefix. Nil function removes the sink.
+func (l *Logger) SetSink(fn func(line string)) {
+	l.sink = fn
+}
+
 func (l *Logger) Info(message string) {
 	l.log("", "", message)
 }
@@ -118,7 +150,32 @@ func (l *Logger) log(prefix, level, message string) {
 
 	sb.WriteString(message)
 
-	fmt.Println(sb.String())
+	line := l.mask(sb.String())
+
+	// sink receives the lines without the prefix, the information of the prefix is kept by the sink itself
+	if l.sink != nil {
+		l.sink(line)
+	}
+
+	if l.prefix != nil {
+		linePrefix := l.mask(l.prefix())
+
+		line = linePrefix + strings.ReplaceAll(line, "\n", fmt.Sprintf("\n%s", linePrefix))
+	}
+
+	stdout.print(line)
+}
+
+// mask replaces the registered mask values in the given string.
+func (l *Logger) mask(str string) string {
+	l.maskMu.RLock()
+	defer l.maskMu.RUnlock()
+
+	for _, m := range l.masks {
+		str = strings.ReplaceAll(str, m, maskReplacement)
+	}
+
+	return str
 }
 
 // wrapWithQuotesAndEscape wraps value in with `"` if given value is string and not quoted already.
@@ -0,0 +1,55 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLogger_AddMask(t *testing.T) {
+	buf := useConsole(t)
+
+	logger := NewLogger()
+
+	logger.AddMask("s3cr3t")
+	logger.AddMask("  ")
+	logger.Infof("Logged in", "token", "s3cr3t")
+
+	if got := buf.String(); strings.Contains(got, "s3cr3t") || !strings.Contains(got, `token="***"`) {
+		t.Errorf("expected the token to be masked, got %q", got)
+	}
+}
+
+func TestLogger_AddMaskConcurrently(t *testing.T) {
+	buf := useConsole(t)
+
+	var (
+		logger = NewLogger()
+		wg     sync.WaitGroup
+	)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+
+			logger.AddMask(fmt.Sprintf("secret-%d", i))
+		}(i)
+
+		go func(i int) {
+			defer wg.Done()
+
+			logger.Info(fmt.Sprintf("line %d", i))
+		}(i)
+	}
+
+	wg.Wait()
+
+	logger.Info("secret-1 secret-9")
+
+	if got := buf.String(); !strings.Contains(got, "*** ***") {
+		t.Errorf("expected the secrets to be masked, got %q", got)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+)
+
+// Actions represents the custom actions used by the workflows.
+type Actions struct{}
+
+// Prefetch downloads all actions used by the workflow to the actions cache and pins them to the commit SHAs resolved
+// from their refs. Container images of the docker steps and actions are pulled as well. Prefetched actions and images
+// can be used later to run the workflow in offline mode.
+func (a *Actions) Prefetch(ctx context.Context, repoOpts WorkflowsRepoOpts, pathOpts WorkflowsDirOpts, workflow string) (string, error) {
+	wr := &WorkflowRun{
+		Config: &WorkflowRunConfig{
+			WorkflowsRepoOpts: &repoOpts,
+			WorkflowsDirOpts:  &pathOpts,
+			WorkflowsRunOpts:  &WorkflowsRunOpts{Workflow: workflow, Event: "push"},
+		},
+	}
+
+	container, err := wr.run(ctx, "prefetch")
+	if err != nil {
+		return "", err
+	}
+
+	return container.Stdout(ctx)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Artifacts represents the artifacts uploaded by the workflow runs to the local artifact service.
+type Artifacts struct{}
+
+// List returns the names of the artifacts uploaded by the workflow run with the given id.
+func (a *Artifacts) List(ctx context.Context, runID string) (string, error) {
+	container, err := a.client(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return container.
+		WithEnvVariable("RUN_ID", runID).
+		WithExec([]string{"sh", "-c", listArtifactsScript}).
+		Stdout(ctx)
+}
+
+// Download returns the directory of the artifact with the given name uploaded by the workflow run with the given id.
+func (a *Artifacts) Download(ctx context.Context, runID string, name string) (*Directory, error) {
+	container, err := a.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return container.
+		WithEnvVariable("RUN_ID", runID).
+		WithEnvVariable("NAME", name).
+		WithExec([]string{"sh", "-c", downloadArtifactScript("/downloads")}).
+		Directory("/downloads/" + name), nil
+}
+
+// listArtifactsScript is the script listing the names of the artifacts of the RUN_ID run from the artifact service.
+const listArtifactsScript = `curl -fsS "${ACTIONS_RUNTIME_URL%/}/_apis/pipelines/workflows/${RUN_ID}/artifacts" | jq -r '.value[].name'`
+
+// downloadArtifactScript returns the script downloading the items of the NAME artifact of the RUN_ID run from the
+// artifact service to the given directory. Items are listed relative to the run, so the artifact name is the first
+// element of the item paths. Gzipped items are decompressed with --compressed flag.
+func downloadArtifactScript(dir string) string {
+	return fmt.Sprintf(`set -e
+curl -fsS -G --data-urlencode "itemPath=${NAME}" "${ACTIONS_RUNTIME_URL%%/}/download/${RUN_ID}" | jq -r '.value[].path' | while IFS= read -r item; do
+  mkdir -p "%[1]s/$(dirname "$item")"
+  curl -fsS --compressed -o "%[1]s/$item" "${ACTIONS_RUNTIME_URL%%/}/artifact/${RUN_ID}/$item"
+done`, dir)
+}
+
+// client returns a container bound to the local artifact service to query the artifacts.
+func (a *Artifacts) client(ctx context.Context) (*Container, error) {
+	container := dag.Container().From("alpine:latest").
+		WithExec([]string{"apk", "add", "--no-cache", "curl", "jq"}).
+		// artifacts could change between calls, so the queries shouldn't be cached
+		WithEnvVariable("CACHE_BUSTER", time.Now().Format(time.RFC3339Nano))
+
+	return dag.Source().ArtifactService().BindAsService(ctx, container)
+}
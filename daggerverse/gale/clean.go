@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CleanOpts represents the options for deleting the data gale keeps between the runs.
+type CleanOpts struct {
+	Runs           bool   `doc:"Delete the run history with the artifacts and the live logs of the runs." default:"false"`
+	Cache          bool   `doc:"Delete the actions cache and, if the cache namespace is given, the tool cache, the user cache, the preserved workspaces and the docker data of the namespace." default:"false"`
+	Actions        bool   `doc:"Delete the downloaded actions and the node runtimes of the javascript actions." default:"false"`
+	All            bool   `doc:"Delete the runs, the caches and the actions." default:"false"`
+	CacheNamespace string `doc:"Namespace of the cache volumes to delete, e.g. the cache namespace option of the runs or the repository name with owner."`
+	DryRun         bool   `doc:"Print what would be deleted with the sizes without deleting anything." default:"false"`
+}
+
+// cleanVolume is a cache volume to delete the contents of.
+type cleanVolume struct {
+	Path   string       // Path is the path to mount the volume to in the clean container
+	Volume *CacheVolume // Volume is the cache volume
+}
+
+// Clean deletes the contents of the cache volumes keeping the runs, the caches or the actions between the runs and
+// returns the deleted paths with their sizes. With dry run, it only returns what would be deleted.
+func (g *Gale) Clean(ctx context.Context, opts CleanOpts) (string, error) {
+	var volumes []cleanVolume
+
+	if opts.Runs || opts.All {
+		volumes = append(volumes,
+			cleanVolume{Path: "/runs", Volume: dag.CacheVolume("gale-runs")},
+			cleanVolume{Path: "/artifacts", Volume: dag.Source().ArtifactService().CacheVolume()},
+			cleanVolume{Path: "/logs", Volume: dag.CacheVolume("gale-logs")},
+			cleanVolume{Path: "/logs-untrusted", Volume: dag.CacheVolume("gale-logs-untrusted")},
+		)
+	}
+
+	if opts.Cache || opts.All {
+		volumes = append(volumes, cleanVolume{Path: "/cache/actions", Volume: dag.Source().ArtifactCacheService().CacheVolume()})
+
+		if opts.CacheNamespace != "" {
+			// cache volume keys use the namespace as is, replacing path separators like the runs
+			namespace := strings.ReplaceAll(opts.CacheNamespace, "/", "-")
+
+			for _, name := range []string{"tool-cache", "cache", "workspaces", "docker"} {
+				volumes = append(volumes, cleanVolume{
+					Path:   fmt.Sprintf("/cache/%s", name),
+					Volume: dag.CacheVolume(fmt.Sprintf("gale-%s-%s", name, namespace)),
+				})
+			}
+		}
+	}
+
+	if opts.Actions || opts.All {
+		volumes = append(volumes,
+			cleanVolume{Path: "/actions", Volume: dag.CacheVolume("gale-actions")},
+			cleanVolume{Path: "/actions-untrusted", Volume: dag.CacheVolume("gale-actions-untrusted")},
+		)
+
+		// node runtimes are installed by the actions, so they're deleted with them
+		for _, platform := range []string{"linux/amd64", "linux/arm64"} {
+			volumes = append(volumes, cleanVolume{
+				Path:   fmt.Sprintf("/externals/%s", strings.ReplaceAll(platform, "/", "-")),
+				Volume: dag.CacheVolume(externalsVolume(platform)),
+			})
+		}
+	}
+
+	if len(volumes) == 0 {
+		return "", errors.New("nothing to clean, use runs, cache, actions or all to select what to delete")
+	}
+
+	container := dag.Container().From("alpine:latest").With(dag.Source().Ghx().Binary)
+
+	args := []string{"ghx", "clean"}
+
+	if opts.DryRun {
+		args = append(args, "--dry-run")
+	}
+
+	for _, v := range volumes {
+		container = container.WithMountedCache(v.Path, v.Volume, ContainerWithMountedCacheOpts{Sharing: Locked})
+		args = append(args, v.Path)
+	}
+
+	out, err := container.
+		// contents of the volumes change between calls, so the clean shouldn't be cached
+		WithEnvVariable("CACHE_BUSTER", time.Now().Format(time.RFC3339Nano)).
+		WithExec(args).
+		Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to clean: %w", err)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,698 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// configFileName is the name of the project level configuration file looked up in the repository root.
+const configFileName = "gale.yaml"
+
+// secretsFilesDir is the directory of the secrets files ghx loads the secrets from in the runner container.
+const secretsFilesDir = "/home/runner/_temp/gale/secrets"
+
+// secretsEnvPath is the path of the environment variables forwarded from the host in the runner container.
+const secretsEnvPath = "/home/runner/_temp/gale/secrets-env"
+
+// galeConfig represents the project level gale.yaml configuration. Options provided with the workflow run have
+// precedence over the configuration.
+//
+// Example:
+//
+//	runner-image: ghcr.io/catthehacker/ubuntu:act-22.04
+//	ghx-version: v0.0.9
+//	registry-mirrors:
+//	  docker.io: mirror.example.com
+//	image-overrides:
+//	  node:16: registry.example.com/node:16
+//	runner-labels:
+//	  ubuntu-22.04: ghcr.io/catthehacker/ubuntu:act-22.04
+//	secrets-file: .secrets
+//	retries:
+//	  - step: test/integration
+//	    max: 3
+//	    backoff: 10s
+//	    on: [failure, timeout]
+//	notifications:
+//	  - type: slack
+//	    url-file: .slack-webhook
+//	    events: [failure, recovered]
+//	workspace: isolated
+//	workspace-jobs:
+//	  build:
+//	    clean: false
+//	env:
+//	  FOO: bar
+//	profiles:
+//	  ci-mirror:
+//	    runner-profile: full
+//	    token-file: .github-token
+type galeConfig struct {
+	configProfile `yaml:",inline"`
+
+	Profiles map[string]configProfile `yaml:"profiles"` // Profiles is the map of named profiles overriding the defaults.
+}
+
+// configProfile represents the configurable options of a workflow run.
+type configProfile struct {
+	RunnerImage     string            `yaml:"runner-image"`           // RunnerImage is the default runner image.
+	RunnerProfile   string            `yaml:"runner-profile"`         // RunnerProfile is the build profile of the runner image.
+	GhxVersion      string            `yaml:"ghx-version"`            // GhxVersion is the version of the published ghx image to pin.
+	GhxBinary       string            `yaml:"ghx-binary"`             // GhxBinary is the prebuilt ghx binary in the repository.
+	RunnerLabels    map[string]string `yaml:"runner-labels"`          // RunnerLabels is the map of runs-on labels to runner images.
+	RunnerPlatforms map[string]string `yaml:"runner-platforms"`       // RunnerPlatforms is the map of runs-on labels to platforms.
+	Platform        string            `yaml:"platform"`               // Platform is the platform of the runner container.
+	RegistryMirrors map[string]string `yaml:"registry-mirrors"`       // RegistryMirrors is the map of registries to their mirrors.
+	ImageOverrides  map[string]string `yaml:"image-overrides"`        // ImageOverrides is the map of images to their replacements.
+	PullPolicy      string            `yaml:"pull-policy"`            // PullPolicy is the pull policy of the step images.
+	HttpProxy       string            `yaml:"http-proxy"`             // HttpProxy is the HTTP proxy of the runner.
+	HttpsProxy      string            `yaml:"https-proxy"`            // HttpsProxy is the HTTPS proxy of the runner.
+	NoProxy         string            `yaml:"no-proxy"`               // NoProxy is the list of hosts to access without the proxy.
+	CaCerts         []string          `yaml:"ca-certs"`               // CaCerts is the list of CA certificate files in the repository.
+	Network         string            `yaml:"network"`                // Network is the network mode of the steps.
+	NetworkJobs     map[string]string `yaml:"network-jobs"`           // NetworkJobs is the map of job ids to their network modes.
+	NetworkAllow    []string          `yaml:"network-allowlist"`      // NetworkAllow is the list of domains allowed in the restricted mode.
+	CacheNamespace  string            `yaml:"cache-namespace"`        // CacheNamespace is the namespace of the cache volumes.
+	Workspace       string            `yaml:"workspace"`              // Workspace is the sharing mode of the workspace between the jobs.
+	WorkspaceJobs   workspaceJobs     `yaml:"workspace-jobs"`         // WorkspaceJobs is the map of job ids to their workspace options.
+	Offline         *bool             `yaml:"offline"`                // Offline disables downloading actions.
+	SecretsFile     string            `yaml:"secrets-file"`           // SecretsFile is the file in the repository with the secrets.
+	TokenFile       string            `yaml:"token-file"`             // TokenFile is the file in the repository with the GitHub token.
+	OnComplete      string            `yaml:"on-complete"`            // OnComplete is the script in the repository to run after the workflow run.
+	FailOn          string            `yaml:"fail-on"`                // FailOn is the policy failing the workflow run result.
+	RetentionDays   string            `yaml:"retention-days"`         // RetentionDays is the number of days to keep the runs in the history.
+	RunsMaxSize     string            `yaml:"runs-max-size"`          // RunsMaxSize is the maximum total size of the run history.
+	RequirePinned   *bool             `yaml:"require-pinned-actions"` // RequirePinned fails the run on the actions not pinned to a commit SHA.
+	CheckArchived   *bool             `yaml:"check-archived-actions"` // CheckArchived warns about the actions from archived repositories.
+	FilesReport     *bool             `yaml:"files-report"`           // FilesReport reports the files written by each step.
+	Deployments     *bool             `yaml:"deployments"`            // Deployments reports the environment jobs as GitHub deployments.
+	ApiAudit        *bool             `yaml:"api-audit"`              // ApiAudit records the GitHub API calls of the steps in the run report.
+	Untrusted       *bool             `yaml:"untrusted"`              // Untrusted evaluates the workflows as untrusted code.
+	CacheJobs       *bool             `yaml:"cache-jobs"`             // CacheJobs reuses the results of the runs with the same inputs.
+	FilesReportPath []string          `yaml:"files-report-paths"`     // FilesReportPath is the list of additional paths to watch for the files report.
+	ActionsDenylist []string          `yaml:"actions-denylist"`       // ActionsDenylist is the list of actions to warn about.
+	ActionsPolicy   actionsPolicy     `yaml:"actions-policy"`         // ActionsPolicy is the policy of the actions allowed to run.
+	JournalSinks    []string          `yaml:"journal-sinks"`          // JournalSinks is the list of sinks to ship the console output to.
+	Retries         []retryConfig     `yaml:"retries"`                // Retries is the list of step retry policies.
+	Notifications   []notifyConfig    `yaml:"notifications"`          // Notifications is the list of notifiers of the workflow run completion.
+	Env             map[string]string `yaml:"env"`                    // Env is the environment variables of the runner.
+}
+
+// retryConfig represents the retry policy of a step in the configuration.
+type retryConfig struct {
+	Step    string   `yaml:"step"`    // Step is the id or name of the step, optionally prefixed with the job id, e.g. test/integration.
+	Max     int      `yaml:"max"`     // Max is the maximum number of attempts including the first one.
+	Backoff string   `yaml:"backoff"` // Backoff is the wait duration before the next attempt, doubled after each attempt.
+	Timeout string   `yaml:"timeout"` // Timeout is the timeout of a single attempt.
+	On      []string `yaml:"on"`      // On is the list of conditions to retry the step. Possible values are failure and timeout.
+}
+
+// actionsPolicy represents the policy of the actions allowed to run in the configuration, like the allowed actions
+// setting of GitHub.
+type actionsPolicy struct {
+	Allow []string `yaml:"allow"` // Allow is the list of action patterns allowed to run, e.g. actions/* or my-org/*.
+	Deny  []string `yaml:"deny"`  // Deny is the list of action patterns refused to run, e.g. */setup-random@*.
+}
+
+// workspaceJobs is the map of job ids to their workspace options.
+type workspaceJobs map[string]workspaceJobConfig
+
+// workspaceJobConfig represents the workspace options of a job in the configuration.
+type workspaceJobConfig struct {
+	Clean *bool `yaml:"clean"` // Clean starts the job with a clean workspace in each run, false keeps the workspace between the runs. Defaults to true.
+}
+
+// notifyConfig represents a notifier of the workflow run completion in the configuration.
+type notifyConfig struct {
+	Type     string   `yaml:"type" json:"type"`         // Type is the type of the notifier. Possible values are: webhook, slack, discord.
+	URL      string   `yaml:"url" json:"url"`           // URL is the webhook URL to post the notifications to.
+	URLFile  string   `yaml:"url-file" json:"-"`        // URLFile is the file in the repository with the webhook URL, to keep the URL out of the configuration.
+	Events   []string `yaml:"events" json:"events"`     // Events is the list of events to notify. Possible values are: success, failure, recovered.
+	Template string   `yaml:"template" json:"template"` // Template is the Go template of the message with the run summary.
+}
+
+// String returns the retry policy in the format ghx expects, e.g. test/integration=max:3,backoff:10s,on:failure|timeout
+func (rc retryConfig) String() string {
+	var options []string
+
+	if rc.Max > 0 {
+		options = append(options, fmt.Sprintf("max:%d", rc.Max))
+	}
+
+	if rc.Backoff != "" {
+		options = append(options, fmt.Sprintf("backoff:%s", rc.Backoff))
+	}
+
+	if rc.Timeout != "" {
+		options = append(options, fmt.Sprintf("timeout:%s", rc.Timeout))
+	}
+
+	if len(rc.On) > 0 {
+		options = append(options, fmt.Sprintf("on:%s", strings.Join(rc.On, "|")))
+	}
+
+	return fmt.Sprintf("%s=%s", rc.Step, strings.Join(options, ","))
+}
+
+// merge merges the given profile into the profile. Values of the given profile have precedence.
+func (p *configProfile) merge(other configProfile) {
+	if other.RunnerImage != "" {
+		p.RunnerImage = other.RunnerImage
+	}
+
+	if other.RunnerProfile != "" {
+		p.RunnerProfile = other.RunnerProfile
+	}
+
+	if other.Platform != "" {
+		p.Platform = other.Platform
+	}
+
+	if other.GhxVersion != "" {
+		p.GhxVersion = other.GhxVersion
+	}
+
+	if other.GhxBinary != "" {
+		p.GhxBinary = other.GhxBinary
+	}
+
+	if other.PullPolicy != "" {
+		p.PullPolicy = other.PullPolicy
+	}
+
+	if other.HttpProxy != "" {
+		p.HttpProxy = other.HttpProxy
+	}
+
+	if other.HttpsProxy != "" {
+		p.HttpsProxy = other.HttpsProxy
+	}
+
+	if other.NoProxy != "" {
+		p.NoProxy = other.NoProxy
+	}
+
+	if other.Network != "" {
+		p.Network = other.Network
+	}
+
+	if other.CacheNamespace != "" {
+		p.CacheNamespace = other.CacheNamespace
+	}
+
+	if other.Workspace != "" {
+		p.Workspace = other.Workspace
+	}
+
+	if len(other.WorkspaceJobs) > 0 {
+		jobs := make(workspaceJobs, len(p.WorkspaceJobs)+len(other.WorkspaceJobs))
+
+		for job, jc := range p.WorkspaceJobs {
+			jobs[job] = jc
+		}
+
+		for job, jc := range other.WorkspaceJobs {
+			jobs[job] = jc
+		}
+
+		p.WorkspaceJobs = jobs
+	}
+
+	if other.SecretsFile != "" {
+		p.SecretsFile = other.SecretsFile
+	}
+
+	if other.TokenFile != "" {
+		p.TokenFile = other.TokenFile
+	}
+
+	if other.OnComplete != "" {
+		p.OnComplete = other.OnComplete
+	}
+
+	if other.FailOn != "" {
+		p.FailOn = other.FailOn
+	}
+
+	if other.RetentionDays != "" {
+		p.RetentionDays = other.RetentionDays
+	}
+
+	if other.RunsMaxSize != "" {
+		p.RunsMaxSize = other.RunsMaxSize
+	}
+
+	// flags set in the given profile override the profile, so a profile could disable a flag enabled by default
+	p.Offline = mergeFlag(p.Offline, other.Offline)
+	p.RequirePinned = mergeFlag(p.RequirePinned, other.RequirePinned)
+	p.CheckArchived = mergeFlag(p.CheckArchived, other.CheckArchived)
+	p.FilesReport = mergeFlag(p.FilesReport, other.FilesReport)
+	p.Deployments = mergeFlag(p.Deployments, other.Deployments)
+	p.ApiAudit = mergeFlag(p.ApiAudit, other.ApiAudit)
+	p.Untrusted = mergeFlag(p.Untrusted, other.Untrusted)
+	p.CacheJobs = mergeFlag(p.CacheJobs, other.CacheJobs)
+
+	// lists of the profile could share their backing arrays with the loaded configuration, so they're copied before
+	// appending
+	p.FilesReportPath = append(slices.Clone(p.FilesReportPath), other.FilesReportPath...)
+	p.ActionsDenylist = append(slices.Clone(p.ActionsDenylist), other.ActionsDenylist...)
+	p.ActionsPolicy.Allow = append(slices.Clone(p.ActionsPolicy.Allow), other.ActionsPolicy.Allow...)
+	p.ActionsPolicy.Deny = append(slices.Clone(p.ActionsPolicy.Deny), other.ActionsPolicy.Deny...)
+	p.JournalSinks = append(slices.Clone(p.JournalSinks), other.JournalSinks...)
+	p.RunnerLabels = mergeMap(p.RunnerLabels, other.RunnerLabels)
+	p.RunnerPlatforms = mergeMap(p.RunnerPlatforms, other.RunnerPlatforms)
+	p.RegistryMirrors = mergeMap(p.RegistryMirrors, other.RegistryMirrors)
+	p.ImageOverrides = mergeMap(p.ImageOverrides, other.ImageOverrides)
+	p.NetworkJobs = mergeMap(p.NetworkJobs, other.NetworkJobs)
+	p.NetworkAllow = append(slices.Clone(p.NetworkAllow), other.NetworkAllow...)
+	p.CaCerts = append(slices.Clone(p.CaCerts), other.CaCerts...)
+	p.Env = mergeMap(p.Env, other.Env)
+
+	// policies are applied in order, so the policies of the given profile are appended to override the previous ones
+	p.Retries = append(slices.Clone(p.Retries), other.Retries...)
+	p.Notifications = append(slices.Clone(p.Notifications), other.Notifications...)
+}
+
+// loadConfig loads the configuration from the config option or the gale.yaml in the repository root and applies the
+// selected profile to the options not provided with the workflow run.
+func (wr *WorkflowRun) loadConfig(ctx context.Context) error {
+	if wr.Config.configLoaded {
+		return nil
+	}
+
+	wr.Config.configLoaded = true
+
+	source := dag.Repo().Source((RepoSourceOpts)(*wr.Config.WorkflowsRepoOpts))
+
+	file := wr.Config.Config
+
+	if file == nil {
+		entries, err := source.Entries(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if entry == configFileName {
+				file = source.File(configFileName)
+				break
+			}
+		}
+	}
+
+	if file == nil {
+		if wr.Config.Profile != "" {
+			return fmt.Errorf("profile %s is requested but no %s is found", wr.Config.Profile, configFileName)
+		}
+
+		return nil
+	}
+
+	var config galeConfig
+
+	if err := file.unmarshalContentsToYAML(ctx, &config); err != nil {
+		return err
+	}
+
+	profile := config.configProfile
+
+	if wr.Config.Profile != "" {
+		selected, ok := config.Profiles[wr.Config.Profile]
+		if !ok {
+			return fmt.Errorf("profile %s not found in %s", wr.Config.Profile, configFileName)
+		}
+
+		profile.merge(selected)
+	}
+
+	return wr.Config.apply(ctx, profile, source)
+}
+
+// apply applies the profile to the options not provided with the workflow run.
+func (wrc *WorkflowRunConfig) apply(ctx context.Context, profile configProfile, source *Directory) error {
+	if wrc.RunnerImage == "" {
+		wrc.RunnerImage = profile.RunnerImage
+	}
+
+	if wrc.RunnerProfile == "" {
+		wrc.RunnerProfile = profile.RunnerProfile
+	}
+
+	if wrc.GhxVersion == "" {
+		wrc.GhxVersion = profile.GhxVersion
+	}
+
+	if wrc.Platform == "" {
+		wrc.Platform = profile.Platform
+	}
+
+	if wrc.CacheNamespace == "" {
+		wrc.CacheNamespace = profile.CacheNamespace
+	}
+
+	if wrc.PullPolicy == "" {
+		wrc.PullPolicy = profile.PullPolicy
+	}
+
+	if wrc.HttpProxy == "" {
+		wrc.HttpProxy = profile.HttpProxy
+	}
+
+	if wrc.HttpsProxy == "" {
+		wrc.HttpsProxy = profile.HttpsProxy
+	}
+
+	if wrc.NoProxy == "" {
+		wrc.NoProxy = profile.NoProxy
+	}
+
+	if wrc.Network == "" {
+		wrc.Network = profile.Network
+	}
+
+	if wrc.WorkspaceMode == "" {
+		wrc.WorkspaceMode = profile.Workspace
+	}
+
+	if wrc.OnComplete == "" {
+		wrc.OnComplete = profile.OnComplete
+	}
+
+	if wrc.FailOn == "" {
+		wrc.FailOn = profile.FailOn
+	}
+
+	if wrc.RetentionDays == "" {
+		wrc.RetentionDays = profile.RetentionDays
+	}
+
+	if wrc.RunsMaxSize == "" {
+		wrc.RunsMaxSize = profile.RunsMaxSize
+	}
+
+	// flags of the run can't be told apart from their defaults, so enabled flags of the run have precedence and the
+	// profile decides otherwise
+	wrc.Offline = applyFlag(wrc.Offline, profile.Offline)
+	wrc.RequirePinnedActions = applyFlag(wrc.RequirePinnedActions, profile.RequirePinned)
+	wrc.CheckArchivedActions = applyFlag(wrc.CheckArchivedActions, profile.CheckArchived)
+	wrc.FilesReport = applyFlag(wrc.FilesReport, profile.FilesReport)
+	wrc.Deployments = applyFlag(wrc.Deployments, profile.Deployments)
+	wrc.ApiAudit = applyFlag(wrc.ApiAudit, profile.ApiAudit)
+	wrc.Untrusted = applyFlag(wrc.Untrusted, profile.Untrusted)
+	wrc.CacheJobs = applyFlag(wrc.CacheJobs, profile.CacheJobs)
+
+	// lists of the profile are copied before appending, so the options never share the backing arrays of the profile
+	wrc.FilesReportPaths = append(slices.Clone(profile.FilesReportPath), wrc.FilesReportPaths...)
+	wrc.ActionsDenylist = append(slices.Clone(profile.ActionsDenylist), wrc.ActionsDenylist...)
+	wrc.ActionsAllow = append(slices.Clone(profile.ActionsPolicy.Allow), wrc.ActionsAllow...)
+	wrc.ActionsDeny = append(slices.Clone(profile.ActionsPolicy.Deny), wrc.ActionsDeny...)
+	wrc.JournalSinks = append(slices.Clone(profile.JournalSinks), wrc.JournalSinks...)
+
+	// label mappings are parsed in order, so mappings provided with the options are appended to override the config
+	wrc.RunnerLabels = append(labelMappings(profile.RunnerLabels), wrc.RunnerLabels...)
+	wrc.RunnerPlatforms = append(labelMappings(profile.RunnerPlatforms), wrc.RunnerPlatforms...)
+	wrc.RegistryMirrors = append(labelMappings(profile.RegistryMirrors), wrc.RegistryMirrors...)
+	wrc.ImageOverrides = append(labelMappings(profile.ImageOverrides), wrc.ImageOverrides...)
+	wrc.NetworkJobs = append(labelMappings(profile.NetworkJobs), wrc.NetworkJobs...)
+	wrc.NetworkAllowlist = append(slices.Clone(profile.NetworkAllow), wrc.NetworkAllowlist...)
+
+	// jobs are sorted to keep the configuration of the runner stable between the runs
+	var preserved []string
+
+	for job, jc := range profile.WorkspaceJobs {
+		if jc.Clean != nil && !*jc.Clean && !slices.Contains(wrc.PreserveWorkspaces, job) {
+			preserved = append(preserved, job)
+		}
+	}
+
+	sort.Strings(preserved)
+
+	wrc.PreserveWorkspaces = append(slices.Clone(wrc.PreserveWorkspaces), preserved...)
+
+	retries := make([]string, 0, len(profile.Retries))
+
+	for _, retry := range profile.Retries {
+		retries = append(retries, retry.String())
+	}
+
+	wrc.Retries = append(retries, wrc.Retries...)
+
+	certs := slices.Clone(wrc.CaCerts)
+
+	for _, cert := range profile.CaCerts {
+		certs = append(certs, source.File(cert))
+	}
+
+	wrc.CaCerts = certs
+
+	if wrc.GhxBinary == nil && profile.GhxBinary != "" {
+		wrc.GhxBinary = source.File(profile.GhxBinary)
+	}
+
+	if wrc.SecretsFile == nil && profile.SecretsFile != "" {
+		wrc.SecretsFile = source.File(profile.SecretsFile)
+	}
+
+	if wrc.Token == nil && wrc.AppID == "" && profile.TokenFile != "" {
+		token, err := source.File(profile.TokenFile).Contents(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read token file: %w", err)
+		}
+
+		token = strings.TrimSpace(token)
+
+		wrc.Token = dag.SetSecret(engineSecretName("gale-config-token", wrc.secretScope(), token), token)
+	}
+
+	for _, notify := range profile.Notifications {
+		if notify.URL == "" && notify.URLFile != "" {
+			url, err := source.File(notify.URLFile).Contents(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read notification url file: %w", err)
+			}
+
+			notify.URL = strings.TrimSpace(url)
+		}
+
+		wrc.notifications = append(wrc.notifications, notify)
+	}
+
+	wrc.env = profile.Env
+
+	return nil
+}
+
+// secretScope returns the scope of the engine secrets of the workflow run, the repository and the workflow.
+func (wrc *WorkflowRunConfig) secretScope() string {
+	return wrc.Repo + "/" + wrc.Workflow
+}
+
+// withConfig applies the environment variables and secrets of the configuration to the container.
+func (wrc *WorkflowRunConfig) withConfig(ctx context.Context, container *Container) (*Container, error) {
+	keys := make([]string, 0, len(wrc.env))
+	for k := range wrc.env {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		container = container.WithEnvVariable(k, wrc.env[k])
+	}
+
+	// host environment doesn't reach the runner, so the GALE_SECRET_ variables of the host are forwarded with a file
+	if wrc.SecretsEnv != nil {
+		container = container.WithMountedSecret(secretsEnvPath, wrc.SecretsEnv)
+		container = container.WithEnvVariable("GHX_SECRETS_ENV_FILE", secretsEnvPath)
+	}
+
+	var err error
+
+	// runner images don't have vault, so it's mounted for the secrets commands calling it
+	if slices.ContainsFunc(wrc.SecretsFrom, callsVault) {
+		container, err = withSecretsTool(ctx, container, "vault")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if wrc.SecretsFile == nil {
+		return container, nil
+	}
+
+	// secrets file is parsed by ghx, the name is kept to select the format of the file by its extension
+	name, err := wrc.SecretsFile.Name(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := wrc.SecretsFile.Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	path := filepath.Join(secretsFilesDir, name)
+
+	container = container.WithMountedSecret(path, dag.SetSecret(engineSecretName("gale-secrets-file", wrc.secretScope(), contents), contents))
+
+	// secrets file could be encrypted with SOPS, ghx decrypts it with the sops binary
+	container, err = withSecretsTool(ctx, container, "sops")
+	if err != nil {
+		return nil, err
+	}
+
+	return withSecretsFile(ctx, container, path)
+}
+
+// withSecretsFile adds the given file to the secrets files ghx loads the secrets from. Files are loaded in the order
+// they're added, so the secrets of the files added later override the previous ones.
+func withSecretsFile(ctx context.Context, container *Container, path string) (*Container, error) {
+	files, err := container.EnvVariable(ctx, "GHX_SECRETS_FILES")
+	if err != nil {
+		return nil, err
+	}
+
+	if files != "" {
+		path = files + "," + path
+	}
+
+	return container.WithEnvVariable("GHX_SECRETS_FILES", path), nil
+}
+
+// callsVault returns true if the secrets command calls the vault cli.
+func callsVault(command string) bool {
+	return slices.Contains(strings.Fields(command), "vault")
+}
+
+// withEnvOverrides passes the environment variables to inject to the workflow run to ghx. Variables of the env option
+// have precedence over the variables of the env file.
+func (wrc *WorkflowRunConfig) withEnvOverrides(ctx context.Context, container *Container) (*Container, error) {
+	overrides := make(map[string]string)
+
+	if wrc.EnvFile != nil {
+		contents, err := wrc.EnvFile.Contents(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read env file: %w", err)
+		}
+
+		overrides, err = parseDotEnv(contents)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse env file: %w", err)
+		}
+	}
+
+	for _, kv := range wrc.Env {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid env %s, expected format is KEY=VALUE", kv)
+		}
+
+		overrides[key] = value
+	}
+
+	if wrc.EnvPrecedence != "" {
+		container = container.WithEnvVariable("GHX_ENV_PRECEDENCE", wrc.EnvPrecedence)
+	}
+
+	if len(overrides) == 0 {
+		return container, nil
+	}
+
+	// values are passed one per line, so multiline values are not supported
+	lines := make([]string, 0, len(overrides))
+
+	for key, value := range overrides {
+		if strings.Contains(value, "\n") {
+			return nil, fmt.Errorf("multiline value of env %s is not supported", key)
+		}
+
+		lines = append(lines, fmt.Sprintf("%s=%s", key, value))
+	}
+
+	sort.Strings(lines)
+
+	return container.WithEnvVariable("GHX_ENV", strings.Join(lines, "\n")), nil
+}
+
+// parseDotEnv parses the KEY=VALUE lines of the dotenv file. Empty lines and comments are ignored, export prefixes and
+// quotes around the values are removed. Lines in any other format are rejected like ghx does for the secrets files.
+func parseDotEnv(contents string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	for i, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid line %d, expected format is KEY=VALUE", i+1)
+		}
+
+		value = strings.TrimSpace(value)
+
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		values[strings.TrimSpace(key)] = value
+	}
+
+	return values, nil
+}
+
+// labelMappings converts the label map to the label=value mappings in a stable order.
+func labelMappings(labels map[string]string) []string {
+	mappings := make([]string, 0, len(labels))
+
+	for label, value := range labels {
+		mappings = append(mappings, fmt.Sprintf("%s=%s", label, value))
+	}
+
+	sort.Strings(mappings)
+
+	return mappings
+}
+
+// mergeFlag returns the flag of the override if it's set, otherwise the base flag.
+func mergeFlag(base, override *bool) *bool {
+	if override != nil {
+		return override
+	}
+
+	return base
+}
+
+// applyFlag returns true if the flag of the run is enabled, otherwise the flag of the profile if it's set.
+func applyFlag(enabled bool, flag *bool) bool {
+	if enabled || flag == nil {
+		return enabled
+	}
+
+	return *flag
+}
+
+// mergeMap returns a new map with the values of both maps. Values of the override map have precedence.
+func mergeMap(base, override map[string]string) map[string]string {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]string, len(base)+len(override))
+
+	for k, v := range base {
+		merged[k] = v
+	}
+
+	for k, v := range override {
+		merged[k] = v
+	}
+
+	return merged
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// billingReport is the estimate of the billable minutes and the cost of a workflow run on GitHub hosted runners in
+// the workflow run report.
+type billingReport struct {
+	Jobs []struct {
+		Name       string  `json:"name"`
+		OS         string  `json:"os"`
+		Duration   string  `json:"duration"`
+		Minutes    int     `json:"minutes"`
+		Multiplier int     `json:"multiplier"`
+		Cost       float64 `json:"cost"`
+	} `json:"jobs"`
+	Minutes        int     `json:"minutes"`
+	Cost           float64 `json:"cost"`
+	RunsPerMonth   float64 `json:"runs_per_month"`
+	MonthlyMinutes float64 `json:"monthly_minutes"`
+	MonthlyCost    float64 `json:"monthly_cost"`
+}
+
+// Cost returns the estimate of the billable minutes and the cost of the workflow run with the given id from the run
+// history if the jobs were run on GitHub hosted runners. Scheduled workflows include the monthly estimate.
+func (g *Gale) Cost(ctx context.Context, runID string) (string, error) {
+	var report struct {
+		Name    string         `json:"name"`
+		Billing *billingReport `json:"billing"`
+	}
+
+	if err := runsHistory().File(runID+"/workflow_run.json").unmarshalContentsToJSON(ctx, &report); err != nil {
+		return "", fmt.Errorf("workflow run %s not found in the history: %w", runID, err)
+	}
+
+	if report.Billing == nil {
+		return "", fmt.Errorf("workflow run %s has no billing estimate, run the workflow again to record it", runID)
+	}
+
+	var (
+		sb      strings.Builder
+		billing = report.Billing
+	)
+
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "JOB\tOS\tDURATION\tMINUTES\tMULTIPLIER\tCOST")
+
+	for _, job := range billing.Jobs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%dx\t$%.3f\n", job.Name, job.OS, job.Duration, job.Minutes, job.Multiplier, job.Cost)
+	}
+
+	fmt.Fprintf(w, "Total\t\t\t%d\t\t$%.3f\n", billing.Minutes, billing.Cost)
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	if billing.RunsPerMonth > 0 {
+		fmt.Fprintf(&sb, "\nScheduled runs per month: %.1f\n", billing.RunsPerMonth)
+		fmt.Fprintf(&sb, "Billable minutes per month: %.0f\n", billing.MonthlyMinutes)
+		fmt.Fprintf(&sb, "Cost per month: $%.2f\n", billing.MonthlyCost)
+	}
+
+	sb.WriteString("\nEstimated with the public per minute rates of GitHub hosted runners, included minutes of the plans are not deducted.\n")
+
+	return sb.String(), nil
+}
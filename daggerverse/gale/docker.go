@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// dockerSocketPath is the path of the docker socket in the runner container when the host socket is used.
+	dockerSocketPath = "/var/run/docker.sock"
+
+	// dockerServicePort is the port of the nested docker engine service.
+	dockerServicePort = 2375
+)
+
+// withDocker binds a docker engine to the container and configures DOCKER_HOST accordingly. If the docker socket
+// option is provided, the host docker engine is used. Otherwise, a nested docker engine is started as a service.
+//
+// Nested docker engine doesn't share the filesystem with the runner, so bind mounts from the workspace are not
+// available to the containers started by the steps.
+func (wr *WorkflowRun) withDocker(ctx context.Context, container *Container, info *RepoInfo) (*Container, error) {
+	container = container.WithEnvVariable("GHX_DOCKER_ENABLED", "true")
+
+	if wr.Config.DockerSocket != nil {
+		return container.
+			WithUnixSocket(dockerSocketPath, wr.Config.DockerSocket).
+			WithEnvVariable("DOCKER_HOST", fmt.Sprintf("unix://%s", dockerSocketPath)), nil
+	}
+
+	namespace, err := wr.cacheNamespace(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := wr.imageRules()
+	if err != nil {
+		return nil, err
+	}
+
+	// docker engine data is persisted between runs to reuse pulled images and build cache
+	dockerd := dag.Container().From(rules.rewrite("docker:dind")).
+		WithMountedCache("/var/lib/docker", dag.CacheVolume(fmt.Sprintf("gale-docker-%s", namespace))).
+		WithExposedPort(dockerServicePort).
+		WithExec(
+			[]string{"dockerd", fmt.Sprintf("--host=tcp://0.0.0.0:%d", dockerServicePort), "--tls=false"},
+			ContainerWithExecOpts{InsecureRootCapabilities: true},
+		).
+		AsService()
+
+	return container.
+		WithServiceBinding("docker", dockerd).
+		WithEnvVariable("DOCKER_HOST", fmt.Sprintf("tcp://docker:%d", dockerServicePort)), nil
+}
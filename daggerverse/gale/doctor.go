@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// doctorEngineVersion is the minimum dagger engine version gale requires.
+	doctorEngineVersion = "v0.9.0"
+
+	// doctorMinFreeDisk is the free disk space in KiB below which the caches of the runs are likely to fill the disk.
+	doctorMinFreeDisk = 10 * 1024 * 1024
+)
+
+// Doctor diagnoses the environment gale runs the workflows in.
+type Doctor struct{}
+
+// DoctorCheckOpts represents the options for diagnosing the environment. Options are the same as the workflow run
+// options, so the checks match the environment of the runs.
+type DoctorCheckOpts struct {
+	RunnerImage     string   `doc:"The image to use for the runner." default:"ghcr.io/catthehacker/ubuntu:act-latest"`
+	GhxVersion      string   `doc:"The version of the published ghx image to use, e.g. v0.0.9. If empty, ghx is built from the source of the module."`
+	GhxBinary       *File    `doc:"The prebuilt ghx binary to use instead of building or pulling it."`
+	RegistryMirrors []string `doc:"Mirrors of the registries to pull the images from. Format: registry=mirror, e.g. docker.io=mirror.example.com."`
+	ImageOverrides  []string `doc:"Replacements of the images, applied before the registry mirrors. Format: image=replacement."`
+	DockerSocket    *Socket  `doc:"Docker socket of the host to check instead of the nested docker engine."`
+	Token           *Secret  `doc:"The GitHub token to validate."`
+}
+
+// doctorCheck is the result of a single diagnostic check.
+type doctorCheck struct {
+	Name   string // Name is the name of the check, e.g. engine
+	OK     bool   // OK indicates the check passed
+	Detail string // Detail is the information found by the check or the reason of the failure
+	Fix    string // Fix is the action to take if the check failed
+}
+
+// Check runs the diagnostics of the dagger engine, the disk space of the caches, docker, the registries, ghx and the
+// GitHub token, and returns the result of each check with the fixes of the failed ones, one check per line.
+func (d *Doctor) Check(ctx context.Context, opts DoctorCheckOpts) (string, error) {
+	image := opts.RunnerImage
+
+	// defaults are not applied when the method is called from the module itself
+	if image == "" {
+		image = defaultRunnerImage
+	}
+
+	rules, err := parseImageRules(opts.RegistryMirrors, opts.ImageOverrides)
+	if err != nil {
+		return "", err
+	}
+
+	checks := []doctorCheck{
+		checkEngine(ctx),
+		checkDisk(ctx, rules),
+		checkDocker(ctx, rules, opts.DockerSocket),
+		checkRegistry(ctx, rules, image),
+		checkGhx(ctx, rules, image, opts.GhxVersion, opts.GhxBinary),
+		checkToken(ctx, opts.Token),
+	}
+
+	return doctorReport(checks), nil
+}
+
+// doctorReport returns the result of each check with the fixes of the failed ones, one check per line, followed by the
+// number of the problems found.
+func doctorReport(checks []doctorCheck) string {
+	var (
+		sb     strings.Builder
+		failed int
+	)
+
+	for _, check := range checks {
+		status := "ok"
+
+		if !check.OK {
+			status = "fail"
+			failed++
+		}
+
+		sb.WriteString(fmt.Sprintf("[%s] %s: %s\n", status, check.Name, check.Detail))
+
+		if !check.OK && check.Fix != "" {
+			sb.WriteString(fmt.Sprintf("       fix: %s\n", check.Fix))
+		}
+	}
+
+	if failed == 0 {
+		sb.WriteString("\nNo problems found\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("\n%d problem(s) found\n", failed))
+	}
+
+	return sb.String()
+}
+
+// checkEngine checks the dagger engine is reachable and compatible with gale.
+func checkEngine(ctx context.Context) doctorCheck {
+	check := doctorCheck{Name: "engine"}
+
+	platform, err := dag.DefaultPlatform(ctx)
+	if err != nil {
+		check.Detail = fmt.Sprintf("failed to reach the engine: %v", err)
+		check.Fix = "make sure the dagger engine is running and _EXPERIMENTAL_DAGGER_RUNNER_HOST points to it if it's not the default one"
+
+		return check
+	}
+
+	compatible, err := dag.CheckVersionCompatibility(ctx, doctorEngineVersion)
+	if err != nil || !compatible {
+		check.Detail = fmt.Sprintf("engine is not compatible with %s", doctorEngineVersion)
+		check.Fix = fmt.Sprintf("upgrade the dagger engine and the dagger cli to %s or later", doctorEngineVersion)
+
+		return check
+	}
+
+	check.OK = true
+	check.Detail = fmt.Sprintf("reachable, compatible with %s, platform %s", doctorEngineVersion, platform)
+
+	return check
+}
+
+// checkDisk checks the free disk space of the cache volumes keeping the actions, the tool caches and the run history.
+func checkDisk(ctx context.Context, rules *imageRules) doctorCheck {
+	check := doctorCheck{Name: "disk"}
+
+	out, err := dag.Container().From(rules.rewrite("alpine:latest")).
+		WithMountedCache("/runs", dag.CacheVolume("gale-runs"), ContainerWithMountedCacheOpts{Sharing: Shared}).
+		// free space changes between calls, so the check shouldn't be cached
+		WithEnvVariable("CACHE_BUSTER", time.Now().Format(time.RFC3339Nano)).
+		WithExec([]string{"sh", "-c", "df -Pk /runs | tail -n 1 | awk '{print $4}'"}).
+		Stdout(ctx)
+	if err != nil {
+		check.Detail = fmt.Sprintf("failed to check free disk space: %v", err)
+		check.Fix = "make sure the engine can pull alpine:latest or configure a registry mirror"
+
+		return check
+	}
+
+	return freeDiskCheck(out)
+}
+
+// freeDiskCheck returns the disk check for the free disk space in KiB reported by df.
+func freeDiskCheck(out string) doctorCheck {
+	check := doctorCheck{Name: "disk"}
+
+	free, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		check.Detail = fmt.Sprintf("unexpected df output %q", strings.TrimSpace(out))
+
+		return check
+	}
+
+	check.Detail = fmt.Sprintf("%.1f GiB free for the caches and the run history", float64(free)/(1024*1024))
+
+	if free < doctorMinFreeDisk {
+		check.Fix = "free disk space of the engine, e.g. with dagger query prune or by removing the unused cache volumes"
+
+		return check
+	}
+
+	check.OK = true
+
+	return check
+}
+
+// checkDocker checks the docker engine of the steps using docker directly. The host engine is checked if the socket is
+// provided, otherwise the image of the nested engine is checked.
+func checkDocker(ctx context.Context, rules *imageRules, socket *Socket) doctorCheck {
+	check := doctorCheck{Name: "docker"}
+
+	if socket == nil {
+		if _, err := dag.Container().From(rules.rewrite("docker:dind")).Sync(ctx); err != nil {
+			check.Detail = fmt.Sprintf("failed to pull the nested docker engine image: %v", err)
+			check.Fix = "configure a registry mirror for docker.io or provide the docker socket of the host"
+
+			return check
+		}
+
+		check.OK = true
+		check.Detail = "nested docker engine available, requires insecure root capabilities in the engine"
+
+		return check
+	}
+
+	out, err := dag.Container().From(rules.rewrite("docker:cli")).
+		WithUnixSocket(dockerSocketPath, socket).
+		WithEnvVariable("DOCKER_HOST", fmt.Sprintf("unix://%s", dockerSocketPath)).
+		// docker engine could be stopped between calls, so the check shouldn't be cached
+		WithEnvVariable("CACHE_BUSTER", time.Now().Format(time.RFC3339Nano)).
+		WithExec([]string{"docker", "version", "--format", "{{.Server.Version}}"}).
+		Stdout(ctx)
+	if err != nil {
+		check.Detail = fmt.Sprintf("failed to reach the docker engine of the host: %v", err)
+		check.Fix = "make sure docker is running and the socket is readable by the dagger engine, e.g. --docker-socket /var/run/docker.sock"
+
+		return check
+	}
+
+	check.OK = true
+	check.Detail = fmt.Sprintf("host docker engine %s", strings.TrimSpace(out))
+
+	return check
+}
+
+// checkRegistry checks the runner image could be pulled with the image rules.
+func checkRegistry(ctx context.Context, rules *imageRules, image string) doctorCheck {
+	check := doctorCheck{Name: "registry"}
+
+	ref := rules.rewrite(image)
+
+	if _, err := dag.Container().From(ref).Sync(ctx); err != nil {
+		check.Detail = fmt.Sprintf("failed to pull %s: %v", ref, err)
+		check.Fix = "check the network access of the engine to the registry, or configure registry mirrors or image overrides"
+
+		return check
+	}
+
+	check.OK = true
+	check.Detail = fmt.Sprintf("pulled %s", ref)
+
+	return check
+}
+
+// checkGhx checks ghx could be built or pulled and reports its version.
+func checkGhx(ctx context.Context, rules *imageRules, image, version string, binary *File) doctorCheck {
+	check := doctorCheck{Name: "ghx"}
+
+	container, err := withGhx(ctx, dag.Container().From(rules.rewrite(image)), version, binary)
+	if err == nil {
+		version, err = ghxVersion(ctx, container)
+	}
+
+	if err != nil {
+		check.Detail = fmt.Sprintf("ghx is not available: %v", err)
+		check.Fix = "check the access to ghcr.io for the published ghx images, or provide a prebuilt ghx binary built with the ghx build function of the source module"
+
+		return check
+	}
+
+	check.OK = true
+	check.Detail = fmt.Sprintf("version %s", version)
+
+	return check
+}
+
+// checkToken checks the GitHub token is accepted by the GitHub API.
+func checkToken(ctx context.Context, token *Secret) doctorCheck {
+	check := doctorCheck{Name: "token"}
+
+	if token == nil {
+		check.OK = true
+		check.Detail = "no token provided, private repositories and actions calling the GitHub API are not available"
+
+		return check
+	}
+
+	plaintext, err := token.Plaintext(ctx)
+	if err != nil {
+		check.Detail = fmt.Sprintf("failed to read the token: %v", err)
+
+		return check
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.github.com/rate_limit", nil)
+	if err != nil {
+		check.Detail = err.Error()
+
+		return check
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", strings.TrimSpace(plaintext)))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		check.Detail = fmt.Sprintf("failed to reach the GitHub API: %v", err)
+		check.Fix = "check the network access to api.github.com"
+
+		return check
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		check.Detail = fmt.Sprintf("token is rejected by the GitHub API with %s", resp.Status)
+		check.Fix = "create a new token, e.g. with gh auth token, and make sure it's not expired"
+
+		return check
+	}
+
+	check.OK = true
+	check.Detail = "valid"
+
+	if scopes := resp.Header.Get("X-OAuth-Scopes"); scopes != "" {
+		check.Detail = fmt.Sprintf("valid, scopes: %s", scopes)
+	}
+
+	return check
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// nullSHA is the commit SHA used in the event payloads when the commit doesn't exist, e.g. before SHA of a new branch.
+const nullSHA = "0000000000000000000000000000000000000000"
+
+// headCommit represents the commit details of the repository head used in the synthesized event payloads.
+type headCommit struct {
+	ID             string
+	Parent         string
+	AuthorName     string
+	AuthorEmail    string
+	CommitterName  string
+	CommitterEmail string
+	Timestamp      string
+	Message        string
+}
+
+// event synthesizes a default webhook event payload for the event of the workflow run from the repository
+// information. It's used when no event file is provided, so expressions like github.event.head_commit.message resolve
+// to realistic values instead of being empty.
+func (wr *WorkflowRun) event(ctx context.Context, info *RepoInfo, source *Directory) (string, error) {
+	nameWithOwner, err := info.NameWithOwner(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	owner, err := info.Owner(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	name, err := info.Name(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	url, err := info.URL(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	htmlURL, err := info.HTMLURL(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	ref, err := info.Ref(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	refName, err := info.RefName(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	refType, err := info.RefType(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	commit, err := getHeadCommit(ctx, source)
+	if err != nil {
+		return "", err
+	}
+
+	defaultBranch, defaultSHA, err := getDefaultBranch(ctx, source)
+	if err != nil {
+		return "", err
+	}
+
+	// without the default branch in the repository, the checked out branch is the best guess
+	if defaultBranch == "" && refType == "branch" {
+		defaultBranch, defaultSHA = refName, commit.Parent
+	}
+
+	var (
+		sender     = map[string]interface{}{"login": owner, "type": "User"}
+		repository = map[string]interface{}{
+			"name":      name,
+			"full_name": nameWithOwner,
+			"owner":     map[string]interface{}{"login": owner},
+			"html_url":  htmlURL,
+			"clone_url": url,
+			"private":   false,
+		}
+		author    = map[string]interface{}{"name": commit.AuthorName, "email": commit.AuthorEmail}
+		committer = map[string]interface{}{"name": commit.CommitterName, "email": commit.CommitterEmail}
+		head      = map[string]interface{}{
+			"id":        commit.ID,
+			"tree_id":   "",
+			"message":   commit.Message,
+			"timestamp": commit.Timestamp,
+			"url":       fmt.Sprintf("%s/commit/%s", htmlURL, commit.ID),
+			"author":    author,
+			"committer": committer,
+		}
+	)
+
+	if defaultBranch != "" {
+		repository["default_branch"] = defaultBranch
+	}
+
+	payload := map[string]interface{}{"repository": repository, "sender": sender}
+
+	switch wr.Config.Event {
+	case "push":
+		payload["ref"] = ref
+		payload["before"] = commit.Parent
+		payload["after"] = commit.ID
+		payload["created"] = false
+		payload["deleted"] = false
+		payload["forced"] = false
+		payload["base_ref"] = nil
+		payload["compare"] = fmt.Sprintf("%s/compare/%s...%s", htmlURL, commit.Parent, commit.ID)
+		payload["pusher"] = author
+		payload["head_commit"] = head
+		payload["commits"] = []interface{}{head}
+	case "pull_request", "pull_request_target":
+		title, _, _ := strings.Cut(commit.Message, "\n")
+
+		// synthesized pull requests merge the checked out ref into the default branch
+		base := map[string]interface{}{"ref": defaultBranch, "sha": defaultSHA, "repo": repository}
+		if defaultBranch == "" {
+			base["ref"], base["sha"] = refName, commit.Parent
+		}
+
+		payload["action"] = "opened"
+		payload["number"] = synthesizedPullRequestNumber
+		payload["pull_request"] = map[string]interface{}{
+			"number":           synthesizedPullRequestNumber,
+			"title":            title,
+			"body":             "",
+			"state":            "open",
+			"draft":            false,
+			"merged":           false,
+			"merge_commit_sha": commit.ID,
+			"user":             sender,
+			"head":             map[string]interface{}{"ref": refName, "sha": commit.ID, "repo": repository},
+			"base":             base,
+		}
+	case "release":
+		tag := refName
+		if refType != "tag" {
+			tag = "v0.0.0"
+		}
+
+		payload["action"] = "published"
+		payload["release"] = map[string]interface{}{
+			"tag_name":         tag,
+			"name":             tag,
+			"target_commitish": commit.ID,
+			"body":             commit.Message,
+			"draft":            false,
+			"prerelease":       false,
+			"created_at":       commit.Timestamp,
+			"published_at":     commit.Timestamp,
+			"author":           sender,
+		}
+	case "schedule":
+		// scheduled runs doesn't have a sender since they aren't triggered by a user
+		delete(payload, "sender")
+	case "workflow_dispatch":
+		payload["ref"] = ref
+		payload["inputs"] = map[string]interface{}{}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// getHeadCommit returns the details of the head commit of the repository source.
+func getHeadCommit(ctx context.Context, source *Directory) (*headCommit, error) {
+	out, err := dag.Container().From("alpine/git:latest").
+		WithMountedDirectory("/src", source).
+		WithWorkdir("/src").
+		WithExec([]string{"log", "-1", "--format=%H%n%P%n%an%n%ae%n%cn%n%ce%n%cI%n%B"}).
+		Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get head commit: %w", err)
+	}
+
+	return parseHeadCommit(out)
+}
+
+// parseHeadCommit parses the head commit from the git log output in the %H%n%P%n%an%n%ae%n%cn%n%ce%n%cI%n%B format.
+func parseHeadCommit(out string) (*headCommit, error) {
+	lines := strings.SplitN(out, "\n", 8)
+	if len(lines) != 8 {
+		return nil, fmt.Errorf("unexpected output while getting head commit: %s", out)
+	}
+
+	commit := &headCommit{
+		ID:             lines[0],
+		Parent:         nullSHA,
+		AuthorName:     lines[2],
+		AuthorEmail:    lines[3],
+		CommitterName:  lines[4],
+		CommitterEmail: lines[5],
+		Timestamp:      lines[6],
+		Message:        strings.TrimSpace(lines[7]),
+	}
+
+	// merge commits have multiple parents, the first one is the previous commit of the branch. Parent could be
+	// missing for the root commit or in shallow clones.
+	if parents := strings.Fields(lines[1]); len(parents) > 0 {
+		commit.Parent = parents[0]
+	}
+
+	return commit, nil
+}
+
+// getDefaultBranch returns the default branch of the repository source with its commit SHA. The branch is empty if it
+// can't be derived from the refs of the repository.
+func getDefaultBranch(ctx context.Context, source *Directory) (string, string, error) {
+	out, err := dag.Container().From("alpine/git:latest").
+		WithMountedDirectory("/src", source).
+		WithWorkdir("/src").
+		WithExec([]string{"for-each-ref", "--format=%(refname) %(objectname) %(symref)", "refs/heads", "refs/remotes/origin"}).
+		Stdout(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list repository refs: %w", err)
+	}
+
+	branch, sha := defaultBranch(out)
+
+	return branch, sha, nil
+}
+
+// defaultBranch returns the default branch and its commit SHA from the given refs in "<ref> <sha> <symref>" format.
+// The branch origin/HEAD points to is the default branch of the remote repository. Otherwise, main or master is used
+// if the repository has one of them. Commits of the remote branches have precedence over the local ones, since the
+// pull requests are based on the remote branches.
+func defaultBranch(refs string) (string, string) {
+	var (
+		shas   = make(map[string]string)
+		remote string
+	)
+
+	for _, line := range strings.Split(refs, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		if fields[0] == "refs/remotes/origin/HEAD" {
+			if len(fields) == 3 {
+				remote = strings.TrimPrefix(fields[2], "refs/remotes/origin/")
+			}
+
+			continue
+		}
+
+		shas[fields[0]] = fields[1]
+	}
+
+	candidates := []string{"main", "master"}
+	if remote != "" {
+		candidates = []string{remote}
+	}
+
+	for _, branch := range candidates {
+		for _, ref := range []string{"refs/remotes/origin/" + branch, "refs/heads/" + branch} {
+			if sha, ok := shas[ref]; ok {
+				return branch, sha
+			}
+		}
+	}
+
+	return "", ""
+}
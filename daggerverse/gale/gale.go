@@ -6,3 +6,45 @@ type Gale struct{}
 func (g *Gale) Workflows() *Workflows {
 	return new(Workflows)
 }
+
+// Workflow returns the workflow with the given name or path from the repository.
+func (g *Gale) Workflow(workflow string, repoOpts WorkflowsRepoOpts, pathOpts WorkflowsDirOpts) *Workflow {
+	// defaults are not applied when the options are not provided by the caller
+	if pathOpts.WorkflowsDir == "" {
+		pathOpts.WorkflowsDir = ".github/workflows"
+	}
+
+	return &Workflow{RepoOpts: &repoOpts, DirOpts: &pathOpts, Name: workflow}
+}
+
+func (g *Gale) Actions() *Actions {
+	return new(Actions)
+}
+
+func (g *Gale) Runner() *Runner {
+	return new(Runner)
+}
+
+func (g *Gale) Artifacts() *Artifacts {
+	return new(Artifacts)
+}
+
+func (g *Gale) Runs() *Runs {
+	return new(Runs)
+}
+
+func (g *Gale) Secrets() *Secrets {
+	return new(Secrets)
+}
+
+func (g *Gale) Version() *Version {
+	return new(Version)
+}
+
+func (g *Gale) Doctor() *Doctor {
+	return new(Doctor)
+}
+
+func (g *Gale) Migrate() *Migrate {
+	return new(Migrate)
+}
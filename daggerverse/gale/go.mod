@@ -3,6 +3,7 @@ module gale
 go 1.21
 
 require (
+	filippo.io/age v1.0.0
 	github.com/99designs/gqlgen v0.17.39
 	github.com/Khan/genqlient v0.6.0
 	golang.org/x/exp v0.0.0-20231006140011-7918f672742d
@@ -12,6 +13,8 @@ require (
 require (
 	github.com/google/uuid v1.3.1 // indirect
 	github.com/sosodev/duration v1.2.0 // indirect
+	golang.org/x/crypto v0.14.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
 )
 
 require (
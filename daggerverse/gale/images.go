@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// imageRules rewrites the image references with the image overrides and the registry mirrors, same as ghx does for the
+// step images.
+type imageRules struct {
+	mirrors   map[string]string // mirrors is the map of the registries to their mirrors
+	overrides map[string]string // overrides is the map of the image references to their replacements
+}
+
+// parseImageRules parses the registry mirrors and the image overrides in from=to format.
+func parseImageRules(mirrors, overrides []string) (*imageRules, error) {
+	rules := &imageRules{mirrors: make(map[string]string), overrides: make(map[string]string)}
+
+	for _, mapping := range []struct {
+		values []string
+		target map[string]string
+	}{{mirrors, rules.mirrors}, {overrides, rules.overrides}} {
+		for _, value := range mapping.values {
+			from, to, ok := strings.Cut(value, "=")
+			if !ok || from == "" || to == "" {
+				return nil, fmt.Errorf("invalid image mapping %s, expected format is from=to", value)
+			}
+
+			mapping.target[from] = to
+		}
+	}
+
+	return rules, nil
+}
+
+// containerImageRules returns the image rules configured to the given container. Containers without the rules return
+// empty rules, so images are used as they are.
+func containerImageRules(ctx context.Context, c *Container) (*imageRules, error) {
+	var values [2][]string
+
+	for idx, name := range []string{"GHX_REGISTRY_MIRRORS", "GHX_IMAGE_OVERRIDES"} {
+		value, err := c.EnvVariable(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range strings.Split(value, ";") {
+			if entry != "" {
+				values[idx] = append(values[idx], entry)
+			}
+		}
+	}
+
+	return parseImageRules(values[0], values[1])
+}
+
+// configure sets the rules to the container, so ghx applies them to the step images and the tool images of the runner
+// are rewritten with the same rules.
+func (r *imageRules) configure(c *Container) *Container {
+	if len(r.mirrors) > 0 {
+		c = c.WithEnvVariable("GHX_REGISTRY_MIRRORS", strings.Join(labelMappings(r.mirrors), ";"))
+	}
+
+	if len(r.overrides) > 0 {
+		c = c.WithEnvVariable("GHX_IMAGE_OVERRIDES", strings.Join(labelMappings(r.overrides), ";"))
+	}
+
+	return c
+}
+
+// rewrite returns the image reference to pull the given image from. Overrides have precedence over the mirrors, and
+// images without a registry are considered as Docker Hub images.
+func (r *imageRules) rewrite(image string) string {
+	if replacement, ok := r.overrides[image]; ok {
+		return replacement
+	}
+
+	registry, name, ok := strings.Cut(image, "/")
+
+	// first part of the reference is the registry only if it looks like a host, otherwise it's a Docker Hub image
+	if !ok || (!strings.ContainsAny(registry, ".:") && registry != "localhost") {
+		registry, name = "docker.io", image
+	}
+
+	if registry == "docker.io" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+
+	if mirror, ok := r.mirrors[registry]; ok {
+		return fmt.Sprintf("%s/%s", strings.TrimSuffix(mirror, "/"), name)
+	}
+
+	return image
+}
+
+// imageRules returns the image rules of the workflow run.
+func (wr *WorkflowRun) imageRules() (*imageRules, error) {
+	return parseImageRules(wr.Config.RegistryMirrors, wr.Config.ImageOverrides)
+}
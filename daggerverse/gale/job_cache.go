@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// validateCacheJobs returns an error if the run depends on the state of the host or of the previous runs, since the
+// engine can't know when such a run needs to run again. Sockets of the host and the preserved workspaces are not part
+// of the cache key.
+func (wrc *WorkflowRunConfig) validateCacheJobs() error {
+	var hidden []string
+
+	if wrc.DockerSocket != nil {
+		hidden = append(hidden, "docker socket")
+	}
+
+	if wrc.EventsSocket != nil {
+		hidden = append(hidden, "events socket")
+	}
+
+	if wrc.JournalSocket != nil {
+		hidden = append(hidden, "journal socket")
+	}
+
+	if len(wrc.PreserveWorkspaces) > 0 {
+		hidden = append(hidden, "preserve workspaces")
+	}
+
+	if len(hidden) > 0 {
+		return fmt.Errorf("cache jobs can't be used with the options depending on the host state: %s", strings.Join(hidden, ", "))
+	}
+
+	return nil
+}
+
+// withCacheKey makes the workflow run a deterministic operation, so the engine returns the result of a previous run
+// with the same inputs instead of running it again. The repository source, the workflow and the options are already
+// part of the cache key. Resolved commit SHAs of the actions and the secrets are not visible to the engine, so their
+// fingerprint is computed by ghx in an uncached exec and added to the run as the cache key.
+func (wr *WorkflowRun) withCacheKey(ctx context.Context, container *Container) (*Container, error) {
+	if err := wr.Config.validateCacheJobs(); err != nil {
+		return nil, err
+	}
+
+	out, err := container.
+		WithEnvVariable("CACHE_BUSTER", time.Now().Format(time.RFC3339Nano)).
+		WithExec([]string{"ghx", "fingerprint"}, wr.execOpts()).
+		Stdout(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// fingerprint is the last line of the output, ghx could log before printing it
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+
+	key := strings.TrimSpace(lines[len(lines)-1])
+	if key == "" {
+		return nil, errors.New("failed to fingerprint the workflow run, ghx printed an empty fingerprint")
+	}
+
+	return container.WithEnvVariable("GALE_CACHE_KEY", key), nil
+}
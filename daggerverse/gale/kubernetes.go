@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// k3sImage is the image used to start the kubernetes cluster.
+	k3sImage = "rancher/k3s:latest"
+
+	// k3sPort is the port of the kubernetes api server.
+	k3sPort = 6443
+
+	// k3sConfigDir is the directory the k3s service writes the kubeconfig to and the runner reads it from.
+	k3sConfigDir = "/home/runner/_temp/gale/k3s"
+
+	// kubeconfigPath is the path of the kubeconfig in the runner container.
+	kubeconfigPath = "/home/runner/_temp/gale/kubeconfig"
+)
+
+// withKubernetes starts a k3s cluster as a service, binds it to the container and exposes the kubeconfig with the
+// KUBECONFIG environment variable. Cluster state is persisted between runs in the cache namespace.
+func (wr *WorkflowRun) withKubernetes(ctx context.Context, container *Container, info *RepoInfo) (*Container, error) {
+	namespace, err := wr.cacheNamespace(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
+	// kubeconfig is shared between the service and the runner with a cache volume since the service filesystem is not
+	// accessible from the runner.
+	config := dag.CacheVolume(fmt.Sprintf("gale-k3s-config-%s", namespace))
+
+	rules, err := wr.imageRules()
+	if err != nil {
+		return nil, err
+	}
+
+	k3s := dag.Container().From(rules.rewrite(k3sImage)).
+		WithMountedCache("/etc/rancher/k3s", config).
+		WithMountedCache("/var/lib/rancher/k3s", dag.CacheVolume(fmt.Sprintf("gale-k3s-data-%s", namespace))).
+		WithMountedTemp("/etc/lib/cni").
+		WithMountedTemp("/var/lib/kubelet").
+		WithMountedTemp("/var/log").
+		WithEntrypoint([]string{"sh", "-c"}).
+		WithExposedPort(k3sPort).
+		WithExec(
+			[]string{fmt.Sprintf("k3s server --bind-address $(ip route | grep src | awk '{print $NF}') --tls-san k3s --https-listen-port %d --disable traefik --disable metrics-server --egress-selector-mode=disabled", k3sPort)},
+			ContainerWithExecOpts{InsecureRootCapabilities: true},
+		).
+		AsService()
+
+	return container.
+		WithServiceBinding("k3s", k3s).
+		WithMountedCache(k3sConfigDir, config).
+		WithExec([]string{"sh", "-c", kubeconfigScript(k3sConfigDir, k3sPort, kubeconfigPath)}).
+		WithEnvVariable("KUBECONFIG", kubeconfigPath), nil
+}
+
+// kubeconfigScript returns the script waiting for the kubeconfig written by the k3s service to the config directory and
+// copying it to the given path, pointing it to the service hostname instead of the loopback address.
+func kubeconfigScript(configDir string, port int, path string) string {
+	return fmt.Sprintf(
+		`for i in $(seq 1 120); do [ -f %[1]s/k3s.yaml ] && break; sleep 1; done; [ -f %[1]s/k3s.yaml ] || { echo "k3s kubeconfig not found" >&2; exit 1; }; sed 's#https://127.0.0.1:%[2]d#https://k3s:%[2]d#' %[1]s/k3s.yaml > %[3]s`,
+		configDir, port, path,
+	)
+}
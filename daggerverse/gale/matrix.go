@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// matrixExprRegex matches the matrix value expressions, e.g. ${{ matrix.os }}.
+var matrixExprRegex = regexp.MustCompile(`\$\{\{\s*matrix\.([A-Za-z0-9_-]+)\s*}}`)
+
+// matrixCombinations returns the combinations of the job matrix with the include and exclude rules applied, like ghx
+// does when planning the job runs.
+func matrixCombinations(matrix map[string]interface{}) []map[string]interface{} {
+	var keys []string
+
+	for key, value := range matrix {
+		if _, ok := value.([]interface{}); ok && key != "include" && key != "exclude" {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	var combinations []map[string]interface{}
+
+	if len(keys) > 0 {
+		combinations = []map[string]interface{}{{}}
+	}
+
+	for _, key := range keys {
+		var expanded []map[string]interface{}
+
+		for _, combination := range combinations {
+			for _, value := range matrix[key].([]interface{}) {
+				next := map[string]interface{}{key: value}
+
+				for k, v := range combination {
+					next[k] = v
+				}
+
+				expanded = append(expanded, next)
+			}
+		}
+
+		combinations = expanded
+	}
+
+	var filtered []map[string]interface{}
+
+	for _, combination := range combinations {
+		excluded := false
+
+		for _, exclude := range matrixRules(matrix["exclude"]) {
+			if matrixValuesMatch(exclude, combination, nil) {
+				excluded = true
+				break
+			}
+		}
+
+		if !excluded {
+			filtered = append(filtered, combination)
+		}
+	}
+
+	// include values are added to the combinations they don't overwrite the original values of, otherwise the include
+	// is added as a new combination
+	original := len(filtered)
+
+	for _, include := range matrixRules(matrix["include"]) {
+		added := false
+
+		for _, combination := range filtered[:original] {
+			if matrixValuesMatch(include, combination, keys) {
+				for k, v := range include {
+					combination[k] = v
+				}
+
+				added = true
+			}
+		}
+
+		if !added {
+			filtered = append(filtered, include)
+		}
+	}
+
+	return filtered
+}
+
+// matrixRules returns the include or exclude rules of the matrix.
+func matrixRules(value interface{}) []map[string]interface{} {
+	var rules []map[string]interface{}
+
+	items, _ := value.([]interface{})
+
+	for _, item := range items {
+		if rule, ok := item.(map[string]interface{}); ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+// matrixValuesMatch returns true if the values of the rule match the combination. If keys are given, only the given
+// keys of the rule are compared, otherwise all keys of the rule must exist in the combination with the same value.
+func matrixValuesMatch(rule, combination map[string]interface{}, keys []string) bool {
+	for k, v := range rule {
+		if keys != nil && !slices.Contains(keys, k) {
+			continue
+		}
+
+		if cv, ok := combination[k]; !ok || fmt.Sprintf("%v", cv) != fmt.Sprintf("%v", v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterMatrixCombinations returns the combinations matching the matrix filters in key=value format. Filter keys
+// missing in all combinations are ignored, same as ghx does.
+func filterMatrixCombinations(combinations []map[string]interface{}, filters []string) ([]map[string]interface{}, error) {
+	values := make(map[string][]string)
+
+	for _, filter := range filters {
+		key, value, ok := strings.Cut(filter, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid matrix filter %s, expected format is key=value", filter)
+		}
+
+		for _, combination := range combinations {
+			if _, exist := combination[key]; exist {
+				values[key] = append(values[key], value)
+				break
+			}
+		}
+	}
+
+	var filtered []map[string]interface{}
+
+	for _, combination := range combinations {
+		match := true
+
+		for key, accepted := range values {
+			if !slices.Contains(accepted, fmt.Sprintf("%v", combination[key])) {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			filtered = append(filtered, combination)
+		}
+	}
+
+	return filtered, nil
+}
+
+// evalMatrix replaces the matrix value expressions in the value with the values of the combination. Expressions of the
+// keys missing in the combination are kept as they are.
+func evalMatrix(value string, combination map[string]interface{}) string {
+	return matrixExprRegex.ReplaceAllStringFunc(value, func(expr string) string {
+		key := matrixExprRegex.FindStringSubmatch(expr)[1]
+
+		if v, ok := combination[key]; ok {
+			return fmt.Sprintf("%v", v)
+		}
+
+		return expr
+	})
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// actrcFileName is the name of the act configuration file in the repository root.
+	actrcFileName = ".actrc"
+
+	// actSecretsFileName is the secrets file act loads by default.
+	actSecretsFileName = ".secrets"
+
+	// actEnvFileName is the env file act loads by default.
+	actEnvFileName = ".env"
+
+	// actReportFileName is the name of the compatibility report of the act flags in the migration directory.
+	actReportFileName = "act-compatibility.txt"
+)
+
+// actUnsupportedFlags are the act flags without a gale.yaml equivalent and the reason, or the gale option to use instead.
+var actUnsupportedFlags = map[string]string{
+	"--secret":               "secrets can't be kept in gale.yaml, add them to the secrets file or use the secrets store",
+	"--var":                  "variables can't be kept in gale.yaml, set them with the vars of the event or the env",
+	"--var-file":             "variables can't be kept in gale.yaml, set them with the vars of the event or the env",
+	"--artifact-server-path": "artifacts are kept in the artifact service cache volume, export them with the artifacts function of the run",
+	"--cache-server-path":    "caches are kept in the artifact cache service volume",
+	"--workflows":            "use the workflows dir option of the run",
+	"--eventpath":            "use the event file option of the run",
+	"--actor":                "actor is the owner of the GitHub token",
+	"--network":              "steps run in the network of the runner, use the network option to restrict it",
+	"--bind":                 "repository is mounted to the workspace, changes are exported with the workspace function of the run",
+	"--reuse":                "use the preserve workspaces option of the run to keep the workspaces between the runs",
+	"--rebuild":              "action images are built in each run and cached by dagger",
+	"--privileged":           "steps run with the capabilities of the runner container",
+	"--userns":               "steps run with the user of the runner container",
+	"--container-options":    "container options are not supported",
+	"--container-cap-add":    "container capabilities are not supported",
+	"--container-cap-drop":   "container capabilities are not supported",
+	"--use-gitignore":        "use the exclude ignored option of the run",
+	"--defaultbranch":        "default branch is read from the repository",
+	"--github-instance":      "only github.com is supported",
+	"--remote-name":          "remote is read from the repository",
+	"--no-recurse":           "workflows directory is not searched recursively",
+}
+
+// actFlagAliases maps the short act flags to their long names.
+var actFlagAliases = map[string]string{
+	"-P": "--platform",
+	"-s": "--secret",
+	"-W": "--workflows",
+	"-e": "--eventpath",
+	"-a": "--actor",
+	"-b": "--bind",
+	"-r": "--reuse",
+}
+
+// Migrate converts the configurations of the other local runners to the gale configuration.
+type Migrate struct{}
+
+// MigrateFromActOpts represents the options for converting the act configuration.
+type MigrateFromActOpts struct {
+	Actrc *File `doc:"The act configuration file to convert, e.g. ~/.actrc. Defaults to .actrc in the repository root."`
+}
+
+// FromAct converts the act configuration, the .actrc flags, the platform mappings, the secrets and env files, to an
+// equivalent gale.yaml. The returned directory has the gale.yaml and the act-compatibility.txt report of the act flags
+// without a gale equivalent.
+func (m *Migrate) FromAct(ctx context.Context, repoOpts WorkflowsRepoOpts, opts MigrateFromActOpts) (*Directory, error) {
+	source := dag.Repo().Source((RepoSourceOpts)(repoOpts))
+
+	entries, err := source.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	file := opts.Actrc
+
+	if file == nil {
+		if !slices.Contains(entries, actrcFileName) {
+			return nil, fmt.Errorf("no %s found in the repository, provide the act configuration file with the actrc option", actrcFileName)
+		}
+
+		file = source.File(actrcFileName)
+	}
+
+	actrc, err := file.Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read act configuration: %w", err)
+	}
+
+	readFile := func(path string) (string, error) {
+		return source.File(path).Contents(ctx)
+	}
+
+	config, report, err := convertActrc(actrc, entries, readFile)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	header := fmt.Sprintf("# generated from %s by gale migrate from-act, see %s for the act flags without a gale equivalent\n", actrcFileName, actReportFileName)
+
+	if len(report) == 0 {
+		report = []string{"all act flags are converted"}
+	}
+
+	return dag.Directory().
+		WithNewFile(configFileName, header+string(data)).
+		WithNewFile(actReportFileName, strings.Join(report, "\n")+"\n"), nil
+}
+
+// convertActrc converts the act flags to the gale.yaml options and returns the compatibility report of the flags without
+// a gale equivalent. The secrets and env files act loads by default are used if they exist in the repository entries
+// and the flags don't set other files. Env files are inlined to the env of the configuration.
+func convertActrc(actrc string, entries []string, readFile func(path string) (string, error)) (map[string]interface{}, []string, error) {
+	var (
+		config      = make(map[string]interface{})
+		labels      = make(map[string]string)
+		env         = make(map[string]string)
+		report      []string
+		secretsFile string
+		envFiles    []string
+	)
+
+	for _, line := range strings.Split(actrc, "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		flag, value := parseActFlag(line)
+
+		switch flag {
+		case "--platform":
+			label, image, ok := strings.Cut(value, "=")
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid platform mapping %s, expected format is label=image", value)
+			}
+
+			if image == "-self-hosted" {
+				report = append(report, fmt.Sprintf("%s: %s runs on the host in act, gale can't run jobs on the host machine, map the label to a runner image", line, label))
+				continue
+			}
+
+			labels[label] = image
+		case "--secret-file":
+			secretsFile = value
+		case "--env-file":
+			envFiles = append(envFiles, value)
+		case "--env":
+			key, val, _ := strings.Cut(value, "=")
+			env[key] = val
+		case "--container-architecture":
+			config["platform"] = value
+		case "--pull":
+			if value == "false" {
+				config["pull-policy"] = "if-not-present"
+			}
+		case "--action-offline-mode":
+			if value != "false" {
+				config["offline"] = true
+			}
+		default:
+			reason, ok := actUnsupportedFlags[flag]
+			if !ok {
+				reason = "no gale equivalent, ignored"
+			}
+
+			report = append(report, fmt.Sprintf("%s: %s", line, reason))
+		}
+	}
+
+	if secretsFile == "" && slices.Contains(entries, actSecretsFileName) {
+		secretsFile = actSecretsFileName
+	}
+
+	if len(envFiles) == 0 && slices.Contains(entries, actEnvFileName) {
+		envFiles = []string{actEnvFileName}
+	}
+
+	for _, path := range envFiles {
+		contents, err := readFile(path)
+		if err != nil {
+			report = append(report, fmt.Sprintf("--env-file %s: failed to read the env file, only the files in the repository are converted", path))
+			continue
+		}
+
+		vars, err := parseDotEnv(contents)
+		if err != nil {
+			report = append(report, fmt.Sprintf("--env-file %s: %s, the env file is not converted", path, err))
+			continue
+		}
+
+		// flags have precedence over the env files like act
+		for key, val := range vars {
+			if _, ok := env[key]; !ok {
+				env[key] = val
+			}
+		}
+	}
+
+	if secretsFile != "" {
+		config["secrets-file"] = secretsFile
+	}
+
+	if len(labels) > 0 {
+		config["runner-labels"] = labels
+	}
+
+	if len(env) > 0 {
+		config["env"] = env
+	}
+
+	sort.Strings(report)
+
+	return config, report, nil
+}
+
+// parseActFlag returns the long name and the value of the act flag line, e.g. -P label=image, --pull=false or --bind.
+// Flags without a value, e.g. --bind, have the value true.
+func parseActFlag(line string) (string, string) {
+	flag, value, ok := strings.Cut(line, " ")
+
+	// --flag=value form, the value of the space separated form could contain = as well, e.g. -P label=image
+	if name, val, found := strings.Cut(flag, "="); found && strings.HasPrefix(flag, "--") {
+		flag, value, ok = name, val, true
+	}
+
+	if alias, found := actFlagAliases[flag]; found {
+		flag = alias
+	}
+
+	if !ok {
+		return flag, "true"
+	}
+
+	return flag, strings.Trim(strings.TrimSpace(value), `"'`)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	// networkProxyPort is the port of the proxies enforcing the network modes.
+	networkProxyPort = 8888
+
+	// networkProxyConfig is the tinyproxy configuration of the network proxies. Requests are denied unless the host
+	// matches one of the filters.
+	networkProxyConfig = `Port 8888
+Listen 0.0.0.0
+Timeout 600
+MaxClients 100
+FilterDefaultDeny Yes
+Filter "/etc/tinyproxy/filter"
+FilterType ere
+FilterURLs Off
+`
+)
+
+// githubHosts is the list of the GitHub hosts allowed in the restricted network mode, so actions and the GitHub API are
+// available to the steps.
+var githubHosts = []string{"github.com", "githubusercontent.com", "ghcr.io"}
+
+// networkModes is the list of the network modes requiring a proxy, in the order the proxies are bound.
+var networkModes = []string{"restricted", "none"}
+
+// withNetwork binds the proxies of the network modes used by the run and the jobs to the container, and configures ghx to
+// route the steps through them. Full network mode doesn't require any configuration.
+//
+// The modes are enforced for the tools respecting the proxy environment variables, so they're meant to validate the
+// builds are hermetic, not to isolate untrusted code.
+func (wr *WorkflowRun) withNetwork(container *Container) (*Container, error) {
+	used := make(map[string]bool)
+
+	if err := validateNetworkMode(wr.Config.Network); err != nil {
+		return nil, err
+	}
+
+	used[wr.Config.Network] = true
+
+	for _, mapping := range wr.Config.NetworkJobs {
+		job, mode, ok := strings.Cut(mapping, "=")
+		if !ok || job == "" {
+			return nil, fmt.Errorf("invalid job network mode %s, expected format is job=mode", mapping)
+		}
+
+		if err := validateNetworkMode(mode); err != nil {
+			return nil, err
+		}
+
+		used[mode] = true
+	}
+
+	rules, err := wr.imageRules()
+	if err != nil {
+		return nil, err
+	}
+
+	var proxies []string
+
+	for _, mode := range networkModes {
+		if !used[mode] {
+			continue
+		}
+
+		proxy, err := wr.networkProxy(rules, mode)
+		if err != nil {
+			return nil, err
+		}
+
+		host := fmt.Sprintf("network-%s", mode)
+
+		container = container.WithServiceBinding(host, proxy.AsService())
+		proxies = append(proxies, fmt.Sprintf("%s=http://%s:%d", mode, host, networkProxyPort))
+	}
+
+	if wr.Config.Network != "" {
+		container = container.WithEnvVariable("GHX_NETWORK", wr.Config.Network)
+	}
+
+	if len(wr.Config.NetworkJobs) > 0 {
+		container = container.WithEnvVariable("GHX_NETWORK_JOBS", strings.Join(wr.Config.NetworkJobs, ";"))
+	}
+
+	if len(proxies) > 0 {
+		container = container.WithEnvVariable("GHX_NETWORK_PROXIES", strings.Join(proxies, ";"))
+	}
+
+	return container, nil
+}
+
+// networkProxy returns the proxy container enforcing the given network mode. Restricted mode allows the GitHub hosts and
+// the network allowlist, none mode denies all requests. The proxy forwards the allowed requests to the configured proxy
+// of the run if exists.
+func (wr *WorkflowRun) networkProxy(rules *imageRules, mode string) (*Container, error) {
+	var filters []string
+
+	if mode == "restricted" {
+		for _, host := range append(append([]string{}, githubHosts...), wr.Config.NetworkAllowlist...) {
+			// both the domain and its subdomains are allowed, e.g. example.com allows api.example.com as well
+			filters = append(filters, fmt.Sprintf(`(^|\.)%s$`, regexp.QuoteMeta(strings.TrimPrefix(host, "*."))))
+		}
+	}
+
+	config := networkProxyConfig
+
+	upstream := wr.Config.HttpsProxy
+	if upstream == "" {
+		upstream = wr.Config.HttpProxy
+	}
+
+	if upstream != "" {
+		u, err := url.Parse(upstream)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %s: %w", upstream, err)
+		}
+
+		config += fmt.Sprintf("Upstream http %s\n", u.Host)
+	}
+
+	container := dag.Container().From(rules.rewrite("alpine:latest"))
+
+	// package installation goes through the proxy of the run as well
+	if upstream != "" {
+		container = container.WithEnvVariable("HTTP_PROXY", upstream).WithEnvVariable("HTTPS_PROXY", upstream)
+	}
+
+	files := dag.Directory().
+		WithNewFile("gale.conf", config).
+		WithNewFile("filter", strings.Join(filters, "\n")+"\n")
+
+	return container.
+		WithExec([]string{"apk", "add", "--no-cache", "tinyproxy"}).
+		WithMountedFile("/etc/tinyproxy/gale.conf", files.File("gale.conf")).
+		WithMountedFile("/etc/tinyproxy/filter", files.File("filter")).
+		WithExposedPort(networkProxyPort).
+		WithExec([]string{"tinyproxy", "-d", "-c", "/etc/tinyproxy/gale.conf"}), nil
+}
+
+// validateNetworkMode returns an error if the given network mode is not supported. Empty mode is the full network mode.
+func validateNetworkMode(mode string) error {
+	switch mode {
+	case "", "full", "restricted", "none":
+		return nil
+	default:
+		return fmt.Errorf("unsupported network mode %s, supported modes are full, restricted and none", mode)
+	}
+}
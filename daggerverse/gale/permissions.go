@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// appPermissions is the permissions of the GitHub App installation tokens the workflow permissions are mapped to.
+var appPermissions = []string{
+	"actions", "attestations", "checks", "contents", "deployments", "discussions", "issues", "packages", "pages",
+	"pull-requests", "repository-projects", "security-events", "statuses",
+}
+
+// permissionsWorkflow represents the parts of a workflow used to scope the token of the run.
+type permissionsWorkflow struct {
+	Permissions interface{} `yaml:"permissions"`
+	Jobs        map[string]struct {
+		Permissions interface{} `yaml:"permissions"`
+	} `yaml:"jobs"`
+}
+
+// runnerToken returns the GitHub token exposed to the steps as GITHUB_TOKEN. If GitHub App credentials are provided
+// instead of a token, a short-lived installation token is minted for the repository with the permissions of the
+// workflow, so the steps only get the access the workflow asks for. Workflows without a permissions block get the
+// installation token with all permissions of the installation.
+func (wr *WorkflowRun) runnerToken(ctx context.Context, info *RepoInfo) (*Secret, error) {
+	// credentials are only used to fetch the repository for the untrusted workflows
+	if wr.Config.Untrusted {
+		return nil, nil
+	}
+
+	if wr.Config.Token != nil || wr.Config.AppID == "" {
+		return wr.token(), nil
+	}
+
+	var workflow permissionsWorkflow
+
+	if err := wr.unmarshalWorkflow(ctx, &workflow); err != nil {
+		return nil, err
+	}
+
+	permissions, scoped, err := wr.workflowPermissions(workflow)
+	if err != nil {
+		return nil, err
+	}
+
+	if !scoped {
+		return wr.token(), nil
+	}
+
+	name, err := info.Name(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return dag.Repo().ScopedToken(name, permissions, (RepoScopedTokenOpts)(*wr.Config.WorkflowsRepoOpts)), nil
+}
+
+// workflowPermissions returns the token permissions in name=level format for the permissions of the workflow. Job
+// permissions replace the workflow permissions for the job. If the job option is set, the permissions of the job are
+// used, otherwise the permissions of all jobs are merged since the jobs share the token. It returns false if neither
+// the workflow nor the jobs define permissions.
+func (wr *WorkflowRun) workflowPermissions(workflow permissionsWorkflow) ([]string, bool, error) {
+	var (
+		merged = make(map[string]string)
+		scoped bool
+	)
+
+	for name, job := range workflow.Jobs {
+		if wr.Config.Job != "" && wr.Config.Job != name {
+			continue
+		}
+
+		permissions := job.Permissions
+		if permissions == nil {
+			permissions = workflow.Permissions
+		}
+
+		if permissions == nil {
+			// job without permissions gets the default permissions of the repository, so the token can't be scoped
+			return nil, false, nil
+		}
+
+		parsed, err := parsePermissions(permissions)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid permissions of job %s: %w", name, err)
+		}
+
+		for permission, level := range parsed {
+			if merged[permission] != "write" {
+				merged[permission] = level
+			}
+		}
+
+		scoped = true
+	}
+
+	if !scoped {
+		return nil, false, nil
+	}
+
+	// tokens can't be minted without permissions, metadata read is implicitly granted to all tokens anyway
+	if len(merged) == 0 {
+		merged["metadata"] = "read"
+	}
+
+	permissions := make([]string, 0, len(merged))
+
+	for permission, level := range merged {
+		permissions = append(permissions, permission+"="+level)
+	}
+
+	sort.Strings(permissions)
+
+	return permissions, true, nil
+}
+
+// parsePermissions returns the GitHub App permissions for the value of a permissions block. The value could be
+// read-all, write-all or a mapping of the permissions to their levels. Permission names are converted to the names
+// used by the GitHub App API, e.g. pull-requests to pull_requests. Permissions with none level and id-token are
+// skipped since they aren't granted with installation tokens.
+func parsePermissions(value interface{}) (map[string]string, error) {
+	permissions := make(map[string]string)
+
+	switch v := value.(type) {
+	case string:
+		var level string
+
+		switch v {
+		case "read-all":
+			level = "read"
+		case "write-all":
+			level = "write"
+		default:
+			return nil, fmt.Errorf("unsupported permissions %s, expected read-all, write-all or a mapping", v)
+		}
+
+		for _, name := range appPermissions {
+			permissions[strings.ReplaceAll(name, "-", "_")] = level
+		}
+	case map[string]interface{}:
+		for name, level := range v {
+			switch level {
+			case "read", "write":
+				if name == "id-token" {
+					continue
+				}
+
+				permissions[strings.ReplaceAll(name, "-", "_")] = level.(string)
+			case "none":
+				continue
+			default:
+				return nil, fmt.Errorf("unsupported level %v of permission %s, expected read, write or none", level, name)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported permissions %v, expected read-all, write-all or a mapping", v)
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// caCertsBundle is the path of the CA certificates bundle generated by update-ca-certificates in the runner container.
+const caCertsBundle = "/etc/ssl/certs/ca-certificates.crt"
+
+// internalHosts is the list of the service hosts bound to the runner container. They're always excluded from the proxy
+// since the proxy can't reach the services of the dagger session.
+var internalHosts = []string{"localhost", "127.0.0.1", "artifact-service", "artifact-cache-service", "docker", "k3s"}
+
+// proxyEnv returns the proxy environment variables of the runner container in both upper and lower case. The internal
+// hosts are always added to the no proxy hosts. Variables are returned in a stable order to keep the layers cacheable.
+func proxyEnv(httpProxy, httpsProxy, noProxy string) [][2]string {
+	if httpProxy == "" && httpsProxy == "" {
+		return nil
+	}
+
+	hosts := internalHosts
+
+	if noProxy != "" {
+		hosts = append([]string{noProxy}, internalHosts...)
+	}
+
+	var env [][2]string
+
+	for _, kv := range [][2]string{
+		{"HTTP_PROXY", httpProxy},
+		{"HTTPS_PROXY", httpsProxy},
+		{"NO_PROXY", strings.Join(hosts, ",")},
+	} {
+		if kv[1] == "" {
+			continue
+		}
+
+		env = append(env, kv, [2]string{strings.ToLower(kv[0]), kv[1]})
+	}
+
+	return env
+}
+
+// withProxy configures the proxy environment variables and installs the extra CA certificates to the trust store of the
+// runner container. ghx propagates them to the step containers as well.
+func (wr *WorkflowRun) withProxy(container *Container) *Container {
+	for _, env := range proxyEnv(wr.Config.HttpProxy, wr.Config.HttpsProxy, wr.Config.NoProxy) {
+		container = container.WithEnvVariable(env[0], env[1])
+	}
+
+	if len(wr.Config.CaCerts) == 0 {
+		return container
+	}
+
+	for idx, cert := range wr.Config.CaCerts {
+		container = container.WithFile(fmt.Sprintf("/usr/local/share/ca-certificates/gale-%d.crt", idx), cert)
+	}
+
+	// node and python don't use the system trust store by default, so they're pointed to the bundle explicitly
+	return container.
+		WithExec([]string{"update-ca-certificates"}).
+		WithEnvVariable("GHX_CA_CERTS", caCertsBundle).
+		WithEnvVariable("NODE_EXTRA_CA_CERTS", caCertsBundle).
+		WithEnvVariable("REQUESTS_CA_BUNDLE", caCertsBundle)
+}
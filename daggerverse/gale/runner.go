@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Runner is a builder for customized runner images.
+type Runner struct{}
+
+// RunnerBuildOpts represents the options for building a runner image.
+type RunnerBuildOpts struct {
+	Manifest *File  `doc:"The runner.yaml manifest declaring the packages, tools and provisioning scripts of the runner image."`
+	Image    string `doc:"The base image to build the runner image from. Overrides the image in the manifest."`
+	Platform string `doc:"Platform of the runner image, e.g. linux/arm64."`
+	Profile  string `doc:"The build profile of the runner image. Overrides the profile in the manifest. Supported profiles: full."`
+}
+
+// Build builds a runner image from the given options.
+func (r *Runner) Build(ctx context.Context, opts RunnerBuildOpts) (*Container, error) {
+	manifest, err := loadRunnerManifest(ctx, opts.Manifest, opts.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	image := opts.Image
+
+	if image == "" {
+		image = manifest.Image
+	}
+
+	if image == "" {
+		image = defaultRunnerImage
+	}
+
+	return manifest.build(ctx, dag.Container(ContainerOpts{Platform: Platform(opts.Platform)}).From(image))
+}
+
+// runnerManifest represents the runner.yaml manifest to customize the runner image.
+//
+// Example:
+//
+//	image: ghcr.io/catthehacker/ubuntu:act-22.04
+//	profile: full
+//	packages: [zip, unzip]
+//	tools:
+//	  node: "20"
+//	  go: "1.21.3"
+//	  python: "3.11"
+//	env:
+//	  FOO: bar
+//	scripts:
+//	  - curl -fsSL https://example.com/install.sh | bash
+type runnerManifest struct {
+	Image    string            `yaml:"image"`    // Image is the base image of the runner image. Optional.
+	Profile  string            `yaml:"profile"`  // Profile is the build profile applied before the manifest. Optional.
+	Packages []string          `yaml:"packages"` // Packages is the list of apt packages to install.
+	Tools    map[string]string `yaml:"tools"`    // Tools is the map of tool names to versions to preinstall.
+	Env      map[string]string `yaml:"env"`      // Env is the map of environment variables to set.
+	Scripts  []string          `yaml:"scripts"`  // Scripts is the list of provisioning scripts to run in order.
+}
+
+// toolInstallers is the map of supported tools to their installers. Installers receive the version of the tool.
+var toolInstallers = map[string]func(ctx context.Context, c *Container, version string) (*Container, error){
+	"node":   installNode,
+	"go":     installGo,
+	"python": installPython,
+}
+
+// runnerProfiles is the map of the build profiles to the manifests applied before the user manifest.
+var runnerProfiles = map[string]runnerManifest{
+	// full installs the most common tools of GitHub hosted ubuntu-latest runners.
+	"full": {
+		Packages: []string{
+			"build-essential", "ca-certificates", "curl", "wget", "git", "gnupg", "jq", "zip", "unzip", "docker.io",
+		},
+		Tools: map[string]string{
+			"node":   "20",
+			"go":     "1.21",
+			"python": "3.11",
+		},
+		Scripts: []string{
+			// gh cli from the official apt repository
+			`mkdir -p -m 755 /etc/apt/keyrings && curl -fsSL https://cli.github.com/packages/githubcli-archive-keyring.gpg -o /etc/apt/keyrings/githubcli-archive-keyring.gpg && chmod go+r /etc/apt/keyrings/githubcli-archive-keyring.gpg && echo "deb [arch=$(dpkg --print-architecture) signed-by=/etc/apt/keyrings/githubcli-archive-keyring.gpg] https://cli.github.com/packages stable main" > /etc/apt/sources.list.d/github-cli.list && apt-get update && apt-get install -y gh && rm -rf /var/lib/apt/lists/*`,
+			// yq from the latest release
+			`curl -fsSL -o /usr/local/bin/yq "https://github.com/mikefarah/yq/releases/latest/download/yq_linux_$(dpkg --print-architecture)" && chmod +x /usr/local/bin/yq`,
+			// kubectl from the latest stable release
+			`curl -fsSL -o /usr/local/bin/kubectl "https://dl.k8s.io/release/$(curl -fsSL https://dl.k8s.io/release/stable.txt)/bin/linux/$(dpkg --print-architecture)/kubectl" && chmod +x /usr/local/bin/kubectl`,
+		},
+	},
+}
+
+// loadRunnerManifest loads the runner manifest from the given file and applies the build profile. Profile argument
+// overrides the profile in the manifest. If the file is nil, an empty manifest is used.
+func loadRunnerManifest(ctx context.Context, file *File, profile string) (*runnerManifest, error) {
+	manifest := &runnerManifest{}
+
+	if file != nil {
+		if err := file.unmarshalContentsToYAML(ctx, manifest); err != nil {
+			return nil, err
+		}
+	}
+
+	if profile != "" {
+		manifest.Profile = profile
+	}
+
+	if manifest.Profile != "" {
+		if err := manifest.applyProfile(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := manifest.validate(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// validate checks the tools of the manifest are supported.
+func (m *runnerManifest) validate() error {
+	for tool := range m.Tools {
+		if _, ok := toolInstallers[tool]; !ok {
+			return fmt.Errorf("unsupported tool %s in runner manifest", tool)
+		}
+	}
+
+	return nil
+}
+
+// applyProfile merges the profile manifest into the manifest. Packages and scripts of the profile are applied before the
+// ones in the manifest, tool versions and env variables in the manifest have precedence over the profile.
+func (m *runnerManifest) applyProfile() error {
+	profile, ok := runnerProfiles[m.Profile]
+	if !ok {
+		return fmt.Errorf("unsupported runner profile %s", m.Profile)
+	}
+
+	m.Packages = append(append([]string{}, profile.Packages...), m.Packages...)
+	m.Scripts = append(append([]string{}, profile.Scripts...), m.Scripts...)
+
+	if m.Tools == nil {
+		m.Tools = make(map[string]string)
+	}
+
+	for tool, version := range profile.Tools {
+		if _, ok := m.Tools[tool]; !ok {
+			m.Tools[tool] = version
+		}
+	}
+
+	if m.Env == nil {
+		m.Env = make(map[string]string)
+	}
+
+	for k, v := range profile.Env {
+		if _, ok := m.Env[k]; !ok {
+			m.Env[k] = v
+		}
+	}
+
+	return nil
+}
+
+// build applies the manifest to the given container in order of packages, tools, env and scripts.
+func (m *runnerManifest) build(ctx context.Context, container *Container) (*Container, error) {
+	if len(m.Packages) > 0 {
+		container = container.With(aptInstall(m.Packages...))
+	}
+
+	// install tools in a stable order to keep the layers cacheable
+	tools := make([]string, 0, len(m.Tools))
+	for tool := range m.Tools {
+		tools = append(tools, tool)
+	}
+
+	sort.Strings(tools)
+
+	for _, tool := range tools {
+		var err error
+
+		container, err = toolInstallers[tool](ctx, container, m.Tools[tool])
+		if err != nil {
+			return nil, fmt.Errorf("failed to install %s: %w", tool, err)
+		}
+	}
+
+	keys := make([]string, 0, len(m.Env))
+	for k := range m.Env {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		container = container.WithEnvVariable(k, m.Env[k])
+	}
+
+	for _, script := range m.Scripts {
+		container = container.WithExec([]string{"bash", "-c", script})
+	}
+
+	return container, nil
+}
+
+// aptInstall installs the given apt packages.
+func aptInstall(packages ...string) func(*Container) *Container {
+	return func(c *Container) *Container {
+		script := fmt.Sprintf("apt-get update && DEBIAN_FRONTEND=noninteractive apt-get install -y --no-install-recommends %s && rm -rf /var/lib/apt/lists/*", strings.Join(packages, " "))
+
+		return c.WithExec([]string{"bash", "-c", script})
+	}
+}
+
+// installNode installs the given node version copying it from the official node image.
+func installNode(ctx context.Context, c *Container, version string) (*Container, error) {
+	node, err := toolImage(ctx, c, fmt.Sprintf("node:%s", version))
+	if err != nil {
+		return nil, err
+	}
+
+	c = c.WithDirectory("/opt/node/bin", node.Directory("/usr/local/bin")).
+		WithDirectory("/opt/node/lib", node.Directory("/usr/local/lib"))
+
+	return prependPath(ctx, c, "/opt/node/bin")
+}
+
+// externalsDir is the directory of the node runtimes of the javascript actions in the runner container.
+const externalsDir = "/home/runner/externals"
+
+// withNodeRuntimes mounts the cache of the node runtimes of the javascript actions to the externals directory, so the
+// actions run with the node version they declare instead of the node installed in the runner image. Ghx installs each
+// runtime to the cache on first use, so only the runtimes of the actions used by the workflows are pulled. Runtimes are
+// platform specific binaries, so each platform has its own cache.
+func withNodeRuntimes(ctx context.Context, c *Container) (*Container, error) {
+	platform, err := c.Platform(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	volume := dag.CacheVolume(externalsVolume(string(platform)))
+
+	return c.
+		WithMountedCache(externalsDir, volume, ContainerWithMountedCacheOpts{Sharing: Shared}).
+		WithEnvVariable("GHX_EXTERNALS_DIR", externalsDir), nil
+}
+
+// externalsVolume returns the name of the cache volume of the node runtimes for the given platform.
+func externalsVolume(platform string) string {
+	return fmt.Sprintf("gale-externals-%s", strings.ReplaceAll(platform, "/", "-"))
+}
+
+// installGo installs the given go version copying it from the official golang image.
+func installGo(ctx context.Context, c *Container, version string) (*Container, error) {
+	golang, err := toolImage(ctx, c, fmt.Sprintf("golang:%s", version))
+	if err != nil {
+		return nil, err
+	}
+
+	c = c.WithDirectory("/usr/local/go", golang.Directory("/usr/local/go"))
+
+	return prependPath(ctx, c, "/usr/local/go/bin")
+}
+
+// installPython installs the given python version from the deadsnakes ppa, since python binaries from the official
+// images are not compatible with the ubuntu based runner images.
+func installPython(_ context.Context, c *Container, version string) (*Container, error) {
+	script := fmt.Sprintf(
+		"add-apt-repository -y ppa:deadsnakes/ppa && apt-get update && DEBIAN_FRONTEND=noninteractive apt-get install -y --no-install-recommends python%[1]s python%[1]s-venv && ln -sf /usr/bin/python%[1]s /usr/local/bin/python && rm -rf /var/lib/apt/lists/*",
+		version,
+	)
+
+	return c.With(aptInstall("software-properties-common")).WithExec([]string{"bash", "-c", script}), nil
+}
+
+// secretsTools is the map of the tools called by the secrets providers to the images and the paths of their binaries.
+var secretsTools = map[string]struct{ Image, Path string }{
+	"sops":  {Image: "ghcr.io/getsops/sops:v3.8.1", Path: "/usr/local/bin/sops"},
+	"vault": {Image: "hashicorp/vault:1.15", Path: "/bin/vault"},
+}
+
+// withSecretsTool mounts the binary of the given secrets tool to the runner container, since the runner images don't
+// have them.
+func withSecretsTool(ctx context.Context, c *Container, tool string) (*Container, error) {
+	t, ok := secretsTools[tool]
+	if !ok {
+		return nil, fmt.Errorf("unsupported secrets tool %s", tool)
+	}
+
+	image, err := toolImage(ctx, c, t.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.WithMountedFile(fmt.Sprintf("/usr/local/bin/%s", tool), image.File(t.Path)), nil
+}
+
+// toolImage returns the container of the given image with the same platform of the runner container to copy the tool
+// binaries from. Image is rewritten with the image rules configured to the runner container.
+func toolImage(ctx context.Context, c *Container, image string) (*Container, error) {
+	platform, err := c.Platform(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := containerImageRules(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return dag.Container(ContainerOpts{Platform: platform}).From(rules.rewrite(image)), nil
+}
+
+// prependPath prepends the given path to the PATH environment variable of the container.
+func prependPath(ctx context.Context, c *Container, path string) (*Container, error) {
+	current, err := c.EnvVariable(ctx, "PATH")
+	if err != nil {
+		return nil, err
+	}
+
+	return c.WithEnvVariable("PATH", fmt.Sprintf("%s:%s", path, current)), nil
+}
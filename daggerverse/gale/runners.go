@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// runner represents the runner image, platform and GPUs selected for the jobs of a workflow run.
+type runner struct {
+	Image    string
+	Platform string
+	Gpus     string // Gpus is the comma separated GPUs exposed to the runner, or all to expose all GPUs.
+}
+
+// jobRunner represents a job of the workflow run with the runner selected for it. Empty job represents all jobs of the
+// workflow run, or the job of the job option, when the runner is not selected per job.
+type jobRunner struct {
+	Job    string
+	Runner *runner
+}
+
+// jobRunners returns the jobs of the workflow run in execution order with the runner selected for each of them. The
+// runners are selected by matching the runs-on labels of the jobs with the runner labels and platforms options. If the
+// job option is set, only the job and its dependencies are returned. Jobs without a matching label use the runner image
+// option.
+func (wr *WorkflowRun) jobRunners(ctx context.Context) ([]jobRunner, error) {
+	images, err := parseLabelMapping(wr.Config.RunnerLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	platforms, err := parseLabelMapping(wr.Config.RunnerPlatforms)
+	if err != nil {
+		return nil, err
+	}
+
+	gpus, err := parseGpus(wr.Config.Gpus)
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := runner{Image: wr.Config.RunnerImage}
+
+	if fallback.Image == "" {
+		fallback.Image = defaultRunnerImage
+	}
+
+	var workflow targetsWorkflow
+
+	if err := wr.unmarshalWorkflow(ctx, &workflow); err != nil {
+		// without label mappings, the workflow is only loaded for the container images, so the fallback is enough
+		if len(images) == 0 && len(platforms) == 0 {
+			return withGpus(wr.withPlatform([]jobRunner{{Job: wr.Config.Job, Runner: &fallback}}), gpus), nil
+		}
+
+		return nil, err
+	}
+
+	targets, err := wr.targets(workflow)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := jobOrder(workflow, wr.Config.Job)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := selectRunners(targets, order, images, platforms, fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	return withGpus(wr.withPlatform(jobs), gpus), nil
+}
+
+// withPlatform applies the platform option to the runners of the given jobs. The platform option has precedence over
+// the platform selected with the labels.
+func (wr *WorkflowRun) withPlatform(jobs []jobRunner) []jobRunner {
+	if wr.Config.Platform == "" {
+		return jobs
+	}
+
+	for _, job := range jobs {
+		job.Runner.Platform = wr.Config.Platform
+	}
+
+	return jobs
+}
+
+// withGpus exposes the requested GPUs to the runners of the given jobs. Jobs requesting different GPUs run in separate
+// runner containers, so the GPUs are only exposed to the jobs requesting them. Empty job represents all jobs, so it
+// gets the GPUs of all requests.
+func withGpus(jobs []jobRunner, gpus map[string][]string) []jobRunner {
+	for _, job := range jobs {
+		requested := slices.Clone(gpus[""])
+
+		if job.Job == "" {
+			for name, devices := range gpus {
+				if name != "" {
+					requested = append(requested, devices...)
+				}
+			}
+		} else {
+			requested = append(requested, gpus[job.Job]...)
+		}
+
+		job.Runner.Gpus = joinGpus(requested)
+	}
+
+	return jobs
+}
+
+// joinGpus returns the given GPUs as a sorted comma separated list without duplicates, or all if any of them is all.
+func joinGpus(gpus []string) string {
+	if slices.Contains(gpus, "all") {
+		return "all"
+	}
+
+	sorted := slices.Clone(gpus)
+
+	slices.Sort(sorted)
+
+	return strings.Join(slices.Compact(sorted), ",")
+}
+
+// sharedRunner returns the runner of the given jobs if all of them target the same runner, otherwise nil.
+func sharedRunner(jobs []jobRunner) *runner {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	for _, job := range jobs[1:] {
+		if *job.Runner != *jobs[0].Runner {
+			return nil
+		}
+	}
+
+	return jobs[0].Runner
+}
+
+// jobTarget represents the runs-on labels and the container image of a job run. Matrix jobs have a target for each
+// matrix combination, since the labels and the image could refer to the matrix values, e.g. ${{ matrix.os }}.
+type jobTarget struct {
+	Labels []string
+	Image  string // Image is the container image of the job. Steps run in the runner container, so it's used as the runner image.
+}
+
+// selectRunners selects the runner of the jobs in the given order by matching the runs-on labels of the jobs with the
+// label mappings. Jobs running in a container use the container image as the runner image. Matrix combinations of a job
+// run in the same container, so they must target the same runner.
+func selectRunners(targets map[string][]jobTarget, order []string, images, platforms map[string]string, fallback runner) ([]jobRunner, error) {
+	jobs := make([]jobRunner, 0, len(order))
+
+	for _, name := range order {
+		var selected *runner
+
+		for _, target := range targets[name] {
+			r := &runner{Image: fallback.Image, Platform: fallback.Platform}
+
+			for _, label := range target.Labels {
+				if image, ok := images[label]; ok {
+					r.Image = image
+				}
+
+				if platform, ok := platforms[label]; ok {
+					r.Platform = platform
+				}
+			}
+
+			if target.Image != "" {
+				r.Image = target.Image
+			}
+
+			if selected != nil && *selected != *r {
+				return nil, fmt.Errorf("matrix combinations of job %s target different runners, run them separately with the matrix option", name)
+			}
+
+			selected = r
+		}
+
+		if selected == nil {
+			selected = &runner{Image: fallback.Image, Platform: fallback.Platform}
+		}
+
+		jobs = append(jobs, jobRunner{Job: name, Runner: selected})
+	}
+
+	return jobs, nil
+}
+
+// jobOrder returns the jobs of the workflow in execution order, each job comes after the jobs it needs. If the job is
+// given, only the job and its dependencies are returned. Jobs are visited by name to keep the order deterministic.
+func jobOrder(workflow targetsWorkflow, job string) ([]string, error) {
+	var (
+		order   []string
+		visited = make(map[string]bool)
+		visitFn func(name string) error
+	)
+
+	visitFn = func(name string) error {
+		config, ok := workflow.Jobs[name]
+		if !ok {
+			return fmt.Errorf("job %s not found", name)
+		}
+
+		if visited[name] {
+			return nil
+		}
+
+		visited[name] = true
+
+		for _, need := range parseNeeds(config.Needs) {
+			if err := visitFn(need); err != nil {
+				return err
+			}
+		}
+
+		order = append(order, name)
+
+		return nil
+	}
+
+	if job != "" {
+		if err := visitFn(job); err != nil {
+			return nil, err
+		}
+
+		return order, nil
+	}
+
+	names := make([]string, 0, len(workflow.Jobs))
+	for name := range workflow.Jobs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := visitFn(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// targetsWorkflow represents the parts of a workflow used to select the runner of its jobs.
+type targetsWorkflow struct {
+	Jobs map[string]struct {
+		Needs     interface{} `yaml:"needs"`
+		RunsOn    interface{} `yaml:"runs-on"`
+		Container interface{} `yaml:"container"`
+		Strategy  struct {
+			Matrix interface{} `yaml:"matrix"`
+		} `yaml:"strategy"`
+	} `yaml:"jobs"`
+}
+
+// unmarshalWorkflow unmarshal the workflow of the run into the given value. The workflow is looked up in the workflows
+// directory of the repository by its name, unless it's given with the workflow file or the workflow content option.
+func (wr *WorkflowRun) unmarshalWorkflow(ctx context.Context, v interface{}) error {
+	// workflow given with its content is used as is instead of looking it up in the repository
+	if file := wr.Config.workflowFile(); file != nil {
+		return file.unmarshalContentsToYAML(ctx, v)
+	}
+
+	dir := dag.Repo().Source((RepoSourceOpts)(*wr.Config.WorkflowsRepoOpts)).Directory(wr.Config.WorkflowsDir)
+
+	entries, err := dir.Entries(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry, ".yaml") && !strings.HasSuffix(entry, ".yml") {
+			continue
+		}
+
+		var workflow struct {
+			Name string `yaml:"name"`
+		}
+
+		if err := dir.File(entry).unmarshalContentsToYAML(ctx, &workflow); err != nil {
+			return err
+		}
+
+		// workflows without a name are identified with their path, same as ghx does
+		name := workflow.Name
+		if name == "" {
+			name = filepath.Join(wr.Config.WorkflowsDir, entry)
+		}
+
+		if name != wr.Config.Workflow {
+			continue
+		}
+
+		return dir.File(entry).unmarshalContentsToYAML(ctx, v)
+	}
+
+	return fmt.Errorf("workflow %s not found", wr.Config.Workflow)
+}
+
+// targets returns the targets of the jobs of the given workflow for the matrix combinations matching the matrix option.
+func (wr *WorkflowRun) targets(workflow targetsWorkflow) (map[string][]jobTarget, error) {
+	targets := make(map[string][]jobTarget, len(workflow.Jobs))
+
+	for job, config := range workflow.Jobs {
+		var (
+			labels = parseRunsOn(config.RunsOn)
+			image  = parseContainerImage(config.Container)
+		)
+
+		// matrix is the value of an expression, e.g. fromJSON(needs.setup.outputs.matrix), values are unknown
+		matrix, _ := config.Strategy.Matrix.(map[string]interface{})
+
+		combinations, err := filterMatrixCombinations(matrixCombinations(matrix), wr.Config.Matrix)
+		if err != nil {
+			return nil, err
+		}
+
+		// jobs without matrix are considered as a matrix with a single empty combination
+		if len(combinations) == 0 {
+			combinations = []map[string]interface{}{{}}
+		}
+
+		for _, combination := range combinations {
+			target := jobTarget{Image: evalMatrix(image, combination)}
+
+			for _, label := range labels {
+				target.Labels = append(target.Labels, evalMatrix(label, combination))
+			}
+
+			targets[job] = append(targets[job], target)
+		}
+	}
+
+	return targets, nil
+}
+
+// parseRunsOn returns the labels from the runs-on value of a job. The value could be a single label, a list of labels
+// or a runner group with labels.
+func parseRunsOn(runsOn interface{}) []string {
+	var labels []string
+
+	switch v := runsOn.(type) {
+	case string:
+		labels = append(labels, v)
+	case []interface{}:
+		for _, label := range v {
+			labels = append(labels, fmt.Sprintf("%v", label))
+		}
+	case map[string]interface{}:
+		return parseRunsOn(v["labels"])
+	}
+
+	return labels
+}
+
+// parseNeeds returns the jobs from the needs value of a job. The value could be a single job or a list of jobs.
+func parseNeeds(needs interface{}) []string {
+	var jobs []string
+
+	switch v := needs.(type) {
+	case string:
+		jobs = append(jobs, v)
+	case []interface{}:
+		for _, job := range v {
+			jobs = append(jobs, fmt.Sprintf("%v", job))
+		}
+	}
+
+	return jobs
+}
+
+// parseContainerImage returns the image from the container value of a job. The value could be the image or a mapping
+// with the image.
+func parseContainerImage(container interface{}) string {
+	switch v := container.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		image, _ := v["image"].(string)
+		return image
+	}
+
+	return ""
+}
+
+// parseGpus parses the GPU requests in job=gpu format. Requests without a job expose the GPU to all jobs and requests
+// of the same job are combined, e.g. train=0,train=1 exposes the first two GPUs to the train job.
+func parseGpus(requests []string) (map[string][]string, error) {
+	parsed := make(map[string][]string)
+
+	for _, request := range requests {
+		job, gpu, ok := strings.Cut(request, "=")
+		if !ok {
+			job, gpu = "", request
+		}
+
+		if (ok && job == "") || gpu == "" {
+			return nil, fmt.Errorf("invalid gpu request %s, expected format is job=gpu or gpu", request)
+		}
+
+		parsed[job] = append(parsed[job], gpu)
+	}
+
+	return parsed, nil
+}
+
+// parseLabelMapping parses the label mappings in label=value format.
+func parseLabelMapping(mappings []string) (map[string]string, error) {
+	parsed := make(map[string]string, len(mappings))
+
+	for _, mapping := range mappings {
+		label, value, ok := strings.Cut(mapping, "=")
+		if !ok || label == "" || value == "" {
+			return nil, fmt.Errorf("invalid label mapping %s, expected format is label=value", mapping)
+		}
+
+		parsed[label] = value
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// runsHistoryPath is the path of the run history in the runner container. Workflow runs are copied to the history
+// after the run completes.
+const runsHistoryPath = "/home/runner/_temp/gale/runs"
+
+// liveLogsPath is the path of the live logs in the runner container. ghx records the console output of the workflow
+// runs to the live logs while they're running.
+const liveLogsPath = "/home/runner/_temp/gale/logs"
+
+// saveRunScript copies the workflow run reports and the log of the run to the run history. A new attempt of a run
+// replaces the run in the history, since it keeps the reports of the previous attempts itself.
+const saveRunScript = `for run in /home/runner/_temp/ghx/runs/*; do
+  rm -rf "` + runsHistoryPath + `/$(basename "$run")"
+  cp -r "$run" ` + runsHistoryPath + `/
+  cp ` + logPath + ` "` + runsHistoryPath + `/$(basename "$run")/"
+done`
+
+// Runs represents the history of the workflow runs executed with gale.
+type Runs struct{}
+
+// RunsShowOpts represents the options for showing a workflow run from the history.
+type RunsShowOpts struct {
+	Job  string `doc:"The job name to show. Matrix jobs are matched with their name or display name, e.g. test (ubuntu-latest, 1.21). If empty, all jobs are shown."`
+	Logs bool   `doc:"Include the logs of the steps." default:"false"`
+}
+
+// RunsLogsOpts represents the options for printing the logs of a workflow run.
+type RunsLogsOpts struct {
+	Follow    bool `doc:"Follow the logs of the workflow run until it completes. Use with --progress plain to see the lines as they're written." default:"false"`
+	Untrusted bool `doc:"Read the logs of an untrusted workflow run, e.g. a pull request from a fork. Untrusted runs keep their logs separately." default:"false"`
+}
+
+// stepRunSummary represents a step in the job run report.
+type stepRunSummary struct {
+	ID         string `json:"id"`         // ID is the unique identifier of the step
+	Name       string `json:"name"`       // Name is the name of the step
+	Stage      string `json:"stage"`      // Stage is the stage of the step, e.g. pre, main, post
+	Conclusion string `json:"conclusion"` // Conclusion is the result of the step after continue-on-error is applied
+	Duration   string `json:"duration"`   // Duration of the step
+}
+
+// List returns the workflow runs in the history as a table of run id, attempt, workflow, conclusion and duration. Latest
+// runs are listed first.
+func (r *Runs) List(ctx context.Context) (string, error) {
+	history := runsHistory()
+
+	entries, err := history.Entries(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	reports := make([]WorkflowRunReport, 0, len(entries))
+
+	for _, entry := range entries {
+		var report WorkflowRunReport
+
+		if err := history.File(filepath.Join(entry, "workflow_run.json")).unmarshalContentsToJSON(ctx, &report); err != nil {
+			return "", err
+		}
+
+		reports = append(reports, report)
+	}
+
+	return runsTable(reports)
+}
+
+// runsTable returns the given workflow runs as a table. Latest runs are listed first.
+func runsTable(reports []WorkflowRunReport) (string, error) {
+	// started at is in RFC3339 format, so sorting the values as strings keeps the chronological order
+	sort.Slice(reports, func(i, j int) bool { return reports[i].StartedAt > reports[j].StartedAt })
+
+	var sb strings.Builder
+
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "RUN ID\tATTEMPT\tWORKFLOW\tCONCLUSION\tDURATION\tSTARTED AT")
+
+	for _, report := range reports {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", report.RunID, report.RunAttempt, report.Name, report.Conclusion, report.Duration, report.StartedAt)
+	}
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+// Show returns the summary of the workflow run with the given id from the history with its jobs and steps. Optionally,
+// it includes the logs of the steps.
+func (r *Runs) Show(ctx context.Context, runID string, opts RunsShowOpts) (string, error) {
+	var (
+		history = runsHistory().Directory(runID)
+		report  WorkflowRunReport
+	)
+
+	if err := history.File("workflow_run.json").unmarshalContentsToJSON(ctx, &report); err != nil {
+		return "", fmt.Errorf("workflow run %s not found in the history: %w", runID, err)
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Run:        %s\n", report.RunID)
+	fmt.Fprintf(&sb, "Workflow:   %s (%s)\n", report.Name, report.Path)
+	fmt.Fprintf(&sb, "Attempt:    %s\n", report.RunAttempt)
+	fmt.Fprintf(&sb, "Conclusion: %s\n", report.Conclusion)
+	fmt.Fprintf(&sb, "Duration:   %s\n", report.Duration)
+	fmt.Fprintf(&sb, "Started at: %s\n", report.StartedAt)
+
+	entries, err := history.Directory("jobs").Entries(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	found := false
+
+	for _, entry := range entries {
+		dir := history.Directory(filepath.Join("jobs", entry))
+
+		var job struct {
+			JobRunResult
+			Steps []stepRunSummary `json:"steps"`
+		}
+
+		if err := dir.File("job_run.json").unmarshalContentsToJSON(ctx, &job); err != nil {
+			return "", err
+		}
+
+		if opts.Job != "" && opts.Job != job.Name && opts.Job != job.DisplayName {
+			continue
+		}
+
+		found = true
+
+		fmt.Fprintf(&sb, "\nJob: %s\n", job.DisplayName)
+
+		w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", "STEP", "CONCLUSION", "DURATION")
+
+		for _, step := range job.Steps {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", stepName(step), step.Conclusion, step.Duration)
+		}
+
+		if err := w.Flush(); err != nil {
+			return "", err
+		}
+
+		if !opts.Logs {
+			continue
+		}
+
+		// pre, main and post stages of a step share the same log
+		seen := make(map[string]bool)
+
+		for _, step := range job.Steps {
+			if seen[step.ID] {
+				continue
+			}
+
+			seen[step.ID] = true
+
+			logs, err := stepLogs(ctx, dir, step.ID)
+			if err != nil {
+				return "", err
+			}
+
+			fmt.Fprintf(&sb, "\n--- %s ---\n%s", step.ID, logs)
+		}
+	}
+
+	if opts.Job != "" && !found {
+		return "", fmt.Errorf("job %s not found in workflow run %s", opts.Job, runID)
+	}
+
+	return sb.String(), nil
+}
+
+// Logs returns the console output of the workflow run with the given id, each line prefixed with its job, step and
+// elapsed time. With follow, it waits for the workflow run to start and prints the new lines until the run completes,
+// e.g. to follow a run started by serve mode or another session.
+func (r *Runs) Logs(ctx context.Context, runID string, opts RunsLogsOpts) (string, error) {
+	args := []string{"ghx", "logs"}
+
+	if opts.Follow {
+		args = append(args, "--follow")
+	}
+
+	args = append(args, filepath.Join("/logs", fmt.Sprintf("%s.ndjson", runID)))
+
+	volume := "gale-logs"
+
+	if opts.Untrusted {
+		volume = "gale-logs-untrusted"
+	}
+
+	out, err := dag.Container().From("alpine:latest").
+		With(dag.Source().Ghx().Binary).
+		WithMountedCache("/logs", dag.CacheVolume(volume), ContainerWithMountedCacheOpts{Sharing: Shared}).
+		// logs could change between calls, so the output shouldn't be cached
+		WithEnvVariable("CACHE_BUSTER", time.Now().Format(time.RFC3339Nano)).
+		WithExec(args).
+		Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read logs of workflow run %s: %w", runID, err)
+	}
+
+	return out, nil
+}
+
+// stepName returns the name of the step in the job run report prefixed with its stage. Steps without a name are
+// shown with their id.
+func stepName(step stepRunSummary) string {
+	name := step.Name
+	if name == "" {
+		name = step.ID
+	}
+
+	switch step.Stage {
+	case "pre":
+		name = fmt.Sprintf("Pre %s", name)
+	case "post":
+		name = fmt.Sprintf("Post %s", name)
+	}
+
+	return name
+}
+
+// stepLogs returns the logs of the step in the job run directory. Steps without output don't have a log file.
+func stepLogs(ctx context.Context, dir *Directory, stepID string) (string, error) {
+	entries, err := dir.Directory(filepath.Join("steps", stepID)).Entries(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range entries {
+		if entry == "step.log" {
+			return dir.File(filepath.Join("steps", stepID, entry)).Contents(ctx)
+		}
+	}
+
+	return "", nil
+}
+
+// runsHistory returns a snapshot of the run history. History is copied out of the cache volume since the contents of
+// the cache volumes can't be read directly.
+func runsHistory() *Directory {
+	return dag.Container().From("alpine:latest").
+		WithMountedCache("/history", dag.CacheVolume("gale-runs"), ContainerWithMountedCacheOpts{Sharing: Shared}).
+		// history could change between calls, so the snapshot shouldn't be cached
+		WithEnvVariable("CACHE_BUSTER", time.Now().Format(time.RFC3339Nano)).
+		WithExec([]string{"sh", "-c", "mkdir -p /snapshot && cp -r /history/. /snapshot/"}).
+		Directory("/snapshot")
+}
+
+// withRunsHistory saves the workflow run executed in the container to the run history and prunes the runs expired with
+// their retention days. If the max size is given, the oldest runs are pruned as well until the history fits the size.
+// Artifacts and live logs of the pruned runs are deleted with them.
+func withRunsHistory(container *Container, maxSize string) *Container {
+	args := []string{"ghx", "prune-runs"}
+
+	if maxSize != "" {
+		args = append(args, "--max-size", maxSize)
+	}
+
+	args = append(args, runsHistoryPath, uploadArtifactsPath, liveLogsPath)
+
+	return container.
+		WithMountedCache(runsHistoryPath, dag.CacheVolume("gale-runs"), ContainerWithMountedCacheOpts{Sharing: Shared}).
+		WithExec([]string{"sh", "-c", saveRunScript}).
+		WithMountedCache(uploadArtifactsPath, dag.Source().ArtifactService().CacheVolume(), ContainerWithMountedCacheOpts{Sharing: Shared}).
+		WithExec(args)
+}
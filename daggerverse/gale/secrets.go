@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// storeSecretsPath is the path of the decrypted secrets from the store in the runner container.
+const storeSecretsPath = "/home/runner/_temp/gale/store-secrets.json"
+
+// secretNameRe is the regular expression of the valid secret names.
+var secretNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Secrets represents the encrypted local secrets store. The store is a directory under the gale home directory, e.g.
+// ~/.gale/secrets/owner/name, with a file encrypted with the age identity of the user for each secret, so plaintext
+// secrets files are not required to run the workflows.
+//
+// Secrets are encrypted and decrypted in the module. Plaintext values never reach an exec or a file, they're only
+// passed to the engine as secrets.
+type Secrets struct{}
+
+// SecretsStoreOpts represents the options for selecting the secrets store.
+type SecretsStoreOpts struct {
+	Store *Directory `doc:"The secrets store directory of the repository, e.g. ~/.gale/secrets/owner/name. Defaults to an empty store."`
+}
+
+// Set encrypts the secret with the age identity and returns the store with the secret saved with the given name. The
+// returned store should be exported to the store directory.
+func (s *Secrets) Set(ctx context.Context, name string, value *Secret, key *Secret, opts SecretsStoreOpts) (*Directory, error) {
+	if !secretNameRe.MatchString(name) {
+		return nil, fmt.Errorf("invalid secret name %s, names can only contain alphanumeric characters or underscores", name)
+	}
+
+	identity, err := secretsIdentity(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := value.Plaintext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	w, err := age.Encrypt(&buf, identity.Recipient())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret %s: %w", name, err)
+	}
+
+	if _, err := io.WriteString(w, plaintext); err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret %s: %w", name, err)
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret %s: %w", name, err)
+	}
+
+	return opts.store().WithNewFile(name+".age", buf.String()), nil
+}
+
+// Get decrypts the secret with the given name from the store.
+func (s *Secrets) Get(ctx context.Context, name string, key *Secret, opts SecretsStoreOpts) (*Secret, error) {
+	if !secretNameRe.MatchString(name) {
+		return nil, fmt.Errorf("invalid secret name %s", name)
+	}
+
+	identity, err := secretsIdentity(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := opts.store().File(name + ".age").Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+
+	value, err := decryptSecret(ciphertext, identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret %s: %w", name, err)
+	}
+
+	return dag.SetSecret(secretsStoreSecretName(name, ciphertext), value), nil
+}
+
+// List returns the names of the secrets in the store.
+func (s *Secrets) List(ctx context.Context, opts SecretsStoreOpts) (string, error) {
+	names, err := secretsStoreNames(ctx, opts.store())
+	if err != nil {
+		return "", err
+	}
+
+	if len(names) == 0 {
+		return "", nil
+	}
+
+	return strings.Join(names, "\n") + "\n", nil
+}
+
+// Rm returns the store with the secret with the given name removed. The returned store should be exported to the store
+// directory.
+func (s *Secrets) Rm(ctx context.Context, name string, opts SecretsStoreOpts) (*Directory, error) {
+	if !secretNameRe.MatchString(name) {
+		return nil, fmt.Errorf("invalid secret name %s", name)
+	}
+
+	names, err := secretsStoreNames(ctx, opts.store())
+	if err != nil {
+		return nil, err
+	}
+
+	if idx := sort.SearchStrings(names, name); idx == len(names) || names[idx] != name {
+		return nil, fmt.Errorf("secret %s not found", name)
+	}
+
+	return opts.store().WithoutFile(name + ".age"), nil
+}
+
+// store returns the store directory of the options or an empty store if not provided.
+func (opts SecretsStoreOpts) store() *Directory {
+	if opts.Store == nil {
+		return dag.Directory()
+	}
+
+	return opts.Store
+}
+
+// decryptAll decrypts all secrets in the store with the given age identity and returns them as a json object secret.
+func decryptAll(ctx context.Context, store *Directory, key *Secret) (*Secret, error) {
+	identity, err := secretsIdentity(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := secretsStoreNames(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		secrets = make(map[string]string, len(names))
+		hash    = sha256.New()
+	)
+
+	for _, name := range names {
+		ciphertext, err := store.File(name + ".age").Contents(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret %s: %w", name, err)
+		}
+
+		value, err := decryptSecret(ciphertext, identity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt secret %s: %w", name, err)
+		}
+
+		secrets[name] = value
+
+		hash.Write([]byte(ciphertext))
+	}
+
+	contents, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	return dag.SetSecret(fmt.Sprintf("gale-secrets-store-%x", hash.Sum(nil)[:8]), string(contents)), nil
+}
+
+// secretsIdentity parses the age identity from the given key.
+func secretsIdentity(ctx context.Context, key *Secret) (*age.X25519Identity, error) {
+	plaintext, err := key.Plaintext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := age.ParseX25519Identity(strings.TrimSpace(plaintext))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse age identity: %w", err)
+	}
+
+	return identity, nil
+}
+
+// decryptSecret decrypts the given age encrypted value with the identity.
+func decryptSecret(ciphertext string, identity age.Identity) (string, error) {
+	r, err := age.Decrypt(strings.NewReader(ciphertext), identity)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
+
+// secretsStoreNames returns the sorted names of the secrets in the store.
+func secretsStoreNames(ctx context.Context, store *Directory) ([]string, error) {
+	entries, err := store.Entries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets store: %w", err)
+	}
+
+	var names []string
+
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry, ".age"); ok && secretNameRe.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// secretsStoreSecretName returns the name of the engine secret of the store secret. The hash of the encrypted value is
+// part of the name, so different values of the same secret don't collide.
+func secretsStoreSecretName(name, ciphertext string) string {
+	return engineSecretName("gale-secrets-store", name, ciphertext)
+}
+
+// engineSecretName returns the name of the engine secret with the given prefix for the scope, e.g. the repository and
+// the workflow of the run. Secrets are shared by the name in the engine, so the hash of the contents is part of the
+// name to keep the runs from replacing each other's secrets.
+func engineSecretName(prefix, scope, contents string) string {
+	hash := sha256.Sum256([]byte(contents))
+
+	scope = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+
+		return '-'
+	}, scope)
+
+	return fmt.Sprintf("%s-%s-%x", prefix, scope, hash[:8])
+}
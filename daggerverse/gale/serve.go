@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// GaleServeOpts represents the options for serving gale as a webhook server.
+type GaleServeOpts struct {
+	Secret   *Secret `doc:"The secret of the GitHub webhook to validate the payload signatures. Webhooks are rejected if the secret isn't provided."`
+	Token    *Secret `doc:"The GitHub token to clone the private repositories and actions."`
+	APIToken *Secret `doc:"The bearer token required by the /runs API to trigger and query the workflow runs. The API is disabled if the token isn't provided."`
+	Module   string  `doc:"The reference of the gale module running the workflows." default:"github.com/jpadams/gale/daggerverse/gale@main"`
+
+	Workers         int `doc:"The number of the workflow runs running concurrently." default:"1"`
+	RepoConcurrency int `doc:"The maximum number of the concurrent workflow runs of a repository. Zero means unlimited." default:"0"`
+
+	Schedules []string `doc:"The repositories to run the scheduled workflows of, in owner/name format with an optional branch, e.g. aweris/gale@main. If the branch is empty, the default branch is used."`
+	CatchUp   string   `doc:"The policy for the schedules missed while the server isn't able to run them. One of skip, once or all." default:"skip"`
+	Jitter    string   `doc:"The maximum random delay before running a scheduled workflow to spread the runs scheduled at the same time, e.g. 5m."`
+
+	JournalSinks []string `doc:"Sinks to ship the console output of the workflow runs to, e.g. syslog=udp://logs.example.com:514 or webhook=https://logs.example.com/gale."`
+}
+
+// Serve returns a webhook server receiving the GitHub webhooks and running the workflows triggered by the push and pull
+// request events, e.g. dagger call serve up --ports 8080:8080. Configure the webhook to send the payloads to the
+// /webhook path with application/json content type. Scheduled workflows of the given repositories are run on their
+// cron schedules. Runs could be triggered and queried with the /runs API. Status badges of the workflows are served in
+// the /badge/<workflow>.svg path. Pending and running workflows are listed in the /status path. Workflow runs are kept
+// in the run history.
+func (g *Gale) Serve(ctx context.Context, opts GaleServeOpts) (*Service, error) {
+	container, err := dag.Source().WebhookService().Container(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Module != "" {
+		container = container.WithEnvVariable("GALE_MODULE", opts.Module)
+	}
+
+	if opts.Secret != nil {
+		container = container.WithSecretVariable("WEBHOOK_SECRET", opts.Secret)
+	}
+
+	if opts.Token != nil {
+		container = container.WithSecretVariable("GITHUB_TOKEN", opts.Token)
+	}
+
+	if opts.APIToken != nil {
+		container = container.WithSecretVariable("API_TOKEN", opts.APIToken)
+	}
+
+	if opts.Workers > 0 {
+		container = container.WithEnvVariable("WORKERS", strconv.Itoa(opts.Workers))
+	}
+
+	if opts.RepoConcurrency > 0 {
+		container = container.WithEnvVariable("REPO_CONCURRENCY", strconv.Itoa(opts.RepoConcurrency))
+	}
+
+	if len(opts.Schedules) > 0 {
+		container = container.WithEnvVariable("SCHEDULE_REPOS", strings.Join(opts.Schedules, ","))
+	}
+
+	if opts.CatchUp != "" {
+		container = container.WithEnvVariable("SCHEDULE_CATCH_UP", opts.CatchUp)
+	}
+
+	if opts.Jitter != "" {
+		container = container.WithEnvVariable("SCHEDULE_JITTER", opts.Jitter)
+	}
+
+	if len(opts.JournalSinks) > 0 {
+		container = container.WithEnvVariable("JOURNAL_SINKS", strings.Join(opts.JournalSinks, ";"))
+	}
+
+	// workflows are run with the dagger cli connected to the same engine
+	return container.
+		WithExec([]string{"webhook"}, ContainerWithExecOpts{ExperimentalPrivilegedNesting: true}).
+		AsService(), nil
+}
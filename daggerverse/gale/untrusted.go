@@ -0,0 +1,40 @@
+package main
+
+// runVolume returns the cache volume with the given name shared by the workflow runs, e.g. the actions or the metadata.
+// Steps run in the same container with the volumes, so the untrusted runs use their own volumes to keep the volumes of
+// the trusted runs intact.
+func (wr *WorkflowRun) runVolume(name string) *CacheVolume {
+	if wr.Config.Untrusted {
+		name += "-untrusted"
+	}
+
+	return dag.CacheVolume(name)
+}
+
+// execOpts returns the options of the ghx execs. Ghx needs the engine for the container steps, but the steps of the
+// untrusted workflows could reach the engine through the session of ghx, so untrusted runs have no access to it.
+func (wr *WorkflowRun) execOpts() ContainerWithExecOpts {
+	return ContainerWithExecOpts{ExperimentalPrivilegedNesting: !wr.Config.Untrusted}
+}
+
+// sandbox restricts the options of the run to evaluate an untrusted workflow, e.g. a workflow from a third-party pull
+// request. It's applied after the configuration, so neither the options nor the gale.yaml profiles could loosen it.
+//
+// Docker and kubernetes are not bound and ghx runs without the engine since they give the steps privileged access to
+// the engine, so container steps and docker actions fail. Secrets are not loaded. Every volume mounted to the runner is
+// namespaced separately, so the untrusted steps can't poison the caches, the actions, the metadata or the logs of the
+// trusted runs.
+//
+// The network is left as it is. Network modes only route the steps through a proxy, the steps could bypass it, so
+// they're not a part of the sandbox.
+func (wrc *WorkflowRunConfig) sandbox() {
+	wrc.EnableDocker = false
+	wrc.DockerSocket = nil
+	wrc.EnableK8s = false
+	wrc.SecretsFile = nil
+	wrc.SecretsStore = nil
+	wrc.SecretsStoreKey = nil
+	wrc.SecretsFrom = nil
+	wrc.SecretsEnv = nil
+	wrc.PreserveWorkspaces = nil
+}
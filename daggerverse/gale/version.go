@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ghxImage is the repository of the published ghx images.
+const ghxImage = "ghcr.io/aweris/gale/tools/ghx"
+
+// ghxProtocol is the version of the protocol between gale and ghx the module implements. It must match the protocol
+// version of the ghx binary, see the protocol package of ghx.
+const ghxProtocol = "1"
+
+// Version reports the versions of the components gale uses for the workflow runs.
+type Version struct{}
+
+// VersionComponentsOpts represents the options for reporting the component versions.
+type VersionComponentsOpts struct {
+	GhxVersion  string `doc:"The version of the published ghx image to use, e.g. v0.0.9. If empty, ghx is built from the source of the module."`
+	GhxBinary   *File  `doc:"The prebuilt ghx binary to use instead of building or pulling it. If ghx version is set as well, the binary must report the version."`
+	RunnerImage string `doc:"The image to use for the runner." default:"ghcr.io/catthehacker/ubuntu:act-latest"`
+}
+
+// Components returns the versions of the components used for the workflow runs with the given options, one component
+// per line. The ghx version is the version reported by the binary itself, so it's the version the runs would use.
+func (v *Version) Components(ctx context.Context, opts VersionComponentsOpts) (string, error) {
+	image := opts.RunnerImage
+
+	// defaults are not applied when the method is called from the module itself
+	if image == "" {
+		image = defaultRunnerImage
+	}
+
+	container, err := withGhx(ctx, dag.Container().From(image), opts.GhxVersion, opts.GhxBinary)
+	if err != nil {
+		return "", err
+	}
+
+	version, err := ghxVersion(ctx, container)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("ghx: %s (%s)\n", version, ghxOrigin(opts.GhxVersion, opts.GhxBinary != nil)))
+	sb.WriteString(fmt.Sprintf("ghx-protocol: %s\n", ghxProtocol))
+	sb.WriteString(fmt.Sprintf("runner: %s\n", image))
+	sb.WriteString("artifact-service: source\n")
+	sb.WriteString("artifact-cache-service: source\n")
+
+	return sb.String(), nil
+}
+
+// ghxOrigin returns where the ghx binary of the run comes from. A prebuilt binary has precedence over the pinned
+// version, and ghx is built from the source of the module if neither is given.
+func ghxOrigin(version string, binary bool) string {
+	switch {
+	case binary:
+		return "binary"
+	case version != "":
+		return fmt.Sprintf("%s:%s", ghxImage, version)
+	default:
+		return "source"
+	}
+}
+
+// withGhx adds the ghx binary to the container. A prebuilt binary is used as it is, so ghx doesn't require access to
+// ghcr.io or the Go toolchain, e.g. in air-gapped environments. Otherwise, if the version is empty, ghx is built from the
+// source of the module, or the binary is copied from the published ghx image of the version. If the version is set, the
+// version reported by the binary must match it, so the pinned binary can't silently drift from the requested version.
+// The protocol version of the binary must match the protocol of the module regardless of the source of the binary.
+func withGhx(ctx context.Context, container *Container, version string, binary *File) (*Container, error) {
+	if binary == nil && version == "" {
+		container = container.With(dag.Source().Ghx().Binary)
+
+		// source module is a separate dependency, so it could be pinned to a source speaking a different protocol
+		if err := checkGhxProtocol(ctx, container); err != nil {
+			return nil, err
+		}
+
+		return container, nil
+	}
+
+	if binary == nil {
+		platform, err := container.Platform(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		binary = dag.Container(ContainerOpts{Platform: platform}).From(fmt.Sprintf("%s:%s", ghxImage, version)).File("/usr/local/bin/ghx")
+	}
+
+	container = container.WithFile("/usr/local/bin/ghx", binary, ContainerWithFileOpts{Permissions: 0777})
+
+	path, err := container.EnvVariable(ctx, "PATH")
+	if err != nil {
+		return nil, err
+	}
+
+	container = container.WithEnvVariable("PATH", fmt.Sprintf("%s:/usr/local/bin", path))
+
+	// binaries built from an older or a newer source speak a different protocol, fail before running the workflow
+	if err := checkGhxProtocol(ctx, container); err != nil {
+		return nil, err
+	}
+
+	if version == "" {
+		return container, nil
+	}
+
+	reported, err := ghxVersion(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	if reported != version {
+		return nil, fmt.Errorf("ghx binary reports version %s, expected %s", reported, version)
+	}
+
+	return container, nil
+}
+
+// checkGhxProtocol returns an error if the ghx binary in the container speaks a different protocol than the module.
+// Binaries released before the protocol print their version instead of the protocol version.
+func checkGhxProtocol(ctx context.Context, container *Container) error {
+	out, err := container.WithExec([]string{"ghx", "version", "--protocol"}).Stdout(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get ghx protocol version: %w", err)
+	}
+
+	if protocol := strings.TrimSpace(out); protocol != ghxProtocol {
+		return fmt.Errorf("ghx binary speaks protocol %s, gale expects protocol %s: use a ghx version built for this version of gale", protocol, ghxProtocol)
+	}
+
+	return nil
+}
+
+// ghxVersion returns the version reported by the ghx binary in the container.
+func ghxVersion(ctx context.Context, container *Container) (string, error) {
+	out, err := container.WithExec([]string{"ghx", "version"}).Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get ghx version: %w", err)
+	}
+
+	return strings.TrimSpace(out), nil
+}
@@ -0,0 +1,33 @@
+package main
+
+// Workflow represents a workflow of a repository to run from other modules. It's a shortcut for the workflows run
+// function with the default run options, e.g. dag.Gale().Workflow("ci").Job("test").Run().Directory().
+type Workflow struct {
+	RepoOpts *WorkflowsRepoOpts // RepoOpts is the options of the repository the workflow belongs to
+	DirOpts  *WorkflowsDirOpts  // DirOpts is the options of the workflows directory
+	Name     string             // Name is the name or the path of the workflow
+	JobName  string             // JobName is the name of the job to run. If empty, all jobs are run
+}
+
+// Job returns the workflow to run only the job with the given name and its dependencies.
+func (w *Workflow) Job(job string) *Workflow {
+	return &Workflow{RepoOpts: w.RepoOpts, DirOpts: w.DirOpts, Name: w.Name, JobName: job}
+}
+
+// Run returns the workflow run with the default run options. The run is executed when one of the functions of the
+// workflow run is called, e.g. result, directory or container.
+func (w *Workflow) Run() *WorkflowRun {
+	return &WorkflowRun{
+		Config: &WorkflowRunConfig{
+			WorkflowsRepoOpts: w.RepoOpts,
+			WorkflowsDirOpts:  w.DirOpts,
+			WorkflowsRunOpts: &WorkflowsRunOpts{
+				Workflow:          w.Name,
+				Job:               w.JobName,
+				Event:             "push",
+				RunnerName:        "Gale Agent",
+				RunnerEnvironment: "github-hosted",
+			},
+		},
+	}
+}
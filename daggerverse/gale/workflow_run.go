@@ -2,20 +2,139 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
+// defaultRunnerImage is the default image to use for the runner.
+const defaultRunnerImage = "ghcr.io/catthehacker/ubuntu:act-latest"
+
+// debugEnvPath is the path of the script ghx saves the environment of the failed step to for the debug shell.
+const debugEnvPath = "/home/runner/_temp/ghx/debug/env.sh"
+
+// logPath is the path of the log of the workflow run in the runner container.
+const logPath = "/home/runner/_temp/ghx/ghx.log"
+
+// eventsSocketPath is the path of the events socket in the runner container.
+const eventsSocketPath = "/home/runner/_temp/gale/events.sock"
+
+// journalSocketPath is the path of the host syslog or journald socket in the runner container.
+const journalSocketPath = "/home/runner/_temp/gale/journal.sock"
+
+// previousRunPath is the path of the previous attempt of the workflow run in the runner container when a run from the
+// run history is run again as a new attempt.
+const previousRunPath = "/home/runner/_temp/gale/previous"
+
+// resumeRunPath is the path of the workflow run completed by the previous jobs in the runner container when the jobs of
+// the workflow run target different runners.
+const resumeRunPath = "/home/runner/_temp/gale/resume"
+
+// onCompleteScript runs the on complete hook given as the first argument with the path of the workflow run report. The
+// hook is executed directly if it's executable, otherwise it's run with sh.
+const onCompleteScript = `report=$(ls /home/runner/_temp/ghx/runs/*/workflow_run.json | head -n 1)
+if [ -x "$0" ]; then exec "$0" "$report"; else exec sh "$0" "$report"; fi`
+
+// notifyScript sends the notifications of the workflow run with ghx. The history is used to detect the recovered runs.
+const notifyScript = `run=$(dirname "$(ls /home/runner/_temp/ghx/runs/*/workflow_run.json | head -n 1)")
+exec ghx notify "$run" ` + runsHistoryPath
+
+// workspacesPath is the path of the preserved workspaces of the jobs in the runner container.
+const workspacesPath = "/home/runner/_temp/gale/workspaces"
+
+// inlineWorkflowPath is the path of the workflow given with its file or content in the runner container.
+const inlineWorkflowPath = "/home/runner/_temp/gale/workflow.yml"
+
+// uploadArtifactsPath is the path of the artifacts of the local artifact service in the runner container.
+const uploadArtifactsPath = "/home/runner/_temp/gale/artifacts"
+
+// uploadArtifactsScript uploads the artifacts of the completed workflow run to the GitHub run running gale.
+const uploadArtifactsScript = `run=$(basename "$(dirname "$(ls /home/runner/_temp/ghx/runs/*/workflow_run.json | head -n 1)")")
+exec ghx upload-artifacts "` + uploadArtifactsPath + `/$run"`
+
 // WorkflowsRunOpts represents the options for running a workflow.
 type WorkflowsRunOpts struct {
-	Workflow    string  `doc:"The workflow to run." required:"true"`
-	Job         string  `doc:"The job name to run. If empty, all jobs will be run."`
-	Event       string  `doc:"Name of the event that triggered the workflow. e.g. push" default:"push"`
-	EventFile   *File   `doc:"The file with the complete webhook event payload."`
-	RunnerImage string  `doc:"The image to use for the runner." default:"ghcr.io/catthehacker/ubuntu:act-latest"`
-	RunnerDebug bool    `doc:"Enable debug mode." default:"false"`
-	Token       *Secret `doc:"The GitHub token to use for authentication."`
+	Workflow             string     `doc:"The workflow to run. Use - to run the workflow given with the workflow file or the workflow content option. Required unless multiple workflows are run with run many."`
+	WorkflowFile         *File      `doc:"The workflow file to run instead of a workflow of the repository, e.g. a generated workflow. Requires the workflow option to be -."`
+	WorkflowContent      string     `doc:"The content of the workflow to run instead of a workflow of the repository, e.g. --workflow-content \"$(generate-workflow)\". Requires the workflow option to be -."`
+	Job                  string     `doc:"The job name to run. If empty, all jobs will be run."`
+	Matrix               []string   `doc:"Matrix combinations to run. Format: key=value, e.g. go=1.21. Combinations should match one of the given values of each key."`
+	Event                string     `doc:"Name of the event that triggered the workflow. e.g. push" default:"push"`
+	EventFile            *File      `doc:"The file with the complete webhook event payload. If not provided, a default payload is generated for the event from the repository."`
+	RunnerImage          string     `doc:"The image to use for the runner. Defaults to the runner image of the configuration, or ghcr.io/catthehacker/ubuntu:act-latest."`
+	GhxVersion           string     `doc:"The version of the published ghx image to use, e.g. v0.0.9. If empty, ghx is built from the source of the module."`
+	GhxBinary            *File      `doc:"The prebuilt ghx binary to use instead of building or pulling it, e.g. for air-gapped environments. Build it with the ghx build function of the source module. If ghx version is set as well, the binary must report the version."`
+	RegistryMirrors      []string   `doc:"Mirrors of the registries to pull the images from. Format: registry=mirror, e.g. docker.io=mirror.example.com. Images without a registry are Docker Hub images."`
+	ImageOverrides       []string   `doc:"Replacements of the images, applied before the registry mirrors. Format: image=replacement, e.g. node:16=registry.example.com/node:16"`
+	PullPolicy           string     `doc:"The pull policy of the step images. Possible values are: always, if-not-present, uses the images pinned by actions prefetch if available. Defaults to always."`
+	HttpProxy            string     `doc:"The HTTP proxy of the runner and the step containers, e.g. http://proxy.example.com:3128."`
+	HttpsProxy           string     `doc:"The HTTPS proxy of the runner and the step containers, e.g. http://proxy.example.com:3128."`
+	NoProxy              string     `doc:"Comma separated list of the hosts to access without the proxy. The services of gale are always excluded."`
+	CaCerts              []*File    `doc:"Extra CA certificates in PEM format to install to the trust store of the runner and the step containers, e.g. the CA of a TLS intercepting proxy."`
+	Network              string     `doc:"Network mode of the steps. Possible values are: full, restricted, allows only the gale services, GitHub hosts and the network allowlist, none, allows only the gale services. Steps are routed through a proxy with the proxy environment variables, so the modes catch the unexpected network access of the builds, but steps ignoring the variables bypass them. Defaults to full."`
+	NetworkJobs          []string   `doc:"Network modes of the jobs overriding the network mode of the run. Format: job=mode, e.g. build=restricted"`
+	NetworkAllowlist     []string   `doc:"Domains allowed in the restricted network mode in addition to the GitHub hosts, e.g. proxy.golang.org. Subdomains are allowed as well."`
+	ToolLayers           bool       `doc:"Prepare the tools of setup-go, setup-node, setup-python and setup-java steps with pinned versions from the official images of the tools instead of downloading them. Tools are kept in the tool cache." default:"false"`
+	CloneCheckout        bool       `doc:"Clone the repository in the actions/checkout steps of the workflow ref as they are instead of reusing the repository mounted to the workspace." default:"false"`
+	WorkspaceMode        string     `doc:"Sharing mode of the workspace between the jobs. Possible values are: shared, jobs continue with the workspace left by the previous jobs, isolated, each job starts with the workspace as it's at the start of the run. Defaults to shared."`
+	PreserveWorkspaces   []string   `doc:"Jobs keeping their workspace between the runs for debugging, like clean: false of the self-hosted runners. Workspaces are kept in a cache volume namespaced with the cache namespace."`
+	FilesReport          bool       `doc:"Report the files created, modified or deleted by each step in the step run reports. The workspace, the tool cache and /tmp are watched." default:"false"`
+	FilesReportPaths     []string   `doc:"Additional paths in the runner to watch for the files report."`
+	RunnerManifest       *File      `doc:"The runner.yaml manifest to customize the runner image with extra packages, tools and provisioning scripts."`
+	RunnerProfile        string     `doc:"The build profile of the runner image. Supported profiles: full, installs the common tools of GitHub hosted runners."`
+	RunnerName           string     `doc:"The name of the runner exposed with the runner context." default:"Gale Agent"`
+	RunnerEnvironment    string     `doc:"The environment of the runner exposed with the runner context. Possible values are github-hosted or self-hosted." default:"github-hosted"`
+	RunnerDebug          bool       `doc:"Enable debug mode." default:"false"`
+	CacheNamespace       string     `doc:"Namespace for the cache volumes used to persist tool cache between runs. Defaults to repository name with owner."`
+	Offline              bool       `doc:"Fail fast with the list of actions, images and tools not available in the caches instead of downloading them. Use actions prefetch to populate the caches." default:"false"`
+	RunnerLabels         []string   `doc:"Mapping of runs-on labels to runner images. Format: label=image, e.g. ubuntu-22.04=ghcr.io/catthehacker/ubuntu:act-22.04. Matrix values in labels, e.g. ${{ matrix.os }}, are resolved for the selected matrix combinations. Jobs targeting different runners run in the containers of their runners."`
+	RunnerPlatforms      []string   `doc:"Mapping of runs-on labels to runner platforms. Format: label=platform, e.g. self-hosted-arm=linux/arm64"`
+	EnableDocker         bool       `doc:"Bind a docker engine to the runner for the steps using docker directly. Uses a nested docker engine unless docker socket is provided." default:"false"`
+	DockerSocket         *Socket    `doc:"Docker socket of the host to use instead of a nested docker engine. Implies enable docker option."`
+	EnableK8s            bool       `doc:"Start a k3s cluster as a service and expose it to the jobs with the KUBECONFIG environment variable." default:"false"`
+	Limits               []string   `doc:"Resource limits of the jobs. Format: job=cpu:4,mem:8g. Memory limit requires a writable cgroup v2 in the engine."`
+	Retries              []string   `doc:"Retry policies of the steps. Format: step=max:3,backoff:10s,timeout:5m,on:failure|timeout. Steps are selected with their id or name, optionally prefixed with the job id, e.g. test/integration."`
+	Gpus                 []string   `doc:"GPUs to expose to the jobs in job=gpu format, e.g. train=all or train=0,train=1. GPUs without a job are exposed to all jobs. Jobs requesting different GPUs run in separate runner containers. Requires GPU support in the dagger engine."`
+	Platform             string     `doc:"Platform of the runner container, e.g. linux/arm64. Overrides the platform selected with runner platforms. Non-native platforms run under emulation."`
+	PullRequest          string     `doc:"Simulate a pull request run. Pull request number to use the merge ref from GitHub or head branch to merge into the checked out branch. Merging a branch requires the .git directory of the repository with the full history."`
+	Config               *File      `doc:"The gale.yaml configuration file. Defaults to gale.yaml in the repository root if exists."`
+	Profile              string     `doc:"The profile of the gale.yaml configuration to apply. Options provided with the run have precedence over the configuration."`
+	SecretsFile          *File      `doc:"The file with the secrets of the workflow run. The format is selected by the extension, json and yaml files are parsed as maps and the rest as dotenv. SOPS encrypted files are decrypted with sops."`
+	SecretsStore         *Directory `doc:"The secrets store directory of the repository, e.g. ~/.gale/secrets/owner/name. Secrets in the store are decrypted with the secrets store key and loaded to the secrets context."`
+	SecretsStoreKey      *Secret    `doc:"The age identity to decrypt the secrets in the secrets store with."`
+	SecretsFrom          []string   `doc:"Commands printing the secrets in json or dotenv format, e.g. vault kv get -format=json -field=data secret/app. Commands run in the runner container, vault is mounted for the commands calling it."`
+	SecretsEnv           *Secret    `doc:"Environment variables of the host in KEY=VALUE lines, e.g. cmd:'env | grep ^GALE_SECRET_'. Variables with the GALE_SECRET_ prefix are loaded as secrets, the host environment doesn't reach the runner otherwise."`
+	EnvironmentApproval  bool       `doc:"Ask for a manual approval before running the jobs referencing an environment. Requires the interactive terminal unless the environment is approved in advance." default:"false"`
+	ApprovedEnvironments []string   `doc:"Environments approved in advance, so the jobs referencing them run without asking for approval, e.g. in CI. Glob patterns are supported, e.g. * approves all environments."`
+	Deployments          bool       `doc:"Create GitHub deployments and deployment statuses for the jobs referencing an environment, so the tools watching the environments see the run. Requires a token with the deployments permission." default:"false"`
+	ChangedPaths         []string   `doc:"Paths changed since the last run. If set, the workflow runs only if the changed paths match the path filters of the event."`
+	UploadArtifactsToken *Secret    `doc:"The ACTIONS_RUNTIME_TOKEN of the GitHub Actions job running gale. If provided with the upload artifacts url, the artifacts of the run are uploaded to the GitHub run after the run completes, so the downstream jobs can download them."`
+	UploadArtifactsUrl   string     `doc:"The ACTIONS_RESULTS_URL of the GitHub Actions job running gale to upload the artifacts of the run to."`
+	OnComplete           string     `doc:"The script in the repository to run after the workflow run completes, e.g. ./scripts/notify.sh. The path of the workflow run report is passed as the first argument."`
+	Report               []string   `doc:"Reports to render for the workflow run. Format: format=path, e.g. html=out/. Supported formats: html. Use report directory to export the rendered reports."`
+	RequirePinnedActions bool       `doc:"Fail the workflow run if any of the remote actions is not pinned to a full length commit SHA." default:"false"`
+	CheckArchivedActions bool       `doc:"Warn about the actions from archived repositories. Repositories are checked with the GitHub API once a day, the check is skipped in offline mode." default:"false"`
+	ActionsDenylist      []string   `doc:"Actions to warn about when used in the workflow, e.g. some-org/* or actions/checkout@v1."`
+	ActionsAllow         []string   `doc:"Actions allowed to run, e.g. actions/* or my-org/*. Actions not matching any of the patterns are refused with a policy error. Local actions are always allowed."`
+	ActionsDeny          []string   `doc:"Actions refused to run with a policy error, e.g. */setup-random@*. Deny has precedence over allow."`
+	OverridePolicy       bool       `doc:"Run the actions not allowed by the actions policy with a warning instead of refusing them, for local experimentation." default:"false"`
+	EventsSocket         *Socket    `doc:"The unix socket to stream the execution events as newline delimited JSON while the workflow is running, e.g. workflow, job and step start and completion events and the log lines of the steps."`
+	Output               string     `doc:"Format of the console output of the workflow run. Possible values are: text, ndjson, prints the workflow, job and step lifecycle events with their conclusions and timings as newline delimited JSON instead of the logs, so the wrappers could build their own UIs. Logs are printed to stderr with ndjson. Defaults to text."`
+	FailOn               string     `doc:"The policy failing the workflow run result. Possible values are: error, fails on the failed workflow or error annotations, warning, fails on warning annotations as well, none, never fails. Defaults to error."`
+	Env                  []string   `doc:"Environment variables to inject to the workflow run without changing the workflow. Format: KEY=VALUE. Overrides the workflow env, job and step env have precedence unless env precedence is changed."`
+	EnvFile              *File      `doc:"The dotenv file with the environment variables to inject to the workflow run, e.g. .env.ci. Variables of the env option have precedence over the file."`
+	EnvPrecedence        string     `doc:"The env blocks the injected environment variables have precedence over. Possible values are: workflow, overrides the workflow env, job, overrides the workflow and the job env, step, overrides all env blocks. Defaults to workflow."`
+	RetentionDays        string     `doc:"Number of days to keep the workflow run in the run history with its artifacts and logs. Expired runs are pruned after each run. Zero keeps the run forever. Defaults to 90."`
+	RunsMaxSize          string     `doc:"Maximum total size of the run history with the artifacts and the logs of the runs, e.g. 10g. Oldest runs are pruned after each run until the history fits the size."`
+	ApiAudit             bool       `doc:"Route the GitHub API calls of the steps through an audit proxy and record the method, the path and the status of each call in the workflow run report. Tokens are redacted and bodies aren't recorded." default:"false"`
+	JournalSinks         []string   `doc:"Sinks to ship the console output of the workflow run to, in addition to the journal file. Format: type=address, e.g. syslog=udp://logs.example.com:514, journald, webhook=https://logs.example.com/gale, console=stdout or file=/path/journal.ndjson. syslog and journald use the journal socket if the address is omitted."`
+	JournalSocket        *Socket    `doc:"The host syslog or journald socket for the syslog and journald journal sinks, e.g. /dev/log or /run/systemd/journal/socket."`
+	Attempt              string     `doc:"The id of a workflow run in the run history to run again as its next attempt. The new attempt keeps the run id and the run number, GITHUB_RUN_ATTEMPT and github.run_attempt are bumped and the reports of the previous attempts are kept side by side in the attempts directory of the run."`
+	CacheJobs            bool       `doc:"Return the result of a previous run from the engine cache instead of running the workflow again if the repository source, the workflow, the options, the commit SHAs resolved from the action refs and the secrets are unchanged. Combine with the job option to cache each job separately. Can't be used with the host sockets and preserved workspaces." default:"false"`
+	Untrusted            bool       `doc:"Evaluate the workflow as untrusted code, e.g. a workflow from a third-party pull request. Docker and kubernetes are not bound and ghx runs without access to the engine, so container steps and docker actions fail. The secrets context and GITHUB_TOKEN are empty and the steps work on a copy of the repository discarded after the run, with volumes separated from the trusted runs." default:"false"`
 }
 
 // WorkflowRunDirectoryOpts represents the options for exporting a workflow run.
@@ -26,11 +145,22 @@ type WorkflowRunDirectoryOpts struct {
 	IncludeArtifacts bool `doc:"Include the artifacts in the exported directory." default:"false"`
 }
 
+// WorkflowRunBreakpointOpts represents the options for stopping a workflow run before a step.
+type WorkflowRunBreakpointOpts struct {
+	BreakAt string `doc:"The id or name of the step to stop the workflow run before."`
+}
+
 // WorkflowRunConfig represents the configuration for running a workflow.
 type WorkflowRunConfig struct {
 	*WorkflowsRepoOpts
 	*WorkflowsDirOpts
 	*WorkflowsRunOpts
+
+	debugShell    bool              // debugShell stops the run at the first failed step to open a debug shell in the same state
+	breakAt       string            // breakAt is the id or name of the step to stop the run before
+	configLoaded  bool              // configLoaded indicates the gale.yaml configuration is applied to the options
+	env           map[string]string // env is the environment variables of the runner from the configuration
+	notifications []notifyConfig    // notifications is the notifiers of the workflow run completion from the configuration
 }
 
 type WorkflowRun struct {
@@ -41,44 +171,196 @@ type WorkflowRun struct {
 
 // WorkflowRunReport represents the result of a workflow run.
 type WorkflowRunReport struct {
-	Ran           bool   `json:"ran"`            // Ran indicates if the execution ran
-	Duration      string `json:"duration"`       // Duration of the execution
-	Name          string `json:"name"`           // Name is the name of the workflow
-	Path          string `json:"path"`           // Path is the path of the workflow
-	RunID         string `json:"run_id"`         // RunID is the ID of the run
-	RunNumber     string `json:"run_number"`     // RunNumber is the number of the run
-	RunAttempt    string `json:"run_attempt"`    // RunAttempt is the attempt number of the run
-	RetentionDays string `json:"retention_days"` // RetentionDays is the number of days to keep the run logs
-	Conclusion    string `json:"conclusion"`     // Conclusion is the result of a completed workflow run after continue-on-error is applied
-}
-
-// Result returns executes the workflow run and returns the result.
+	Ran           bool         `json:"ran"`            // Ran indicates if the execution ran
+	Duration      string       `json:"duration"`       // Duration of the execution
+	StartedAt     string       `json:"started_at"`     // StartedAt is the time the execution started
+	CompletedAt   string       `json:"completed_at"`   // CompletedAt is the time the execution completed
+	Name          string       `json:"name"`           // Name is the name of the workflow
+	Path          string       `json:"path"`           // Path is the path of the workflow
+	RunID         string       `json:"run_id"`         // RunID is the ID of the run
+	RunNumber     string       `json:"run_number"`     // RunNumber is the number of the run
+	RunAttempt    string       `json:"run_attempt"`    // RunAttempt is the attempt number of the run
+	RetentionDays string       `json:"retention_days"` // RetentionDays is the number of days to keep the run logs
+	Conclusion    string       `json:"conclusion"`     // Conclusion is the result of a completed workflow run after continue-on-error is applied
+	Annotations   []Annotation `json:"annotations"`    // Annotations is the list of error and warning annotations created by the steps
+}
+
+// Annotation represents an error or warning annotation created by a step of the workflow run.
+type Annotation struct {
+	Level   string `json:"level"`   // Level is the level of the annotation. Possible values are: error, warning.
+	Message string `json:"message"` // Message is the message of the annotation
+	File    string `json:"file"`    // File is the file the annotation is created for
+	Line    string `json:"line"`    // Line is the start line of the annotation
+	Job     string `json:"job"`     // Job is the name of the job the annotation is created in
+	Step    string `json:"step"`    // Step is the name of the step the annotation is created in
+}
+
+// Result returns executes the workflow run and returns the result. It returns an error if the result violates the fail
+// on policy of the run, so the workflow run fails the calling process as well.
 func (wr *WorkflowRun) Result(ctx context.Context) (string, error) {
 	container, err := wr.run(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	var result WorkflowRunReport
+	result, err := report(ctx, container)
+	if err != nil {
+		return "", err
+	}
+
+	summary := fmt.Sprintf("Workflow %s (run %s) completed with conclusion %s in %s", result.Name, result.RunID, result.Conclusion, result.Duration)
 
-	runs := container.Directory("/home/runner/_temp/ghx/runs")
+	if err := checkFailOn(wr.Config.FailOn, result); err != nil {
+		return "", fmt.Errorf("%s: %w", summary, err)
+	}
+
+	return summary, nil
+}
 
-	// runs directory should only have one entry with the workflow run id
-	entries, err := runs.Entries(ctx)
+// triggerResult executes the workflow run and returns its result. Errors are reported in the result instead of failing,
+// so the results of the other workflows triggered by the same event are still returned.
+func (wr *WorkflowRun) triggerResult(ctx context.Context, name string) triggerResult {
+	container, err := wr.run(ctx)
 	if err != nil {
-		return "", err
+		return triggerResult{Name: name, Conclusion: "failure", Error: err.Error()}
+	}
+
+	runReport, err := report(ctx, container)
+	if err != nil {
+		return triggerResult{Name: name, Conclusion: "failure", Error: err.Error()}
+	}
+
+	result := triggerResult{Name: runReport.Name, RunID: runReport.RunID, Conclusion: runReport.Conclusion, Duration: runReport.Duration}
+
+	if err := checkFailOn(wr.Config.FailOn, runReport); err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// checkFailOn returns an error if the workflow run report violates the given fail on policy.
+func checkFailOn(policy string, result *WorkflowRunReport) error {
+	var (
+		counts = make(map[string]int)
+		seen   = make(map[Annotation]bool)
+	)
+
+	// annotations repeated by the retried steps or the resumed runs are counted once
+	for _, annotation := range result.Annotations {
+		if seen[annotation] {
+			continue
+		}
+
+		seen[annotation] = true
+		counts[annotation.Level]++
+	}
+
+	switch policy {
+	case "none":
+		return nil
+	case "", "error":
+		if result.Conclusion == "failure" || counts["error"] > 0 {
+			return fmt.Errorf("workflow run failed with %d error annotation(s)", counts["error"])
+		}
+	case "warning":
+		if result.Conclusion == "failure" || counts["error"] > 0 || counts["warning"] > 0 {
+			return fmt.Errorf("workflow run failed with %d error and %d warning annotation(s)", counts["error"], counts["warning"])
+		}
+	default:
+		return fmt.Errorf("unsupported fail on policy %s, possible values are: error, warning, none", policy)
+	}
+
+	return nil
+}
+
+// DebugShell executes the workflow run until the first failed step or the breakpoint and opens an interactive terminal
+// in the state of the step with its environment, working directory and PATH.
+func (wr *WorkflowRun) DebugShell(ctx context.Context, opts WorkflowRunBreakpointOpts) (*Terminal, error) {
+	wr.Config.debugShell = true
+	wr.Config.breakAt = opts.BreakAt
+
+	container, err := wr.run(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	wrID := entries[0]
+	result, err := report(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Conclusion != "failure" {
+		if opts.BreakAt != "" {
+			return nil, fmt.Errorf("workflow %s completed with conclusion %s before reaching step %s", result.Name, result.Conclusion, opts.BreakAt)
+		}
+
+		return nil, fmt.Errorf("workflow %s completed with conclusion %s, there is no failed step to debug", result.Name, result.Conclusion)
+	}
+
+	return container.Terminal(ContainerTerminalOpts{Cmd: []string{"bash", "--rcfile", debugEnvPath, "-i"}}), nil
+}
+
+// Interactive opens an interactive terminal running the workflow step by step. Before each step, the user is asked to
+// continue, skip the step, open a shell or abort the workflow. If the break at option is set, steps run without pausing
+// until the step is reached.
+func (wr *WorkflowRun) Interactive(ctx context.Context, opts WorkflowRunBreakpointOpts) (*Terminal, error) {
+	wr.Config.breakAt = opts.BreakAt
+
+	jobs, err := wr.runners(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the terminal is attached to a single runner container, so the jobs can't switch between the runners
+	runner := sharedRunner(jobs)
+	if runner == nil {
+		return nil, fmt.Errorf("jobs of workflow %s target different runners, run them one by one with the job option", wr.Config.Workflow)
+	}
+
+	container, err := wr.prepare(ctx, runner)
+	if err != nil {
+		return nil, err
+	}
+
+	container = container.WithEnvVariable("GHX_INTERACTIVE", "true")
+
+	// like the execs, the terminal of the untrusted runs has no access to the engine
+	return container.Terminal(ContainerTerminalOpts{Cmd: []string{"ghx"}, ExperimentalPrivilegedNesting: !wr.Config.Untrusted}), nil
+}
+
+// report returns the report of the workflow run executed in the given container.
+func report(ctx context.Context, container *Container) (*WorkflowRunReport, error) {
+	var result WorkflowRunReport
+
+	wrID, err := workflowRunID(ctx, container)
+	if err != nil {
+		return nil, err
+	}
 
 	resultJSON := filepath.Join("/home/runner/_temp/ghx/runs", wrID, "workflow_run.json")
 
 	err = container.File(resultJSON).unmarshalContentsToJSON(ctx, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// workflowRunID returns the id of the workflow run executed in the given container. Runs directory should only have
+// one entry with the workflow run id, it's empty if ghx failed before starting the run.
+func workflowRunID(ctx context.Context, container *Container) (string, error) {
+	entries, err := container.Directory("/home/runner/_temp/ghx/runs").Entries(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	return fmt.Sprintf("Workflow %s completed with conclusion %s in %s", result.Name, result.Conclusion, result.Duration), nil
+	if len(entries) == 0 {
+		return "", errors.New("no workflow run found in the runner, ghx failed before starting the run")
+	}
+
+	return entries[0], nil
 }
 
 // Directory returns the directory of the workflow run information.
@@ -88,17 +370,12 @@ func (wr *WorkflowRun) Directory(ctx context.Context, opts WorkflowRunDirectoryO
 		return nil, err
 	}
 
-	runs := container.Directory("/home/runner/_temp/ghx/runs")
-
-	// runs directory should only have one entry with the workflow run id
-	entries, err := runs.Entries(ctx)
+	wrID, err := workflowRunID(ctx, container)
 	if err != nil {
 		return nil, err
 	}
 
-	wrID := entries[0]
-
-	dir := dag.Directory().WithDirectory("runs", runs)
+	dir := dag.Directory().WithDirectory("runs", container.Directory("/home/runner/_temp/ghx/runs"))
 
 	if opts.IncludeRepo {
 		dir = dir.WithDirectory(fmt.Sprintf("runs/%s/repo", wrID), container.Directory("."))
@@ -108,23 +385,257 @@ func (wr *WorkflowRun) Directory(ctx context.Context, opts WorkflowRunDirectoryO
 		dir = dir.WithDirectory(fmt.Sprintf("runs/%s/secrets", wrID), container.Directory("/home/runner/_temp/ghx/secrets"))
 	}
 
-	if opts.IncludeEvent && wr.Config.EventFile != nil {
-		dir = dir.WithFile(fmt.Sprintf("runs/%s/event.json", wrID), container.File(filepath.Join("/home", "runner", "work", "_temp", "_github_workflow", "event.json")))
+	if opts.IncludeEvent {
+		dir = dir.WithFile(fmt.Sprintf("runs/%s/event.json", wrID), container.File(eventPath))
 	}
 
 	if opts.IncludeArtifacts {
-		container = dag.Container().From("alpine:latest").
-			WithMountedCache("/artifacts", dag.Source().ArtifactService().CacheVolume()).
-			WithExec([]string{"cp", "-r", fmt.Sprintf("/artifacts/%s", wrID), "/exported_artifacts"})
+		dir = dir.WithDirectory(fmt.Sprintf("runs/%s/artifacts", wrID), artifacts(wrID))
+	}
+
+	return dir, nil
+}
 
-		dir = dir.WithDirectory(fmt.Sprintf("runs/%s/artifacts", wrID), container.Directory("/exported_artifacts"))
+// ReportDirectory executes the workflow run and returns the directory with the rendered reports of the run under the
+// paths given with the report option, e.g. out/report.html for html=out/.
+func (wr *WorkflowRun) ReportDirectory(ctx context.Context) (*Directory, error) {
+	if len(wr.Config.Report) == 0 {
+		return nil, errors.New("no report is configured, use the report option to render a report, e.g. html=out/")
+	}
+
+	container, err := wr.run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wrID, err := workflowRunID(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := dag.Directory()
+
+	for _, report := range wr.Config.Report {
+		format, path, _ := strings.Cut(report, "=")
+
+		switch format {
+		case "html":
+			dir = dir.WithFile(filepath.Join(path, "report.html"), container.File(filepath.Join("/home/runner/_temp/ghx/runs", wrID, "report.html")))
+		default:
+			return nil, fmt.Errorf("unsupported report format %s, supported formats: html", format)
+		}
 	}
 
 	return dir, nil
 }
 
-func (wr *WorkflowRun) run(ctx context.Context) (*Container, error) {
-	container, err := wr.container(ctx)
+// reportFormats returns the formats of the given reports in format=path format.
+func reportFormats(reports []string) []string {
+	formats := make([]string, 0, len(reports))
+
+	for _, report := range reports {
+		format, _, _ := strings.Cut(report, "=")
+		formats = append(formats, format)
+	}
+
+	return formats
+}
+
+// Workspace executes the workflow run and returns the workspace directory with the files produced by the run.
+func (wr *WorkflowRun) Workspace(ctx context.Context) (*Directory, error) {
+	container, err := wr.run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return container.Directory("."), nil
+}
+
+// Container executes the workflow run and returns the runner container in the state after the run.
+func (wr *WorkflowRun) Container(ctx context.Context) (*Container, error) {
+	return wr.run(ctx)
+}
+
+// Artifacts executes the workflow run and returns the directory of the artifacts uploaded during the run.
+func (wr *WorkflowRun) Artifacts(ctx context.Context) (*Directory, error) {
+	container, err := wr.run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wrID, err := workflowRunID(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	return artifacts(wrID), nil
+}
+
+// artifacts returns the directory of the artifacts uploaded during the workflow run with the given id.
+func artifacts(runID string) *Directory {
+	return dag.Container().From("alpine:latest").
+		WithMountedCache("/artifacts", dag.Source().ArtifactService().CacheVolume()).
+		WithExec([]string{"sh", "-c", copyArtifactsScript("/artifacts", "/exported_artifacts", runID)}).
+		Directory("/exported_artifacts")
+}
+
+// copyArtifactsScript returns the script copying the artifacts of the workflow run with the given id from the artifacts
+// directory to the target directory. Target directory is empty if the run didn't upload any artifacts.
+func copyArtifactsScript(dir, target, runID string) string {
+	return fmt.Sprintf("mkdir -p %[2]s && cp -r %[1]s/%[3]s/. %[2]s/ 2>/dev/null || true", dir, target, runID)
+}
+
+// run executes ghx with the given arguments in the workflow run container and returns the container.
+func (wr *WorkflowRun) run(ctx context.Context, args ...string) (*Container, error) {
+	jobs, err := wr.runners(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var container *Container
+
+	// commands other than running the workflow don't run the jobs, so any of the runners is enough for them
+	switch runner := sharedRunner(jobs); {
+	case runner != nil || len(args) > 0:
+		if runner == nil {
+			runner = jobs[0].Runner
+		}
+
+		container, err = wr.prepare(ctx, runner)
+		if err != nil {
+			return nil, err
+		}
+
+		container = container.WithExec(ghxCommand(args...), wr.execOpts())
+	default:
+		container, err = wr.runJobs(ctx, jobs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// keep the workflow run in the history to browse it later, lint and prefetch don't execute a workflow run to keep
+	if len(args) == 0 {
+		container = withRunsHistory(container, wr.Config.RunsMaxSize)
+	}
+
+	// upload the artifacts to the GitHub run, the runtime token is only valid in the GitHub job running gale
+	if wr.Config.UploadArtifactsToken != nil || wr.Config.UploadArtifactsUrl != "" {
+		if wr.Config.UploadArtifactsToken == nil || wr.Config.UploadArtifactsUrl == "" {
+			return nil, errors.New("upload artifacts token and upload artifacts url are required together")
+		}
+
+		container = container.
+			WithMountedCache(uploadArtifactsPath, dag.Source().ArtifactService().CacheVolume(), ContainerWithMountedCacheOpts{Sharing: Shared}).
+			WithSecretVariable("GHX_UPLOAD_RUNTIME_TOKEN", wr.Config.UploadArtifactsToken).
+			WithEnvVariable("GHX_UPLOAD_RESULTS_URL", wr.Config.UploadArtifactsUrl).
+			WithExec([]string{"sh", "-c", uploadArtifactsScript})
+	}
+
+	// run the completion hook regardless of the conclusion of the workflow run, ghx doesn't fail when the workflow fails
+	if wr.Config.OnComplete != "" {
+		container = container.WithExec([]string{"sh", "-c", onCompleteScript, wr.Config.OnComplete})
+	}
+
+	// notify the configured destinations, webhook urls are credentials, so they're passed as a secret
+	if len(wr.Config.notifications) > 0 {
+		data, err := json.Marshal(wr.Config.notifications)
+		if err != nil {
+			return nil, err
+		}
+
+		container = container.
+			WithSecretVariable("GHX_NOTIFICATIONS", dag.SetSecret(engineSecretName("gale-notifications", wr.Config.secretScope(), string(data)), string(data))).
+			WithExec([]string{"sh", "-c", notifyScript})
+	}
+
+	// unloading request scoped configs
+	container = container.WithoutEnvVariable("GHX_WORKFLOW")
+	container = container.WithoutEnvVariable("GHX_WORKFLOW_FILE")
+	container = container.WithoutEnvVariable("GHX_JOB")
+	container = container.WithoutEnvVariable("GHX_WORKFLOWS_DIR")
+
+	return container, nil
+}
+
+// ghxCommand returns the command executing ghx with the given arguments. The output is appended to the log of the run,
+// so the jobs running in different runner containers share the same log.
+func ghxCommand(args ...string) []string {
+	script := fmt.Sprintf(`ghx "$@" | tee -a %s`, logPath)
+
+	return append([]string{"bash", "-o", "pipefail", "-c", script, "ghx"}, args...)
+}
+
+// runJobs runs each job in the container of its runner in order and returns the container of the last job. Each job
+// continues the workflow run of the previous job, so the jobs share the run id, the results of the needed jobs, the
+// reports and the log of the run. In debug shell mode, the run stops at the job failing or reaching the breakpoint.
+func (wr *WorkflowRun) runJobs(ctx context.Context, jobs []jobRunner) (*Container, error) {
+	var container *Container
+
+	for _, job := range jobs {
+		next, err := wr.prepare(ctx, job.Runner)
+		if err != nil {
+			return nil, err
+		}
+
+		next = next.WithEnvVariable("GHX_JOB", job.Job)
+
+		if container != nil {
+			wrID, err := workflowRunID(ctx, container)
+			if err != nil {
+				return nil, err
+			}
+
+			next = next.
+				WithMountedDirectory(resumeRunPath, container.Directory(filepath.Join("/home/runner/_temp/ghx/runs", wrID))).
+				WithEnvVariable("GHX_RESUME_RUN", resumeRunPath).
+				WithFile(logPath, container.File(logPath))
+
+			// the previous attempt of the run is already kept in the resumed run by the first job
+			next = next.WithoutEnvVariable("GHX_PREVIOUS_RUN")
+		}
+
+		container = next.WithExec(ghxCommand(), wr.execOpts())
+
+		if !wr.Config.debugShell {
+			continue
+		}
+
+		result, err := report(ctx, container)
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Conclusion == "failure" || result.Conclusion == "cancelled" {
+			break
+		}
+	}
+
+	return container, nil
+}
+
+// runners prepares the configuration of the workflow run and returns the jobs of the run with their runners.
+func (wr *WorkflowRun) runners(ctx context.Context) ([]jobRunner, error) {
+	if err := wr.Config.validateWorkflow(); err != nil {
+		return nil, err
+	}
+
+	// configuration needs to be applied before the runner selection
+	if err := wr.loadConfig(ctx); err != nil {
+		return nil, err
+	}
+
+	// restrictions of the untrusted workflows have precedence over the options and the configuration
+	if wr.Config.Untrusted {
+		wr.Config.sandbox()
+	}
+
+	return wr.jobRunners(ctx)
+}
+
+// prepare returns the workflow run container of the given runner configured to execute ghx.
+func (wr *WorkflowRun) prepare(ctx context.Context, runner *runner) (*Container, error) {
+	container, err := wr.container(ctx, runner)
 	if err != nil {
 		return nil, err
 	}
@@ -137,36 +648,102 @@ func (wr *WorkflowRun) run(ctx context.Context) (*Container, error) {
 	// ghx specific directory configuration
 	container = container.WithEnvVariable("GHX_HOME", "/home/runner/_temp/ghx")
 	container = container.WithMountedDirectory("/home/runner/_temp/ghx", dag.Directory())
-	container = container.WithMountedCache("/home/runner/_temp/ghx/metadata", dag.CacheVolume("gale-metadata"), ContainerWithMountedCacheOpts{Sharing: Shared})
-	container = container.WithMountedCache("/home/runner/_temp/ghx/actions", dag.CacheVolume("gale-actions"), ContainerWithMountedCacheOpts{Sharing: Shared})
+	container = container.WithMountedCache("/home/runner/_temp/ghx/metadata", wr.runVolume("gale-metadata"), ContainerWithMountedCacheOpts{Sharing: Shared})
+	container = container.WithMountedCache("/home/runner/_temp/ghx/actions", wr.runVolume("gale-actions"), ContainerWithMountedCacheOpts{Sharing: Shared})
+
+	// record the console output to the live logs, so the run could be followed from another session
+	container = container.WithMountedCache(liveLogsPath, wr.runVolume("gale-logs"), ContainerWithMountedCacheOpts{Sharing: Shared})
+	container = container.WithEnvVariable("GHX_LOGS_DIR", liveLogsPath)
 
-	// workaround for disabling cache
-	container = container.WithEnvVariable("CACHE_BUSTER", time.Now().Format(time.RFC3339Nano))
+	// stream the execution events to the host while the workflow is running
+	if wr.Config.EventsSocket != nil {
+		container = container.WithUnixSocket(eventsSocketPath, wr.Config.EventsSocket)
+		container = container.WithEnvVariable("GHX_EVENTS_SOCKET", eventsSocketPath)
+	}
 
-	// execute the workflow
-	container = container.WithExec([]string{"ghx"}, ContainerWithExecOpts{ExperimentalPrivilegedNesting: true})
+	// ship the journal entries to the host log system
+	if wr.Config.JournalSocket != nil {
+		container = container.WithUnixSocket(journalSocketPath, wr.Config.JournalSocket)
+		container = container.WithEnvVariable("GHX_JOURNAL_SOCKET", journalSocketPath)
+	}
 
-	// unloading request scoped configs
-	container = container.WithoutEnvVariable("GHX_WORKFLOW")
-	container = container.WithoutEnvVariable("GHX_JOB")
-	container = container.WithoutEnvVariable("GHX_WORKFLOWS_DIR")
+	// run the workflow run from the run history again as a new attempt
+	if wr.Config.Attempt != "" {
+		previous := runsHistory().Directory(wr.Config.Attempt)
+
+		if _, err := previous.File("workflow_run.json").Contents(ctx); err != nil {
+			return nil, fmt.Errorf("workflow run %s not found in the history: %w", wr.Config.Attempt, err)
+		}
+
+		container = container.WithMountedDirectory(previousRunPath, previous)
+		container = container.WithEnvVariable("GHX_PREVIOUS_RUN", previousRunPath)
+	}
+
+	// configured env and secrets, secrets need to be mounted after the ghx home directory
+	container, err = wr.Config.withConfig(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	// inject the env overrides of the run to the workflow
+	container, err = wr.Config.withEnvOverrides(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	// decrypt the secrets store to load the secrets to the secrets context
+	if wr.Config.SecretsStore != nil && wr.Config.SecretsStoreKey != nil {
+		secrets, err := decryptAll(ctx, wr.Config.SecretsStore, wr.Config.SecretsStoreKey)
+		if err != nil {
+			return nil, err
+		}
+
+		container = container.WithMountedSecret(storeSecretsPath, secrets)
+
+		container, err = withSecretsFile(ctx, container, storeSecretsPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// reuse the result of a previous run with the same inputs if the jobs are cached, otherwise disable the cache
+	if wr.Config.CacheJobs {
+		container, err = wr.withCacheKey(ctx, container)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		container = container.WithEnvVariable("CACHE_BUSTER", time.Now().Format(time.RFC3339Nano))
+	}
 
 	return container, nil
 }
 
-func (wr *WorkflowRun) container(ctx context.Context) (*Container, error) {
-	container := dag.Container().From(wr.Config.RunnerImage)
-
-	// set github token as secret if provided
-	if wr.Config.Token != nil {
-		container = container.WithSecretVariable("GITHUB_TOKEN", wr.Config.Token)
+// container returns the runner container of the given runner with the repository and the components of the workflow
+// run configured.
+func (wr *WorkflowRun) container(ctx context.Context, runner *runner) (*Container, error) {
+	container, err := wr.runnerContainer(ctx, runner)
+	if err != nil {
+		return nil, err
 	}
 
 	// configure internal components
-	container = container.With(dag.Source().Ghx().Binary)
+	container, err = withGhx(ctx, container, wr.Config.GhxVersion, wr.Config.GhxBinary)
+	if err != nil {
+		return nil, err
+	}
+
 	container = container.With(dag.Source().ArtifactService().BindAsService)
 	container = container.With(dag.Source().ArtifactCacheService().BindAsService)
 
+	// ghx installs the node runtimes with the engine, untrusted runs use the node of the runner image instead
+	if !wr.Config.Untrusted {
+		container, err = withNodeRuntimes(ctx, container)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// configure repo -- when *Directory can be included in to repo info, we can move source mounting to repo module as well
 	var (
 		info   = dag.Repo().Info((RepoInfoOpts)(*wr.Config.WorkflowsRepoOpts))
@@ -178,9 +755,91 @@ func (wr *WorkflowRun) container(ctx context.Context) (*Container, error) {
 		return nil, err
 	}
 
+	// set github token as secret if provided
+	token, err := wr.runnerToken(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
+	if token != nil {
+		container = container.WithSecretVariable("GITHUB_TOKEN", token)
+	}
+
 	container = container.With(info.Configure)
+
+	// simulate the pull request by checking out the merge commit and overriding the ref information
+	if wr.Config.PullRequest != "" {
+		pr, err := wr.pullRequest(ctx, info, source)
+		if err != nil {
+			return nil, err
+		}
+
+		source = pr.Source
+
+		container = container.With(pr.configure)
+
+		if wr.Config.EventFile == nil {
+			container = container.WithMountedFile(eventPath, dag.Directory().WithNewFile("event.json", pr.Event).File("event.json"))
+			container = container.WithEnvVariable("GITHUB_EVENT_PATH", eventPath)
+		}
+	}
+
+	// synthesize a default event payload for the event when no event file is provided
+	if wr.Config.EventFile == nil && wr.Config.PullRequest == "" {
+		event, err := wr.event(ctx, info, source)
+		if err != nil {
+			return nil, err
+		}
+
+		container = container.WithMountedFile(eventPath, dag.Directory().WithNewFile("event.json", event).File("event.json"))
+		container = container.WithEnvVariable("GITHUB_EVENT_PATH", eventPath)
+	}
+
 	container = container.WithMountedDirectory(workdir, source).WithWorkdir(workdir)
 	container = container.WithEnvVariable("GITHUB_WORKSPACE", workdir)
+	container = container.WithEnvVariable("RUNNER_WORKSPACE", filepath.Dir(workdir))
+
+	// configure tool cache to persist tools installed by setup actions between runs
+	container, err = wr.withToolCache(ctx, container, info)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep the workspaces of the jobs preserving their workspace between runs
+	container, err = wr.withPreservedWorkspaces(ctx, container, info)
+	if err != nil {
+		return nil, err
+	}
+
+	// bind a docker engine to the container for the steps using docker directly
+	if wr.Config.EnableDocker || wr.Config.DockerSocket != nil {
+		container, err = wr.withDocker(ctx, container, info)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// start a kubernetes cluster for the workflows testing against kubernetes
+	if wr.Config.EnableK8s {
+		container, err = wr.withKubernetes(ctx, container, info)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// expose the GPUs requested by the jobs of the runner
+	switch {
+	case runner.Gpus == "all":
+		container = container.ExperimentalWithAllGPUs()
+	case runner.Gpus != "":
+		container = container.ExperimentalWithGPU(strings.Split(runner.Gpus, ","))
+	}
+
+	// route the steps through the proxies enforcing the network modes of the run and the jobs
+	container, err = wr.withNetwork(container)
+	if err != nil {
+		return nil, err
+	}
 
 	// add env variable to the container to indicate container is configured
 	container = container.WithEnvVariable("GALE_CONFIGURED", "true")
@@ -188,22 +847,333 @@ func (wr *WorkflowRun) container(ctx context.Context) (*Container, error) {
 	return container, nil
 }
 
+// runnerContainer returns the runner container for the selected runner. If a runner manifest or a profile is provided,
+// the image is customized with them. Image declared in the manifest has precedence over the selected runner image.
+func (wr *WorkflowRun) runnerContainer(ctx context.Context, runner *runner) (*Container, error) {
+	manifest, err := loadRunnerManifest(ctx, wr.Config.RunnerManifest, wr.Config.RunnerProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	image := runner.Image
+
+	if manifest.Image != "" {
+		image = manifest.Image
+	}
+
+	rules, err := wr.imageRules()
+	if err != nil {
+		return nil, err
+	}
+
+	// rules are configured before the manifest is applied, so the tool images are rewritten with the same rules. Proxy
+	// and CA certificates are configured before anything in the runner accesses the network as well.
+	base := dag.Container(ContainerOpts{Platform: Platform(runner.Platform)}).
+		From(rules.rewrite(image)).
+		With(rules.configure).
+		With(wr.withProxy)
+
+	container, err := manifest.build(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+
+	// record the runner image in the workflow run report with the other components of the run
+	return container.WithEnvVariable("GHX_RUNNER_IMAGE", image), nil
+}
+
+// token returns the GitHub token to use for authentication. GitHub app credentials are exchanged with an installation
+// token. It returns nil if no credentials are provided.
+func (wr *WorkflowRun) token() *Secret {
+	switch {
+	case wr.Config.Token != nil:
+		return wr.Config.Token
+	case wr.Config.AppID != "":
+		return dag.Repo().Token((RepoTokenOpts)(*wr.Config.WorkflowsRepoOpts))
+	default:
+		return nil
+	}
+}
+
+// cacheNamespace returns the namespace of the cache volumes. It's the cache namespace option or the full path of the
+// repository, including the GitLab subgroups, if the option is not provided.
+func (wr *WorkflowRun) cacheNamespace(ctx context.Context, info *RepoInfo) (string, error) {
+	namespace := wr.Config.CacheNamespace
+
+	if namespace == "" {
+		path, err := info.Path(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		namespace = path
+	}
+
+	return cacheVolumeKey(namespace, wr.Config.Untrusted), nil
+}
+
+// cacheVolumeKey returns the key of the namespace used in the cache volume names. Untrusted runs don't share the caches
+// with the trusted runs of the same namespace.
+func cacheVolumeKey(namespace string, untrusted bool) string {
+	if untrusted {
+		namespace = "untrusted-" + namespace
+	}
+
+	// cache volume keys are used as is, replacing path separators to keep keys readable
+	return strings.ReplaceAll(namespace, "/", "-")
+}
+
+// withToolCache mounts the runner tool cache and the user cache directory of the container as cache volumes. Volumes are
+// namespaced with the cache namespace option or the repository name with owner if the option is not provided.
+func (wr *WorkflowRun) withToolCache(ctx context.Context, container *Container, info *RepoInfo) (*Container, error) {
+	namespace, err := wr.cacheNamespace(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
+	// runner images could define a custom tool cache location, e.g. /opt/hostedtoolcache, so we're respecting it if
+	// it's set. Otherwise, we're using the default location and exporting it to the container.
+	toolCache, err := container.EnvVariable(ctx, "RUNNER_TOOL_CACHE")
+	if err != nil {
+		return nil, err
+	}
+
+	opts := ContainerWithMountedCacheOpts{Sharing: Shared}
+
+	toolCacheOpts := opts
+
+	if toolCache == "" {
+		toolCache = "/home/runner/hostedtoolcache"
+		container = container.WithEnvVariable("RUNNER_TOOL_CACHE", toolCache)
+	} else {
+		// the tools pre-installed in the image are copied to the volume when it's created, otherwise mounting the
+		// volume would hide them from the steps
+		toolCacheOpts.Source = container.Directory(toolCache)
+	}
+
+	container = container.WithMountedCache(toolCache, dag.CacheVolume(fmt.Sprintf("gale-tool-cache-%s", namespace)), toolCacheOpts)
+
+	home, err := container.EnvVariable(ctx, "HOME")
+	if err != nil {
+		return nil, err
+	}
+
+	// no home directory to mount the user cache, skip it
+	if home == "" {
+		return container, nil
+	}
+
+	cacheDir := filepath.Join(home, ".cache")
+
+	return container.WithMountedCache(cacheDir, dag.CacheVolume(fmt.Sprintf("gale-cache-%s", namespace)), opts), nil
+}
+
+// withPreservedWorkspaces mounts the cache volume keeping the workspaces of the jobs between runs if any job preserves
+// its workspace. Volume is namespaced like the tool cache, so the workspaces of the different repositories don't mix.
+func (wr *WorkflowRun) withPreservedWorkspaces(ctx context.Context, container *Container, info *RepoInfo) (*Container, error) {
+	if len(wr.Config.PreserveWorkspaces) == 0 {
+		return container, nil
+	}
+
+	namespace, err := wr.cacheNamespace(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
+	volume := dag.CacheVolume(fmt.Sprintf("gale-workspaces-%s", namespace))
+
+	return container.
+		WithMountedCache(workspacesPath, volume, ContainerWithMountedCacheOpts{Sharing: Locked}).
+		WithEnvVariable("GHX_WORKSPACES_DIR", workspacesPath), nil
+}
+
+// validateWorkflow checks the workflow option matches the workflow file and the workflow content options. Workflow
+// option must be - if and only if the workflow is given with its file or content.
+func (wrc *WorkflowRunConfig) validateWorkflow() error {
+	switch {
+	case wrc.Workflow == "":
+		return errors.New("workflow is required")
+	case wrc.WorkflowFile != nil && wrc.WorkflowContent != "":
+		return errors.New("only one of workflow file and workflow content can be used")
+	case wrc.Workflow == "-" && wrc.workflowFile() == nil:
+		return errors.New("workflow - requires the workflow file or the workflow content option")
+	case wrc.Workflow != "-" && wrc.workflowFile() != nil:
+		return fmt.Errorf("workflow %s is ambiguous with the workflow file or the workflow content option, use - to run the given workflow", wrc.Workflow)
+	}
+
+	return nil
+}
+
+// workflowFile returns the workflow given with the workflow file or the workflow content option. It returns nil if the
+// workflow is looked up in the workflows directory of the repository.
+func (wrc *WorkflowRunConfig) workflowFile() *File {
+	switch {
+	case wrc.WorkflowFile != nil:
+		return wrc.WorkflowFile
+	case wrc.WorkflowContent != "":
+		return dag.Directory().WithNewFile("workflow.yml", wrc.WorkflowContent).File("workflow.yml")
+	default:
+		return nil
+	}
+}
+
 func (wrc *WorkflowRunConfig) configure(c *Container) *Container {
 	container := c
 
 	container = container.WithEnvVariable("GHX_WORKFLOW", wrc.Workflow)
+
+	if file := wrc.workflowFile(); file != nil {
+		container = container.WithMountedFile(inlineWorkflowPath, file)
+		container = container.WithEnvVariable("GHX_WORKFLOW_FILE", inlineWorkflowPath)
+	}
 	container = container.WithEnvVariable("GHX_JOB", wrc.Job)
 	container = container.WithEnvVariable("GHX_WORKFLOWS_DIR", wrc.WorkflowsDir)
 
-	container = container.WithEnvVariable("GITHUB_EVENT_NAME", wrc.Event)
+	event := wrc.Event
+
+	// pull request simulation implies pull_request event unless pull_request_target is explicitly requested
+	if wrc.PullRequest != "" && event != "pull_request_target" {
+		event = "pull_request"
+	}
+
+	container = container.WithEnvVariable("GITHUB_EVENT_NAME", event)
 
 	if wrc.EventFile != nil {
-		container = container.WithMountedFile("/home/runner/_temp/_github_workflow/event.json", wrc.EventFile)
+		container = container.WithMountedFile(eventPath, wrc.EventFile)
+		container = container.WithEnvVariable("GITHUB_EVENT_PATH", eventPath)
+	}
+
+	if wrc.RunnerName != "" {
+		container = container.WithEnvVariable("RUNNER_NAME", wrc.RunnerName)
+	}
+
+	if wrc.RunnerEnvironment != "" {
+		container = container.WithEnvVariable("RUNNER_ENVIRONMENT", wrc.RunnerEnvironment)
 	}
 
 	if wrc.RunnerDebug {
 		container = container.WithEnvVariable("RUNNER_DEBUG", "1")
 	}
 
+	if wrc.Offline {
+		container = container.WithEnvVariable("GHX_OFFLINE", "true")
+	}
+
+	if wrc.FilesReport {
+		container = container.WithEnvVariable("GHX_FILES_REPORT", "true")
+	}
+
+	if len(wrc.FilesReportPaths) > 0 {
+		container = container.WithEnvVariable("GHX_FILES_REPORT_PATHS", strings.Join(wrc.FilesReportPaths, ","))
+	}
+
+	if wrc.PullPolicy != "" {
+		container = container.WithEnvVariable("GHX_PULL_POLICY", wrc.PullPolicy)
+	}
+
+	if len(wrc.Limits) > 0 {
+		container = container.WithEnvVariable("GHX_LIMITS", strings.Join(wrc.Limits, ";"))
+	}
+
+	if wrc.debugShell {
+		container = container.WithEnvVariable("GHX_DEBUG_SHELL", "true")
+	}
+
+	if wrc.breakAt != "" {
+		container = container.WithEnvVariable("GHX_BREAK_AT", wrc.breakAt)
+	}
+
+	if len(wrc.SecretsFrom) > 0 {
+		container = container.WithEnvVariable("GHX_SECRETS_FROM", strings.Join(wrc.SecretsFrom, "\n"))
+	}
+
+	if wrc.EnvironmentApproval {
+		container = container.WithEnvVariable("GHX_ENVIRONMENT_APPROVAL", "true")
+	}
+
+	if len(wrc.ApprovedEnvironments) > 0 {
+		container = container.WithEnvVariable("GHX_APPROVED_ENVIRONMENTS", strings.Join(wrc.ApprovedEnvironments, ","))
+	}
+
+	if wrc.Deployments {
+		container = container.WithEnvVariable("GHX_DEPLOYMENTS", "true")
+	}
+
+	if wrc.ApiAudit {
+		container = container.WithEnvVariable("GHX_API_AUDIT", "true")
+	}
+
+	if wrc.Untrusted {
+		container = container.WithEnvVariable("GHX_UNTRUSTED", "true")
+	}
+
+	if len(wrc.JournalSinks) > 0 {
+		container = container.WithEnvVariable("GHX_JOURNAL_SINKS", strings.Join(wrc.JournalSinks, ";"))
+	}
+
+	if len(wrc.ChangedPaths) > 0 {
+		container = container.WithEnvVariable("GHX_CHANGED_PATHS", strings.Join(wrc.ChangedPaths, ","))
+	}
+
+	if wrc.ToolLayers {
+		container = container.WithEnvVariable("GHX_TOOL_LAYERS", "true")
+	}
+
+	if wrc.CloneCheckout {
+		container = container.WithEnvVariable("GHX_CHECKOUT_FAST_PATH", "false")
+	}
+
+	if wrc.RetentionDays != "" {
+		container = container.WithEnvVariable("GHX_RETENTION_DAYS", wrc.RetentionDays)
+	}
+
+	if wrc.Output != "" {
+		container = container.WithEnvVariable("GHX_OUTPUT", wrc.Output)
+	}
+
+	if wrc.WorkspaceMode != "" {
+		container = container.WithEnvVariable("GHX_WORKSPACE_MODE", wrc.WorkspaceMode)
+	}
+
+	if len(wrc.PreserveWorkspaces) > 0 {
+		container = container.WithEnvVariable("GHX_PRESERVE_WORKSPACES", strings.Join(wrc.PreserveWorkspaces, ","))
+	}
+
+	if len(wrc.Retries) > 0 {
+		container = container.WithEnvVariable("GHX_RETRIES", strings.Join(wrc.Retries, ";"))
+	}
+
+	if formats := reportFormats(wrc.Report); len(formats) > 0 {
+		container = container.WithEnvVariable("GHX_REPORT", strings.Join(formats, ","))
+	}
+
+	if wrc.RequirePinnedActions {
+		container = container.WithEnvVariable("GHX_REQUIRE_PINNED_ACTIONS", "true")
+	}
+
+	if wrc.CheckArchivedActions {
+		container = container.WithEnvVariable("GHX_CHECK_ARCHIVED_ACTIONS", "true")
+	}
+
+	if len(wrc.ActionsDenylist) > 0 {
+		container = container.WithEnvVariable("GHX_ACTIONS_DENYLIST", strings.Join(wrc.ActionsDenylist, ","))
+	}
+
+	if len(wrc.ActionsAllow) > 0 {
+		container = container.WithEnvVariable("GHX_ACTIONS_ALLOW", strings.Join(wrc.ActionsAllow, ","))
+	}
+
+	if len(wrc.ActionsDeny) > 0 {
+		container = container.WithEnvVariable("GHX_ACTIONS_DENY", strings.Join(wrc.ActionsDeny, ","))
+	}
+
+	if wrc.OverridePolicy {
+		container = container.WithEnvVariable("GHX_ACTIONS_POLICY_OVERRIDE", "true")
+	}
+
+	if len(wrc.Matrix) > 0 {
+		container = container.WithEnvVariable("GHX_MATRIX", strings.Join(wrc.Matrix, ","))
+	}
+
 	return container
 }
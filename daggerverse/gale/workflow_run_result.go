@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+)
+
+// WorkflowRunResult represents the result of an executed workflow run. Values are resolved lazily from the runner
+// container, so callers can chain on the result without evaluating the run until a value is requested.
+type WorkflowRunResult struct {
+	Container *Container // Container is the runner container in the state after the workflow run
+}
+
+// JobRunResult represents the result of a job run in a workflow run. Matrix jobs have a job run for each combination.
+type JobRunResult struct {
+	RunID       string `json:"run_id"`       // RunID is the ID of the job run
+	Name        string `json:"name"`         // Name is the name of the job
+	DisplayName string `json:"display_name"` // DisplayName is the name of the job including the matrix values, e.g. test (ubuntu-latest, 1.21)
+	Conclusion  string `json:"conclusion"`   // Conclusion is the result of a completed job after continue-on-error is applied
+	Outcome     string `json:"outcome"`      // Outcome is the result of a completed job before continue-on-error is applied
+	Duration    string `json:"duration"`     // Duration of the job run
+	StartedAt   string `json:"started_at"`   // StartedAt is the time the job run started
+	CompletedAt string `json:"completed_at"` // CompletedAt is the time the job run completed
+	Report      *File  `json:"report"`       // Report is the job run report file with the outputs and the steps of the job
+}
+
+// Execute executes the workflow run and returns the result of the run.
+func (wr *WorkflowRun) Execute(ctx context.Context) (*WorkflowRunResult, error) {
+	container, err := wr.run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WorkflowRunResult{Container: container}, nil
+}
+
+// Report returns the report of the workflow run.
+func (r *WorkflowRunResult) Report(ctx context.Context) (*WorkflowRunReport, error) {
+	return report(ctx, r.Container)
+}
+
+// Conclusion returns the conclusion of the workflow run.
+func (r *WorkflowRunResult) Conclusion(ctx context.Context) (string, error) {
+	result, err := report(ctx, r.Container)
+	if err != nil {
+		return "", err
+	}
+
+	return result.Conclusion, nil
+}
+
+// Jobs returns the results of the job runs in the workflow run.
+func (r *WorkflowRunResult) Jobs(ctx context.Context) ([]*JobRunResult, error) {
+	runID, err := r.runID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join("/home/runner/_temp/ghx/runs", runID, "jobs")
+
+	entries, err := r.Container.Directory(dir).Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*JobRunResult, 0, len(entries))
+
+	for _, entry := range entries {
+		file := r.Container.File(filepath.Join(dir, entry, "job_run.json"))
+
+		var job JobRunResult
+
+		if err := file.unmarshalContentsToJSON(ctx, &job); err != nil {
+			return nil, err
+		}
+
+		job.Report = file
+
+		jobs = append(jobs, &job)
+	}
+
+	return jobs, nil
+}
+
+// Log returns the log of the workflow run.
+func (r *WorkflowRunResult) Log() *File {
+	return r.Container.File(logPath)
+}
+
+// Directory returns the directory of the workflow run information.
+func (r *WorkflowRunResult) Directory() *Directory {
+	return dag.Directory().WithDirectory("runs", r.Container.Directory("/home/runner/_temp/ghx/runs"))
+}
+
+// Workspace returns the workspace directory with the files produced by the run.
+func (r *WorkflowRunResult) Workspace() *Directory {
+	return r.Container.Directory(".")
+}
+
+// Artifacts returns the directory of the artifacts uploaded during the workflow run.
+func (r *WorkflowRunResult) Artifacts(ctx context.Context) (*Directory, error) {
+	runID, err := r.runID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return artifacts(runID), nil
+}
+
+// runID returns the id of the workflow run.
+func (r *WorkflowRunResult) runID(ctx context.Context) (string, error) {
+	return workflowRunID(ctx, r.Container)
+}
@@ -2,9 +2,13 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
+	"text/tabwriter"
 )
 
 type Workflows struct{}
@@ -14,10 +18,24 @@ type Workflows struct{}
 // This is copy of RepoOpts from daggerverse/gale/repo.go to be able to expose options with gale module and pass them to
 // the repo module just type casting.
 type WorkflowsRepoOpts struct {
-	Source *Directory `doc:"The directory containing the repository source. If source is provided, rest of the options are ignored."`
-	Repo   string     `doc:"The name of the repository. Format: owner/name."`
-	Branch string     `doc:"Branch name to checkout. Only one of branch or tag can be used. Precedence is as follows: tag, branch."`
-	Tag    string     `doc:"Tag name to checkout. Only one of branch or tag can be used. Precedence is as follows: tag, branch."`
+	Source              *Directory `doc:"The directory containing the repository source. If source is provided, rest of the options except dirty and exclude ignored are ignored."`
+	Repo                string     `doc:"The name of the repository. Format: owner/name for GitHub, host/owner/name or the git URL for GitLab and Bitbucket."`
+	Branch              string     `doc:"Branch name to checkout. Only one of branch, tag, ref or commit can be used. Precedence is as follows: commit, ref, tag, branch."`
+	Tag                 string     `doc:"Tag name to checkout. Only one of branch, tag, ref or commit can be used. Precedence is as follows: commit, ref, tag, branch."`
+	Commit              string     `doc:"Commit SHA to checkout. Only one of branch, tag, ref or commit can be used. Precedence is as follows: commit, ref, tag, branch."`
+	Ref                 string     `doc:"Git ref to checkout, e.g. refs/pull/123/merge. Only one of branch, tag, ref or commit can be used. Precedence is as follows: commit, ref, tag, branch."`
+	Token               *Secret    `doc:"The GitHub token to use for authentication. Required for private repositories and actions."`
+	AppID               string     `doc:"The GitHub App ID to use for authentication instead of a token. The GITHUB_TOKEN of the steps is a short-lived installation token scoped to the repository and the workflow permissions."`
+	AppInstallationID   string     `doc:"The GitHub App installation ID to use for authentication instead of a token."`
+	AppPrivateKey       *Secret    `doc:"The GitHub App private key in PEM format to use for authentication instead of a token."`
+	Submodules          bool       `doc:"Initialize the submodules of the repository." default:"false"`
+	SubmodulesRecursive bool       `doc:"Initialize the submodules of the repository recursively. Implies submodules." default:"false"`
+	LFS                 bool       `doc:"Pull the Git LFS objects of the repository." default:"false"`
+	Depth               int        `doc:"Create a shallow clone with the given number of commits. Zero means full history." default:"0"`
+	SparsePaths         []string   `doc:"Paths to checkout with sparse checkout. The .github directory is always included."`
+	Dirty               bool       `doc:"Report the commit SHA as <sha>-dirty if the source directory has uncommitted changes." default:"false"`
+	ExcludeIgnored      bool       `doc:"Exclude the files ignored by .gitignore from the source directory." default:"false"`
+	SSHAuthSocket       *Socket    `doc:"The ssh agent socket to clone the repositories given with ssh urls, e.g. git@github.com:owner/name.git."`
 }
 
 // WorkflowsDirOpts represents the options for getting workflow information.
@@ -73,6 +91,36 @@ func (w *Workflows) List(ctx context.Context, repoOpts WorkflowsRepoOpts, pathOp
 	return sb.String(), nil
 }
 
+// WorkflowsLintOpts represents the options for linting a workflow.
+type WorkflowsLintOpts struct {
+	Strict bool `doc:"Fail on warnings as well as errors." default:"false"`
+}
+
+// Lint reports the constructs of the workflow gale can't execute yet, e.g. services, reusable workflows or known
+// incompatible actions, with their severities. Lint fails if the workflow has any errors or, in strict mode, warnings.
+func (w *Workflows) Lint(ctx context.Context, repoOpts WorkflowsRepoOpts, pathOpts WorkflowsDirOpts, workflow string, opts WorkflowsLintOpts) (string, error) {
+	wr := &WorkflowRun{
+		Config: &WorkflowRunConfig{
+			WorkflowsRepoOpts: &repoOpts,
+			WorkflowsDirOpts:  &pathOpts,
+			WorkflowsRunOpts:  &WorkflowsRunOpts{Workflow: workflow, Event: "push"},
+		},
+	}
+
+	args := []string{"lint"}
+
+	if opts.Strict {
+		args = append(args, "--strict")
+	}
+
+	container, err := wr.run(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+
+	return container.Stdout(ctx)
+}
+
 func (w *Workflows) Run(repoOpts WorkflowsRepoOpts, pathOpts WorkflowsDirOpts, runOpts WorkflowsRunOpts) *WorkflowRun {
 	return &WorkflowRun{
 		Config: &WorkflowRunConfig{
@@ -82,3 +130,367 @@ func (w *Workflows) Run(repoOpts WorkflowsRepoOpts, pathOpts WorkflowsDirOpts, r
 		},
 	}
 }
+
+// WorkflowsRunManyOpts represents the options for running multiple workflows in one invocation.
+type WorkflowsRunManyOpts struct {
+	Workflows []string `doc:"The workflows to run with their names or paths."`
+	All       bool     `doc:"Run all workflows of the repository." default:"false"`
+}
+
+// workflowRunSummary is the result of a workflow run in the combined report of the runs.
+type workflowRunSummary struct {
+	Workflow string             // Workflow is the workflow name or path requested to run
+	Report   *WorkflowRunReport // Report is the report of the workflow run, nil if the run failed to execute
+	Err      error              // Err is the error of the run, either the execution error or the fail on policy violation
+}
+
+// RunMany runs the given workflows, or all workflows of the repository, concurrently in the same session with the same
+// run options. Runs share the actions cache, the tool caches and the artifact service of the session. It returns the
+// combined report of the runs and fails if any of the runs fails to execute or violates the fail on policy.
+func (w *Workflows) RunMany(ctx context.Context, repoOpts WorkflowsRepoOpts, pathOpts WorkflowsDirOpts, runOpts WorkflowsRunOpts, opts WorkflowsRunManyOpts) (string, error) {
+	// defaults are not applied when the options are not provided by the caller
+	if pathOpts.WorkflowsDir == "" {
+		pathOpts.WorkflowsDir = ".github/workflows"
+	}
+
+	workflows := opts.Workflows
+
+	if runOpts.Workflow != "" {
+		workflows = append([]string{runOpts.Workflow}, workflows...)
+	}
+
+	if opts.All {
+		names, err := workflowNames(ctx, repoOpts, pathOpts)
+		if err != nil {
+			return "", err
+		}
+
+		workflows = names
+	}
+
+	if len(workflows) == 0 {
+		return "", errors.New("no workflows to run, use the workflows or the all option to select them")
+	}
+
+	summaries := make([]workflowRunSummary, len(workflows))
+
+	var wg sync.WaitGroup
+
+	for i, workflow := range workflows {
+		wg.Add(1)
+
+		go func(i int, workflow string) {
+			defer wg.Done()
+
+			// each run has its own copy of the options, since the configuration is applied to the options in place
+			options := runOpts
+			options.Workflow = workflow
+
+			wr := w.Run(repoOpts, pathOpts, options)
+
+			summary := workflowRunSummary{Workflow: workflow}
+
+			container, err := wr.run(ctx)
+			if err == nil {
+				summary.Report, err = report(ctx, container)
+			}
+
+			if err == nil {
+				err = checkFailOn(wr.Config.FailOn, summary.Report)
+			}
+
+			summary.Err = err
+			summaries[i] = summary
+		}(i, workflow)
+	}
+
+	wg.Wait()
+
+	return combinedReport(summaries)
+}
+
+// combinedReport returns the table of the workflow runs with their conclusions and the errors of the failed runs. It
+// returns an error with the report if any of the runs failed.
+func combinedReport(summaries []workflowRunSummary) (string, error) {
+	var (
+		sb     strings.Builder
+		failed int
+	)
+
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "WORKFLOW\tRUN ID\tCONCLUSION\tDURATION")
+
+	for _, summary := range summaries {
+		if summary.Err != nil {
+			failed++
+		}
+
+		if summary.Report == nil {
+			fmt.Fprintf(w, "%s\t-\terror\t-\n", summary.Workflow)
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", summary.Report.Name, summary.Report.RunID, summary.Report.Conclusion, summary.Report.Duration)
+	}
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	for _, summary := range summaries {
+		if summary.Err != nil {
+			fmt.Fprintf(&sb, "\n%s: %v\n", summary.Workflow, summary.Err)
+		}
+	}
+
+	if failed > 0 {
+		return "", fmt.Errorf("%d of %d workflow(s) failed:\n%s", failed, len(summaries), sb.String())
+	}
+
+	return sb.String(), nil
+}
+
+// workflowNames returns the names of the workflows in the workflows directory. Workflows without a name are identified
+// with their paths, same as ghx does.
+func workflowNames(ctx context.Context, repoOpts WorkflowsRepoOpts, pathOpts WorkflowsDirOpts) ([]string, error) {
+	dir := dag.Repo().Source((RepoSourceOpts)(repoOpts)).Directory(pathOpts.WorkflowsDir)
+
+	entries, err := dir.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry, ".yaml") && !strings.HasSuffix(entry, ".yml") {
+			continue
+		}
+
+		var workflow struct {
+			Name string `yaml:"name"`
+		}
+
+		if err := dir.File(entry).unmarshalContentsToYAML(ctx, &workflow); err != nil {
+			return nil, err
+		}
+
+		name := workflow.Name
+		if name == "" {
+			name = filepath.Join(pathOpts.WorkflowsDir, entry)
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// WorkflowsTriggerOpts represents the options for running the workflows triggered by an event.
+type WorkflowsTriggerOpts struct {
+	Event     string `doc:"Name of the event that triggered the workflows. e.g. push" default:"push"`
+	EventFile *File  `doc:"The file with the complete webhook event payload. If not provided, a default payload is generated for the event from the repository."`
+	Workflow  string `doc:"The name or the path of the workflow to run. If empty, all workflows triggered by the event are run."`
+	Untrusted bool   `doc:"Evaluate the workflows as untrusted code, e.g. the workflows of a pull request from a fork." default:"false"`
+	Output    string `doc:"Format of the output. Possible values are: text, ndjson. With ndjson, the result of each workflow run is printed as a line of JSON and failed workflow runs don't fail the trigger." default:"text"`
+
+	JournalSinks []string `doc:"Sinks to ship the console output of the workflow runs to, e.g. syslog=udp://logs.example.com:514."`
+}
+
+// triggerResult represents the result of a workflow run in the ndjson output of the trigger.
+type triggerResult struct {
+	Name       string `json:"name"`               // Name is the name of the workflow
+	RunID      string `json:"run_id,omitempty"`   // RunID is the id of the workflow run, empty if the run didn't start
+	Conclusion string `json:"conclusion"`         // Conclusion is the conclusion of the workflow run
+	Duration   string `json:"duration,omitempty"` // Duration is the duration of the workflow run
+	Error      string `json:"error,omitempty"`    // Error is the error failing the workflow run, e.g. the fail on policy
+}
+
+// Trigger runs the workflows of the repository triggered by the event one by one. It returns the summaries of the
+// workflow runs and fails if any of the workflow runs fails. With ndjson output, it returns the results of the workflow
+// runs instead and only fails if the workflows can't be read.
+func (w *Workflows) Trigger(ctx context.Context, repoOpts WorkflowsRepoOpts, pathOpts WorkflowsDirOpts, opts WorkflowsTriggerOpts) (string, error) {
+	// defaults are not applied when the options are not provided by the caller
+	if pathOpts.WorkflowsDir == "" {
+		pathOpts.WorkflowsDir = ".github/workflows"
+	}
+
+	switch opts.Output {
+	case "", "text", "ndjson":
+	default:
+		return "", fmt.Errorf("unsupported output %s, possible values are: text, ndjson", opts.Output)
+	}
+
+	if opts.Event == "" {
+		opts.Event = "push"
+	}
+
+	dir := dag.Repo().Source((RepoSourceOpts)(repoOpts)).Directory(pathOpts.WorkflowsDir)
+
+	entries, err := dir.Entries(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		summaries []string
+		results   []string
+		failed    int
+	)
+
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry, ".yaml") && !strings.HasSuffix(entry, ".yml") {
+			continue
+		}
+
+		var workflow struct {
+			Name string      `yaml:"name"`
+			On   interface{} `yaml:"on"`
+		}
+
+		if err := dir.File(entry).unmarshalContentsToYAML(ctx, &workflow); err != nil {
+			return "", err
+		}
+
+		if !triggeredBy(workflow.On, opts.Event) {
+			continue
+		}
+
+		// workflows are identified with their names, or the paths if they don't have a name
+		name := workflow.Name
+		if name == "" {
+			name = filepath.Join(pathOpts.WorkflowsDir, entry)
+		}
+
+		if opts.Workflow != "" && opts.Workflow != name && opts.Workflow != filepath.Join(pathOpts.WorkflowsDir, entry) {
+			continue
+		}
+
+		runOpts := WorkflowsRunOpts{Workflow: name, Event: opts.Event, EventFile: opts.EventFile, Untrusted: opts.Untrusted, JournalSinks: opts.JournalSinks}
+
+		if opts.Output == "ndjson" {
+			// result only contains basic types, so it can't fail to marshal
+			data, _ := json.Marshal(w.Run(repoOpts, pathOpts, runOpts).triggerResult(ctx, name))
+
+			results = append(results, string(data))
+
+			continue
+		}
+
+		summary, err := w.Run(repoOpts, pathOpts, runOpts).Result(ctx)
+		if err != nil {
+			failed++
+			summary = err.Error()
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	if opts.Output == "ndjson" {
+		if len(results) == 0 {
+			return "", nil
+		}
+
+		return strings.Join(results, "\n") + "\n", nil
+	}
+
+	if len(summaries) == 0 {
+		return fmt.Sprintf("No workflows triggered by %s event", opts.Event), nil
+	}
+
+	result := strings.Join(summaries, "\n")
+
+	if failed > 0 {
+		return "", fmt.Errorf("%d of %d workflow(s) failed:\n%s", failed, len(summaries), result)
+	}
+
+	return result, nil
+}
+
+// Schedules returns the cron schedules of the workflows of the repository triggered by the schedule event. Each line
+// is the cron expression and the workflow name, or the path if the workflow doesn't have a name, separated with a tab.
+func (w *Workflows) Schedules(ctx context.Context, repoOpts WorkflowsRepoOpts, pathOpts WorkflowsDirOpts) (string, error) {
+	// defaults are not applied when the options are not provided by the caller
+	if pathOpts.WorkflowsDir == "" {
+		pathOpts.WorkflowsDir = ".github/workflows"
+	}
+
+	dir := dag.Repo().Source((RepoSourceOpts)(repoOpts)).Directory(pathOpts.WorkflowsDir)
+
+	entries, err := dir.Entries(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry, ".yaml") && !strings.HasSuffix(entry, ".yml") {
+			continue
+		}
+
+		var workflow struct {
+			Name string      `yaml:"name"`
+			On   interface{} `yaml:"on"`
+		}
+
+		if err := dir.File(entry).unmarshalContentsToYAML(ctx, &workflow); err != nil {
+			return "", err
+		}
+
+		name := workflow.Name
+		if name == "" {
+			name = filepath.Join(pathOpts.WorkflowsDir, entry)
+		}
+
+		for _, cron := range scheduleCrons(workflow.On) {
+			fmt.Fprintf(&sb, "%s\t%s\n", cron, name)
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// triggeredBy returns true if the on value of the workflow includes the event. The value could be a single event, a
+// list of events or a map of events to their filters.
+func triggeredBy(on interface{}, event string) bool {
+	switch v := on.(type) {
+	case string:
+		return v == event
+	case []interface{}:
+		for _, item := range v {
+			if item == event {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		_, ok := v[event]
+		return ok
+	}
+
+	return false
+}
+
+// scheduleCrons returns the cron expressions of the schedule event in the on value of the workflow. Only the map form of
+// the on value could have schedules.
+func scheduleCrons(on interface{}) []string {
+	events, ok := on.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	schedules, _ := events["schedule"].([]interface{})
+
+	crons := make([]string, 0, len(schedules))
+
+	for _, schedule := range schedules {
+		item, _ := schedule.(map[string]interface{})
+
+		if cron, ok := item["cron"].(string); ok {
+			crons = append(crons, cron)
+		}
+	}
+
+	return crons
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Token returns the GitHub token for the given options. If a token is provided, it is returned as is. Otherwise, if
+// GitHub App credentials are provided, an installation token is generated for the app.
+func (_ *Repo) Token(ctx context.Context, opts RepoOpts) (*Secret, error) {
+	token, err := getToken(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if token == nil {
+		return nil, fmt.Errorf("either a token or github app credentials must be provided")
+	}
+
+	return token, nil
+}
+
+// ScopedToken returns a GitHub App installation token limited to the given repository and permissions, e.g. to expose a
+// short-lived token with the permissions of a workflow to the steps. Permissions are in name=level format, e.g.
+// contents=read, with the permission names of the GitHub App API. Requires GitHub App credentials.
+func (_ *Repo) ScopedToken(ctx context.Context, opts RepoOpts, repository string, permissions []string) (*Secret, error) {
+	if opts.AppID == "" || opts.AppInstallationID == "" || opts.AppPrivateKey == nil {
+		return nil, fmt.Errorf("app id, app installation id and app private key are required to mint a scoped token")
+	}
+
+	scope := &tokenScope{Permissions: make(map[string]string, len(permissions))}
+
+	if repository != "" {
+		scope.Repositories = []string{repository}
+	}
+
+	for _, permission := range permissions {
+		name, level, ok := strings.Cut(permission, "=")
+		if !ok || name == "" || (level != "read" && level != "write") {
+			return nil, fmt.Errorf("invalid permission %s, expected format is name=read or name=write", permission)
+		}
+
+		scope.Permissions[name] = level
+	}
+
+	key, err := opts.AppPrivateKey.Plaintext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAppInstallationToken(ctx, opts.AppID, opts.AppInstallationID, key, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// each scope has its own secret, so the tokens with different permissions don't override each other
+	sort.Strings(permissions)
+
+	name := fmt.Sprintf("github-app-token-%s-%s-%s", opts.AppInstallationID, repository, strings.Join(permissions, ","))
+
+	return dag.SetSecret(name, token), nil
+}
+
+// tokenScope represents the repositories and the permissions an installation token is limited to.
+type tokenScope struct {
+	Repositories []string          `json:"repositories,omitempty"` // Repositories is the names of the repositories the token can access
+	Permissions  map[string]string `json:"permissions,omitempty"`  // Permissions is the map of the permission names to their levels, read or write
+}
+
+// getToken returns the GitHub token for the given options. It returns nil if no credentials are provided.
+func getToken(ctx context.Context, opts RepoOpts) (*Secret, error) {
+	if opts.Token != nil {
+		return opts.Token, nil
+	}
+
+	if opts.AppID == "" && opts.AppInstallationID == "" && opts.AppPrivateKey == nil {
+		return nil, nil
+	}
+
+	if opts.AppID == "" || opts.AppInstallationID == "" || opts.AppPrivateKey == nil {
+		return nil, fmt.Errorf("app id, app installation id and app private key must be provided together")
+	}
+
+	key, err := opts.AppPrivateKey.Plaintext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := getAppInstallationToken(ctx, opts.AppID, opts.AppInstallationID, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return dag.SetSecret(fmt.Sprintf("github-app-token-%s", opts.AppInstallationID), token), nil
+}
+
+// getAppInstallationToken exchanges a JWT signed with the app private key with an installation access token. If the
+// scope is given, the token is limited to its repositories and permissions, otherwise it has all permissions of the
+// installation.
+func getAppInstallationToken(ctx context.Context, appID, installationID, privateKey string, scope *tokenScope) (string, error) {
+	jwt, err := getAppJWT(appID, privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("https://api.github.com/app/installations/%s/access_tokens", installationID)
+
+	var body io.Reader
+
+	if scope != nil {
+		data, err := json.Marshal(scope)
+		if err != nil {
+			return "", err
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", jwt))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("failed to get installation token: unexpected status %s", resp.Status)
+	}
+
+	var result struct {
+		Token string `json:"token"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode installation token: %w", err)
+	}
+
+	return result.Token, nil
+}
+
+// getAppJWT returns a JWT signed with the app private key to authenticate as the GitHub App.
+func getAppJWT(appID, privateKey string) (string, error) {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return "", fmt.Errorf("failed to decode app private key")
+	}
+
+	key, err := parseRSAPrivateKey(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	// issued at is set 60 seconds in the past to allow for clock drift, GitHub accepts at most 10 minutes expiration.
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var sb bytes.Buffer
+
+	sb.WriteString(base64.RawURLEncoding.EncodeToString(header))
+	sb.WriteString(".")
+	sb.WriteString(base64.RawURLEncoding.EncodeToString(claims))
+
+	digest := sha256.Sum256(sb.Bytes())
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign jwt: %w", err)
+	}
+
+	sb.WriteString(".")
+	sb.WriteString(base64.RawURLEncoding.EncodeToString(signature))
+
+	return sb.String(), nil
+}
+
+// parseRSAPrivateKey parses the private key in PKCS#1 or PKCS#8 format.
+func parseRSAPrivateKey(der []byte) (*rsa.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse app private key: %w", err)
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("app private key is not a RSA key")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// supportedGitHosts is the list of git hosts supported to parse the repository urls.
+var supportedGitHosts = []string{"github.com", "gitlab.com", "bitbucket.org"}
+
+// gitURL represents the parsed git url of a repository.
+type gitURL struct {
+	Host  string // Host of the repository, e.g. github.com.
+	Owner string // Owner is the user or the top-level group owning the repository.
+	Name  string // Name of the repository.
+	Path  string // Path is the full path of the repository, e.g. group/subgroup/name for GitLab subgroups.
+	SSH   bool   // SSH is true if the url is an ssh url, e.g. git@github.com:owner/name.git.
+}
+
+// NameWithOwner returns the name of the repository with its owner, e.g. owner/name. Subgroups are not part of it like
+// the other hosts, the full path of the repository is kept in the path.
+func (u *gitURL) NameWithOwner() string {
+	return fmt.Sprintf("%s/%s", u.Owner, u.Name)
+}
+
+// HTMLURL returns the web url of the repository, e.g. https://gitlab.com/group/subgroup/name.
+func (u *gitURL) HTMLURL() string {
+	return fmt.Sprintf("https://%s/%s", u.Host, u.Path)
+}
+
+// parseGitURL parses the git url of the repository. Both https and ssh urls of GitHub, GitLab and Bitbucket are
+// supported. GitLab subgroups are part of the path of the repository, the owner is the top-level group.
+func parseGitURL(url string) (*gitURL, error) {
+	var (
+		trimmed = url
+		ssh     = strings.HasPrefix(url, "ssh://")
+	)
+
+	for _, scheme := range []string{"https://", "http://", "ssh://", "git://"} {
+		trimmed = strings.TrimPrefix(trimmed, scheme)
+	}
+
+	// remove user info, e.g. git@ or x-access-token:token@
+	if at := strings.Index(trimmed, "@"); at >= 0 && !strings.Contains(trimmed[:at], "/") {
+		trimmed = trimmed[at+1:]
+	}
+
+	// host is separated with : in scp-like ssh urls and with / in the others. Port of the ssh urls is ignored.
+	host, path, _ := strings.Cut(trimmed, "/")
+	if h, p, found := strings.Cut(host, ":"); found {
+		host = h
+
+		if _, err := strconv.Atoi(p); err != nil {
+			path = p + "/" + path
+			ssh = true
+		}
+	}
+
+	if !slices.Contains(supportedGitHosts, host) {
+		return nil, fmt.Errorf("unsupported git URL format: %s, supported hosts are %s", url, strings.Join(supportedGitHosts, ", "))
+	}
+
+	path = strings.Trim(strings.TrimSuffix(strings.Trim(path, "/"), ".git"), "/")
+	parts := strings.Split(path, "/")
+
+	// only GitLab supports nested groups, other hosts must have exactly owner and repo
+	if len(parts) < 2 || (len(parts) > 2 && host != "gitlab.com") || slices.Contains(parts, "") {
+		return nil, fmt.Errorf("invalid git URL: %s", url)
+	}
+
+	return &gitURL{Host: host, Owner: parts[0], Name: parts[len(parts)-1], Path: path, SSH: ssh}, nil
+}
+
+// repoURL returns the clone url of the repository. The repository could be given as owner/name for GitHub
+// repositories, as host/owner/name, e.g. gitlab.com/owner/name, or as a https or ssh url. Ssh urls are kept as they
+// are to clone the private repositories with the ssh keys, the others are cloned over https.
+func repoURL(repo string) (string, error) {
+	host, _, _ := strings.Cut(repo, "/")
+
+	// owner/name of GitHub repositories
+	if !strings.Contains(repo, "://") && !strings.Contains(repo, "@") && !strings.Contains(host, ".") {
+		return fmt.Sprintf("https://github.com/%s.git", strings.TrimSuffix(repo, ".git")), nil
+	}
+
+	u, err := parseGitURL(repo)
+	if err != nil {
+		return "", err
+	}
+
+	if u.SSH {
+		return repo, nil
+	}
+
+	return fmt.Sprintf("https://%s/%s.git", u.Host, u.Path), nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"golang.org/x/mod/modfile"
 )
@@ -50,3 +51,49 @@ func GoBase(version string) *Container {
 		With(ModCache).
 		With(BuildCache)
 }
+
+// GoPlatform returns a function that configures the go build environment to cross compile for the given platform. The
+// platform format is os/arch[/variant], e.g. linux/arm64 or linux/arm/v7.
+func GoPlatform(platform Platform) func(*Container) *Container {
+	return func(c *Container) *Container {
+		for _, env := range goPlatformEnv(platform) {
+			key, value, _ := strings.Cut(env, "=")
+
+			c = c.WithEnvVariable(key, value)
+		}
+
+		return c
+	}
+}
+
+// goPlatformEnv returns the go environment variables in KEY=VALUE format to cross compile for the given platform. It
+// returns nil if the platform doesn't have an architecture.
+func goPlatformEnv(platform Platform) []string {
+	parts := strings.Split(string(platform), "/")
+
+	if len(parts) < 2 {
+		return nil
+	}
+
+	env := []string{"CGO_ENABLED=0", "GOOS=" + parts[0], "GOARCH=" + parts[1]}
+
+	if len(parts) > 2 && parts[1] == "arm" {
+		env = append(env, "GOARM="+strings.TrimPrefix(parts[2], "v"))
+	}
+
+	return env
+}
+
+// imageTag returns the tag of the given image address, e.g. v0.0.9 for ghcr.io/aweris/gale/tools/ghx:v0.0.9. It returns
+// an empty string if the address doesn't have a tag.
+func imageTag(address string) string {
+	// digest is not a tag, and colon of the registry port must not be taken as the tag separator
+	address, _, _ = strings.Cut(address, "@")
+
+	idx := strings.LastIndex(address, ":")
+	if idx == -1 || strings.Contains(address[idx:], "/") {
+		return ""
+	}
+
+	return address[idx+1:]
+}
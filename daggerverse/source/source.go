@@ -25,6 +25,10 @@ func (m *Source) ArtifactCacheService() *ArtifactCacheServiceSource {
 	return &ArtifactCacheServiceSource{}
 }
 
+func (m *Source) WebhookService() *WebhookServiceSource {
+	return &WebhookServiceSource{}
+}
+
 // GhxSource represents the source code of the ghx module.
 type GhxSource struct{}
 
@@ -56,17 +60,30 @@ func (m *GhxSource) MountedCode(c *Container) *Container {
 	return c.WithMountedDirectory("/src", m.Code()).WithWorkdir("/src/ghx")
 }
 
-// Binary adds the ghx binary to the given container and adds binary to the PATH environment variable.
-func (m *GhxSource) Binary(ctx context.Context, container *Container) (*Container, error) {
-	version, err := m.GoVersion(ctx)
+// Build returns the ghx binary built for the given platform. Format of the platform is os/arch[/variant].
+func (m *GhxSource) Build(ctx context.Context, platform Platform) (*File, error) {
+	return m.build(ctx, platform, "")
+}
+
+// build returns the ghx binary built for the given platform with the given version. If the version is empty, the
+// binary reports the default version of ghx.
+func (m *GhxSource) build(ctx context.Context, platform Platform, version string) (*File, error) {
+	goVersion, err := m.GoVersion(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	source, err := GoBase(version).
+	args := []string{"go", "build", "-o", "bin/ghx"}
+
+	if version != "" {
+		args = append(args, "-ldflags", fmt.Sprintf("-X github.com/aweris/gale/ghx.Version=%s", version))
+	}
+
+	source, err := GoBase(goVersion).
 		With(m.MountedCode).
+		With(GoPlatform(platform)).
 		WithExec([]string{"go", "mod", "download"}).
-		WithExec([]string{"go", "build", "-o", "bin/ghx", "."}).
+		WithExec(append(args, "./cmd/ghx")).
 		Sync(ctx)
 	if err != nil {
 		return nil, err
@@ -78,6 +95,27 @@ func (m *GhxSource) Binary(ctx context.Context, container *Container) (*Containe
 		return nil, fmt.Errorf("failed to build ghx: %w", err)
 	}
 
+	return binary, nil
+}
+
+// Binary adds the ghx binary to the given container and adds binary to the PATH environment variable. The binary is
+// built for the platform of the given container.
+func (m *GhxSource) Binary(ctx context.Context, container *Container) (*Container, error) {
+	return m.withBinary(ctx, container, "")
+}
+
+// withBinary adds the ghx binary built with the given version to the given container.
+func (m *GhxSource) withBinary(ctx context.Context, container *Container, version string) (*Container, error) {
+	platform, err := container.Platform(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	binary, err := m.build(ctx, platform, version)
+	if err != nil {
+		return nil, err
+	}
+
 	container = container.WithFile("/usr/local/bin/ghx", binary, ContainerWithFileOpts{Permissions: 0777})
 
 	path, err := container.EnvVariable(ctx, "PATH")
@@ -88,6 +126,50 @@ func (m *GhxSource) Binary(ctx context.Context, container *Container) (*Containe
 	return container.WithEnvVariable("PATH", fmt.Sprintf("%s:/usr/local/bin", path)), nil
 }
 
+// GhxImageOpts represents the options for building ghx images.
+type GhxImageOpts struct {
+	Base    string `doc:"The base image of the ghx image. Use a runner image to build a runner image with ghx." default:"alpine:latest"`
+	Version string `doc:"The version of ghx reported by ghx version. Publish defaults to the tag of the address."`
+}
+
+// Image returns the ghx image for the given platform.
+func (m *GhxSource) Image(ctx context.Context, platform string, opts GhxImageOpts) (*Container, error) {
+	base := opts.Base
+
+	// defaults are not applied when the method is called from the module itself
+	if base == "" {
+		base = "alpine:latest"
+	}
+
+	return m.withBinary(ctx, dag.Container(ContainerOpts{Platform: Platform(platform)}).From(base), opts.Version)
+}
+
+// Publish builds the ghx image for the given platforms and publishes it as a multi-platform image to the given address.
+// Published binaries report the tag of the address as their version unless a version is given, so gale can verify
+// the pinned ghx version before using the image.
+func (m *GhxSource) Publish(ctx context.Context, address string, platforms []string, opts GhxImageOpts) (string, error) {
+	if len(platforms) == 0 {
+		platforms = []string{"linux/amd64", "linux/arm64"}
+	}
+
+	if opts.Version == "" {
+		opts.Version = imageTag(address)
+	}
+
+	variants := make([]*Container, 0, len(platforms))
+
+	for _, platform := range platforms {
+		image, err := m.Image(ctx, platform, opts)
+		if err != nil {
+			return "", err
+		}
+
+		variants = append(variants, image)
+	}
+
+	return dag.Container().Publish(ctx, address, ContainerPublishOpts{PlatformVariants: variants})
+}
+
 // ArtifactServiceSource represents the source code of the artifact service.
 type ArtifactServiceSource struct{}
 
@@ -139,22 +221,19 @@ func (m *ArtifactServiceSource) Container(ctx context.Context) (*Container, erro
 		WithExec([]string{"go", "run", "."}), nil
 }
 
+// BindAsService binds the artifact service to the container and configures the actions runtime to use it. The service
+// is accessed by its alias, so the URL is stable across the sessions and matches the no proxy hosts. Trailing slash is
+// required since the toolkit appends the API paths to the URL as they are.
 func (m *ArtifactServiceSource) BindAsService(ctx context.Context, container *Container) (*Container, error) {
 	serviceContainer, err := m.Container(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	service := serviceContainer.AsService()
-
-	endpoint, err := service.Endpoint(ctx, ServiceEndpointOpts{Port: 8080, Scheme: "http"})
-	if err != nil {
-		return nil, err
-	}
-
 	return container.
-		WithServiceBinding("artifact-service", service).
-		WithEnvVariable("ACTIONS_RUNTIME_URL", endpoint).
+		WithServiceBinding("artifact-service", serviceContainer.AsService()).
+		WithEnvVariable("ACTIONS_RUNTIME_URL", "http://artifact-service:8080/").
+		WithEnvVariable("ACTIONS_RESULTS_URL", "http://artifact-service:8080/").
 		WithEnvVariable("ACTIONS_RUNTIME_TOKEN", "token"), nil
 }
 
@@ -209,21 +288,66 @@ func (m *ArtifactCacheServiceSource) Container(ctx context.Context) (*Container,
 		WithExec([]string{"go", "run", "."}), nil
 }
 
+// BindAsService binds the artifact cache service to the container and configures the actions cache to use it, same as
+// the artifact service.
 func (m *ArtifactCacheServiceSource) BindAsService(ctx context.Context, container *Container) (*Container, error) {
 	serviceContainer, err := m.Container(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	service := serviceContainer.AsService()
+	return container.
+		WithServiceBinding("artifact-cache-service", serviceContainer.AsService()).
+		WithEnvVariable("ACTIONS_CACHE_URL", "http://artifact-cache-service:8081/").
+		WithEnvVariable("ACTIONS_RUNTIME_TOKEN", "token"), nil
+}
+
+// WebhookServiceSource represents the source code of the webhook service.
+type WebhookServiceSource struct{}
+
+// Code returns the source code of the webhook service.
+func (m *WebhookServiceSource) Code() *Directory {
+	return dag.Host().root(HostDirectoryOpts{
+		Include: []string{
+			"services/webhook/**/*.go",
+			"services/webhook/go.*",
+		},
+	})
+}
+
+// GoMod returns the go.mod file of the webhook service.
+func (m *WebhookServiceSource) GoMod() *File {
+	return m.Code().Directory("services/webhook").File("go.mod")
+}
+
+// GoVersion returns the Go version of the webhook service.
+func (m *WebhookServiceSource) GoVersion(ctx context.Context) (string, error) {
+	return GoVersion(ctx, m.GoMod())
+}
+
+// MountedCode returns the source code of the webhook service mounted in a container at /src and sets the working
+// directory to /src/services/webhook.
+func (m *WebhookServiceSource) MountedCode(c *Container) *Container {
+	return c.WithMountedDirectory("/src", m.Code()).WithWorkdir("/src/services/webhook")
+}
 
-	endpoint, err := service.Endpoint(ctx, ServiceEndpointOpts{Port: 8081, Scheme: "http"})
+func (m *WebhookServiceSource) CacheVolume() *CacheVolume {
+	return dag.CacheVolume("gale-webhook-service")
+}
+
+// Container returns the container with the webhook service binary. The service is not started, since it needs to be
+// started with the privileged nesting to run the workflows with the same engine.
+func (m *WebhookServiceSource) Container(ctx context.Context) (*Container, error) {
+	version, err := m.GoVersion(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return container.
-		WithServiceBinding("artifact-cache-service", service).
-		WithEnvVariable("ACTIONS_CACHE_URL", endpoint).
-		WithEnvVariable("ACTIONS_RUNTIME_TOKEN", "token"), nil
+	return GoBase(version).
+		With(m.MountedCode).
+		WithExec([]string{"go", "build", "-o", "/usr/local/bin/webhook", "."}).
+		WithMountedCache("/events", m.CacheVolume(), ContainerWithMountedCacheOpts{Sharing: Shared}).
+		WithEnvVariable("EVENTS_DIR", "/events").
+		WithEnvVariable("PORT", "8080").
+		WithExposedPort(8080), nil
 }
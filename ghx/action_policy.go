@@ -0,0 +1,55 @@
+package ghx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+)
+
+// errActionNotAllowed is the policy error of the actions not allowed by the actions policy.
+var errActionNotAllowed = errors.New("action not allowed by the actions policy")
+
+// ActionPolicy is the policy of the remote actions allowed to run, like the allowed actions setting of GitHub. Local
+// actions and docker images are always allowed.
+type ActionPolicy struct {
+	Allow    []string // Allow is the patterns of the allowed actions. If empty, all actions not denied are allowed.
+	Deny     []string // Deny is the patterns of the denied actions. Deny has precedence over allow.
+	Override bool     // Override reports the actions not allowed as warnings instead of refusing them.
+}
+
+// newActionPolicy returns the actions policy of the configuration. It returns nil if the configuration doesn't have
+// any allow or deny patterns.
+func newActionPolicy(cfg context.GhxConfig) *ActionPolicy {
+	if len(cfg.ActionsAllow) == 0 && len(cfg.ActionsDeny) == 0 {
+		return nil
+	}
+
+	return &ActionPolicy{Allow: cfg.ActionsAllow, Deny: cfg.ActionsDeny, Override: cfg.ActionsPolicyOverride}
+}
+
+// Check returns a policy error if the action isn't allowed by the policy. If the policy is overridden, the action is
+// reported as a warning instead.
+func (p *ActionPolicy) Check(source string) error {
+	if p == nil || isLocalAction(source) || strings.HasPrefix(source, "docker://") {
+		return nil
+	}
+
+	var err error
+
+	if pattern, ok := matchActionPattern(p.Deny, source); ok {
+		err = fmt.Errorf("%w: %s matches the deny pattern %s", errActionNotAllowed, source, pattern)
+	} else if _, ok := matchActionPattern(p.Allow, source); !ok && len(p.Allow) > 0 {
+		err = fmt.Errorf("%w: %s doesn't match any of the allow patterns", errActionNotAllowed, source)
+	}
+
+	if err != nil && p.Override {
+		log.Warn(fmt.Sprintf("%v, running it since the actions policy is overridden", err))
+
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,41 @@
+package ghx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+)
+
+func TestActionPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     context.GhxConfig
+		source  string
+		allowed bool
+	}{
+		{name: "no policy", cfg: context.GhxConfig{}, source: "some/action@v1", allowed: true},
+		{name: "allowed org", cfg: context.GhxConfig{ActionsAllow: []string{"actions/*", "my-org/*"}}, source: "actions/checkout@v4", allowed: true},
+		{name: "allowed sub-directory action", cfg: context.GhxConfig{ActionsAllow: []string{"actions/*"}}, source: "actions/cache/save@v3", allowed: true},
+		{name: "not allowed", cfg: context.GhxConfig{ActionsAllow: []string{"actions/*"}}, source: "some/action@v1", allowed: false},
+		{name: "denied", cfg: context.GhxConfig{ActionsDeny: []string{"*/setup-random@*"}}, source: "any/setup-random@v1", allowed: false},
+		{name: "deny has precedence", cfg: context.GhxConfig{ActionsAllow: []string{"my-org/*"}, ActionsDeny: []string{"my-org/legacy"}}, source: "my-org/legacy@v1", allowed: false},
+		{name: "overridden", cfg: context.GhxConfig{ActionsAllow: []string{"actions/*"}, ActionsPolicyOverride: true}, source: "some/action@v1", allowed: true},
+		{name: "local action", cfg: context.GhxConfig{ActionsAllow: []string{"actions/*"}}, source: "./.github/actions/build", allowed: true},
+		{name: "docker image", cfg: context.GhxConfig{ActionsAllow: []string{"actions/*"}}, source: "docker://alpine:3.18", allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newActionPolicy(tt.cfg).Check(tt.source)
+
+			if tt.allowed && err != nil {
+				t.Errorf("expected %s to be allowed, got %v", tt.source, err)
+			}
+
+			if !tt.allowed && !errors.Is(err, errActionNotAllowed) {
+				t.Errorf("expected policy error for %s, got %v", tt.source, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package ghx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// commitSHARegex matches the full length commit SHAs. Only full length SHAs are immutable refs, short SHAs and tags
+// could be moved to a different commit.
+var commitSHARegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// archivedReposFile is the name of the file in the metadata directory caching the archived state of the repositories.
+const archivedReposFile = "archived_repos.json"
+
+// archivedReposTTL is the duration the archived state of a repository is cached for.
+const archivedReposTTL = 24 * time.Hour
+
+// checkActionRefs warns about the remote actions used by the workflow that are pinned to a mutable ref instead of a
+// commit SHA, on the actions denylist or, if enabled, from an archived repository. If pinned actions are required, it
+// returns an error with the list of the actions not pinned to a commit SHA. Actions not allowed by the actions policy
+// are refused before running the workflow as well.
+func checkActionRefs(ctx *context.Context, wf core.Workflow) error {
+	var (
+		unpinned []string
+		refused  []error
+		repos    = make(map[string][]string)
+		policy   = newActionPolicy(ctx.GhxConfig)
+	)
+
+	for _, source := range getRemoteActions(wf) {
+		repo, _, ref, err := parseRepoRef(source)
+		if err != nil {
+			return err
+		}
+
+		if !isPinnedRef(ref) {
+			unpinned = append(unpinned, source)
+
+			log.Warn(fmt.Sprintf("Action '%s' is pinned to the mutable ref '%s', pin it to a full length commit SHA", source, ref))
+		}
+
+		if pattern, ok := matchActionPattern(ctx.GhxConfig.ActionsDenylist, source); ok {
+			log.Warn(fmt.Sprintf("Action '%s' is on the actions denylist (%s)", source, pattern))
+		}
+
+		if err := policy.Check(source); err != nil {
+			refused = append(refused, err)
+		}
+
+		repos[repo] = append(repos[repo], source)
+	}
+
+	// archived repositories can't be checked without the network
+	if ctx.GhxConfig.CheckArchivedActions && !ctx.GhxConfig.Offline {
+		archived, err := archivedRepos(ctx, repos)
+		if err != nil {
+			return err
+		}
+
+		for _, repo := range archived {
+			for _, source := range repos[repo] {
+				log.Warn(fmt.Sprintf("Action '%s' is from the archived repository '%s', it doesn't receive updates anymore", source, repo))
+			}
+		}
+	}
+
+	if len(refused) > 0 {
+		return errors.Join(refused...)
+	}
+
+	if ctx.GhxConfig.RequirePinnedActions && len(unpinned) > 0 {
+		return fmt.Errorf("actions not pinned to a commit SHA: %s", strings.Join(unpinned, ", "))
+	}
+
+	return nil
+}
+
+// isPinnedRef returns true if the ref is a full length commit SHA.
+func isPinnedRef(ref string) bool {
+	return commitSHARegex.MatchString(ref)
+}
+
+// matchActionPattern returns the first pattern matching the action source. Patterns are matched with the source, the
+// action and the repository of the action, e.g. some-org/*, actions/checkout@v1 or actions/checkout.
+func matchActionPattern(patterns []string, source string) (string, bool) {
+	name, _, _ := strings.Cut(source, "@")
+
+	// repository of the actions in sub-directories, e.g. actions/cache for actions/cache/save
+	repo := name
+	if parts := strings.SplitN(name, "/", 3); len(parts) == 3 {
+		repo = parts[0] + "/" + parts[1]
+	}
+
+	for _, pattern := range patterns {
+		for _, target := range []string{source, name, repo} {
+			if ok, _ := path.Match(pattern, target); ok {
+				return pattern, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// archivedRepo is the cached archived state of a repository.
+type archivedRepo struct {
+	Archived  bool      `json:"archived"`   // Archived indicates if the repository is archived
+	CheckedAt time.Time `json:"checked_at"` // CheckedAt is the time the repository is checked
+}
+
+// archivedRepos returns the archived repositories of the given repositories in order. Archived states checked in the last day
+// are read from the cache in the metadata directory, the rest are checked with the GitHub API and cached. Repositories
+// failed to be checked are not cached and not reported, since the check is only used for warnings.
+func archivedRepos(ctx *context.Context, repos map[string][]string) ([]string, error) {
+	path, err := ctx.GetMetadataPath()
+	if err != nil {
+		return nil, err
+	}
+
+	file := filepath.Join(path, archivedReposFile)
+
+	var archived []string
+
+	err = fs.WithLock(file, func() error {
+		cache := make(map[string]archivedRepo)
+
+		exist, err := fs.Exists(file)
+		if err != nil {
+			return err
+		}
+
+		if exist {
+			if err := fs.ReadJSONFile(file, &cache); err != nil {
+				return fmt.Errorf("failed to read archived repositories: %w", err)
+			}
+		}
+
+		now := time.Now()
+
+		for repo := range repos {
+			state, ok := cache[repo]
+
+			if !ok || now.Sub(state.CheckedAt) > archivedReposTTL {
+				isArchived, err := isArchivedRepo(ctx.Github.APIURL, ctx.Github.Token, repo)
+				if err != nil {
+					log.Debugf("failed to check if the repository is archived", "repo", repo, "error", err)
+					continue
+				}
+
+				state = archivedRepo{Archived: isArchived, CheckedAt: now}
+
+				cache[repo] = state
+			}
+
+			if state.Archived {
+				archived = append(archived, repo)
+			}
+		}
+
+		return fs.WriteJSONFile(file, cache)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(archived)
+
+	return archived, nil
+}
+
+// isArchivedRepo returns true if the GitHub repository is archived. The token is used to check the private
+// repositories and to avoid the rate limits of the anonymous requests.
+func isArchivedRepo(apiURL, token, repo string) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/repos/%s", strings.TrimSuffix(apiURL, "/"), repo), nil)
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var info struct {
+		Archived bool `json:"archived"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return false, fmt.Errorf("failed to decode repository: %w", err)
+	}
+
+	return info.Archived, nil
+}
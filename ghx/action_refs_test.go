@@ -0,0 +1,110 @@
+package ghx
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+)
+
+func TestIsPinnedRef(t *testing.T) {
+	tests := map[string]bool{
+		"v4":      false,
+		"main":    false,
+		"b4ffde6": false,
+		"v4.1.1":  false,
+		"b4ffde65f46336ab88eb53be808477a3936bae11": true,
+	}
+
+	for ref, expected := range tests {
+		if got := isPinnedRef(ref); got != expected {
+			t.Errorf("isPinnedRef(%q) = %v, want %v", ref, got, expected)
+		}
+	}
+}
+
+func TestMatchActionPattern(t *testing.T) {
+	denylist := []string{"some-org/*", "actions/checkout@v1", "actions/cache/save", "other-org/toolkit"}
+
+	tests := []struct {
+		source  string
+		pattern string
+		denied  bool
+	}{
+		{source: "some-org/action@v1", pattern: "some-org/*", denied: true},
+		{source: "actions/checkout@v1", pattern: "actions/checkout@v1", denied: true},
+		{source: "actions/checkout@v4", denied: false},
+		{source: "actions/cache/save@v3", pattern: "actions/cache/save", denied: true},
+		{source: "actions/cache@v3", denied: false},
+		{source: "other-org/toolkit/setup@v2", pattern: "other-org/toolkit", denied: true},
+	}
+
+	for _, tt := range tests {
+		pattern, denied := matchActionPattern(denylist, tt.source)
+		if denied != tt.denied || pattern != tt.pattern {
+			t.Errorf("matchActionPattern(%q) = %q, %v, want %q, %v", tt.source, pattern, denied, tt.pattern, tt.denied)
+		}
+	}
+}
+
+func TestArchivedRepos(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.URL.Path)
+		mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/repos/old-org/action":
+			fmt.Fprint(w, `{"archived": true}`)
+		case "/repos/actions/checkout":
+			fmt.Fprint(w, `{"archived": false}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	ctx := &context.Context{
+		GhxConfig: context.GhxConfig{HomeDir: t.TempDir()},
+		Github:    context.GithubContext{APIURL: api.URL, Token: "token"},
+	}
+
+	repos := map[string][]string{
+		"old-org/action":   {"old-org/action@v1"},
+		"actions/checkout": {"actions/checkout@v4"},
+		"missing/action":   {"missing/action@v1"},
+	}
+
+	for i := 0; i < 2; i++ {
+		archived, err := archivedRepos(ctx, repos)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(archived, []string{"old-org/action"}) {
+			t.Errorf("expected old-org/action to be archived, got %v", archived)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// checked repositories are cached, repositories failed to be checked are checked again
+	if len(calls) != 4 {
+		t.Errorf("expected 4 api calls, got %v", calls)
+	}
+}
@@ -0,0 +1,104 @@
+package ghx
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+)
+
+// startAPIAudit starts the API audit proxy in the background and points the GitHub API URLs of the steps to it. The
+// proxy listens on the address of the runner container, so the steps running in their own containers reach it as well.
+func startAPIAudit(ctx *context.Context) error {
+	api, err := url.Parse(ctx.Github.APIURL)
+	if err != nil {
+		return fmt.Errorf("invalid api url %s: %w", ctx.Github.APIURL, err)
+	}
+
+	graphql, err := url.Parse(ctx.Github.GraphqlURL)
+	if err != nil {
+		return fmt.Errorf("invalid graphql url %s: %w", ctx.Github.GraphqlURL, err)
+	}
+
+	addr, err := runnerAddress()
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(addr, "0"))
+	if err != nil {
+		return fmt.Errorf("failed to start api audit proxy: %w", err)
+	}
+
+	audit := context.NewAPIAudit()
+
+	go func() {
+		if err := http.Serve(listener, newAPIAuditProxy(audit, api, graphql)); err != nil {
+			log.Errorf("API audit proxy stopped", "error", err)
+		}
+	}()
+
+	proxy := fmt.Sprintf("http://%s", listener.Addr().String())
+
+	ctx.APIAudit = audit
+	ctx.Github.APIURL = proxy
+	ctx.Github.GraphqlURL = proxy + "/graphql"
+
+	// steps running in the runner container inherit the environment of ghx
+	os.Setenv("GITHUB_API_URL", ctx.Github.APIURL)
+	os.Setenv("GITHUB_GRAPHQL_URL", ctx.Github.GraphqlURL)
+
+	return nil
+}
+
+// newAPIAuditProxy returns the reverse proxy forwarding the calls to the GitHub API and recording them to the audit.
+// Calls to /graphql are forwarded to the GraphQL API, the others to the REST API.
+func newAPIAuditProxy(audit *context.APIAudit, api, graphql *url.URL) http.Handler {
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			if r.In.URL.Path == "/graphql" {
+				r.SetURL(&url.URL{Scheme: graphql.Scheme, Host: graphql.Host})
+				r.Out.URL.Path = graphql.Path
+				r.Out.URL.RawPath = ""
+
+				return
+			}
+
+			r.SetURL(api)
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			audit.Record(resp.Request.Method, resp.Request.URL, resp.StatusCode)
+
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			audit.Record(r.Method, r.URL, http.StatusBadGateway)
+
+			log.Warnf("API audit proxy failed to forward the call", "method", r.Method, "error", err)
+
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+}
+
+// runnerAddress returns the first non loopback IPv4 address of the runner container.
+func runnerAddress() (string, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", err
+	}
+
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
+			return ipnet.IP.String(), nil
+		}
+	}
+
+	return "", errors.New("no address found to expose the api audit proxy to the steps")
+}
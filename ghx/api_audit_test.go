@@ -0,0 +1,86 @@
+package ghx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+)
+
+func TestAPIAuditProxy(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path == "/api/v3/repos/octo/app/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	api, _ := url.Parse(upstream.URL + "/api/v3")
+	graphql, _ := url.Parse(upstream.URL + "/api/graphql")
+
+	audit := context.NewAPIAudit()
+
+	proxy := httptest.NewServer(newAPIAuditProxy(audit, api, graphql))
+	defer proxy.Close()
+
+	for _, call := range []struct{ method, path string }{
+		{http.MethodGet, "/repos/octo/app/issues?state=open&access_token=secret-token"},
+		{http.MethodPost, "/graphql"},
+		{http.MethodGet, "/repos/octo/app/missing"},
+	} {
+		req, _ := http.NewRequest(call.method, proxy.URL+call.path, nil)
+		req.Header.Set("Authorization", "Bearer secret-token")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		resp.Body.Close()
+	}
+
+	expected := []context.APICall{
+		{Method: http.MethodGet, Path: "/api/v3/repos/octo/app/issues?access_token=%2A%2A%2A&state=open", Status: http.StatusOK},
+		{Method: http.MethodPost, Path: "/api/graphql", Status: http.StatusOK},
+		{Method: http.MethodGet, Path: "/api/v3/repos/octo/app/missing", Status: http.StatusNotFound},
+	}
+
+	calls := audit.Calls()
+
+	if len(calls) != len(expected) {
+		t.Fatalf("expected %d calls, got %d: %v", len(expected), len(calls), calls)
+	}
+
+	for i, call := range calls {
+		if call.Method != expected[i].Method || call.Path != expected[i].Path || call.Status != expected[i].Status {
+			t.Errorf("call %d: expected %s %s %d, got %s %s %d", i, expected[i].Method, expected[i].Path, expected[i].Status, call.Method, call.Path, call.Status)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(paths) != 3 || paths[1] != "/api/graphql" {
+		t.Errorf("unexpected upstream paths %v", paths)
+	}
+}
@@ -0,0 +1,97 @@
+package ghx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/aweris/gale/common/fs"
+)
+
+// attemptsDir is the directory in the workflow run directory keeping the reports of the previous attempts of the run.
+// Each attempt is kept in a directory named with its attempt number, e.g. attempts/1.
+const attemptsDir = "attempts"
+
+// previousAttempt is the previous attempt of a workflow run run again as a new attempt.
+type previousAttempt struct {
+	Dir        string `json:"-"`           // Dir is the directory of the previous attempt in the run history
+	RunID      string `json:"run_id"`      // RunID is the id of the workflow run
+	RunNumber  string `json:"run_number"`  // RunNumber is the number of the workflow run
+	RunAttempt string `json:"run_attempt"` // RunAttempt is the attempt number of the previous attempt
+}
+
+// loadPreviousAttempt loads the previous attempt of the workflow run from its directory in the run history.
+func loadPreviousAttempt(dir string) (*previousAttempt, error) {
+	previous := &previousAttempt{Dir: dir}
+
+	if err := fs.ReadJSONFile(filepath.Join(dir, "workflow_run.json"), previous); err != nil {
+		return nil, fmt.Errorf("failed to read previous attempt of the workflow run: %w", err)
+	}
+
+	if previous.RunID == "" {
+		return nil, fmt.Errorf("previous attempt in %s doesn't have a run id", dir)
+	}
+
+	// runs recorded before the attempts were tracked are the first attempts of their runs
+	if previous.RunAttempt == "" {
+		previous.RunAttempt = "1"
+	}
+
+	if previous.RunNumber == "" {
+		previous.RunNumber = "1"
+	}
+
+	return previous, nil
+}
+
+// next returns the attempt number of the new attempt. Invalid attempt numbers are treated as the first attempt.
+func (p *previousAttempt) next() string {
+	attempt, err := strconv.Atoi(p.RunAttempt)
+	if err != nil || attempt < 1 {
+		attempt = 1
+	}
+
+	return strconv.Itoa(attempt + 1)
+}
+
+// keep copies the reports of the previous attempt to the attempts directory of the given workflow run directory. The
+// attempts kept by the previous attempt are copied as they are, so all attempts of the run are kept side by side.
+func (p *previousAttempt) keep(runDir string) error {
+	entries, err := os.ReadDir(p.Dir)
+	if err != nil {
+		return err
+	}
+
+	if err := fs.EnsureDir(filepath.Join(runDir, attemptsDir, p.RunAttempt)); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		src := filepath.Join(p.Dir, entry.Name())
+
+		if entry.Name() == attemptsDir {
+			if err := copyDir(src, filepath.Join(runDir, attemptsDir)); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		dst := filepath.Join(runDir, attemptsDir, p.RunAttempt, entry.Name())
+
+		if entry.IsDir() {
+			if err := copyDir(src, dst); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := fs.CopyFile(src, dst); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package ghx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aweris/gale/common/fs"
+)
+
+func TestLoadPreviousAttempt(t *testing.T) {
+	tests := []struct {
+		name     string
+		report   string
+		wantErr  bool
+		expected previousAttempt
+		next     string
+	}{
+		{name: "first attempt", report: `{"run_id":"42","run_number":"7","run_attempt":"1"}`, expected: previousAttempt{RunID: "42", RunNumber: "7", RunAttempt: "1"}, next: "2"},
+		{name: "rerun attempt", report: `{"run_id":"42","run_number":"7","run_attempt":"3"}`, expected: previousAttempt{RunID: "42", RunNumber: "7", RunAttempt: "3"}, next: "4"},
+		{name: "recorded before attempts", report: `{"run_id":"42"}`, expected: previousAttempt{RunID: "42", RunNumber: "1", RunAttempt: "1"}, next: "2"},
+		{name: "invalid attempt", report: `{"run_id":"42","run_number":"7","run_attempt":"x"}`, expected: previousAttempt{RunID: "42", RunNumber: "7", RunAttempt: "x"}, next: "2"},
+		{name: "missing run id", report: `{"run_number":"7"}`, wantErr: true},
+		{name: "invalid report", report: `{`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			if err := os.WriteFile(filepath.Join(dir, "workflow_run.json"), []byte(tt.report), 0600); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			previous, err := loadPreviousAttempt(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadPreviousAttempt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			tt.expected.Dir = dir
+
+			if *previous != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, *previous)
+			}
+
+			if got := previous.next(); got != tt.next {
+				t.Errorf("expected next attempt %s, got %s", tt.next, got)
+			}
+		})
+	}
+}
+
+func TestPreviousAttempt_Keep(t *testing.T) {
+	var (
+		previous = t.TempDir()
+		run      = t.TempDir()
+	)
+
+	// second attempt of the run, keeping the first attempt already
+	for path, content := range map[string]string{
+		"workflow_run.json":                      `{"run_id":"42","run_attempt":"2"}`,
+		"jobs/5/job_run.json":                    `{"conclusion":"failure"}`,
+		"attempts/1/workflow_run.json":           `{"run_id":"42","run_attempt":"1"}`,
+		"attempts/1/jobs/3/job_run.json":         `{"conclusion":"failure"}`,
+		"attempts/1/jobs/3/steps/test/step.json": `{}`,
+	} {
+		if err := fs.WriteFile(filepath.Join(previous, path), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	attempt, err := loadPreviousAttempt(previous)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := attempt.keep(run); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, path := range []string{
+		"attempts/1/workflow_run.json",
+		"attempts/1/jobs/3/steps/test/step.json",
+		"attempts/2/workflow_run.json",
+		"attempts/2/jobs/5/job_run.json",
+	} {
+		if exist, _ := fs.Exists(filepath.Join(run, path)); !exist {
+			t.Errorf("expected %s to be kept", path)
+		}
+	}
+
+	// previous attempts of the previous attempt are not nested
+	if exist, _ := fs.Exists(filepath.Join(run, "attempts", "2", attemptsDir)); exist {
+		t.Errorf("expected attempts to be kept side by side, got nested attempts")
+	}
+
+	// the new attempt writes its own reports to the run directory
+	if exist, _ := fs.Exists(filepath.Join(run, "workflow_run.json")); exist {
+		t.Errorf("expected the report of the previous attempt not to be copied to the run directory")
+	}
+}
@@ -0,0 +1,130 @@
+package ghx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+	"github.com/aweris/gale/ghx/task"
+)
+
+// errStopped is the error returned when the workflow is stopped before completion by a breakpoint or by the user.
+var errStopped = errors.New("workflow stopped")
+
+var (
+	// breakpointReached indicates the break at step is reached. Interactive mode pauses only after the breakpoint.
+	breakpointReached bool
+
+	// interactiveDisabled indicates interactive mode is disabled since stdin is not a terminal.
+	interactiveDisabled bool
+
+	// stdin is the reader of the user input in interactive mode.
+	stdin = bufio.NewReader(os.Stdin)
+)
+
+// withBreakpoint wraps the conditional function of the step to pause before the step in interactive mode or stop the
+// workflow when the break at step is reached. Breakpoint is matched with the step id or name.
+func withBreakpoint(step core.Step, fn task.ConditionalFn) task.ConditionalFn {
+	return func(ctx *context.Context) (bool, core.Conclusion, error) {
+		run, conclusion, err := fn(ctx)
+		if err != nil || !run {
+			return run, conclusion, err
+		}
+
+		cfg := ctx.GhxConfig
+
+		if cfg.BreakAt != "" && !breakpointReached {
+			if cfg.BreakAt != step.ID && cfg.BreakAt != step.Name {
+				return run, conclusion, nil
+			}
+
+			breakpointReached = true
+
+			if !cfg.Interactive {
+				log.Infof("Breakpoint reached, stopping the workflow", "step", cfg.BreakAt)
+
+				if err := saveDebugEnv(ctx, "", stepEnv(ctx)); err != nil {
+					return false, core.ConclusionFailure, fmt.Errorf("failed to save debug shell environment: %w", err)
+				}
+
+				return false, core.ConclusionCancelled, fmt.Errorf("%w: breakpoint reached at step %s", errStopped, cfg.BreakAt)
+			}
+		}
+
+		if !cfg.Interactive || interactiveDisabled {
+			return run, conclusion, nil
+		}
+
+		if !isTerminal() {
+			log.Warn("Interactive mode requires a terminal, running the remaining steps without pausing")
+
+			interactiveDisabled = true
+
+			return run, conclusion, nil
+		}
+
+		return prompt(ctx, step, conclusion)
+	}
+}
+
+// prompt pauses before the step and asks the user to continue, skip the step, open a shell or abort the workflow.
+func prompt(ctx *context.Context, step core.Step, conclusion core.Conclusion) (bool, core.Conclusion, error) {
+	for {
+		fmt.Printf("Next step: %s\n[c]ontinue, [s]kip, s[h]ell, [a]bort: ", getStepName("", step))
+
+		input, err := stdin.ReadString('\n')
+		if err != nil {
+			return false, core.ConclusionFailure, fmt.Errorf("failed to read input: %w", err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(input)) {
+		case "", "c", "continue":
+			return true, conclusion, nil
+		case "s", "skip":
+			return false, core.ConclusionSkipped, nil
+		case "h", "shell":
+			if err := openShell(ctx); err != nil {
+				log.Errorf("failed to open shell", "error", err)
+			}
+		case "a", "abort":
+			return false, core.ConclusionCancelled, fmt.Errorf("%w: aborted by user", errStopped)
+		}
+	}
+}
+
+// openShell opens an interactive shell with the environment of the step. The step continues to wait for the user
+// input after the shell exits.
+func openShell(ctx *context.Context) error {
+	cmd := exec.Command("bash", "-i")
+
+	cmd.Env = stepEnv(ctx)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// stepEnv returns the environment of the current step with the env context.
+func stepEnv(ctx *context.Context) []string {
+	env := os.Environ()
+
+	for k, v := range ctx.Env {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	return env
+}
+
+// isTerminal returns true if stdin is a terminal to read the user input.
+func isTerminal() bool {
+	stat, err := os.Stdin.Stat()
+
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
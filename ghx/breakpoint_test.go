@@ -0,0 +1,61 @@
+package ghx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestWithBreakpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		breakAt    string
+		step       core.Step
+		run        bool
+		expected   bool
+		conclusion core.Conclusion
+		stopped    bool
+	}{
+		{name: "no breakpoint", step: core.Step{ID: "test"}, run: true, expected: true},
+		{name: "other step", breakAt: "deploy", step: core.Step{ID: "test"}, run: true, expected: true},
+		{name: "step id", breakAt: "test", step: core.Step{ID: "test"}, run: true, conclusion: core.ConclusionCancelled, stopped: true},
+		{name: "step name", breakAt: "Run tests", step: core.Step{ID: "test", Name: "Run tests"}, run: true, conclusion: core.ConclusionCancelled, stopped: true},
+		{name: "skipped step", breakAt: "test", step: core.Step{ID: "test"}, conclusion: core.ConclusionSkipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakpointReached = false
+
+			t.Cleanup(func() { breakpointReached = false })
+
+			ctx := newTestContext(t, &core.JobRun{RunID: "1", Job: core.Job{ID: "build"}})
+			ctx.GhxConfig.BreakAt = tt.breakAt
+
+			fn := withBreakpoint(tt.step, func(_ *context.Context) (bool, core.Conclusion, error) {
+				if !tt.run {
+					return false, core.ConclusionSkipped, nil
+				}
+
+				return true, "", nil
+			})
+
+			run, conclusion, err := fn(ctx)
+			if errors.Is(err, errStopped) != tt.stopped {
+				t.Fatalf("expected stopped %v, got error %v", tt.stopped, err)
+			}
+
+			if run != tt.expected || conclusion != tt.conclusion {
+				t.Errorf("expected run %v with conclusion %q, got run %v with conclusion %q", tt.expected, tt.conclusion, run, conclusion)
+			}
+
+			if _, err := os.Stat(filepath.Join(ctx.GhxConfig.HomeDir, "debug", "env.sh")); (err == nil) != tt.stopped {
+				t.Errorf("expected the debug environment to be saved only when stopped, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package ghx
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+)
+
+// checkoutRepository is the repository of the actions/checkout action.
+const checkoutRepository = "actions/checkout"
+
+// actionInput returns the value of the action input. Input names are case-insensitive.
+func actionInput(inputs map[string]string, name string) string {
+	for k, v := range inputs {
+		if strings.EqualFold(k, name) {
+			return strings.TrimSpace(v)
+		}
+	}
+
+	return ""
+}
+
+// checkoutFastPathReason returns the reason the actions/checkout step can't reuse the mounted workspace. It returns an
+// empty string if the step checks out the repository, the ref and the path already mounted to the workspace.
+func checkoutFastPathReason(ctx *context.Context, inputs map[string]string) string {
+	if repo := actionInput(inputs, "repository"); repo != "" && !strings.EqualFold(repo, ctx.Github.Repository) {
+		return fmt.Sprintf("repository %s is not the workflow repository", repo)
+	}
+
+	if ref := actionInput(inputs, "ref"); !isWorkspaceRef(ctx, ref) {
+		return fmt.Sprintf("ref %s is not the workflow ref", ref)
+	}
+
+	if path := actionInput(inputs, "path"); path != "" && filepath.Clean(path) != "." {
+		return fmt.Sprintf("path %s is not the workspace", path)
+	}
+
+	if submodules := actionInput(inputs, "submodules"); submodules != "" && submodules != "false" {
+		return "submodules are requested"
+	}
+
+	if lfs := actionInput(inputs, "lfs"); lfs == "true" {
+		return "lfs files are requested"
+	}
+
+	return ""
+}
+
+// isWorkspaceRef returns true if the ref is the ref of the workflow run, so it's the ref already mounted to the
+// workspace. Empty ref is the default ref of the checkout.
+func isWorkspaceRef(ctx *context.Context, ref string) bool {
+	switch {
+	case ref == "", ref == ctx.Github.Ref, ref == ctx.Github.RefName, ref == ctx.Github.SHA:
+		return true
+	case len(ref) >= 7 && strings.HasPrefix(ctx.Github.SHA, ref):
+		return true
+	default:
+		return false
+	}
+}
+
+// useCheckoutFastPath returns true if the current step is an actions/checkout of the repository already mounted to the
+// workspace, so it could be completed with the checkoutExecutor instead of cloning the repository again.
+func useCheckoutFastPath(ctx *context.Context) (bool, error) {
+	if !ctx.GhxConfig.CheckoutFastPath || !strings.EqualFold(ctx.Github.ActionRepository, checkoutRepository) {
+		return false, nil
+	}
+
+	inputs, err := ctx.ActionInputs()
+	if err != nil {
+		return false, err
+	}
+
+	if reason := checkoutFastPathReason(ctx, inputs); reason != "" {
+		log.Infof("Running actions/checkout, the mounted workspace can't be used", "reason", reason)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+var _ Executor = new(checkoutExecutor)
+
+// checkoutExecutor completes the actions/checkout step with the repository mounted to the workspace. Only the
+// additional history and tags requested with fetch-depth and fetch-tags are fetched.
+type checkoutExecutor struct{}
+
+func (e *checkoutExecutor) Execute(ctx *context.Context) error {
+	inputs, err := ctx.ActionInputs()
+	if err != nil {
+		return err
+	}
+
+	dir := ctx.Github.Workspace
+
+	log.Info("Skipping actions/checkout, repository is already mounted to the workspace")
+
+	commit := ctx.Github.SHA
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		log.Debugf("Workspace is not a git repository, nothing to fetch", "workspace", dir)
+	} else {
+		if actionInput(inputs, "fetch-depth") == "0" && gitOutput(dir, "rev-parse", "--is-shallow-repository") == "true" {
+			if err := runGit(dir, "fetch", "--unshallow", "--tags", "origin"); err != nil {
+				return fmt.Errorf("failed to fetch the history of the repository: %w", err)
+			}
+		} else if actionInput(inputs, "fetch-tags") == "true" {
+			if err := runGit(dir, "fetch", "--tags", "origin"); err != nil {
+				return fmt.Errorf("failed to fetch the tags of the repository: %w", err)
+			}
+		}
+
+		if head := gitOutput(dir, "rev-parse", "HEAD"); head != "" {
+			commit = head
+		}
+	}
+
+	// outputs of the action, so the next steps referring to them work the same
+	ctx.Execution.StepRun.Outputs["ref"] = ctx.Github.Ref
+	ctx.Execution.StepRun.Outputs["commit"] = commit
+
+	return nil
+}
+
+// runGit runs the git command in the directory and logs its output.
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	out, err := cmd.CombinedOutput()
+
+	if output := strings.TrimSpace(string(out)); output != "" {
+		log.Info(output)
+	}
+
+	return err
+}
+
+// gitOutput returns the trimmed output of the git command in the directory or an empty string if it fails.
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
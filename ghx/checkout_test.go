@@ -0,0 +1,63 @@
+package ghx
+
+import (
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestCheckoutFastPathReason(t *testing.T) {
+	ctx := &context.Context{}
+
+	ctx.Github.Repository = "aweris/gale"
+	ctx.Github.Ref = "refs/heads/main"
+	ctx.Github.RefName = "main"
+	ctx.Github.SHA = "0123456789abcdef0123456789abcdef01234567"
+
+	tests := []struct {
+		inputs   map[string]string
+		fastPath bool
+	}{
+		{inputs: map[string]string{}, fastPath: true},
+		{inputs: map[string]string{"repository": "Aweris/Gale", "ref": "main", "path": "./", "fetch-depth": "0"}, fastPath: true},
+		{inputs: map[string]string{"ref": "0123456"}, fastPath: true},
+		{inputs: map[string]string{"ref": "refs/heads/main", "submodules": "false"}, fastPath: true},
+		{inputs: map[string]string{"repository": "aweris/other"}, fastPath: false},
+		{inputs: map[string]string{"ref": "develop"}, fastPath: false},
+		{inputs: map[string]string{"path": "gale"}, fastPath: false},
+		{inputs: map[string]string{"submodules": "recursive"}, fastPath: false},
+		{inputs: map[string]string{"lfs": "true"}, fastPath: false},
+	}
+
+	for _, tt := range tests {
+		reason := checkoutFastPathReason(ctx, tt.inputs)
+
+		if (reason == "") != tt.fastPath {
+			t.Errorf("inputs %v: expected fast path %t, got reason %q", tt.inputs, tt.fastPath, reason)
+		}
+	}
+}
+
+func TestCheckoutExecutor(t *testing.T) {
+	ctx := &context.Context{
+		Execution: context.ExecutionContext{
+			CurrentAction: &core.CustomAction{},
+			StepRun:       &core.StepRun{Step: core.Step{Uses: "actions/checkout@v4"}, Outputs: make(map[string]string)},
+		},
+	}
+
+	ctx.Github.Workspace = t.TempDir()
+	ctx.Github.Ref = "refs/heads/main"
+	ctx.Github.SHA = "0123456789abcdef0123456789abcdef01234567"
+
+	if err := (&checkoutExecutor{}).Execute(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	outputs := ctx.Execution.StepRun.Outputs
+
+	if outputs["ref"] != ctx.Github.Ref || outputs["commit"] != ctx.Github.SHA {
+		t.Errorf("expected ref and commit outputs of the workflow, got %v", outputs)
+	}
+}
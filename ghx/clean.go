@@ -0,0 +1,301 @@
+package ghx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aweris/gale/ghx/context"
+)
+
+// historyRun represents a workflow run in the run history with the information needed to prune it.
+type historyRun struct {
+	ID            string    // ID is the id of the workflow run, the name of its directory in the history
+	StartedAt     time.Time // StartedAt is the time the workflow run started
+	CompletedAt   time.Time // CompletedAt is the time the workflow run completed
+	RetentionDays int       // RetentionDays is the number of days to keep the workflow run, zero keeps it forever
+	Size          int64     // Size is the total size of the run directory, the artifacts and the logs of the run
+}
+
+// CleanDirs removes the contents of the given directories, e.g. the cache volumes mounted by gale. With --dry-run, it
+// only prints what would be deleted with the sizes.
+//
+// Usage: ghx clean [--dry-run] <dir>...
+func CleanDirs(args []string) error {
+	var (
+		dryRun bool
+		dirs   []string
+	)
+
+	for _, arg := range args {
+		switch arg {
+		case "--dry-run":
+			dryRun = true
+		default:
+			dirs = append(dirs, arg)
+		}
+	}
+
+	if len(dirs) == 0 {
+		return errors.New("at least one directory is required, usage: ghx clean [--dry-run] <dir>...")
+	}
+
+	var total int64
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+
+			size := dirSize(path)
+			total += size
+
+			if err := removePath(path, size, dryRun); err != nil {
+				return err
+			}
+		}
+	}
+
+	printTotal(total, dryRun)
+
+	return nil
+}
+
+// PruneRuns removes the workflow runs from the run history whose retention days are passed since they completed. If the
+// max size is given, the oldest runs are removed as well until the history fits the size. Artifacts and live logs of the
+// removed runs are removed with them if their directories are given. With --dry-run, it only prints what would be
+// deleted.
+//
+// Usage: ghx prune-runs [--dry-run] [--max-size <size>] <history-dir> [<artifacts-dir> [<logs-dir>]]
+func PruneRuns(args []string) error {
+	var (
+		dryRun  bool
+		maxSize uint64
+		dirs    []string
+	)
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--dry-run":
+			dryRun = true
+		case "--max-size":
+			if i+1 >= len(args) {
+				return errors.New("max size value is required, e.g. --max-size 10g")
+			}
+
+			i++
+
+			size, err := context.ParseBytes(args[i])
+			if err != nil {
+				return fmt.Errorf("invalid max size %s: %w", args[i], err)
+			}
+
+			maxSize = size
+		default:
+			dirs = append(dirs, args[i])
+		}
+	}
+
+	if len(dirs) == 0 || len(dirs) > 3 {
+		return errors.New("usage: ghx prune-runs [--dry-run] [--max-size <size>] <history-dir> [<artifacts-dir> [<logs-dir>]]")
+	}
+
+	// artifacts and logs directories are optional
+	dirs = append(dirs, "", "")
+
+	history, artifacts, logs := dirs[0], dirs[1], dirs[2]
+
+	runs, err := loadHistoryRuns(history, artifacts, logs)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+
+	for _, run := range expiredRuns(runs, int64(maxSize), time.Now()) {
+		total += run.Size
+
+		paths := []string{filepath.Join(history, run.ID)}
+
+		if artifacts != "" {
+			paths = append(paths, filepath.Join(artifacts, run.ID))
+		}
+
+		if logs != "" {
+			paths = append(paths, filepath.Join(logs, fmt.Sprintf("%s.ndjson", run.ID)))
+		}
+
+		for _, path := range paths {
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+
+			if err := removePath(path, dirSize(path), dryRun); err != nil {
+				return err
+			}
+		}
+	}
+
+	printTotal(total, dryRun)
+
+	return nil
+}
+
+// loadHistoryRuns loads the workflow runs in the run history from their reports. Directories without a report, e.g. a
+// run still being copied, are skipped.
+func loadHistoryRuns(history, artifacts, logs string) ([]historyRun, error) {
+	entries, err := os.ReadDir(history)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]historyRun, 0, len(entries))
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(history, entry.Name(), "workflow_run.json"))
+		if err != nil {
+			continue
+		}
+
+		var report context.WorkflowRunReport
+
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, fmt.Errorf("failed to read report of workflow run %s: %w", entry.Name(), err)
+		}
+
+		// empty or invalid retention days keep the run forever
+		days, _ := strconv.Atoi(report.RetentionDays)
+
+		run := historyRun{
+			ID:            entry.Name(),
+			StartedAt:     report.StartedAt,
+			CompletedAt:   report.CompletedAt,
+			RetentionDays: days,
+			Size:          dirSize(filepath.Join(history, entry.Name())),
+		}
+
+		if artifacts != "" {
+			run.Size += dirSize(filepath.Join(artifacts, entry.Name()))
+		}
+
+		if logs != "" {
+			run.Size += dirSize(filepath.Join(logs, fmt.Sprintf("%s.ndjson", entry.Name())))
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
+// expiredRuns returns the runs to remove from the history. Runs are expired when their retention days are passed since
+// they completed. If the max size is positive, the oldest of the remaining runs are expired as well until the total
+// size of the history fits the max size. The latest run is never expired by the max size.
+func expiredRuns(runs []historyRun, maxSize int64, now time.Time) []historyRun {
+	sorted := make([]historyRun, len(runs))
+
+	copy(sorted, runs)
+
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].StartedAt.Before(sorted[j].StartedAt) })
+
+	var (
+		expired []historyRun
+		kept    []historyRun
+		size    int64
+	)
+
+	for _, run := range sorted {
+		if run.RetentionDays > 0 && now.Sub(run.CompletedAt) > time.Duration(run.RetentionDays)*24*time.Hour {
+			expired = append(expired, run)
+			continue
+		}
+
+		kept = append(kept, run)
+		size += run.Size
+	}
+
+	for maxSize > 0 && size > maxSize && len(kept) > 1 {
+		expired = append(expired, kept[0])
+		size -= kept[0].Size
+		kept = kept[1:]
+	}
+
+	return expired
+}
+
+// removePath removes the given path and prints it with its size. With dry run, it only prints the path.
+func removePath(path string, size int64, dryRun bool) error {
+	if dryRun {
+		fmt.Printf("would delete %s (%s)\n", path, formatSize(size))
+		return nil
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+
+	fmt.Printf("deleted %s (%s)\n", path, formatSize(size))
+
+	return nil
+}
+
+// printTotal prints the total size of the deleted paths.
+func printTotal(total int64, dryRun bool) {
+	if dryRun {
+		fmt.Printf("%s would be freed\n", formatSize(total))
+		return
+	}
+
+	fmt.Printf("%s freed\n", formatSize(total))
+}
+
+// dirSize returns the total size of the files under the given path. Unreadable files are ignored.
+func dirSize(path string) int64 {
+	var size int64
+
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size
+}
+
+// formatSize formats the size in bytes with a binary unit, e.g. 1.5 GiB.
+func formatSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,119 @@
+package ghx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpiredRuns(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	day := func(d int) time.Time { return now.AddDate(0, 0, -d) }
+
+	runs := []historyRun{
+		{ID: "3", StartedAt: day(1), CompletedAt: day(1), RetentionDays: 90, Size: 30},
+		{ID: "1", StartedAt: day(100), CompletedAt: day(100), RetentionDays: 90, Size: 10},
+		{ID: "2", StartedAt: day(50), CompletedAt: day(50), RetentionDays: 0, Size: 20},
+		{ID: "4", StartedAt: day(0), CompletedAt: day(0), RetentionDays: 90, Size: 40},
+	}
+
+	tests := []struct {
+		name    string
+		maxSize int64
+		want    string
+	}{
+		{name: "retention only", maxSize: 0, want: "1"},
+		{name: "history fits max size", maxSize: 90, want: "1"},
+		{name: "oldest runs over max size", maxSize: 70, want: "1,2"},
+		{name: "max size smaller than the latest run", maxSize: 1, want: "1,2,3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+
+			for _, run := range expiredRuns(runs, tt.maxSize, now) {
+				ids = append(ids, run.ID)
+			}
+
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("expiredRuns() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneRuns(t *testing.T) {
+	var (
+		root      = t.TempDir()
+		history   = filepath.Join(root, "runs")
+		artifacts = filepath.Join(root, "artifacts")
+		logs      = filepath.Join(root, "logs")
+		old       = time.Now().AddDate(0, 0, -10).Format(time.RFC3339)
+		recent    = time.Now().Format(time.RFC3339)
+	)
+
+	write := func(path, content string) {
+		t.Helper()
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(filepath.Join(history, "1", "workflow_run.json"), `{"started_at":"`+old+`","completed_at":"`+old+`","retention_days":"7"}`)
+	write(filepath.Join(artifacts, "1", "artifact.txt"), "artifact")
+	write(filepath.Join(logs, "1.ndjson"), "{}")
+	write(filepath.Join(history, "2", "workflow_run.json"), `{"started_at":"`+recent+`","completed_at":"`+recent+`","retention_days":"7"}`)
+	write(filepath.Join(history, "3", "ghx.log"), "run still being copied")
+
+	if err := PruneRuns([]string{"--dry-run", history, artifacts, logs}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(history, "1")); err != nil {
+		t.Fatalf("dry run should keep the expired run: %v", err)
+	}
+
+	if err := PruneRuns([]string{history, artifacts, logs}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, path := range []string{filepath.Join(history, "1"), filepath.Join(artifacts, "1"), filepath.Join(logs, "1.ndjson")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be deleted", path)
+		}
+	}
+
+	for _, path := range []string{filepath.Join(history, "2"), filepath.Join(history, "3")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to be kept: %v", path, err)
+		}
+	}
+
+	if err := PruneRuns([]string{"--max-size"}); err == nil {
+		t.Error("expected an error for the missing max size value")
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		512:           "512 B",
+		1536:          "1.5 KiB",
+		10 * 1 << 30:  "10.0 GiB",
+		3 * (1 << 20): "3.0 MiB",
+	}
+
+	for size, want := range tests {
+		if got := formatSize(size); got != want {
+			t.Errorf("formatSize(%d) = %s, want %s", size, got, want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Command ghx is the command line interface of the ghx library running the workflows in the runner container.
+package main
+
+import (
+	stdContext "context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/aweris/gale/ghx"
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/protocol"
+)
+
+// usage is the help message of the ghx commands.
+const usage = `Usage: ghx [command]
+
+Runs the workflow configured by gale in the runner container if no command is given.
+
+Commands:
+  version [--protocol]  Print the version of ghx or the version of the events protocol
+  logs                  Print the recorded journal of a workflow run
+  lsp                   Serve the language server of the workflow files over stdio
+  notify                Send the notifications of a completed workflow run
+  upload-artifacts      Upload the artifacts of a completed workflow run
+  clean                 Remove the contents of the cache directories
+  prune-runs            Remove the expired workflow runs
+  prefetch              Warm up the actions and the images of the workflow
+  fingerprint           Print the fingerprint of the inputs of the workflow run
+  lint [--strict]       Report the unsupported features of the workflow
+`
+
+// commands is the list of the commands ghx accepts.
+var commands = []string{"version", "logs", "lsp", "notify", "upload-artifacts", "clean", "prune-runs", "prefetch", "fingerprint", "lint"}
+
+func main() {
+	if len(os.Args) > 1 {
+		switch command := os.Args[1]; {
+		case command == "help" || command == "-h" || command == "--help":
+			fmt.Print(usage)
+			return
+		case !slices.Contains(commands, command):
+			fmt.Fprintf(os.Stderr, "unknown command %s\n\n%s", command, usage)
+			os.Exit(2)
+		}
+	}
+
+	// version command only prints the version of ghx, so gale can verify the binary it uses without a workflow. With
+	// --protocol, it prints the version of the protocol between ghx and gale instead.
+	if len(os.Args) > 1 && os.Args[1] == "version" {
+		if len(os.Args) > 2 && os.Args[2] == "--protocol" {
+			fmt.Println(protocol.Version)
+			return
+		}
+
+		fmt.Println(ghx.Version)
+		return
+	}
+
+	// logs command only prints a recorded journal, so it doesn't require the dagger client or the workflow context
+	if len(os.Args) > 1 && os.Args[1] == "logs" {
+		if err := ghx.PrintLogs(os.Args[2:]); err != nil {
+			fatalf("failed to print logs: %v", err)
+		}
+
+		return
+	}
+
+	// lsp command only serves the language server of the workflow files over stdio for the editors
+	if len(os.Args) > 1 && os.Args[1] == "lsp" {
+		if err := ghx.ServeLSP(os.Stdin, os.Stdout); err != nil {
+			fatalf("language server failed: %v", err)
+		}
+
+		return
+	}
+
+	// notify command only sends the notifications of a completed workflow run from its reports
+	if len(os.Args) > 1 && os.Args[1] == "notify" {
+		if err := ghx.SendNotifications(os.Args[2:]); err != nil {
+			fatalf("failed to send notifications: %v", err)
+		}
+
+		return
+	}
+
+	// upload-artifacts command only uploads the artifacts of a completed workflow run to the GitHub run running gale
+	if len(os.Args) > 1 && os.Args[1] == "upload-artifacts" {
+		if err := ghx.UploadArtifacts(os.Args[2:]); err != nil {
+			fatalf("failed to upload artifacts: %v", err)
+		}
+
+		return
+	}
+
+	// clean and prune-runs commands only remove the contents of the cache volumes and the expired runs mounted by gale
+	if len(os.Args) > 1 && os.Args[1] == "clean" {
+		if err := ghx.CleanDirs(os.Args[2:]); err != nil {
+			fatalf("failed to clean: %v", err)
+		}
+
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "prune-runs" {
+		if err := ghx.PruneRuns(os.Args[2:]); err != nil {
+			fatalf("failed to prune runs: %v", err)
+		}
+
+		return
+	}
+
+	stdctx := stdContext.Background()
+
+	untrusted, _ := strconv.ParseBool(os.Getenv("GHX_UNTRUSTED"))
+
+	client, err := ghx.NewDaggerClient(stdctx, untrusted)
+	if err != nil {
+		fatalf("failed to get dagger client: %v", err)
+	}
+
+	// Load context
+	ctx, err := context.New(stdctx, client)
+	if err != nil {
+		fatalf("failed to load context: %v", err)
+	}
+
+	ctx.GhxConfig.Version = ghx.Version
+
+	cfg := ctx.GhxConfig
+
+	// Load workflow
+	wf, err := ghx.LoadWorkflow(cfg, os.Stdin)
+	if err != nil {
+		fatalf("failed to load workflow: %v", err)
+	}
+
+	// prefetch command only warms up the actions cache and the images for the workflow without running it
+	if len(os.Args) > 1 && os.Args[1] == "prefetch" {
+		if err := ghx.Prefetch(ctx, wf); err != nil {
+			fatalf("%v", err)
+		}
+
+		return
+	}
+
+	// fingerprint command only prints the fingerprint of the inputs of the workflow run not visible to the engine cache
+	if len(os.Args) > 1 && os.Args[1] == "fingerprint" {
+		fp, err := ghx.Fingerprint(ctx, wf)
+		if err != nil {
+			fatalf("failed to fingerprint workflow run: %v", err)
+		}
+
+		fmt.Println(fp)
+
+		return
+	}
+
+	// lint command only reports the unsupported features of the workflow without running it
+	if len(os.Args) > 1 && os.Args[1] == "lint" {
+		strict := len(os.Args) > 2 && os.Args[2] == "--strict"
+
+		if err := ghx.LintWorkflowFile(wf, strict); err != nil {
+			fatalf("lint failed: %v", err)
+		}
+
+		return
+	}
+
+	// Run the workflow
+	if err := ghx.Run(ctx, wf); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+// fatalf prints the error message to stderr and exits with a non-zero code.
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
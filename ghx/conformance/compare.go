@@ -0,0 +1,94 @@
+package conformance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Divergence is a difference between the recorded GitHub run and the gale run.
+type Divergence struct {
+	Path     string // Path is the location of the value in the run, e.g. jobs.build.steps.test.outputs.version
+	Expected string // Expected is the value recorded on GitHub
+	Actual   string // Actual is the value produced by gale
+}
+
+func (d Divergence) String() string {
+	return fmt.Sprintf("%s: expected %q, got %q", d.Path, d.Expected, d.Actual)
+}
+
+// Compare returns the divergences of the actual run from the expected run, sorted by path. Only the jobs and steps of
+// the expected run are compared since recordings can't include the record job itself and the steps without an id.
+// Outputs are compared in both directions, so missing and unexpected outputs are reported as well.
+func Compare(expected, actual Run) []Divergence {
+	var divergences []Divergence
+
+	add := func(path, expected, actual string) {
+		if expected != actual {
+			divergences = append(divergences, Divergence{Path: path, Expected: expected, Actual: actual})
+		}
+	}
+
+	if expected.Conclusion != "" {
+		add("conclusion", string(expected.Conclusion), string(actual.Conclusion))
+	}
+
+	for id, ej := range expected.Jobs {
+		path := "jobs." + id
+
+		aj, ok := actual.Jobs[id]
+		if !ok {
+			add(path, "job run", "")
+			continue
+		}
+
+		add(path+".conclusion", string(ej.Conclusion), string(aj.Conclusion))
+
+		compareOutputs(path+".outputs", ej.Outputs, aj.Outputs, add)
+
+		for sid, es := range ej.Steps {
+			spath := path + ".steps." + sid
+
+			as, ok := aj.Steps[sid]
+			if !ok {
+				add(spath, "step run", "")
+				continue
+			}
+
+			add(spath+".conclusion", string(es.Conclusion), string(as.Conclusion))
+			add(spath+".outcome", string(es.Outcome), string(as.Outcome))
+
+			compareOutputs(spath+".outputs", es.Outputs, as.Outputs, add)
+		}
+	}
+
+	sort.Slice(divergences, func(i, j int) bool { return divergences[i].Path < divergences[j].Path })
+
+	return divergences
+}
+
+// compareOutputs compares the outputs in both directions. Missing outputs are reported with an empty value like the
+// expressions evaluate them.
+func compareOutputs(path string, expected, actual map[string]string, add func(path, expected, actual string)) {
+	for k, v := range expected {
+		add(path+"."+k, v, actual[k])
+	}
+
+	for k, v := range actual {
+		if _, ok := expected[k]; !ok {
+			add(path+"."+k, "", v)
+		}
+	}
+}
+
+// Report returns the divergences as a human-readable report, one divergence per line.
+func Report(divergences []Divergence) string {
+	var sb strings.Builder
+
+	for _, d := range divergences {
+		sb.WriteString(d.String())
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,203 @@
+package conformance
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// TestConformance compares the gale runs of the reference workflows with the recorded GitHub runs. The runs are
+// read from the directory in GALE_CONFORMANCE_RUNS, where each reference workflow has a directory with the same name as
+// its fixture containing the exported directory of the gale run. See ci/conformance/README.md for details.
+func TestConformance(t *testing.T) {
+	runs := os.Getenv("GALE_CONFORMANCE_RUNS")
+	if runs == "" {
+		t.Skip("GALE_CONFORMANCE_RUNS is not set")
+	}
+
+	fixtures, err := filepath.Glob("../../ci/conformance/fixtures/*.json")
+	if err != nil {
+		t.Fatalf("failed to list fixtures: %v", err)
+	}
+
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".json")
+
+		t.Run(name, func(t *testing.T) {
+			expected, err := LoadFixture(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dirs, err := filepath.Glob(filepath.Join(runs, name, "runs", "*"))
+			if err != nil || len(dirs) != 1 {
+				t.Fatalf("expected a single run of %s in %s, found %d", name, runs, len(dirs))
+			}
+
+			actual, err := LoadRun(dirs[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if divergences := Compare(expected, actual); len(divergences) > 0 {
+				t.Errorf("gale run diverges from GitHub:\n%s", Report(divergences))
+			}
+		})
+	}
+}
+
+func TestParseRecording(t *testing.T) {
+	recording := `{
+		"build": {
+			"result": "success",
+			"outputs": {
+				"version": "1.0.0",
+				"steps": "{\"version\":{\"outputs\":{\"value\":\"1.0.0\"},\"outcome\":\"success\",\"conclusion\":\"success\"}}"
+			}
+		},
+		"deploy": {"result": "skipped", "outputs": {}}
+	}`
+
+	run, err := ParseRecording("ci.yaml", []byte(recording))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Run{
+		Workflow: "ci.yaml",
+		Jobs: map[string]Job{
+			"build": {
+				Conclusion: core.ConclusionSuccess,
+				Outputs:    map[string]string{"version": "1.0.0"},
+				Steps: map[string]Step{
+					"version": {Conclusion: core.ConclusionSuccess, Outcome: core.ConclusionSuccess, Outputs: map[string]string{"value": "1.0.0"}},
+				},
+			},
+			"deploy": {Conclusion: core.ConclusionSkipped, Outputs: map[string]string{}},
+		},
+	}
+
+	if !reflect.DeepEqual(run, expected) {
+		t.Errorf("expected %v, got %v", expected, run)
+	}
+}
+
+func TestLoadRun(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(path string, val interface{}) {
+		if err := fs.WriteJSONFile(filepath.Join(append([]string{dir}, strings.Split(path, "/")...)...), val); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	write("workflow_run.json", context.WorkflowRunReport{Path: "ci.yaml", Conclusion: core.ConclusionFailure})
+	write("jobs/1/job_run.json", context.JobRunReport{
+		ID:         "build",
+		Conclusion: core.ConclusionFailure,
+		Outputs:    map[string]string{"steps": "{}", "version": "1.0.0"},
+		Steps:      []context.StepRunSummary{{ID: "test", Stage: core.StepStagePre}, {ID: "test", Stage: core.StepStageMain}},
+	})
+	write("jobs/1/steps/test/step_run.json", context.StepRunReport{ID: "test", Conclusion: core.ConclusionFailure, Outcome: core.ConclusionFailure})
+
+	run, err := LoadRun(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Run{
+		Workflow:   "ci.yaml",
+		Conclusion: core.ConclusionFailure,
+		Jobs: map[string]Job{
+			"build": {
+				Conclusion: core.ConclusionFailure,
+				Outputs:    map[string]string{"version": "1.0.0"},
+				Steps:      map[string]Step{"test": {Conclusion: core.ConclusionFailure, Outcome: core.ConclusionFailure}},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(run, expected) {
+		t.Errorf("expected %v, got %v", expected, run)
+	}
+
+	// a second run of the same job, e.g. a matrix job, can't be compared with the recording
+	write("jobs/2/job_run.json", context.JobRunReport{ID: "build"})
+
+	if _, err := LoadRun(dir); err == nil {
+		t.Error("expected error for multiple runs of the same job")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	expected := Run{
+		Conclusion: core.ConclusionFailure,
+		Jobs: map[string]Job{
+			"build": {
+				Conclusion: core.ConclusionSuccess,
+				Outputs:    map[string]string{"version": "1.0.0"},
+				Steps: map[string]Step{
+					"lint": {Conclusion: core.ConclusionSuccess, Outcome: core.ConclusionFailure},
+					"test": {Conclusion: core.ConclusionSuccess, Outcome: core.ConclusionSuccess, Outputs: map[string]string{"env": "ci"}},
+				},
+			},
+			"deploy": {Conclusion: core.ConclusionSkipped},
+		},
+	}
+
+	actual := Run{
+		Conclusion: core.ConclusionFailure,
+		Jobs: map[string]Job{
+			"build": {
+				Conclusion: core.ConclusionSuccess,
+				Outputs:    map[string]string{"version": "1.0.0", "extra": "value"},
+				Steps: map[string]Step{
+					"lint": {Conclusion: core.ConclusionFailure, Outcome: core.ConclusionFailure},
+					"test": {Conclusion: core.ConclusionSuccess, Outcome: core.ConclusionSuccess},
+					"0":    {Conclusion: core.ConclusionSuccess, Outcome: core.ConclusionSuccess},
+				},
+			},
+			// jobs missing in the recording, e.g. the record job, are ignored
+			"record": {Conclusion: core.ConclusionSuccess},
+		},
+	}
+
+	got := Compare(expected, actual)
+
+	want := []Divergence{
+		{Path: "jobs.build.outputs.extra", Expected: "", Actual: "value"},
+		{Path: "jobs.build.steps.lint.conclusion", Expected: "success", Actual: "failure"},
+		{Path: "jobs.build.steps.test.outputs.env", Expected: "ci", Actual: ""},
+		{Path: "jobs.deploy", Expected: "job run", Actual: ""},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected:\n%s\ngot:\n%s", Report(want), Report(got))
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	fixtures, err := filepath.Glob("../../ci/conformance/fixtures/*.json")
+	if err != nil {
+		t.Fatalf("failed to list fixtures: %v", err)
+	}
+
+	for _, fixture := range fixtures {
+		run, err := LoadFixture(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		workflow := filepath.Join("../../ci/conformance/workflows", run.Workflow)
+
+		if exist, _ := fs.Exists(workflow); !exist {
+			t.Errorf("%s: reference workflow %s doesn't exist", fixture, workflow)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// RecordOutput is the name of the job output the reference workflows use to record the steps context of the job as
+// JSON. The output is only used to record the step results, so it's not compared as a job output.
+const RecordOutput = "steps"
+
+// Run is the comparable result of a workflow run. Recorded GitHub runs and gale runs are converted to this form before
+// they're compared.
+type Run struct {
+	Workflow   string          `json:"workflow"`             // Workflow is the path of the reference workflow
+	Conclusion core.Conclusion `json:"conclusion,omitempty"` // Conclusion is the result of the workflow run, not compared if empty
+	Jobs       map[string]Job  `json:"jobs"`                 // Jobs is map of the job id to its result
+}
+
+// Job is the comparable result of a job run.
+type Job struct {
+	Conclusion core.Conclusion   `json:"conclusion"`        // Conclusion is the result of the job after continue-on-error is applied
+	Outputs    map[string]string `json:"outputs,omitempty"` // Outputs is the outputs of the job
+	Steps      map[string]Step   `json:"steps,omitempty"`   // Steps is map of the step id to its result
+}
+
+// Step is the comparable result of a step run. Reference workflows export the env and context values they check as
+// step outputs, so they're compared as outputs as well.
+type Step struct {
+	Conclusion core.Conclusion   `json:"conclusion"`        // Conclusion is the result of the step after continue-on-error is applied
+	Outcome    core.Conclusion   `json:"outcome"`           // Outcome is the result of the step before continue-on-error is applied
+	Outputs    map[string]string `json:"outputs,omitempty"` // Outputs is the outputs of the step
+}
+
+// LoadFixture loads the recorded GitHub run from the given fixture file.
+func LoadFixture(file string) (Run, error) {
+	var run Run
+
+	if err := fs.ReadJSONFile(file, &run); err != nil {
+		return Run{}, fmt.Errorf("failed to read fixture %s: %w", file, err)
+	}
+
+	return run, nil
+}
+
+// ParseRecording converts the needs context recorded by the reference workflow to a run. The recording is the
+// output of toJSON(needs) in the record job, where each job exports its steps context as the RecordOutput output.
+func ParseRecording(workflow string, data []byte) (Run, error) {
+	var needs map[string]struct {
+		Result  core.Conclusion   `json:"result"`
+		Outputs map[string]string `json:"outputs"`
+	}
+
+	if err := json.Unmarshal(data, &needs); err != nil {
+		return Run{}, fmt.Errorf("failed to parse recording: %w", err)
+	}
+
+	run := Run{Workflow: workflow, Jobs: make(map[string]Job, len(needs))}
+
+	for id, need := range needs {
+		job := Job{Conclusion: need.Result, Outputs: make(map[string]string)}
+
+		for k, v := range need.Outputs {
+			if k != RecordOutput {
+				job.Outputs[k] = v
+			}
+		}
+
+		// skipped jobs don't set outputs, so the steps context might be missing
+		if raw := need.Outputs[RecordOutput]; raw != "" {
+			if err := json.Unmarshal([]byte(raw), &job.Steps); err != nil {
+				return Run{}, fmt.Errorf("failed to parse steps of job %s: %w", id, err)
+			}
+		}
+
+		run.Jobs[id] = job
+	}
+
+	return run, nil
+}
+
+// LoadRun loads the result of a gale run from the reports written to the given workflow run directory.
+func LoadRun(dir string) (Run, error) {
+	var wr context.WorkflowRunReport
+
+	if err := fs.ReadJSONFile(filepath.Join(dir, "workflow_run.json"), &wr); err != nil {
+		return Run{}, fmt.Errorf("failed to read workflow run report: %w", err)
+	}
+
+	run := Run{Workflow: wr.Path, Conclusion: wr.Conclusion, Jobs: make(map[string]Job)}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "jobs"))
+	if err != nil {
+		return Run{}, fmt.Errorf("failed to read job runs: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		var jr context.JobRunReport
+
+		if err := fs.ReadJSONFile(filepath.Join(dir, "jobs", entry.Name(), "job_run.json"), &jr); err != nil {
+			return Run{}, fmt.Errorf("failed to read job run report of %s: %w", entry.Name(), err)
+		}
+
+		// recordings keep a single result per job, so there is nothing to compare the matrix job runs with
+		if _, exist := run.Jobs[jr.ID]; exist {
+			return Run{}, fmt.Errorf("job %s has multiple runs, matrix jobs are not supported", jr.ID)
+		}
+
+		job := Job{Conclusion: jr.Conclusion, Outputs: make(map[string]string), Steps: make(map[string]Step)}
+
+		for k, v := range jr.Outputs {
+			if k != RecordOutput {
+				job.Outputs[k] = v
+			}
+		}
+
+		for _, summary := range jr.Steps {
+			if _, exist := job.Steps[summary.ID]; exist {
+				continue
+			}
+
+			var sr context.StepRunReport
+
+			if err := fs.ReadJSONFile(filepath.Join(dir, "jobs", entry.Name(), "steps", summary.ID, "step_run.json"), &sr); err != nil {
+				return Run{}, fmt.Errorf("failed to read step run report of %s/%s: %w", jr.ID, summary.ID, err)
+			}
+
+			job.Steps[summary.ID] = Step{Conclusion: sr.Conclusion, Outcome: sr.Outcome, Outputs: sr.Outputs}
+		}
+
+		run.Jobs[jr.ID] = job
+	}
+
+	return run, nil
+}
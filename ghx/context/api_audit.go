@@ -0,0 +1,88 @@
+package context
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aweris/gale/common/log"
+)
+
+// APICall is a GitHub API call recorded by the API audit proxy. Headers and bodies aren't recorded.
+type APICall struct {
+	Time   time.Time `json:"time"`           // Time is the time the call is completed
+	Job    string    `json:"job,omitempty"`  // Job is the display name of the job running when the call is made
+	Step   string    `json:"step,omitempty"` // Step is the display name of the step running when the call is made
+	Method string    `json:"method"`         // Method is the HTTP method of the call
+	Path   string    `json:"path"`           // Path is the path of the call with the query, tokens are redacted
+	Status int       `json:"status"`         // Status is the HTTP status of the response, 502 if the API is unreachable
+}
+
+// APIAudit records the GitHub API calls made during the workflow run. Calls are attributed to the step running when
+// the call is made, since the steps run one at a time in a job.
+type APIAudit struct {
+	mu    sync.Mutex
+	job   string
+	step  string
+	calls []APICall
+}
+
+// NewAPIAudit returns a new empty API audit.
+func NewAPIAudit() *APIAudit {
+	return &APIAudit{}
+}
+
+// Record records the API call with the given method, URL and response status. Query values of the token-like
+// parameters and the masked values are redacted.
+func (a *APIAudit) Record(method string, u *url.URL, status int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.calls = append(a.calls, APICall{
+		Time:   time.Now(),
+		Job:    a.job,
+		Step:   a.step,
+		Method: method,
+		Path:   redactURL(u),
+		Status: status,
+	})
+}
+
+// Calls returns the recorded API calls in the order they're completed.
+func (a *APIAudit) Calls() []APICall {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]APICall(nil), a.calls...)
+}
+
+// setStep sets the job and the step the following calls are attributed to.
+func (a *APIAudit) setStep(job, step string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.job, a.step = job, step
+}
+
+// redactURL returns the path with the query of the URL. Values of the query parameters carrying credentials are
+// replaced and the masked values are redacted from the result.
+func redactURL(u *url.URL) string {
+	path := u.EscapedPath()
+
+	if u.RawQuery != "" {
+		query := u.Query()
+
+		for key := range query {
+			name := strings.ToLower(key)
+
+			if strings.Contains(name, "token") || strings.Contains(name, "secret") || strings.Contains(name, "password") || name == "sig" {
+				query[key] = []string{"***"}
+			}
+		}
+
+		path += "?" + query.Encode()
+	}
+
+	return log.Mask(path)
+}
@@ -10,6 +10,10 @@ type GhxConfig struct {
 	// Workflow name to run.
 	Workflow string `env:"GHX_WORKFLOW"`
 
+	// WorkflowFile is the path of the workflow file to run instead of looking up the workflow in the workflows
+	// directory, e.g. a generated workflow.
+	WorkflowFile string `env:"GHX_WORKFLOW_FILE"`
+
 	// Job name to run. If not specified, the all jobs will be run.
 	Job string `env:"GHX_JOB"`
 
@@ -18,6 +22,204 @@ type GhxConfig struct {
 
 	// Home directory for the ghx to use for storing execution related files.
 	HomeDir string `env:"GHX_HOME" envDefault:"/home/runner/_temp/ghx"`
+
+	// Offline disables downloading actions and pins the prefetched images. If an action, an image or a tool is not
+	// available in the caches, execution fails before running the workflow.
+	Offline bool `env:"GHX_OFFLINE" envDefault:"false"`
+
+	// RequirePinnedActions fails the workflow before running it if any of the remote actions is not pinned to a full
+	// length commit SHA.
+	RequirePinnedActions bool `env:"GHX_REQUIRE_PINNED_ACTIONS" envDefault:"false"`
+
+	// CheckArchivedActions warns about the remote actions from archived repositories. Repositories are checked with the
+	// GitHub API and the results are cached in the metadata directory. It's skipped in offline mode.
+	CheckArchivedActions bool `env:"GHX_CHECK_ARCHIVED_ACTIONS" envDefault:"false"`
+
+	// ActionsDenylist is the list of action patterns to warn about, e.g. some-org/* or actions/checkout@v1.
+	ActionsDenylist []string `env:"GHX_ACTIONS_DENYLIST"`
+
+	// ActionsAllow is the list of action patterns allowed to run, e.g. actions/* or my-org/*. If empty, all actions not
+	// denied are allowed.
+	ActionsAllow []string `env:"GHX_ACTIONS_ALLOW"`
+
+	// ActionsDeny is the list of action patterns refused to run, e.g. */setup-random@*. Deny has precedence over allow.
+	ActionsDeny []string `env:"GHX_ACTIONS_DENY"`
+
+	// ActionsPolicyOverride reports the actions not allowed by the actions policy as warnings instead of refusing them,
+	// for local experimentation.
+	ActionsPolicyOverride bool `env:"GHX_ACTIONS_POLICY_OVERRIDE" envDefault:"false"`
+
+	// ExternalsDir is the directory of the node runtimes of the javascript actions. Runtimes are installed to it on
+	// first use in <dir>/<version>/bin/node format, e.g. /home/runner/externals/node20/bin/node, like the GitHub runner.
+	ExternalsDir string `env:"GHX_EXTERNALS_DIR" envDefault:"/home/runner/externals"`
+
+	// DockerEnabled indicates a docker engine is bound to the runner and DOCKER_HOST is configured.
+	DockerEnabled bool `env:"GHX_DOCKER_ENABLED" envDefault:"false"`
+
+	// Limits is the resource limits of the jobs. Format: job=cpu:4,mem:8g;job2=cpu:2
+	Limits JobLimits `env:"GHX_LIMITS"`
+
+	// CheckoutFastPath completes the actions/checkout steps of the repository already mounted to the workspace without
+	// cloning it again.
+	CheckoutFastPath bool `env:"GHX_CHECKOUT_FAST_PATH" envDefault:"true"`
+
+	// ToolLayers prepares the tools of the setup actions with pinned versions, e.g. setup-go, in the tool cache from
+	// the official images of the tools instead of downloading them with the actions.
+	ToolLayers bool `env:"GHX_TOOL_LAYERS" envDefault:"false"`
+
+	// WorkspaceMode is the sharing mode of the workspace between the jobs of the workflow run.
+	WorkspaceMode WorkspaceMode `env:"GHX_WORKSPACE_MODE" envDefault:"shared"`
+
+	// PreserveWorkspaces is the list of job ids keeping their workspace between the workflow runs, like clean: false
+	// of the self-hosted runners. Jobs start with the workspace they left in the previous run.
+	PreserveWorkspaces []string `env:"GHX_PRESERVE_WORKSPACES"`
+
+	// WorkspacesDir is the directory to keep the preserved workspaces of the jobs between the workflow runs.
+	WorkspacesDir string `env:"GHX_WORKSPACES_DIR"`
+
+	// Matrix is the list of matrix filters in key=value format. If set, only the matching combinations of the matrix
+	// jobs are run.
+	Matrix []string `env:"GHX_MATRIX"`
+
+	// Retries is the retry policies of the steps. Format: step=max:3,backoff:10s,on:failure|timeout;job/step2=max:2
+	Retries StepRetries `env:"GHX_RETRIES"`
+
+	// EventsSocket is the path of the unix socket to stream the execution events as newline delimited JSON while the
+	// workflow is running.
+	EventsSocket string `env:"GHX_EVENTS_SOCKET"`
+
+	// Output is the format of the console output. With ndjson, the execution events are printed to stdout as newline
+	// delimited JSON instead of the logs, and the logs are printed to stderr.
+	Output OutputFormat `env:"GHX_OUTPUT" envDefault:"text"`
+
+	// Env is the environment variables injected to the workflow run. Format: KEY=VALUE lines.
+	Env EnvOverrides `env:"GHX_ENV"`
+
+	// EnvPrecedence is the scope of the env blocks the env overrides have precedence over. Possible values are
+	// workflow, job and step.
+	EnvPrecedence EnvPrecedence `env:"GHX_ENV_PRECEDENCE" envDefault:"workflow"`
+
+	// RetentionDays is the number of days to keep the workflow run in the run history with its artifacts and logs.
+	// Zero keeps the run forever. Expired runs are pruned by gale after the next runs.
+	RetentionDays int `env:"GHX_RETENTION_DAYS" envDefault:"90"`
+
+	// Report is the list of report formats to render into the workflow run directory. Supported formats: html
+	Report []string `env:"GHX_REPORT"`
+
+	// FailOn is the policy setting the exit code of ghx from the workflow run result. Possible values are: error, fails
+	// on the failed workflow or error annotations, warning, fails on warning annotations as well, none, never fails.
+	// Gale applies the policy to the report of the workflow run instead, so it keeps the default.
+	FailOn string `env:"GHX_FAIL_ON" envDefault:"none"`
+
+	// DebugShell stops the workflow at the first failed step and saves the environment of the step to open a debug
+	// shell in the same state.
+	DebugShell bool `env:"GHX_DEBUG_SHELL" envDefault:"false"`
+
+	// Interactive pauses before each step and waits for the user to continue, skip the step, open a shell or abort
+	// the workflow. Requires a terminal.
+	Interactive bool `env:"GHX_INTERACTIVE" envDefault:"false"`
+
+	// BreakAt is the id or name of the step to stop the workflow before. In interactive mode, steps run without pausing
+	// until the step is reached.
+	BreakAt string `env:"GHX_BREAK_AT"`
+
+	// ChangedPaths is the list of paths changed since the last run. If set, workflow runs only if the changed paths
+	// match the path filters of the event.
+	ChangedPaths []string `env:"GHX_CHANGED_PATHS"`
+
+	// EnvironmentsDir is the directory relative to the workspace to look for the environment files. Environment files
+	// are json files named after the environment containing the secrets and vars of the environment.
+	EnvironmentsDir string `env:"GHX_ENVIRONMENTS_DIR" envDefault:".gale/environments"`
+
+	// EnvironmentApproval asks for a manual approval before running the jobs referencing an environment. Requires a
+	// terminal unless the environment is approved in advance.
+	EnvironmentApproval bool `env:"GHX_ENVIRONMENT_APPROVAL" envDefault:"false"`
+
+	// ApprovedEnvironments is the list of environments approved in advance, so the jobs referencing them run without
+	// asking for approval, e.g. in CI. Environments are matched with glob patterns, e.g. * approves all environments.
+	ApprovedEnvironments []string `env:"GHX_APPROVED_ENVIRONMENTS"`
+
+	// Deployments creates GitHub deployments and deployment statuses for the jobs referencing an environment. Requires
+	// a GitHub token with the deployments permission.
+	Deployments bool `env:"GHX_DEPLOYMENTS" envDefault:"false"`
+
+	// APIAudit routes the GitHub API calls of the steps through the audit proxy of ghx and records the method, the path
+	// and the status of the calls in the workflow run report.
+	APIAudit bool `env:"GHX_API_AUDIT" envDefault:"false"`
+
+	// Untrusted evaluates the workflow as untrusted code. Secrets context is empty regardless of the secrets providers
+	// and the environment secrets.
+	Untrusted bool `env:"GHX_UNTRUSTED" envDefault:"false"`
+
+	// PreviousRun is the directory of a workflow run from the run history to run again as a new attempt. The new attempt
+	// keeps the run id and the run number of the run and the reports of the previous attempts.
+	PreviousRun string `env:"GHX_PREVIOUS_RUN"`
+
+	// ResumeRun is the directory of a part of the workflow run completed in another runner container. The run continues
+	// with the remaining jobs, keeping the run id and the results and the reports of the completed jobs.
+	ResumeRun string `env:"GHX_RESUME_RUN"`
+
+	// SecretsFiles is the list of files to load the secrets from. Files could be json, yaml or dotenv files and
+	// optionally encrypted with SOPS.
+	SecretsFiles []string `env:"GHX_SECRETS_FILES"`
+
+	// SecretsEnvFile is the file with the environment variables of the host in KEY=VALUE lines. Variables with the
+	// GALE_SECRET_ prefix are loaded as secrets, like the environment variables of the runner.
+	SecretsEnvFile string `env:"GHX_SECRETS_ENV_FILE"`
+
+	// RegistryMirrors is the map of the registries to their mirrors to pull the step images from. Format:
+	// registry=mirror;registry2=mirror2, images without a registry are Docker Hub images, e.g. docker.io=mirror.example.com
+	RegistryMirrors ImageMappings `env:"GHX_REGISTRY_MIRRORS"`
+
+	// ImageOverrides is the map of the step images to their replacements. Overrides have precedence over the registry
+	// mirrors. Format: image=replacement;image2=replacement2, e.g. node:16=registry.example.com/node:16
+	ImageOverrides ImageMappings `env:"GHX_IMAGE_OVERRIDES"`
+
+	// PullPolicy is the policy of pulling the step images. Possible values are always and if-not-present. In offline
+	// mode, images pinned by prefetch are always used.
+	PullPolicy PullPolicy `env:"GHX_PULL_POLICY" envDefault:"always"`
+
+	// Network is the network mode of the steps. Possible values are full, restricted and none.
+	Network NetworkMode `env:"GHX_NETWORK" envDefault:"full"`
+
+	// NetworkJobs is the network modes of the jobs overriding the network mode of the run. Format: job=mode;job2=mode
+	NetworkJobs JobNetworks `env:"GHX_NETWORK_JOBS"`
+
+	// NetworkProxies is the URLs of the proxies enforcing the network modes. Format: restricted=url;none=url
+	NetworkProxies NetworkProxies `env:"GHX_NETWORK_PROXIES"`
+
+	// LogsDir is the directory to record the console output of the workflow runs while they're running, as
+	// <run-id>.ndjson. The output is recorded to the workflow run directory as journal.ndjson regardless.
+	LogsDir string `env:"GHX_LOGS_DIR"`
+
+	// JournalSinks is the list of the additional sinks to ship the console output of the workflow runs to, e.g.
+	// syslog=udp://logs.example.com:514 or webhook=https://logs.example.com/gale. Format: sink;sink2
+	JournalSinks []string `env:"GHX_JOURNAL_SINKS" envSeparator:";"`
+
+	// JournalSocket is the path of the syslog or journald socket of the host mounted to the runner. It's the default
+	// address of the syslog and journald sinks.
+	JournalSocket string `env:"GHX_JOURNAL_SOCKET"`
+
+	// FilesReport reports the files created, modified or deleted by each step in the step run report. The workspace,
+	// the tool cache and /tmp are watched by default.
+	FilesReport bool `env:"GHX_FILES_REPORT" envDefault:"false"`
+
+	// FilesReportPaths is the list of additional paths to watch for the files report.
+	FilesReportPaths []string `env:"GHX_FILES_REPORT_PATHS"`
+
+	// CACerts is the path of the CA certificates bundle of the runner including the extra CA certificates. If set, the
+	// bundle replaces the trust store of the step containers.
+	CACerts string `env:"GHX_CA_CERTS"`
+
+	// RunnerImage is the image of the runner container ghx runs in. It's recorded in the workflow run report with the
+	// version of ghx to track the components used for the run.
+	RunnerImage string `env:"GHX_RUNNER_IMAGE"`
+
+	// Version is the version of the ghx binary. It's set by ghx itself, not from the environment.
+	Version string
+
+	// SecretsFrom is the list of commands printing the secrets in json or dotenv format to load the secrets from.
+	SecretsFrom []string `env:"GHX_SECRETS_FROM" envSeparator:"\n"`
 }
 
 // DaggerContext is the context holding the dagger client.
@@ -48,6 +250,9 @@ type ActionsContext struct {
 	// CacheURL is the URL for the actions cache service.
 	CacheURL string `env:"ACTIONS_CACHE_URL"`
 
+	// ResultsURL is the URL for the actions results service. In scope of gale, this is the URL of the artifact service.
+	ResultsURL string `env:"ACTIONS_RESULTS_URL"`
+
 	// Token is the token for the actions runtime. In scope of gale, this is a dummy token.
 	Token string `env:"ACTIONS_RUNTIME_TOKEN" envDefault:"dummy-token"`
 }
@@ -159,8 +364,28 @@ type GithubContext struct {
 	// Event is the full event webhook payload.
 	Event map[string]interface{} `json:"event"`
 
+	// Environment is the name of the environment the current job references. This is not part of the GitHub context,
+	// it's exposed by gale to access the environment from expressions.
+	Environment string `json:"environment"`
+
 	// Token is the GitHub token to use for authentication.
 	Token string `json:"token" env:"GITHUB_TOKEN"`
+
+	// Actor is the username of the user that triggered the initial workflow run. Defaults to the sender of the event.
+	Actor string `json:"actor" env:"GITHUB_ACTOR"`
+
+	// Action is the id of the current step, e.g. build. It's only available while a step is running.
+	Action string `json:"action"`
+
+	// ActionPath is the path of the current action. It's only available while an action is running.
+	ActionPath string `json:"action_path"`
+
+	// ActionRepository is the owner and repository name of the current action, e.g. actions/checkout. It's only
+	// available while a remote action is running.
+	ActionRepository string `json:"action_repository"`
+
+	// ActionRef is the ref of the current action, e.g. v4. It's only available while a remote action is running.
+	ActionRef string `json:"action_ref"`
 }
 
 // InputsContext contains input properties passed to an action, to a reusable workflow, or to a manually triggered
@@ -196,11 +421,15 @@ type RunnerContext struct {
 	// Name is the name of the runner.
 	Name string `json:"name" env:"RUNNER_NAME" envDefault:"Gale Agent"`
 
-	// OS is the operating system of the runner.
-	OS string `json:"os" env:"RUNNER_OS" envDefault:"linux"`
+	// OS is the operating system of the runner. Possible values are Linux, Windows, or macOS.
+	OS string `json:"os" env:"RUNNER_OS" envDefault:"Linux"`
 
-	// Arch is the architecture of the runner.
-	Arch string `json:"arch" env:"RUNNER_ARCH" envDefault:"x64"`
+	// Arch is the architecture of the runner. Possible values are X86, X64, ARM, or ARM64. Defaults to the architecture
+	// of the ghx binary.
+	Arch string `json:"arch" env:"RUNNER_ARCH"`
+
+	// Environment is the environment of the runner. Possible values are github-hosted or self-hosted.
+	Environment string `json:"environment" env:"RUNNER_ENVIRONMENT" envDefault:"github-hosted"`
 
 	// Temp is the path to the directory containing temporary files created by the runner during the job.
 	Temp string `json:"temp" env:"RUNNER_TEMP" envDefault:"/home/runner/_temp"`
@@ -208,6 +437,9 @@ type RunnerContext struct {
 	// ToolCache is the path to the directory containing installed tools.
 	ToolCache string `json:"tool_cache" env:"RUNNER_TOOL_CACHE" envDefault:"/home/runner/hostedtoolcache"`
 
+	// Workspace is the path of the parent directory of the repository workspace. e.g. /home/runner/work/repo
+	Workspace string `json:"workspace" env:"RUNNER_WORKSPACE"`
+
 	// Debug is a boolean value that indicates whether to run the runner in debug mode.
 	Debug string `json:"debug" env:"RUNNER_DEBUG" envDefault:"0"`
 }
@@ -246,11 +478,33 @@ type StepContext struct {
 	Summary string `json:"-"`
 }
 
+// VarsContext is a context that contains the configuration variables of the environment.
+//
+// See: https://docs.github.com/en/actions/learn-github-actions/contexts#vars-context
+type VarsContext map[string]string
+
 // EnvContext is a context that contains environment variables.
 //
 // See: https://docs.github.com/en/actions/learn-github-actions/contexts#env-context
 type EnvContext map[string]string
 
+// StrategyContext is a context that contains information about the matrix execution strategy for the current job.
+//
+// See: https://docs.github.com/en/actions/learn-github-actions/contexts#strategy-context
+type StrategyContext struct {
+	// FailFast indicates all in-progress jobs are cancelled if any job in the matrix fails.
+	FailFast bool `json:"fail-fast"`
+
+	// JobIndex is the zero-based index of the current job in the matrix.
+	JobIndex int `json:"job-index"`
+
+	// JobTotal is the total number of jobs in the matrix.
+	JobTotal int `json:"job-total"`
+
+	// MaxParallel is the maximum number of jobs that can run simultaneously. Defaults to the job total if not set.
+	MaxParallel int `json:"max-parallel"`
+}
+
 // MatrixContext is a context that contains matrix information.
 //
 // See: https://docs.github.com/en/actions/learn-github-actions/contexts#matrix-context
@@ -0,0 +1,227 @@
+package context
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+// Operating systems of the GitHub hosted runners. Jobs targeting self-hosted runners are not billed.
+const (
+	BillingOSLinux      = "linux"
+	BillingOSWindows    = "windows"
+	BillingOSMacOS      = "macos"
+	BillingOSSelfHosted = "self-hosted"
+)
+
+// billingRates is the per minute rate of the GitHub hosted runners in USD and the minute multiplier applied to the
+// included minutes of the plans.
+//
+// See: https://docs.github.com/en/billing/managing-billing-for-github-actions/about-billing-for-github-actions
+var billingRates = map[string]struct {
+	Rate       float64
+	Multiplier int
+}{
+	BillingOSLinux:      {Rate: 0.008, Multiplier: 1},
+	BillingOSWindows:    {Rate: 0.016, Multiplier: 2},
+	BillingOSMacOS:      {Rate: 0.08, Multiplier: 10},
+	BillingOSSelfHosted: {Rate: 0, Multiplier: 0},
+}
+
+// BillingReport is the estimate of the billable minutes and the cost of the workflow run on GitHub hosted runners.
+type BillingReport struct {
+	Jobs           []JobBilling `json:"jobs"`                      // Jobs is the estimate of each job run
+	Minutes        int          `json:"minutes"`                   // Minutes is the billable minutes of the run after the multipliers are applied
+	Cost           float64      `json:"cost"`                      // Cost is the estimated cost of the run in USD
+	RunsPerMonth   float64      `json:"runs_per_month,omitempty"`  // RunsPerMonth is the average number of scheduled runs in a month
+	MonthlyMinutes float64      `json:"monthly_minutes,omitempty"` // MonthlyMinutes is the billable minutes of the scheduled runs in a month
+	MonthlyCost    float64      `json:"monthly_cost,omitempty"`    // MonthlyCost is the estimated cost of the scheduled runs in a month in USD
+}
+
+// JobBilling is the estimate of the billable minutes and the cost of a job run.
+type JobBilling struct {
+	Name       string  `json:"name"`       // Name is the display name of the job run
+	OS         string  `json:"os"`         // OS is the operating system of the runner selected from the runs-on labels
+	Duration   string  `json:"duration"`   // Duration is the recorded duration of the job run
+	Minutes    int     `json:"minutes"`    // Minutes is the duration rounded up to the next minute, like GitHub
+	Multiplier int     `json:"multiplier"` // Multiplier is the minute multiplier of the operating system
+	Cost       float64 `json:"cost"`       // Cost is the estimated cost of the job run in USD
+}
+
+// NewBillingReport estimates the billable minutes and the cost of the workflow run from the recorded job durations and
+// the runs-on labels of the jobs. If the workflow is scheduled, the monthly estimate is calculated from the schedules.
+func NewBillingReport(wr *core.WorkflowRun) (*BillingReport, error) {
+	report := &BillingReport{}
+
+	for _, jr := range wr.JobRuns {
+		var (
+			os      = billingOS(jr.Job.RunsOn)
+			rate    = billingRates[os]
+			minutes = int(math.Ceil(jr.Duration.Minutes()))
+		)
+
+		job := JobBilling{
+			Name:       jr.DisplayName(),
+			OS:         os,
+			Duration:   jr.Duration.String(),
+			Minutes:    minutes,
+			Multiplier: rate.Multiplier,
+			Cost:       float64(minutes) * rate.Rate,
+		}
+
+		report.Minutes += minutes * rate.Multiplier
+		report.Cost += job.Cost
+		report.Jobs = append(report.Jobs, job)
+	}
+
+	for _, cron := range wr.Workflow.On["schedule"].Crons {
+		runs, err := cronRunsPerMonth(cron)
+		if err != nil {
+			return nil, err
+		}
+
+		report.RunsPerMonth += runs
+	}
+
+	report.MonthlyMinutes = report.RunsPerMonth * float64(report.Minutes)
+	report.MonthlyCost = report.RunsPerMonth * report.Cost
+
+	return report, nil
+}
+
+// billingOS returns the operating system of the runner from the runs-on labels of the job.
+func billingOS(labels core.RunsOn) string {
+	os := BillingOSLinux
+
+	for _, label := range labels {
+		switch label = strings.ToLower(label); {
+		case label == "self-hosted":
+			return BillingOSSelfHosted
+		case strings.HasPrefix(label, "windows"):
+			os = BillingOSWindows
+		case strings.HasPrefix(label, "macos"):
+			os = BillingOSMacOS
+		}
+	}
+
+	return os
+}
+
+// cronRunsPerMonth returns the average number of runs in a month for the given cron expression. Runs are counted
+// over a non-leap year in UTC, same as GitHub evaluates the schedules.
+func cronRunsPerMonth(expr string) (float64, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return 0, fmt.Errorf("invalid cron expression %q, expected 5 fields", expr)
+	}
+
+	// minute, hour, day of month, month, day of week
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+	var sets [5]map[int]bool
+
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+
+		sets[i] = set
+	}
+
+	// like cron, if both day of month and day of week are restricted, runs match either of them
+	var (
+		domRestricted = !strings.HasPrefix(fields[2], "*")
+		dowRestricted = !strings.HasPrefix(fields[4], "*")
+		start         = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end           = start.AddDate(1, 0, 0)
+		runs          = 0
+	)
+
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if !sets[3][int(day.Month())] {
+			continue
+		}
+
+		dom, dow := sets[2][day.Day()], sets[4][int(day.Weekday())]
+
+		matches := dom && dow
+		if domRestricted && dowRestricted {
+			matches = dom || dow
+		}
+
+		if matches {
+			runs += len(sets[0]) * len(sets[1])
+		}
+	}
+
+	return float64(runs) / 12, nil
+}
+
+// parseCronField parses a cron field into the set of values it matches. Lists, ranges, steps and wildcards are
+// supported, names of the months and the days are not.
+func parseCronField(field string, lower, upper int) (map[int]bool, error) {
+	set := make(map[int]bool)
+
+	for _, part := range strings.Split(field, ",") {
+		var (
+			rng  = part
+			step = 1
+			err  error
+		)
+
+		if r, s, ok := strings.Cut(part, "/"); ok {
+			rng = r
+
+			if step, err = strconv.Atoi(s); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step %s", s)
+			}
+		}
+
+		lo, hi := lower, upper
+
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+
+			if lo, err = strconv.Atoi(from); err != nil {
+				return nil, fmt.Errorf("invalid value %s", from)
+			}
+
+			hi = lo
+
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return nil, fmt.Errorf("invalid value %s", to)
+				}
+			} else if step > 1 {
+				// a single value with a step means from the value to the end of the range, e.g. 5/15
+				hi = upper
+			}
+		}
+
+		// 7 is an alias of sunday in the day of week field
+		if upper == 6 && hi == 7 {
+			set[0] = true
+
+			if lo == 7 {
+				continue
+			}
+
+			hi = 6
+		}
+
+		if lo < lower || hi > upper || lo > hi {
+			return nil, fmt.Errorf("value %s out of range %d-%d", rng, lower, upper)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+
+	return set, nil
+}
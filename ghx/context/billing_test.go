@@ -0,0 +1,76 @@
+package context
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestCronRunsPerMonth(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected float64
+		wantErr  bool
+	}{
+		{expr: "0 0 * * *", expected: 365.0 / 12},
+		{expr: "*/15 * * * *", expected: 4 * 24 * 365.0 / 12},
+		{expr: "30 6,18 * * 1-5", expected: 2 * 260.0 / 12}, // 2023 has 260 weekdays
+		{expr: "0 0 1 * *", expected: 1},
+		{expr: "0 0 1 * 0", expected: (12 + 53 - 2) / 12.0}, // first days of the month or sundays, 2 of them overlap
+		{expr: "0 0 * * 7", expected: 53.0 / 12},            // 7 is an alias of sunday
+		{expr: "0 0 * *", wantErr: true},
+		{expr: "60 0 * * *", wantErr: true},
+		{expr: "0 0 * * MON", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := cronRunsPerMonth(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cronRunsPerMonth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("cronRunsPerMonth() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewBillingReport(t *testing.T) {
+	wr := &core.WorkflowRun{
+		Workflow: core.Workflow{
+			On: core.Events{"schedule": {Crons: []string{"0 0 1 * *"}}},
+		},
+		JobRuns: []core.JobRun{
+			{Job: core.Job{Name: "linux", RunsOn: core.RunsOn{"ubuntu-latest"}}, Duration: 90 * time.Second},
+			{Job: core.Job{Name: "windows", RunsOn: core.RunsOn{"windows-latest"}}, Duration: 30 * time.Second},
+			{Job: core.Job{Name: "macos", RunsOn: core.RunsOn{"macos-14"}}, Duration: time.Minute},
+			{Job: core.Job{Name: "self-hosted", RunsOn: core.RunsOn{"self-hosted", "linux"}}, Duration: time.Hour},
+		},
+	}
+
+	report, err := NewBillingReport(wr)
+	if err != nil {
+		t.Fatalf("NewBillingReport() error = %v", err)
+	}
+
+	// 2 linux minutes, 1 windows minute with 2x multiplier, 1 macos minute with 10x multiplier, self-hosted is free
+	if report.Minutes != 2+2+10 {
+		t.Errorf("Minutes = %d, want %d", report.Minutes, 14)
+	}
+
+	if expected := 2*0.008 + 0.016 + 0.08; math.Abs(report.Cost-expected) > 1e-9 {
+		t.Errorf("Cost = %v, want %v", report.Cost, expected)
+	}
+
+	if report.RunsPerMonth != 1 || report.MonthlyMinutes != 14 {
+		t.Errorf("RunsPerMonth = %v, MonthlyMinutes = %v, want 1, 14", report.RunsPerMonth, report.MonthlyMinutes)
+	}
+
+	if os := report.Jobs[3].OS; os != BillingOSSelfHosted {
+		t.Errorf("OS = %s, want %s", os, BillingOSSelfHosted)
+	}
+}
@@ -2,12 +2,16 @@ package context
 
 import (
 	"context"
+	"os"
+	"runtime"
 
 	"dagger.io/dagger"
 
 	"github.com/caarlos0/env/v9"
 
 	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/journal"
 )
 
 // Context represents the main context of the application.
@@ -26,6 +30,15 @@ type Context struct {
 	Steps     StepsContext
 	Env       EnvContext
 	Matrix    MatrixContext
+	Strategy  StrategyContext
+	Vars      VarsContext
+	Events    *EventStream // Events is the stream of the execution events, nil if neither the events socket nor ndjson output is configured
+	APIAudit  *APIAudit    // APIAudit records the GitHub API calls of the steps, nil if the API audit is disabled
+
+	baseSecrets map[string]string // baseSecrets is the secrets without the environment secrets of the current job
+	files       filesSnapshot     // files is the snapshot of the watched files before the current step, nil if the files report is disabled
+	envBase     map[string]string // envBase is the env of the job before the current step, to report the env changes of the step
+	recorder    *journal.Recorder // recorder records the console output of the workflow run, nil if no workflow is set
 }
 
 // New returns a new Context initialized from environment variables.
@@ -57,6 +70,15 @@ func New(std context.Context, client *dagger.Client) (*Context, error) {
 		ctx.Matrix = make(MatrixContext)
 	}
 
+	if ctx.Vars == nil {
+		ctx.Vars = make(VarsContext)
+	}
+
+	// runner architecture defaults to the architecture of the ghx binary since it's built for the runner platform
+	if ctx.Runner.Arch == "" {
+		ctx.Runner.Arch = runnerArch(runtime.GOARCH)
+	}
+
 	// set the standard ctx
 	ctx.Context = std
 
@@ -76,6 +98,13 @@ func New(std context.Context, client *dagger.Client) (*Context, error) {
 		ctx.Github.Event = make(map[string]interface{})
 	}
 
+	// like GitHub, the actor is the sender of the event if it's not set explicitly
+	if ctx.Github.Actor == "" {
+		if sender, ok := ctx.Github.Event["sender"].(map[string]interface{}); ok {
+			ctx.Github.Actor, _ = sender["login"].(string)
+		}
+	}
+
 	// set secrets ctx
 	secretsMountPath, err := ctx.GetSecretsPath()
 
@@ -88,25 +117,77 @@ func New(std context.Context, client *dagger.Client) (*Context, error) {
 		return nil, err
 	}
 
-	if err := fs.ReadJSONFile(ctx.Secrets.MountPath, &ctx.Secrets.Data); err != nil {
+	if err := ctx.loadSecrets(); err != nil {
 		return nil, err
 	}
 
-	// ensure secrets data is initialized
-	if ctx.Secrets.Data == nil {
-		ctx.Secrets.Data = make(map[string]string)
-	}
-
 	// add github token to secrets
 	ctx.Secrets.Data["GITHUB_TOKEN"] = ctx.Github.Token
 
+	// mask secrets in the log output
+	for _, v := range ctx.Secrets.Data {
+		log.AddMask(v)
+	}
+
 	// update environment variables with defaults and manually set values
 	syncWithEnvValues(&ctx)
 
+	// events are printed to stdout in place of the logs for the wrappers building their own UIs, logs are kept on
+	// stderr for the humans
+	if ctx.GhxConfig.Output == OutputFormatNDJSON {
+		if ctx.GhxConfig.EventsSocket != "" {
+			log.Warnf("Events socket is ignored, events are printed to stdout with ndjson output", "socket", ctx.GhxConfig.EventsSocket)
+		}
+
+		log.SetOutput(os.Stderr)
+
+		ctx.Events = NewEventWriter(os.Stdout)
+	}
+
+	// events are optional, so the workflow runs without streaming the events if the socket is not reachable
+	if ctx.GhxConfig.EventsSocket != "" && ctx.Events == nil {
+		events, err := NewEventStream(ctx.GhxConfig.EventsSocket)
+		if err != nil {
+			log.Warnf("Events won't be streamed", "error", err)
+		}
+
+		ctx.Events = events
+	}
+
 	return &ctx, nil
 }
 
+// runnerArch returns the runner architecture name for the given go architecture.
+func runnerArch(goarch string) string {
+	switch goarch {
+	case "386":
+		return "X86"
+	case "arm":
+		return "ARM"
+	case "arm64":
+		return "ARM64"
+	default:
+		return "X64"
+	}
+}
+
 // Debug returns true if debug mode is enabled.
 func (c *Context) Debug() bool {
 	return c.Runner.Debug == "1"
 }
+
+// Components returns the versions of the components used for the run. Components without a known version, e.g. the
+// runner image when ghx runs outside gale, are omitted.
+func (c *Context) Components() map[string]string {
+	components := make(map[string]string)
+
+	if c.GhxConfig.Version != "" {
+		components["ghx"] = c.GhxConfig.Version
+	}
+
+	if c.GhxConfig.RunnerImage != "" {
+		components["runner"] = c.GhxConfig.RunnerImage
+	}
+
+	return components
+}
@@ -0,0 +1,52 @@
+package context
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRunnerArch(t *testing.T) {
+	tests := []struct {
+		goarch   string
+		expected string
+	}{
+		{goarch: "amd64", expected: "X64"},
+		{goarch: "386", expected: "X86"},
+		{goarch: "arm", expected: "ARM"},
+		{goarch: "arm64", expected: "ARM64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goarch, func(t *testing.T) {
+			if got := runnerArch(tt.goarch); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestContext_Components(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   GhxConfig
+		expected map[string]string
+	}{
+		{name: "unknown components", expected: map[string]string{}},
+		{name: "ghx version", config: GhxConfig{Version: "v0.1.0"}, expected: map[string]string{"ghx": "v0.1.0"}},
+		{
+			name:     "ghx version and runner image",
+			config:   GhxConfig{Version: "dev", RunnerImage: "ghcr.io/catthehacker/ubuntu:act-22.04"},
+			expected: map[string]string{"ghx": "dev", "runner": "ghcr.io/catthehacker/ubuntu:act-22.04"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &Context{GhxConfig: tt.config}
+
+			if got := ctx.Components(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
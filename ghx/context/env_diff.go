@@ -0,0 +1,34 @@
+package context
+
+import "github.com/aweris/gale/ghx/core"
+
+// diffEnv returns the variables the step added or changed with the written values, compared to the env of the job
+// before the step. Variables written with the same value are not reported. It returns nil if nothing is changed.
+func diffEnv(before, written map[string]string) *core.EnvironmentDiff {
+	diff := &core.EnvironmentDiff{}
+
+	for k, v := range written {
+		previous, ok := before[k]
+
+		switch {
+		case !ok:
+			if diff.Added == nil {
+				diff.Added = make(map[string]string)
+			}
+
+			diff.Added[k] = v
+		case previous != v:
+			if diff.Changed == nil {
+				diff.Changed = make(map[string]core.EnvironmentChange)
+			}
+
+			diff.Changed[k] = core.EnvironmentChange{From: previous, To: v}
+		}
+	}
+
+	if diff.Added == nil && diff.Changed == nil {
+		return nil
+	}
+
+	return diff
+}
@@ -0,0 +1,26 @@
+package context
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestDiffEnv(t *testing.T) {
+	before := map[string]string{"GOFLAGS": "-mod=mod", "CI": "true"}
+	written := map[string]string{"GOFLAGS": "-mod=vendor", "CI": "true", "TAG": "v1"}
+
+	want := &core.EnvironmentDiff{
+		Added:   map[string]string{"TAG": "v1"},
+		Changed: map[string]core.EnvironmentChange{"GOFLAGS": {From: "-mod=mod", To: "-mod=vendor"}},
+	}
+
+	if got := diffEnv(before, written); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffEnv() = %+v, want %+v", got, want)
+	}
+
+	if got := diffEnv(before, map[string]string{"CI": "true"}); got != nil {
+		t.Errorf("expected nil diff for unchanged values, got %+v", got)
+	}
+}
@@ -0,0 +1,78 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnvOverrides is the environment variables injected to the workflow run without changing the workflow, e.g. for
+// local tweaks. Values are used as is, expressions are not evaluated.
+type EnvOverrides map[string]string
+
+// UnmarshalText parses the overrides from the text format, one KEY=VALUE pair per line.
+func (eo *EnvOverrides) UnmarshalText(text []byte) error {
+	overrides := make(EnvOverrides)
+
+	for _, line := range strings.Split(string(text), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("invalid env override %s, expected format is KEY=VALUE", line)
+		}
+
+		overrides[strings.TrimSpace(key)] = value
+	}
+
+	*eo = overrides
+
+	return nil
+}
+
+// EnvPrecedence is the scope of the env blocks the env overrides have precedence over.
+type EnvPrecedence string
+
+const (
+	// EnvPrecedenceWorkflow overrides the workflow env. Job and step env have precedence over the overrides, like the
+	// overrides are added to the workflow env.
+	EnvPrecedenceWorkflow EnvPrecedence = "workflow"
+
+	// EnvPrecedenceJob overrides the workflow and the job env. Step env has precedence over the overrides.
+	EnvPrecedenceJob EnvPrecedence = "job"
+
+	// EnvPrecedenceStep overrides the workflow, the job and the step env, so the overrides are always used.
+	EnvPrecedenceStep EnvPrecedence = "step"
+)
+
+// UnmarshalText parses the env precedence from the text format.
+func (p *EnvPrecedence) UnmarshalText(text []byte) error {
+	switch precedence := EnvPrecedence(strings.TrimSpace(string(text))); precedence {
+	case EnvPrecedenceWorkflow, EnvPrecedenceJob, EnvPrecedenceStep:
+		*p = precedence
+	default:
+		return fmt.Errorf("unsupported env precedence %s, supported values are workflow, job and step", text)
+	}
+
+	return nil
+}
+
+// overrides returns true if the overrides have precedence over the env of the given scope.
+func (p EnvPrecedence) overrides(scope EnvPrecedence) bool {
+	rank := map[EnvPrecedence]int{EnvPrecedenceWorkflow: 0, EnvPrecedenceJob: 1, EnvPrecedenceStep: 2}
+
+	// empty precedence is the default workflow precedence
+	return rank[p] >= rank[scope]
+}
+
+// applyEnvOverrides sets the env overrides to the given env if the overrides have precedence over the env of the scope.
+func (c *Context) applyEnvOverrides(env map[string]string, scope EnvPrecedence) {
+	if !c.GhxConfig.EnvPrecedence.overrides(scope) {
+		return
+	}
+
+	for k, v := range c.GhxConfig.Env {
+		env[k] = v
+	}
+}
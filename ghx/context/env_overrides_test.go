@@ -0,0 +1,81 @@
+package context
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestEnvOverrides_UnmarshalText(t *testing.T) {
+	var overrides EnvOverrides
+
+	if err := overrides.UnmarshalText([]byte("FOO=bar\n\nURL=http://example.com?a=b\nEMPTY=\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := EnvOverrides{"FOO": "bar", "URL": "http://example.com?a=b", "EMPTY": ""}
+
+	if !reflect.DeepEqual(overrides, expected) {
+		t.Errorf("expected %v, got %v", expected, overrides)
+	}
+
+	if err := overrides.UnmarshalText([]byte("INVALID")); err == nil {
+		t.Error("expected an error for the line without a value")
+	}
+}
+
+func TestContext_EnvOverrides(t *testing.T) {
+	tests := []struct {
+		precedence EnvPrecedence
+		job        EnvContext
+		step       EnvContext
+	}{
+		{
+			precedence: EnvPrecedenceWorkflow,
+			job:        EnvContext{"LEVEL": "job", "IMAGE": "app:override", "DEBUG": "1", "TAG": "override"},
+			step:       EnvContext{"LEVEL": "step", "IMAGE": "app:override", "DEBUG": "1", "TAG": "override"},
+		},
+		{
+			precedence: EnvPrecedenceJob,
+			job:        EnvContext{"LEVEL": "override", "IMAGE": "app:override", "DEBUG": "1", "TAG": "override"},
+			step:       EnvContext{"LEVEL": "step", "IMAGE": "app:override", "DEBUG": "1", "TAG": "override"},
+		},
+		{
+			precedence: EnvPrecedenceStep,
+			job:        EnvContext{"LEVEL": "override", "IMAGE": "app:override", "DEBUG": "1", "TAG": "override"},
+			step:       EnvContext{"LEVEL": "override", "IMAGE": "app:override", "DEBUG": "1", "TAG": "override"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.precedence), func(t *testing.T) {
+			ctx := &Context{
+				GhxConfig: GhxConfig{
+					Env:           EnvOverrides{"LEVEL": "override", "TAG": "override", "DEBUG": "1"},
+					EnvPrecedence: tt.precedence,
+				},
+				Execution: ExecutionContext{
+					WorkflowRun: &core.WorkflowRun{
+						Workflow: core.Workflow{Env: map[string]string{"LEVEL": "workflow", "TAG": "workflow"}},
+					},
+				},
+			}
+
+			ctx.resetEnv(&core.JobRun{
+				Job: core.Job{Env: map[string]string{"LEVEL": "job", "IMAGE": "app:${{ env.TAG }}"}},
+			})
+
+			if !reflect.DeepEqual(ctx.Env, tt.job) {
+				t.Errorf("job env: expected %v, got %v", tt.job, ctx.Env)
+			}
+
+			ctx.Env["LEVEL"] = "step"
+			ctx.applyEnvOverrides(ctx.Env, EnvPrecedenceStep)
+
+			if !reflect.DeepEqual(ctx.Env, tt.step) {
+				t.Errorf("step env: expected %v, got %v", tt.step, ctx.Env)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package context
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/log"
+)
+
+// environmentFile represents the secrets and vars of an environment.
+type environmentFile struct {
+	Secrets map[string]string `json:"secrets"` // Secrets is the secrets of the environment.
+	Vars    map[string]string `json:"vars"`    // Vars is the configuration variables of the environment.
+}
+
+// SetEnvironment sets the environment of the current job. Secrets and vars of the environment are loaded from the
+// environment file in the environments directory and secrets of the environment override the repository secrets.
+func (c *Context) SetEnvironment(name string) error {
+	if c.Execution.JobRun == nil {
+		return errors.New("no job is set")
+	}
+
+	c.Execution.JobRun.Job.Environment.Name = name
+	c.Github.Environment = name
+
+	file := filepath.Join(c.Github.Workspace, c.GhxConfig.EnvironmentsDir, name+".json")
+
+	exist, err := fs.Exists(file)
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		log.Warnf("Environment file not found, environment doesn't have any secrets or vars", "environment", name, "path", file)
+
+		return nil
+	}
+
+	var env environmentFile
+
+	if err := fs.ReadJSONFile(file, &env); err != nil {
+		return err
+	}
+
+	// vars of the environment are still loaded, they're not sensitive
+	if c.GhxConfig.Untrusted {
+		env.Secrets = nil
+	}
+
+	secrets := make(map[string]string, len(c.Secrets.Data)+len(env.Secrets))
+
+	for k, v := range c.Secrets.Data {
+		secrets[k] = v
+	}
+
+	for k, v := range env.Secrets {
+		secrets[k] = v
+
+		log.AddMask(v)
+	}
+
+	c.baseSecrets = c.Secrets.Data
+	c.Secrets.Data = secrets
+
+	c.Vars = make(VarsContext, len(env.Vars))
+
+	for k, v := range env.Vars {
+		c.Vars[k] = v
+	}
+
+	return nil
+}
+
+// unsetEnvironment unsets the environment of the current job and restores the repository secrets.
+func (c *Context) unsetEnvironment() {
+	c.Github.Environment = ""
+
+	if c.baseSecrets != nil {
+		c.Secrets.Data = c.baseSecrets
+		c.baseSecrets = nil
+	}
+
+	c.Vars = make(VarsContext)
+}
@@ -0,0 +1,85 @@
+package context
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestContext_SetEnvironment(t *testing.T) {
+	workspace := t.TempDir()
+
+	file := environmentFile{
+		Secrets: map[string]string{"TOKEN": "prod-token"},
+		Vars:    map[string]string{"URL": "https://example.com"},
+	}
+
+	if err := fs.WriteJSONFile(filepath.Join(workspace, ".gale", "environments", "production.json"), file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		environment string
+		untrusted   bool
+		secrets     map[string]string
+		vars        VarsContext
+	}{
+		{
+			name:        "environment secrets and vars",
+			environment: "production",
+			secrets:     map[string]string{"TOKEN": "prod-token", "NPM_TOKEN": "npm"},
+			vars:        VarsContext{"URL": "https://example.com"},
+		},
+		{
+			name:        "untrusted workflow",
+			environment: "production",
+			untrusted:   true,
+			secrets:     map[string]string{"TOKEN": "repo-token", "NPM_TOKEN": "npm"},
+			vars:        VarsContext{"URL": "https://example.com"},
+		},
+		{
+			name:        "missing environment file",
+			environment: "staging",
+			secrets:     map[string]string{"TOKEN": "repo-token", "NPM_TOKEN": "npm"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := map[string]string{"TOKEN": "repo-token", "NPM_TOKEN": "npm"}
+
+			ctx := &Context{
+				GhxConfig: GhxConfig{EnvironmentsDir: ".gale/environments", Untrusted: tt.untrusted},
+				Github:    GithubContext{Workspace: workspace},
+				Secrets:   SecretsContext{Data: repository},
+				Execution: ExecutionContext{JobRun: &core.JobRun{Job: core.Job{ID: "deploy"}}},
+			}
+
+			if err := ctx.SetEnvironment(tt.environment); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ctx.Github.Environment != tt.environment || ctx.Execution.JobRun.Job.Environment.Name != tt.environment {
+				t.Errorf("expected environment %s, got %s", tt.environment, ctx.Github.Environment)
+			}
+
+			if !reflect.DeepEqual(ctx.Secrets.Data, tt.secrets) {
+				t.Errorf("expected secrets %v, got %v", tt.secrets, ctx.Secrets.Data)
+			}
+
+			if len(ctx.Vars) != len(tt.vars) || (len(tt.vars) > 0 && !reflect.DeepEqual(ctx.Vars, tt.vars)) {
+				t.Errorf("expected vars %v, got %v", tt.vars, ctx.Vars)
+			}
+
+			ctx.unsetEnvironment()
+
+			if !reflect.DeepEqual(ctx.Secrets.Data, repository) || len(ctx.Vars) != 0 || ctx.Github.Environment != "" {
+				t.Errorf("expected the repository secrets to be restored, got %v", ctx.Secrets.Data)
+			}
+		})
+	}
+}
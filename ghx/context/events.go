@@ -0,0 +1,216 @@
+package context
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/protocol"
+)
+
+// OutputFormat is the format of the console output of ghx.
+type OutputFormat string
+
+const (
+	// OutputFormatText prints the human-readable logs of the workflow run.
+	OutputFormatText OutputFormat = "text"
+
+	// OutputFormatNDJSON prints the execution events as newline delimited JSON, so the wrappers could build their own
+	// UIs without parsing the logs. Logs are printed to stderr instead.
+	OutputFormatNDJSON OutputFormat = "ndjson"
+)
+
+// UnmarshalText parses the output format from the text format.
+func (f *OutputFormat) UnmarshalText(text []byte) error {
+	switch format := OutputFormat(strings.TrimSpace(string(text))); format {
+	case OutputFormatText, OutputFormatNDJSON:
+		*f = format
+	default:
+		return fmt.Errorf("unsupported output format %s, supported formats are text and ndjson", text)
+	}
+
+	return nil
+}
+
+// EventStream streams the execution events to a unix socket, a named pipe or stdout. Failing to send an event doesn't
+// fail the workflow run, the stream is disabled instead.
+type EventStream struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer // closer closes the destination of the stream, nil if the destination is kept open, e.g. stdout
+}
+
+// NewEventStream connects to the unix socket or opens the named pipe at the given path to stream the execution events.
+// Opening a named pipe waits until the pipe is opened for reading.
+func NewEventStream(path string) (*EventStream, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		pipe, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open events pipe: %w", err)
+		}
+
+		return &EventStream{w: pipe, closer: pipe}, nil
+	}
+
+	conn, err := net.DialTimeout("unix", path, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect events socket: %w", err)
+	}
+
+	return &EventStream{w: conn, closer: conn}, nil
+}
+
+// NewEventWriter returns a stream writing the execution events to the given writer. Closing the stream doesn't close
+// the writer.
+func NewEventWriter(w io.Writer) *EventStream {
+	return &EventStream{w: w}
+}
+
+// Send writes the event to the stream with the protocol version. It's a no-op if the stream is nil or disabled.
+func (s *EventStream) Send(event protocol.Event) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.w == nil {
+		return
+	}
+
+	event.Version = protocol.Version
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Debugf("failed to marshal event", "type", event.Type, "error", err)
+		return
+	}
+
+	if _, err := s.w.Write(append(data, '\n')); err != nil {
+		log.Warnf("Failed to send event, disabling events stream", "error", err)
+
+		s.close()
+	}
+}
+
+// Close closes the destination of the stream.
+func (s *EventStream) Close() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.close()
+}
+
+// close disables the stream and closes its destination. Requires the lock.
+func (s *EventStream) close() error {
+	var err error
+
+	if s.closer != nil {
+		err = s.closer.Close()
+	}
+
+	s.w = nil
+	s.closer = nil
+
+	return err
+}
+
+// EmitEvent sends the event to the events stream with the workflow, job and step of the current execution.
+func (c *Context) EmitEvent(event protocol.Event) {
+	if c.Events == nil {
+		return
+	}
+
+	event = c.withExecution(event)
+	event.Time = time.Now()
+	event.Message = log.Mask(event.Message)
+
+	c.Events.Send(event)
+}
+
+// WithLogEvents returns a writer sending each line written to the given writer as a log event of the current step. If
+// the events stream is not configured, the writer is returned as it is.
+func (c *Context) WithLogEvents(w io.WriteCloser) io.WriteCloser {
+	if c.Events == nil {
+		return w
+	}
+
+	// step is captured when the writer is created since the writer could be used after the step is unset
+	return &logEventWriter{WriteCloser: w, events: c.Events, base: c.withExecution(protocol.Event{Type: protocol.EventTypeLog})}
+}
+
+// withExecution returns the event with the workflow, job and step of the current execution.
+func (c *Context) withExecution(event protocol.Event) protocol.Event {
+	if wr := c.Execution.WorkflowRun; wr != nil {
+		event.RunID = wr.RunID
+		event.Workflow = wr.Workflow.Name
+	}
+
+	if jr := c.Execution.JobRun; jr != nil {
+		event.JobRunID = jr.RunID
+		event.Job = jr.DisplayName()
+	}
+
+	if sr := c.Execution.StepRun; sr != nil {
+		event.Step = sr.Step.ID
+		event.Stage = string(sr.Stage)
+	}
+
+	return event
+}
+
+// logEventWriter sends the lines written to the underlying writer as log events.
+type logEventWriter struct {
+	io.WriteCloser
+	events *EventStream
+	base   protocol.Event
+	buf    []byte
+}
+
+func (w *logEventWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+
+	w.buf = append(w.buf, p[:n]...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.send(string(w.buf[:i]))
+
+		w.buf = w.buf[i+1:]
+	}
+
+	return n, err
+}
+
+func (w *logEventWriter) Close() error {
+	if len(w.buf) > 0 {
+		w.send(string(w.buf))
+		w.buf = nil
+	}
+
+	return w.WriteCloser.Close()
+}
+
+func (w *logEventWriter) send(line string) {
+	event := w.base
+	event.Time = time.Now()
+	event.Message = log.Mask(line)
+
+	w.events.Send(event)
+}
@@ -0,0 +1,158 @@
+package context
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/core"
+	"github.com/aweris/gale/ghx/protocol"
+)
+
+func TestEventStream(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "events.sock")
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan []protocol.Event)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		defer conn.Close()
+
+		var events []protocol.Event
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			var event protocol.Event
+
+			if err := json.Unmarshal(scanner.Bytes(), &event); err == nil {
+				events = append(events, event)
+			}
+		}
+
+		received <- events
+	}()
+
+	stream, err := NewEventStream(socket)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	log.AddMask("s3cr3t")
+
+	ctx := &Context{
+		Events: stream,
+		Execution: ExecutionContext{
+			WorkflowRun: &core.WorkflowRun{RunID: "1", Workflow: core.Workflow{Name: "CI"}},
+			JobRun:      &core.JobRun{RunID: "2", Job: core.Job{ID: "build", Name: "Build"}},
+			StepRun:     &core.StepRun{Step: core.Step{ID: "test"}, Stage: core.StepStageMain},
+		},
+	}
+
+	ctx.EmitEvent(protocol.Event{Type: protocol.EventTypeStepStarted})
+
+	w := ctx.WithLogEvents(nopCloser{})
+
+	fmt.Fprint(w, "hello\ntoken s3cr3t\npartial")
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx.EmitEvent(protocol.Event{Type: protocol.EventTypeStepCompleted, Conclusion: string(core.ConclusionSuccess), Duration: time.Second.String()})
+
+	if err := stream.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := <-received
+
+	var types, messages []string
+
+	for _, event := range events {
+		if event.RunID != "1" || event.JobRunID != "2" || event.Step != "test" || event.Stage != string(core.StepStageMain) {
+			t.Errorf("unexpected execution of the event %+v", event)
+		}
+
+		types = append(types, string(event.Type))
+
+		if event.Type == protocol.EventTypeLog {
+			messages = append(messages, event.Message)
+		}
+	}
+
+	expected := fmt.Sprint([]string{"step_started", "log", "log", "log", "step_completed"})
+
+	if fmt.Sprint(types) != expected {
+		t.Errorf("event types = %v, want %v", types, expected)
+	}
+
+	if fmt.Sprint(messages) != fmt.Sprint([]string{"hello", "token ***", "partial"}) {
+		t.Errorf("log messages = %q", messages)
+	}
+
+	// sending to a closed stream is a no-op
+	stream.Send(protocol.Event{Type: protocol.EventTypeLog})
+}
+
+func TestEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	stream := NewEventWriter(&buf)
+
+	ctx := &Context{Events: stream, Execution: ExecutionContext{WorkflowRun: &core.WorkflowRun{RunID: "1"}}}
+
+	ctx.EmitEvent(protocol.Event{Type: protocol.EventTypeWorkflowStarted})
+	ctx.EmitEvent(protocol.Event{Type: protocol.EventTypeWorkflowCompleted, Conclusion: string(core.ConclusionSuccess)})
+
+	if err := stream.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 events, got %d: %s", len(lines), buf.String())
+	}
+
+	var event protocol.Event
+
+	if err := json.Unmarshal(lines[1], &event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if event.Type != protocol.EventTypeWorkflowCompleted || event.RunID != "1" || event.Conclusion != string(core.ConclusionSuccess) {
+		t.Errorf("unexpected event %+v", event)
+	}
+}
+
+func TestOutputFormat_UnmarshalText(t *testing.T) {
+	var format OutputFormat
+
+	if err := format.UnmarshalText([]byte("ndjson")); err != nil || format != OutputFormatNDJSON {
+		t.Errorf("expected ndjson, got %s, error: %v", format, err)
+	}
+
+	if err := format.UnmarshalText([]byte("json")); err == nil {
+		t.Error("expected error for unsupported output format")
+	}
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Write(p []byte) (int, error) { return len(p), nil }
+func (nopCloser) Close() error                { return nil }
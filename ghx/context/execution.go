@@ -3,11 +3,17 @@ package context
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/aweris/gale/common/fs"
 	"github.com/aweris/gale/common/log"
 	"github.com/aweris/gale/ghx/core"
+	"github.com/aweris/gale/ghx/expression"
+	"github.com/aweris/gale/ghx/journal"
+	"github.com/aweris/gale/ghx/protocol"
 )
 
 // SetWorkflow creates a new execution context with the given workflow and sets it to the context.
@@ -31,16 +37,29 @@ func (c *Context) SetWorkflow(wr *core.WorkflowRun) error {
 	syncWithEnvValues(&c.Github)
 
 	// set env context
-	c.Env = wr.Workflow.Env
+	c.resetEnv(nil)
+
+	if err := c.startJournal(); err != nil {
+		return fmt.Errorf("failed to start journal: %w", err)
+	}
+
+	c.EmitEvent(protocol.Event{Type: protocol.EventTypeWorkflowStarted})
 
 	return nil
 }
 
 func (c *Context) UnsetWorkflow(result RunResult) {
+	c.EmitEvent(protocol.Event{Type: protocol.EventTypeWorkflowCompleted, Conclusion: string(result.Conclusion), Duration: result.Duration.String()})
+
 	// ignoring error since directory must exist at this point of execution
 	dir, _ := c.GetWorkflowRunPath()
 
 	report := NewWorkflowRunReport(&result, c.Execution.WorkflowRun)
+	report.Components = c.Components()
+
+	if c.APIAudit != nil {
+		report.APICalls = c.APIAudit.Calls()
+	}
 
 	if err := fs.WriteJSONFile(filepath.Join(dir, "workflow_run.json"), report); err != nil {
 		log.Errorf("failed to write workflow run", "error", err, "workflow", c.Execution.WorkflowRun.Workflow.Name)
@@ -57,6 +76,8 @@ func (c *Context) UnsetWorkflow(result RunResult) {
 	if err := fs.CopyFile(src, dst); err != nil {
 		log.Errorf("failed to write workflow", "error", err, "workflow", c.Execution.WorkflowRun.Workflow.Name)
 	}
+
+	c.stopJournal(string(result.Conclusion))
 }
 
 // SetJob sets the given job to the execution context.
@@ -67,35 +88,69 @@ func (c *Context) SetJob(jr *core.JobRun) error {
 
 	// set the job run to the execution context
 	c.Execution.JobRun = jr
-	c.Execution.WorkflowRun.Jobs[jr.Job.ID] = *jr
+
+	// matrix jobs share the same job id, keep the results of the previous runs to merge them when the job is unset
+	if _, ok := c.Execution.WorkflowRun.Jobs[jr.Job.ID]; !ok {
+		c.Execution.WorkflowRun.Jobs[jr.Job.ID] = *jr
+	}
 
 	// set the job run to the github context
 	c.Github.Job = jr.Job.ID
 
-	// set env context
-	for k, v := range jr.Job.Env {
-		c.Env[k] = v
-	}
-
 	// set matrix context if matrix has any values
 	if len(jr.Matrix) > 0 {
 		c.Matrix = MatrixContext(jr.Matrix)
 	}
 
-	// load the job context
-	c.Job = JobContext{Status: c.Execution.WorkflowRun.Conclusion}
+	// set strategy context, jobs without matrix are considered as a matrix with a single job like GitHub does
+	c.Strategy = StrategyContext{
+		FailFast:    jr.Job.Strategy.FailFast == nil || *jr.Job.Strategy.FailFast,
+		JobIndex:    jr.JobIndex,
+		JobTotal:    max(jr.JobTotal, 1),
+		MaxParallel: jr.Job.Strategy.MaxParallel,
+	}
+
+	if c.Strategy.MaxParallel == 0 {
+		c.Strategy.MaxParallel = c.Strategy.JobTotal
+	}
 
 	// load the steps context
 	c.Steps = make(StepsContext)
 
-	c.Needs = make(NeedsContext)
+	c.Needs = make(NeedsContext, len(jr.Job.Needs))
 
-	if len(jr.Job.Needs) > 0 {
-		for _, need := range jr.Job.Needs {
-			need := c.Execution.WorkflowRun.Jobs[need]
+	// needed jobs missing in the workflow run are not executed, so they're considered as skipped
+	for _, id := range jr.Job.Needs {
+		c.Needs[id] = newNeedContext(c.Execution.WorkflowRun.Jobs[id])
+	}
 
-			c.Needs[need.Job.ID] = NeedContext{Result: need.Conclusion, Outputs: need.Outputs}
-		}
+	// load the job context
+	c.Job = JobContext{Status: c.needsStatus(jr.Job)}
+
+	// set env context after the matrix, strategy and needs contexts, job env could refer to them
+	c.resetEnv(jr)
+
+	// output is owned by the job until a step is set
+	journal.SetOwner(jr.DisplayName(), "")
+
+	c.EmitEvent(protocol.Event{Type: protocol.EventTypeJobStarted})
+
+	return nil
+}
+
+// RestoreJobRun adds the job run completed in a previous part of the workflow run, e.g. in another runner container, to
+// the workflow run. The needs context of the jobs depending on it is loaded from the restored results.
+func (c *Context) RestoreJobRun(jr core.JobRun) error {
+	if c.Execution.WorkflowRun == nil {
+		return errors.New("no workflow is set")
+	}
+
+	c.Execution.WorkflowRun.JobRuns = append(c.Execution.WorkflowRun.JobRuns, jr)
+	c.Execution.WorkflowRun.Jobs[jr.Job.ID] = mergeJobRuns(c.Execution.WorkflowRun.Jobs[jr.Job.ID], jr)
+
+	// skipped jobs don't change the conclusion of the workflow like GitHub
+	if c.Execution.WorkflowRun.Conclusion == core.ConclusionSuccess && jr.Conclusion != core.ConclusionSuccess && jr.Conclusion != core.ConclusionSkipped {
+		c.Execution.WorkflowRun.Conclusion = jr.Conclusion
 	}
 
 	return nil
@@ -103,24 +158,43 @@ func (c *Context) SetJob(jr *core.JobRun) error {
 
 // UnsetJob unsets the job from the execution context.
 func (c *Context) UnsetJob(result RunResult) {
+	c.EmitEvent(protocol.Event{Type: protocol.EventTypeJobCompleted, Conclusion: string(result.Conclusion), Duration: result.Duration.String()})
+
 	jr := c.Execution.JobRun
 
+	jr.Duration = result.Duration
+
+	// jobs not executed don't set their results, e.g. skipped by the job condition, so the result of the task is used
+	if jr.Conclusion == "" {
+		jr.Conclusion = result.Conclusion
+		jr.Outcome = result.Conclusion
+	}
+
+	// keep the job runs in execution order for the duration summary
+	c.Execution.WorkflowRun.JobRuns = append(c.Execution.WorkflowRun.JobRuns, *jr)
+
 	// update the job run in the workflow run
-	c.Execution.WorkflowRun.Jobs[jr.Job.ID] = *jr
+	c.Execution.WorkflowRun.Jobs[jr.Job.ID] = mergeJobRuns(c.Execution.WorkflowRun.Jobs[jr.Job.ID], *jr)
 
-	// update workflow conclusion
-	if c.Execution.WorkflowRun.Conclusion == core.ConclusionSuccess && jr.Conclusion != core.ConclusionSuccess {
+	// update workflow conclusion, skipped jobs don't change the conclusion of the workflow like GitHub
+	if c.Execution.WorkflowRun.Conclusion == core.ConclusionSuccess && jr.Conclusion != core.ConclusionSuccess && jr.Conclusion != core.ConclusionSkipped {
 		c.Execution.WorkflowRun.Conclusion = jr.Conclusion
 	}
 	// unset the job run from the github context
 	c.Github.Job = ""
 
-	// unset the job from env context -- just set it to the workflow env would be enough
-	c.Env = c.Execution.WorkflowRun.Workflow.Env
+	// unset the job from env context, variables exported by the steps of the job are dropped as well
+	c.resetEnv(nil)
 
 	// reset matrix context
 	c.Matrix = make(MatrixContext)
 
+	// reset strategy context
+	c.Strategy = StrategyContext{}
+
+	// reset environment secrets and vars
+	c.unsetEnvironment()
+
 	// write the job run result to the file system
 	// ignoring error since directory must be exist at this point of execution
 	dir, _ := c.GetJobRunPath()
@@ -133,6 +207,71 @@ func (c *Context) UnsetJob(result RunResult) {
 
 	// unset the job run from the execution context
 	c.Execution.JobRun = nil
+
+	journal.SetOwner("", "")
+}
+
+// newNeedContext returns the needs context of the job run. Job runs without a conclusion are considered as skipped and
+// outputs are always set, so the expressions referring to the outputs of a skipped job evaluate to empty strings.
+func newNeedContext(jr core.JobRun) NeedContext {
+	result := jr.Conclusion
+	if result == "" {
+		result = core.ConclusionSkipped
+	}
+
+	outputs := make(map[string]string, len(jr.Outputs))
+
+	for k, v := range jr.Outputs {
+		outputs[k] = v
+	}
+
+	return NeedContext{Result: result, Outputs: outputs}
+}
+
+// needsStatus returns the status of the job to evaluate the status check functions of the job condition. Jobs without
+// needs inherit the conclusion of the workflow. Otherwise, like GitHub, the status is derived from the results of the
+// needed jobs and their needs: cancelled or failed jobs fail the status, skipped jobs make both success() and failure()
+// false, so the job is skipped as well unless its condition is always() or checks the needs explicitly.
+func (c *Context) needsStatus(job core.Job) core.Conclusion {
+	wr := c.Execution.WorkflowRun
+
+	if len(job.Needs) == 0 || wr.Conclusion == core.ConclusionCancelled {
+		return wr.Conclusion
+	}
+
+	// severity of the results, the most severe result of the needed jobs is the status of the job
+	severity := map[core.Conclusion]int{
+		core.ConclusionSuccess:   0,
+		core.ConclusionSkipped:   1,
+		core.ConclusionFailure:   2,
+		core.ConclusionCancelled: 3,
+	}
+
+	var (
+		status  = core.ConclusionSuccess
+		visited = make(map[string]bool)
+		visit   func(needs []string)
+	)
+
+	visit = func(needs []string) {
+		for _, id := range needs {
+			if visited[id] {
+				continue
+			}
+
+			visited[id] = true
+
+			if result := newNeedContext(wr.Jobs[id]).Result; severity[result] > severity[status] {
+				status = result
+			}
+
+			visit(wr.Workflow.Jobs[id].Needs)
+		}
+	}
+
+	visit(job.Needs)
+
+	return status
 }
 
 // SetJobResults sets the status of the job.
@@ -160,11 +299,32 @@ func (c *Context) SetStep(sr *core.StepRun) error {
 
 	c.Execution.StepRun = sr
 
-	// set the step env context
-	for k, v := range sr.Step.Environment {
+	c.Github.Action = sr.Step.ID
+	c.Github.ActionRepository, c.Github.ActionRef = actionRepositoryRef(sr.Step)
+
+	// keep the env of the job before the step env to report the variables the step adds or changes
+	c.envBase = maps.Clone(c.Env)
+
+	// set the step env context, step env is evaluated with the env of the workflow, the job and the previous steps
+	for k, v := range c.EvalEnv(sr.Step.Environment) {
 		c.Env[k] = v
 	}
 
+	c.applyEnvOverrides(c.Env, EnvPrecedenceStep)
+
+	// take a snapshot of the watched files to report the changes made by the main stage of the step
+	if c.GhxConfig.FilesReport && sr.Stage == core.StepStageMain {
+		c.files = takeFilesSnapshot(c.filesReportPaths())
+	}
+
+	journal.SetOwner(c.Execution.JobRun.DisplayName(), stepDisplayName(sr))
+
+	if c.APIAudit != nil {
+		c.APIAudit.setStep(c.Execution.JobRun.DisplayName(), stepDisplayName(sr))
+	}
+
+	c.EmitEvent(protocol.Event{Type: protocol.EventTypeStepStarted})
+
 	return nil
 }
 
@@ -174,17 +334,40 @@ func (c *Context) UnsetStep(result RunResult) {
 		return
 	}
 
-	// TODO: improve this logic
+	c.EmitEvent(protocol.Event{Type: protocol.EventTypeStepCompleted, Conclusion: string(result.Conclusion), Duration: result.Duration.String()})
+
+	// calls between the steps, e.g. the deployment statuses, are attributed to the job only
+	if c.APIAudit != nil {
+		c.APIAudit.setStep(c.Execution.JobRun.DisplayName(), "")
+	}
+
+	sr := c.Execution.StepRun
 
-	// unset the step run from the execution context
+	sr.EnvDiff = diffEnv(c.envBase, sr.Environment)
 
-	c.Env = c.Execution.WorkflowRun.Workflow.Env
+	c.envBase = nil
 
-	for k, v := range c.Execution.JobRun.Job.Env {
-		c.Env[k] = v
+	// keep the variables and paths exported by the step in the job run to make them available to subsequent steps
+	if c.Execution.JobRun.Environment == nil {
+		c.Execution.JobRun.Environment = make(map[string]string)
 	}
 
-	sr := c.Execution.StepRun
+	for k, v := range sr.Environment {
+		c.Execution.JobRun.Environment[k] = v
+	}
+
+	c.Execution.JobRun.Path = append(c.Execution.JobRun.Path, sr.Path...)
+
+	// unset the step env from the env context
+	c.resetEnv(c.Execution.JobRun)
+
+	sr.Duration = result.Duration
+
+	// steps not executed don't set their results, e.g. skipped by the step condition, so the result of the task is used
+	if sr.Conclusion == "" {
+		sr.Conclusion = result.Conclusion
+		sr.Outcome = result.Conclusion
+	}
 
 	// update the step run in the job run
 	c.Execution.JobRun.Steps = append(c.Execution.JobRun.Steps, *sr)
@@ -205,6 +388,12 @@ func (c *Context) UnsetStep(result RunResult) {
 
 	// only export the result of the main stage
 	if c.Execution.StepRun.Stage == core.StepStageMain {
+		if c.files != nil && result.Ran {
+			c.Execution.StepRun.Files = c.files.diff(takeFilesSnapshot(c.filesReportPaths()))
+		}
+
+		c.files = nil
+
 		// write the job run result to the file system
 		// ignoring error since directory must exist at this point of execution
 		dir, _ := c.GetStepRunPath()
@@ -223,6 +412,24 @@ func (c *Context) UnsetStep(result RunResult) {
 	}
 
 	c.Execution.StepRun = nil
+
+	c.Github.Action = ""
+	c.Github.ActionRepository = ""
+	c.Github.ActionRef = ""
+
+	journal.SetOwner(c.Execution.JobRun.DisplayName(), "")
+}
+
+// stepDisplayName returns the display name of the step run. Pre and post stages are prefixed like their task names.
+func stepDisplayName(sr *core.StepRun) string {
+	switch sr.Stage {
+	case core.StepStagePre:
+		return fmt.Sprintf("Pre %s", sr.Step.DisplayName())
+	case core.StepStagePost:
+		return fmt.Sprintf("Post %s", sr.Step.DisplayName())
+	default:
+		return sr.Step.DisplayName()
+	}
 }
 
 func (c *Context) SetStepResults(conclusion, outcome core.Conclusion) error {
@@ -280,11 +487,49 @@ func (c *Context) AddStepPath(path string) error {
 	return nil
 }
 
+// AddAnnotation adds an annotation created by the current step to the workflow run. Location parameters of the
+// workflow command are kept as they are.
+func (c *Context) AddAnnotation(level core.AnnotationLevel, message string, params map[string]string) error {
+	if c.Execution.StepRun == nil {
+		return errors.New("no step is set")
+	}
+
+	annotation := core.Annotation{
+		Level:   level,
+		Message: message,
+		Title:   params["title"],
+		File:    params["file"],
+		Line:    params["line"],
+		EndLine: params["endLine"],
+		Col:     params["col"],
+		EndCol:  params["endCol"],
+		Job:     c.Execution.JobRun.DisplayName(),
+		Step:    c.Execution.StepRun.Step.Name,
+	}
+
+	if annotation.Step == "" {
+		annotation.Step = c.Execution.StepRun.Step.ID
+	}
+
+	// retried steps and repeated workflow commands create the same annotation again, it's reported only once
+	if slices.Contains(c.Execution.WorkflowRun.Annotations, annotation) {
+		return nil
+	}
+
+	c.Execution.WorkflowRun.Annotations = append(c.Execution.WorkflowRun.Annotations, annotation)
+
+	return nil
+}
+
 func (c *Context) SetStepEnv(key, value string) error {
 	if c.Execution.StepRun == nil {
 		return errors.New("no step is set")
 	}
 
+	if c.Execution.StepRun.Environment == nil {
+		c.Execution.StepRun.Environment = make(map[string]string)
+	}
+
 	c.Execution.StepRun.Environment[key] = value
 
 	return nil
@@ -292,8 +537,127 @@ func (c *Context) SetStepEnv(key, value string) error {
 
 func (c *Context) SetAction(action *core.CustomAction) {
 	c.Execution.CurrentAction = action
+	c.Github.ActionPath = action.Path
 }
 
 func (c *Context) UnsetAction() {
 	c.Execution.CurrentAction = nil
+	c.Github.ActionPath = ""
+}
+
+// ActionEnv returns the environment variables describing the current step and action, e.g. GITHUB_ACTION. Actions
+// use them to identify themselves, e.g. the context of the actions/github toolkit.
+func (c *Context) ActionEnv() map[string]string {
+	env := map[string]string{"GITHUB_ACTION": c.Github.Action}
+
+	if c.Github.ActionPath != "" {
+		env["GITHUB_ACTION_PATH"] = c.Github.ActionPath
+	}
+
+	if c.Github.ActionRepository != "" {
+		env["GITHUB_ACTION_REPOSITORY"] = c.Github.ActionRepository
+		env["GITHUB_ACTION_REF"] = c.Github.ActionRef
+	}
+
+	return env
+}
+
+// actionRepositoryRef returns the repository and the ref of the remote action of the step, e.g. actions/checkout and
+// v4 for actions/checkout@v4. Local actions, docker actions and run steps don't have a repository.
+func actionRepositoryRef(step core.Step) (string, string) {
+	if step.Type() != core.StepTypeAction || strings.HasPrefix(step.Uses, "./") {
+		return "", ""
+	}
+
+	ref, version, _ := strings.Cut(step.Uses, "@")
+
+	// actions in the subdirectories of a repository are identified with the repository, e.g. github/codeql-action
+	parts := strings.SplitN(ref, "/", 3)
+	if len(parts) < 2 {
+		return "", ""
+	}
+
+	return parts[0] + "/" + parts[1], version
+}
+
+// resetEnv resets the env context to the workflow env and the env of the given job run if any. Workflow env is
+// evaluated without the env context and job env is evaluated with the workflow env, so each level could only refer to
+// the levels above it. Env overrides of the run are applied after the levels they have precedence over. Variables
+// exported by the previous steps of the job are applied last like GitHub does, so they override the workflow and the
+// job env and only the step env has precedence over them.
+func (c *Context) resetEnv(jr *core.JobRun) {
+	c.Env = make(EnvContext)
+
+	for k, v := range c.EvalEnv(c.Execution.WorkflowRun.Workflow.Env) {
+		c.Env[k] = v
+	}
+
+	// overrides are added to the workflow env, so the job env could refer to them as well
+	c.applyEnvOverrides(c.Env, EnvPrecedenceWorkflow)
+
+	if jr == nil {
+		return
+	}
+
+	env := make(EnvContext, len(c.Env))
+
+	for k, v := range c.Env {
+		env[k] = v
+	}
+
+	for k, v := range c.EvalEnv(jr.Job.Env) {
+		env[k] = v
+	}
+
+	c.applyEnvOverrides(env, EnvPrecedenceJob)
+
+	for k, v := range jr.Environment {
+		env[k] = v
+	}
+
+	c.Env = env
+}
+
+// EvalEnv returns the env values with their expressions evaluated, e.g. TAG: ${{ github.sha }}. Values are evaluated
+// with the current contexts, so the values in the same map can't refer to each other.
+func (c *Context) EvalEnv(env map[string]string) map[string]string {
+	evaluated := make(map[string]string, len(env))
+
+	for k, v := range env {
+		evaluated[k] = expression.NewString(v).Eval(c)
+	}
+
+	return evaluated
+}
+
+// mergeJobRuns merges the results of the job runs sharing the same job id, e.g. runs of a matrix job. The job fails if
+// any of the runs fails and outputs of the later runs override the previous ones unless they are empty.
+func mergeJobRuns(prev, curr core.JobRun) core.JobRun {
+	if prev.RunID == curr.RunID {
+		return curr
+	}
+
+	merged := curr
+
+	merged.Outputs = make(map[string]string, len(prev.Outputs)+len(curr.Outputs))
+
+	for k, v := range prev.Outputs {
+		merged.Outputs[k] = v
+	}
+
+	for k, v := range curr.Outputs {
+		if v != "" {
+			merged.Outputs[k] = v
+		}
+	}
+
+	if prev.Conclusion != "" && prev.Conclusion != core.ConclusionSuccess {
+		merged.Conclusion = prev.Conclusion
+	}
+
+	if prev.Outcome != "" && prev.Outcome != core.ConclusionSuccess {
+		merged.Outcome = prev.Outcome
+	}
+
+	return merged
 }
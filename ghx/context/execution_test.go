@@ -0,0 +1,456 @@
+package context
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aweris/gale/ghx/core"
+	"github.com/aweris/gale/ghx/expression"
+)
+
+func TestNewNeedContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		jr       core.JobRun
+		expected NeedContext
+	}{
+		{
+			name:     "Not executed",
+			jr:       core.JobRun{},
+			expected: NeedContext{Result: core.ConclusionSkipped, Outputs: map[string]string{}},
+		},
+		{
+			name:     "Failed with outputs",
+			jr:       core.JobRun{Conclusion: core.ConclusionFailure, Outputs: map[string]string{"version": "1.0.0"}},
+			expected: NeedContext{Result: core.ConclusionFailure, Outputs: map[string]string{"version": "1.0.0"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newNeedContext(tt.jr); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestContext_NeedsStatus(t *testing.T) {
+	workflow := core.Workflow{
+		Jobs: map[string]core.Job{
+			"lint":    {ID: "lint"},
+			"build":   {ID: "build"},
+			"test":    {ID: "test", Needs: core.Needs{"build"}},
+			"deploy":  {ID: "deploy", Needs: core.Needs{"test"}},
+			"release": {ID: "release", Needs: core.Needs{"lint"}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		conclusion core.Conclusion
+		jobs       map[string]core.JobRun
+		job        string
+		expected   core.Conclusion
+	}{
+		{
+			name:       "Without needs inherits workflow conclusion",
+			conclusion: core.ConclusionFailure,
+			job:        "build",
+			expected:   core.ConclusionFailure,
+		},
+		{
+			name:       "Failure of unrelated job is ignored",
+			conclusion: core.ConclusionFailure,
+			jobs: map[string]core.JobRun{
+				"lint":  {Conclusion: core.ConclusionFailure},
+				"build": {Conclusion: core.ConclusionSuccess},
+			},
+			job:      "test",
+			expected: core.ConclusionSuccess,
+		},
+		{
+			name:       "Skipped need",
+			conclusion: core.ConclusionSuccess,
+			jobs: map[string]core.JobRun{
+				"lint": {Conclusion: core.ConclusionSkipped},
+			},
+			job:      "release",
+			expected: core.ConclusionSkipped,
+		},
+		{
+			name:       "Failure of transitive need",
+			conclusion: core.ConclusionFailure,
+			jobs: map[string]core.JobRun{
+				"build": {Conclusion: core.ConclusionFailure},
+				"test":  {Conclusion: core.ConclusionSkipped},
+			},
+			job:      "deploy",
+			expected: core.ConclusionFailure,
+		},
+		{
+			name:       "Cancelled workflow",
+			conclusion: core.ConclusionCancelled,
+			jobs: map[string]core.JobRun{
+				"build": {Conclusion: core.ConclusionSuccess},
+			},
+			job:      "test",
+			expected: core.ConclusionCancelled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &Context{
+				Execution: ExecutionContext{
+					WorkflowRun: &core.WorkflowRun{Workflow: workflow, Conclusion: tt.conclusion, Jobs: tt.jobs},
+				},
+			}
+
+			if got := ctx.needsStatus(workflow.Jobs[tt.job]); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestContext_ResetEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		exported map[string]string
+		expected EnvContext
+	}{
+		{
+			name:     "exported variable",
+			exported: map[string]string{"EXPORTED": "${{ literal }}"},
+			expected: EnvContext{"TAG": "abc123", "IMAGE": "app:abc123", "OS": "ubuntu-latest", "EXPORTED": "${{ literal }}"},
+		},
+		{
+			name:     "exported variables override workflow and job env",
+			exported: map[string]string{"TAG": "exported", "IMAGE": "app:exported"},
+			expected: EnvContext{"TAG": "exported", "IMAGE": "app:exported", "OS": "ubuntu-latest"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &Context{
+				Matrix: MatrixContext{"os": "ubuntu-latest"},
+				Execution: ExecutionContext{
+					WorkflowRun: &core.WorkflowRun{
+						Workflow: core.Workflow{
+							Env: map[string]string{"TAG": "${{ github.sha }}", "IMAGE": "${{ env.TAG }}"},
+						},
+					},
+				},
+			}
+
+			ctx.Github.SHA = "abc123"
+
+			jr := &core.JobRun{
+				Job: core.Job{
+					Env: map[string]string{"IMAGE": "app:${{ env.TAG }}", "OS": "${{ matrix.os }}"},
+				},
+				Environment: tt.exported,
+			}
+
+			ctx.resetEnv(jr)
+
+			if !reflect.DeepEqual(ctx.Env, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, ctx.Env)
+			}
+		})
+	}
+}
+
+func TestActionRepositoryRef(t *testing.T) {
+	tests := []struct {
+		uses       string
+		repository string
+		ref        string
+	}{
+		{uses: "actions/checkout@v4", repository: "actions/checkout", ref: "v4"},
+		{uses: "github/codeql-action/init@v3", repository: "github/codeql-action", ref: "v3"},
+		{uses: "./.github/actions/build", repository: "", ref: ""},
+		{uses: "docker://alpine:3", repository: "", ref: ""},
+	}
+
+	for _, tt := range tests {
+		repository, ref := actionRepositoryRef(core.Step{Uses: tt.uses})
+		if repository != tt.repository || ref != tt.ref {
+			t.Errorf("actionRepositoryRef(%q) = %q, %q, expected %q, %q", tt.uses, repository, ref, tt.repository, tt.ref)
+		}
+	}
+}
+
+func TestContext_ActionEnv(t *testing.T) {
+	ctx := &Context{}
+
+	ctx.Github.Action = "script"
+	ctx.Github.ActionRepository = "actions/github-script"
+	ctx.Github.ActionRef = "v7"
+
+	env := ctx.ActionEnv()
+
+	expected := map[string]string{"GITHUB_ACTION": "script", "GITHUB_ACTION_REPOSITORY": "actions/github-script", "GITHUB_ACTION_REF": "v7"}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("expected %v, got %v", expected, env)
+	}
+}
+
+func TestContext_JobEnvIsolation(t *testing.T) {
+	build := core.Job{ID: "build", Env: map[string]string{"JOB": "build"}}
+	test := core.Job{ID: "test", Env: map[string]string{"JOB": "test"}}
+
+	ctx := &Context{
+		GhxConfig: GhxConfig{HomeDir: t.TempDir()},
+		Execution: ExecutionContext{
+			WorkflowRun: &core.WorkflowRun{
+				RunID:    "1",
+				Workflow: core.Workflow{Env: map[string]string{"WORKFLOW": "ci"}, Jobs: map[string]core.Job{"build": build, "test": test}},
+				Jobs:     make(map[string]core.JobRun),
+			},
+		},
+	}
+
+	newStep := func(id string, env map[string]string) *core.StepRun {
+		return &core.StepRun{
+			Step:        core.Step{ID: id, Environment: env},
+			Stage:       core.StepStageMain,
+			Environment: make(map[string]string),
+			Outputs:     make(map[string]string),
+			State:       make(map[string]string),
+		}
+	}
+
+	if err := ctx.SetJob(&core.JobRun{RunID: "build", Job: build}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the first step exports a variable and adds a path for the subsequent steps
+	if err := ctx.SetStep(newStep("export", map[string]string{"STEP": "export"})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := ctx.SetStepEnv("EXPORTED", "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := ctx.AddStepPath("/opt/tool/bin"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx.UnsetStep(RunResult{Ran: true, Conclusion: core.ConclusionSuccess})
+
+	// the next step of the job sees the exported variable and the path, but not the env of the previous step
+	if err := ctx.SetStep(newStep("use", nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := EnvContext{"WORKFLOW": "ci", "JOB": "build", "EXPORTED": "1"}
+	if !reflect.DeepEqual(ctx.Env, expected) {
+		t.Errorf("expected env %v in the next step, got %v", expected, ctx.Env)
+	}
+
+	if path := ctx.Execution.JobRun.Path; !reflect.DeepEqual(path, []string{"/opt/tool/bin"}) {
+		t.Errorf("expected the added path in the job run, got %v", path)
+	}
+
+	ctx.UnsetStep(RunResult{Ran: true, Conclusion: core.ConclusionSuccess})
+	ctx.UnsetJob(RunResult{Ran: true, Conclusion: core.ConclusionSuccess})
+
+	// the next job starts with the workflow env only
+	expected = EnvContext{"WORKFLOW": "ci"}
+	if !reflect.DeepEqual(ctx.Env, expected) {
+		t.Errorf("expected env %v between the jobs, got %v", expected, ctx.Env)
+	}
+
+	jr := &core.JobRun{RunID: "test", Job: test}
+
+	if err := ctx.SetJob(jr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected = EnvContext{"WORKFLOW": "ci", "JOB": "test"}
+	if !reflect.DeepEqual(ctx.Env, expected) {
+		t.Errorf("expected env %v in the next job, got %v", expected, ctx.Env)
+	}
+
+	if len(jr.Path) != 0 || len(jr.Environment) != 0 {
+		t.Errorf("expected no exported variables or paths in the next job, got %v and %v", jr.Environment, jr.Path)
+	}
+}
+
+func TestContext_RestoreJobRun(t *testing.T) {
+	c := &Context{Execution: ExecutionContext{WorkflowRun: &core.WorkflowRun{Conclusion: core.ConclusionSuccess, Jobs: make(map[string]core.JobRun)}}}
+
+	runs := []core.JobRun{
+		{RunID: "1", Job: core.Job{ID: "test"}, Conclusion: core.ConclusionSuccess, Outputs: map[string]string{"os": "linux"}},
+		{RunID: "2", Job: core.Job{ID: "test"}, Conclusion: core.ConclusionFailure, Outputs: map[string]string{"arch": "arm64"}},
+	}
+
+	for _, jr := range runs {
+		if err := c.RestoreJobRun(jr); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	need := newNeedContext(c.Execution.WorkflowRun.Jobs["test"])
+
+	expected := NeedContext{Result: core.ConclusionFailure, Outputs: map[string]string{"os": "linux", "arch": "arm64"}}
+
+	if !reflect.DeepEqual(need, expected) {
+		t.Errorf("expected %+v, got %+v", expected, need)
+	}
+
+	if c.Execution.WorkflowRun.Conclusion != core.ConclusionFailure || len(c.Execution.WorkflowRun.JobRuns) != 2 {
+		t.Errorf("unexpected workflow run %+v", c.Execution.WorkflowRun)
+	}
+
+	if err := (&Context{}).RestoreJobRun(runs[0]); err == nil {
+		t.Error("expected error without a workflow")
+	}
+}
+
+func TestContext_AddAnnotation(t *testing.T) {
+	c := &Context{
+		Execution: ExecutionContext{
+			WorkflowRun: &core.WorkflowRun{},
+			JobRun:      &core.JobRun{Job: core.Job{ID: "build", Name: "build"}},
+			StepRun:     &core.StepRun{Step: core.Step{ID: "test"}},
+		},
+	}
+
+	for _, message := range []string{"broken", "broken", "deprecated"} {
+		if err := c.AddAnnotation(core.AnnotationLevelError, message, map[string]string{"file": "main.go", "line": "1"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var messages []string
+
+	for _, annotation := range c.Execution.WorkflowRun.Annotations {
+		messages = append(messages, annotation.Message)
+	}
+
+	if expected := []string{"broken", "deprecated"}; !reflect.DeepEqual(messages, expected) {
+		t.Errorf("expected annotations %v, got %v", expected, messages)
+	}
+}
+
+func TestMergeJobRuns(t *testing.T) {
+	tests := []struct {
+		name     string
+		prev     core.JobRun
+		curr     core.JobRun
+		expected core.JobRun
+	}{
+		{
+			name:     "same run",
+			prev:     core.JobRun{RunID: "1", Conclusion: core.ConclusionFailure, Outputs: map[string]string{"version": "1"}},
+			curr:     core.JobRun{RunID: "1", Conclusion: core.ConclusionSuccess, Outputs: map[string]string{"version": "2"}},
+			expected: core.JobRun{RunID: "1", Conclusion: core.ConclusionSuccess, Outputs: map[string]string{"version": "2"}},
+		},
+		{
+			name:     "outputs of the later runs",
+			prev:     core.JobRun{RunID: "1", Conclusion: core.ConclusionSuccess, Outputs: map[string]string{"linux": "ok", "version": "1"}},
+			curr:     core.JobRun{RunID: "2", Conclusion: core.ConclusionSuccess, Outputs: map[string]string{"darwin": "ok", "version": "2", "linux": ""}},
+			expected: core.JobRun{RunID: "2", Conclusion: core.ConclusionSuccess, Outputs: map[string]string{"linux": "ok", "darwin": "ok", "version": "2"}},
+		},
+		{
+			name:     "failed previous run",
+			prev:     core.JobRun{RunID: "1", Conclusion: core.ConclusionFailure, Outcome: core.ConclusionFailure},
+			curr:     core.JobRun{RunID: "2", Conclusion: core.ConclusionSuccess, Outcome: core.ConclusionSuccess},
+			expected: core.JobRun{RunID: "2", Conclusion: core.ConclusionFailure, Outcome: core.ConclusionFailure, Outputs: map[string]string{}},
+		},
+		{
+			name:     "first run",
+			curr:     core.JobRun{RunID: "1", Conclusion: core.ConclusionSuccess, Outputs: map[string]string{"version": "1"}},
+			expected: core.JobRun{RunID: "1", Conclusion: core.ConclusionSuccess, Outputs: map[string]string{"version": "1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeJobRuns(tt.prev, tt.curr); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestContext_Strategy(t *testing.T) {
+	disabled, enabled := false, true
+
+	tests := []struct {
+		name     string
+		jr       core.JobRun
+		expected string
+	}{
+		{name: "job without matrix", expected: "true 0 1 1"},
+		{
+			name:     "matrix job",
+			jr:       core.JobRun{JobIndex: 2, JobTotal: 4},
+			expected: "true 2 4 4",
+		},
+		{
+			name:     "max parallel",
+			jr:       core.JobRun{Job: core.Job{Strategy: core.Strategy{MaxParallel: 2}}, JobIndex: 1, JobTotal: 4},
+			expected: "true 1 4 2",
+		},
+		{
+			name:     "fail fast disabled",
+			jr:       core.JobRun{Job: core.Job{Strategy: core.Strategy{FailFast: &disabled}}, JobTotal: 3},
+			expected: "false 0 3 3",
+		},
+		{
+			name:     "fail fast enabled",
+			jr:       core.JobRun{Job: core.Job{Strategy: core.Strategy{FailFast: &enabled}}, JobTotal: 3},
+			expected: "true 0 3 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &Context{
+				GhxConfig: GhxConfig{HomeDir: t.TempDir()},
+				Execution: ExecutionContext{WorkflowRun: &core.WorkflowRun{RunID: "1", Jobs: make(map[string]core.JobRun)}},
+			}
+
+			jr := tt.jr
+			jr.Job.ID = "test"
+
+			if err := ctx.SetJob(&jr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var values []string
+
+			for _, field := range []string{"fail-fast", "job-index", "job-total", "max-parallel"} {
+				expr, err := expression.NewExpression(fmt.Sprintf("${{ strategy.%s }}", field))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				value, err := expr.Evaluate(ctx)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				values = append(values, fmt.Sprint(value))
+			}
+
+			if got := strings.Join(values, " "); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+
+			ctx.UnsetJob(RunResult{Ran: true, Conclusion: core.ConclusionSuccess})
+
+			if ctx.Strategy != (StrategyContext{}) {
+				t.Errorf("expected the strategy context to be reset, got %+v", ctx.Strategy)
+			}
+		})
+	}
+}
@@ -8,7 +8,10 @@ import (
 )
 
 // expression.VariableProvider interface to be used in expressions.
-var _ expression.VariableProvider = new(Context)
+var (
+	_ expression.VariableProvider  = new(Context)
+	_ expression.WorkspaceProvider = new(Context)
+)
 
 func (c *Context) GetVariable(name string) (interface{}, error) {
 	switch name {
@@ -19,7 +22,7 @@ func (c *Context) GetVariable(name string) (interface{}, error) {
 	case "env":
 		return c.Env, nil
 	case "vars":
-		return map[string]string{}, nil
+		return c.Vars, nil
 	case "job":
 		return c.Job, nil
 	case "steps":
@@ -27,13 +30,13 @@ func (c *Context) GetVariable(name string) (interface{}, error) {
 	case "secrets":
 		return c.Secrets.Data, nil
 	case "strategy":
-		return map[string]string{}, nil
+		return c.Strategy, nil
 	case "matrix":
 		return c.Matrix, nil
 	case "needs":
 		return c.Needs, nil
 	case "inputs":
-		return c.Inputs, nil
+		return c.inputs(), nil
 	case "infinity":
 		return math.Inf(1), nil
 	case "nan":
@@ -43,52 +46,42 @@ func (c *Context) GetVariable(name string) (interface{}, error) {
 	}
 }
 
-var _ expression.VariableProvider = new(ActionsVariableProvider)
-
-// ActionsVariableProvider is a variable provider for actions. It provides the inputs context contains the inputs of the
-// current action to pass to the expressions. All other variables are provided by the main context.
-type ActionsVariableProvider struct {
-	main   *Context
-	inputs InputsContext
+// Workspace returns the workspace of the job, hashFiles() matches the files relative to it.
+func (c *Context) Workspace() string {
+	return c.Github.Workspace
 }
 
-// GetVariableProvider returns a variable provider for the current action. If the current action or step run is nil,
-// it returns the main context as the variable provider.
-func (c *Context) GetVariableProvider() expression.VariableProvider {
+// inputs returns the inputs context. While an action is running, the context contains the inputs of the action instead
+// of the workflow inputs, so all expressions of the action, including the conditions of its composite steps, refer to
+// the inputs of the action.
+func (c *Context) inputs() InputsContext {
 	if c.Execution.StepRun == nil || c.Execution.CurrentAction == nil {
-		return c
+		return c.Inputs
 	}
 
-	var (
-		inputs = make(InputsContext)
-		step   = c.Execution.StepRun.Step
-		action = c.Execution.CurrentAction
-	)
+	inputs := make(InputsContext)
 
-	for k, v := range step.With {
+	// missing required inputs are reported by the executors, the resolved values are enough for the expressions
+	resolved, _ := ResolveInputs(c.Execution.CurrentAction.Meta.Inputs, c.Execution.StepRun.Step.With, workflowScope{c})
+	for k, v := range resolved {
 		inputs[k] = v
 	}
 
-	// add default values for inputs that are not defined in the step config
-	for k, v := range action.Meta.Inputs {
-		if _, ok := step.With[k]; ok {
-			continue
-		}
-
-		if v.Default == "" {
-			continue
-		}
+	return inputs
+}
 
-		inputs[k] = v.Default
-	}
+var _ expression.VariableProvider = new(workflowScope)
 
-	return &ActionsVariableProvider{main: c, inputs: inputs}
+// workflowScope provides the variables of the context with the workflow inputs instead of the inputs of the current
+// action. Defaults of the action inputs are evaluated with it since they can't refer to the inputs of the action.
+type workflowScope struct {
+	*Context
 }
 
-func (p *ActionsVariableProvider) GetVariable(name string) (interface{}, error) {
+func (w workflowScope) GetVariable(name string) (interface{}, error) {
 	if name == "inputs" {
-		return p.inputs, nil
+		return w.Inputs, nil
 	}
 
-	return p.main.GetVariable(name)
+	return w.Context.GetVariable(name)
 }
@@ -0,0 +1,99 @@
+package context
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+// fileState is the state of a file used to detect the changes between the snapshots.
+type fileState struct {
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// filesSnapshot is the map of the file paths to their states.
+type filesSnapshot map[string]fileState
+
+// takeFilesSnapshot walks the given paths and returns the states of the files under them. Paths under the excluded
+// paths are skipped. Missing paths and the files that can't be read are ignored, since the snapshot is only used for
+// reporting.
+func takeFilesSnapshot(paths, exclude []string) filesSnapshot {
+	snapshot := make(filesSnapshot)
+
+	for _, root := range paths {
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			for _, excluded := range exclude {
+				if path == excluded || strings.HasPrefix(path, excluded+string(filepath.Separator)) {
+					if d.IsDir() {
+						return filepath.SkipDir
+					}
+
+					return nil
+				}
+			}
+
+			if d.IsDir() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+
+			snapshot[path] = fileState{size: info.Size(), mode: info.Mode(), modTime: info.ModTime()}
+
+			return nil
+		})
+	}
+
+	return snapshot
+}
+
+// diff returns the changes of the files from the snapshot to the given snapshot. Paths are sorted to keep the reports
+// stable.
+func (s filesSnapshot) diff(after filesSnapshot) *core.FilesystemDiff {
+	diff := &core.FilesystemDiff{}
+
+	for path, state := range after {
+		before, ok := s[path]
+
+		switch {
+		case !ok:
+			diff.Created = append(diff.Created, path)
+		case before != state:
+			diff.Modified = append(diff.Modified, path)
+		}
+	}
+
+	for path := range s {
+		if _, ok := after[path]; !ok {
+			diff.Deleted = append(diff.Deleted, path)
+		}
+	}
+
+	sort.Strings(diff.Created)
+	sort.Strings(diff.Modified)
+	sort.Strings(diff.Deleted)
+
+	return diff
+}
+
+// filesReportPaths returns the paths to report the file changes of the steps for, the workspace, the tool cache, /tmp
+// and the configured paths. The ghx home directory is excluded since it's written by ghx itself.
+func (c *Context) filesReportPaths() (paths, exclude []string) {
+	paths = append([]string{c.Github.Workspace, c.Runner.ToolCache, os.TempDir()}, c.GhxConfig.FilesReportPaths...)
+
+	return paths, []string{c.GhxConfig.HomeDir}
+}
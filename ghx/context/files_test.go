@@ -0,0 +1,58 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestFilesSnapshot_Diff(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		home  = filepath.Join(dir, "ghx")
+		write = func(name, content string) string {
+			path := filepath.Join(dir, name)
+
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			return path
+		}
+	)
+
+	unchanged := write("unchanged.txt", "same")
+	modified := write("modified.txt", "before")
+	deleted := write("deleted.txt", "deleted")
+
+	write("ghx/runs/step_run.json", "{}")
+
+	before := takeFilesSnapshot([]string{dir, filepath.Join(dir, "missing")}, []string{home})
+
+	if _, ok := before[unchanged]; !ok || len(before) != 3 {
+		t.Fatalf("expected 3 files in the snapshot without the excluded paths, got %v", before)
+	}
+
+	write("modified.txt", "after the step")
+	created := write("out/created.txt", "created")
+	write("ghx/runs/job_run.json", "{}")
+
+	if err := os.Remove(deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	diff := before.diff(takeFilesSnapshot([]string{dir}, []string{home}))
+
+	expected := &core.FilesystemDiff{Created: []string{created}, Modified: []string{modified}, Deleted: []string{deleted}}
+
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("expected %v, got %v", expected, diff)
+	}
+}
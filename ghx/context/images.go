@@ -0,0 +1,92 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PullPolicy is the policy of pulling the images of the steps.
+type PullPolicy string
+
+const (
+	// PullPolicyAlways resolves the image references from the registry on every run.
+	PullPolicyAlways PullPolicy = "always"
+
+	// PullPolicyIfNotPresent uses the images pinned by prefetch if available, and resolves the rest from the registry.
+	PullPolicyIfNotPresent PullPolicy = "if-not-present"
+)
+
+// UnmarshalText parses the pull policy from the text format.
+func (p *PullPolicy) UnmarshalText(text []byte) error {
+	switch policy := PullPolicy(strings.TrimSpace(string(text))); policy {
+	case PullPolicyAlways, PullPolicyIfNotPresent:
+		*p = policy
+	default:
+		return fmt.Errorf("unsupported pull policy %s, supported policies are always and if-not-present", text)
+	}
+
+	return nil
+}
+
+// ImageMappings is the map of the image references or the registries to their replacements.
+//
+// Text format is a list of mappings separated by semicolon, e.g. node:16=registry.example.com/node:16;docker.io=mirror.example.com
+type ImageMappings map[string]string
+
+// UnmarshalText parses the image mappings from the text format.
+func (im *ImageMappings) UnmarshalText(text []byte) error {
+	mappings := make(ImageMappings)
+
+	for _, entry := range strings.Split(string(text), ";") {
+		entry = strings.TrimSpace(entry)
+
+		if entry == "" {
+			continue
+		}
+
+		from, to, ok := strings.Cut(entry, "=")
+		if !ok || from == "" || to == "" {
+			return fmt.Errorf("invalid image mapping %s, expected format is from=to", entry)
+		}
+
+		mappings[from] = to
+	}
+
+	*im = mappings
+
+	return nil
+}
+
+// RewriteImage returns the image reference to pull the given image from. Image overrides are matched with the image
+// reference as it's written and have precedence over the registry mirrors. Images without a registry are considered
+// as Docker Hub images, so docker.io mirror applies to them, e.g. node:16 is pulled as <mirror>/library/node:16.
+func (c *GhxConfig) RewriteImage(image string) string {
+	if replacement, ok := c.ImageOverrides[image]; ok {
+		return replacement
+	}
+
+	registry, name := splitImage(image)
+
+	if mirror, ok := c.RegistryMirrors[registry]; ok {
+		return fmt.Sprintf("%s/%s", strings.TrimSuffix(mirror, "/"), name)
+	}
+
+	return image
+}
+
+// splitImage splits the image reference to the registry and the name of the image in the registry. Official Docker
+// Hub images are prefixed with library like the registry expects.
+func splitImage(image string) (string, string) {
+	registry, name, ok := strings.Cut(image, "/")
+
+	// first part of the reference is the registry only if it looks like a host, otherwise it's a Docker Hub image
+	if !ok || (!strings.ContainsAny(registry, ".:") && registry != "localhost") {
+		registry, name = "docker.io", image
+	}
+
+	if registry == "docker.io" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+
+	return registry, name
+}
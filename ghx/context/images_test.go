@@ -0,0 +1,62 @@
+package context
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImageMappings_UnmarshalText(t *testing.T) {
+	var mappings ImageMappings
+
+	if err := mappings.UnmarshalText([]byte("node:16=registry.example.com/node:16; docker.io=mirror.example.com;")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := ImageMappings{"node:16": "registry.example.com/node:16", "docker.io": "mirror.example.com"}
+
+	if !reflect.DeepEqual(mappings, expected) {
+		t.Errorf("expected %v, got %v", expected, mappings)
+	}
+
+	if err := mappings.UnmarshalText([]byte("node:16")); err == nil {
+		t.Error("expected error for mapping without replacement")
+	}
+}
+
+func TestPullPolicy_UnmarshalText(t *testing.T) {
+	var policy PullPolicy
+
+	if err := policy.UnmarshalText([]byte("if-not-present")); err != nil || policy != PullPolicyIfNotPresent {
+		t.Errorf("expected %s, got %s with error %v", PullPolicyIfNotPresent, policy, err)
+	}
+
+	if err := policy.UnmarshalText([]byte("never")); err == nil {
+		t.Error("expected error for unsupported pull policy")
+	}
+}
+
+func TestGhxConfig_RewriteImage(t *testing.T) {
+	cfg := GhxConfig{
+		RegistryMirrors: ImageMappings{"docker.io": "mirror.example.com/", "ghcr.io": "ghcr.example.com"},
+		ImageOverrides:  ImageMappings{"node:16": "registry.example.com/node:16"},
+	}
+
+	tests := []struct {
+		image    string
+		expected string
+	}{
+		{image: "node:16", expected: "registry.example.com/node:16"},
+		{image: "alpine:latest", expected: "mirror.example.com/library/alpine:latest"},
+		{image: "alpine/git:latest", expected: "mirror.example.com/alpine/git:latest"},
+		{image: "docker.io/golang:1.21", expected: "mirror.example.com/library/golang:1.21"},
+		{image: "ghcr.io/aweris/gale/tools/ghx:v0.0.9", expected: "ghcr.example.com/aweris/gale/tools/ghx:v0.0.9"},
+		{image: "quay.io/coreos/etcd:v3.5", expected: "quay.io/coreos/etcd:v3.5"},
+		{image: "localhost:5000/app", expected: "localhost:5000/app"},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.RewriteImage(tt.image); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.image, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package context
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aweris/gale/ghx/core"
+	"github.com/aweris/gale/ghx/expression"
+)
+
+// InputEnvName returns the environment variable name of the action input. Same as GitHub, spaces in the name are
+// replaced with underscores and the name is converted to upper case, e.g. `node version` becomes INPUT_NODE_VERSION.
+func InputEnvName(name string) string {
+	return fmt.Sprintf("INPUT_%s", strings.ToUpper(strings.ReplaceAll(name, " ", "_")))
+}
+
+// ActionInputs resolves the inputs of the current action with the values of the current step. It returns an error if
+// there is no action in the execution context or any of the required inputs are missing.
+func (c *Context) ActionInputs() (map[string]string, error) {
+	if c.Execution.StepRun == nil || c.Execution.CurrentAction == nil {
+		return nil, errors.New("no action is set")
+	}
+
+	inputs, err := ResolveInputs(c.Execution.CurrentAction.Meta.Inputs, c.Execution.StepRun.Step.With, workflowScope{c})
+	if err != nil {
+		return nil, fmt.Errorf("invalid inputs for action %s: %w", c.Execution.StepRun.Step.Uses, err)
+	}
+
+	return inputs, nil
+}
+
+// ResolveInputs resolves the action inputs with the given step values. Inputs not provided by the step are set to
+// their default values evaluated with the given variable provider. Input names are matched case-insensitively and
+// values not defined by the action are kept as they are.
+//
+// It returns an error listing the required inputs that are neither provided by the step nor have a default value.
+func ResolveInputs(inputs map[string]core.CustomActionInput, with map[string]string, vp expression.VariableProvider) (map[string]string, error) {
+	var (
+		resolved = make(map[string]string)
+		provided = make(map[string]bool)
+		missing  []string
+	)
+
+	for k, v := range with {
+		resolved[k] = v
+		provided[strings.ToLower(k)] = true
+	}
+
+	for name, input := range inputs {
+		if provided[strings.ToLower(name)] {
+			continue
+		}
+
+		if input.Default == "" {
+			if input.Required {
+				missing = append(missing, name)
+			}
+
+			continue
+		}
+
+		resolved[name] = expression.NewString(input.Default).Eval(vp)
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+
+		return nil, fmt.Errorf("missing required inputs: %s", strings.Join(missing, ", "))
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,115 @@
+package context
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestInputEnvName(t *testing.T) {
+	tests := map[string]string{
+		"token":        "INPUT_TOKEN",
+		"node-version": "INPUT_NODE-VERSION",
+		"node version": "INPUT_NODE_VERSION",
+		"Cache_Key":    "INPUT_CACHE_KEY",
+	}
+
+	for name, expected := range tests {
+		if got := InputEnvName(name); got != expected {
+			t.Errorf("InputEnvName(%q) = %q, want %q", name, got, expected)
+		}
+	}
+}
+
+func TestResolveInputs(t *testing.T) {
+	ctx := &Context{Github: GithubContext{Repository: "aweris/gale"}}
+
+	inputs := map[string]core.CustomActionInput{
+		"token":      {Required: true},
+		"repository": {Default: "${{ github.repository }}"},
+		"fetch-tags": {Default: "false"},
+		"path":       {},
+	}
+
+	tests := []struct {
+		name     string
+		with     map[string]string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name: "Defaults are evaluated",
+			with: map[string]string{"token": "secret"},
+			expected: map[string]string{
+				"token":      "secret",
+				"repository": "aweris/gale",
+				"fetch-tags": "false",
+			},
+		},
+		{
+			name: "Step values override defaults case-insensitively",
+			with: map[string]string{"token": "secret", "Fetch-Tags": "true", "extra": "value"},
+			expected: map[string]string{
+				"token":      "secret",
+				"repository": "aweris/gale",
+				"Fetch-Tags": "true",
+				"extra":      "value",
+			},
+		},
+		{
+			name:    "Missing required input",
+			with:    map[string]string{"fetch-tags": "true"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveInputs(inputs, tt.with, ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveInputs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ResolveInputs() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestContext_GetVariable_Inputs(t *testing.T) {
+	ctx := &Context{Inputs: InputsContext{"name": "workflow"}}
+
+	got, err := ctx.GetVariable("inputs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, InputsContext{"name": "workflow"}) {
+		t.Errorf("expected workflow inputs without an action, got %v", got)
+	}
+
+	ctx.SetAction(&core.CustomAction{
+		Meta: core.CustomActionMeta{
+			Inputs: map[string]core.CustomActionInput{
+				"greeting": {Default: "hello"},
+				"name":     {Default: "${{ inputs.name }}"},
+			},
+		},
+	})
+
+	ctx.Execution.StepRun = &core.StepRun{Step: core.Step{With: map[string]string{"greeting": "hi"}}}
+
+	got, err = ctx.GetVariable("inputs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// defaults of the action inputs are evaluated with the workflow inputs
+	expected := InputsContext{"greeting": "hi", "name": "workflow"}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected action inputs %v, got %v", expected, got)
+	}
+}
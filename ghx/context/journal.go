@@ -0,0 +1,73 @@
+package context
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/journal"
+)
+
+// journalFile is the name of the file recording the console output in the workflow run directory.
+const journalFile = "journal.ndjson"
+
+// startJournal starts recording the console output of the workflow run to the workflow run directory, and to the logs
+// directory if configured, so the run could be followed while it's running. Output is shipped to the configured journal
+// sinks as well.
+func (c *Context) startJournal() error {
+	dir, err := c.GetWorkflowRunPath()
+	if err != nil {
+		return err
+	}
+
+	paths := []string{filepath.Join(dir, journalFile)}
+
+	if c.GhxConfig.LogsDir != "" {
+		logs, err := EnsureDir(c.GhxConfig.LogsDir)
+		if err != nil {
+			return err
+		}
+
+		paths = append(paths, filepath.Join(logs, fmt.Sprintf("%s.ndjson", c.Execution.WorkflowRun.RunID)))
+	}
+
+	recorder, err := journal.NewRecorder(paths...)
+	if err != nil {
+		return err
+	}
+
+	for _, spec := range c.GhxConfig.JournalSinks {
+		if spec == "" {
+			continue
+		}
+
+		sink, err := journal.OpenSink(spec, c.GhxConfig.JournalSocket)
+		if err != nil {
+			return errors.Join(err, recorder.Close("cancelled"))
+		}
+
+		recorder.AddSink(sink)
+	}
+
+	c.recorder = recorder
+
+	log.SetSink(recorder.RecordLine)
+
+	return nil
+}
+
+// stopJournal stops recording the console output and marks the end of the journal with the given conclusion.
+func (c *Context) stopJournal(conclusion string) {
+	if c.recorder == nil {
+		return
+	}
+
+	log.SetSink(nil)
+
+	if err := c.recorder.Close(conclusion); err != nil {
+		log.Errorf("failed to close journal", "error", err)
+	}
+
+	c.recorder = nil
+}
@@ -0,0 +1,98 @@
+package context
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Limits represents the resource limits of a job.
+type Limits struct {
+	CPU    int    // CPU is the number of CPUs the job can use. Zero means no limit.
+	Memory uint64 // Memory is the memory limit of the job in bytes. Zero means no limit.
+}
+
+// JobLimits is the map of job ids to their resource limits.
+//
+// Text format is a list of job limits separated by semicolon, e.g. build=cpu:4,mem:8g;test=cpu:2
+type JobLimits map[string]Limits
+
+// UnmarshalText parses the job limits from the text format.
+func (jl *JobLimits) UnmarshalText(text []byte) error {
+	limits := make(JobLimits)
+
+	for _, entry := range strings.Split(string(text), ";") {
+		entry = strings.TrimSpace(entry)
+
+		if entry == "" {
+			continue
+		}
+
+		job, values, ok := strings.Cut(entry, "=")
+		if !ok || job == "" {
+			return fmt.Errorf("invalid job limits %s, expected format is job=cpu:4,mem:8g", entry)
+		}
+
+		var l Limits
+
+		for _, value := range strings.Split(values, ",") {
+			key, val, ok := strings.Cut(strings.TrimSpace(value), ":")
+			if !ok {
+				return fmt.Errorf("invalid limit %s for job %s, expected format is key:value", value, job)
+			}
+
+			switch key {
+			case "cpu":
+				cpu, err := strconv.Atoi(val)
+				if err != nil || cpu < 0 {
+					return fmt.Errorf("invalid cpu limit %s for job %s", val, job)
+				}
+
+				l.CPU = cpu
+			case "mem", "memory":
+				mem, err := ParseBytes(val)
+				if err != nil {
+					return fmt.Errorf("invalid memory limit %s for job %s: %w", val, job, err)
+				}
+
+				l.Memory = mem
+			default:
+				return fmt.Errorf("unsupported limit %s for job %s, supported limits are cpu and mem", key, job)
+			}
+		}
+
+		limits[job] = l
+	}
+
+	*jl = limits
+
+	return nil
+}
+
+// ParseBytes parses the given size with an optional unit suffix, e.g. 512m, 8g or 8Gi, to bytes.
+func ParseBytes(size string) (uint64, error) {
+	var (
+		s          = strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(size)), "b"), "i")
+		multiplier = uint64(1)
+	)
+
+	switch {
+	case strings.HasSuffix(s, "k"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(s, "m"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(s, "g"):
+		multiplier = 1 << 30
+	}
+
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+
+	value, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return value * multiplier, nil
+}
@@ -0,0 +1,245 @@
+package context
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+// NetworkMode is the network access mode of the steps of a job.
+type NetworkMode string
+
+const (
+	// NetworkModeFull doesn't restrict the network access of the steps.
+	NetworkModeFull NetworkMode = "full"
+
+	// NetworkModeRestricted routes the steps through the network proxy allowing only the gale services, GitHub hosts and
+	// the allowlist. Steps ignoring the proxy environment variables bypass it.
+	NetworkModeRestricted NetworkMode = "restricted"
+
+	// NetworkModeNone routes the steps through the network proxy allowing only the gale services. Steps ignoring the proxy
+	// environment variables bypass it.
+	NetworkModeNone NetworkMode = "none"
+)
+
+// UnmarshalText parses the network mode from the text format.
+func (m *NetworkMode) UnmarshalText(text []byte) error {
+	switch mode := NetworkMode(strings.TrimSpace(string(text))); mode {
+	case NetworkModeFull, NetworkModeRestricted, NetworkModeNone:
+		*m = mode
+	default:
+		return fmt.Errorf("unsupported network mode %s, supported modes are full, restricted and none", text)
+	}
+
+	return nil
+}
+
+// JobNetworks is the map of job ids to their network modes.
+//
+// Text format is a list of job network modes separated by semicolon, e.g. build=restricted;test=none
+type JobNetworks map[string]NetworkMode
+
+// UnmarshalText parses the job network modes from the text format.
+func (jn *JobNetworks) UnmarshalText(text []byte) error {
+	networks := make(JobNetworks)
+
+	for _, entry := range strings.Split(string(text), ";") {
+		entry = strings.TrimSpace(entry)
+
+		if entry == "" {
+			continue
+		}
+
+		job, value, ok := strings.Cut(entry, "=")
+		if !ok || job == "" {
+			return fmt.Errorf("invalid job network mode %s, expected format is job=mode", entry)
+		}
+
+		var mode NetworkMode
+
+		if err := mode.UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("invalid network mode for job %s: %w", job, err)
+		}
+
+		networks[job] = mode
+	}
+
+	*jn = networks
+
+	return nil
+}
+
+// NetworkProxies is the map of the network modes to the URLs of the proxies enforcing them.
+//
+// Text format is a list of proxies separated by semicolon, e.g. restricted=http://network-restricted:8888;none=http://network-none:8888
+type NetworkProxies map[NetworkMode]string
+
+// UnmarshalText parses the network proxies from the text format.
+func (np *NetworkProxies) UnmarshalText(text []byte) error {
+	proxies := make(NetworkProxies)
+
+	for _, entry := range strings.Split(string(text), ";") {
+		entry = strings.TrimSpace(entry)
+
+		if entry == "" {
+			continue
+		}
+
+		value, proxy, ok := strings.Cut(entry, "=")
+		if !ok || proxy == "" {
+			return fmt.Errorf("invalid network proxy %s, expected format is mode=url", entry)
+		}
+
+		var mode NetworkMode
+
+		if err := mode.UnmarshalText([]byte(value)); err != nil {
+			return err
+		}
+
+		proxies[mode] = proxy
+	}
+
+	*np = proxies
+
+	return nil
+}
+
+// NetworkMode returns the network mode of the current job. Job network modes have precedence over the network mode of
+// the run.
+func (c *Context) NetworkMode() NetworkMode {
+	if jr := c.Execution.JobRun; jr != nil {
+		if mode, ok := c.GhxConfig.NetworkJobs[jr.Job.ID]; ok {
+			return mode
+		}
+	}
+
+	if c.GhxConfig.Network == "" {
+		return NetworkModeFull
+	}
+
+	return c.GhxConfig.Network
+}
+
+// NetworkEnv returns the proxy environment variables enforcing the network mode of the current job. Steps are routed to
+// the network proxy of the mode, and only the gale services are accessed without the proxy. It returns an empty map
+// for the full network mode, so the proxy configuration of the runner is used as it is.
+//
+// The mode is enforced for the tools respecting the proxy environment variables, it's meant to validate the builds are
+// hermetic, not to isolate untrusted code.
+func (c *Context) NetworkEnv() (map[string]string, error) {
+	env := make(map[string]string)
+
+	mode := c.NetworkMode()
+	if mode == NetworkModeFull {
+		return env, nil
+	}
+
+	proxy, ok := c.GhxConfig.NetworkProxies[mode]
+	if !ok {
+		return nil, fmt.Errorf("network mode %s requires a network proxy, no proxy is configured for the mode", mode)
+	}
+
+	noProxy := append([]string{"localhost", "127.0.0.1"}, c.ServiceHosts()...)
+
+	for name, value := range map[string]string{"HTTP_PROXY": proxy, "HTTPS_PROXY": proxy, "NO_PROXY": strings.Join(noProxy, ",")} {
+		env[name] = value
+		env[strings.ToLower(name)] = value
+	}
+
+	return env, nil
+}
+
+// lookupHost resolves the addresses of the host. It's a variable to replace the resolver in the tests.
+var lookupHost = net.LookupHost
+
+// ServiceHosts returns the hosts of the gale services, e.g. the artifact service, with their resolved addresses. The
+// services are accessed directly, so the hosts are excluded from the proxy.
+func (c *Context) ServiceHosts() []string {
+	var hosts []string
+
+	services := []string{c.Actions.RuntimeURL, c.Actions.CacheURL, c.Actions.ResultsURL, os.Getenv("DOCKER_HOST")}
+
+	// API audit proxy runs in the runner container, the API URL points to it
+	if c.APIAudit != nil {
+		services = append(services, c.Github.APIURL)
+	}
+
+	for _, service := range services {
+		u, err := url.Parse(service)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+
+		hosts = appendHost(hosts, u.Hostname())
+
+		if resolved := resolveServiceURL(service); resolved != service {
+			if ru, err := url.Parse(resolved); err == nil {
+				hosts = appendHost(hosts, ru.Hostname())
+			}
+		}
+	}
+
+	return hosts
+}
+
+// ActionsServicesEnv returns the environment variables of the actions runtime services, e.g. ACTIONS_CACHE_URL, for the
+// step containers. The services are bound to the runner container with aliases like artifact-service, which aren't
+// resolvable in the step containers, so the hosts of the URLs are replaced with the addresses of the services.
+func (c *Context) ActionsServicesEnv() map[string]string {
+	env := map[string]string{"ACTIONS_RUNTIME_TOKEN": c.Actions.Token}
+
+	for name, value := range map[string]string{
+		"ACTIONS_RUNTIME_URL": c.Actions.RuntimeURL,
+		"ACTIONS_CACHE_URL":   c.Actions.CacheURL,
+		"ACTIONS_RESULTS_URL": c.Actions.ResultsURL,
+	} {
+		if value != "" {
+			env[name] = resolveServiceURL(value)
+		}
+	}
+
+	// steps in their own containers call the GitHub API through the audit proxy of the runner as well
+	if c.APIAudit != nil {
+		env["GITHUB_API_URL"] = c.Github.APIURL
+		env["GITHUB_GRAPHQL_URL"] = c.Github.GraphqlURL
+	}
+
+	return env
+}
+
+// resolveServiceURL replaces the host of the service URL with its address. The URL is returned as it is if the host is
+// already an address or it can't be resolved.
+func resolveServiceURL(service string) string {
+	u, err := url.Parse(service)
+	if err != nil || u.Hostname() == "" || net.ParseIP(u.Hostname()) != nil {
+		return service
+	}
+
+	addrs, err := lookupHost(u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return service
+	}
+
+	switch port := u.Port(); {
+	case port != "":
+		u.Host = net.JoinHostPort(addrs[0], port)
+	case strings.Contains(addrs[0], ":"):
+		u.Host = "[" + addrs[0] + "]"
+	default:
+		u.Host = addrs[0]
+	}
+
+	return u.String()
+}
+
+// appendHost appends the host to the list if it's not in the list already.
+func appendHost(hosts []string, host string) []string {
+	if slices.Contains(hosts, host) {
+		return hosts
+	}
+
+	return append(hosts, host)
+}
@@ -0,0 +1,103 @@
+package context
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestJobNetworks_UnmarshalText(t *testing.T) {
+	var networks JobNetworks
+
+	if err := networks.UnmarshalText([]byte("build=restricted; test=none")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := JobNetworks{"build": NetworkModeRestricted, "test": NetworkModeNone}
+
+	if !reflect.DeepEqual(networks, expected) {
+		t.Errorf("expected %v, got %v", expected, networks)
+	}
+
+	if err := networks.UnmarshalText([]byte("build=offline")); err == nil {
+		t.Error("expected error for unsupported network mode")
+	}
+}
+
+func TestContext_NetworkEnv(t *testing.T) {
+	ctx := &Context{
+		GhxConfig: GhxConfig{
+			Network:        NetworkModeRestricted,
+			NetworkJobs:    JobNetworks{"release": NetworkModeFull, "test": NetworkModeNone},
+			NetworkProxies: NetworkProxies{NetworkModeRestricted: "http://network-restricted:8888"},
+		},
+		Actions: ActionsContext{RuntimeURL: "http://artifact-service:8080/", CacheURL: "http://artifact-cache-service:8081/"},
+	}
+
+	env, err := ctx.NetworkEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if env["HTTPS_PROXY"] != "http://network-restricted:8888" || env["https_proxy"] != "http://network-restricted:8888" {
+		t.Errorf("expected restricted proxy, got %v", env)
+	}
+
+	if expected := "localhost,127.0.0.1,artifact-service,artifact-cache-service"; env["NO_PROXY"] != expected {
+		t.Errorf("expected no proxy %s, got %s", expected, env["NO_PROXY"])
+	}
+
+	// job network mode overrides the mode of the run
+	ctx.Execution.JobRun = &core.JobRun{Job: core.Job{ID: "release"}}
+
+	if env, err := ctx.NetworkEnv(); err != nil || len(env) != 0 {
+		t.Errorf("expected no proxy for full network mode, got %v with error %v", env, err)
+	}
+
+	// modes without a proxy can't be enforced
+	ctx.Execution.JobRun = &core.JobRun{Job: core.Job{ID: "test"}}
+
+	if _, err := ctx.NetworkEnv(); err == nil {
+		t.Error("expected error for network mode without proxy")
+	}
+}
+
+func TestContext_ActionsServicesEnv(t *testing.T) {
+	defer func(fn func(string) ([]string, error)) { lookupHost = fn }(lookupHost)
+
+	lookupHost = func(host string) ([]string, error) {
+		if host == "artifact-service" {
+			return []string{"10.87.0.5"}, nil
+		}
+
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	ctx := &Context{
+		Actions: ActionsContext{
+			RuntimeURL: "http://artifact-service:8080/",
+			CacheURL:   "http://artifact-cache-service:8081/",
+			ResultsURL: "http://artifact-service:8080/",
+			Token:      "token",
+		},
+	}
+
+	expected := map[string]string{
+		"ACTIONS_RUNTIME_URL":   "http://10.87.0.5:8080/",
+		"ACTIONS_CACHE_URL":     "http://artifact-cache-service:8081/",
+		"ACTIONS_RESULTS_URL":   "http://10.87.0.5:8080/",
+		"ACTIONS_RUNTIME_TOKEN": "token",
+	}
+
+	if env := ctx.ActionsServicesEnv(); !reflect.DeepEqual(env, expected) {
+		t.Errorf("expected %v, got %v", expected, env)
+	}
+
+	hosts := ctx.ServiceHosts()
+
+	if expected := []string{"artifact-service", "10.87.0.5", "artifact-cache-service"}; !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("expected hosts %v, got %v", expected, hosts)
+	}
+}
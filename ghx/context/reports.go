@@ -3,27 +3,36 @@ package context
 import (
 	"time"
 
+	"github.com/aweris/gale/common/log"
 	"github.com/aweris/gale/ghx/core"
 )
 
 // RunResult is the result of the task execution
 type RunResult struct {
-	Ran        bool            `json:"ran"`        // Ran indicates if the execution ran
-	Conclusion core.Conclusion `json:"conclusion"` // Conclusion of the execution
-	Duration   time.Duration   `json:"duration"`   // Duration of the execution
+	Ran         bool            `json:"ran"`          // Ran indicates if the execution ran
+	Conclusion  core.Conclusion `json:"conclusion"`   // Conclusion of the execution
+	Duration    time.Duration   `json:"duration"`     // Duration of the execution
+	StartedAt   time.Time       `json:"started_at"`   // StartedAt is the time the execution started
+	CompletedAt time.Time       `json:"completed_at"` // CompletedAt is the time the execution completed
 }
 
 type WorkflowRunReport struct {
-	Ran           bool                       `json:"ran"`            // Ran indicates if the execution ran
-	Duration      string                     `json:"duration"`       // Duration of the execution
-	Name          string                     `json:"name"`           // Name is the name of the workflow
-	Path          string                     `json:"path"`           // Path is the path of the workflow
-	RunID         string                     `json:"run_id"`         // RunID is the ID of the run
-	RunNumber     string                     `json:"run_number"`     // RunNumber is the number of the run
-	RunAttempt    string                     `json:"run_attempt"`    // RunAttempt is the attempt number of the run
-	RetentionDays string                     `json:"retention_days"` // RetentionDays is the number of days to keep the run logs
-	Conclusion    core.Conclusion            `json:"conclusion"`     // Conclusion is the result of a completed workflow run after continue-on-error is applied
-	Jobs          map[string]core.Conclusion `json:"jobs"`           // Jobs is map of the job run id to its result
+	Ran           bool                       `json:"ran"`                 // Ran indicates if the execution ran
+	Duration      string                     `json:"duration"`            // Duration of the execution
+	StartedAt     time.Time                  `json:"started_at"`          // StartedAt is the time the execution started
+	CompletedAt   time.Time                  `json:"completed_at"`        // CompletedAt is the time the execution completed
+	Name          string                     `json:"name"`                // Name is the name of the workflow
+	Path          string                     `json:"path"`                // Path is the path of the workflow
+	RunID         string                     `json:"run_id"`              // RunID is the ID of the run
+	RunNumber     string                     `json:"run_number"`          // RunNumber is the number of the run
+	RunAttempt    string                     `json:"run_attempt"`         // RunAttempt is the attempt number of the run
+	RetentionDays string                     `json:"retention_days"`      // RetentionDays is the number of days to keep the run logs
+	Conclusion    core.Conclusion            `json:"conclusion"`          // Conclusion is the result of a completed workflow run after continue-on-error is applied
+	Jobs          map[string]core.Conclusion `json:"jobs"`                // Jobs is map of the job run id to its result
+	Annotations   []core.Annotation          `json:"annotations"`         // Annotations is the list of error and warning annotations created by the steps
+	Billing       *BillingReport             `json:"billing"`             // Billing is the estimate of the billable minutes of the run on GitHub hosted runners
+	Components    map[string]string          `json:"components"`          // Components is the versions of the components used for the run, e.g. ghx and the runner image
+	APICalls      []APICall                  `json:"api_calls,omitempty"` // APICalls is the GitHub API calls recorded by the API audit proxy
 }
 
 // NewWorkflowRunReport creates a new workflow run report from the given workflow run.
@@ -31,6 +40,8 @@ func NewWorkflowRunReport(result *RunResult, wr *core.WorkflowRun) *WorkflowRunR
 	report := &WorkflowRunReport{
 		Ran:           result.Ran,
 		Duration:      result.Duration.String(),
+		StartedAt:     result.StartedAt,
+		CompletedAt:   result.CompletedAt,
 		Conclusion:    result.Conclusion,
 		Name:          wr.Workflow.Name,
 		Path:          wr.Workflow.Path,
@@ -39,45 +50,70 @@ func NewWorkflowRunReport(result *RunResult, wr *core.WorkflowRun) *WorkflowRunR
 		RunAttempt:    wr.RunAttempt,
 		RetentionDays: wr.RetentionDays,
 		Jobs:          make(map[string]core.Conclusion),
+		Annotations:   wr.Annotations,
 	}
 
 	for id, job := range wr.Jobs {
 		report.Jobs[id] = job.Conclusion
 	}
 
+	billing, err := NewBillingReport(wr)
+	if err != nil {
+		log.Warnf("Failed to estimate billable minutes", "error", err)
+	}
+
+	report.Billing = billing
+
 	return report
 }
 
 type JobRunReport struct {
-	Ran        bool                   `json:"ran"`               // Ran indicates if the execution ran
-	Duration   string                 `json:"duration"`          // Duration of the execution
-	Name       string                 `json:"name"`              // Name is the name of the job
-	RunID      string                 `json:"run_id"`            // RunID is the ID of the run
-	Conclusion core.Conclusion        `json:"conclusion"`        // Conclusion is the result of a completed job after continue-on-error is applied
-	Outcome    core.Conclusion        `json:"outcome"`           // Outcome is  the result of a completed job before continue-on-error is applied
-	Outputs    map[string]string      `json:"outputs,omitempty"` // Outputs is the outputs generated by the job
-	Matrix     core.MatrixCombination `json:"matrix,omitempty"`  // Matrix is the matrix parameters used to run the job
-	Steps      []StepRunSummary       `json:"steps"`             // Steps is the list of steps in the job
+	Ran         bool                   `json:"ran"`                   // Ran indicates if the execution ran
+	Duration    string                 `json:"duration"`              // Duration of the execution
+	StartedAt   time.Time              `json:"started_at"`            // StartedAt is the time the execution started
+	CompletedAt time.Time              `json:"completed_at"`          // CompletedAt is the time the execution completed
+	ID          string                 `json:"id"`                    // ID is the ID of the job in the workflow
+	Name        string                 `json:"name"`                  // Name is the name of the job
+	DisplayName string                 `json:"display_name"`          // DisplayName is the name of the job including the matrix values
+	RunID       string                 `json:"run_id"`                // RunID is the ID of the run
+	Conclusion  core.Conclusion        `json:"conclusion"`            // Conclusion is the result of a completed job after continue-on-error is applied
+	Outcome     core.Conclusion        `json:"outcome"`               // Outcome is  the result of a completed job before continue-on-error is applied
+	Outputs     map[string]string      `json:"outputs,omitempty"`     // Outputs is the outputs generated by the job
+	Matrix      core.MatrixCombination `json:"matrix,omitempty"`      // Matrix is the matrix parameters used to run the job
+	Environment string                 `json:"environment,omitempty"` // Environment is the name of the environment the job references
+	URL         string                 `json:"url,omitempty"`         // URL is the deployment URL of the job environment
+	Deployment  int64                  `json:"deployment,omitempty"`  // Deployment is the id of the GitHub deployment created for the job environment
+	Steps       []StepRunSummary       `json:"steps"`                 // Steps is the list of steps in the job
 }
 
 type StepRunSummary struct {
-	ID         string          `json:"id"`             // ID is the unique identifier of the step.
-	Name       string          `json:"name,omitempty"` // Name is the name of the step
-	Stage      core.StepStage  `json:"stage"`          // Stage is the stage of the step during the execution of the job. Possible values are: setup, pre, main, post, complete.
-	Conclusion core.Conclusion `json:"conclusion"`     // Conclusion is the result of a completed job after continue-on-error is applied
+	ID           string          `json:"id"`                      // ID is the unique identifier of the step.
+	Name         string          `json:"name,omitempty"`          // Name is the name of the step
+	Stage        core.StepStage  `json:"stage"`                   // Stage is the stage of the step during the execution of the job. Possible values are: setup, pre, main, post, complete.
+	Conclusion   core.Conclusion `json:"conclusion"`              // Conclusion is the result of a completed job after continue-on-error is applied
+	Duration     string          `json:"duration"`                // Duration of the step
+	PullDuration string          `json:"pull_duration,omitempty"` // PullDuration is the time spent pulling the image of the step
+	Attempts     int             `json:"attempts,omitempty"`      // Attempts is the number of attempts to run the step with the retry policy
 }
 
 // NewJobRunReport creates a new job run report from the given job run.
 func NewJobRunReport(result *RunResult, jr *core.JobRun) *JobRunReport {
 	report := &JobRunReport{
-		Ran:        result.Ran,
-		Duration:   result.Duration.String(),
-		Conclusion: result.Conclusion,
-		Name:       jr.Job.Name,
-		RunID:      jr.RunID,
-		Outcome:    jr.Outcome,
-		Outputs:    jr.Outputs,
-		Matrix:     jr.Matrix,
+		Ran:         result.Ran,
+		Duration:    result.Duration.String(),
+		StartedAt:   result.StartedAt,
+		CompletedAt: result.CompletedAt,
+		Conclusion:  result.Conclusion,
+		ID:          jr.Job.ID,
+		Name:        jr.Job.Name,
+		DisplayName: jr.DisplayName(),
+		RunID:       jr.RunID,
+		Outcome:     jr.Outcome,
+		Outputs:     jr.Outputs,
+		Matrix:      jr.Matrix,
+		Environment: jr.Job.Environment.Name,
+		URL:         jr.URL,
+		Deployment:  jr.Deployment,
 	}
 
 	for _, step := range jr.Steps {
@@ -86,6 +122,12 @@ func NewJobRunReport(result *RunResult, jr *core.JobRun) *JobRunReport {
 			Name:       step.Step.Name,
 			Stage:      step.Stage,
 			Conclusion: step.Conclusion,
+			Duration:   step.Duration.String(),
+			Attempts:   step.Attempts,
+		}
+
+		if step.PullDuration > 0 {
+			summary.PullDuration = step.PullDuration.String()
 		}
 
 		report.Steps = append(report.Steps, summary)
@@ -95,30 +137,47 @@ func NewJobRunReport(result *RunResult, jr *core.JobRun) *JobRunReport {
 }
 
 type StepRunReport struct {
-	Ran        bool              `json:"ran"`               // Ran indicates if the execution ran
-	Duration   string            `json:"duration"`          // Duration of the execution
-	ID         string            `json:"id"`                // ID is the unique identifier of the step.
-	Name       string            `json:"name,omitempty"`    // Name is the name of the step
-	Conclusion core.Conclusion   `json:"conclusion"`        // Conclusion is the result of a completed job after continue-on-error is applied
-	Outcome    core.Conclusion   `json:"outcome"`           // Outcome is  the result of a completed job before continue-on-error is applied
-	Outputs    map[string]string `json:"outputs,omitempty"` // Outputs is the outputs generated by the job
-	State      map[string]string `json:"state,omitempty"`   // State is a map of step state variables.
-	Env        map[string]string `json:"env,omitempty"`     // Env is the extra environment variables set by the step.
-	Path       []string          `json:"path,omitempty"`    // Path is extra PATH items set by the step.
+	Ran          bool                  `json:"ran"`                     // Ran indicates if the execution ran
+	Duration     string                `json:"duration"`                // Duration of the execution
+	StartedAt    time.Time             `json:"started_at"`              // StartedAt is the time the execution started
+	CompletedAt  time.Time             `json:"completed_at"`            // CompletedAt is the time the execution completed
+	PullDuration string                `json:"pull_duration,omitempty"` // PullDuration is the time spent pulling the image of the step
+	Attempts     int                   `json:"attempts,omitempty"`      // Attempts is the number of attempts to run the step with the retry policy
+	ID           string                `json:"id"`                      // ID is the unique identifier of the step.
+	Name         string                `json:"name,omitempty"`          // Name is the name of the step
+	Conclusion   core.Conclusion       `json:"conclusion"`              // Conclusion is the result of a completed job after continue-on-error is applied
+	Outcome      core.Conclusion       `json:"outcome"`                 // Outcome is  the result of a completed job before continue-on-error is applied
+	Outputs      map[string]string     `json:"outputs,omitempty"`       // Outputs is the outputs generated by the job
+	State        map[string]string     `json:"state,omitempty"`         // State is a map of step state variables.
+	Env          map[string]string     `json:"env,omitempty"`           // Env is the extra environment variables set by the step.
+	Path         []string              `json:"path,omitempty"`          // Path is extra PATH items set by the step.
+	Files        *core.FilesystemDiff  `json:"files,omitempty"`         // Files is the files created, modified or deleted by the step.
+	EnvDiff      *core.EnvironmentDiff `json:"env_diff,omitempty"`      // EnvDiff is the environment variables added or changed by the step.
 }
 
 // NewStepRunReport creates a new step run report from the given step run.
 func NewStepRunReport(result *RunResult, sr *core.StepRun) *StepRunReport {
-	return &StepRunReport{
-		Ran:        result.Ran,
-		Duration:   result.Duration.String(),
-		ID:         sr.Step.ID,
-		Name:       sr.Step.Name,
-		Conclusion: result.Conclusion,
-		Outcome:    sr.Outcome,
-		Outputs:    sr.Outputs,
-		State:      sr.State,
-		Env:        sr.Environment,
-		Path:       sr.Path,
+	report := &StepRunReport{
+		Ran:         result.Ran,
+		Duration:    result.Duration.String(),
+		StartedAt:   result.StartedAt,
+		CompletedAt: result.CompletedAt,
+		ID:          sr.Step.ID,
+		Name:        sr.Step.Name,
+		Conclusion:  result.Conclusion,
+		Outcome:     sr.Outcome,
+		Outputs:     sr.Outputs,
+		State:       sr.State,
+		Env:         sr.Environment,
+		Path:        sr.Path,
+		Files:       sr.Files,
+		EnvDiff:     sr.EnvDiff,
+		Attempts:    sr.Attempts,
 	}
+
+	if sr.PullDuration > 0 {
+		report.PullDuration = sr.PullDuration.String()
+	}
+
+	return report
 }
@@ -0,0 +1,157 @@
+package context
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+// Retry conditions of the step retry policy.
+const (
+	RetryOnFailure = "failure" // RetryOnFailure retries the step if the step fails.
+	RetryOnTimeout = "timeout" // RetryOnTimeout retries the step if the attempt exceeds the timeout of the policy.
+)
+
+// RetryPolicy represents the retry policy of a step.
+type RetryPolicy struct {
+	Max     int           // Max is the maximum number of attempts including the first one.
+	Backoff time.Duration // Backoff is the wait duration before the next attempt. It's doubled after each attempt.
+	Timeout time.Duration // Timeout is the timeout of a single attempt. Zero means no timeout.
+	On      []string      // On is the list of conditions to retry the step. Possible values are failure and timeout.
+}
+
+// RetryOn returns true if the policy retries the step for the given condition.
+func (p RetryPolicy) RetryOn(condition string) bool {
+	for _, on := range p.On {
+		if on == condition {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StepRetries is the map of step selectors to their retry policies. Steps are selected with their id or name,
+// optionally prefixed with the job id, e.g. integration or test/integration.
+//
+// Text format is a list of step retry policies separated by semicolon, e.g. test/integration=max:3,backoff:10s,on:failure|timeout
+//
+// Values of on are compatible with the retry_on input of nick-fields/retry action, error and any are accepted as
+// aliases of failure and failure|timeout.
+type StepRetries map[string]RetryPolicy
+
+// UnmarshalText parses the step retry policies from the text format.
+func (sr *StepRetries) UnmarshalText(text []byte) error {
+	retries := make(StepRetries)
+
+	for _, entry := range strings.Split(string(text), ";") {
+		entry = strings.TrimSpace(entry)
+
+		if entry == "" {
+			continue
+		}
+
+		step, values, ok := strings.Cut(entry, "=")
+		if !ok || step == "" {
+			return fmt.Errorf("invalid step retry policy %s, expected format is step=max:3,backoff:10s,on:failure|timeout", entry)
+		}
+
+		policy := RetryPolicy{Max: 1, On: []string{RetryOnFailure, RetryOnTimeout}}
+
+		for _, value := range strings.Split(values, ",") {
+			if strings.TrimSpace(value) == "" {
+				continue
+			}
+
+			key, val, ok := strings.Cut(strings.TrimSpace(value), ":")
+			if !ok {
+				return fmt.Errorf("invalid retry option %s for step %s, expected format is key:value", value, step)
+			}
+
+			switch key {
+			case "max":
+				attempts, err := strconv.Atoi(val)
+				if err != nil || attempts < 1 {
+					return fmt.Errorf("invalid max attempts %s for step %s", val, step)
+				}
+
+				policy.Max = attempts
+			case "backoff", "timeout":
+				duration, err := time.ParseDuration(val)
+				if err != nil || duration < 0 {
+					return fmt.Errorf("invalid %s %s for step %s", key, val, step)
+				}
+
+				if key == "backoff" {
+					policy.Backoff = duration
+				} else {
+					policy.Timeout = duration
+				}
+			case "on":
+				on, err := parseRetryOn(val)
+				if err != nil {
+					return fmt.Errorf("invalid retry condition for step %s: %w", step, err)
+				}
+
+				policy.On = on
+			default:
+				return fmt.Errorf("unsupported retry option %s for step %s, supported options are max, backoff, timeout and on", key, step)
+			}
+		}
+
+		retries[step] = policy
+	}
+
+	*sr = retries
+
+	return nil
+}
+
+// Policy returns the retry policy of the step in the given job. Policies selecting the step with the job id have
+// precedence over the ones selecting only the step.
+func (sr StepRetries) Policy(job string, step core.Step) (RetryPolicy, bool) {
+	for _, name := range []string{step.ID, step.Name} {
+		if name == "" {
+			continue
+		}
+
+		if policy, ok := sr[fmt.Sprintf("%s/%s", job, name)]; ok {
+			return policy, true
+		}
+	}
+
+	for _, name := range []string{step.ID, step.Name} {
+		if name == "" {
+			continue
+		}
+
+		if policy, ok := sr[name]; ok {
+			return policy, true
+		}
+	}
+
+	return RetryPolicy{}, false
+}
+
+// parseRetryOn parses the retry conditions separated by pipe, e.g. failure|timeout.
+func parseRetryOn(value string) ([]string, error) {
+	var on []string
+
+	for _, condition := range strings.Split(value, "|") {
+		switch strings.TrimSpace(condition) {
+		case RetryOnFailure, "error":
+			on = append(on, RetryOnFailure)
+		case RetryOnTimeout:
+			on = append(on, RetryOnTimeout)
+		case "any":
+			on = append(on, RetryOnFailure, RetryOnTimeout)
+		default:
+			return nil, fmt.Errorf("unsupported condition %s, supported conditions are failure and timeout", condition)
+		}
+	}
+
+	return on, nil
+}
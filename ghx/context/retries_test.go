@@ -0,0 +1,94 @@
+package context
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestStepRetries_UnmarshalText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected StepRetries
+		wantErr  bool
+	}{
+		{
+			name: "Defaults",
+			text: "integration=max:3",
+			expected: StepRetries{
+				"integration": {Max: 3, On: []string{RetryOnFailure, RetryOnTimeout}},
+			},
+		},
+		{
+			name: "Multiple policies",
+			text: "test/integration=max:3,backoff:10s,timeout:5m,on:timeout;lint=max:2,on:error",
+			expected: StepRetries{
+				"test/integration": {Max: 3, Backoff: 10 * time.Second, Timeout: 5 * time.Minute, On: []string{RetryOnTimeout}},
+				"lint":             {Max: 2, On: []string{RetryOnFailure}},
+			},
+		},
+		{
+			name:    "Invalid max",
+			text:    "integration=max:0",
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported condition",
+			text:    "integration=max:3,on:cancelled",
+			wantErr: true,
+		},
+		{
+			name:    "Missing step",
+			text:    "max:3",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got StepRetries
+
+			err := got.UnmarshalText([]byte(tt.text))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("UnmarshalText() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStepRetries_Policy(t *testing.T) {
+	retries := StepRetries{
+		"integration":      {Max: 2},
+		"test/integration": {Max: 3},
+		"Run e2e":          {Max: 4},
+	}
+
+	tests := []struct {
+		name     string
+		job      string
+		step     core.Step
+		expected int
+		found    bool
+	}{
+		{name: "Job and step id", job: "test", step: core.Step{ID: "integration"}, expected: 3, found: true},
+		{name: "Step id", job: "build", step: core.Step{ID: "integration"}, expected: 2, found: true},
+		{name: "Step name", job: "build", step: core.Step{ID: "0", Name: "Run e2e"}, expected: 4, found: true},
+		{name: "No policy", job: "build", step: core.Step{ID: "unit"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, ok := retries.Policy(tt.job, tt.step)
+			if ok != tt.found || policy.Max != tt.expected {
+				t.Errorf("Policy() = %v, %v, expected %v, %v", policy.Max, ok, tt.expected, tt.found)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package context
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/aweris/gale/common/fs"
+)
+
+// secretEnvPrefix is the prefix of the environment variables loaded as secrets. The prefix is removed from the name.
+const secretEnvPrefix = "GALE_SECRET_"
+
+// loadSecrets loads the secrets from the providers in order of secrets file in ghx home, secrets files, environment
+// variables with GALE_SECRET_ prefix forwarded from the host, environment variables with GALE_SECRET_ prefix and secrets
+// commands. Secrets loaded later override the previous ones. Untrusted workflows don't get any secrets.
+func (c *Context) loadSecrets() error {
+	if c.GhxConfig.Untrusted {
+		c.Secrets.Data = make(map[string]string)
+
+		return nil
+	}
+
+	if err := fs.ReadJSONFile(c.Secrets.MountPath, &c.Secrets.Data); err != nil {
+		return err
+	}
+
+	// ensure secrets data is initialized
+	if c.Secrets.Data == nil {
+		c.Secrets.Data = make(map[string]string)
+	}
+
+	for _, file := range c.GhxConfig.SecretsFiles {
+		secrets, err := readSecretsFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to load secrets from %s: %w", file, err)
+		}
+
+		for k, v := range secrets {
+			c.Secrets.Data[k] = v
+		}
+	}
+
+	// host environment doesn't reach the runner, so the variables of the host are forwarded with a file
+	if file := c.GhxConfig.SecretsEnvFile; file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to load secrets from %s: %w", file, err)
+		}
+
+		vars, err := parseDotEnv(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse secrets from %s: %w", file, err)
+		}
+
+		for k, v := range envSecrets(vars) {
+			c.Secrets.Data[k] = v
+		}
+	}
+
+	vars := make(map[string]string)
+
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+
+		vars[key] = value
+	}
+
+	for k, v := range envSecrets(vars) {
+		c.Secrets.Data[k] = v
+	}
+
+	for _, command := range c.GhxConfig.SecretsFrom {
+		if strings.TrimSpace(command) == "" {
+			continue
+		}
+
+		//nolint:gosec // secrets command is provided by the user to run as it is
+		out, err := exec.Command("sh", "-c", command).Output()
+		if err != nil {
+			return fmt.Errorf("failed to load secrets from command %s: %w", command, err)
+		}
+
+		format := ".env"
+		if bytes.HasPrefix(bytes.TrimSpace(out), []byte("{")) {
+			format = ".json"
+		}
+
+		secrets, err := parseSecrets(format, out)
+		if err != nil {
+			return fmt.Errorf("failed to parse secrets from command %s: %w", command, err)
+		}
+
+		for k, v := range secrets {
+			c.Secrets.Data[k] = v
+		}
+	}
+
+	return nil
+}
+
+// envSecrets returns the secrets from the environment variables with the GALE_SECRET_ prefix. The prefix is removed from
+// the names of the secrets.
+func envSecrets(vars map[string]string) map[string]string {
+	secrets := make(map[string]string)
+
+	for key, value := range vars {
+		if name := strings.TrimPrefix(key, secretEnvPrefix); name != key && name != "" {
+			secrets[name] = value
+		}
+	}
+
+	return secrets
+}
+
+// readSecretsFile reads the secrets from the given file. SOPS encrypted files are decrypted with the sops binary
+// before parsing. File format is selected by the file extension, json and yaml files are parsed as key value maps and
+// rest of the files are parsed as dotenv files.
+func readSecretsFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if isSOPSEncrypted(data) {
+		//nolint:gosec // path is provided by the user
+		data, err = exec.Command("sops", "--decrypt", path).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt with sops: %w", err)
+		}
+	}
+
+	return parseSecrets(filepath.Ext(path), data)
+}
+
+// isSOPSEncrypted returns true if the data contains the SOPS metadata of json, yaml or dotenv files.
+func isSOPSEncrypted(data []byte) bool {
+	for _, marker := range []string{`"sops":`, "\nsops:", "sops_version="} {
+		if bytes.Contains(data, []byte(marker)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseSecrets parses the secrets in the given format. Supported formats are .json, .yaml, .yml and .env. Unknown
+// formats are parsed as dotenv.
+func parseSecrets(format string, data []byte) (map[string]string, error) {
+	secrets := make(map[string]string)
+
+	switch format {
+	case ".json":
+		if err := json.Unmarshal(data, &secrets); err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &secrets); err != nil {
+			return nil, err
+		}
+	default:
+		return parseDotEnv(data)
+	}
+
+	return secrets, nil
+}
+
+// parseDotEnv parses the KEY=VALUE lines of the dotenv data. Empty lines and comments are ignored, export prefixes and
+// quotes around the values are removed. Lines in any other format are rejected instead of being dropped silently.
+func parseDotEnv(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid line %d, expected format is KEY=VALUE", i+1)
+		}
+
+		value = strings.TrimSpace(value)
+
+		// remove the quotes around the value
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		values[strings.TrimSpace(key)] = value
+	}
+
+	return values, nil
+}
@@ -0,0 +1,147 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		data     string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "JSON",
+			format:   ".json",
+			data:     `{"FOO": "bar", "BAZ": "qux"}`,
+			expected: map[string]string{"FOO": "bar", "BAZ": "qux"},
+		},
+		{
+			name:     "YAML",
+			format:   ".yaml",
+			data:     "FOO: bar\nBAZ: qux\n",
+			expected: map[string]string{"FOO": "bar", "BAZ": "qux"},
+		},
+		{
+			name:     "Dotenv with comments, quotes and export",
+			format:   ".env",
+			data:     "# comment\nFOO=bar\n\nexport BAZ=\"qux quux\"\nQUOTED='a=b'\n",
+			expected: map[string]string{"FOO": "bar", "BAZ": "qux quux", "QUOTED": "a=b"},
+		},
+		{
+			name:    "Dotenv with invalid line",
+			format:  ".env",
+			data:    "FOO=bar\ninvalid\n",
+			wantErr: true,
+		},
+		{
+			name:    "Dotenv with empty key",
+			format:  ".env",
+			data:    "=bar\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSecrets(tt.format, []byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSecrets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseSecrets() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsSOPSEncrypted(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected bool
+	}{
+		{name: "JSON", data: `{"FOO": "ENC[AES256_GCM,data:abc]", "sops": {"version": "3.8.1"}}`, expected: true},
+		{name: "YAML", data: "FOO: ENC[AES256_GCM,data:abc]\nsops:\n  version: 3.8.1\n", expected: true},
+		{name: "Dotenv", data: "FOO=ENC[AES256_GCM,data:abc]\nsops_version=3.8.1\n", expected: true},
+		{name: "Plain", data: "FOO=bar\n", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSOPSEncrypted([]byte(tt.data)); got != tt.expected {
+				t.Errorf("isSOPSEncrypted() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadSecrets_Untrusted(t *testing.T) {
+	t.Setenv("GALE_SECRET_FOO", "bar")
+
+	ctx := &Context{GhxConfig: GhxConfig{Untrusted: true, SecretsFrom: []string{"echo BAZ=qux"}}}
+
+	if err := ctx.loadSecrets(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(ctx.Secrets.Data) != 0 {
+		t.Errorf("expected no secrets for untrusted workflows, got %v", ctx.Secrets.Data)
+	}
+}
+
+func TestLoadSecrets(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"secrets.json": `{"FROM_HOME": "home", "OVERRIDDEN": "home"}`,
+		"secrets.yaml": "FROM_FILE: file\nOVERRIDDEN: file\n",
+		"host.env":     "GALE_SECRET_FROM_HOST=host\nGALE_SECRET_OVERRIDDEN=host\nPATH=/usr/bin\n",
+	}
+
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	t.Setenv("GALE_SECRET_FROM_ENV", "env")
+
+	ctx := &Context{
+		GhxConfig: GhxConfig{
+			SecretsFiles:   []string{filepath.Join(dir, "secrets.yaml")},
+			SecretsEnvFile: filepath.Join(dir, "host.env"),
+			SecretsFrom:    []string{`echo '{"FROM_COMMAND": "command"}'`, "echo OVERRIDDEN=command"},
+		},
+		Secrets: SecretsContext{MountPath: filepath.Join(dir, "secrets.json")},
+	}
+
+	if err := ctx.loadSecrets(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"FROM_HOME":    "home",
+		"FROM_FILE":    "file",
+		"FROM_HOST":    "host",
+		"FROM_ENV":     "env",
+		"FROM_COMMAND": "command",
+		"OVERRIDDEN":   "command",
+	}
+
+	for name, value := range expected {
+		if got := ctx.Secrets.Data[name]; got != value {
+			t.Errorf("expected secret %s to be %s, got %s", name, value, got)
+		}
+	}
+
+	if _, ok := ctx.Secrets.Data["PATH"]; ok {
+		t.Error("expected variables without the prefix to be ignored")
+	}
+}
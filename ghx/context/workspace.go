@@ -0,0 +1,30 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WorkspaceMode is the sharing mode of the workspace between the jobs of a workflow run.
+type WorkspaceMode string
+
+const (
+	// WorkspaceModeShared shares the workspace between the jobs, changes made by a job are visible to the next jobs.
+	WorkspaceModeShared WorkspaceMode = "shared"
+
+	// WorkspaceModeIsolated starts each job with the workspace as it's at the start of the workflow run, like a fresh
+	// checkout of the GitHub hosted runners.
+	WorkspaceModeIsolated WorkspaceMode = "isolated"
+)
+
+// UnmarshalText parses the workspace mode from the text format.
+func (m *WorkspaceMode) UnmarshalText(text []byte) error {
+	switch mode := WorkspaceMode(strings.TrimSpace(string(text))); mode {
+	case WorkspaceModeShared, WorkspaceModeIsolated:
+		*m = mode
+	default:
+		return fmt.Errorf("unsupported workspace mode %s, supported modes are shared and isolated", text)
+	}
+
+	return nil
+}
@@ -115,7 +115,7 @@ func (c *CustomActionRuns) PreCondition() (bool, string) {
 	switch c.Using {
 	case ActionRunsUsingDocker:
 		pre = c.PreEntrypoint
-	case ActionRunsUsingNode16, ActionRunsUsingNode12:
+	case ActionRunsUsingNode20, ActionRunsUsingNode16, ActionRunsUsingNode12:
 		pre = c.Pre
 	default:
 		pre = "" // all other types of actions do not have a pre-condition
@@ -137,7 +137,7 @@ func (c *CustomActionRuns) PostCondition() (bool, string) {
 	switch c.Using {
 	case ActionRunsUsingDocker:
 		post = c.PostEntrypoint
-	case ActionRunsUsingNode16, ActionRunsUsingNode12:
+	case ActionRunsUsingNode20, ActionRunsUsingNode16, ActionRunsUsingNode12:
 		post = c.Post
 	default:
 		post = "" // all other types of actions do not have a post-condition
@@ -148,5 +148,10 @@ func (c *CustomActionRuns) PostCondition() (bool, string) {
 		return false, ""
 	}
 
+	// post steps are cleanup steps, so they run regardless of the job status unless post-if is set
+	if c.PostIf == "" {
+		return true, "always()"
+	}
+
 	return true, c.PostIf
 }
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomActionRuns_PostCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		runs      CustomActionRuns
+		hasPost   bool
+		condition string
+	}{
+		{name: "no post step", runs: CustomActionRuns{Using: ActionRunsUsingNode16}},
+		{name: "post step runs always by default", runs: CustomActionRuns{Using: ActionRunsUsingNode16, Post: "cleanup.js"}, hasPost: true, condition: "always()"},
+		{name: "post-if is kept", runs: CustomActionRuns{Using: ActionRunsUsingNode16, Post: "cleanup.js", PostIf: "success()"}, hasPost: true, condition: "success()"},
+		{name: "node20 post step", runs: CustomActionRuns{Using: ActionRunsUsingNode20, Post: "cleanup.js"}, hasPost: true, condition: "always()"},
+		{name: "docker post entrypoint", runs: CustomActionRuns{Using: ActionRunsUsingDocker, PostEntrypoint: "/cleanup.sh"}, hasPost: true, condition: "always()"},
+		{name: "composite has no post step", runs: CustomActionRuns{Using: ActionRunsUsingComposite, Post: "cleanup.js"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasPost, condition := tt.runs.PostCondition()
+
+			assert.Equal(t, tt.hasPost, hasPost)
+			assert.Equal(t, tt.condition, condition)
+		})
+	}
+}
+
+func TestCustomActionRuns_PreCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		runs      CustomActionRuns
+		hasPre    bool
+		condition string
+	}{
+		{name: "no pre step", runs: CustomActionRuns{Using: ActionRunsUsingNode20}},
+		{name: "node16 pre step", runs: CustomActionRuns{Using: ActionRunsUsingNode16, Pre: "setup.js", PreIf: "runner.os == 'Linux'"}, hasPre: true, condition: "runner.os == 'Linux'"},
+		{name: "node20 pre step", runs: CustomActionRuns{Using: ActionRunsUsingNode20, Pre: "setup.js"}, hasPre: true},
+		{name: "docker pre entrypoint", runs: CustomActionRuns{Using: ActionRunsUsingDocker, PreEntrypoint: "/setup.sh"}, hasPre: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasPre, condition := tt.runs.PreCondition()
+
+			assert.Equal(t, tt.hasPre, hasPre)
+			assert.Equal(t, tt.condition, condition)
+		})
+	}
+}
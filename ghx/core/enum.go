@@ -43,3 +43,11 @@ const (
 	StepStageMain StepStage = "main"
 	StepStagePost StepStage = "post"
 )
+
+// AnnotationLevel is the level of the annotation created by a step. Possible values are: error, warning.
+type AnnotationLevel string
+
+const (
+	AnnotationLevelError   AnnotationLevel = "error"
+	AnnotationLevelWarning AnnotationLevel = "warning"
+)
@@ -1,23 +1,41 @@
 package core
 
-import "gopkg.in/yaml.v3"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
 
 // Job represents a single job in a GitHub Actions workflow
 //
 // See: https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#jobsjob_id
 type Job struct {
-	ID       string            `yaml:"id"`       // ID is the ID of the job
-	If       string            `yaml:"if"`       // If is the conditional expression to run the job.
-	Name     string            `yaml:"name"`     // Name is the name of the job
-	Needs    Needs             `yaml:"needs"`    // Needs is the list of jobs that must be completed before this job will run
-	Strategy Strategy          `yaml:"strategy"` // Strategy is the matrix strategy lets you use variables in a single job definition to automatically create multiple job runs that are based on the combinations of the variables.
-	Env      map[string]string `yaml:"env"`      // Env is the environment variables used in the workflow
-	Outputs  map[string]string `yaml:"outputs"`  // Outputs is the list of outputs of the job
-	Steps    []Step            `yaml:"steps"`    // Steps is the list of steps in the job
+	ID          string            `yaml:"-"`                     // ID is the ID of the job
+	If          string            `yaml:"if,omitempty"`          // If is the conditional expression to run the job.
+	Name        string            `yaml:"name,omitempty"`        // Name is the name of the job
+	Needs       Needs             `yaml:"needs,omitempty"`       // Needs is the list of jobs that must be completed before this job will run
+	RunsOn      RunsOn            `yaml:"runs-on,omitempty"`     // RunsOn is the list of runner labels the job targets
+	Environment Environment       `yaml:"environment,omitempty"` // Environment is the environment the job references
+	Strategy    Strategy          `yaml:"strategy,omitempty"`    // Strategy is the matrix strategy lets you use variables in a single job definition to automatically create multiple job runs that are based on the combinations of the variables.
+	Env         map[string]string `yaml:"env,omitempty"`         // Env is the environment variables used in the workflow
+	Outputs     map[string]string `yaml:"outputs,omitempty"`     // Outputs is the list of outputs of the job
+	Steps       []Step            `yaml:"steps,omitempty"`       // Steps is the list of steps in the job
 
 	// TBD: add more fields when needed
 }
 
+// DisplayName returns the name of the job run for the given matrix combination. Like GitHub, values of the combination
+// are appended to the job name, e.g. test (ubuntu-latest, 1.21).
+func (j *Job) DisplayName(mc MatrixCombination) string {
+	if len(mc) == 0 {
+		return j.Name
+	}
+
+	return fmt.Sprintf("%s (%s)", j.Name, strings.Join(j.Strategy.Matrix.Values(mc), ", "))
+}
+
 // Needs is the list of jobs that must be completed before this job will run
 type Needs []string
 
@@ -46,12 +64,101 @@ func (n *Needs) UnmarshalYAML(value *yaml.Node) error {
 	return nil
 }
 
+// RunsOn is the list of runner labels the job targets.
+type RunsOn []string
+
+// UnmarshalYAML implements yaml.Unmarshaler interface for RunsOn. It supports scalar, sequence and runner group nodes.
+//
+// Example:
+//
+//	runs-on: ubuntu-latest # scalar node
+//	runs-on: # sequence node
+//	  - self-hosted
+//	  - linux
+//	runs-on: # runner group node
+//	  group: ubuntu-runners
+//	  labels: ubuntu-20.04-16core
+func (r *RunsOn) UnmarshalYAML(value *yaml.Node) error {
+	var labels []string
+
+	switch value.Kind {
+	case yaml.ScalarNode:
+		labels = append(labels, value.Value)
+	case yaml.SequenceNode:
+		for _, node := range value.Content {
+			labels = append(labels, node.Value)
+		}
+	case yaml.MappingNode:
+		var group struct {
+			Labels RunsOn `yaml:"labels"`
+		}
+
+		if err := value.Decode(&group); err != nil {
+			return err
+		}
+
+		labels = group.Labels
+	}
+
+	*r = labels
+
+	return nil
+}
+
+// Environment represents the environment a job references.
+//
+// See: https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#jobsjob_idenvironment
+type Environment struct {
+	Name string `yaml:"name"` // Name is the name of the environment
+	URL  string `yaml:"url"`  // URL is the deployment URL of the environment
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface for Environment. It supports both scalar and mapping nodes.
+//
+// Example:
+//
+//	environment: staging # scalar node
+//	environment: # mapping node
+//	  name: production
+//	  url: https://github.com
+func (e *Environment) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		e.Name = value.Value
+
+		return nil
+	}
+
+	type environment Environment
+
+	var env environment
+
+	if err := value.Decode(&env); err != nil {
+		return err
+	}
+
+	*e = Environment(env)
+
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler interface for Environment. Environments without a URL are marshaled as scalar
+// nodes.
+func (e Environment) MarshalYAML() (interface{}, error) {
+	if e.URL == "" {
+		return e.Name, nil
+	}
+
+	type environment Environment
+
+	return environment(e), nil
+}
+
 // Strategy represents a matrix strategy lets you use variables in a single job definition to automatically create
 // multiple job runs that are based on the combinations of the variables.
 type Strategy struct {
-	Matrix      Matrix `yaml:"matrix"`       // Matrix is the matrix of different OS versions and other parameters
-	FailFast    bool   `yaml:"fail-fast"`    // FailFast is a boolean to indicate if the job should fail immediately when a job fails.
-	MaxParallel int    `yaml:"max-parallel"` // MaxParallel is the maximum number of jobs to run at a time.
+	Matrix      Matrix `yaml:"matrix,omitempty"`       // Matrix is the matrix of different OS versions and other parameters
+	FailFast    *bool  `yaml:"fail-fast,omitempty"`    // FailFast is a boolean to indicate if the job should fail immediately when a job fails. Defaults to true if not set.
+	MaxParallel int    `yaml:"max-parallel,omitempty"` // MaxParallel is the maximum number of jobs to run at a time.
 }
 
 // JobRun represents a single job run in a GitHub Actions workflow run
@@ -63,4 +170,17 @@ type JobRun struct {
 	Outputs    map[string]string `json:"outputs"`    // Outputs is the outputs generated by the job
 	Matrix     MatrixCombination `json:"matrix"`     // Matrix is the matrix parameters used to run the job
 	Steps      []StepRun         `json:"steps"`      // Steps is the list of steps in the job
+	URL        string            `json:"url"`        // URL is the evaluated deployment URL of the job environment
+	Deployment int64             `json:"deployment"` // Deployment is the id of the GitHub deployment created for the job environment, if any
+	Duration   time.Duration     `json:"duration"`   // Duration is the wall-clock duration of the job run
+	JobIndex   int               `json:"job_index"`  // JobIndex is the zero-based index of the job run in the matrix
+	JobTotal   int               `json:"job_total"`  // JobTotal is the total number of job runs of the matrix
+
+	Environment map[string]string `json:"environment"` // Environment is the variables exported by the steps for the subsequent steps of the job
+	Path        []string          `json:"path"`        // Path is the PATH items added by the steps for the subsequent steps of the job
+}
+
+// DisplayName returns the name of the job run including the values of the matrix combination, e.g. test (ubuntu-latest, 1.21).
+func (jr *JobRun) DisplayName() string {
+	return jr.Job.DisplayName(jr.Matrix)
 }
@@ -2,6 +2,8 @@ package core
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 
 	"gopkg.in/yaml.v3"
 )
@@ -43,6 +45,39 @@ type Matrix struct {
 	Dimensions map[string]MatrixDimension // Dimensions is the list of matrix dimensions given in the workflow.
 	Include    []MatrixCombination        // Include is the list of matrix combinations to update or extend the matrix.
 	Exclude    []MatrixCombination        // Exclude is the list of matrix combinations to remove from the matrix.
+	Keys       []string                   `json:"-"` // Keys is the list of dimension keys in the order given in the workflow.
+}
+
+// Values returns the values of the matrix combination in the order of the matrix dimensions followed by the values of
+// the included keys in alphabetical order, like GitHub uses in the display names of the matrix jobs.
+func (m *Matrix) Values(mc MatrixCombination) []string {
+	keys := make([]string, 0, len(mc))
+	seen := make(map[string]bool, len(mc))
+
+	for _, key := range m.Keys {
+		if _, ok := mc[key]; ok {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+
+	var extra []string
+
+	for key := range mc {
+		if !seen[key] {
+			extra = append(extra, key)
+		}
+	}
+
+	sort.Strings(extra)
+
+	values := make([]string, 0, len(mc))
+
+	for _, key := range append(keys, extra...) {
+		values = append(values, fmt.Sprintf("%v", mc[key]))
+	}
+
+	return values
 }
 
 // GenerateCombinations generates all possible combinations from the given matrix dimensions, includes and excludes
@@ -197,9 +232,74 @@ func (m *Matrix) UnmarshalYAML(node *yaml.Node) error {
 
 	m.populate(raw)
 
+	// keep the order of the dimensions given in the workflow, map keys are unordered
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if _, ok := m.Dimensions[node.Content[i].Value]; ok {
+			m.Keys = append(m.Keys, node.Content[i].Value)
+		}
+	}
+
 	return nil
 }
 
+// MarshalYAML implements yaml.Marshaler interface for Matrix. Dimensions are marshaled in the order given in the
+// workflow, followed by the dimensions added later in alphabetical order, include and exclude.
+func (m Matrix) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+
+	add := func(key string, value interface{}) error {
+		var valueNode yaml.Node
+
+		if err := valueNode.Encode(value); err != nil {
+			return err
+		}
+
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &valueNode)
+
+		return nil
+	}
+
+	keys := make([]string, 0, len(m.Dimensions))
+	seen := make(map[string]bool, len(m.Dimensions))
+
+	for _, key := range m.Keys {
+		if _, ok := m.Dimensions[key]; ok && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+
+	var extra []string
+
+	for key := range m.Dimensions {
+		if !seen[key] {
+			extra = append(extra, key)
+		}
+	}
+
+	sort.Strings(extra)
+
+	for _, key := range append(keys, extra...) {
+		if err := add(key, m.Dimensions[key].Values); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(m.Include) > 0 {
+		if err := add("include", m.Include); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(m.Exclude) > 0 {
+		if err := add("exclude", m.Exclude); err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
+
 // UnmarshalJSON implements json.Unmarshaler interface for Matrix
 func (m *Matrix) UnmarshalJSON(data []byte) error {
 	var raw map[string]interface{}
@@ -80,6 +80,7 @@ func TestMatrix_UnmarshalYAML(t *testing.T) {
 		Exclude: []MatrixCombination{
 			{"animal": "cat"},
 		},
+		Keys: []string{"fruit", "animal"},
 	}
 
 	assert.Equal(t, expected, actual)
@@ -1,6 +1,9 @@
 package core
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
 // Step represents a single task in a job context at GitHub Actions workflow
 //
@@ -38,15 +41,56 @@ func (s *Step) Type() StepType {
 	return st
 }
 
+// DisplayName returns the name of the step. If the name is not set, it's generated from the properties of the step.
+func (s *Step) DisplayName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+
+	switch s.Type() {
+	case StepTypeAction:
+		return s.Uses
+	case StepTypeRun:
+		return strings.Split(s.Run, "\n")[0]
+	default:
+		return s.ID
+	}
+}
+
 // StepRun represents a single job run in a GitHub Actions workflow run
 type StepRun struct {
-	Step        Step              `json:"step"`        // Step is the step to run
-	Stage       StepStage         `json:"stage"`       // Stage is the stage of the step during the execution of the job. Possible values are: setup, pre, main, post, complete.
-	Conclusion  Conclusion        `json:"conclusion"`  // Conclusion is the result of a completed job after continue-on-error is applied
-	Outcome     Conclusion        `json:"outcome"`     // Outcome is  the result of a completed job before continue-on-error is applied
-	Outputs     map[string]string `json:"outputs"`     // Outputs is the outputs generated by the job
-	State       map[string]string `json:"state"`       // State is a map of step state variables.
-	Summary     string            `json:"summary"`     // Summary is the summary of the step.
-	Environment map[string]string `json:"environment"` // Environment is the extra environment variables set by the step.
-	Path        []string          `json:"path"`        // Path is extra PATH items set by the step.
+	Step         Step              `json:"step"`          // Step is the step to run
+	Stage        StepStage         `json:"stage"`         // Stage is the stage of the step during the execution of the job. Possible values are: setup, pre, main, post, complete.
+	Conclusion   Conclusion        `json:"conclusion"`    // Conclusion is the result of a completed job after continue-on-error is applied
+	Outcome      Conclusion        `json:"outcome"`       // Outcome is  the result of a completed job before continue-on-error is applied
+	Outputs      map[string]string `json:"outputs"`       // Outputs is the outputs generated by the job
+	State        map[string]string `json:"state"`         // State is a map of step state variables.
+	Summary      string            `json:"summary"`       // Summary is the summary of the step.
+	Environment  map[string]string `json:"environment"`   // Environment is the extra environment variables set by the step.
+	Path         []string          `json:"path"`          // Path is extra PATH items set by the step.
+	Duration     time.Duration     `json:"duration"`      // Duration is the wall-clock duration of the step
+	Attempts     int               `json:"attempts"`      // Attempts is the number of attempts to run the step with the retry policy
+	PullDuration time.Duration     `json:"pull_duration"` // PullDuration is the time spent pulling or building the image of the step
+	Files        *FilesystemDiff   `json:"files"`         // Files is the files the step created, modified or deleted, nil if the filesystem report is disabled
+	EnvDiff      *EnvironmentDiff  `json:"env_diff"`      // EnvDiff is the environment variables the step added or changed for the subsequent steps, nil if none
+}
+
+// EnvironmentDiff represents the environment variables a step added or changed for the subsequent steps of the job, e.g.
+// with GITHUB_ENV or the set-env command.
+type EnvironmentDiff struct {
+	Added   map[string]string            `json:"added,omitempty"`   // Added is the variables not set before the step
+	Changed map[string]EnvironmentChange `json:"changed,omitempty"` // Changed is the variables set to a different value
+}
+
+// EnvironmentChange represents the change of an environment variable.
+type EnvironmentChange struct {
+	From string `json:"from"` // From is the value before the step
+	To   string `json:"to"`   // To is the value set by the step
+}
+
+// FilesystemDiff represents the changes of the files between two snapshots of the filesystem.
+type FilesystemDiff struct {
+	Created  []string `json:"created,omitempty"`  // Created is the paths of the files created
+	Modified []string `json:"modified,omitempty"` // Modified is the paths of the files with a different size, mode or modification time
+	Deleted  []string `json:"deleted,omitempty"`  // Deleted is the paths of the files deleted
 }
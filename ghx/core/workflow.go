@@ -1,17 +1,182 @@
 package core
 
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
 // Workflow represents a GitHub Actions workflow.
 //
 // See: https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions
 type Workflow struct {
-	Path string            `yaml:"-"`    // Path is the relative path to the workflow file.
-	Name string            `yaml:"name"` // Name is the name of the workflow.
-	Env  map[string]string `yaml:"env"`  // Env is the environment variables used in the workflow
-	Jobs map[string]Job    `yaml:"jobs"` // Jobs is the list of jobs in the workflow.
+	Path string            `yaml:"-"`              // Path is the relative path to the workflow file.
+	Name string            `yaml:"name,omitempty"` // Name is the name of the workflow.
+	On   Events            `yaml:"on,omitempty"`   // On is the events that trigger the workflow.
+	Env  map[string]string `yaml:"env,omitempty"`  // Env is the environment variables used in the workflow
+	Jobs map[string]Job    `yaml:"jobs"`           // Jobs is the list of jobs in the workflow.
 
 	// TBD: add more fields when needed
 }
 
+// ParseWorkflow parses the workflow from the given YAML data and sets the defaults of the workflow. Path is the
+// relative path to the workflow file, it's used as the workflow name if the workflow doesn't have a name.
+func ParseWorkflow(data []byte, path string) (Workflow, error) {
+	var workflow Workflow
+
+	if err := yaml.Unmarshal(data, &workflow); err != nil {
+		return Workflow{}, fmt.Errorf("failed to parse workflow %s: %w", path, err)
+	}
+
+	workflow.Path = path
+	workflow.SetDefaults()
+
+	return workflow, nil
+}
+
+// SetDefaults sets the values GitHub derives from the workflow definition: the workflow name defaults to its path,
+// job IDs are the keys of the jobs, job names default to their IDs and step IDs default to their indexes.
+func (w *Workflow) SetDefaults() {
+	if w.Name == "" {
+		w.Name = w.Path
+	}
+
+	for idj, job := range w.Jobs {
+		job.ID = idj
+
+		if job.Name == "" {
+			job.Name = idj
+		}
+
+		for ids, step := range job.Steps {
+			if step.ID == "" {
+				step.ID = fmt.Sprintf("%d", ids)
+			}
+
+			job.Steps[ids] = step
+		}
+
+		w.Jobs[idj] = job
+	}
+}
+
+// Events is the map of event names to their filters that trigger the workflow.
+type Events map[string]EventFilters
+
+// EventFilters represents the filters of an event that trigger the workflow. Only path filters and schedules are
+// supported.
+type EventFilters struct {
+	Paths       []string `yaml:"paths,omitempty"`        // Paths is the list of path patterns that trigger the workflow.
+	PathsIgnore []string `yaml:"paths-ignore,omitempty"` // PathsIgnore is the list of path patterns that don't trigger the workflow.
+	Crons       []string `yaml:"-"`                      // Crons is the list of cron expressions of the schedule event.
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface for Events. It supports scalar, sequence and mapping nodes.
+//
+// Example:
+//
+//	on: push # scalar node
+//	on: [push, pull_request] # sequence node
+//	on: # mapping node
+//	  push:
+//	    paths:
+//	      - 'src/**'
+//	  schedule:
+//	    - cron: '0 0 * * *'
+func (e *Events) UnmarshalYAML(value *yaml.Node) error {
+	events := make(Events)
+
+	switch value.Kind {
+	case yaml.ScalarNode:
+		events[value.Value] = EventFilters{}
+	case yaml.SequenceNode:
+		for _, node := range value.Content {
+			events[node.Value] = EventFilters{}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			var filters EventFilters
+
+			// only mapping nodes could have path filters, schedule is a sequence node of cron expressions
+			switch node := value.Content[i+1]; {
+			case node.Kind == yaml.MappingNode:
+				if err := node.Decode(&filters); err != nil {
+					return err
+				}
+			case node.Kind == yaml.SequenceNode && value.Content[i].Value == "schedule":
+				var schedules []struct {
+					Cron string `yaml:"cron"`
+				}
+
+				if err := node.Decode(&schedules); err != nil {
+					return err
+				}
+
+				for _, schedule := range schedules {
+					filters.Crons = append(filters.Crons, schedule.Cron)
+				}
+			}
+
+			events[value.Content[i].Value] = filters
+		}
+	}
+
+	*e = events
+
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler interface for Events. Events without filters are marshaled as a sequence node,
+// otherwise as a mapping node with the schedule event as a sequence of cron expressions. Events are sorted by name to
+// have a stable output.
+func (e Events) MarshalYAML() (interface{}, error) {
+	names := make([]string, 0, len(e))
+	filtered := false
+
+	for name, filters := range e {
+		names = append(names, name)
+
+		if len(filters.Paths) > 0 || len(filters.PathsIgnore) > 0 || len(filters.Crons) > 0 {
+			filtered = true
+		}
+	}
+
+	sort.Strings(names)
+
+	if !filtered {
+		return names, nil
+	}
+
+	node := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, name := range names {
+		filters := e[name]
+
+		var value yaml.Node
+
+		if name == "schedule" {
+			value.Kind = yaml.SequenceNode
+
+			for _, cron := range filters.Crons {
+				value.Content = append(value.Content, &yaml.Node{
+					Kind: yaml.MappingNode,
+					Content: []*yaml.Node{
+						{Kind: yaml.ScalarNode, Value: "cron"},
+						{Kind: yaml.ScalarNode, Value: cron, Style: yaml.SingleQuotedStyle},
+					},
+				})
+			}
+		} else if err := value.Encode(filters); err != nil {
+			return nil, err
+		}
+
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, &value)
+	}
+
+	return node, nil
+}
+
 type WorkflowRun struct {
 	RunID         string            `json:"run_id"`         // RunID is the ID of the run
 	RunNumber     string            `json:"run_number"`     // RunNumber is the number of the run
@@ -20,4 +185,22 @@ type WorkflowRun struct {
 	Workflow      Workflow          `json:"workflow"`       // Workflow is the workflow to run
 	Conclusion    Conclusion        `json:"conclusion"`     // Conclusion is the result of a completed workflow run after continue-on-error is applied
 	Jobs          map[string]JobRun `json:"jobs"`           // Jobs is map of the job run id to its result
+	JobRuns       []JobRun          `json:"job_runs"`       // JobRuns is the list of completed job runs in execution order, including each matrix combination
+	Annotations   []Annotation      `json:"annotations"`    // Annotations is the list of error and warning annotations created by the steps
+}
+
+// Annotation represents an error or warning message created by a step with the workflow commands.
+//
+// See: https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#setting-an-error-message
+type Annotation struct {
+	Level   AnnotationLevel `json:"level"`              // Level is the level of the annotation. Possible values are: error, warning.
+	Message string          `json:"message"`            // Message is the message of the annotation
+	Title   string          `json:"title,omitempty"`    // Title is the custom title of the annotation
+	File    string          `json:"file,omitempty"`     // File is the file the annotation is created for
+	Line    string          `json:"line,omitempty"`     // Line is the start line of the annotation
+	EndLine string          `json:"end_line,omitempty"` // EndLine is the end line of the annotation
+	Col     string          `json:"col,omitempty"`      // Col is the start column of the annotation
+	EndCol  string          `json:"end_col,omitempty"`  // EndCol is the end column of the annotation
+	Job     string          `json:"job"`                // Job is the name of the job the annotation is created in
+	Step    string          `json:"step"`               // Step is the name of the step the annotation is created in
 }
@@ -0,0 +1,276 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// jobIDRegex matches the valid job IDs. Like GitHub, an ID must start with a letter or _ and contain only alphanumeric
+// characters, - or _.
+var jobIDRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// NewWorkflow creates an empty workflow with the given name. Events and jobs are added with the With* methods, e.g.
+//
+//	wf := core.NewWorkflow("CI").
+//		WithEvent("push", core.EventFilters{}).
+//		WithJob(core.NewJob("test", "ubuntu-latest").AddStep(core.NewRunStep("test", "go test ./...")))
+func NewWorkflow(name string) *Workflow {
+	return &Workflow{Name: name, On: make(Events), Jobs: make(map[string]Job)}
+}
+
+// WithEvent adds the event with the given filters to the events triggering the workflow. Existing filters of the
+// event are replaced.
+func (w *Workflow) WithEvent(event string, filters EventFilters) *Workflow {
+	if w.On == nil {
+		w.On = make(Events)
+	}
+
+	w.On[event] = filters
+
+	return w
+}
+
+// WithEnv sets the environment variable of the workflow.
+func (w *Workflow) WithEnv(key, value string) *Workflow {
+	if w.Env == nil {
+		w.Env = make(map[string]string)
+	}
+
+	w.Env[key] = value
+
+	return w
+}
+
+// WithJob adds the job to the workflow, replacing the existing job with the same ID. Defaults of the job are set like
+// the jobs loaded from the workflow files, so the workflow could be passed to the executor as it is.
+func (w *Workflow) WithJob(job *Job) *Workflow {
+	if w.Jobs == nil {
+		w.Jobs = make(map[string]Job)
+	}
+
+	w.Jobs[job.ID] = *job
+	w.SetDefaults()
+
+	return w
+}
+
+// Validate checks the workflow has the minimum required fields to run and the references between the jobs are valid.
+// It returns all the problems found in the workflow joined in a single error.
+func (w *Workflow) Validate() error {
+	var errs []error
+
+	if len(w.On) == 0 {
+		errs = append(errs, errors.New("workflow has no events"))
+	}
+
+	if len(w.Jobs) == 0 {
+		errs = append(errs, errors.New("workflow has no jobs"))
+	}
+
+	// sort job IDs to report the errors in a stable order
+	ids := make([]string, 0, len(w.Jobs))
+
+	for id := range w.Jobs {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		job := w.Jobs[id]
+
+		if !jobIDRegex.MatchString(id) {
+			errs = append(errs, fmt.Errorf("job %q: invalid id, expected to start with a letter or _ and contain only alphanumeric characters, - or _", id))
+		}
+
+		if job.ID != "" && job.ID != id {
+			errs = append(errs, fmt.Errorf("job %q: id %q doesn't match the job key", id, job.ID))
+		}
+
+		for _, need := range job.Needs {
+			if _, ok := w.Jobs[need]; !ok {
+				errs = append(errs, fmt.Errorf("job %q: needs unknown job %q", id, need))
+			}
+		}
+
+		for _, err := range job.validate() {
+			errs = append(errs, fmt.Errorf("job %q: %w", id, err))
+		}
+	}
+
+	if cycle := w.needsCycle(ids); cycle != "" {
+		errs = append(errs, fmt.Errorf("jobs have a dependency cycle: %s", cycle))
+	}
+
+	return errors.Join(errs...)
+}
+
+// needsCycle returns the first dependency cycle of the jobs as a chain of job IDs, e.g. a -> b -> a. It returns an empty
+// string if the jobs don't have a cycle.
+func (w *Workflow) needsCycle(ids []string) string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	var (
+		state = make(map[string]int, len(ids))
+		path  []string
+		visit func(id string) string
+	)
+
+	visit = func(id string) string {
+		switch state[id] {
+		case visiting:
+			for i, p := range path {
+				if p == id {
+					return fmt.Sprintf("%s -> %s", strings.Join(path[i:], " -> "), id)
+				}
+			}
+		case visited:
+			return ""
+		}
+
+		state[id] = visiting
+		path = append(path, id)
+
+		for _, need := range w.Jobs[id].Needs {
+			if _, ok := w.Jobs[need]; !ok {
+				continue
+			}
+
+			if cycle := visit(need); cycle != "" {
+				return cycle
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[id] = visited
+
+		return ""
+	}
+
+	for _, id := range ids {
+		if cycle := visit(id); cycle != "" {
+			return cycle
+		}
+	}
+
+	return ""
+}
+
+// NewJob creates a job with the given ID targeting the given runner labels.
+func NewJob(id string, runsOn ...string) *Job {
+	return &Job{ID: id, Name: id, RunsOn: runsOn}
+}
+
+// WithNeeds adds the jobs that must be completed before the job runs.
+func (j *Job) WithNeeds(needs ...string) *Job {
+	j.Needs = append(j.Needs, needs...)
+
+	return j
+}
+
+// WithEnv sets the environment variable of the job.
+func (j *Job) WithEnv(key, value string) *Job {
+	if j.Env == nil {
+		j.Env = make(map[string]string)
+	}
+
+	j.Env[key] = value
+
+	return j
+}
+
+// AddStep appends the step to the steps of the job.
+func (j *Job) AddStep(step Step) *Job {
+	j.Steps = append(j.Steps, step)
+
+	return j
+}
+
+// InsertStep inserts the step at the given index of the steps of the job. Indexes out of range are clamped, so a
+// negative index inserts the step as the first step and an index greater than the number of steps appends it.
+func (j *Job) InsertStep(index int, step Step) *Job {
+	if index < 0 {
+		index = 0
+	}
+
+	if index > len(j.Steps) {
+		index = len(j.Steps)
+	}
+
+	// copy the steps to avoid modifying the backing array shared with the copies of the job
+	steps := make([]Step, 0, len(j.Steps)+1)
+	steps = append(steps, j.Steps[:index]...)
+	steps = append(steps, step)
+	steps = append(steps, j.Steps[index:]...)
+
+	j.Steps = steps
+
+	return j
+}
+
+// Validate checks the job has a runner, at least one step and the steps are valid.
+func (j *Job) Validate() error {
+	return errors.Join(j.validate()...)
+}
+
+// validate returns the problems found in the job.
+func (j *Job) validate() []error {
+	var errs []error
+
+	if len(j.RunsOn) == 0 {
+		errs = append(errs, errors.New("runs-on is required"))
+	}
+
+	if len(j.Steps) == 0 {
+		errs = append(errs, errors.New("job has no steps"))
+	}
+
+	seen := make(map[string]bool, len(j.Steps))
+
+	for idx, step := range j.Steps {
+		name := step.ID
+		if name == "" {
+			name = fmt.Sprintf("%d", idx)
+		}
+
+		if seen[name] {
+			errs = append(errs, fmt.Errorf("step %q: duplicate step id", name))
+		}
+
+		seen[name] = true
+
+		if err := step.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("step %q: %w", name, err))
+		}
+	}
+
+	return errs
+}
+
+// NewRunStep creates a step running the given shell command.
+func NewRunStep(id, run string) Step {
+	return Step{ID: id, Run: run}
+}
+
+// NewActionStep creates a step running the given action with the inputs.
+func NewActionStep(id, uses string, with map[string]string) Step {
+	return Step{ID: id, Uses: uses, With: with}
+}
+
+// Validate checks the step has either uses or run, but not both.
+func (s *Step) Validate() error {
+	switch {
+	case s.Uses != "" && s.Run != "":
+		return errors.New("uses and run can't be used together")
+	case s.Uses == "" && s.Run == "":
+		return errors.New("either uses or run is required")
+	}
+
+	return nil
+}
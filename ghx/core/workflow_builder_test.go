@@ -0,0 +1,129 @@
+package core
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const roundTripWorkflow = `name: CI
+on:
+  push:
+    paths:
+      - 'src/**'
+  schedule:
+    - cron: '0 0 * * *'
+  workflow_dispatch: {}
+env:
+  GO_VERSION: "1.21"
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    environment:
+      name: production
+      url: https://example.com
+    strategy:
+      matrix:
+        os: [ubuntu, windows]
+        go: ["1.20", "1.21"]
+        include:
+          - os: ubuntu
+            experimental: true
+      fail-fast: false
+    steps:
+      - uses: actions/checkout@v3
+      - id: test
+        run: go test ./...
+  release:
+    needs: build
+    runs-on: [self-hosted, linux]
+    environment: staging
+    steps:
+      - run: make release
+`
+
+func TestWorkflow_RoundTrip(t *testing.T) {
+	wf, err := ParseWorkflow([]byte(roundTripWorkflow), ".github/workflows/ci.yaml")
+	require.NoError(t, err)
+	require.NoError(t, wf.Validate())
+
+	data, err := yaml.Marshal(&wf)
+	require.NoError(t, err)
+
+	parsed, err := ParseWorkflow(data, wf.Path)
+	require.NoError(t, err)
+
+	assert.Equal(t, wf, parsed)
+	assert.Equal(t, []string{"0 0 * * *"}, parsed.On["schedule"].Crons)
+	assert.Equal(t, []string{"os", "go"}, parsed.Jobs["build"].Strategy.Matrix.Keys)
+	assert.Equal(t, Environment{Name: "staging"}, parsed.Jobs["release"].Environment)
+}
+
+func TestEvents_MarshalYAML(t *testing.T) {
+	data, err := yaml.Marshal(Events{"push": {}, "pull_request": {}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "- pull_request\n- push\n", string(data))
+}
+
+func TestNewWorkflow(t *testing.T) {
+	wf := NewWorkflow("CI").
+		WithEvent("push", EventFilters{}).
+		WithJob(NewJob("build", "ubuntu-latest").AddStep(NewRunStep("build", "make"))).
+		WithJob(NewJob("test", "ubuntu-latest").WithNeeds("build").AddStep(NewActionStep("", "actions/checkout@v3", nil)))
+
+	require.NoError(t, wf.Validate())
+
+	// defaults are set like the workflows loaded from the files
+	assert.Equal(t, "0", wf.Jobs["test"].Steps[0].ID)
+
+	data, err := yaml.Marshal(wf)
+	require.NoError(t, err)
+
+	parsed, err := ParseWorkflow(data, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, *wf, parsed)
+}
+
+func TestJob_InsertStep(t *testing.T) {
+	original := NewJob("build", "ubuntu-latest").AddStep(NewRunStep("a", "a")).AddStep(NewRunStep("b", "b"))
+
+	// job is copied by value, like the jobs of a workflow
+	job := *original
+	job.InsertStep(1, NewRunStep("debug", "env")).InsertStep(-1, NewRunStep("first", "true")).InsertStep(10, NewRunStep("last", "true"))
+
+	var ids []string
+
+	for _, step := range job.Steps {
+		ids = append(ids, step.ID)
+	}
+
+	assert.Equal(t, []string{"first", "a", "debug", "b", "last"}, ids)
+	assert.Len(t, original.Steps, 2)
+}
+
+func TestWorkflow_Validate(t *testing.T) {
+	wf := NewWorkflow("CI").
+		WithJob(NewJob("a", "ubuntu-latest").WithNeeds("b").AddStep(NewRunStep("x", "true"))).
+		WithJob(NewJob("b", "ubuntu-latest").WithNeeds("a", "missing").AddStep(NewRunStep("x", "true"))).
+		WithJob(NewJob("1c").AddStep(Step{ID: "x", Run: "true", Uses: "actions/checkout@v3"}).AddStep(NewRunStep("x", "true")))
+
+	err := wf.Validate()
+	require.Error(t, err)
+
+	for _, msg := range []string{
+		"workflow has no events",
+		`job "1c": invalid id`,
+		`job "1c": runs-on is required`,
+		`job "1c": step "x": uses and run can't be used together`,
+		`job "1c": step "x": duplicate step id`,
+		`job "b": needs unknown job "missing"`,
+		"jobs have a dependency cycle: a -> b -> a",
+	} {
+		assert.Contains(t, err.Error(), msg)
+	}
+}
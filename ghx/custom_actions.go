@@ -1,4 +1,4 @@
-package main
+package ghx
 
 import (
 	"context"
@@ -13,36 +13,80 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
 
 	"github.com/aweris/gale/common/fs"
 	"github.com/aweris/gale/common/log"
 	"github.com/aweris/gale/ghx/core"
 )
 
-// LoadActionFromSource loads an action from given source to the target directory. If the source is a local action,
-// the target directory will be the same as the source. If the source is a remote action, the action will be downloaded
-// to the target directory using the source as the reference(e.g. {target}/{owner}/{repo}/{path}@{ref}).
-func LoadActionFromSource(ctx context.Context, client *dagger.Client, source, targetDir string) (*core.CustomAction, error) {
-	var target string
+// LoadActionOpts represents the options for loading an action.
+type LoadActionOpts struct {
+	Offline   bool          // Offline disables downloading actions. Loading fails if the action does not exist in the actions cache.
+	Workspace string        // Workspace is the directory that relative local actions are resolved from.
+	Token     string        // Token is the GitHub token used to download private actions. Optional.
+	Policy    *ActionPolicy // Policy is the policy of the remote actions allowed to run. Optional.
+}
 
-	repo, path, ref, err := parseRepoRef(source)
-	if err != nil {
-		return nil, err
+// LoadActionFromSource loads an action from given source to the target directory. If the source is a local action,
+// the action is loaded from the source path, relative paths are resolved from the workspace. If the source is a remote
+// action, the action will be downloaded to the target directory using the source as the reference
+// (e.g. {target}/{owner}/{repo}/{path}@{ref}).
+//
+// Optionally, it can be configured with the given options. Only first option is used if multiple options are provided.
+func LoadActionFromSource(ctx context.Context, client *dagger.Client, source, targetDir string, opts ...LoadActionOpts) (*core.CustomAction, error) {
+	var (
+		target string
+		path   string
+		opt    LoadActionOpts
+	)
+
+	if len(opts) > 0 {
+		opt = opts[0]
 	}
 
 	// no need to load action if it is a local action
 	if isLocalAction(source) {
 		target = source
+
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(opt.Workspace, target)
+		}
 	} else {
+		repo, subpath, ref, err := parseRepoRef(source)
+		if err != nil {
+			return nil, err
+		}
+
 		target = filepath.Join(targetDir, source)
+		path = subpath
+
+		// actions not allowed by the policy are refused before downloading them
+		if err := opt.Policy.Check(source); err != nil {
+			return nil, err
+		}
 
 		// ensure action exists locally -- FIXME: source just passed for logging purposes, should be refactored
-		if err := ensureActionExistsLocally(source, repo, ref, target); err != nil {
+		if _, err := ensureActionExistsLocally(source, repo, ref, targetDir, opt); err != nil {
 			return nil, err
 		}
 	}
 
+	// without the engine, e.g. in untrusted workflows, the metadata is read from the file system and the action has no
+	// directory to build the docker actions from
+	if client == nil {
+		meta, err := readCustomActionMeta(filepath.Join(target, path))
+		if err != nil {
+			return nil, err
+		}
+
+		return &core.CustomAction{Meta: meta, Path: filepath.Join(target, path)}, nil
+	}
+
 	dir := client.Host().Directory(target)
 
 	if path != "" {
@@ -54,7 +98,8 @@ func LoadActionFromSource(ctx context.Context, client *dagger.Client, source, ta
 		return nil, err
 	}
 
-	return &core.CustomAction{Meta: meta, Path: target, Dir: dir}, nil
+	// action path is the directory of the action itself, not the repository root
+	return &core.CustomAction{Meta: meta, Path: filepath.Join(target, path), Dir: dir}, nil
 }
 
 // isLocalAction checks if the given source is a local action
@@ -62,47 +107,130 @@ func isLocalAction(source string) bool {
 	return strings.HasPrefix(source, "./") || filepath.IsAbs(source) || strings.HasPrefix(source, "/")
 }
 
-// ensureActionExistsLocally ensures that the action exists locally. If the action does not exist locally, it will be
-// downloaded from the source to the target directory.
-func ensureActionExistsLocally(source, repo, ref, target string) error {
+// actionsIndexFile is the name of the file in the actions directory that keeps the resolved commit SHAs of the
+// downloaded actions by source. It's used to pin the action to the resolved commit SHA and detect the changes in the
+// refs. Actions of the same repository and ref with different paths are downloaded to different directories, so the
+// index is keyed by the source instead of the repository and ref.
+const actionsIndexFile = "index.json"
+
+// ensureActionExistsLocally ensures that the action exists locally and pinned to the commit SHA resolved from the ref.
+// If the action does not exist locally, or the ref points to a different commit than the cached one, it will be
+// downloaded from the source to the {targetDir}/{source} directory. The method returns the resolved commit SHA.
+//
+// In offline mode, the action is not downloaded and the method fails if the action does not exist in the cache.
+//
+// The actions directory is shared by the concurrent workflow runs through the actions cache, so the index and the
+// actions are updated holding the lock of the index.
+func ensureActionExistsLocally(source, repo, ref, targetDir string, opt LoadActionOpts) (string, error) {
+	var sha string
+
+	err := fs.WithLock(filepath.Join(targetDir, actionsIndexFile), func() error {
+		var err error
+
+		sha, err = syncAction(source, repo, ref, targetDir, opt)
+
+		return err
+	})
+
+	return sha, err
+}
+
+// syncAction downloads the action to the {targetDir}/{source} directory unless it's already pinned to the commit SHA
+// resolved from the ref, and returns the commit SHA.
+func syncAction(source, repo, ref, targetDir string, opt LoadActionOpts) (string, error) {
+	var (
+		target    = filepath.Join(targetDir, source)
+		indexFile = filepath.Join(targetDir, actionsIndexFile)
+		index     = make(map[string]string)
+	)
+
+	if err := fs.EnsureFile(indexFile); err != nil {
+		return "", fmt.Errorf("failed to ensure actions index: %w", err)
+	}
+
+	if err := fs.ReadJSONFile(indexFile, &index); err != nil {
+		return "", fmt.Errorf("failed to read actions index: %w", err)
+	}
+
 	// check if action exists locally
 	exist, err := fs.Exists(target)
 	if err != nil {
-		return fmt.Errorf("failed to check if action exists locally: %w", err)
+		return "", fmt.Errorf("failed to check if action exists locally: %w", err)
+	}
+
+	cached, pinned := index[source]
+
+	if opt.Offline {
+		if !exist || !pinned {
+			return "", fmt.Errorf("action %s does not exist in the actions cache and offline mode is enabled", source)
+		}
+
+		log.Debugf("offline mode, using cached action", "source", source, "target", target, "sha", cached)
+
+		return cached, nil
+	}
+
+	sha, err := resolveActionRef(repo, ref, opt.Token)
+	if err != nil {
+		// use the cached action if the ref can't be resolved, e.g. network is not available
+		if exist && pinned {
+			log.Debugf("failed to resolve action ref, using cached action", "source", source, "error", err)
+
+			return cached, nil
+		}
+
+		return "", err
 	}
 
-	// do nothing if target path already exists
+	// do nothing if target path already exists and pinned to the same commit
+	if exist && cached == sha {
+		log.Debugf("action already exists locally", "source", source, "target", target, "sha", sha)
+		return sha, nil
+	}
+
+	// remove the stale action before downloading it again
 	if exist {
-		log.Debugf("action already exists locally", "source", source, "target", target)
-		return nil
+		if err := os.RemoveAll(target); err != nil {
+			return "", fmt.Errorf("failed to remove stale action: %w", err)
+		}
 	}
 
-	log.Debugf("action does not exist locally, downloading...", "source", source, "target", target)
+	log.Debugf("action does not exist locally, downloading...", "source", source, "target", target, "sha", sha)
+
+	if err := downloadAction(repo, sha, target, opt.Token); err != nil {
+		return "", err
+	}
 
+	index[source] = sha
+
+	if err := fs.WriteJSONFile(indexFile, index); err != nil {
+		return "", fmt.Errorf("failed to write actions index: %w", err)
+	}
+
+	return sha, nil
+}
+
+// downloadAction clones the given repository to the target directory and checkouts the given commit SHA.
+func downloadAction(repo, sha, target, token string) error {
 	url := fmt.Sprintf("https://github.com/%s.git", repo)
 
 	// Clone the repository into the target directory using go-git
 	r, err := git.PlainClone(target, false, &git.CloneOptions{
 		URL:      url,
+		Auth:     gitAuth(token),
 		Progress: os.Stdout,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to clone action repository: %w", err)
 	}
 
-	// Resolve the revision (be it a branch, tag or commit hash)
-	hash, err := r.ResolveRevision(plumbing.Revision(ref))
-	if err != nil {
-		return fmt.Errorf("failed to resolve revision: %w", err)
-	}
-
 	// Checkout to the commit
 	w, err := r.Worktree()
 	if err != nil {
 		return fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	err = w.Checkout(&git.CheckoutOptions{Hash: *hash})
+	err = w.Checkout(&git.CheckoutOptions{Hash: plumbing.NewHash(sha)})
 	if err != nil {
 		return fmt.Errorf("failed to checkout: %w", err)
 	}
@@ -110,6 +238,59 @@ func ensureActionExistsLocally(source, repo, ref, target string) error {
 	return nil
 }
 
+// shaRe matches full length commit SHAs.
+var shaRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// resolveActionRef resolves the given ref of the repository to a commit SHA without cloning the repository. The ref
+// could be a commit SHA, a tag or a branch. Tags have precedence over branches with the same name.
+func resolveActionRef(repo, ref, token string) (string, error) {
+	if shaRe.MatchString(ref) {
+		return ref, nil
+	}
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{fmt.Sprintf("https://github.com/%s.git", repo)},
+	})
+
+	refs, err := remote.List(&git.ListOptions{Auth: gitAuth(token)})
+	if err != nil {
+		return "", fmt.Errorf("failed to list refs of %s: %w", repo, err)
+	}
+
+	hashes := make(map[string]string, len(refs))
+
+	for _, r := range refs {
+		hashes[r.Name().String()] = r.Hash().String()
+	}
+
+	// annotated tags are listed with the tag object hash, peeled version of the tag points to the commit itself.
+	candidates := []string{
+		fmt.Sprintf("refs/tags/%s^{}", ref),
+		fmt.Sprintf("refs/tags/%s", ref),
+		fmt.Sprintf("refs/heads/%s", ref),
+	}
+
+	for _, candidate := range candidates {
+		if sha, ok := hashes[candidate]; ok {
+			return sha, nil
+		}
+	}
+
+	return "", fmt.Errorf("failed to resolve ref %s of %s", ref, repo)
+}
+
+// gitAuth returns the authentication method for the given GitHub token. If the token is empty, it returns nil to use
+// anonymous access.
+func gitAuth(token string) transport.AuthMethod {
+	if token == "" {
+		return nil
+	}
+
+	// username is ignored by GitHub as long as it's not empty
+	return &http.BasicAuth{Username: "x-access-token", Password: token}
+}
+
 // getCustomActionMeta returns the meta information about the custom action from the action directory.
 func getCustomActionMeta(ctx context.Context, actionDir *dagger.Directory) (core.CustomActionMeta, error) {
 	var meta core.CustomActionMeta
@@ -132,6 +313,29 @@ func getCustomActionMeta(ctx context.Context, actionDir *dagger.Directory) (core
 	return meta, nil
 }
 
+// readCustomActionMeta reads the action.yml or action.yaml file in the root of the given action directory from the file
+// system.
+func readCustomActionMeta(dir string) (core.CustomActionMeta, error) {
+	var meta core.CustomActionMeta
+
+	for _, name := range []string{"action.yml", "action.yaml"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return meta, err
+		}
+
+		err = yaml.Unmarshal(content, &meta)
+
+		return meta, err
+	}
+
+	return meta, fmt.Errorf("action.yml or action.yaml not found in the root of the action directory")
+}
+
 // findActionMetadataFileName finds the action.yml or action.yaml file in the root of the action directory.
 func findActionMetadataFileName(ctx context.Context, dir *dagger.Directory) (string, error) {
 	// list all entries in the root of the action directory
@@ -1,4 +1,4 @@
-package main
+package ghx
 
 import (
 	"context"
@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	"dagger.io/dagger"
+
+	"github.com/aweris/gale/common/fs"
 )
 
 func TestCustomActionManager_GetCustomAction(t *testing.T) {
@@ -33,8 +35,8 @@ func TestCustomActionManager_GetCustomAction(t *testing.T) {
 			t.Fatal("action dir is different than expected")
 		}
 
-		if ca.Meta == nil {
-			t.Fatal("action meta is nil")
+		if ca.Meta.Name == "" {
+			t.Fatal("action meta is empty")
 		}
 
 		if _, err := os.Stat(filepath.Join(dir, "actions", "actions/checkout@v2")); err != nil {
@@ -69,8 +71,8 @@ func TestCustomActionManager_GetCustomAction(t *testing.T) {
 			t.Fatal("action dir is different than expected")
 		}
 
-		if ca.Meta == nil {
-			t.Fatal("action meta is nil")
+		if ca.Meta.Name == "" {
+			t.Fatal("action meta is empty")
 		}
 
 		if ca.Meta.Name != "some-action" {
@@ -78,3 +80,115 @@ func TestCustomActionManager_GetCustomAction(t *testing.T) {
 		}
 	})
 }
+
+func TestSyncAction_Offline(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, source := range []string{"actions/cache/save@v4", "actions/cache/restore@v4"} {
+		if err := os.MkdirAll(filepath.Join(dir, source), 0755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	index := map[string]string{"actions/cache/save@v4": "a1b2c3"}
+
+	if err := fs.WriteJSONFile(filepath.Join(dir, actionsIndexFile), index); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+		wantErr  bool
+	}{
+		{name: "pinned action", source: "actions/cache/save@v4", expected: "a1b2c3"},
+		{name: "same repository and ref with a different path", source: "actions/cache/restore@v4", wantErr: true},
+		{name: "missing action", source: "actions/checkout@v4", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _, ref, err := parseRepoRef(tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			sha, err := syncAction(tt.source, repo, ref, dir, LoadActionOpts{Offline: true})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("syncAction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if sha != tt.expected {
+				t.Errorf("expected sha %q, got %q", tt.expected, sha)
+			}
+		})
+	}
+}
+
+func TestLoadActionFromSource_WithoutEngine(t *testing.T) {
+	workspace := t.TempDir()
+
+	files := map[string]string{
+		".github/actions/yml/action.yml":   "name: yml\nruns:\n  using: composite\n",
+		".github/actions/yaml/action.yaml": "name: yaml\nruns:\n  using: node20\n  main: index.js\n",
+		".github/actions/none/README.md":   "no metadata",
+	}
+
+	for path, content := range files {
+		if err := fs.WriteFile(filepath.Join(workspace, path), []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		source   string
+		expected string
+		wantErr  bool
+	}{
+		{source: "./.github/actions/yml", expected: "yml"},
+		{source: "./.github/actions/yaml", expected: "yaml"},
+		{source: "./.github/actions/none", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			ca, err := LoadActionFromSource(context.Background(), nil, tt.source, t.TempDir(), LoadActionOpts{Workspace: workspace})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadActionFromSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if ca.Meta.Name != tt.expected {
+				t.Errorf("expected action %s, got %s", tt.expected, ca.Meta.Name)
+			}
+
+			if ca.Dir != nil {
+				t.Errorf("expected no action directory without the engine")
+			}
+		})
+	}
+}
+
+func TestIsLocalAction(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected bool
+	}{
+		{source: "./.github/actions/build", expected: true},
+		{source: "/home/runner/work/actions/build", expected: true},
+		{source: "actions/checkout@v4", expected: false},
+		{source: "docker://alpine:3.19", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			if got := isLocalAction(tt.source); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
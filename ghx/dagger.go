@@ -1,7 +1,8 @@
-package main
+package ghx
 
 import (
 	"context"
+	"errors"
 	"os"
 
 	"dagger.io/dagger"
@@ -10,7 +11,21 @@ import (
 	"github.com/aweris/gale/ghx/journal"
 )
 
-func getDaggerClient(ctx context.Context) (*dagger.Client, error) {
+// errNoEngine is the error of the steps requiring the engine, e.g. container steps, when ghx runs without the engine.
+var errNoEngine = errors.New("step requires the dagger engine, which is not available to untrusted workflows")
+
+// NewDaggerClient returns the dagger client of ghx. Untrusted workflows run without access to the engine, otherwise the
+// steps could reach the engine through the session of ghx, so the client is nil for them and the steps requiring the
+// engine fail with errNoEngine.
+func NewDaggerClient(ctx context.Context, untrusted bool) (*dagger.Client, error) {
+	// prefix the console output with the job and the step producing it and the elapsed time, so the interleaved output
+	// of the parallel jobs is readable
+	log.SetPrefix(journal.Prefix)
+
+	if untrusted {
+		return nil, nil
+	}
+
 	// initialize dagger client and set it to config
 	var opts []dagger.ClientOpt
 
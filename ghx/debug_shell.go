@@ -0,0 +1,51 @@
+package ghx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/ghx/context"
+)
+
+// saveDebugEnv saves the working directory and the environment of the failed command as a shell script to the debug
+// directory in ghx home. The script is sourced by the debug shell to restore the state of the failed step.
+func saveDebugEnv(ctx *context.Context, dir string, env []string) error {
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+
+		dir = wd
+	}
+
+	sb := strings.Builder{}
+
+	for _, kv := range env {
+		key, value, ok := strings.Cut(kv, "=")
+
+		// skip the variables can't be exported by the shell
+		if !ok || key == "" || strings.IndexFunc(key, isInvalidEnvKeyRune) >= 0 {
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("export %s=%s\n", key, shellQuote(value)))
+	}
+
+	sb.WriteString(fmt.Sprintf("cd %s\n", shellQuote(dir)))
+
+	return fs.WriteFile(filepath.Join(ctx.GhxConfig.HomeDir, "debug", "env.sh"), []byte(sb.String()), 0600)
+}
+
+// shellQuote quotes the given value with single quotes to use it as is in a shell script.
+func shellQuote(value string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(value, "'", `'\''`))
+}
+
+// isInvalidEnvKeyRune returns true if the rune is not allowed in an exported shell variable name.
+func isInvalidEnvKeyRune(r rune) bool {
+	return !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+}
@@ -0,0 +1,56 @@
+package ghx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestSaveDebugEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		dir      string
+		env      []string
+		expected string
+	}{
+		{
+			name:     "exported variables",
+			dir:      "/home/runner/work/gale",
+			env:      []string{"FOO=bar", "EMPTY=", "MULTI=a=b"},
+			expected: "export FOO='bar'\nexport EMPTY=''\nexport MULTI='a=b'\ncd '/home/runner/work/gale'\n",
+		},
+		{
+			name:     "quoted values",
+			dir:      "/tmp/it's",
+			env:      []string{"MSG=it's done"},
+			expected: "export MSG='it'\\''s done'\ncd '/tmp/it'\\''s'\n",
+		},
+		{
+			name:     "invalid keys are skipped",
+			dir:      "/src",
+			env:      []string{"=C:=C:\\", "NO_VALUE", "DASHED-KEY=1", "OK_1=1"},
+			expected: "export OK_1='1'\ncd '/src'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, &core.JobRun{RunID: "1", Job: core.Job{ID: "build"}})
+
+			if err := saveDebugEnv(ctx, tt.dir, tt.env); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := os.ReadFile(filepath.Join(ctx.GhxConfig.HomeDir, "debug", "env.sh"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(got) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
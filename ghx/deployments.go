@@ -0,0 +1,140 @@
+package ghx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// createDeployment creates a GitHub deployment for the environment of the current job and marks it in progress, so the
+// tools watching the environment see the gale run like a GitHub run. It returns the id of the deployment or zero if
+// deployments are disabled or the job doesn't reference an environment. Failures are only logged, since reporting the
+// deployment shouldn't fail the job.
+//
+// See: https://docs.github.com/en/rest/deployments/deployments#create-a-deployment
+func createDeployment(ctx *context.Context) int64 {
+	if !ctx.GhxConfig.Deployments || ctx.Github.Environment == "" {
+		return 0
+	}
+
+	if ctx.Github.Token == "" {
+		log.Warnf("Skipping deployment, GitHub token is required", "environment", ctx.Github.Environment)
+		return 0
+	}
+
+	ref := ctx.Github.SHA
+	if ref == "" {
+		ref = ctx.Github.Ref
+	}
+
+	request := map[string]interface{}{
+		"ref":               ref,
+		"environment":       ctx.Github.Environment,
+		"auto_merge":        false,
+		"required_contexts": []string{},
+		"description":       fmt.Sprintf("Deployed by gale workflow run %s", ctx.Execution.WorkflowRun.RunID),
+	}
+
+	var deployment struct {
+		ID int64 `json:"id"`
+	}
+
+	path := fmt.Sprintf("/repos/%s/deployments", ctx.Github.Repository)
+
+	if err := githubPost(ctx, path, request, &deployment); err != nil {
+		log.Warnf("Failed to create deployment", "environment", ctx.Github.Environment, "error", err)
+		return 0
+	}
+
+	log.Infof("Deployment created", "environment", ctx.Github.Environment, "id", deployment.ID)
+
+	updateDeploymentStatus(ctx, deployment.ID, "in_progress", "")
+
+	return deployment.ID
+}
+
+// completeDeployment updates the status of the deployment with the conclusion of the job and the deployment url of
+// the environment.
+func completeDeployment(ctx *context.Context, id int64, conclusion core.Conclusion) {
+	if id == 0 {
+		return
+	}
+
+	var state string
+
+	switch conclusion {
+	case core.ConclusionSuccess:
+		state = "success"
+	case core.ConclusionFailure:
+		state = "failure"
+	default:
+		state = "error"
+	}
+
+	updateDeploymentStatus(ctx, id, state, ctx.Execution.JobRun.URL)
+}
+
+// updateDeploymentStatus creates a status for the deployment with the given state.
+//
+// See: https://docs.github.com/en/rest/deployments/statuses#create-a-deployment-status
+func updateDeploymentStatus(ctx *context.Context, id int64, state, url string) {
+	request := map[string]interface{}{
+		"state":         state,
+		"environment":   ctx.Github.Environment,
+		"description":   fmt.Sprintf("Job %s of gale workflow run %s", ctx.Execution.JobRun.Job.ID, ctx.Execution.WorkflowRun.RunID),
+		"auto_inactive": true,
+	}
+
+	if url != "" {
+		request["environment_url"] = url
+	}
+
+	path := fmt.Sprintf("/repos/%s/deployments/%d/statuses", ctx.Github.Repository, id)
+
+	if err := githubPost(ctx, path, request, nil); err != nil {
+		log.Warnf("Failed to update deployment status", "environment", ctx.Github.Environment, "state", state, "error", err)
+	}
+}
+
+// githubPost sends the request to the GitHub API with the token of the context and decodes the response into out if
+// it's not nil.
+func githubPost(ctx *context.Context, path string, request, out interface{}) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(ctx.Github.APIURL, "/")+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", ctx.Github.Token))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
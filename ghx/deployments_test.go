@@ -0,0 +1,93 @@
+package ghx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestDeployments(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+		states   []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var body map[string]interface{}
+
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		requests = append(requests, r.URL.Path)
+
+		if state, ok := body["state"].(string); ok {
+			states = append(states, state+" "+toString(body["environment_url"]))
+		}
+
+		w.WriteHeader(http.StatusCreated)
+
+		_, _ = w.Write([]byte(`{"id": 42}`))
+	}))
+	defer server.Close()
+
+	ctx := &context.Context{
+		GhxConfig: context.GhxConfig{Deployments: true},
+		Execution: context.ExecutionContext{
+			WorkflowRun: &core.WorkflowRun{RunID: "1"},
+			JobRun:      &core.JobRun{Job: core.Job{ID: "deploy"}, URL: "https://example.com"},
+		},
+	}
+
+	ctx.Github.APIURL = server.URL
+	ctx.Github.Token = "token"
+	ctx.Github.Repository = "aweris/gale"
+	ctx.Github.SHA = "abc"
+	ctx.Github.Environment = "production"
+
+	id := createDeployment(ctx)
+	if id != 42 {
+		t.Fatalf("expected deployment 42, got %d", id)
+	}
+
+	completeDeployment(ctx, id, core.ConclusionSuccess)
+
+	expected := []string{"/repos/aweris/gale/deployments", "/repos/aweris/gale/deployments/42/statuses", "/repos/aweris/gale/deployments/42/statuses"}
+	if len(requests) != len(expected) {
+		t.Fatalf("expected requests %v, got %v", expected, requests)
+	}
+
+	for i := range expected {
+		if requests[i] != expected[i] {
+			t.Errorf("expected request %s, got %s", expected[i], requests[i])
+		}
+	}
+
+	if len(states) != 2 || states[0] != "in_progress " || states[1] != "success https://example.com" {
+		t.Errorf("unexpected deployment statuses %q", states)
+	}
+
+	// jobs without environment don't create deployments
+	ctx.Github.Environment = ""
+
+	if id := createDeployment(ctx); id != 0 {
+		t.Errorf("expected no deployment without environment, got %d", id)
+	}
+}
+
+func toString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
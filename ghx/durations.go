@@ -0,0 +1,72 @@
+package ghx
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// printDurationSummary prints the durations of the jobs and steps of the workflow run as a table. Time spent pulling
+// the step images is printed separately from the total duration of the step.
+func printDurationSummary(wr *core.WorkflowRun, total time.Duration) {
+	var (
+		sb        strings.Builder
+		totalPull time.Duration
+	)
+
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "NAME\tCONCLUSION\tPULL\tDURATION")
+
+	for _, jr := range wr.JobRuns {
+		fmt.Fprintf(w, "%s\t%s\t\t%s\n", jr.DisplayName(), jr.Conclusion, formatDuration(jr.Duration))
+
+		for _, sr := range jr.Steps {
+			prefix := ""
+
+			switch sr.Stage {
+			case core.StepStagePre:
+				prefix = "Pre"
+			case core.StepStagePost:
+				prefix = "Post"
+			}
+
+			pull := ""
+			if sr.PullDuration > 0 {
+				pull = formatDuration(sr.PullDuration)
+				totalPull += sr.PullDuration
+			}
+
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", getStepName(prefix, sr.Step), sr.Conclusion, pull, formatDuration(sr.Duration))
+		}
+	}
+
+	fmt.Fprintf(w, "Total\t\t%s\t%s\n", formatDuration(totalPull), formatDuration(total))
+
+	if err := w.Flush(); err != nil {
+		log.Debugf("Failed to print duration summary", "error", err)
+		return
+	}
+
+	log.Info("Duration summary")
+	log.StartGroup()
+
+	for _, line := range strings.Split(strings.TrimRight(sb.String(), "\n"), "\n") {
+		log.Info(line)
+	}
+
+	log.EndGroup()
+}
+
+// formatDuration rounds the duration to make it readable in the summary.
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+
+	return d.Round(100 * time.Millisecond).String()
+}
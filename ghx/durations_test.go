@@ -0,0 +1,105 @@
+package ghx
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{duration: 0, expected: "0s"},
+		{duration: 1234567 * time.Nanosecond, expected: "1ms"},
+		{duration: 999400 * time.Microsecond, expected: "999ms"},
+		{duration: 1260 * time.Millisecond, expected: "1.3s"},
+		{duration: 83*time.Second + 420*time.Millisecond, expected: "1m23.4s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := formatDuration(tt.duration); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPrintDurationSummary(t *testing.T) {
+	var buf bytes.Buffer
+
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stdout) })
+
+	wr := &core.WorkflowRun{
+		JobRuns: []core.JobRun{
+			{
+				Job:        core.Job{Name: "build"},
+				Conclusion: core.ConclusionSuccess,
+				Duration:   12 * time.Second,
+				Steps: []core.StepRun{
+					{Step: core.Step{Name: "checkout"}, Stage: core.StepStagePre, Conclusion: core.ConclusionSuccess, Duration: 40 * time.Millisecond},
+					{Step: core.Step{Name: "lint"}, Stage: core.StepStageMain, Conclusion: core.ConclusionSuccess, Duration: 9 * time.Second, PullDuration: 3 * time.Second},
+					{Step: core.Step{Name: "checkout"}, Stage: core.StepStagePost, Conclusion: core.ConclusionSuccess, Duration: 20 * time.Millisecond},
+				},
+			},
+		},
+	}
+
+	printDurationSummary(wr, 15*time.Second)
+
+	tests := []struct {
+		name   string
+		fields []string
+	}{
+		{name: "header", fields: []string{"NAME", "CONCLUSION", "PULL", "DURATION"}},
+		{name: "job", fields: []string{"build", "success", "12s"}},
+		{name: "pre step", fields: []string{"Pre", "checkout", "success", "40ms"}},
+		{name: "step with pull", fields: []string{"lint", "success", "3s", "9s"}},
+		{name: "post step", fields: []string{"Post", "checkout", "success", "20ms"}},
+		{name: "total", fields: []string{"Total", "3s", "15s"}},
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, line := range lines {
+				if containsFields(line, tt.fields) {
+					return
+				}
+			}
+
+			t.Errorf("expected a line with %v, got:\n%s", tt.fields, buf.String())
+		})
+	}
+}
+
+// containsFields returns true if the line has the given fields in order.
+func containsFields(line string, fields []string) bool {
+	got := strings.Fields(line)
+
+	for i := 0; i+len(fields) <= len(got); i++ {
+		match := true
+
+		for j, field := range fields {
+			if got[i+j] != field {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,61 @@
+package ghx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+)
+
+// approveEnvironment approves the deployment of the current job to its environment. Environments approved in advance
+// are approved without asking, otherwise the user is asked to approve the deployment.
+func approveEnvironment(ctx *context.Context) (bool, error) {
+	var (
+		job         = ctx.Execution.JobRun.Job.ID
+		environment = ctx.Github.Environment
+	)
+
+	if isApprovedEnvironment(ctx.GhxConfig.ApprovedEnvironments, environment) {
+		log.Infof("Deployment is approved in advance", "job", job, "environment", environment)
+
+		return true, nil
+	}
+
+	if !isTerminal() {
+		return false, errors.New("environment approval requires a terminal, approve the environment in advance to run without a terminal")
+	}
+
+	log.Infof("Deployment requires approval, approve? [y/N]", "job", job, "environment", environment)
+
+	return readApproval(stdin)
+}
+
+// isApprovedEnvironment returns true if the environment matches any of the approved environment patterns.
+func isApprovedEnvironment(approved []string, environment string) bool {
+	for _, pattern := range approved {
+		if ok, _ := path.Match(pattern, environment); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readApproval reads the answer of the user from the reader. Only yes answers approve the deployment.
+func readApproval(r *bufio.Reader) (bool, error) {
+	input, err := r.ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
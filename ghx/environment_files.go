@@ -1,11 +1,10 @@
-package main
+package ghx
 
 import (
 	"bufio"
 	stdContext "context"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 
 	"github.com/aweris/gale/ghx/context"
@@ -51,36 +50,29 @@ func (ef *EnvironmentFiles) Process(ctx *context.Context) error {
 		return err
 	}
 
+	// variables and paths are kept in the step run and applied to the subsequent steps of the job, not to the ghx process
 	for k, v := range env {
-		if err := os.Setenv(k, v); err != nil {
-			return err
-		}
-		// FIXME: for now it's just reporting but we should use this as source of truth for step extra env
 		if err := ctx.SetStepEnv(k, v); err != nil {
 			return err
 		}
 	}
 
-	paths, err := ef.Path.ReadData(ctx.Context)
+	// paths are read line by line to keep the order of the paths
+	paths, err := ef.Path.RawData(ctx.Context)
 	if err != nil {
 		return err
 	}
 
-	path := os.Getenv("PATH")
-
-	for p := range paths {
-		path = fmt.Sprintf("%s:%s", path, p)
+	for _, p := range strings.Split(paths, "\n") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
 
-		// FIXME: for now it's just reporting but we should use this as source of truth for step path
 		if err := ctx.AddStepPath(p); err != nil {
 			return err
 		}
 	}
 
-	if err := os.Setenv("PATH", path); err != nil {
-		return err
-	}
-
 	outputs, err := ef.Outputs.ReadData(ctx.Context)
 	if err != nil {
 		return err
@@ -100,68 +92,82 @@ func (ef *EnvironmentFiles) Process(ctx *context.Context) error {
 	return nil
 }
 
+// read parses the environment file content. Lines are in key=value format or the heredoc-style multi-line format, and
+// lines without any separator are considered as keys without value, e.g. paths in GITHUB_PATH.
+//
+// Multi-line format:
+//
+//	{name}<<{delimiter}
+//	{value}
+//	{delimiter}
+//
+// See: https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#multiline-strings
 func read(r io.Reader) (map[string]string, error) {
 	keyValues := make(map[string]string)
 
 	scanner := bufio.NewScanner(r)
 
 	var (
-		inMultiLineValue bool            // indicates if the scanner is currently processing a multi-line value
-		currentKey       string          // current key of the multi-line value
-		endMarker        string          // end marker of the multi-line value
-		valueBuilder     strings.Builder // builder to build the multi-line value
+		lineNumber       int      // current line number to report in errors
+		inMultiLineValue bool     // indicates if the scanner is currently processing a multi-line value
+		currentKey       string   // current key of the multi-line value
+		startLine        int      // line number of the start of the multi-line value
+		endMarker        string   // end marker of the multi-line value
+		lines            []string // lines of the multi-line value
 	)
 
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		lineNumber++
 
-		// Skip empty lines
-		if line == "" {
-			continue
-		}
-
-		// Check if the line contains "<<" indicating the start of a multi-line value
-		if strings.Contains(line, "<<") {
-			if inMultiLineValue {
-				return nil, fmt.Errorf("unexpected '<<' in line: %s", line)
+		// multi-line values are kept as they are until the end marker, including empty lines and whitespaces
+		if inMultiLineValue {
+			if strings.TrimRight(scanner.Text(), "\r") == endMarker {
+				inMultiLineValue = false
+				keyValues[currentKey] = strings.Join(lines, "\n")
+			} else {
+				lines = append(lines, scanner.Text())
 			}
 
-			parts := strings.SplitN(line, "<<", 2)
-			currentKey = strings.TrimSpace(parts[0])
-			valueBuilder.Reset()
-			inMultiLineValue = true
+			continue
+		}
 
-			// Extract the end marker from the line
-			endMarker = strings.TrimSpace(parts[1])
+		line := strings.TrimSpace(scanner.Text())
 
+		// Skip empty lines
+		if line == "" {
 			continue
 		}
 
-		// Check if there is active multi-line value processing
-		if inMultiLineValue {
-			// Check if the line is the end of the multi-line value
-			if strings.TrimSpace(line) == endMarker {
-				inMultiLineValue = false
-				value := strings.TrimSpace(strings.TrimSuffix(valueBuilder.String(), "\n"))
-				keyValues[currentKey] = value
-			} else {
-				valueBuilder.WriteString(line + "\n") // scanner removes the new line character, so we need to add it back
+		equalsIndex := strings.Index(line, "=")
+		heredocIndex := strings.Index(line, "<<")
+
+		switch {
+		// key=value format, value could contain "<<" as long as "=" comes first
+		case equalsIndex >= 0 && (heredocIndex < 0 || equalsIndex < heredocIndex):
+			key := strings.TrimSpace(line[:equalsIndex])
+			if key == "" {
+				return nil, fmt.Errorf("invalid format at line %d, key is empty: %s", lineNumber, line)
 			}
 
-			continue
-		}
+			keyValues[key] = strings.TrimSpace(line[equalsIndex+1:])
+		// {name}<<{delimiter} format
+		case heredocIndex >= 0:
+			currentKey = strings.TrimSpace(line[:heredocIndex])
+			if currentKey == "" {
+				return nil, fmt.Errorf("invalid format at line %d, key is empty: %s", lineNumber, line)
+			}
 
-		// split key and value by "="
-		parts := strings.SplitN(line, "=", 2)
+			endMarker = strings.TrimSpace(line[heredocIndex+2:])
+			if endMarker == "" {
+				return nil, fmt.Errorf("invalid format at line %d, delimiter is empty for key %s", lineNumber, currentKey)
+			}
 
+			lines = nil
+			startLine = lineNumber
+			inMultiLineValue = true
 		// if there is no "=" in the line, then it is a key without value (e.g. "path" values in GITHUB_PATH)
-		if len(parts) == 2 {
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			keyValues[key] = value
-		} else if len(parts) == 1 {
-			key := strings.TrimSpace(parts[0])
-			keyValues[key] = ""
+		default:
+			keyValues[line] = ""
 		}
 	}
 
@@ -169,5 +175,9 @@ func read(r io.Reader) (map[string]string, error) {
 		return nil, err
 	}
 
+	if inMultiLineValue {
+		return nil, fmt.Errorf("invalid format at line %d, matching delimiter %s not found for key %s", startLine, endMarker, currentKey)
+	}
+
 	return keyValues, nil
 }
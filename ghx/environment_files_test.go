@@ -1,9 +1,11 @@
-package main
+package ghx
 
 import (
 	"context"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"dagger.io/dagger"
@@ -206,3 +208,61 @@ More text here`
 		t.Errorf("Expected raw data to be '%s', but got '%s'", testData, rawData)
 	}
 }
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "multi-line value keeps whitespaces and empty lines",
+			data:     "key<<EOF\n  indented\n\nlast line\nEOF\n",
+			expected: map[string]string{"key": "  indented\n\nlast line"},
+		},
+		{
+			name:     "multi-line value with heredoc in content",
+			data:     "script<<EOF\ncat <<END\nEND\nEOF\nnext=value\n",
+			expected: map[string]string{"script": "cat <<END\nEND", "next": "value"},
+		},
+		{
+			name:     "value containing heredoc separator",
+			data:     "key=a<<b\n",
+			expected: map[string]string{"key": "a<<b"},
+		},
+		{
+			name:     "empty multi-line value",
+			data:     "key<<EOF\nEOF\n",
+			expected: map[string]string{"key": ""},
+		},
+		{
+			name:    "missing delimiter",
+			data:    "key<<EOF\nvalue\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty delimiter",
+			data:    "key<<\nvalue\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty key",
+			data:    "=value\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := read(strings.NewReader(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("read() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("read() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
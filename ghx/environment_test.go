@@ -0,0 +1,58 @@
+package ghx
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestIsApprovedEnvironment(t *testing.T) {
+	tests := []struct {
+		name        string
+		approved    []string
+		environment string
+		expected    bool
+	}{
+		{name: "no approved environments", environment: "production", expected: false},
+		{name: "approved environment", approved: []string{"staging", "production"}, environment: "production", expected: true},
+		{name: "not approved environment", approved: []string{"staging"}, environment: "production", expected: false},
+		{name: "all environments", approved: []string{"*"}, environment: "production", expected: true},
+		{name: "pattern", approved: []string{"review-*"}, environment: "review-42", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isApprovedEnvironment(tt.approved, tt.environment); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReadApproval(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+		wantErr  bool
+	}{
+		{name: "yes", input: "y\n", expected: true},
+		{name: "yes with spaces", input: "  YES \n", expected: true},
+		{name: "no", input: "n\n", expected: false},
+		{name: "default", input: "\n", expected: false},
+		{name: "closed input", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approved, err := readApproval(bufio.NewReader(strings.NewReader(tt.input)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readApproval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if approved != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, approved)
+			}
+		})
+	}
+}
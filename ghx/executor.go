@@ -1,11 +1,45 @@
-package main
+package ghx
 
 import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/aweris/gale/common/log"
 	"github.com/aweris/gale/ghx/context"
 )
 
+// stepLogFile is the name of the file in the step run directory keeping the output of the step.
+const stepLogFile = "step.log"
+
 // Executor is the interface that defines contract for objects capable of performing an execution task.
 type Executor interface {
 	// Execute performs the execution of a specific task with the given context.
 	Execute(ctx *context.Context) error
 }
+
+// openStepLog opens the log file of the current step in append mode, pre, main and post stages of the step share the
+// same log file. Failing to open the log file doesn't fail the step, the output is discarded instead. Lines written to
+// the log are streamed as log events as well if the events socket is configured.
+func openStepLog(ctx *context.Context) io.WriteCloser {
+	dir, err := ctx.GetStepRunPath()
+	if err != nil {
+		log.Debugf("failed to get step run path", "error", err)
+		return ctx.WithLogEvents(nopWriteCloser{io.Discard})
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, stepLogFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Debugf("failed to open step log", "error", err)
+		return ctx.WithLogEvents(nopWriteCloser{io.Discard})
+	}
+
+	return ctx.WithLogEvents(file)
+}
+
+// nopWriteCloser is a writer with a no-op Close method.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
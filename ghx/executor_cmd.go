@@ -1,16 +1,17 @@
-package main
+package ghx
 
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/aweris/gale/common/log"
 	"github.com/aweris/gale/ghx/context"
-	"github.com/aweris/gale/ghx/expression"
 )
 
 var _ Executor = new(CmdExecutor)
@@ -21,9 +22,11 @@ type CmdExecutor struct {
 
 }
 
-func NewCmdExecutorFromStepAction(sa *StepAction, entrypoint string) *CmdExecutor {
+// NewCmdExecutorFromStepAction creates a new command executor running the entrypoint of the javascript action with the
+// given node binary.
+func NewCmdExecutorFromStepAction(sa *StepAction, node, entrypoint string) *CmdExecutor {
 	return &CmdExecutor{
-		args: []string{"node", fmt.Sprintf("%s/%s", sa.Action.Path, entrypoint)},
+		args: []string{node, fmt.Sprintf("%s/%s", sa.Action.Path, entrypoint)},
 		cp:   NewCommandProcessor(),
 	}
 }
@@ -36,13 +39,21 @@ func NewCmdExecutorFromStepRun(sr *StepRun) *CmdExecutor {
 }
 
 func (c *CmdExecutor) Execute(ctx *context.Context) error {
-	// variable provider for the container executor. It returns expression.VariableProvider for the container executor
-	// based on step being executed. If the step is an action, it returns a variable provider that contains the inputs
-	// of the action. Otherwise, it returns the main context as the variable provider.
-	vp := ctx.GetVariableProvider()
+	var (
+		args   = c.args
+		job    string
+		limits context.Limits
+	)
+
+	// apply resource limits of the job if any
+	if ctx.Execution.JobRun != nil {
+		job = ctx.Execution.JobRun.Job.ID
+		limits = ctx.GhxConfig.Limits[job]
+		args = withCPULimit(job, limits, args)
+	}
 
 	//nolint:gosec // this is a command executor, we need to execute the command as it is
-	cmd := exec.Command(c.args[0], c.args[1:]...)
+	cmd := exec.CommandContext(ctx.Context, args[0], args[1:]...)
 
 	envMap := make(map[string]string)
 
@@ -66,22 +77,20 @@ func (c *CmdExecutor) Execute(ctx *context.Context) error {
 
 	// add environment variables
 
+	// identify the step and the action like GitHub does, composite actions use the action path to access their own
+	// files
+	for k, v := range ctx.ActionEnv() {
+		envMap[k] = v
+	}
+
 	if ctx.Execution.CurrentAction != nil {
-		for k, v := range ctx.Execution.StepRun.Step.With {
-			envMap[fmt.Sprintf("INPUT_%s", strings.ToUpper(k))] = v
+		inputs, err := ctx.ActionInputs()
+		if err != nil {
+			return err
 		}
 
-		// add default values for inputs that are not defined in the step config
-		for k, v := range ctx.Execution.CurrentAction.Meta.Inputs {
-			if _, ok := ctx.Execution.StepRun.Step.With[k]; ok {
-				continue
-			}
-
-			if v.Default == "" {
-				continue
-			}
-
-			envMap[fmt.Sprintf("INPUT_%s", strings.ToUpper(k))] = v.Default
+		for k, v := range withGithubScriptShims(ctx, inputs) {
+			envMap[context.InputEnvName(k)] = v
 		}
 	}
 
@@ -95,18 +104,26 @@ func (c *CmdExecutor) Execute(ctx *context.Context) error {
 		envMap[k] = v
 	}
 
-	env := os.Environ()
+	// network mode of the job overrides the proxy configuration of the runner and the steps
+	network, err := ctx.NetworkEnv()
+	if err != nil {
+		return err
+	}
 
-	for k, v := range envMap {
-		// convert value to Evaluable String type
-		str := expression.NewString(v)
+	for k, v := range network {
+		envMap[k] = v
+	}
 
-		// evaluate the expression
-		res := str.Eval(vp)
+	env := os.Environ()
 
-		log.Debugf("Environment variable evaluated", "key", k, "value", v, "evaluated", res)
+	// env context is evaluated when the workflow, the job and the step are set, values are passed as they are
+	for k, v := range envMap {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
 
-		env = append(env, fmt.Sprintf("%s=%s", k, res))
+	// paths added by the previous steps of the job are prepended to the PATH
+	if jr := ctx.Execution.JobRun; jr != nil && len(jr.Path) > 0 {
+		env = append(env, fmt.Sprintf("PATH=%s", withJobPath(jr.Path, os.Getenv("PATH"))))
 	}
 
 	cmd.Env = env
@@ -116,27 +133,78 @@ func (c *CmdExecutor) Execute(ctx *context.Context) error {
 		return err
 	}
 
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
 	err = cmd.Start()
 	if err != nil {
 		return err
 	}
 
-	go func() {
-		scanner := bufio.NewScanner(stdoutPipe)
+	applyMemoryLimit(job, limits, cmd.Process.Pid)
+
+	var (
+		stepLog = openStepLog(ctx)
+		mu      sync.Mutex // mu serializes the streams since they share the step log and the context
+		wg      sync.WaitGroup
+	)
+
+	process := func(stream io.Reader) {
+		defer wg.Done()
+
+		scanner := bufio.NewScanner(stream)
 		for scanner.Scan() {
 			output := scanner.Text()
 
+			mu.Lock()
+
+			// workflow commands are kept in the step log as they are
+			fmt.Fprintln(stepLog, output)
+
 			if err := c.cp.ProcessOutput(ctx, output); err != nil {
 				log.Errorf("failed to process output", "output", output, "error", err)
 			}
+
+			mu.Unlock()
 		}
-	}()
+	}
+
+	wg.Add(2)
+
+	go process(stdoutPipe)
+	go process(stderrPipe)
+
+	// streams must be consumed before waiting for the command, since wait closes the pipes and drops the output not
+	// read yet, e.g. the workflow commands written right before the command exits
+	wg.Wait()
+	stepLog.Close()
 
 	waitErr := cmd.Wait()
 
+	// save the state of the failed command to open a debug shell in the same state
+	if waitErr != nil && ctx.GhxConfig.DebugShell {
+		if err := saveDebugEnv(ctx, cmd.Dir, env); err != nil {
+			log.Errorf("failed to save debug shell environment", "error", err)
+		}
+	}
+
 	if err := efs.Process(ctx); err != nil {
 		return err
 	}
 
 	return waitErr
 }
+
+// withJobPath returns the PATH with the paths added by the steps of the job. Like GitHub, each added path is prepended
+// to the PATH, so the last added path has the highest precedence.
+func withJobPath(paths []string, path string) string {
+	items := make([]string, 0, len(paths)+1)
+
+	for i := len(paths) - 1; i >= 0; i-- {
+		items = append(items, paths[i])
+	}
+
+	return strings.Join(append(items, path), ":")
+}
@@ -0,0 +1,112 @@
+package ghx
+
+import (
+	stdContext "context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// newTestContext returns a context with a job and a step set to execute commands.
+func newTestContext(t *testing.T, jr *core.JobRun) *context.Context {
+	t.Helper()
+
+	sr := &core.StepRun{
+		Step:        core.Step{ID: "test"},
+		Environment: make(map[string]string),
+		Outputs:     make(map[string]string),
+		State:       make(map[string]string),
+	}
+
+	return &context.Context{
+		Context:   stdContext.Background(),
+		GhxConfig: context.GhxConfig{HomeDir: t.TempDir()},
+		Runner:    context.RunnerContext{Temp: t.TempDir()},
+		Steps:     make(context.StepsContext),
+		Execution: context.ExecutionContext{
+			WorkflowRun: &core.WorkflowRun{RunID: "1"},
+			JobRun:      jr,
+			StepRun:     sr,
+		},
+	}
+}
+
+func TestCmdExecutor_OutputAtExit(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{name: "last line", script: `echo "::set-output name=result::done"`},
+		{name: "after a large output", script: `i=0; while [ $i -lt 5000 ]; do echo "line $i"; i=$((i+1)); done; echo "::set-output name=result::done"`},
+		{name: "on stderr", script: `echo "line" ; echo "::set-output name=result::done" >&2`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, &core.JobRun{RunID: "1", Job: core.Job{ID: "build"}})
+			sr := ctx.Execution.StepRun
+
+			executor := &CmdExecutor{args: []string{"sh", "-c", tt.script}, cp: NewCommandProcessor()}
+
+			if err := executor.Execute(ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := sr.Outputs["result"]; got != "done" {
+				t.Errorf("expected the workflow command written at exit to be processed, got outputs %s", fmt.Sprint(sr.Outputs))
+			}
+		})
+	}
+}
+
+func TestCmdExecutor_JobPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		paths  []string
+		prefix string
+	}{
+		{name: "no added paths", prefix: os.Getenv("PATH")},
+		{name: "added paths are prepended", paths: []string{"/opt/first", "/opt/second"}, prefix: "/opt/second:/opt/first:" + os.Getenv("PATH")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, &core.JobRun{RunID: "1", Job: core.Job{ID: "build"}, Path: tt.paths})
+
+			executor := &CmdExecutor{args: []string{"sh", "-c", `echo "::set-output name=path::$PATH"`}, cp: NewCommandProcessor()}
+
+			if err := executor.Execute(ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := ctx.Execution.StepRun.Outputs["path"]; !strings.HasPrefix(got, tt.prefix) {
+				t.Errorf("expected PATH to start with %s, got %s", tt.prefix, got)
+			}
+		})
+	}
+}
+
+func TestWithJobPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		paths    []string
+		path     string
+		expected string
+	}{
+		{name: "no added paths", path: "/usr/bin", expected: "/usr/bin"},
+		{name: "last added path has precedence", paths: []string{"/opt/a", "/opt/b"}, path: "/usr/bin", expected: "/opt/b:/opt/a:/usr/bin"},
+		{name: "expanded by the container", paths: []string{"/opt/a"}, path: "${PATH}", expected: "/opt/a:${PATH}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withJobPath(tt.paths, tt.path); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -1,10 +1,12 @@
-package main
+package ghx
 
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"dagger.io/dagger"
 
@@ -41,13 +43,17 @@ func NewContainerExecutorFromStepAction(sa *StepAction, entrypoint string) *Cont
 }
 
 func (c *ContainerExecutor) Execute(ctx *context.Context) error {
-	// variable provider for the container executor. It returns expression.VariableProvider for the container executor
-	// based on step being executed. If the step is an action, it returns a variable provider that contains the inputs
-	// of the action. Otherwise, it returns the main context as the variable provider.
-	vp := ctx.GetVariableProvider()
+	// pull the image before the execution to report the time spent pulling the image separately from the execution
+	pullStartedAt := time.Now()
+
+	if _, err := c.container.Sync(ctx.Context); err != nil {
+		return err
+	}
+
+	if ctx.Execution.StepRun != nil {
+		ctx.Execution.StepRun.PullDuration = time.Since(pullStartedAt)
+	}
 
-	// load environment files - this will create env files and load it to the environment. That's why we need to do this
-	// before setting the environment variables
 	// load environment files - this will create env files and load it to the environment. That's why we need to do this
 	// before setting the environment variables
 	dir, efs := NewDaggerEnvironmentFiles(filepath.Join(ctx.Runner.Temp, "env_files"), ctx.Dagger.Client)
@@ -83,13 +89,28 @@ func (c *ContainerExecutor) Execute(ctx *context.Context) error {
 		str := expression.NewString(arg)
 
 		// evaluate the expression
-		res := str.Eval(vp)
+		res := str.Eval(ctx)
 
 		log.Debugf("arg evaluated", "original", arg, "evaluated", res)
 
 		args = append(args, strings.Split(res, " ")...) // TODO: this is not correct, we need to parse the string as shell does
 	}
 
+	env, err := containerEnv(ctx)
+	if err != nil {
+		return err
+	}
+
+	// env is set before the exec, otherwise the exec doesn't see it
+	for k, v := range env {
+		c.container = c.container.WithEnvVariable(k, v)
+	}
+
+	// paths added by the previous steps of the job are prepended to the PATH of the image
+	if jr := ctx.Execution.JobRun; jr != nil && len(jr.Path) > 0 {
+		c.container = c.container.WithEnvVariable("PATH", withJobPath(jr.Path, "${PATH}"), dagger.ContainerWithEnvVariableOpts{Expand: true})
+	}
+
 	if entrypoint != "" {
 		c.container = c.container.WithEntrypoint([]string{entrypoint})
 	}
@@ -98,31 +119,65 @@ func (c *ContainerExecutor) Execute(ctx *context.Context) error {
 		c.container = c.container.WithExec(args, dagger.ContainerWithExecOpts{ExperimentalPrivilegedNesting: true})
 	}
 
+	// TODO: if no args are provided, we need to execute the container with the default entrypoint and args
+	//  however this is causing an error since Stdout is looking for last execs output. We need to find a way to
+	//  execute the container without execs and get the output.
+	out, err := c.container.Stdout(ctx.Context)
+	if err != nil {
+		return err
+	}
+
+	stepLog := openStepLog(ctx)
+	defer stepLog.Close()
+
+	// stderr is appended to the step log after stdout since the streams of the container can't be interleaved
+	errOut, err := c.container.Stderr(ctx.Context)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(stepLog, out+errOut); err != nil {
+		log.Debugf("failed to write step log", "error", err)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		output := scanner.Text()
+
+		if err := c.cp.ProcessOutput(ctx, output); err != nil {
+			log.Errorf("failed to process output", "output", output, "error", err)
+		}
+	}
+
+	return efs.Process(ctx)
+}
+
+// containerEnv returns the env of the container step, the env of the action, the inputs, the state of the step and the
+// env context.
+func containerEnv(ctx *context.Context) (map[string]string, error) {
 	env := make(map[string]string)
 
 	if ctx.Execution.CurrentAction != nil {
-		for k, v := range ctx.Execution.CurrentAction.Meta.Runs.Env {
+		// action env could refer to the inputs of the action
+		for k, v := range ctx.EvalEnv(ctx.Execution.CurrentAction.Meta.Runs.Env) {
 			env[k] = v
 		}
 
-		for k, v := range ctx.Execution.StepRun.Step.With {
-			env[fmt.Sprintf("INPUT_%s", strings.ToUpper(k))] = v
+		inputs, err := ctx.ActionInputs()
+		if err != nil {
+			return nil, err
 		}
 
-		// add default values for inputs that are not defined in the step config
-		for k, v := range ctx.Execution.CurrentAction.Meta.Inputs {
-			if _, ok := ctx.Execution.StepRun.Step.With[k]; ok {
-				continue
-			}
-
-			if v.Default == "" {
-				continue
-			}
-
-			env[fmt.Sprintf("INPUT_%s", strings.ToUpper(k))] = v.Default
+		for k, v := range inputs {
+			env[context.InputEnvName(k)] = v
 		}
 	}
 
+	// identify the step and the action like GitHub does
+	for k, v := range ctx.ActionEnv() {
+		env[k] = v
+	}
+
 	// add step state to the environment
 	for k, v := range ctx.Steps[ctx.Execution.StepRun.Step.ID].State {
 		env[fmt.Sprintf("STATE_%s", k)] = v
@@ -133,26 +188,5 @@ func (c *ContainerExecutor) Execute(ctx *context.Context) error {
 		env[k] = v
 	}
 
-	for k, v := range env {
-		c.container = c.container.WithEnvVariable(k, v)
-	}
-
-	// TODO: if no args are provided, we need to execute the container with the default entrypoint and args
-	//  however this is causing an error since Stdout is looking for last execs output. We need to find a way to
-	//  execute the container without execs and get the output.
-	out, err := c.container.Stdout(ctx.Context)
-	if err != nil {
-		return err
-	}
-
-	scanner := bufio.NewScanner(strings.NewReader(out))
-	for scanner.Scan() {
-		output := scanner.Text()
-
-		if err := c.cp.ProcessOutput(ctx, output); err != nil {
-			log.Errorf("failed to process output", "output", output, "error", err)
-		}
-	}
-
-	return efs.Process(ctx)
+	return env, nil
 }
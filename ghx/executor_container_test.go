@@ -0,0 +1,38 @@
+package ghx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestContainerEnv(t *testing.T) {
+	ctx := newTestContext(t, &core.JobRun{RunID: "1", Job: core.Job{ID: "build"}, Environment: map[string]string{"EXPORTED": "1"}})
+
+	ctx.Env = context.EnvContext{"EXPORTED": "1", "JOB": "build"}
+	ctx.Steps["test"] = context.StepContext{State: map[string]string{"pid": "42"}}
+	ctx.Github.Action = "test"
+
+	env, err := containerEnv(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"EXPORTED":      "1",
+		"JOB":           "build",
+		"STATE_pid":     "42",
+		"GITHUB_ACTION": "test",
+	}
+
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("expected %v, got %v", expected, env)
+	}
+
+	// PATH is expanded by the container to keep the PATH of the image
+	if _, ok := env["PATH"]; ok {
+		t.Errorf("expected PATH to be set by the executor, got %s", env["PATH"])
+	}
+}
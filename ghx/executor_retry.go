@@ -0,0 +1,85 @@
+package ghx
+
+import (
+	stdContext "context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+)
+
+var _ Executor = new(RetryExecutor)
+
+// RetryExecutor executes the wrapped executor again according to the retry policy of the step when it fails.
+type RetryExecutor struct {
+	executor Executor            // executor is the executor to retry
+	policy   context.RetryPolicy // policy is the retry policy of the step
+}
+
+// withRetries wraps the executor with a retry executor if the current step has a retry policy. Otherwise, it returns
+// the executor as it is.
+func withRetries(ctx *context.Context, executor Executor) Executor {
+	if ctx.Execution.JobRun == nil || ctx.Execution.StepRun == nil {
+		return executor
+	}
+
+	policy, ok := ctx.GhxConfig.Retries.Policy(ctx.Execution.JobRun.Job.ID, ctx.Execution.StepRun.Step)
+	if !ok {
+		return executor
+	}
+
+	return &RetryExecutor{executor: executor, policy: policy}
+}
+
+func (r *RetryExecutor) Execute(ctx *context.Context) error {
+	var (
+		err       error
+		condition string
+		backoff   = r.policy.Backoff
+		parent    = ctx.Context
+	)
+
+	// restore the original context after the attempts with timeout
+	defer func() {
+		ctx.Context = parent
+	}()
+
+	for attempt := 1; attempt <= r.policy.Max; attempt++ {
+		ctx.Execution.StepRun.Attempts = attempt
+
+		condition, err = r.attempt(ctx, parent)
+		if err == nil || attempt == r.policy.Max || !r.policy.RetryOn(condition) {
+			return err
+		}
+
+		log.Warnf("Step attempt failed, retrying", "attempt", attempt, "max", r.policy.Max, "backoff", backoff, "error", err)
+
+		time.Sleep(backoff)
+
+		// double the backoff for the next attempt
+		backoff *= 2
+	}
+
+	return err
+}
+
+// attempt executes the wrapped executor once and returns the retry condition matching the failure.
+func (r *RetryExecutor) attempt(ctx *context.Context, parent stdContext.Context) (string, error) {
+	if r.policy.Timeout == 0 {
+		return context.RetryOnFailure, r.executor.Execute(ctx)
+	}
+
+	attemptCtx, cancel := stdContext.WithTimeout(parent, r.policy.Timeout)
+	defer cancel()
+
+	ctx.Context = attemptCtx
+
+	err := r.executor.Execute(ctx)
+	if err != nil && errors.Is(attemptCtx.Err(), stdContext.DeadlineExceeded) {
+		return context.RetryOnTimeout, fmt.Errorf("attempt timed out after %s: %w", r.policy.Timeout, err)
+	}
+
+	return context.RetryOnFailure, err
+}
@@ -18,6 +18,13 @@ type VariableProvider interface {
 	GetVariable(name string) (interface{}, error)
 }
 
+// WorkspaceProvider is an optional interface of the variable providers to provide the workspace directory. hashFiles()
+// matches the files relative to the workspace, or to the working directory if the provider doesn't implement it.
+type WorkspaceProvider interface {
+	// Workspace returns the path of the workspace directory.
+	Workspace() string
+}
+
 // Interpreter is an interface to evaluate expression.
 type Interpreter interface {
 	// Evaluate evaluates the expression and returns the result.
@@ -37,6 +44,21 @@ type Expression struct {
 // The method assumes that the string contains an expression. If the string omits the expression syntax (${{ }}). It
 // will be added to the string automatically and parsed.
 func NewExpression(value string) (*Expression, error) {
+	value, node, err := parseExpression(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Expression{
+		Value:       value,
+		StartIndex:  0,
+		EndIndex:    len(value) - 1,
+		interpreter: getInterpreterFromNode(node),
+	}, nil
+}
+
+// parseExpression parses the expression and returns the expression with the expression syntax (${{ }}) and its node.
+func parseExpression(value string) (string, actionlint.ExprNode, error) {
 	matches := exprRe.FindStringSubmatch(value)
 
 	// If there are no matches, then the string omits the expression syntax (${{ }}). This is valid for if conditionals.
@@ -54,15 +76,51 @@ func NewExpression(value string) (*Expression, error) {
 
 	node, err := parser.Parse(lexer)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse expression: %w", err)
+		return "", nil, fmt.Errorf("failed to parse expression: %w", err)
 	}
 
-	return &Expression{
-		Value:       value,
-		StartIndex:  0,
-		EndIndex:    len(value) - 1,
-		interpreter: getInterpreterFromNode(node),
-	}, nil
+	return value, node, nil
+}
+
+// WithStatusCheck returns the condition with the implicit status check of GitHub applied. Empty conditions are
+// success() and conditions without a status check function, success(), failure(), cancelled() or always(), are
+// combined with success(), e.g. github.ref == 'refs/heads/main' is evaluated as success() && (github.ref ==
+// 'refs/heads/main'). Invalid conditions are returned as they are, so the parse errors are reported on evaluation.
+//
+// See: https://docs.github.com/en/actions/learn-github-actions/expressions#status-check-functions
+func WithStatusCheck(condition string) string {
+	condition = strings.TrimSpace(condition)
+
+	if condition == "" {
+		return "success()"
+	}
+
+	_, node, err := parseExpression(condition)
+	if err != nil {
+		return condition
+	}
+
+	found := false
+
+	actionlint.VisitExprNode(node, func(n, _ actionlint.ExprNode, entering bool) {
+		if call, ok := n.(*actionlint.FuncCallNode); ok && entering {
+			switch strings.ToLower(call.Callee) {
+			case "success", "failure", "cancelled", "always":
+				found = true
+			}
+		}
+	})
+
+	if found {
+		return condition
+	}
+
+	// conditions could be wrapped with the expression syntax as a whole, e.g. ${{ github.event_name == 'push' }}
+	if loc := exprRe.FindStringIndex(condition); loc != nil && loc[0] == 0 && loc[1] == len(condition) {
+		condition = strings.TrimSpace(condition[3 : len(condition)-2])
+	}
+
+	return fmt.Sprintf("success() && (%s)", condition)
 }
 
 // ParseExpressions parses a string and returns a slice of Expressions with their start and end indexes in input string.
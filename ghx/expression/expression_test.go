@@ -674,7 +674,7 @@ func TestExpression_EvaluateHashFunc(t *testing.T) {
 		{
 			name:     "Hash of existing files matching pattern",
 			input:    `hashFiles('./testdata/file-*.txt')`,
-			expected: "7f83b1657ff1fc53b92dc18148a1d65dfc2d4b1fa3d677284addd200126d9069",
+			expected: "61f417374f4400b47dcae1a8f402d4f4dacf455a0442a06aa455a447b0d4e170",
 			files: []struct {
 				path    string
 				name    string
@@ -686,7 +686,7 @@ func TestExpression_EvaluateHashFunc(t *testing.T) {
 		{
 			name:     "multiple files matching",
 			input:    `hashFiles('./testdata/file-*.txt')`,
-			expected: "7f83b1657ff1fc53b92dc18148a1d65dfc2d4b1fa3d677284addd200126d9069b86691d0513ea10d252be103dba3459b45f4d4bb3aa5b07bd5460f5ef0357fc8",
+			expected: "95cdbcdd8d42ffa23e01603f1bf83cdeefde710fc7f51bb2dfbef90bd2a1b60d",
 			files: []struct {
 				path    string
 				name    string
@@ -699,7 +699,7 @@ func TestExpression_EvaluateHashFunc(t *testing.T) {
 		{
 			name:     "Hash of nested directory",
 			input:    `hashFiles('./testdata/nested/**/file-*.txt')`,
-			expected: "14d53b936bfc2c45b26e84f74742f32f1449bab0ee83f6bb3410ef87b3a4d4beb86691d0513ea10d252be103dba3459b45f4d4bb3aa5b07bd5460f5ef0357fc8",
+			expected: "151a6655041a9373414353d553d2cd49cf54ff8449b2cf02e0c9c3c66d982451",
 			files: []struct {
 				path    string
 				name    string
@@ -710,6 +710,32 @@ func TestExpression_EvaluateHashFunc(t *testing.T) {
 				{path: "nested/bar", name: "file-3.txt", content: []byte("Bar Foo!")},
 			},
 		},
+		{
+			name:     "Excluded files are not hashed",
+			input:    `hashFiles('testdata/**', '!testdata/**/file-2.txt')`,
+			expected: "61f417374f4400b47dcae1a8f402d4f4dacf455a0442a06aa455a447b0d4e170",
+			files: []struct {
+				path    string
+				name    string
+				content []byte
+			}{
+				{name: "file-1.txt", content: []byte("Hello World!")},
+				{path: "nested", name: "file-2.txt", content: []byte("Foo Bar!")},
+			},
+		},
+		{
+			name:     "Multiple patterns match the same files once",
+			input:    `hashFiles('testdata/file-1.txt', 'testdata/*.txt')`,
+			expected: "95cdbcdd8d42ffa23e01603f1bf83cdeefde710fc7f51bb2dfbef90bd2a1b60d",
+			files: []struct {
+				path    string
+				name    string
+				content []byte
+			}{
+				{name: "file-1.txt", content: []byte("Hello World!")},
+				{name: "file-2.txt", content: []byte("Foo Bar!")},
+			},
+		},
 		{
 			name:     "Hash of non-existing file",
 			input:    `hashFiles('./testdata/non-extant-file.txt')`,
@@ -765,6 +791,54 @@ func TestExpression_EvaluateHashFunc(t *testing.T) {
 
 // TODO: find a better way. Currently tests are relying on static values. It's not maintainable for long term.
 
+// workspaceProvider is a variable provider with a workspace directory.
+type workspaceProvider struct {
+	TestVariableProvider
+	workspace string
+}
+
+func (p *workspaceProvider) Workspace() string {
+	return p.workspace
+}
+
+func TestExpression_EvaluateHashFuncInWorkspace(t *testing.T) {
+	var (
+		parent    = t.TempDir()
+		workspace = filepath.Join(parent, "workspace")
+	)
+
+	for path, content := range map[string]string{
+		filepath.Join(workspace, "go.sum"):            "Hello World!",
+		filepath.Join(workspace, "module", "go.sum"):  "Foo Bar!",
+		filepath.Join(parent, "outside", "go.sum"):    "Bar Foo!",
+		filepath.Join(workspace, "module", "go.mod"):  "module foo",
+		filepath.Join(workspace, "module", "go.sum2"): "Foo Bar!",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Expected no error, but got %s", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Expected no error, but got %s", err)
+		}
+	}
+
+	expr, err := NewExpression(fmt.Sprintf("hashFiles('**/go.sum', '%s/outside/go.sum')", parent))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err)
+	}
+
+	result, err := expr.Evaluate(&workspaceProvider{workspace: workspace})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %s", err)
+	}
+
+	// only go.sum files in the workspace are hashed, the file outside of the workspace is ignored
+	if expected := "95cdbcdd8d42ffa23e01603f1bf83cdeefde710fc7f51bb2dfbef90bd2a1b60d"; result != expected {
+		t.Errorf("Expected %v, but got %v", expected, result)
+	}
+}
+
 type TestVariableProvider struct{}
 
 func (p *TestVariableProvider) GetVariable(name string) (interface{}, error) {
@@ -791,3 +865,28 @@ func (p *TestVariableProvider) GetVariable(name string) (interface{}, error) {
 
 	return nil, fmt.Errorf("variable %s not found", name)
 }
+
+func TestWithStatusCheck(t *testing.T) {
+	tests := []struct {
+		condition string
+		expected  string
+	}{
+		{condition: "", expected: "success()"},
+		{condition: "always()", expected: "always()"},
+		{condition: "failure() && steps.test.outcome == 'failure'", expected: "failure() && steps.test.outcome == 'failure'"},
+		{condition: "${{ !cancelled() }}", expected: "${{ !cancelled() }}"},
+		{condition: "Success()", expected: "Success()"},
+		{condition: "github.ref == 'refs/heads/main'", expected: "success() && (github.ref == 'refs/heads/main')"},
+		{condition: "${{ github.event_name == 'push' }}", expected: "success() && (github.event_name == 'push')"},
+		{condition: "contains(github.ref, 'success()')", expected: "success() && (contains(github.ref, 'success()'))"},
+		{condition: "${{ invalid expression }", expected: "${{ invalid expression }"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			if got := WithStatusCheck(tt.condition); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
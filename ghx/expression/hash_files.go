@@ -0,0 +1,180 @@
+package expression
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/aweris/gale/common/fs"
+)
+
+// hashPattern is a pattern of hashFiles() resolved to an absolute path.
+type hashPattern struct {
+	pattern  string         // pattern is the pattern as it's given
+	negative bool           // negative is true if the pattern excludes the matching files
+	base     string         // base is the directory to search the files in, the path up to the first glob segment
+	re       *regexp.Regexp // re is the regular expression matching the absolute paths
+}
+
+// hashFiles returns a single hash for the files matching the given patterns in the workspace like GitHub does. Each
+// argument could contain multiple patterns separated by new lines, and patterns starting with ! exclude the files
+// matched by the previous patterns.
+//
+// The hash is the SHA-256 of the SHA-256 digests of the matched files, in the order of the files found by searching
+// the patterns. Files outside the workspace are ignored. If no file matches, it returns an empty string.
+//
+// See: https://docs.github.com/en/actions/learn-github-actions/expressions#hashfiles
+func hashFiles(provider VariableProvider, args ...reflect.Value) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("hashFiles() requires at least one argument")
+	}
+
+	root, err := hashFilesRoot(provider)
+	if err != nil {
+		return "", err
+	}
+
+	var patterns []hashPattern
+
+	for _, arg := range args {
+		for _, line := range strings.Split(arg.String(), "\n") {
+			line = strings.TrimSpace(line)
+
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			patterns = append(patterns, newHashPattern(root, line))
+		}
+	}
+
+	var (
+		hash = sha256.New()
+		seen = make(map[string]bool)
+	)
+
+	for _, pattern := range patterns {
+		if pattern.negative || !isInDir(root, pattern.base) {
+			continue
+		}
+
+		err := filepath.WalkDir(pattern.base, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				// search paths of the patterns not matching any file don't exist
+				if errors.Is(err, os.ErrNotExist) {
+					return nil
+				}
+
+				return err
+			}
+
+			if d.IsDir() || seen[path] || !isInDir(root, path) || !matchHashPatterns(patterns, path) {
+				return nil
+			}
+
+			seen[path] = true
+
+			digest, err := fileDigest(path)
+			if err != nil {
+				return err
+			}
+
+			hash.Write(digest)
+
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("hashFiles('%s') failed: %w", pattern.pattern, err)
+		}
+	}
+
+	if len(seen) == 0 {
+		return "", nil
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// hashFilesRoot returns the absolute path of the directory to match the patterns in, the workspace if the provider
+// knows it, otherwise the working directory.
+func hashFilesRoot(provider VariableProvider) (string, error) {
+	if wp, ok := provider.(WorkspaceProvider); ok && wp.Workspace() != "" {
+		return filepath.Abs(wp.Workspace())
+	}
+
+	return os.Getwd()
+}
+
+// newHashPattern resolves the pattern relative to the root directory.
+func newHashPattern(root, raw string) hashPattern {
+	negative := strings.HasPrefix(raw, "!")
+
+	pattern := strings.TrimPrefix(raw, "!")
+
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(root, pattern)
+	}
+
+	pattern = filepath.Clean(pattern)
+
+	// files are searched from the last directory without glob characters, not to walk the whole file system
+	var base []string
+
+	for _, segment := range strings.Split(pattern, string(filepath.Separator)) {
+		if strings.ContainsAny(segment, "*?") {
+			break
+		}
+
+		base = append(base, segment)
+	}
+
+	return hashPattern{
+		pattern:  raw,
+		negative: negative,
+		base:     strings.Join(base, string(filepath.Separator)),
+		re:       fs.GlobRegexp(filepath.ToSlash(pattern)),
+	}
+}
+
+// matchHashPatterns returns true if the path matches the patterns. Patterns are evaluated in order and the last
+// matching pattern wins.
+func matchHashPatterns(patterns []hashPattern, path string) bool {
+	matched := false
+
+	for _, pattern := range patterns {
+		if pattern.re.MatchString(filepath.ToSlash(path)) {
+			matched = !pattern.negative
+		}
+	}
+
+	return matched
+}
+
+// isInDir returns true if the path is the directory itself or inside the directory.
+func isInDir(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// fileDigest returns the SHA-256 digest of the file.
+func fileDigest(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
+
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+
+	return hash.Sum(nil), nil
+}
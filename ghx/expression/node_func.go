@@ -1,11 +1,8 @@
 package expression
 
 import (
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
 	"reflect"
 	"strings"
 
@@ -50,7 +47,7 @@ func (n FuncCallNode) Evaluate(provider VariableProvider) (interface{}, error) {
 	case "fromjson":
 		return fromJSON(args...)
 	case "hashfiles":
-		return hashFiles(args...)
+		return hashFiles(provider, args...)
 	case "success":
 		return success(provider)
 	case "failure":
@@ -200,41 +197,6 @@ func fromJSON(args ...reflect.Value) (interface{}, error) {
 	return value, nil
 }
 
-// TODO: double check this how GHA handles this. This is just a some hacky implementation to get it working
-
-func hashFiles(args ...reflect.Value) (string, error) {
-	if len(args) < 1 {
-		return "", fmt.Errorf("hashFiles() requires at least one argument")
-	}
-
-	paths := make([]string, 0, len(args))
-
-	for _, arg := range args {
-		paths = append(paths, arg.String())
-	}
-
-	var fileHashes []string
-
-	for _, path := range paths {
-		matches, err := filepath.Glob(path)
-		if err != nil {
-			return "", err
-		}
-
-		for _, match := range matches {
-			fileBytes, err := os.ReadFile(match)
-			if err != nil {
-				return "", err
-			}
-
-			hash := sha256.Sum256(fileBytes)
-			fileHashes = append(fileHashes, fmt.Sprintf("%x", hash))
-		}
-	}
-
-	return strings.Join(fileHashes, ""), nil
-}
-
 func always() bool {
 	return true
 }
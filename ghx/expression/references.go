@@ -0,0 +1,62 @@
+package expression
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/rhysd/actionlint"
+)
+
+// References returns the context properties referenced by the expression, e.g. github.ref or steps.build.outputs.id,
+// sorted and without duplicates. References are the longest property chains, so they could be evaluated as
+// expressions on their own to explain the result of the expression. Invalid expressions have no references.
+func References(expr string) []string {
+	_, node, err := parseExpression(expr)
+	if err != nil {
+		return nil
+	}
+
+	found := make(map[string]bool)
+
+	actionlint.VisitExprNode(node, func(n, parent actionlint.ExprNode, entering bool) {
+		if !entering {
+			return
+		}
+
+		// only the outermost node of a property chain is the reference, inner nodes are part of it
+		if _, ok := parent.(*actionlint.ObjectDerefNode); ok {
+			return
+		}
+
+		if ref, ok := referenceOf(n); ok {
+			found[ref] = true
+		}
+	})
+
+	refs := make([]string, 0, len(found))
+
+	for ref := range found {
+		refs = append(refs, ref)
+	}
+
+	sort.Strings(refs)
+
+	return refs
+}
+
+// referenceOf returns the property chain of the node if the node is a variable or a property dereference of one.
+func referenceOf(node actionlint.ExprNode) (string, bool) {
+	switch n := node.(type) {
+	case *actionlint.VariableNode:
+		return strings.ToLower(n.Name), true
+	case *actionlint.ObjectDerefNode:
+		receiver, ok := referenceOf(n.Receiver)
+		if !ok {
+			return "", false
+		}
+
+		return receiver + "." + n.Property, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,29 @@
+package expression
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []string
+	}{
+		{expr: "github.ref == 'refs/heads/main'", expected: []string{"github.ref"}},
+		{expr: "${{ steps.build.outputs.version != '' && env.DEPLOY }}", expected: []string{"env.deploy", "steps.build.outputs.version"}},
+		{expr: "contains(github.event.pull_request.labels.*.name, 'ok')", expected: []string{"github.event.pull_request.labels"}},
+		{expr: "matrix['os'] == 'linux' || matrix.os == 'linux'", expected: []string{"matrix", "matrix.os"}},
+		{expr: "github.ref == github.ref", expected: []string{"github.ref"}},
+		{expr: "success() && true", expected: []string{}},
+		{expr: "github.ref ==", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := References(tt.expr); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("References(%q) = %v, expected %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package ghx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// Fingerprint returns the fingerprint of the inputs of the workflow run not visible to the engine cache, the commit
+// SHAs resolved from the refs of the remote actions and the secrets. Gale passes the fingerprint to the workflow run,
+// so the engine reuses the result of a previous run only if none of them changed. The repository source and the
+// workflow are mounted to the workflow run, so they're already part of the cache key.
+func Fingerprint(ctx *context.Context, wf core.Workflow) (string, error) {
+	shas, err := resolveActionSHAs(ctx, wf)
+	if err != nil {
+		return "", err
+	}
+
+	return inputsFingerprint(shas, ctx.Secrets.Data), nil
+}
+
+// resolveActionSHAs returns the commit SHAs resolved from the refs of the remote actions used by the workflow. In
+// offline mode, the SHAs are read from the actions index instead, since the actions are pinned to them.
+func resolveActionSHAs(ctx *context.Context, wf core.Workflow) (map[string]string, error) {
+	index := make(map[string]string)
+
+	if ctx.GhxConfig.Offline {
+		path, err := ctx.GetActionsPath()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := fs.ReadJSONFile(filepath.Join(path, actionsIndexFile), &index); err != nil {
+			return nil, fmt.Errorf("failed to read actions index: %w", err)
+		}
+	}
+
+	shas := make(map[string]string)
+
+	for _, source := range getRemoteActions(wf) {
+		repo, _, ref, err := parseRepoRef(source)
+		if err != nil {
+			return nil, err
+		}
+
+		if ctx.GhxConfig.Offline {
+			sha, ok := index[source]
+			if !ok {
+				return nil, fmt.Errorf("action %s does not exist in the actions cache and offline mode is enabled", source)
+			}
+
+			shas[source] = sha
+
+			continue
+		}
+
+		sha, err := resolveActionRef(repo, ref, ctx.Github.Token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve action %s: %w", source, err)
+		}
+
+		shas[source] = sha
+	}
+
+	return shas, nil
+}
+
+// inputsFingerprint returns the hex encoded SHA256 of the given action SHAs and secrets. Secret values are hashed
+// separately, so the fingerprint doesn't depend on how the values are delimited.
+func inputsFingerprint(shas, secrets map[string]string) string {
+	h := sha256.New()
+
+	for _, source := range sortedKeys(shas) {
+		fmt.Fprintf(h, "action\x00%s\x00%s\n", source, shas[source])
+	}
+
+	for _, name := range sortedKeys(secrets) {
+		value := sha256.Sum256([]byte(secrets[name]))
+
+		fmt.Fprintf(h, "secret\x00%s\x00%x\n", name, value)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sortedKeys returns the keys of the map in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,30 @@
+package ghx
+
+import "testing"
+
+func TestInputsFingerprint(t *testing.T) {
+	base := inputsFingerprint(map[string]string{"actions/checkout@v4": "b4ffde65f46336ab88eb53be808477a3936bae11"}, map[string]string{"TOKEN": "secret"})
+
+	tests := []struct {
+		name    string
+		shas    map[string]string
+		secrets map[string]string
+		same    bool
+	}{
+		{name: "same inputs", shas: map[string]string{"actions/checkout@v4": "b4ffde65f46336ab88eb53be808477a3936bae11"}, secrets: map[string]string{"TOKEN": "secret"}, same: true},
+		{name: "ref moved", shas: map[string]string{"actions/checkout@v4": "0ad4b8fadaa221de15dcec353f45205ec38ea70b"}, secrets: map[string]string{"TOKEN": "secret"}},
+		{name: "secret rotated", shas: map[string]string{"actions/checkout@v4": "b4ffde65f46336ab88eb53be808477a3936bae11"}, secrets: map[string]string{"TOKEN": "rotated"}},
+		{name: "secret added", shas: map[string]string{"actions/checkout@v4": "b4ffde65f46336ab88eb53be808477a3936bae11"}, secrets: map[string]string{"TOKEN": "secret", "KEY": "value"}},
+		{name: "action removed", shas: map[string]string{}, secrets: map[string]string{"TOKEN": "secret"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := inputsFingerprint(tt.shas, tt.secrets)
+
+			if (got == base) != tt.same {
+				t.Errorf("expected same fingerprint %v, got %s and %s", tt.same, base, got)
+			}
+		})
+	}
+}
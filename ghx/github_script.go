@@ -0,0 +1,42 @@
+package ghx
+
+import (
+	"strings"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+)
+
+// githubScriptRepository is the repository of the actions/github-script action.
+const githubScriptRepository = "actions/github-script"
+
+// githubScriptPlaceholderToken is the token passed to actions/github-script when no GitHub token is available.
+const githubScriptPlaceholderToken = "gale-no-token"
+
+// withGithubScriptShims returns the inputs of the current action with the compatibility shims of actions/github-script
+// applied. Other actions are returned as they are.
+//
+// The action requires the github-token input to create the octokit client, even if the script only uses the core or
+// the context objects. Without a token, a placeholder token is passed, so these scripts work and the API calls fail
+// with an authentication error instead. The octokit client, the core functions and the context object need nothing
+// else: the client uses GITHUB_API_URL, the core functions write to the environment files of the step and the context
+// is read from GITHUB_EVENT_PATH and the GITHUB_* variables.
+//
+// See: https://github.com/actions/github-script
+func withGithubScriptShims(ctx *context.Context, inputs map[string]string) map[string]string {
+	if !strings.EqualFold(ctx.Github.ActionRepository, githubScriptRepository) {
+		return inputs
+	}
+
+	if inputs == nil {
+		inputs = make(map[string]string)
+	}
+
+	if strings.TrimSpace(inputs["github-token"]) == "" {
+		log.Warnf("No GitHub token for actions/github-script, API calls of the script will fail", "step", ctx.Github.Action)
+
+		inputs["github-token"] = githubScriptPlaceholderToken
+	}
+
+	return inputs
+}
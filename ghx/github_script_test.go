@@ -0,0 +1,28 @@
+package ghx
+
+import (
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+)
+
+func TestWithGithubScriptShims(t *testing.T) {
+	ctx := &context.Context{}
+
+	// other actions are not changed
+	ctx.Github.ActionRepository = "actions/checkout"
+
+	if inputs := withGithubScriptShims(ctx, map[string]string{"token": ""}); inputs["github-token"] != "" {
+		t.Errorf("expected no github-token for other actions, got %q", inputs["github-token"])
+	}
+
+	ctx.Github.ActionRepository = "actions/github-script"
+
+	if inputs := withGithubScriptShims(ctx, map[string]string{"github-token": ""}); inputs["github-token"] != githubScriptPlaceholderToken {
+		t.Errorf("expected placeholder token, got %q", inputs["github-token"])
+	}
+
+	if inputs := withGithubScriptShims(ctx, map[string]string{"github-token": "token"}); inputs["github-token"] != "token" {
+		t.Errorf("expected the given token, got %q", inputs["github-token"])
+	}
+}
@@ -1,6 +1,7 @@
-package main
+package ghx
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -11,32 +12,16 @@ import (
 
 // getStepName returns the step name. If step name is not set, it will be generated from the step type.
 func getStepName(prefix string, s core.Step) string {
-	if s.Name != "" {
-		return strings.TrimSpace(strings.Join([]string{prefix, s.Name}, " "))
-	}
-
-	switch s.Type() {
-	case core.StepTypeAction:
-		return strings.TrimSpace(strings.Join([]string{prefix, s.Uses}, " "))
-	case core.StepTypeRun:
-		return strings.TrimSpace(strings.Join([]string{prefix, strings.Split(s.Run, "\n")[0]}, " "))
-	default:
-		return fmt.Sprintf("%s %s", prefix, s.ID)
-	}
+	return strings.TrimSpace(strings.Join([]string{prefix, s.DisplayName()}, " "))
 }
 
-// evalCondition evaluates the given condition and returns the result. If the condition is empty, then it uses
-// success() as default.
+// evalCondition evaluates the given condition and returns the result. Like GitHub, if the condition is empty or doesn't
+// call a status check function, success() is implied, so the condition is only checked if the previous steps succeed.
 func evalCondition(condition string, ac *context.Context) (bool, core.Conclusion, error) {
-	// if condition is empty, then use success() as default
-	if condition == "" {
-		condition = "success()"
-	}
-
 	// evaluate the condition as boolean expression
-	run, err := expression.NewBoolExpr(condition).Eval(ac)
+	run, err := expression.NewBoolExpr(expression.WithStatusCheck(condition)).Eval(ac)
 	if err != nil {
-		return false, "", err
+		return false, "", newConditionError(condition, ac, err)
 	}
 
 	var conclusion core.Conclusion
@@ -48,3 +33,107 @@ func evalCondition(condition string, ac *context.Context) (bool, core.Conclusion
 
 	return run, conclusion, nil
 }
+
+// maxConditionValueLen is the maximum length of the referenced values shown in condition errors. Longer values, e.g.
+// the whole event payload, are truncated to keep the error readable.
+const maxConditionValueLen = 200
+
+// conditionError is the error of a condition that couldn't be evaluated. It explains where the condition is defined and
+// what the contexts referenced by the condition contain at the time of the evaluation.
+type conditionError struct {
+	condition string   // condition is the condition as it's written in the workflow
+	workflow  string   // workflow is the path of the workflow file
+	job       string   // job is the id of the job
+	step      string   // step is the id of the step, empty for job conditions
+	values    []string // values are the referenced contexts and their values in "name = value" format
+	err       error    // err is the evaluation error
+}
+
+// newConditionError creates a condition error for the condition evaluated in the given context.
+func newConditionError(condition string, ac *context.Context, err error) *conditionError {
+	ce := &conditionError{condition: condition, err: err}
+
+	if ac.Execution.WorkflowRun != nil {
+		ce.workflow = ac.Execution.WorkflowRun.Workflow.Path
+	}
+
+	if ac.Execution.JobRun != nil {
+		ce.job = ac.Execution.JobRun.Job.ID
+	}
+
+	if ac.Execution.StepRun != nil {
+		ce.step = ac.Execution.StepRun.Step.ID
+	}
+
+	for _, ref := range expression.References(condition) {
+		ce.values = append(ce.values, fmt.Sprintf("%s = %s", ref, conditionValue(ref, ac)))
+	}
+
+	return ce
+}
+
+func (e *conditionError) Error() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "failed to evaluate condition %q", e.condition)
+
+	var location []string
+
+	if e.workflow != "" {
+		location = append(location, "workflow "+e.workflow)
+	}
+
+	if e.job != "" {
+		location = append(location, "job "+e.job)
+	}
+
+	if e.step != "" {
+		location = append(location, "step "+e.step)
+	}
+
+	if len(location) > 0 {
+		fmt.Fprintf(&sb, " (%s)", strings.Join(location, ", "))
+	}
+
+	fmt.Fprintf(&sb, ": %v", e.err)
+
+	for _, value := range e.values {
+		sb.WriteString("\n  ")
+		sb.WriteString(value)
+	}
+
+	return sb.String()
+}
+
+func (e *conditionError) Unwrap() error {
+	return e.err
+}
+
+// conditionValue returns the value of the referenced context as JSON. Secrets are never shown and the references
+// that can't be evaluated are explained with their errors.
+func conditionValue(ref string, ac *context.Context) string {
+	if ref == "secrets" || strings.HasPrefix(ref, "secrets.") {
+		return "***"
+	}
+
+	expr, err := expression.NewExpression(ref)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+
+	val, err := expr.Evaluate(ac)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprintf("%v", val)
+	}
+
+	if len(data) > maxConditionValueLen {
+		return string(data[:maxConditionValueLen]) + "..."
+	}
+
+	return string(data)
+}
@@ -0,0 +1,102 @@
+package ghx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// TestEvalCondition checks the step conditions follow the status check semantics of GitHub for each job status. Steps
+// are skipped when the condition is false.
+func TestEvalCondition(t *testing.T) {
+	statuses := []core.Conclusion{core.ConclusionSuccess, core.ConclusionFailure, core.ConclusionCancelled}
+
+	tests := []struct {
+		condition string
+		expected  []bool // expected is the result of the condition for the success, failure and cancelled job status
+	}{
+		{condition: "", expected: []bool{true, false, false}},
+		{condition: "success()", expected: []bool{true, false, false}},
+		{condition: "failure()", expected: []bool{false, true, false}},
+		{condition: "cancelled()", expected: []bool{false, false, true}},
+		{condition: "always()", expected: []bool{true, true, true}},
+		{condition: "!cancelled()", expected: []bool{true, true, false}},
+		{condition: "success() || failure()", expected: []bool{true, true, false}},
+		{condition: "true", expected: []bool{true, false, false}},
+		{condition: "${{ true }}", expected: []bool{true, false, false}},
+		{condition: "github.event_name == 'push'", expected: []bool{true, false, false}},
+		{condition: "steps.lint.outcome == 'failure'", expected: []bool{true, false, false}},
+		{condition: "always() && steps.lint.outcome == 'failure'", expected: []bool{true, true, true}},
+		{condition: "failure() && steps.lint.conclusion == 'success'", expected: []bool{false, true, false}},
+		{condition: "false", expected: []bool{false, false, false}},
+	}
+
+	for _, tt := range tests {
+		for idx, status := range statuses {
+			ctx := &context.Context{
+				Job: context.JobContext{Status: status},
+				Steps: context.StepsContext{
+					// step with continue-on-error failed, but its conclusion is success
+					"lint": {Outcome: core.ConclusionFailure, Conclusion: core.ConclusionSuccess},
+				},
+			}
+
+			ctx.Github.EventName = "push"
+
+			run, conclusion, err := evalCondition(tt.condition, ctx)
+			if err != nil {
+				t.Fatalf("%q with %s job status: unexpected error: %v", tt.condition, status, err)
+			}
+
+			if run != tt.expected[idx] {
+				t.Errorf("%q with %s job status: expected %t, got %t", tt.condition, status, tt.expected[idx], run)
+			}
+
+			if !run && conclusion != core.ConclusionSkipped {
+				t.Errorf("%q with %s job status: expected skipped conclusion, got %s", tt.condition, status, conclusion)
+			}
+		}
+	}
+}
+
+// TestEvalCondition_Error checks the condition errors explain the location of the condition and the values of the
+// referenced contexts without revealing the secrets.
+func TestEvalCondition_Error(t *testing.T) {
+	ctx := &context.Context{
+		Job:     context.JobContext{Status: core.ConclusionSuccess},
+		Secrets: context.SecretsContext{Data: map[string]string{"TOKEN": "s3cr3t"}},
+		Execution: context.ExecutionContext{
+			WorkflowRun: &core.WorkflowRun{Workflow: core.Workflow{Path: ".github/workflows/ci.yaml"}},
+			JobRun:      &core.JobRun{Job: core.Job{ID: "build"}},
+			StepRun:     &core.StepRun{Step: core.Step{ID: "deploy"}},
+		},
+	}
+
+	ctx.Github.Ref = "refs/heads/main"
+
+	_, _, err := evalCondition("github.ref == 'refs/heads/main' && secrets.TOKEN && unknown.value", ctx)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	msg := err.Error()
+
+	for _, expected := range []string{
+		`"github.ref == 'refs/heads/main' && secrets.TOKEN && unknown.value"`,
+		"workflow .github/workflows/ci.yaml, job build, step deploy",
+		"unknown variable: unknown",
+		`github.ref = "refs/heads/main"`,
+		"secrets.token = ***",
+		"unknown.value = <unknown variable: unknown>",
+	} {
+		if !strings.Contains(msg, expected) {
+			t.Errorf("expected error to contain %q, got:\n%s", expected, msg)
+		}
+	}
+
+	if strings.Contains(msg, "s3cr3t") {
+		t.Errorf("expected error not to reveal secrets, got:\n%s", msg)
+	}
+}
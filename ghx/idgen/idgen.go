@@ -16,8 +16,6 @@ const (
 
 type counter map[string]int
 
-// TODO: This is not concurrency safe. Need to use lock file or something similar to make it concurrency safe
-
 // GenerateWorkflowRunID generates a unique workflow run id for the given repository
 func GenerateWorkflowRunID(ctx *context.Context) (string, error) {
 	path, err := ctx.GetMetadataPath()
@@ -42,29 +40,36 @@ func GenerateJobRunID(ctx *context.Context) (string, error) {
 	return generateID(dataPath, keyJobRunID)
 }
 
+// generateID increments the counter of the given key in the data file and returns it. The data file is shared by the
+// concurrent workflow runs through the metadata cache, so the counter is incremented holding the lock of the file to
+// keep the ids unique.
 func generateID(dataPath, key string) (string, error) {
-	err := fs.EnsureFile(dataPath)
-	if err != nil {
-		return "", err
-	}
+	var id int
 
-	var ids counter
+	err := fs.WithLock(dataPath, func() error {
+		if err := fs.EnsureFile(dataPath); err != nil {
+			return err
+		}
 
-	err = fs.ReadJSONFile(dataPath, &ids)
-	if err != nil {
-		return "", err
-	}
+		var ids counter
 
-	if ids == nil {
-		ids = make(counter)
-	}
+		if err := fs.ReadJSONFile(dataPath, &ids); err != nil {
+			return err
+		}
+
+		if ids == nil {
+			ids = make(counter)
+		}
+
+		ids[key]++
 
-	ids[key]++
+		id = ids[key]
 
-	err = fs.WriteJSONFile(dataPath, ids)
+		return fs.WriteJSONFile(dataPath, ids)
+	})
 	if err != nil {
 		return "", err
 	}
 
-	return strconv.Itoa(ids[key]), nil
+	return strconv.Itoa(id), nil
 }
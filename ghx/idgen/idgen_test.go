@@ -2,6 +2,7 @@ package idgen_test
 
 import (
 	"os"
+	"sync"
 	"testing"
 
 	"github.com/aweris/gale/ghx/context"
@@ -77,3 +78,47 @@ func TestGenerateJobRunID(t *testing.T) {
 		t.Errorf("Expected second job run ID to be 2, got %s", jobRunID)
 	}
 }
+
+func TestGenerateWorkflowRunID_Concurrent(t *testing.T) {
+	ctx := &context.Context{
+		GhxConfig: context.GhxConfig{
+			HomeDir: t.TempDir(),
+		},
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		ids = make(map[string]bool)
+	)
+
+	// concurrent workflow runs share the metadata, so the generated ids must be unique
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			id, err := idgen.GenerateWorkflowRunID(ctx)
+			if err != nil {
+				t.Errorf("Error generating workflow run ID: %v", err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if ids[id] {
+				t.Errorf("Duplicate workflow run ID %s", id)
+			}
+
+			ids[id] = true
+		}()
+	}
+
+	wg.Wait()
+
+	if len(ids) != 20 {
+		t.Errorf("Expected 20 unique workflow run IDs, got %d", len(ids))
+	}
+}
@@ -1,7 +1,9 @@
-package main
+package ghx
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/aweris/gale/common/log"
@@ -12,8 +14,9 @@ import (
 	"github.com/aweris/gale/ghx/task"
 )
 
-// planJob plans the job and returns the job runner.
-func planJob(job core.Job) ([]*task.Runner, error) {
+// planJob plans the job and returns the job runners. Matrix jobs have a runner for each combination matching the
+// matrix filters.
+func planJob(job core.Job, filters []string) ([]*task.Runner, error) {
 	// step task executors that execute the steps
 	var (
 		setupFns = make([]task.RunFn, 0)
@@ -69,7 +72,7 @@ func planJob(job core.Job) ([]*task.Runner, error) {
 
 		// main task options
 		opt := task.Opts{
-			ConditionalFn: sr.condition(),
+			ConditionalFn: withBreakpoint(step, sr.condition()),
 			PreRunFn:      preRunFn,
 			PostRunFn:     postRunFn,
 		}
@@ -109,8 +112,21 @@ func planJob(job core.Job) ([]*task.Runner, error) {
 	tasks = append(tasks, post...)
 	tasks = append(tasks, task.New("Complete job", complete()))
 
+	// cleanup tasks, post steps and the job completion, are the tasks run even if the workflow is stopped
+	cleanup := len(tasks) - len(post) - 1
+
 	runFn := func(ctx *context.Context) (core.Conclusion, error) {
-		for _, te := range tasks {
+		var stopped error
+
+		// report the job to the GitHub deployments of the environment if enabled
+		ctx.Execution.JobRun.Deployment = createDeployment(ctx)
+
+		for idx, te := range tasks {
+			// skip the remaining steps of the stopped workflow except the cleanup tasks
+			if stopped != nil && idx < cleanup {
+				continue
+			}
+
 			result, err := te.Run(ctx)
 
 			// no need to continue if the task taskRunner did not run.
@@ -122,10 +138,33 @@ func planJob(job core.Job) ([]*task.Runner, error) {
 				log.Errorf(te.Name, "error", err)
 			}
 
+			// workflow is stopped by a breakpoint or the user, no need to run the remaining steps. Debug shell keeps the
+			// state of the step as it is, otherwise cleanup tasks run to release the resources of the job.
+			if errors.Is(err, errStopped) {
+				ctx.Job.Status = core.ConclusionCancelled
+				stopped = err
+
+				if ctx.GhxConfig.DebugShell {
+					break
+				}
+
+				continue
+			}
+
 			// set the job status to the conclusion of the job status is success and the conclusion is not success.
 			if ctx.Job.Status == core.ConclusionSuccess && result.Conclusion != ctx.Job.Status {
 				ctx.Job.Status = result.Conclusion
 			}
+
+			// keep the state of the failed step as it is for the debug shell
+			if ctx.GhxConfig.DebugShell && ctx.Job.Status == core.ConclusionFailure {
+				log.Warnf("Debug shell is enabled, stopping the job at the failed step", "step", te.Name)
+				break
+			}
+		}
+
+		if err := saveWorkspace(ctx); err != nil {
+			log.Warnf("Failed to preserve workspace", "job", ctx.Execution.JobRun.Job.ID, "error", err)
 		}
 
 		totalSize := 0
@@ -157,35 +196,39 @@ func planJob(job core.Job) ([]*task.Runner, error) {
 			log.Warnf("Total size of the outputs is bigger than 50MB", "size", fmt.Sprintf("%dMB", totalSize/MB))
 		}
 
+		// evaluate the deployment url of the environment after the steps, since it could refer to the step outputs
+		if url := ctx.Execution.JobRun.Job.Environment.URL; url != "" {
+			ctx.Execution.JobRun.URL = expression.NewString(url).Eval(ctx)
+
+			log.Infof("Deployment URL", "environment", ctx.Github.Environment, "url", ctx.Execution.JobRun.URL)
+		}
+
 		// TODO: refactor this later into properly. It's added just to make results available for the context.
 
 		ctx.SetJobResults(ctx.Job.Status, ctx.Job.Status, outputs)
 
-		return ctx.Job.Status, nil
+		completeDeployment(ctx, ctx.Execution.JobRun.Deployment, ctx.Job.Status)
+
+		return ctx.Job.Status, stopped
 	}
 
 	runners := make([]*task.Runner, 0)
 	matrices := job.Strategy.Matrix.GenerateCombinations()
 
 	if len(matrices) > 0 {
-		for _, matrix := range matrices {
-			var values []string
-
-			for k, v := range matrix {
-				values = append(values, fmt.Sprintf("%s:%s", k, v))
-			}
-
-			sb := strings.Builder{}
+		filtered, err := filterMatrices(matrices, filters)
+		if err != nil {
+			return nil, err
+		}
 
-			sb.WriteString("Job: ")
-			sb.WriteString(job.Name)
-			sb.WriteRune('(')
-			sb.WriteString(strings.Join(values, ","))
-			sb.WriteRune(')')
+		if len(filtered) == 0 {
+			return nil, fmt.Errorf("no matrix combination of job %s matches the filters %v", job.ID, filters)
+		}
 
-			runner := task.New(sb.String(), runFn, task.Opts{
+		for idx, matrix := range filtered {
+			runner := task.New(fmt.Sprintf("Job: %s", job.DisplayName(matrix)), runFn, task.Opts{
 				ConditionalFn: newTaskConditionalFnForJob(job),
-				PreRunFn:      newTaskPreRunFnForJob(job, matrix),
+				PreRunFn:      newTaskPreRunFnForJob(job, idx, len(filtered), matrix),
 				PostRunFn:     newTaskPostRunFnForJob(),
 			})
 
@@ -195,7 +238,7 @@ func planJob(job core.Job) ([]*task.Runner, error) {
 		// task runner options for the job
 		opt := task.Opts{
 			ConditionalFn: newTaskConditionalFnForJob(job),
-			PreRunFn:      newTaskPreRunFnForJob(job),
+			PreRunFn:      newTaskPreRunFnForJob(job, 0, 1),
 			PostRunFn:     newTaskPostRunFnForJob(),
 		}
 
@@ -207,6 +250,46 @@ func planJob(job core.Job) ([]*task.Runner, error) {
 	return runners, nil
 }
 
+// filterMatrices returns the matrix combinations matching the filters in key=value format. Combinations should match
+// one of the values of each filter key. Filter keys missing in all combinations are ignored, so the same filters could
+// be used for the jobs with different matrices.
+func filterMatrices(matrices []core.MatrixCombination, filters []string) ([]core.MatrixCombination, error) {
+	values := make(map[string][]string)
+
+	for _, filter := range filters {
+		key, value, ok := strings.Cut(filter, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid matrix filter %s, expected format is key=value", filter)
+		}
+
+		for _, matrix := range matrices {
+			if _, exist := matrix[key]; exist {
+				values[key] = append(values[key], value)
+				break
+			}
+		}
+	}
+
+	var filtered []core.MatrixCombination
+
+	for _, matrix := range matrices {
+		match := true
+
+		for key, accepted := range values {
+			if !slices.Contains(accepted, fmt.Sprintf("%v", matrix[key])) {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			filtered = append(filtered, matrix)
+		}
+	}
+
+	return filtered, nil
+}
+
 // setup returns a task taskRunner function that will be executed by the task taskRunner for the setup step.
 func setup(setupFns ...task.RunFn) task.RunFn {
 	return func(ctx *context.Context) (core.Conclusion, error) {
@@ -246,26 +329,79 @@ func complete() task.RunFn {
 
 func newTaskConditionalFnForJob(job core.Job) task.ConditionalFn {
 	return func(ctx *context.Context) (bool, core.Conclusion, error) {
-		return evalCondition(job.If, ctx)
+		run, conclusion, err := evalCondition(job.If, ctx)
+		if err != nil || !run {
+			return run, conclusion, err
+		}
+
+		if ctx.Github.Environment != "" && ctx.GhxConfig.EnvironmentApproval {
+			approved, err := approveEnvironment(ctx)
+			if err != nil {
+				return false, core.ConclusionFailure, err
+			}
+
+			if !approved {
+				log.Warnf("Deployment is rejected", "job", job.ID, "environment", ctx.Github.Environment)
+
+				return false, core.ConclusionFailure, nil
+			}
+		}
+
+		if err := prepareWorkspace(ctx); err != nil {
+			return false, core.ConclusionFailure, fmt.Errorf("failed to prepare workspace: %w", err)
+		}
+
+		if label, ok := unsupportedLabel(ctx, job); ok {
+			log.Warnf("Job targets a runner that can't run in a container, skipping", "job", job.ID, "runs-on", label)
+
+			return false, core.ConclusionSkipped, nil
+		}
+
+		return run, conclusion, nil
 	}
 }
 
 // newTaskPreRunFnForJob returns a task pre run function that will be executed by the task taskRunner for the job. The
-// matrix parameter is optional. If it's provided, first matrix combination will be set to the job run.
-func newTaskPreRunFnForJob(job core.Job, matrix ...core.MatrixCombination) task.PreRunFn {
+// index and total are the position of the job run in the matrix. The matrix parameter is optional. If it's provided,
+// first matrix combination will be set to the job run.
+func newTaskPreRunFnForJob(job core.Job, index, total int, matrix ...core.MatrixCombination) task.PreRunFn {
 	return func(ctx *context.Context) error {
 		runID, err := idgen.GenerateJobRunID(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to generate job run id: %w", err)
 		}
 
-		jr := &core.JobRun{RunID: runID, Job: job, Outputs: make(map[string]string)}
+		jr := &core.JobRun{RunID: runID, Job: job, Outputs: make(map[string]string), JobIndex: index, JobTotal: total}
 
 		if len(matrix) > 0 {
 			jr.Matrix = matrix[0]
 		}
 
-		return ctx.SetJob(jr)
+		if err := ctx.SetJob(jr); err != nil {
+			return err
+		}
+
+		// runs-on labels could refer to the matrix values, e.g. ${{ matrix.os }}, so they're evaluated once the matrix
+		// context is set to keep the labels of the job run in the reports
+		labels := make(core.RunsOn, 0, len(job.RunsOn))
+
+		for _, label := range job.RunsOn {
+			labels = append(labels, expression.NewString(label).Eval(ctx))
+		}
+
+		jr.Job.RunsOn = labels
+
+		// fail before running the steps if the network mode of the job can't be enforced
+		if _, err := ctx.NetworkEnv(); err != nil {
+			return err
+		}
+
+		if job.Environment.Name == "" {
+			return nil
+		}
+
+		// environment name could be an expression, e.g. ${{ matrix.environment }}
+		return ctx.SetEnvironment(expression.NewString(job.Environment.Name).Eval(ctx))
 	}
 }
 
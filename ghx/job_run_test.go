@@ -0,0 +1,112 @@
+package ghx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestFilterMatrices(t *testing.T) {
+	matrices := []core.MatrixCombination{
+		{"os": "ubuntu-latest", "go": "1.21"},
+		{"os": "ubuntu-latest", "go": "1.22"},
+		{"os": "macos-latest", "go": "1.21"},
+		{"os": "macos-latest", "go": "1.22"},
+	}
+
+	tests := []struct {
+		name     string
+		filters  []string
+		expected []core.MatrixCombination
+		wantErr  bool
+	}{
+		{
+			name:     "no filters",
+			filters:  nil,
+			expected: matrices,
+		},
+		{
+			name:     "single filter",
+			filters:  []string{"go=1.21"},
+			expected: []core.MatrixCombination{matrices[0], matrices[2]},
+		},
+		{
+			name:     "multiple keys",
+			filters:  []string{"go=1.21", "os=ubuntu-latest"},
+			expected: []core.MatrixCombination{matrices[0]},
+		},
+		{
+			name:     "multiple values of the same key",
+			filters:  []string{"go=1.21", "go=1.22", "os=macos-latest"},
+			expected: []core.MatrixCombination{matrices[2], matrices[3]},
+		},
+		{
+			name:     "unknown key is ignored",
+			filters:  []string{"node=20", "go=1.22"},
+			expected: []core.MatrixCombination{matrices[1], matrices[3]},
+		},
+		{
+			name:     "no match",
+			filters:  []string{"go=1.20"},
+			expected: nil,
+		},
+		{
+			name:    "invalid filter",
+			filters: []string{"go"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterMatrices(matrices, tt.filters)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("filterMatrices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("filterMatrices() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJobDisplayName(t *testing.T) {
+	job := core.Job{
+		Name: "test",
+		Strategy: core.Strategy{
+			Matrix: core.Matrix{Keys: []string{"os", "go"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		matrix   core.MatrixCombination
+		expected string
+	}{
+		{
+			name:     "no matrix",
+			matrix:   nil,
+			expected: "test",
+		},
+		{
+			name:     "dimension order",
+			matrix:   core.MatrixCombination{"go": "1.21", "os": "ubuntu-latest"},
+			expected: "test (ubuntu-latest, 1.21)",
+		},
+		{
+			name:     "included keys",
+			matrix:   core.MatrixCombination{"go": 1.21, "os": "ubuntu-latest", "experimental": true},
+			expected: "test (ubuntu-latest, 1.21, true)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := job.DisplayName(tt.matrix); got != tt.expected {
+				t.Errorf("DisplayName() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
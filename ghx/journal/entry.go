@@ -15,16 +15,23 @@ const (
 
 	// EntryTypeExecution is a log entry from the container execution.
 	EntryTypeExecution EntryType = "execution"
+
+	// EntryTypeEnd marks the end of a recorded journal, the message is the conclusion of the workflow run.
+	EntryTypeEnd EntryType = "end"
 )
 
 // Entry is a single log entry from the journal
 type Entry struct {
-	Raw        string
-	ID         int
-	Index      int
-	Type       EntryType
-	ElapsedTme time.Duration
-	Message    string
+	Raw        string        `json:"-"`
+	ID         int           `json:"id"`
+	Index      int           `json:"-"`
+	Type       EntryType     `json:"type"`
+	ElapsedTme time.Duration `json:"-"`
+	Message    string        `json:"message"`
+	Time       time.Time     `json:"time"`           // Time is the wall-clock time the entry is written to the journal.
+	Elapsed    time.Duration `json:"elapsed"`        // Elapsed is the monotonic time elapsed from the start of the journal to the entry.
+	Job        string        `json:"job,omitempty"`  // Job is the display name of the job producing the entry, empty outside the jobs.
+	Step       string        `json:"step,omitempty"` // Step is the display name of the step producing the entry, empty outside the steps.
 }
 
 // String returns the raw log line
@@ -32,6 +39,25 @@ func (e *Entry) String() string {
 	return e.Raw
 }
 
+// Prefix returns the console prefix of the entry, e.g. [build ▸ Run tests] 00:12.3 | . It returns an empty string for
+// the entries written outside the jobs.
+func (e *Entry) Prefix() string {
+	return formatPrefix(Owner{Job: e.Job, Step: e.Step}, e.Elapsed)
+}
+
+// stamp sets the time and the owner of the entry to the current ones.
+func (e *Entry) stamp() *Entry {
+	now := time.Now()
+	current := CurrentOwner()
+
+	e.Time = now
+	e.Elapsed = now.Sub(start)
+	e.Job = current.Job
+	e.Step = current.Step
+
+	return e
+}
+
 // parseEntry parses a log line into an entry
 func parseEntry(id int, line string) *Entry {
 	// Create a new entry with the raw log line and the ID. We'll fill in the rest later.
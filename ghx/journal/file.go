@@ -0,0 +1,213 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// followInterval is the interval to check the journal file for the new entries while following it.
+const followInterval = 500 * time.Millisecond
+
+// Recorder records the journal entries to the sinks, e.g. files as NDJSON, one JSON encoded entry per line. Elapsed
+// times are encoded in nanoseconds.
+type Recorder struct {
+	mu      sync.Mutex
+	sinks   []Sink
+	counter int
+}
+
+// NewRecorder creates a recorder writing the entries to the given files. Existing files are truncated.
+func NewRecorder(paths ...string) (*Recorder, error) {
+	recorder := &Recorder{}
+
+	for _, path := range paths {
+		sink, err := OpenFileSink(path)
+		if err != nil {
+			recorder.closeSinks()
+
+			return nil, err
+		}
+
+		recorder.sinks = append(recorder.sinks, sink)
+	}
+
+	return recorder, nil
+}
+
+// AddSink adds the sink to the recorder. The sink receives the entries recorded from now on and it's closed with the
+// recorder.
+func (r *Recorder) AddSink(sink Sink) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sinks = append(r.sinks, sink)
+}
+
+// Record writes the given entry to the sinks of the recorder. The entry is numbered in the order of the recording. A
+// failing sink doesn't prevent writing the entry to the other sinks.
+func (r *Recorder) Record(entry *Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.counter++
+
+	entry.ID = r.counter
+
+	var errs []error
+
+	for _, sink := range r.sinks {
+		errs = append(errs, sink.Write(entry))
+	}
+
+	return errors.Join(errs...)
+}
+
+// RecordLine records the given output line as an execution entry owned by the current job and step. Errors are
+// ignored since the recording shouldn't interrupt the output.
+func (r *Recorder) RecordLine(line string) {
+	_ = r.Record((&Entry{Raw: line, Type: EntryTypeExecution, Message: line}).stamp())
+}
+
+// Close records the end entry with the given conclusion and closes the sinks, so the followers stop reading.
+func (r *Recorder) Close(conclusion string) error {
+	err := r.Record((&Entry{Type: EntryTypeEnd, Message: conclusion}).stamp())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return errors.Join(err, r.closeSinks())
+}
+
+// closeSinks closes the sinks of the recorder.
+func (r *Recorder) closeSinks() error {
+	var errs []error
+
+	for _, sink := range r.sinks {
+		errs = append(errs, sink.Close())
+	}
+
+	r.sinks = nil
+
+	return errors.Join(errs...)
+}
+
+// fileSink writes the entries to a file as NDJSON.
+type fileSink struct {
+	file *os.File
+}
+
+// OpenFileSink opens the sink writing the entries to the file at the given path as NDJSON. Existing file is truncated.
+func OpenFileSink(path string) (Sink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal file %s: %w", path, err)
+	}
+
+	return &fileSink{file: file}, nil
+}
+
+func (s *fileSink) Write(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal file %s: %w", s.file.Name(), err)
+	}
+
+	return nil
+}
+
+func (s *fileSink) Close() error {
+	return s.file.Close()
+}
+
+// ReadFile reads the entries of the recorded journal file and calls fn for each entry except the end entry. If follow
+// is true, it waits for the file to be created and for the new entries until the end entry is read or the context is
+// done, e.g. to follow a workflow run in progress.
+func ReadFile(ctx context.Context, path string, follow bool, fn func(*Entry)) error {
+	file, err := openFile(ctx, path, follow)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	var (
+		reader  = bufio.NewReader(file)
+		partial []byte
+	)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+
+		// incomplete lines are kept until the rest of the line is written
+		partial = append(partial, line...)
+
+		if errors.Is(err, io.EOF) {
+			if !follow {
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(followInterval):
+				continue
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		data := bytes.TrimSpace(partial)
+		partial = nil
+
+		if len(data) == 0 {
+			continue
+		}
+
+		var entry Entry
+
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return fmt.Errorf("failed to parse journal entry: %w", err)
+		}
+
+		if entry.Type == EntryTypeEnd {
+			return nil
+		}
+
+		fn(&entry)
+	}
+}
+
+// openFile opens the journal file. If follow is true, it waits for the file to be created until the context is done.
+func openFile(ctx context.Context, path string, follow bool) (*os.File, error) {
+	for {
+		file, err := os.Open(path)
+		if err == nil {
+			return file, nil
+		}
+
+		if !follow || !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to open journal file %s: %w", path, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(followInterval):
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package journal
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecorder_ReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.ndjson")
+
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	SetOwner("build", "Run tests")
+	defer SetOwner("", "")
+
+	recorder.RecordLine("hello")
+	recorder.RecordLine("world")
+
+	var messages []string
+
+	// without follow, reading stops at the end of the file even if the journal is not closed yet
+	err = ReadFile(context.Background(), path, false, func(entry *Entry) {
+		messages = append(messages, entry.Message)
+
+		if entry.Job != "build" || entry.Step != "Run tests" {
+			t.Errorf("expected owner build/Run tests, got %s/%s", entry.Job, entry.Step)
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(messages) != 2 || messages[0] != "hello" || messages[1] != "world" {
+		t.Errorf("expected hello and world, got %v", messages)
+	}
+
+	done := make(chan error)
+
+	messages = nil
+
+	go func() {
+		done <- ReadFile(context.Background(), path, true, func(entry *Entry) {
+			messages = append(messages, entry.Message)
+		})
+	}()
+
+	recorder.RecordLine("!")
+
+	if err := recorder.Close("success"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// following stops at the end entry
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected following to stop at the end of the journal")
+	}
+
+	if len(messages) != 3 || messages[2] != "!" {
+		t.Errorf("expected hello, world and !, got %v", messages)
+	}
+}
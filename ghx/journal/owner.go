@@ -0,0 +1,62 @@
+package journal
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// start is the start time of the journal. Elapsed times are relative to it and measured with the monotonic clock
+// reading of time.Now, so they're not affected by the changes of the wall clock.
+var start = time.Now()
+
+// owner is the job and the step producing the output at the moment.
+var owner atomic.Pointer[Owner]
+
+// Owner is the job and the step producing the output.
+type Owner struct {
+	Job  string // Job is the display name of the job.
+	Step string // Step is the display name of the step, empty between the steps of the job.
+}
+
+// SetOwner sets the job and the step producing the output from now on. Empty job unsets the owner.
+func SetOwner(job, step string) {
+	owner.Store(&Owner{Job: job, Step: step})
+}
+
+// CurrentOwner returns the job and the step producing the output at the moment.
+func CurrentOwner() Owner {
+	if current := owner.Load(); current != nil {
+		return *current
+	}
+
+	return Owner{}
+}
+
+// Prefix returns the console prefix of the output produced at the moment, e.g. [build ▸ Run tests] 00:12.3 | . It
+// returns an empty string if no job is running, so the output outside the jobs is not prefixed.
+func Prefix() string {
+	return formatPrefix(CurrentOwner(), time.Since(start))
+}
+
+// formatPrefix formats the console prefix of the given owner and the elapsed time.
+func formatPrefix(o Owner, elapsed time.Duration) string {
+	if o.Job == "" {
+		return ""
+	}
+
+	name := o.Job
+
+	if o.Step != "" {
+		name = fmt.Sprintf("%s ▸ %s", o.Job, o.Step)
+	}
+
+	return fmt.Sprintf("[%s] %s | ", name, formatElapsed(elapsed))
+}
+
+// formatElapsed formats the elapsed time as mm:ss.d. Minutes are not wrapped to hours, long runs show minutes above 59.
+func formatElapsed(elapsed time.Duration) string {
+	tenths := elapsed.Milliseconds() / 100
+
+	return fmt.Sprintf("%02d:%02d.%d", tenths/600, tenths/10%60, tenths%10)
+}
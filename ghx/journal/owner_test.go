@@ -0,0 +1,52 @@
+package journal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		owner    Owner
+		elapsed  time.Duration
+		expected string
+	}{
+		{"no job", Owner{}, time.Second, ""},
+		{"job", Owner{Job: "build"}, 1500 * time.Millisecond, "[build] 00:01.5 | "},
+		{"step", Owner{Job: "build", Step: "Run tests"}, 12*time.Second + 340*time.Millisecond, "[build ▸ Run tests] 00:12.3 | "},
+		{"long run", Owner{Job: "build", Step: "Run tests"}, 75 * time.Minute, "[build ▸ Run tests] 75:00.0 | "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatPrefix(tt.owner, tt.elapsed); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPipe_StampsEntries(t *testing.T) {
+	SetOwner("build", "Run tests")
+	defer SetOwner("", "")
+
+	w, r := Pipe()
+
+	if _, err := w.Write([]byte("1: [0.1s] hello\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entry, ok := r.ReadEntry()
+	if !ok {
+		t.Fatal("expected an entry")
+	}
+
+	if entry.Job != "build" || entry.Step != "Run tests" {
+		t.Errorf("expected owner build/Run tests, got %s/%s", entry.Job, entry.Step)
+	}
+
+	if entry.Time.IsZero() || entry.Elapsed <= 0 {
+		t.Errorf("expected time and elapsed time to be set, got %v and %v", entry.Time, entry.Elapsed)
+	}
+}
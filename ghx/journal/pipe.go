@@ -58,7 +58,7 @@ func (p *unboundedPipe) Write(data []byte) (n int, err error) {
 			continue
 		}
 
-		p.buffer = append(p.buffer, parseEntry(int(p.counter.Add(1)), line))
+		p.buffer = append(p.buffer, parseEntry(int(p.counter.Add(1)), line).stamp())
 		p.cond.Signal()
 	}
 
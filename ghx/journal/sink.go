@@ -0,0 +1,184 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultJournaldSocket is the socket of the native protocol of the journald.
+const defaultJournaldSocket = "/run/systemd/journal/socket"
+
+// Sink is a destination of the journal entries, e.g. a file, the syslog or a webhook.
+type Sink interface {
+	// Write writes the entry to the sink.
+	Write(entry *Entry) error
+
+	// Close flushes the pending entries and closes the sink.
+	Close() error
+}
+
+// OpenSink opens the sink of the given spec. Spec format is type=address, the address is optional for some types:
+//
+//   - file=<path> writes the entries to the file as NDJSON.
+//   - console[=stdout|stderr] writes the entries as text lines to the console, defaults to stderr.
+//   - syslog[=<network>://<address>] writes the entries to the syslog, e.g. udp://logs.example.com:514. Defaults to
+//     the given socket if not empty, otherwise the local syslog.
+//   - journald[=<socket>] writes the entries to the journald with the native protocol. Defaults to the given socket if
+//     not empty, otherwise /run/systemd/journal/socket.
+//   - webhook=<url> posts the entries to the url as JSON arrays in batches.
+func OpenSink(spec, socket string) (Sink, error) {
+	kind, address, _ := strings.Cut(strings.TrimSpace(spec), "=")
+
+	switch kind {
+	case "file":
+		if address == "" {
+			return nil, fmt.Errorf("file journal sink requires a path, e.g. file=/var/log/gale.ndjson")
+		}
+
+		return OpenFileSink(address)
+	case "console":
+		switch address {
+		case "", "stderr":
+			return NewConsoleSink(os.Stderr), nil
+		case "stdout":
+			return NewConsoleSink(os.Stdout), nil
+		default:
+			return nil, fmt.Errorf("unsupported console journal sink %s, expected stdout or stderr", address)
+		}
+	case "syslog":
+		if address == "" && socket != "" {
+			address = "unixgram://" + socket
+		}
+
+		return openSyslogSink(address)
+	case "journald":
+		if address == "" {
+			address = socket
+		}
+
+		if address == "" {
+			address = defaultJournaldSocket
+		}
+
+		return openJournaldSink(address)
+	case "webhook":
+		if address == "" {
+			return nil, fmt.Errorf("webhook journal sink requires a url, e.g. webhook=https://logs.example.com/gale")
+		}
+
+		return NewWebhookSink(address), nil
+	default:
+		return nil, fmt.Errorf("unsupported journal sink %s, supported sinks are file, console, syslog, journald and webhook", kind)
+	}
+}
+
+// formatLine formats the entry as a plain text line with its owner, e.g. [build ▸ Run tests] hello. Elapsed time is
+// omitted since the log systems keep the time of the lines.
+func formatLine(entry *Entry) string {
+	message := entry.Message
+
+	if entry.Type == EntryTypeEnd {
+		message = fmt.Sprintf("Workflow run completed with %s", entry.Message)
+	}
+
+	switch {
+	case entry.Job != "" && entry.Step != "":
+		return fmt.Sprintf("[%s ▸ %s] %s", entry.Job, entry.Step, message)
+	case entry.Job != "":
+		return fmt.Sprintf("[%s] %s", entry.Job, message)
+	default:
+		return message
+	}
+}
+
+// consoleSink writes the entries as text lines to the writer.
+type consoleSink struct {
+	w io.Writer
+}
+
+// NewConsoleSink creates a sink writing the entries as text lines to the given writer.
+func NewConsoleSink(w io.Writer) Sink {
+	return &consoleSink{w: w}
+}
+
+func (s *consoleSink) Write(entry *Entry) error {
+	_, err := fmt.Fprintln(s.w, formatLine(entry))
+
+	return err
+}
+
+func (s *consoleSink) Close() error {
+	return nil
+}
+
+const (
+	// webhookBatchSize is the number of the entries to post to the webhook in a single request.
+	webhookBatchSize = 100
+
+	// webhookFlushInterval is the maximum time the entries wait in the batch before they're posted to the webhook.
+	webhookFlushInterval = 2 * time.Second
+)
+
+// webhookSink posts the entries to a webhook as JSON arrays in batches.
+type webhookSink struct {
+	url       string
+	client    *http.Client
+	batch     []*Entry
+	lastFlush time.Time
+}
+
+// NewWebhookSink creates a sink posting the entries to the url. Entries are posted when the batch is full, the batch
+// is older than the flush interval or the journal ends.
+func NewWebhookSink(url string) Sink {
+	return &webhookSink{url: url, client: &http.Client{Timeout: 10 * time.Second}, lastFlush: time.Now()}
+}
+
+func (s *webhookSink) Write(entry *Entry) error {
+	s.batch = append(s.batch, entry)
+
+	if len(s.batch) < webhookBatchSize && time.Since(s.lastFlush) < webhookFlushInterval && entry.Type != EntryTypeEnd {
+		return nil
+	}
+
+	return s.flush()
+}
+
+func (s *webhookSink) Close() error {
+	return s.flush()
+}
+
+// flush posts the entries in the batch to the webhook. The batch is dropped even if the post fails, so an unreachable
+// webhook doesn't grow the batch without a limit.
+func (s *webhookSink) flush() error {
+	if len(s.batch) == 0 {
+		return nil
+	}
+
+	batch := s.batch
+
+	s.batch = nil
+	s.lastFlush = time.Now()
+
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to post journal entries: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("failed to post journal entries: %s", resp.Status)
+	}
+
+	return nil
+}
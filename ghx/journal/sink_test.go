@@ -0,0 +1,154 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOpenSink(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: "console"},
+		{spec: "console=stdout"},
+		{spec: "file=" + filepath.Join(t.TempDir(), "journal.ndjson")},
+		{spec: "webhook=http://logs.example.com"},
+		{spec: "console=printer", wantErr: true},
+		{spec: "file", wantErr: true},
+		{spec: "webhook", wantErr: true},
+		{spec: "syslog=logs.example.com:514", wantErr: true},
+		{spec: "kafka=logs:9092", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			sink, err := OpenSink(tt.spec, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OpenSink() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if sink != nil {
+				sink.Close()
+			}
+		})
+	}
+}
+
+func TestConsoleSink(t *testing.T) {
+	var buf bytes.Buffer
+
+	sink := NewConsoleSink(&buf)
+
+	for _, entry := range []*Entry{
+		{Type: EntryTypeExecution, Message: "starting"},
+		{Type: EntryTypeExecution, Message: "hello", Job: "build"},
+		{Type: EntryTypeExecution, Message: "world", Job: "build", Step: "Run tests"},
+		{Type: EntryTypeEnd, Message: "success"},
+	} {
+		if err := sink.Write(entry); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	expected := "starting\n[build] hello\n[build ▸ Run tests] world\nWorkflow run completed with success\n"
+
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches [][]Entry
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []Entry
+
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("failed to decode batch: %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		batches = append(batches, batch)
+	}))
+	defer server.Close()
+
+	posted := func() [][]Entry {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([][]Entry(nil), batches...)
+	}
+
+	sink := NewWebhookSink(server.URL)
+
+	for i := 0; i < webhookBatchSize+1; i++ {
+		if err := sink.Write(&Entry{Type: EntryTypeExecution, Message: "line"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got := posted(); len(got) != 1 || len(got[0]) != webhookBatchSize {
+		t.Fatalf("expected a full batch to be posted, got %d batches", len(got))
+	}
+
+	// end of the journal flushes the pending entries
+	if err := sink.Write(&Entry{Type: EntryTypeEnd, Message: "success"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := posted(); len(got) != 2 || len(got[1]) != 2 || got[1][1].Type != EntryTypeEnd {
+		t.Errorf("expected the pending entries with the end entry, got %v", got)
+	}
+}
+
+func TestJournaldSink(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unix datagram sockets are not supported: %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := OpenSink("journald", socket)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sink.Close()
+
+	if err := sink.Write(&Entry{Type: EntryTypeExecution, Message: "first\nsecond", Job: "build"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buf := make([]byte, 1024)
+
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	message := string(buf[:n])
+
+	for _, field := range []string{"MESSAGE\n", "[build] first\nsecond\n", "SYSLOG_IDENTIFIER=gale\n", "GALE_JOB=build\n"} {
+		if !strings.Contains(message, field) {
+			t.Errorf("expected %q in the journald message %q", field, message)
+		}
+	}
+
+	if strings.Contains(message, "GALE_STEP") {
+		t.Errorf("expected empty fields to be omitted, got %q", message)
+	}
+}
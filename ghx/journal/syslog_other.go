@@ -0,0 +1,15 @@
+//go:build !unix
+
+package journal
+
+import "errors"
+
+// openSyslogSink returns an error since the syslog is not supported on this platform.
+func openSyslogSink(_ string) (Sink, error) {
+	return nil, errors.New("syslog journal sink is not supported on this platform")
+}
+
+// openJournaldSink returns an error since the journald is not supported on this platform.
+func openJournaldSink(_ string) (Sink, error) {
+	return nil, errors.New("journald journal sink is not supported on this platform")
+}
@@ -0,0 +1,110 @@
+//go:build unix
+
+package journal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log/syslog"
+	"net"
+	"strings"
+)
+
+// syslogTag is the tag of the syslog messages and the identifier of the journald entries.
+const syslogTag = "gale"
+
+// syslogSink writes the entries to the syslog.
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+// openSyslogSink opens the sink writing to the syslog at the address in <network>://<address> format, e.g.
+// udp://logs.example.com:514. Empty address writes to the local syslog.
+func openSyslogSink(address string) (Sink, error) {
+	var network, raddr string
+
+	if address != "" {
+		var ok bool
+
+		network, raddr, ok = strings.Cut(address, "://")
+		if !ok {
+			return nil, fmt.Errorf("invalid syslog address %s, expected format is <network>://<address>", address)
+		}
+	}
+
+	writer, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_USER, syslogTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+
+	return &syslogSink{writer: writer}, nil
+}
+
+func (s *syslogSink) Write(entry *Entry) error {
+	return s.writer.Info(formatLine(entry))
+}
+
+func (s *syslogSink) Close() error {
+	return s.writer.Close()
+}
+
+// journaldSink writes the entries to the journald with the native protocol, keeping the job and the step of the entry
+// as the GALE_JOB and GALE_STEP fields.
+type journaldSink struct {
+	conn net.Conn
+}
+
+// openJournaldSink opens the sink writing to the journald socket at the given path.
+func openJournaldSink(socket string) (Sink, error) {
+	conn, err := net.Dial("unixgram", socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+
+	return &journaldSink{conn: conn}, nil
+}
+
+func (s *journaldSink) Write(entry *Entry) error {
+	var buf bytes.Buffer
+
+	for _, field := range [][2]string{
+		{"MESSAGE", formatLine(entry)},
+		{"PRIORITY", "6"},
+		{"SYSLOG_IDENTIFIER", syslogTag},
+		{"GALE_ENTRY_TYPE", string(entry.Type)},
+		{"GALE_JOB", entry.Job},
+		{"GALE_STEP", entry.Step},
+	} {
+		if field[1] == "" {
+			continue
+		}
+
+		writeJournaldField(&buf, field[0], field[1])
+	}
+
+	_, err := s.conn.Write(buf.Bytes())
+
+	return err
+}
+
+func (s *journaldSink) Close() error {
+	return s.conn.Close()
+}
+
+// writeJournaldField writes the field in the format of the journald native protocol. Values with new lines are written
+// with their length in binary, the others as KEY=VALUE lines.
+func writeJournaldField(buf *bytes.Buffer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(buf, "%s=%s\n", key, value)
+		return
+	}
+
+	buf.WriteString(key)
+	buf.WriteByte('\n')
+
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
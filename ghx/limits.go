@@ -0,0 +1,87 @@
+package ghx
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+)
+
+// cgroupRoot is the root directory of the cgroup v2 hierarchy used to limit the memory of the jobs.
+const cgroupRoot = "/sys/fs/cgroup/gale"
+
+var (
+	// limitWarnings keeps track of the unsupported limits warned for the jobs to warn only once per job and limit.
+	limitWarnings = make(map[string]bool)
+
+	// limitWarningsMu guards the limit warnings, since the steps of the jobs could run concurrently.
+	limitWarningsMu sync.Mutex
+)
+
+// withCPULimit returns the command arguments pinned to the number of CPUs in the limits. If taskset is not available
+// in the runner image, the arguments are returned as is.
+func withCPULimit(job string, limits context.Limits, args []string) []string {
+	if limits.CPU == 0 {
+		return args
+	}
+
+	if _, err := exec.LookPath("taskset"); err != nil {
+		warnLimit(job, "cpu", "cpu limit is ignored, taskset is not available in the runner image")
+
+		return args
+	}
+
+	cpus := limits.CPU
+
+	if cpus > runtime.NumCPU() {
+		cpus = runtime.NumCPU()
+	}
+
+	return append([]string{"taskset", "-c", fmt.Sprintf("0-%d", cpus-1)}, args...)
+}
+
+// applyMemoryLimit moves the process to the cgroup of the job with the memory limit. Memory limit requires a writable
+// cgroup v2 hierarchy, otherwise the limit is ignored with a warning.
+func applyMemoryLimit(job string, limits context.Limits, pid int) {
+	if limits.Memory == 0 {
+		return
+	}
+
+	dir := filepath.Join(cgroupRoot, job)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		warnLimit(job, "mem", fmt.Sprintf("memory limit is ignored, cgroup is not writable: %v", err))
+		return
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(strconv.FormatUint(limits.Memory, 10)), 0644); err != nil {
+		warnLimit(job, "mem", fmt.Sprintf("memory limit is ignored, failed to set memory.max: %v", err))
+		return
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0644); err != nil {
+		warnLimit(job, "mem", fmt.Sprintf("memory limit is ignored, failed to move process to cgroup: %v", err))
+	}
+}
+
+// warnLimit logs the given warning once per job and limit.
+func warnLimit(job, limit, message string) {
+	key := fmt.Sprintf("%s/%s", job, limit)
+
+	limitWarningsMu.Lock()
+	defer limitWarningsMu.Unlock()
+
+	if limitWarnings[key] {
+		return
+	}
+
+	limitWarnings[key] = true
+
+	log.Warnf(message, "job", job)
+}
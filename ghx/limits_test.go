@@ -0,0 +1,43 @@
+package ghx
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+)
+
+func TestWithCPULimit(t *testing.T) {
+	args := []string{"bash", "-e", "script.sh"}
+
+	if got := withCPULimit("build", context.Limits{}, args); !reflect.DeepEqual(got, args) {
+		t.Errorf("expected %v without a cpu limit, got %v", args, got)
+	}
+}
+
+func TestWarnLimitConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			warnLimit(fmt.Sprintf("job-%d", i%5), "mem", "memory limit is ignored")
+		}(i)
+	}
+
+	wg.Wait()
+
+	limitWarningsMu.Lock()
+	defer limitWarningsMu.Unlock()
+
+	for i := 0; i < 5; i++ {
+		if key := fmt.Sprintf("job-%d/mem", i); !limitWarnings[key] {
+			t.Errorf("expected warning %s to be recorded", key)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package ghx
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// lintSeverity is the severity of a lint finding.
+type lintSeverity string
+
+const (
+	// lintSeverityError is the severity of the constructs gale can't execute. The workflow is expected to fail.
+	lintSeverityError lintSeverity = "error"
+
+	// lintSeverityWarning is the severity of the constructs gale ignores. The workflow runs with a different behavior.
+	lintSeverityWarning lintSeverity = "warning"
+
+	// lintSeverityInfo is the severity of the constructs gale partially supports or simulates.
+	lintSeverityInfo lintSeverity = "info"
+)
+
+// lintRule is the severity and the message of an unsupported construct.
+type lintRule struct {
+	Severity lintSeverity
+	Message  string
+}
+
+// lintFinding is an unsupported construct found in the workflow.
+type lintFinding struct {
+	Severity lintSeverity
+	Job      string
+	Step     string
+	Message  string
+}
+
+// String returns the finding in the format of severity: [job/step] message.
+func (f lintFinding) String() string {
+	location := f.Job
+	if f.Step != "" {
+		location = fmt.Sprintf("%s/%s", f.Job, f.Step)
+	}
+
+	if location == "" {
+		return fmt.Sprintf("%s: %s", f.Severity, f.Message)
+	}
+
+	return fmt.Sprintf("%s: [%s] %s", f.Severity, location, f.Message)
+}
+
+// jobLintRules are the rules for the job keys gale doesn't fully support.
+var jobLintRules = map[string]lintRule{
+	"uses":              {lintSeverityError, "reusable workflows are not supported"},
+	"services":          {lintSeverityError, "service containers are not supported"},
+	"container":         {lintSeverityInfo, "job container image is used as the runner image, container options are ignored"},
+	"concurrency":       {lintSeverityWarning, "concurrency is ignored"},
+	"timeout-minutes":   {lintSeverityWarning, "job timeout is ignored"},
+	"continue-on-error": {lintSeverityWarning, "continue-on-error of the job is ignored"},
+	"defaults":          {lintSeverityWarning, "defaults are ignored"},
+	"permissions":       {lintSeverityInfo, "permissions are ignored, the token has the permissions it's created with"},
+}
+
+// strategyLintRules are the rules for the strategy keys gale doesn't fully support.
+var strategyLintRules = map[string]lintRule{
+	"fail-fast":    {lintSeverityWarning, "fail-fast is ignored, matrix jobs run until completion"},
+	"max-parallel": {lintSeverityWarning, "max-parallel is ignored, matrix jobs run sequentially"},
+}
+
+// stepLintRules are the rules for the step keys gale doesn't fully support.
+var stepLintRules = map[string]lintRule{
+	"working-directory": {lintSeverityWarning, "working-directory is ignored, step runs in the workspace"},
+	"timeout-minutes":   {lintSeverityWarning, "step timeout is ignored"},
+}
+
+// actionLintRules are the rules for the actions known to be incompatible with gale. Actions are matched by the prefix
+// of the uses value.
+var actionLintRules = map[string]lintRule{
+	"actions/upload-artifact@v4":   {lintSeverityError, "artifact service only supports upload-artifact v3 and earlier"},
+	"actions/download-artifact@v4": {lintSeverityError, "artifact service only supports download-artifact v3 and earlier"},
+	"actions/checkout":             {lintSeverityInfo, "workspace is already mounted, checkout of the workflow ref is skipped"},
+}
+
+// LintWorkflowFile lints the workflow file and prints the findings. It returns an error if the workflow has any error
+// findings or, in strict mode, any warning findings.
+func LintWorkflowFile(wf core.Workflow, strict bool) error {
+	data, err := os.ReadFile(wf.Path)
+	if err != nil {
+		return err
+	}
+
+	findings, err := lintWorkflow(data)
+	if err != nil {
+		return err
+	}
+
+	if len(findings) == 0 {
+		log.Info(fmt.Sprintf("No unsupported features found in %s", wf.Path))
+		return nil
+	}
+
+	counts := make(map[lintSeverity]int)
+
+	for _, finding := range findings {
+		counts[finding.Severity]++
+
+		switch finding.Severity {
+		case lintSeverityError:
+			log.Error(finding.String())
+		case lintSeverityWarning:
+			log.Warn(finding.String())
+		default:
+			log.Info(finding.String())
+		}
+	}
+
+	if counts[lintSeverityError] > 0 || (strict && counts[lintSeverityWarning] > 0) {
+		return fmt.Errorf("%s has %d error(s) and %d warning(s)", wf.Path, counts[lintSeverityError], counts[lintSeverityWarning])
+	}
+
+	return nil
+}
+
+// lintWorkflow statically analyzes the workflow and returns the constructs gale can't execute yet. Findings are sorted
+// by job and step to have a stable output.
+func lintWorkflow(data []byte) ([]lintFinding, error) {
+	var workflow struct {
+		On   yaml.Node `yaml:"on"`
+		Jobs map[string]struct {
+			Keys     map[string]yaml.Node   `yaml:",inline"`
+			RunsOn   core.RunsOn            `yaml:"runs-on"`
+			Strategy map[string]yaml.Node   `yaml:"strategy"`
+			Steps    []map[string]yaml.Node `yaml:"steps"`
+		} `yaml:"jobs"`
+	}
+
+	if err := yaml.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+
+	var findings []lintFinding
+
+	if hasEvent(&workflow.On, "workflow_call") {
+		findings = append(findings, lintFinding{Severity: lintSeverityInfo, Message: "workflow_call trigger is ignored, workflow runs as a regular workflow"})
+	}
+
+	for id, job := range workflow.Jobs {
+		for key, rule := range jobLintRules {
+			if _, ok := job.Keys[key]; ok {
+				findings = append(findings, lintFinding{Severity: rule.Severity, Job: id, Message: rule.Message})
+			}
+		}
+
+		for key, rule := range strategyLintRules {
+			if _, ok := job.Strategy[key]; ok {
+				findings = append(findings, lintFinding{Severity: rule.Severity, Job: id, Message: rule.Message})
+			}
+		}
+
+		for _, label := range job.RunsOn {
+			for _, prefix := range unsupportedLabelPrefixes {
+				if strings.HasPrefix(strings.ToLower(label), prefix) {
+					findings = append(findings, lintFinding{Severity: lintSeverityError, Job: id, Message: fmt.Sprintf("%s runners are not supported, job is skipped", label)})
+				}
+			}
+		}
+
+		for idx, step := range job.Steps {
+			name := fmt.Sprintf("%d", idx)
+			if node, ok := step["id"]; ok {
+				name = node.Value
+			}
+
+			for key, rule := range stepLintRules {
+				if _, ok := step[key]; ok {
+					findings = append(findings, lintFinding{Severity: rule.Severity, Job: id, Step: name, Message: rule.Message})
+				}
+			}
+
+			uses := step["uses"]
+
+			for prefix, rule := range actionLintRules {
+				if strings.HasPrefix(uses.Value, prefix) {
+					findings = append(findings, lintFinding{Severity: rule.Severity, Job: id, Step: name, Message: rule.Message})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Job != findings[j].Job {
+			return findings[i].Job < findings[j].Job
+		}
+
+		if findings[i].Step != findings[j].Step {
+			return findings[i].Step < findings[j].Step
+		}
+
+		return findings[i].Message < findings[j].Message
+	})
+
+	return findings, nil
+}
+
+// hasEvent returns true if the on node of the workflow contains the event. Scalar, sequence and mapping nodes are
+// supported.
+func hasEvent(on *yaml.Node, event string) bool {
+	switch on.Kind {
+	case yaml.ScalarNode:
+		return on.Value == event
+	case yaml.SequenceNode:
+		for _, node := range on.Content {
+			if node.Value == event {
+				return true
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(on.Content); i += 2 {
+			if on.Content[i].Value == event {
+				return true
+			}
+		}
+	}
+
+	return false
+}
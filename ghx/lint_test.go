@@ -0,0 +1,82 @@
+package ghx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintWorkflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		workflow string
+		expected []lintFinding
+	}{
+		{
+			name: "Supported workflow",
+			workflow: `
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - run: go test ./...
+`,
+		},
+		{
+			name: "Unsupported job keys",
+			workflow: `
+on: push
+jobs:
+  call:
+    uses: ./.github/workflows/reusable.yaml
+  test:
+    runs-on: ubuntu-latest
+    services:
+      redis:
+        image: redis
+    strategy:
+      fail-fast: false
+    steps:
+      - run: go test ./...
+`,
+			expected: []lintFinding{
+				{Severity: lintSeverityError, Job: "call", Message: "reusable workflows are not supported"},
+				{Severity: lintSeverityWarning, Job: "test", Message: "fail-fast is ignored, matrix jobs run until completion"},
+				{Severity: lintSeverityError, Job: "test", Message: "service containers are not supported"},
+			},
+		},
+		{
+			name: "Unsupported steps and runners",
+			workflow: `
+on: [push, workflow_call]
+jobs:
+  build:
+    runs-on: macos-latest
+    steps:
+      - id: upload
+        uses: actions/upload-artifact@v4
+      - run: make
+        working-directory: src
+`,
+			expected: []lintFinding{
+				{Severity: lintSeverityInfo, Message: "workflow_call trigger is ignored, workflow runs as a regular workflow"},
+				{Severity: lintSeverityError, Job: "build", Message: "macos-latest runners are not supported, job is skipped"},
+				{Severity: lintSeverityWarning, Job: "build", Step: "1", Message: "working-directory is ignored, step runs in the workspace"},
+				{Severity: lintSeverityError, Job: "build", Step: "upload", Message: "artifact service only supports upload-artifact v3 and earlier"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lintWorkflow([]byte(tt.workflow))
+			if err != nil {
+				t.Fatalf("lintWorkflow() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("lintWorkflow() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package ghx
+
+import (
+	stdContext "context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/aweris/gale/ghx/journal"
+)
+
+// PrintLogs prints the console output recorded to the given journal file with the job, the step and the elapsed time of
+// each line. With --follow, it keeps printing the new lines until the workflow run completes.
+//
+// Usage: ghx logs [--follow] <journal-file>
+func PrintLogs(args []string) error {
+	var (
+		follow bool
+		path   string
+	)
+
+	for _, arg := range args {
+		switch arg {
+		case "-f", "--follow":
+			follow = true
+		default:
+			path = arg
+		}
+	}
+
+	if path == "" {
+		return errors.New("journal file is required, usage: ghx logs [--follow] <journal-file>")
+	}
+
+	ctx, stop := signal.NotifyContext(stdContext.Background(), os.Interrupt)
+	defer stop()
+
+	return journal.ReadFile(ctx, path, follow, func(entry *journal.Entry) {
+		fmt.Println(entry.Prefix() + entry.Message)
+	})
+}
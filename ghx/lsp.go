@@ -0,0 +1,501 @@
+package ghx
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// lspCommandRunJob is the command of the code lenses running a job of the workflow with gale.
+const lspCommandRunJob = "gale.runJob"
+
+// lspDefaultModule is the gale module the run job command calls if GALE_MODULE is not set.
+const lspDefaultModule = "github.com/jpadams/gale/daggerverse/gale@main"
+
+// lspSeverities maps the lint severities to the diagnostic severities of the language server protocol.
+var lspSeverities = map[lintSeverity]int{
+	lintSeverityError:   1,
+	lintSeverityWarning: 2,
+	lintSeverityInfo:    3,
+}
+
+// lspExpressionDocs is the hover documentation of the expression contexts and functions.
+var lspExpressionDocs = map[string]string{
+	"github":     "Information about the workflow run and the event that triggered it. Gale fills it from the repository and the event of the run.",
+	"env":        "Variables set in the workflow, the job or the step.",
+	"vars":       "Configuration variables of the repository, the organization and the environment.",
+	"job":        "Information about the currently running job, e.g. job.status.",
+	"jobs":       "Outputs and results of the jobs of a reusable workflow.",
+	"steps":      "Outputs, outcomes and conclusions of the steps with an id that have already run in the job.",
+	"runner":     "Information about the runner executing the job, e.g. runner.os and runner.temp.",
+	"secrets":    "Secrets available to the workflow run. Values are masked in the logs.",
+	"strategy":   "Matrix execution strategy of the job, e.g. strategy.job-index.",
+	"matrix":     "Matrix properties of the current job combination.",
+	"needs":      "Outputs and results of the jobs the current job depends on.",
+	"inputs":     "Inputs of the workflow_dispatch or workflow_call event.",
+	"success":    "`success()` returns true when none of the previous steps have failed or been cancelled.",
+	"always":     "`always()` returns true, the step runs even when the workflow is cancelled.",
+	"failure":    "`failure()` returns true when any previous step of the job has failed.",
+	"cancelled":  "`cancelled()` returns true if the workflow was cancelled.",
+	"contains":   "`contains(search, item)` returns true if search contains item.",
+	"startsWith": "`startsWith(searchString, searchValue)` returns true when searchString starts with searchValue.",
+	"endsWith":   "`endsWith(searchString, searchValue)` returns true if searchString ends with searchValue.",
+	"format":     "`format(string, replaceValue0, replaceValue1, ...)` replaces the {N} placeholders with the values.",
+	"join":       "`join(array, optionalSeparator)` concatenates the values of the array.",
+	"toJSON":     "`toJSON(value)` returns a pretty-print JSON representation of the value.",
+	"fromJSON":   "`fromJSON(value)` returns a JSON object or JSON data type for the value.",
+	"hashFiles":  "`hashFiles(path)` returns a single hash for the set of files that matches the path pattern.",
+}
+
+// errLSPMethodNotFound is returned for the requests of the methods the server doesn't support.
+var errLSPMethodNotFound = errors.New("method not found")
+
+// yamlErrorLine extracts the line of the yaml parse errors, e.g. yaml: line 3: mapping values are not allowed.
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// lspMessage is a JSON-RPC message of the language server protocol.
+type lspMessage struct {
+	ID     *json.RawMessage `json:"id,omitempty"`
+	Method string           `json:"method,omitempty"`
+	Params json.RawMessage  `json:"params,omitempty"`
+}
+
+// lspPosition is a zero based position in a text document.
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// lspRange is a range in a text document.
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+// lspDiagnostic is a finding of the workflow reported to the editor.
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+// lspCommand is a command the editor executes with the workspace/executeCommand request.
+type lspCommand struct {
+	Title     string        `json:"title"`
+	Command   string        `json:"command"`
+	Arguments []interface{} `json:"arguments,omitempty"`
+}
+
+// lspCodeLens is a command shown inline in the workflow.
+type lspCodeLens struct {
+	Range   lspRange   `json:"range"`
+	Command lspCommand `json:"command"`
+}
+
+// lspServer is a language server for the workflow files. It reports the lint findings as diagnostics, documents the
+// expression contexts on hover and adds code lenses running the jobs with gale.
+type lspServer struct {
+	in   *bufio.Reader
+	out  io.Writer
+	docs map[string]string // docs is the content of the open documents by their uri
+}
+
+// ServeLSP serves the language server protocol over stdio until the client sends the exit notification.
+//
+// Usage: ghx lsp
+func ServeLSP(in io.Reader, out io.Writer) error {
+	server := &lspServer{in: bufio.NewReader(in), out: out, docs: make(map[string]string)}
+
+	for {
+		msg, err := server.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		result, err := server.handle(msg)
+
+		// notifications don't have a response
+		if msg.ID == nil {
+			continue
+		}
+
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID}
+
+		switch {
+		case errors.Is(err, errLSPMethodNotFound):
+			response["error"] = map[string]interface{}{"code": -32601, "message": err.Error()}
+		case err != nil:
+			response["error"] = map[string]interface{}{"code": -32603, "message": err.Error()}
+		default:
+			response["result"] = result
+		}
+
+		if err := server.write(response); err != nil {
+			return err
+		}
+	}
+}
+
+// read reads the next message framed with the Content-Length header.
+func (s *lspServer) read() (*lspMessage, error) {
+	length := -1
+
+	for {
+		line, err := s.in.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			break
+		}
+
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(name, "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid content length %s", value)
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, errors.New("missing content length header")
+	}
+
+	body := make([]byte, length)
+
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+
+	var msg lspMessage
+
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	return &msg, nil
+}
+
+// write writes the message framed with the Content-Length header.
+func (s *lspServer) write(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(data), data)
+
+	return err
+}
+
+// handle handles the message and returns the result of the requests.
+func (s *lspServer) handle(msg *lspMessage) (interface{}, error) {
+	var params struct {
+		TextDocument struct {
+			URI  string `json:"uri"`
+			Text string `json:"text"`
+		} `json:"textDocument"`
+		ContentChanges []struct {
+			Text string `json:"text"`
+		} `json:"contentChanges"`
+		Position  lspPosition       `json:"position"`
+		Command   string            `json:"command"`
+		Arguments []json.RawMessage `json:"arguments"`
+	}
+
+	if len(msg.Params) > 0 {
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+	}
+
+	uri := params.TextDocument.URI
+
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":       1, // full document is sent on each change
+				"hoverProvider":          true,
+				"codeLensProvider":       map[string]interface{}{},
+				"executeCommandProvider": map[string]interface{}{"commands": []string{lspCommandRunJob}},
+			},
+			"serverInfo": map[string]interface{}{"name": "ghx", "version": Version},
+		}, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		s.docs[uri] = params.TextDocument.Text
+
+		return nil, s.publishDiagnostics(uri)
+	case "textDocument/didChange":
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+
+		s.docs[uri] = params.ContentChanges[len(params.ContentChanges)-1].Text
+
+		return nil, s.publishDiagnostics(uri)
+	case "textDocument/didClose":
+		delete(s.docs, uri)
+
+		return nil, s.write(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  "textDocument/publishDiagnostics",
+			"params":  map[string]interface{}{"uri": uri, "diagnostics": []lspDiagnostic{}},
+		})
+	case "textDocument/hover":
+		contents := lspHover(s.docs[uri], params.Position)
+		if contents == "" {
+			return nil, nil
+		}
+
+		return map[string]interface{}{"contents": map[string]string{"kind": "markdown", "value": contents}}, nil
+	case "textDocument/codeLens":
+		return lspCodeLenses(uri, s.docs[uri]), nil
+	case "workspace/executeCommand":
+		if params.Command != lspCommandRunJob || len(params.Arguments) != 2 {
+			return nil, fmt.Errorf("unsupported command %s", params.Command)
+		}
+
+		var workflow, job string
+
+		if err := json.Unmarshal(params.Arguments[0], &workflow); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(params.Arguments[1], &job); err != nil {
+			return nil, err
+		}
+
+		return lspRunJobCommand(workflow, job), nil
+	default:
+		if msg.ID != nil {
+			return nil, fmt.Errorf("%w: %s", errLSPMethodNotFound, msg.Method)
+		}
+
+		return nil, nil
+	}
+}
+
+// publishDiagnostics sends the diagnostics of the document to the client.
+func (s *lspServer) publishDiagnostics(uri string) error {
+	return s.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "textDocument/publishDiagnostics",
+		"params":  map[string]interface{}{"uri": uri, "diagnostics": lspDiagnostics(s.docs[uri])},
+	})
+}
+
+// lspDiagnostics returns the lint findings of the workflow as diagnostics positioned at their jobs and steps. Parse
+// errors are reported at the line of the error.
+func lspDiagnostics(text string) []lspDiagnostic {
+	diagnostics := make([]lspDiagnostic, 0)
+
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal([]byte(text), &doc); err != nil {
+		line := 0
+
+		if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+			line, _ = strconv.Atoi(match[1])
+			line--
+		}
+
+		return append(diagnostics, lspDiagnostic{Range: lspLineRange(line, 0), Severity: 1, Source: "gale", Message: err.Error()})
+	}
+
+	findings, err := lintWorkflow([]byte(text))
+	if err != nil {
+		return append(diagnostics, lspDiagnostic{Severity: 1, Source: "gale", Message: err.Error()})
+	}
+
+	for _, finding := range findings {
+		line, column := findingPosition(&doc, finding)
+
+		diagnostics = append(diagnostics, lspDiagnostic{
+			Range:    lspLineRange(line, column),
+			Severity: lspSeverities[finding.Severity],
+			Source:   "gale",
+			Message:  finding.Message,
+		})
+	}
+
+	return diagnostics
+}
+
+// findingPosition returns the zero based line and column of the job or the step of the finding in the workflow.
+// Findings of the workflow itself are positioned at the start of the document.
+func findingPosition(doc *yaml.Node, finding lintFinding) (int, int) {
+	if len(doc.Content) == 0 || finding.Job == "" {
+		return 0, 0
+	}
+
+	_, jobs := mappingEntry(doc.Content[0], "jobs")
+
+	key, job := mappingEntry(jobs, finding.Job)
+	if key == nil {
+		return 0, 0
+	}
+
+	_, steps := mappingEntry(job, "steps")
+
+	if finding.Step != "" && steps != nil && steps.Kind == yaml.SequenceNode {
+		for idx, step := range steps.Content {
+			_, id := mappingEntry(step, "id")
+
+			if (id != nil && id.Value == finding.Step) || (id == nil && strconv.Itoa(idx) == finding.Step) {
+				return step.Line - 1, step.Column - 1
+			}
+		}
+	}
+
+	return key.Line - 1, key.Column - 1
+}
+
+// mappingEntry returns the key and the value nodes of the given key in the mapping node. It returns nil nodes if the
+// node is not a mapping or the key doesn't exist.
+func mappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+
+	return nil, nil
+}
+
+// lspHover returns the documentation of the expression context or function at the position. It returns an empty string
+// if the position is not in an expression.
+func lspHover(text string, pos lspPosition) string {
+	lines := strings.Split(text, "\n")
+	if pos.Line >= len(lines) {
+		return ""
+	}
+
+	line := lines[pos.Line]
+	if pos.Character > len(line) {
+		return ""
+	}
+
+	// position must be between ${{ and }} of the same line
+	open := strings.LastIndex(line[:pos.Character], "${{")
+	if open < 0 || strings.Contains(line[open:pos.Character], "}}") || !strings.Contains(line[pos.Character:], "}}") {
+		return ""
+	}
+
+	isIdent := func(b byte) bool {
+		return b == '_' || b == '-' || b == '.' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+	}
+
+	start, end := pos.Character, pos.Character
+
+	for start > 0 && isIdent(line[start-1]) {
+		start--
+	}
+
+	for end < len(line) && isIdent(line[end]) {
+		end++
+	}
+
+	word := line[start:end]
+	name, _, _ := strings.Cut(word, ".")
+
+	doc, ok := lspExpressionDocs[name]
+	if !ok {
+		return ""
+	}
+
+	return fmt.Sprintf("**%s**\n\n%s", word, doc)
+}
+
+// lspCodeLenses returns the code lenses running each job of the workflow with gale.
+func lspCodeLenses(uri, text string) []lspCodeLens {
+	lenses := make([]lspCodeLens, 0)
+
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal([]byte(text), &doc); err != nil || len(doc.Content) == 0 {
+		return lenses
+	}
+
+	workflow := lspWorkflowName(uri)
+
+	if _, name := mappingEntry(doc.Content[0], "name"); name != nil && name.Value != "" {
+		workflow = name.Value
+	}
+
+	_, jobs := mappingEntry(doc.Content[0], "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return lenses
+	}
+
+	for i := 0; i+1 < len(jobs.Content); i += 2 {
+		key := jobs.Content[i]
+
+		lenses = append(lenses, lspCodeLens{
+			Range:   lspLineRange(key.Line-1, key.Column-1),
+			Command: lspCommand{Title: "▶ Run job", Command: lspCommandRunJob, Arguments: []interface{}{workflow, key.Value}},
+		})
+	}
+
+	return lenses
+}
+
+// lspWorkflowName returns the path of the workflow file relative to the repository, e.g. .github/workflows/ci.yml, the
+// name gale uses for the workflows without a name.
+func lspWorkflowName(uri string) string {
+	path := uri
+
+	if parsed, err := url.Parse(uri); err == nil && parsed.Scheme == "file" {
+		path = parsed.Path
+	}
+
+	if idx := strings.LastIndex(path, ".github/"); idx >= 0 {
+		return path[idx:]
+	}
+
+	return path
+}
+
+// lspRunJobCommand returns the command line running the job of the workflow with gale. The editor runs it in the
+// repository root, e.g. in a terminal.
+func lspRunJobCommand(workflow, job string) []string {
+	module := os.Getenv("GALE_MODULE")
+	if module == "" {
+		module = lspDefaultModule
+	}
+
+	return []string{"dagger", "call", "-m", module, "workflows", "run", "--source", ".", "--workflow", workflow, "--job", job, "result"}
+}
+
+// lspLineRange returns the range from the position to the end of the line.
+func lspLineRange(line, column int) lspRange {
+	// end character beyond the line length is clamped to the end of the line by the editors
+	return lspRange{Start: lspPosition{Line: line, Character: column}, End: lspPosition{Line: line, Character: 1 << 16}}
+}
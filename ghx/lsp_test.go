@@ -0,0 +1,135 @@
+package ghx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const lspTestWorkflow = `name: CI
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    timeout-minutes: 10
+    steps:
+      - uses: actions/checkout@v4
+      - id: test
+        run: echo ${{ github.sha }}
+        working-directory: src
+`
+
+func TestLSPDiagnostics(t *testing.T) {
+	diagnostics := lspDiagnostics(lspTestWorkflow)
+
+	var got []string
+
+	for _, d := range diagnostics {
+		got = append(got, fmt.Sprintf("%d:%d %d %s", d.Range.Start.Line, d.Range.Start.Character, d.Severity, d.Message))
+	}
+
+	want := []string{
+		"3:2 2 job timeout is ignored",
+		"7:8 3 workspace is already mounted, checkout of the workflow ref is skipped",
+		"8:8 2 working-directory is ignored, step runs in the workspace",
+	}
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	diagnostics = lspDiagnostics("jobs:\n  build:\n  - foo\n bar: baz")
+	if len(diagnostics) != 1 || diagnostics[0].Severity != 1 || diagnostics[0].Range.Start.Line == 0 {
+		t.Errorf("expected a parse error diagnostic with its line, got %+v", diagnostics)
+	}
+}
+
+func TestLSPHover(t *testing.T) {
+	tests := []struct {
+		pos  lspPosition
+		want string
+	}{
+		{lspPosition{Line: 9, Character: 28}, "**github.sha**"},
+		{lspPosition{Line: 9, Character: 14}, ""}, // outside the expression
+		{lspPosition{Line: 4, Character: 6}, ""},
+	}
+
+	for _, tt := range tests {
+		got := lspHover(lspTestWorkflow, tt.pos)
+
+		if !strings.HasPrefix(got, tt.want) || (tt.want == "" && got != "") {
+			t.Errorf("lspHover(%+v) = %q, want prefix %q", tt.pos, got, tt.want)
+		}
+	}
+}
+
+func TestLSPCodeLenses(t *testing.T) {
+	lenses := lspCodeLenses("file:///repo/.github/workflows/ci.yml", lspTestWorkflow)
+
+	if len(lenses) != 1 {
+		t.Fatalf("expected 1 code lens, got %d", len(lenses))
+	}
+
+	if lenses[0].Range.Start.Line != 3 || fmt.Sprint(lenses[0].Command.Arguments) != "[CI build]" {
+		t.Errorf("unexpected code lens %+v", lenses[0])
+	}
+
+	if name := lspWorkflowName("file:///repo/.github/workflows/ci.yml"); name != ".github/workflows/ci.yml" {
+		t.Errorf("lspWorkflowName = %s", name)
+	}
+}
+
+func TestServeLSP(t *testing.T) {
+	var in bytes.Buffer
+
+	send := func(msg string) {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	send(fmt.Sprintf(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///ci.yml","text":%q}}}`, lspTestWorkflow))
+	send(`{"jsonrpc":"2.0","id":2,"method":"workspace/executeCommand","params":{"command":"gale.runJob","arguments":["CI","build"]}}`)
+	send(`{"jsonrpc":"2.0","id":3,"method":"unknown"}`)
+	send(`{"jsonrpc":"2.0","method":"exit"}`)
+
+	var out bytes.Buffer
+
+	if err := ServeLSP(&in, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server := &lspServer{in: bufio.NewReader(bytes.NewReader(out.Bytes()))}
+
+	var messages []map[string]json.RawMessage
+
+	for {
+		msg, err := server.read()
+		if err != nil {
+			break
+		}
+
+		raw := map[string]json.RawMessage{"method": json.RawMessage(fmt.Sprintf("%q", msg.Method))}
+
+		if msg.ID != nil {
+			raw["id"] = *msg.ID
+		}
+
+		messages = append(messages, raw)
+	}
+
+	// initialize response, published diagnostics, execute command response and method not found error
+	if len(messages) != 4 {
+		t.Fatalf("expected 4 messages, got %d: %s", len(messages), out.String())
+	}
+
+	if !strings.Contains(out.String(), `"textDocument/publishDiagnostics"`) || !strings.Contains(out.String(), `"code":-32601`) {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+
+	if !strings.Contains(out.String(), `"--workflow","CI","--job","build"`) {
+		t.Errorf("expected the run job command line, got: %s", out.String())
+	}
+}
@@ -0,0 +1,133 @@
+package ghx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"dagger.io/dagger"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// allowUnsecureNodeEnv is the environment variable to opt out running the actions using deprecated node versions with
+// node20.
+const allowUnsecureNodeEnv = "ACTIONS_ALLOW_USE_UNSECURE_NODE_VERSION"
+
+// nodeRuntimeImage is the image the node runtimes are installed from. The version is the major version of node.
+const nodeRuntimeImage = "node:%s-bookworm-slim"
+
+// nodeBinary returns the node binary to run the javascript action with. Like GitHub, actions using node12 or node16 run
+// with node20 unless ACTIONS_ALLOW_USE_UNSECURE_NODE_VERSION is true, then node16 is used. The runtime is installed to
+// the externals directory on first use. If it can't be installed, node in the PATH is used.
+func nodeBinary(ctx *context.Context, using core.CustomActionRunsUsing) string {
+	version := nodeVersion(ctx, using)
+
+	if using != core.ActionRunsUsingNode20 && version == core.ActionRunsUsingNode20 {
+		log.Warnf("Action uses a deprecated node version, running with node20 instead", "using", using, "opt-out", allowUnsecureNodeEnv+"=true")
+	}
+
+	if err := ensureNodeRuntime(ctx, version); err != nil {
+		log.Warnf("Node runtime is not available, using node in the PATH", "version", version, "error", err)
+
+		return "node"
+	}
+
+	return filepath.Join(ctx.GhxConfig.ExternalsDir, string(version), "bin", "node")
+}
+
+// nodeVersion returns the node runtime to run the actions using the given node version with.
+func nodeVersion(ctx *context.Context, using core.CustomActionRunsUsing) core.CustomActionRunsUsing {
+	if using != core.ActionRunsUsingNode20 && allowUnsecureNode(ctx) {
+		return core.ActionRunsUsingNode16
+	}
+
+	return core.ActionRunsUsingNode20
+}
+
+// ensureNodeRuntime installs the given node runtime, e.g. node20, to the externals directory unless it's already
+// installed. The runtime is copied from the node image with its libraries, e.g. npm, like the externals of the GitHub
+// runner. In offline mode, the runtime is only installed from a prefetched image.
+func ensureNodeRuntime(ctx *context.Context, version core.CustomActionRunsUsing) error {
+	dir := filepath.Join(ctx.GhxConfig.ExternalsDir, string(version))
+
+	// the runtimes are shared by the concurrent workflow runs through the externals cache
+	return fs.WithLock(dir, func() error {
+		if nodeInstalled(ctx, version) {
+			return nil
+		}
+
+		if ctx.Dagger.Client == nil {
+			return errNoEngine
+		}
+
+		image := nodeImage(version)
+
+		if ctx.GhxConfig.Offline {
+			index, err := readImagesIndex(ctx)
+			if err != nil {
+				return err
+			}
+
+			if _, ok := index[image]; !ok {
+				return fmt.Errorf("image %s is not prefetched and offline mode is enabled", image)
+			}
+		}
+
+		source := ctx.Dagger.Client.
+			Container(dagger.ContainerOpts{Platform: dagger.Platform("linux/" + runtime.GOARCH)}).
+			From(resolveImage(ctx, image)).
+			Directory("/usr/local")
+
+		// export to a temporary directory first, so an interrupted export doesn't leave a broken runtime behind
+		tmp := dir + ".tmp"
+
+		if err := os.RemoveAll(tmp); err != nil {
+			return err
+		}
+
+		if _, err := source.Export(ctx.Context, tmp); err != nil {
+			return fmt.Errorf("failed to export node runtime from %s: %w", image, err)
+		}
+
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+
+		if err := os.Rename(tmp, dir); err != nil {
+			return err
+		}
+
+		log.Debugf("Node runtime is installed", "version", version, "image", image, "path", dir)
+
+		return nil
+	})
+}
+
+// nodeImage returns the image to install the given node runtime from.
+func nodeImage(version core.CustomActionRunsUsing) string {
+	return fmt.Sprintf(nodeRuntimeImage, strings.TrimPrefix(string(version), "node"))
+}
+
+// nodeInstalled returns true if the given node runtime is installed to the externals directory.
+func nodeInstalled(ctx *context.Context, version core.CustomActionRunsUsing) bool {
+	exist, _ := fs.Exists(filepath.Join(ctx.GhxConfig.ExternalsDir, string(version), "bin", "node"))
+
+	return exist
+}
+
+// allowUnsecureNode returns true if ACTIONS_ALLOW_USE_UNSECURE_NODE_VERSION is set to true in the env context or in the
+// runner environment.
+func allowUnsecureNode(ctx *context.Context) bool {
+	value, ok := ctx.Env[allowUnsecureNodeEnv]
+	if !ok {
+		value = os.Getenv(allowUnsecureNodeEnv)
+	}
+
+	return strings.EqualFold(strings.TrimSpace(value), "true")
+}
@@ -0,0 +1,69 @@
+package ghx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestNodeBinary(t *testing.T) {
+	tests := []struct {
+		name      string
+		using     core.CustomActionRunsUsing
+		env       context.EnvContext
+		installed []string
+		expected  string
+	}{
+		{name: "node20", using: core.ActionRunsUsingNode20, installed: []string{"node16", "node20"}, expected: "node20"},
+		{name: "node16 runs with node20", using: core.ActionRunsUsingNode16, installed: []string{"node16", "node20"}, expected: "node20"},
+		{name: "node12 runs with node20", using: core.ActionRunsUsingNode12, installed: []string{"node20"}, expected: "node20"},
+		{
+			name:      "node16 with unsecure node allowed",
+			using:     core.ActionRunsUsingNode16,
+			env:       context.EnvContext{allowUnsecureNodeEnv: "true"},
+			installed: []string{"node16", "node20"},
+			expected:  "node16",
+		},
+		{
+			name:      "node20 with unsecure node allowed",
+			using:     core.ActionRunsUsingNode20,
+			env:       context.EnvContext{allowUnsecureNodeEnv: "true"},
+			installed: []string{"node16", "node20"},
+			expected:  "node20",
+		},
+		{name: "runtime not prefetched in offline mode", using: core.ActionRunsUsingNode20, installed: []string{"node16"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, &core.JobRun{RunID: "1", Job: core.Job{ID: "build"}})
+			ctx.GhxConfig.ExternalsDir = t.TempDir()
+			ctx.GhxConfig.Offline = true
+			ctx.Env = tt.env
+
+			for _, version := range tt.installed {
+				bin := filepath.Join(ctx.GhxConfig.ExternalsDir, version, "bin")
+
+				if err := os.MkdirAll(bin, 0755); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if err := os.WriteFile(filepath.Join(bin, "node"), nil, 0755); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			expected := "node"
+			if tt.expected != "" {
+				expected = filepath.Join(ctx.GhxConfig.ExternalsDir, tt.expected, "bin", "node")
+			}
+
+			if got := nodeBinary(ctx, tt.using); got != expected {
+				t.Errorf("expected %s, got %s", expected, got)
+			}
+		})
+	}
+}
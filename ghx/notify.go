@@ -0,0 +1,333 @@
+package ghx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// notificationsEnv is the environment variable with the notifiers to send the workflow run notifications to as JSON.
+const notificationsEnv = "GHX_NOTIFICATIONS"
+
+// Events of the workflow runs to send notifications for.
+const (
+	notifyEventSuccess   = "success"   // notifyEventSuccess is a successful run, including the recovered runs
+	notifyEventFailure   = "failure"   // notifyEventFailure is a failed run
+	notifyEventRecovered = "recovered" // notifyEventRecovered is a successful run after a failed run of the same workflow
+)
+
+// defaultNotifyTemplate is the default message template of the notifications.
+const defaultNotifyTemplate = `Workflow {{ .Name }} (run {{ .RunID }}) {{ .Event }}: {{ .Conclusion }} in {{ .Duration }}
+{{- range .Jobs }}
+- {{ .Name }}: {{ .Conclusion }} in {{ .Duration }}
+{{- end }}`
+
+// maxDiscordMessageLen is the maximum length of the content of a discord message.
+const maxDiscordMessageLen = 2000
+
+// notifier represents a destination of the workflow run notifications.
+type notifier struct {
+	Type     string   `json:"type"`     // Type is the type of the destination. Possible values are: webhook, slack, discord. Defaults to webhook.
+	URL      string   `json:"url"`      // URL is the webhook URL to post the notifications to.
+	Events   []string `json:"events"`   // Events is the list of events to notify. Defaults to failure and recovered.
+	Template string   `json:"template"` // Template is the Go template of the message. Defaults to the run summary.
+}
+
+// notification represents the workflow run sent to the notifiers.
+type notification struct {
+	Event       string            `json:"event"`              // Event is the event of the run, one of success, failure or recovered
+	Name        string            `json:"name"`               // Name is the name of the workflow
+	Path        string            `json:"path"`               // Path is the path of the workflow
+	RunID       string            `json:"run_id"`             // RunID is the id of the workflow run
+	RunNumber   string            `json:"run_number"`         // RunNumber is the number of the workflow run
+	Conclusion  core.Conclusion   `json:"conclusion"`         // Conclusion is the conclusion of the workflow run
+	Previous    core.Conclusion   `json:"previous,omitempty"` // Previous is the conclusion of the previous run of the workflow, if any
+	Duration    string            `json:"duration"`           // Duration is the duration of the workflow run
+	Jobs        []notificationJob `json:"jobs"`               // Jobs are the job runs of the workflow run in the execution order
+	Message     string            `json:"message,omitempty"`  // Message is the rendered message of the notification
+	Annotations int               `json:"annotations"`        // Annotations is the number of the annotations of the workflow run
+}
+
+// notificationJob represents a job run of the workflow run sent to the notifiers.
+type notificationJob struct {
+	Name       string          `json:"name"`       // Name is the display name of the job
+	Conclusion core.Conclusion `json:"conclusion"` // Conclusion is the conclusion of the job run
+	Duration   string          `json:"duration"`   // Duration is the duration of the job run
+}
+
+// SendNotifications sends the notifications of the workflow run in the given run directory to the notifiers configured
+// with the GHX_NOTIFICATIONS environment variable. If the history directory is given, the previous run of the workflow
+// is looked up in it to detect the recovered runs. Failed deliveries are only logged, so the notifications don't change
+// the result of the run.
+//
+// Usage: ghx notify <run-dir> [<history-dir>]
+func SendNotifications(args []string) error {
+	if len(args) < 1 {
+		return errors.New("workflow run directory is required, usage: ghx notify <run-dir> [<history-dir>]")
+	}
+
+	notifiers, err := parseNotifiers(os.Getenv(notificationsEnv))
+	if err != nil {
+		return err
+	}
+
+	if len(notifiers) == 0 {
+		return nil
+	}
+
+	history := ""
+	if len(args) > 1 {
+		history = args[1]
+	}
+
+	n, err := newNotification(args[0], history)
+	if err != nil {
+		return err
+	}
+
+	// cancelled and skipped runs aren't notified
+	if n.Event == "" {
+		return nil
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	for _, nt := range notifiers {
+		if !nt.accepts(n.Event) {
+			continue
+		}
+
+		if err := nt.send(client, *n); err != nil {
+			log.Warnf("Failed to send notification", "type", nt.Type, "event", n.Event, "error", err)
+			continue
+		}
+
+		log.Infof("Notification sent", "type", nt.Type, "event", n.Event)
+	}
+
+	return nil
+}
+
+// parseNotifiers parses the notifiers from the JSON value and applies the defaults.
+func parseNotifiers(value string) ([]notifier, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var notifiers []notifier
+
+	if err := json.Unmarshal([]byte(value), &notifiers); err != nil {
+		return nil, fmt.Errorf("invalid notifications: %w", err)
+	}
+
+	for i := range notifiers {
+		nt := &notifiers[i]
+
+		switch nt.Type {
+		case "":
+			nt.Type = "webhook"
+		case "webhook", "slack", "discord":
+		default:
+			return nil, fmt.Errorf("unsupported notification type %s, possible values are: webhook, slack, discord", nt.Type)
+		}
+
+		if nt.URL == "" {
+			return nil, fmt.Errorf("url of the %s notification is required", nt.Type)
+		}
+
+		if len(nt.Events) == 0 {
+			nt.Events = []string{notifyEventFailure, notifyEventRecovered}
+		}
+
+		for _, event := range nt.Events {
+			switch event {
+			case notifyEventSuccess, notifyEventFailure, notifyEventRecovered:
+			default:
+				return nil, fmt.Errorf("unsupported notification event %s, possible values are: success, failure, recovered", event)
+			}
+		}
+
+		if nt.Template == "" {
+			nt.Template = defaultNotifyTemplate
+		}
+
+		if _, err := template.New("notification").Parse(nt.Template); err != nil {
+			return nil, fmt.Errorf("invalid notification template: %w", err)
+		}
+	}
+
+	return notifiers, nil
+}
+
+// accepts returns true if the notifier is configured for the event. Recovered runs are successful runs as well.
+func (nt notifier) accepts(event string) bool {
+	return slices.Contains(nt.Events, event) || (event == notifyEventRecovered && slices.Contains(nt.Events, notifyEventSuccess))
+}
+
+// send renders the message of the notification and posts it to the URL of the notifier in the payload format of its
+// type.
+func (nt notifier) send(client *http.Client, n notification) error {
+	tmpl, err := template.New("notification").Parse(nt.Template)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+
+	if err := tmpl.Execute(&sb, n); err != nil {
+		return fmt.Errorf("failed to render message: %w", err)
+	}
+
+	n.Message = sb.String()
+
+	var payload interface{}
+
+	switch nt.Type {
+	case "slack":
+		payload = map[string]string{"text": n.Message}
+	case "discord":
+		payload = map[string]string{"content": truncate(n.Message, maxDiscordMessageLen)}
+	default:
+		payload = n
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Post(nt.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		// url could contain the credentials of the webhook, so it isn't included in the error
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// newNotification creates the notification of the workflow run in the given run directory.
+func newNotification(dir, history string) (*notification, error) {
+	var report context.WorkflowRunReport
+
+	if err := fs.ReadJSONFile(filepath.Join(dir, "workflow_run.json"), &report); err != nil {
+		return nil, fmt.Errorf("failed to read workflow run report: %w", err)
+	}
+
+	n := &notification{
+		Name:        report.Name,
+		Path:        report.Path,
+		RunID:       report.RunID,
+		RunNumber:   report.RunNumber,
+		Conclusion:  report.Conclusion,
+		Duration:    report.Duration,
+		Annotations: len(report.Annotations),
+	}
+
+	jobs, err := filepath.Glob(filepath.Join(dir, "jobs", "*", "job_run.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []context.JobRunReport
+
+	for _, path := range jobs {
+		var jr context.JobRunReport
+
+		if err := fs.ReadJSONFile(path, &jr); err != nil {
+			return nil, fmt.Errorf("failed to read job run report: %w", err)
+		}
+
+		reports = append(reports, jr)
+	}
+
+	sort.SliceStable(reports, func(i, j int) bool { return reports[i].StartedAt.Before(reports[j].StartedAt) })
+
+	for _, jr := range reports {
+		n.Jobs = append(n.Jobs, notificationJob{Name: jr.DisplayName, Conclusion: jr.Conclusion, Duration: jr.Duration})
+	}
+
+	if history != "" {
+		n.Previous = previousConclusion(history, report)
+	}
+
+	switch {
+	case report.Conclusion == core.ConclusionFailure:
+		n.Event = notifyEventFailure
+	case report.Conclusion == core.ConclusionSuccess && n.Previous == core.ConclusionFailure:
+		n.Event = notifyEventRecovered
+	case report.Conclusion == core.ConclusionSuccess:
+		n.Event = notifyEventSuccess
+	}
+
+	return n, nil
+}
+
+// previousConclusion returns the conclusion of the latest run of the same workflow started before the given run in the
+// history. Runs that can't be read are ignored.
+func previousConclusion(history string, current context.WorkflowRunReport) core.Conclusion {
+	paths, err := filepath.Glob(filepath.Join(history, "*", "workflow_run.json"))
+	if err != nil {
+		return ""
+	}
+
+	var previous *context.WorkflowRunReport
+
+	for _, path := range paths {
+		var report context.WorkflowRunReport
+
+		if err := fs.ReadJSONFile(path, &report); err != nil {
+			continue
+		}
+
+		if report.RunID == current.RunID || report.Path != current.Path || !report.StartedAt.Before(current.StartedAt) {
+			continue
+		}
+
+		if previous == nil || report.StartedAt.After(previous.StartedAt) {
+			previous = &report
+		}
+	}
+
+	if previous == nil {
+		return ""
+	}
+
+	return previous.Conclusion
+}
+
+// truncate truncates the string to the given number of runes.
+func truncate(s string, max int) string {
+	runes := []rune(s)
+
+	if len(runes) <= max {
+		return s
+	}
+
+	return string(runes[:max-3]) + "..."
+}
@@ -0,0 +1,114 @@
+package ghx
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// writeRunReport writes the workflow run report with a single job to the run directory in the given directory.
+func writeRunReport(t *testing.T, dir, runID string, conclusion core.Conclusion, startedAt time.Time) string {
+	t.Helper()
+
+	runDir := filepath.Join(dir, runID)
+
+	report := context.WorkflowRunReport{Name: "CI", Path: ".github/workflows/ci.yaml", RunID: runID, Conclusion: conclusion, Duration: "1m0s", StartedAt: startedAt}
+	job := context.JobRunReport{DisplayName: "build", Conclusion: conclusion, Duration: "59s", StartedAt: startedAt}
+
+	if err := fs.WriteJSONFile(filepath.Join(runDir, "workflow_run.json"), &report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := fs.WriteJSONFile(filepath.Join(runDir, "jobs", "1", "job_run.json"), &job); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return runDir
+}
+
+func TestSendNotifications(t *testing.T) {
+	var (
+		history = t.TempDir()
+		now     = time.Now()
+		bodies  = make(map[string]string)
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies[r.URL.Path] = string(data)
+	}))
+	defer server.Close()
+
+	writeRunReport(t, history, "1", core.ConclusionFailure, now.Add(-time.Hour))
+
+	// history contains the current run as well, since runs are copied to the history before the notifications
+	run := writeRunReport(t, history, "2", core.ConclusionSuccess, now)
+
+	notifiers, _ := json.Marshal([]notifier{
+		{Type: "slack", URL: server.URL + "/slack"},
+		{URL: server.URL + "/webhook", Events: []string{"success"}, Template: "{{ .Name }} {{ .Event }}"},
+		{Type: "discord", URL: server.URL + "/discord", Events: []string{"failure"}},
+	})
+
+	t.Setenv(notificationsEnv, string(notifiers))
+
+	if err := SendNotifications([]string{run, history}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var slack map[string]string
+
+	if err := json.Unmarshal([]byte(bodies["/slack"]), &slack); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "Workflow CI (run 2) recovered: success in 1m0s\n- build: success in 59s"
+	if slack["text"] != expected {
+		t.Errorf("expected slack message %q, got %q", expected, slack["text"])
+	}
+
+	var webhook notification
+
+	if err := json.Unmarshal([]byte(bodies["/webhook"]), &webhook); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if webhook.Event != "recovered" || webhook.Previous != core.ConclusionFailure || webhook.Message != "CI recovered" || len(webhook.Jobs) != 1 {
+		t.Errorf("unexpected webhook payload %+v", webhook)
+	}
+
+	if _, ok := bodies["/discord"]; ok {
+		t.Errorf("expected no discord notification for recovered run, got %s", bodies["/discord"])
+	}
+}
+
+func TestParseNotifiers(t *testing.T) {
+	notifiers, err := parseNotifiers(`[{"url": "http://example.com"}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if nt := notifiers[0]; nt.Type != "webhook" || strings.Join(nt.Events, ",") != "failure,recovered" || nt.Template != defaultNotifyTemplate {
+		t.Errorf("unexpected defaults %+v", nt)
+	}
+
+	for _, invalid := range []string{
+		`[{"type": "email", "url": "http://example.com"}]`,
+		`[{"type": "slack"}]`,
+		`[{"url": "http://example.com", "events": ["cancelled"]}]`,
+		`[{"url": "http://example.com", "template": "{{ .Name "}]`,
+	} {
+		if _, err := parseNotifiers(invalid); err == nil {
+			t.Errorf("expected error for %s", invalid)
+		}
+	}
+}
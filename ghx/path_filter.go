@@ -0,0 +1,58 @@
+package ghx
+
+import (
+	"strings"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// isAffected returns true if the changed paths trigger the workflow for the given event. Workflows without path filters
+// for the event are always affected.
+//
+// See: https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#onpushpull_requestpull_request_targetpathspaths-ignore
+func isAffected(wf core.Workflow, event string, changed []string) bool {
+	filters, ok := wf.On[event]
+	if !ok {
+		return true
+	}
+
+	switch {
+	case len(filters.Paths) > 0:
+		// workflow runs if at least one path matches the paths filter
+		for _, path := range changed {
+			if matchPaths(filters.Paths, path) {
+				return true
+			}
+		}
+
+		return false
+	case len(filters.PathsIgnore) > 0:
+		// workflow runs unless all paths match the paths-ignore filter
+		for _, path := range changed {
+			if !matchPaths(filters.PathsIgnore, path) {
+				return true
+			}
+		}
+
+		return false
+	default:
+		return true
+	}
+}
+
+// matchPaths returns true if the path matches the patterns. Patterns are evaluated in order and the last matching
+// pattern wins, so negative patterns starting with ! exclude the paths matched by the previous patterns.
+func matchPaths(patterns []string, path string) bool {
+	matched := false
+
+	for _, pattern := range patterns {
+		negative := strings.HasPrefix(pattern, "!")
+
+		if fs.GlobRegexp(strings.TrimPrefix(pattern, "!")).MatchString(path) {
+			matched = !negative
+		}
+	}
+
+	return matched
+}
@@ -0,0 +1,67 @@
+package ghx
+
+import (
+	"testing"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestMatchPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		expected bool
+	}{
+		{name: "Exact path", patterns: []string{"README.md"}, path: "README.md", expected: true},
+		{name: "Single star doesn't match directories", patterns: []string{"*.go"}, path: "ghx/main.go", expected: false},
+		{name: "Single star in directory", patterns: []string{"ghx/*.go"}, path: "ghx/main.go", expected: true},
+		{name: "Double star matches directories", patterns: []string{"**.go"}, path: "ghx/core/job.go", expected: true},
+		{name: "Double star slash matches root", patterns: []string{"**/*.go"}, path: "main.go", expected: true},
+		{name: "Directory prefix", patterns: []string{"docs/**"}, path: "docs/guide/intro.md", expected: true},
+		{name: "Question mark", patterns: []string{"v?.txt"}, path: "v1.txt", expected: true},
+		{name: "Negative pattern excludes", patterns: []string{"docs/**", "!docs/**/*.md"}, path: "docs/guide/intro.md", expected: false},
+		{name: "Last matching pattern wins", patterns: []string{"docs/**", "!docs/**/*.md", "docs/README.md"}, path: "docs/README.md", expected: true},
+		{name: "No match", patterns: []string{"src/**"}, path: "docs/README.md", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchPaths(tt.patterns, tt.path); got != tt.expected {
+				t.Errorf("matchPaths(%v, %s) = %v, expected %v", tt.patterns, tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsAffected(t *testing.T) {
+	wf := core.Workflow{
+		On: core.Events{
+			"push":         {Paths: []string{"src/**"}},
+			"pull_request": {PathsIgnore: []string{"docs/**"}},
+			"schedule":     {},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		event    string
+		changed  []string
+		expected bool
+	}{
+		{name: "Paths match", event: "push", changed: []string{"docs/README.md", "src/main.go"}, expected: true},
+		{name: "Paths don't match", event: "push", changed: []string{"docs/README.md"}, expected: false},
+		{name: "Paths ignore match all", event: "pull_request", changed: []string{"docs/README.md"}, expected: false},
+		{name: "Paths ignore don't match some", event: "pull_request", changed: []string{"docs/README.md", "src/main.go"}, expected: true},
+		{name: "No path filters", event: "schedule", changed: []string{"docs/README.md"}, expected: true},
+		{name: "Event not in workflow", event: "workflow_dispatch", changed: []string{"docs/README.md"}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAffected(wf, tt.event, tt.changed); got != tt.expected {
+				t.Errorf("isAffected(%s, %v) = %v, expected %v", tt.event, tt.changed, got, tt.expected)
+			}
+		})
+	}
+}
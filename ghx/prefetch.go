@@ -0,0 +1,360 @@
+package ghx
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"dagger.io/dagger"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// dockerfileImagePrefix is the prefix of the images built from the Dockerfile of the docker actions in the images
+// index. The prefix is followed by the action source.
+const dockerfileImagePrefix = "dockerfile://"
+
+// imagesIndexFile is the name of the file in the metadata directory mapping the prefetched images to their digests.
+const imagesIndexFile = "images.json"
+
+// Prefetch warms up the caches for the workflow without running it, so it could run later in offline mode. Remote
+// actions are downloaded first, since the images of the docker actions are built from the downloaded actions.
+func Prefetch(ctx *context.Context, wf core.Workflow) error {
+	if err := prefetchActions(ctx, wf); err != nil {
+		return fmt.Errorf("failed to prefetch actions: %w", err)
+	}
+
+	if err := prefetchImages(ctx, wf); err != nil {
+		return fmt.Errorf("failed to prefetch images: %w", err)
+	}
+
+	return nil
+}
+
+// prefetchActions downloads all remote actions used by the workflow to the actions cache without running the
+// workflow. Already cached actions are only updated if their refs point to a different commit.
+func prefetchActions(ctx *context.Context, wf core.Workflow) error {
+	path, err := ctx.GetActionsPath()
+	if err != nil {
+		return err
+	}
+
+	for _, source := range getRemoteActions(wf) {
+		repo, _, ref, err := parseRepoRef(source)
+		if err != nil {
+			return err
+		}
+
+		sha, err := ensureActionExistsLocally(source, repo, ref, path, LoadActionOpts{Token: ctx.Github.Token})
+		if err != nil {
+			return err
+		}
+
+		log.Info(fmt.Sprintf("Prefetched action '%s' (%s)", source, sha))
+	}
+
+	return nil
+}
+
+// verifyCached checks if everything the workflow needs from the network, remote actions, container images and tools
+// installed by the setup actions, exists in the local caches. It returns an error with the list of the missing items
+// if any of them is not cached.
+func verifyCached(ctx *context.Context, wf core.Workflow) error {
+	path, err := ctx.GetActionsPath()
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+
+	for _, source := range getRemoteActions(wf) {
+		exist, err := fs.Exists(filepath.Join(path, source))
+		if err != nil {
+			return err
+		}
+
+		if !exist {
+			missing = append(missing, fmt.Sprintf("action %s", source))
+		}
+	}
+
+	index, err := readImagesIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, image := range getImages(ctx, wf) {
+		if _, ok := index[image]; !ok {
+			missing = append(missing, fmt.Sprintf("image %s", image))
+		}
+	}
+
+	for _, tool := range getMissingTools(ctx, wf) {
+		missing = append(missing, fmt.Sprintf("tool %s", tool))
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("offline mode is enabled and following are not cached, run prefetch first:\n - %s", strings.Join(missing, "\n - "))
+	}
+
+	return nil
+}
+
+// prefetchImages pulls the container images used by the workflow, images of docker steps and docker actions, and
+// records their digests in the images index to use them in offline mode. Actions must be prefetched first.
+func prefetchImages(ctx *context.Context, wf core.Workflow) error {
+	index := make(map[string]string)
+
+	images := getImages(ctx, wf)
+
+	if len(images) > 0 && ctx.Dagger.Client == nil {
+		return errNoEngine
+	}
+
+	for _, image := range images {
+		var container *dagger.Container
+
+		if source, ok := strings.CutPrefix(image, dockerfileImagePrefix); ok {
+			path, err := ctx.GetActionsPath()
+			if err != nil {
+				return err
+			}
+
+			ca, err := LoadActionFromSource(ctx.Context, ctx.Dagger.Client, source, path, LoadActionOpts{Offline: true})
+			if err != nil {
+				return err
+			}
+
+			container = ctx.Dagger.Client.Container().Build(ca.Dir, dagger.ContainerBuildOpts{Dockerfile: strings.TrimPrefix(ca.Meta.Runs.Image, "./")})
+		} else {
+			container = ctx.Dagger.Client.Container().From(ctx.GhxConfig.RewriteImage(image))
+		}
+
+		if _, err := container.Sync(ctx.Context); err != nil {
+			return fmt.Errorf("failed to pull image %s: %w", image, err)
+		}
+
+		// built images don't have a reference to pin, so they are only marked as prefetched
+		var (
+			ref = image
+			err error
+		)
+
+		if !strings.HasPrefix(image, dockerfileImagePrefix) {
+			ref, err = container.ImageRef(ctx.Context)
+			if err != nil {
+				return fmt.Errorf("failed to get image reference of %s: %w", image, err)
+			}
+		}
+
+		index[image] = ref
+
+		log.Info(fmt.Sprintf("Prefetched image '%s' (%s)", image, ref))
+	}
+
+	return updateImagesIndex(ctx, index)
+}
+
+// readImagesIndex reads the images index from the metadata directory. The index is read holding its lock, since it
+// could be updated by the concurrent workflow runs sharing the metadata.
+func readImagesIndex(ctx *context.Context) (map[string]string, error) {
+	path, err := ctx.GetMetadataPath()
+	if err != nil {
+		return nil, err
+	}
+
+	file := filepath.Join(path, imagesIndexFile)
+
+	index := make(map[string]string)
+
+	err = fs.WithLock(file, func() error {
+		exist, err := fs.Exists(file)
+		if err != nil || !exist {
+			return err
+		}
+
+		return fs.ReadJSONFile(file, &index)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read images index: %w", err)
+	}
+
+	return index, nil
+}
+
+// updateImagesIndex adds the given images to the images index in the metadata directory. The index is read and written
+// holding its lock, so the images prefetched by the concurrent workflow runs are not lost.
+func updateImagesIndex(ctx *context.Context, images map[string]string) error {
+	path, err := ctx.GetMetadataPath()
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Join(path, imagesIndexFile)
+
+	return fs.WithLock(file, func() error {
+		index := make(map[string]string)
+
+		exist, err := fs.Exists(file)
+		if err != nil {
+			return err
+		}
+
+		if exist {
+			if err := fs.ReadJSONFile(file, &index); err != nil {
+				return fmt.Errorf("failed to read images index: %w", err)
+			}
+		}
+
+		for image, ref := range images {
+			index[image] = ref
+		}
+
+		return fs.WriteJSONFile(file, index)
+	})
+}
+
+// resolveImage returns the image reference to pull the image from. Image is rewritten with the image overrides and the
+// registry mirrors. In offline mode or with the if-not-present pull policy, the reference pinned to the digest in the
+// images index is returned if exists, so the image is used from the engine cache.
+func resolveImage(ctx *context.Context, image string) string {
+	ref := ctx.GhxConfig.RewriteImage(image)
+
+	if !ctx.GhxConfig.Offline && ctx.GhxConfig.PullPolicy != context.PullPolicyIfNotPresent {
+		return ref
+	}
+
+	index, err := readImagesIndex(ctx)
+	if err != nil {
+		log.Warnf("failed to read images index", "error", err)
+		return ref
+	}
+
+	if pinned, ok := index[image]; ok {
+		return pinned
+	}
+
+	return ref
+}
+
+// getImages returns the sorted unique list of the container images used by the workflow, including the images of the
+// node runtimes not installed yet. Images of the actions are only resolved for the actions existing in the actions
+// cache.
+func getImages(ctx *context.Context, wf core.Workflow) []string {
+	seen := make(map[string]bool)
+
+	var images []string
+
+	add := func(image string) {
+		if !seen[image] {
+			seen[image] = true
+			images = append(images, image)
+		}
+	}
+
+	path, _ := ctx.GetActionsPath()
+
+	for _, job := range wf.Jobs {
+		for _, step := range job.Steps {
+			switch step.Type() {
+			case core.StepTypeDocker:
+				add(strings.TrimPrefix(step.Uses, "docker://"))
+			case core.StepTypeAction:
+				if isLocalAction(step.Uses) {
+					continue
+				}
+
+				if exist, _ := fs.Exists(filepath.Join(path, step.Uses)); !exist {
+					continue
+				}
+
+				ca, err := LoadActionFromSource(ctx.Context, ctx.Dagger.Client, step.Uses, path, LoadActionOpts{Offline: true})
+				if err != nil {
+					continue
+				}
+
+				switch using := ca.Meta.Runs.Using; using {
+				case core.ActionRunsUsingDocker:
+					if image, ok := strings.CutPrefix(ca.Meta.Runs.Image, "docker://"); ok {
+						add(image)
+					} else {
+						add(dockerfileImagePrefix + step.Uses)
+					}
+				case core.ActionRunsUsingNode12, core.ActionRunsUsingNode16, core.ActionRunsUsingNode20:
+					// node runtimes are installed from the images on first use, so they're only needed until then
+					if version := nodeVersion(ctx, using); !nodeInstalled(ctx, version) {
+						add(nodeImage(version))
+					}
+				}
+			}
+		}
+	}
+
+	sort.Strings(images)
+
+	return images
+}
+
+// setupActionTools maps the setup actions to the directories of the tools they install in the tool cache.
+var setupActionTools = map[string]string{
+	"actions/setup-go":     "go",
+	"actions/setup-node":   "node",
+	"actions/setup-python": "Python",
+	"actions/setup-java":   "Java_*",
+}
+
+// getMissingTools returns the tools installed by the setup actions used in the workflow that don't exist in the tool
+// cache. Versions are not checked since they could be expressions or version ranges.
+func getMissingTools(ctx *context.Context, wf core.Workflow) []string {
+	seen := make(map[string]bool)
+
+	var missing []string
+
+	for _, job := range wf.Jobs {
+		for _, step := range job.Steps {
+			repo, _, _ := strings.Cut(step.Uses, "@")
+
+			tool, ok := setupActionTools[repo]
+			if !ok || seen[tool] {
+				continue
+			}
+
+			seen[tool] = true
+
+			if matches, _ := filepath.Glob(filepath.Join(ctx.Runner.ToolCache, tool)); len(matches) == 0 {
+				missing = append(missing, fmt.Sprintf("%s (%s), run the workflow once online to populate the tool cache", tool, repo))
+			}
+		}
+	}
+
+	sort.Strings(missing)
+
+	return missing
+}
+
+// getRemoteActions returns the sorted unique list of remote actions used by the workflow.
+func getRemoteActions(wf core.Workflow) []string {
+	seen := make(map[string]bool)
+
+	var actions []string
+
+	for _, job := range wf.Jobs {
+		for _, step := range job.Steps {
+			if step.Type() != core.StepTypeAction || isLocalAction(step.Uses) || seen[step.Uses] {
+				continue
+			}
+
+			seen[step.Uses] = true
+
+			actions = append(actions, step.Uses)
+		}
+	}
+
+	sort.Strings(actions)
+
+	return actions
+}
@@ -0,0 +1,125 @@
+package ghx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestGetRemoteActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		jobs     map[string]core.Job
+		expected []string
+	}{
+		{name: "no jobs"},
+		{
+			name: "remote actions",
+			jobs: map[string]core.Job{
+				"build": {Steps: []core.Step{{Uses: "actions/setup-go@v5"}, {Uses: "actions/checkout@v4"}, {Run: "make"}}},
+				"test":  {Steps: []core.Step{{Uses: "actions/checkout@v4"}}},
+			},
+			expected: []string{"actions/checkout@v4", "actions/setup-go@v5"},
+		},
+		{
+			name: "local and docker actions",
+			jobs: map[string]core.Job{
+				"build": {Steps: []core.Step{{Uses: "./.github/actions/build"}, {Uses: "docker://alpine:3.19"}, {Uses: "actions/cache/save@v4"}}},
+			},
+			expected: []string{"actions/cache/save@v4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getRemoteActions(core.Workflow{Jobs: tt.jobs}); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestResolveImage(t *testing.T) {
+	const pinned = "node@sha256:0123456789abcdef"
+
+	tests := []struct {
+		name      string
+		image     string
+		offline   bool
+		policy    context.PullPolicy
+		overrides context.ImageMappings
+		expected  string
+	}{
+		{name: "always pull", image: "node:20", policy: context.PullPolicyAlways, expected: "node:20"},
+		{name: "if not present", image: "node:20", policy: context.PullPolicyIfNotPresent, expected: pinned},
+		{name: "offline", image: "node:20", offline: true, policy: context.PullPolicyAlways, expected: pinned},
+		{name: "offline without prefetched image", image: "alpine:3.19", offline: true, expected: "alpine:3.19"},
+		{
+			name:      "image override",
+			image:     "alpine:3.19",
+			policy:    context.PullPolicyAlways,
+			overrides: context.ImageMappings{"alpine:3.19": "registry.example.com/alpine:3.19"},
+			expected:  "registry.example.com/alpine:3.19",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, nil)
+			ctx.GhxConfig.Offline = tt.offline
+			ctx.GhxConfig.PullPolicy = tt.policy
+			ctx.GhxConfig.ImageOverrides = tt.overrides
+
+			if err := updateImagesIndex(ctx, map[string]string{"node:20": pinned}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := resolveImage(ctx, tt.image); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestUpdateImagesIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		updates  []map[string]string
+		expected map[string]string
+	}{
+		{name: "no updates", expected: map[string]string{}},
+		{
+			name:     "new images",
+			updates:  []map[string]string{{"node:20": "node@sha256:1"}, {"alpine:3.19": "alpine@sha256:2"}},
+			expected: map[string]string{"node:20": "node@sha256:1", "alpine:3.19": "alpine@sha256:2"},
+		},
+		{
+			name:     "updated image",
+			updates:  []map[string]string{{"node:20": "node@sha256:1", "alpine:3.19": "alpine@sha256:2"}, {"node:20": "node@sha256:3"}},
+			expected: map[string]string{"node:20": "node@sha256:3", "alpine:3.19": "alpine@sha256:2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, nil)
+
+			for _, images := range tt.updates {
+				if err := updateImagesIndex(ctx, images); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			index, err := readImagesIndex(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(index, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, index)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package protocol defines the protocol between ghx running a workflow in the runner container and the host running
+// the container, e.g. the gale module. Alternate runners implementing the protocol could replace ghx in the container
+// without changing the host.
+//
+// The host configures the runner with the GHX_* environment variables and the GitHub Actions environment variables of
+// the run, then runs the runner without arguments to run the workflow. The runner must:
+//
+//   - print its version with the version command and the protocol version with the version --protocol command, so the
+//     host could detect a runner speaking a different protocol before running the workflow.
+//   - stream the execution events as newline delimited JSON to the unix socket or the named pipe at GHX_EVENTS_SOCKET,
+//     or to stdout if GHX_OUTPUT is ndjson. Each event carries the protocol version.
+//   - write the report of the workflow run to $GHX_HOME/runs/<run id>/workflow_run.json.
+//   - exit with zero after writing the report, even if the workflow fails. The conclusion of the workflow is reported
+//     in the report, so the host could decide how to handle it.
+//
+// Version is bumped for the changes the hosts or the runners of the previous version can't handle, e.g. a renamed
+// event type or a moved report. New event types and fields don't change the version, consumers ignore them.
+package protocol
+
+import "time"
+
+// Version is the version of the protocol implemented by ghx.
+const Version = "1"
+
+// EventType is the type of the execution event.
+type EventType string
+
+const (
+	EventTypeWorkflowStarted   EventType = "workflow_started"
+	EventTypeWorkflowCompleted EventType = "workflow_completed"
+	EventTypeJobStarted        EventType = "job_started"
+	EventTypeJobCompleted      EventType = "job_completed"
+	EventTypeStepStarted       EventType = "step_started"
+	EventTypeStepCompleted     EventType = "step_completed"
+	EventTypeLog               EventType = "log"
+)
+
+// Event is an execution event of the workflow run. Events are streamed as newline delimited JSON while the workflow
+// is running, so the host doesn't need to wait for the reports written after the run completes.
+type Event struct {
+	Version    string    `json:"version"`              // Version is the version of the protocol of the event
+	Type       EventType `json:"type"`                 // Type is the type of the event
+	Time       time.Time `json:"time"`                 // Time is the time the event is emitted
+	RunID      string    `json:"run_id,omitempty"`     // RunID is the id of the workflow run
+	Workflow   string    `json:"workflow,omitempty"`   // Workflow is the name of the workflow
+	JobRunID   string    `json:"job_run_id,omitempty"` // JobRunID is the id of the job run
+	Job        string    `json:"job,omitempty"`        // Job is the display name of the job run
+	Step       string    `json:"step,omitempty"`       // Step is the id of the step
+	Stage      string    `json:"stage,omitempty"`      // Stage is the stage of the step, e.g. pre, main or post
+	Conclusion string    `json:"conclusion,omitempty"` // Conclusion is the conclusion of the completed workflow, job or step
+	Duration   string    `json:"duration,omitempty"`   // Duration is the duration of the completed workflow, job or step
+	Message    string    `json:"message,omitempty"`    // Message is the log line for the log events
+}
+
+// Compatible returns true if the runner speaking the given protocol version could be used by a host implementing
+// this version of the protocol.
+func Compatible(version string) bool {
+	return version == Version
+}
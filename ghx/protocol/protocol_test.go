@@ -0,0 +1,51 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCompatible(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{version: Version, expected: true},
+		{version: "0", expected: false},
+		{version: "2", expected: false},
+		{version: "v0.0.9", expected: false}, // runners before the protocol print their version instead
+		{version: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := Compatible(tt.version); got != tt.expected {
+				t.Errorf("Compatible(%q) = %v, want %v", tt.version, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvent_JSON(t *testing.T) {
+	data, err := json.Marshal(Event{Version: Version, Type: EventTypeStepCompleted, Step: "test", Conclusion: "success"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var fields map[string]interface{}
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// field names are part of the protocol, renaming them requires a new protocol version
+	for field, expected := range map[string]string{"version": Version, "type": "step_completed", "step": "test", "conclusion": "success"} {
+		if fields[field] != expected {
+			t.Errorf("expected %s to be %q, got %v", field, expected, fields[field])
+		}
+	}
+
+	if _, ok := fields["message"]; ok {
+		t.Errorf("expected empty fields to be omitted, got %s", data)
+	}
+}
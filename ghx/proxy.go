@@ -0,0 +1,107 @@
+package ghx
+
+import (
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"dagger.io/dagger"
+
+	"github.com/aweris/gale/ghx/context"
+)
+
+// stepCACertsPath is the path of the CA certificates bundle in the step containers. It's the default bundle path of the
+// debian and alpine based images, so the bundle of the runner replaces the trust store of the image.
+const stepCACertsPath = "/etc/ssl/certs/ca-certificates.crt"
+
+// proxyEnvNames is the list of the proxy environment variables propagated to the step containers. Both upper and lower
+// case variants are used by the tools, so both are propagated as they are.
+var proxyEnvNames = []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy"}
+
+// withProxy propagates the proxy configuration and the CA certificates of the runner to the step container, so the
+// steps running in their own containers work behind the same TLS intercepting proxy as the runner. The network mode of
+// the job is applied to the step container as well.
+func withProxy(ctx *context.Context) dagger.WithContainerFunc {
+	return func(c *dagger.Container) *dagger.Container {
+		for _, name := range proxyEnvNames {
+			if value := os.Getenv(name); value != "" {
+				c = c.WithEnvVariable(name, withServiceHosts(ctx, name, value))
+			}
+		}
+
+		// network mode of the job overrides the proxy configuration of the runner, the mode is validated when the job
+		// is set, so the error is not expected here
+		network, _ := ctx.NetworkEnv()
+
+		for _, name := range proxyEnvNames {
+			if value, ok := network[name]; ok {
+				c = c.WithEnvVariable(name, value)
+			}
+		}
+
+		if ctx.GhxConfig.CACerts == "" {
+			return c
+		}
+
+		return c.WithMountedFile(stepCACertsPath, ctx.Dagger.Client.Host().File(ctx.GhxConfig.CACerts)).
+			WithEnvVariable("SSL_CERT_FILE", stepCACertsPath).
+			WithEnvVariable("NODE_EXTRA_CA_CERTS", stepCACertsPath).
+			WithEnvVariable("REQUESTS_CA_BUNDLE", stepCACertsPath)
+	}
+}
+
+// withServiceHosts appends the hosts of the gale services to the value if the variable is one of the no proxy
+// variables. Step containers access the services by their addresses, which aren't in the no proxy list of the runner.
+func withServiceHosts(ctx *context.Context, name, value string) string {
+	if name != "NO_PROXY" && name != "no_proxy" {
+		return value
+	}
+
+	hosts := strings.Split(value, ",")
+
+	for _, host := range ctx.ServiceHosts() {
+		if !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return strings.Join(hosts, ",")
+}
+
+// withActionsServices configures the actions runtime services, the artifact and the cache services, for the step
+// container, so the actions using the toolkit, e.g. actions/cache, work in their own containers as well.
+func withActionsServices(ctx *context.Context) dagger.WithContainerFunc {
+	return func(c *dagger.Container) *dagger.Container {
+		env := ctx.ActionsServicesEnv()
+
+		// variables are set in a stable order to keep the layers cacheable
+		names := make([]string, 0, len(env))
+
+		for name := range env {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			c = c.WithEnvVariable(name, env[name])
+		}
+
+		return c
+	}
+}
+
+// proxyBuildArgs returns the proxy environment variables as the build args of the Dockerfile actions. Proxy variables
+// are predefined build args of the Dockerfiles, so they're available to the build without declaring them.
+func proxyBuildArgs() []dagger.BuildArg {
+	var args []dagger.BuildArg
+
+	for _, name := range proxyEnvNames {
+		if value := os.Getenv(name); value != "" {
+			args = append(args, dagger.BuildArg{Name: name, Value: value})
+		}
+	}
+
+	return args
+}
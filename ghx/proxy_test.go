@@ -0,0 +1,68 @@
+package ghx
+
+import (
+	"reflect"
+	"testing"
+
+	"dagger.io/dagger"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestWithServiceHosts(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "tcp://10.0.0.4:2375")
+
+	tests := []struct {
+		name     string
+		env      string
+		value    string
+		expected string
+	}{
+		{name: "proxy", env: "HTTP_PROXY", value: "http://proxy:3128", expected: "http://proxy:3128"},
+		{name: "no proxy", env: "NO_PROXY", value: "example.com", expected: "example.com,10.0.0.2,10.0.0.3,10.0.0.4"},
+		{name: "lower case no proxy", env: "no_proxy", value: "example.com", expected: "example.com,10.0.0.2,10.0.0.3,10.0.0.4"},
+		{name: "service hosts already excluded", env: "NO_PROXY", value: "10.0.0.3,example.com", expected: "10.0.0.3,example.com,10.0.0.2,10.0.0.4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, &core.JobRun{RunID: "1", Job: core.Job{ID: "build"}})
+			ctx.Actions.RuntimeURL = "http://10.0.0.2:8080/"
+			ctx.Actions.CacheURL = "http://10.0.0.3:8081/"
+
+			if got := withServiceHosts(ctx, tt.env, tt.value); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestProxyBuildArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected []dagger.BuildArg
+	}{
+		{name: "no proxy configured"},
+		{
+			name: "proxies",
+			env:  map[string]string{"HTTPS_PROXY": "http://proxy:3128", "no_proxy": "example.com"},
+			expected: []dagger.BuildArg{
+				{Name: "HTTPS_PROXY", Value: "http://proxy:3128"},
+				{Name: "no_proxy", Value: "example.com"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range proxyEnvNames {
+				t.Setenv(name, tt.env[name])
+			}
+
+			if got := proxyBuildArgs(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package ghx
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// reportFormatHTML is the report format rendering the workflow run into a self-contained html file.
+const reportFormatHTML = "html"
+
+// htmlReportFile is the name of the html report file in the workflow run directory.
+const htmlReportFile = "report.html"
+
+// htmlReport is the data of the html report template.
+type htmlReport struct {
+	Name        string
+	RunID       string
+	Conclusion  core.Conclusion
+	Duration    string
+	Stages      [][]string // Stages is the job ids grouped by their depth in the needs graph, in execution order.
+	Jobs        []htmlReportJob
+	Annotations []core.Annotation
+}
+
+// htmlReportJob is a job run in the html report.
+type htmlReportJob struct {
+	ID         string
+	Name       string
+	Conclusion core.Conclusion
+	Duration   string
+	Steps      []htmlReportStep
+}
+
+// htmlReportStep is a step run in the html report.
+type htmlReportStep struct {
+	Name       string
+	Conclusion core.Conclusion
+	Duration   string
+	Summary    string
+	Log        string
+}
+
+// writeHTMLReport renders the workflow run into the html report in the workflow run directory. Step logs are read
+// from the step run directories, so the report needs to be rendered after the jobs are completed.
+func writeHTMLReport(ctx *context.Context, total time.Duration) error {
+	dir, err := ctx.GetWorkflowRunPath()
+	if err != nil {
+		return err
+	}
+
+	wr := ctx.Execution.WorkflowRun
+
+	report := htmlReport{
+		Name:        wr.Workflow.Name,
+		RunID:       wr.RunID,
+		Conclusion:  wr.Conclusion,
+		Duration:    formatDuration(total),
+		Stages:      jobStages(wr.Workflow),
+		Annotations: wr.Annotations,
+	}
+
+	for _, jr := range wr.JobRuns {
+		job := htmlReportJob{
+			ID:         jr.Job.ID,
+			Name:       jr.DisplayName(),
+			Conclusion: jr.Conclusion,
+			Duration:   formatDuration(jr.Duration),
+		}
+
+		for _, sr := range jr.Steps {
+			prefix := ""
+
+			switch sr.Stage {
+			case core.StepStagePre:
+				prefix = "Pre"
+			case core.StepStagePost:
+				prefix = "Post"
+			}
+
+			step := htmlReportStep{
+				Name:       getStepName(prefix, sr.Step),
+				Conclusion: sr.Conclusion,
+				Duration:   formatDuration(sr.Duration),
+				Summary:    sr.Summary,
+			}
+
+			// pre, main and post stages of the step share the same log, so it's only shown with the main stage
+			if sr.Stage == core.StepStageMain {
+				data, err := os.ReadFile(filepath.Join(dir, "jobs", jr.RunID, "steps", sr.Step.ID, stepLogFile))
+				if err != nil && !os.IsNotExist(err) {
+					return err
+				}
+
+				step.Log = string(data)
+			}
+
+			job.Steps = append(job.Steps, step)
+		}
+
+		report.Jobs = append(report.Jobs, job)
+	}
+
+	var buf bytes.Buffer
+
+	if err := htmlReportTemplate.Execute(&buf, report); err != nil {
+		return err
+	}
+
+	return fs.WriteFile(filepath.Join(dir, htmlReportFile), buf.Bytes(), 0600)
+}
+
+// jobStages groups the jobs of the workflow by their depth in the needs graph. Jobs in the same stage don't depend on
+// each other, so the stages are the columns of the run graph.
+func jobStages(wf core.Workflow) [][]string {
+	depths := make(map[string]int)
+
+	var depthFn func(id string, visiting map[string]bool) int
+
+	depthFn = func(id string, visiting map[string]bool) int {
+		if depth, ok := depths[id]; ok {
+			return depth
+		}
+
+		// cycles are reported by the planner, ignoring them here to keep the report renderable
+		if visiting[id] {
+			return 0
+		}
+
+		visiting[id] = true
+
+		depth := 0
+
+		for _, need := range wf.Jobs[id].Needs {
+			if _, ok := wf.Jobs[need]; !ok {
+				continue
+			}
+
+			depth = max(depth, depthFn(need, visiting)+1)
+		}
+
+		depths[id] = depth
+
+		return depth
+	}
+
+	var stages [][]string
+
+	ids := make([]string, 0, len(wf.Jobs))
+	for id := range wf.Jobs {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		depth := depthFn(id, make(map[string]bool))
+
+		for len(stages) <= depth {
+			stages = append(stages, nil)
+		}
+
+		stages[depth] = append(stages[depth], id)
+	}
+
+	return stages
+}
+
+// htmlReportTemplate is the template of the html report. Styles are inlined to keep the report a single file.
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Name }} - {{ .RunID }}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem; color: #1f2328; }
+h1 { margin-bottom: 0.25rem; }
+pre { background: #f6f8fa; padding: 0.75rem; overflow-x: auto; font-size: 0.85rem; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1rem; }
+th, td { text-align: left; padding: 0.25rem 0.5rem; border-bottom: 1px solid #d0d7de; vertical-align: top; }
+.graph { display: flex; gap: 2rem; margin: 1rem 0; }
+.stage { display: flex; flex-direction: column; gap: 0.5rem; }
+.node { border: 1px solid #d0d7de; border-radius: 6px; padding: 0.25rem 0.75rem; }
+.success { color: #1a7f37; }
+.failure { color: #d1242f; }
+.cancelled, .skipped { color: #656d76; }
+.error { color: #d1242f; }
+.warning { color: #9a6700; }
+</style>
+</head>
+<body>
+<h1>{{ .Name }}</h1>
+<p>Run {{ .RunID }} &middot; <span class="{{ .Conclusion }}">{{ .Conclusion }}</span> &middot; {{ .Duration }}</p>
+
+<h2>Jobs</h2>
+<div class="graph">
+{{- range .Stages }}
+<div class="stage">{{ range . }}<div class="node"><a href="#job-{{ . }}">{{ . }}</a></div>{{ end }}</div>
+{{- end }}
+</div>
+
+{{- if .Annotations }}
+<h2>Annotations</h2>
+<table>
+<tr><th>Level</th><th>Location</th><th>Message</th></tr>
+{{- range .Annotations }}
+<tr><td class="{{ .Level }}">{{ .Level }}</td><td>{{ .Job }}/{{ .Step }}{{ if .File }} {{ .File }}{{ if .Line }}:{{ .Line }}{{ end }}{{ end }}</td><td>{{ if .Title }}<strong>{{ .Title }}</strong> {{ end }}{{ .Message }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+
+{{- range .Jobs }}
+<h2 id="job-{{ .ID }}">{{ .Name }} <small class="{{ .Conclusion }}">{{ .Conclusion }}</small> <small>{{ .Duration }}</small></h2>
+<table>
+<tr><th>Step</th><th>Conclusion</th><th>Duration</th></tr>
+{{- range .Steps }}
+<tr>
+<td>{{ .Name }}
+{{- if .Summary }}<details><summary>Summary</summary><pre>{{ .Summary }}</pre></details>{{ end }}
+{{- if .Log }}<details><summary>Log</summary><pre>{{ .Log }}</pre></details>{{ end }}
+</td>
+<td class="{{ .Conclusion }}">{{ .Conclusion }}</td>
+<td>{{ .Duration }}</td>
+</tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`))
@@ -0,0 +1,31 @@
+package ghx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestJobStages(t *testing.T) {
+	wf := core.Workflow{
+		Jobs: map[string]core.Job{
+			"lint":    {},
+			"build":   {},
+			"test":    {Needs: core.Needs{"build"}},
+			"e2e":     {Needs: core.Needs{"build", "test"}},
+			"release": {Needs: core.Needs{"lint", "e2e", "missing"}},
+		},
+	}
+
+	expected := [][]string{
+		{"build", "lint"},
+		{"test"},
+		{"e2e"},
+		{"release"},
+	}
+
+	if got := jobStages(wf); !reflect.DeepEqual(got, expected) {
+		t.Errorf("jobStages() = %v, want %v", got, expected)
+	}
+}
@@ -0,0 +1,101 @@
+package ghx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+// resumedRun is a part of a workflow run completed in another runner container. Jobs targeting different runners run
+// in separate containers, each continuing the workflow run of the previous one.
+type resumedRun struct {
+	Dir         string            `json:"-"`           // Dir is the directory of the resumed workflow run
+	RunID       string            `json:"run_id"`      // RunID is the id of the workflow run
+	RunNumber   string            `json:"run_number"`  // RunNumber is the number of the workflow run
+	RunAttempt  string            `json:"run_attempt"` // RunAttempt is the attempt number of the workflow run
+	Annotations []core.Annotation `json:"annotations"` // Annotations is the list of annotations created by the completed jobs
+}
+
+// loadResumedRun loads the resumed workflow run from its directory.
+func loadResumedRun(dir string) (*resumedRun, error) {
+	resumed := &resumedRun{Dir: dir}
+
+	if err := fs.ReadJSONFile(filepath.Join(dir, "workflow_run.json"), resumed); err != nil {
+		return nil, fmt.Errorf("failed to read resumed workflow run: %w", err)
+	}
+
+	if resumed.RunID == "" {
+		return nil, fmt.Errorf("resumed workflow run in %s doesn't have a run id", dir)
+	}
+
+	return resumed, nil
+}
+
+// jobRuns returns the job runs completed in the resumed workflow run in the order they started. The job runs are
+// loaded from their reports, so only the results of the jobs are restored.
+func (r *resumedRun) jobRuns(wf core.Workflow) ([]core.JobRun, error) {
+	dir := filepath.Join(r.Dir, "jobs")
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var reports []context.JobRunReport
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		var report context.JobRunReport
+
+		if err := fs.ReadJSONFile(filepath.Join(dir, entry.Name(), "job_run.json"), &report); err != nil {
+			return nil, fmt.Errorf("failed to read job run %s of the resumed workflow run: %w", entry.Name(), err)
+		}
+
+		reports = append(reports, report)
+	}
+
+	sort.SliceStable(reports, func(i, j int) bool { return reports[i].StartedAt.Before(reports[j].StartedAt) })
+
+	runs := make([]core.JobRun, 0, len(reports))
+
+	for _, report := range reports {
+		job, ok := wf.Jobs[report.ID]
+		if !ok {
+			return nil, fmt.Errorf("job %s of the resumed workflow run not found in the workflow", report.ID)
+		}
+
+		// duration is only used for the duration summary, invalid values are ignored
+		duration, _ := time.ParseDuration(report.Duration)
+
+		runs = append(runs, core.JobRun{
+			RunID:      report.RunID,
+			Job:        job,
+			Conclusion: report.Conclusion,
+			Outcome:    report.Outcome,
+			Outputs:    report.Outputs,
+			Matrix:     report.Matrix,
+			Duration:   duration,
+		})
+	}
+
+	return runs, nil
+}
+
+// keep copies the reports of the resumed workflow run to the given workflow run directory, so the directory has the
+// reports of all jobs of the run.
+func (r *resumedRun) keep(runDir string) error {
+	return copyDir(r.Dir, runDir)
+}
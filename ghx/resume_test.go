@@ -0,0 +1,111 @@
+package ghx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestLoadResumedRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		report  string
+		wantErr bool
+		runID   string
+	}{
+		{name: "resumed run", report: `{"run_id":"42","run_number":"7","run_attempt":"1","annotations":[{"level":"warning","message":"deprecated"}]}`, runID: "42"},
+		{name: "missing run id", report: `{"run_number":"7"}`, wantErr: true},
+		{name: "invalid report", report: `{`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			if err := os.WriteFile(filepath.Join(dir, "workflow_run.json"), []byte(tt.report), 0600); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			resumed, err := loadResumedRun(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadResumedRun() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if resumed.RunID != tt.runID || resumed.Dir != dir || len(resumed.Annotations) != 1 {
+				t.Errorf("unexpected resumed run %+v", *resumed)
+			}
+		})
+	}
+}
+
+func TestResumedRun_JobRuns(t *testing.T) {
+	dir := t.TempDir()
+
+	reports := map[string]string{
+		"2": `{"id":"test","run_id":"2","conclusion":"failure","outcome":"failure","started_at":"2024-01-01T00:01:00Z","duration":"1m0s"}`,
+		"1": `{"id":"build","run_id":"1","conclusion":"success","outcome":"success","outputs":{"version":"1.0.0"},"started_at":"2024-01-01T00:00:00Z","duration":"1m0s"}`,
+	}
+
+	for id, report := range reports {
+		if err := os.MkdirAll(filepath.Join(dir, "jobs", id), 0700); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "jobs", id, "job_run.json"), []byte(report), 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	wf := core.Workflow{Jobs: map[string]core.Job{"build": {ID: "build"}, "test": {ID: "test"}}}
+
+	runs, err := (&resumedRun{Dir: dir}).jobRuns(wf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(runs) != 2 || runs[0].Job.ID != "build" || runs[1].Job.ID != "test" {
+		t.Fatalf("expected build and test job runs in order, got %+v", runs)
+	}
+
+	if runs[0].Outputs["version"] != "1.0.0" || runs[1].Conclusion != core.ConclusionFailure {
+		t.Errorf("unexpected results of the job runs %+v", runs)
+	}
+
+	// jobs missing in the workflow can't be restored
+	if _, err := (&resumedRun{Dir: dir}).jobRuns(core.Workflow{Jobs: map[string]core.Job{"build": {ID: "build"}}}); err == nil {
+		t.Error("expected error for the job missing in the workflow")
+	}
+
+	// resumed runs without jobs don't have job runs
+	if runs, err := (&resumedRun{Dir: t.TempDir()}).jobRuns(wf); err != nil || len(runs) != 0 {
+		t.Errorf("expected no job runs, got %v, %v", runs, err)
+	}
+}
+
+func TestWorkflowConclusion(t *testing.T) {
+	tests := []struct {
+		name     string
+		workflow core.Conclusion
+		job      core.Conclusion
+		expected core.Conclusion
+	}{
+		{name: "successful job", workflow: core.ConclusionSuccess, job: core.ConclusionSuccess, expected: core.ConclusionSuccess},
+		{name: "skipped job", workflow: core.ConclusionSuccess, job: core.ConclusionSkipped, expected: core.ConclusionSuccess},
+		{name: "failed job", workflow: core.ConclusionSuccess, job: core.ConclusionFailure, expected: core.ConclusionFailure},
+		{name: "first failure is kept", workflow: core.ConclusionFailure, job: core.ConclusionCancelled, expected: core.ConclusionFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := workflowConclusion(tt.workflow, tt.job); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package ghx
+
+import (
+	"strings"
+
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+	"github.com/aweris/gale/ghx/expression"
+)
+
+// unsupportedLabelPrefixes is the list of runs-on label prefixes of the runners that can't run in a linux container.
+var unsupportedLabelPrefixes = []string{"windows", "macos"}
+
+// unsupportedLabel returns the first runs-on label of the job targeting a windows or macos runner. Labels are evaluated
+// before matching to support matrix values like ${{ matrix.os }}.
+func unsupportedLabel(ctx *context.Context, job core.Job) (string, bool) {
+	for _, label := range job.RunsOn {
+		label = expression.NewString(label).Eval(ctx)
+
+		for _, prefix := range unsupportedLabelPrefixes {
+			if strings.HasPrefix(strings.ToLower(label), prefix) {
+				return label, true
+			}
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,32 @@
+package ghx
+
+import (
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestUnsupportedLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		runsOn   []string
+		expected string
+	}{
+		{name: "linux runner", runsOn: []string{"ubuntu-latest"}},
+		{name: "macos runner", runsOn: []string{"macos-14"}, expected: "macos-14"},
+		{name: "windows runner", runsOn: []string{"Windows-2022"}, expected: "Windows-2022"},
+		{name: "matrix label", runsOn: []string{"${{ matrix.os }}"}, expected: "macos-latest"},
+		{name: "self-hosted runner", runsOn: []string{"self-hosted", "linux"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &context.Context{Matrix: context.MatrixContext{"os": "macos-latest"}}
+
+			if label, _ := unsupportedLabel(ctx, core.Job{ID: "build", RunsOn: tt.runsOn}); label != tt.expected {
+				t.Errorf("expected unsupported label %q, got %q", tt.expected, label)
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package ghx
 
 import (
 	"fmt"
@@ -96,7 +96,7 @@ func newTaskPostRunFnForStep() task.PostRunFn {
 
 func executeStep(ctx *context.Context, executor Executor, continueOnError bool) (core.Conclusion, error) {
 	// execute the step
-	if err := executor.Execute(ctx); err != nil {
+	if err := withRetries(ctx, executor).Execute(ctx); err != nil {
 		if continueOnError {
 			ctx.SetStepResults(core.ConclusionSuccess, core.ConclusionFailure)
 
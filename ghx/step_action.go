@@ -1,4 +1,4 @@
-package main
+package ghx
 
 import (
 	"fmt"
@@ -9,6 +9,7 @@ import (
 	"github.com/aweris/gale/common/log"
 	"github.com/aweris/gale/ghx/context"
 	"github.com/aweris/gale/ghx/core"
+	"github.com/aweris/gale/ghx/expression"
 	"github.com/aweris/gale/ghx/task"
 )
 
@@ -24,6 +25,7 @@ var (
 // StepAction is a step that runs an action.
 type StepAction struct {
 	container *dagger.Container
+	checkout  bool // checkout indicates the step is an actions/checkout completed with the mounted workspace
 	Step      core.Step
 	Action    core.CustomAction
 }
@@ -35,7 +37,14 @@ func (s *StepAction) setup() task.RunFn {
 			return core.ConclusionFailure, err
 		}
 
-		ca, err := LoadActionFromSource(ctx.Context, ctx.Dagger.Client, s.Step.Uses, path)
+		opts := LoadActionOpts{
+			Offline:   ctx.GhxConfig.Offline,
+			Workspace: ctx.Github.Workspace,
+			Token:     ctx.Github.Token,
+			Policy:    newActionPolicy(ctx.GhxConfig),
+		}
+
+		ca, err := LoadActionFromSource(ctx.Context, ctx.Dagger.Client, s.Step.Uses, path, opts)
 		if err != nil {
 			return core.ConclusionFailure, err
 		}
@@ -43,9 +52,15 @@ func (s *StepAction) setup() task.RunFn {
 		// update the step action with the loaded action
 		s.Action = *ca
 
-		log.Info(fmt.Sprintf("Download action repository '%s'", s.Step.Uses))
+		if !isLocalAction(s.Step.Uses) {
+			log.Info(fmt.Sprintf("Download action repository '%s'", s.Step.Uses))
+		}
 
 		if s.Action.Meta.Runs.Using == core.ActionRunsUsingDocker {
+			if ctx.Dagger.Client == nil {
+				return core.ConclusionFailure, errNoEngine
+			}
+
 			var (
 				image        = ca.Meta.Runs.Image
 				workspace    = ctx.Github.Workspace
@@ -53,17 +68,24 @@ func (s *StepAction) setup() task.RunFn {
 			)
 
 			switch {
-			case image == "Dockerfile":
-				s.container = ctx.Dagger.Client.Container().Build(ca.Dir)
-			case strings.HasPrefix(image, "docker://"):
-				s.container = ctx.Dagger.Client.Container().From(strings.TrimPrefix(image, "docker://"))
-			default:
+			case image == "":
 				// This should never happen. Adding it for safety.
 				return core.ConclusionFailure, fmt.Errorf("invalid docker image: %s", image)
+			case strings.HasPrefix(image, "docker://"):
+				s.container = ctx.Dagger.Client.Container().From(resolveImage(ctx, strings.TrimPrefix(image, "docker://")))
+			default:
+				// image is a path of the Dockerfile relative to the action directory, e.g. Dockerfile or docker/Dockerfile
+				opts := dagger.ContainerBuildOpts{Dockerfile: strings.TrimPrefix(image, "./"), BuildArgs: proxyBuildArgs()}
+
+				s.container = ctx.Dagger.Client.Container().Build(ca.Dir, opts)
 			}
 
 			// add repository to the container
-			s.container = s.container.WithMountedDirectory(workspace, workspaceDir).WithWorkdir(workspace)
+			s.container = s.container.
+				WithMountedDirectory(workspace, workspaceDir).
+				WithWorkdir(workspace).
+				With(withProxy(ctx)).
+				With(withActionsServices(ctx))
 		}
 
 		return core.ConclusionSuccess, nil
@@ -111,7 +133,7 @@ func (s *StepAction) pre() task.RunFn {
 		case core.ActionRunsUsingDocker:
 			executor = NewContainerExecutorFromStepAction(s, s.Action.Meta.Runs.PreEntrypoint)
 		case core.ActionRunsUsingNode12, core.ActionRunsUsingNode16, core.ActionRunsUsingNode20:
-			executor = NewCmdExecutorFromStepAction(s, s.Action.Meta.Runs.Pre)
+			executor = NewCmdExecutorFromStepAction(s, nodeBinary(ctx, s.Action.Meta.Runs.Using), s.Action.Meta.Runs.Pre)
 		default:
 			return core.ConclusionFailure, fmt.Errorf("invalid action runs using: %s", s.Action.Meta.Runs.Using)
 		}
@@ -129,13 +151,30 @@ func (s *StepAction) condition() task.ConditionalFn {
 
 func (s *StepAction) main() task.RunFn {
 	return func(ctx *context.Context) (core.Conclusion, error) {
+		// setup actions with pinned versions find the tool in the tool cache instead of downloading it
+		prepareToolLayer(ctx)
+
+		// checkout of the mounted repository doesn't need to clone it again
+		checkout, err := useCheckoutFastPath(ctx)
+		if err != nil {
+			return core.ConclusionFailure, err
+		}
+
+		if checkout {
+			s.checkout = true
+
+			return executeStep(ctx, &checkoutExecutor{}, s.Step.ContinueOnError)
+		}
+
 		var executor Executor
 
 		switch s.Action.Meta.Runs.Using {
 		case core.ActionRunsUsingDocker:
 			executor = NewContainerExecutorFromStepAction(s, s.Action.Meta.Runs.Entrypoint)
 		case core.ActionRunsUsingNode12, core.ActionRunsUsingNode16, core.ActionRunsUsingNode20:
-			executor = NewCmdExecutorFromStepAction(s, s.Action.Meta.Runs.Main)
+			executor = NewCmdExecutorFromStepAction(s, nodeBinary(ctx, s.Action.Meta.Runs.Using), s.Action.Meta.Runs.Main)
+		case core.ActionRunsUsingComposite:
+			return s.composite(ctx)
 		default:
 			return core.ConclusionFailure, fmt.Errorf("invalid action runs using: %s", s.Action.Meta.Runs.Using)
 		}
@@ -158,13 +197,18 @@ func (s *StepAction) postCondition() task.ConditionalFn {
 
 func (s *StepAction) post() task.RunFn {
 	return func(ctx *context.Context) (core.Conclusion, error) {
+		// nothing to clean up, the checkout is completed with the mounted workspace
+		if s.checkout {
+			return core.ConclusionSuccess, nil
+		}
+
 		var executor Executor
 
 		switch s.Action.Meta.Runs.Using {
 		case core.ActionRunsUsingDocker:
 			executor = NewContainerExecutorFromStepAction(s, s.Action.Meta.Runs.PostEntrypoint)
 		case core.ActionRunsUsingNode12, core.ActionRunsUsingNode16, core.ActionRunsUsingNode20:
-			executor = NewCmdExecutorFromStepAction(s, s.Action.Meta.Runs.Post)
+			executor = NewCmdExecutorFromStepAction(s, nodeBinary(ctx, s.Action.Meta.Runs.Using), s.Action.Meta.Runs.Post)
 		default:
 			return core.ConclusionFailure, fmt.Errorf("invalid action runs using: %s", s.Action.Meta.Runs.Using)
 		}
@@ -173,3 +217,149 @@ func (s *StepAction) post() task.RunFn {
 		return executeStep(ctx, executor, s.Step.ContinueOnError)
 	}
 }
+
+// composite executes the steps of the composite action sequentially. Steps of the composite action have their own
+// steps context and step runs, so outputs of the action can be mapped from the outputs of its steps. The inputs context
+// of the steps refers to the inputs of the composite action, and the variables and paths exported by the steps are
+// applied to the subsequent steps of the action and the job.
+//
+// Actions used by the composite steps run their main stage only, their pre and post stages are not supported for now.
+func (s *StepAction) composite(ctx *context.Context) (core.Conclusion, error) {
+	inputs, err := ctx.ActionInputs()
+	if err != nil {
+		return core.ConclusionFailure, err
+	}
+
+	var (
+		steps    = make(context.StepsContext)
+		parent   = ctx.Steps
+		env      = ctx.Env
+		workflow = ctx.Inputs
+		sr       = ctx.Execution.StepRun
+		action   = ctx.Execution.CurrentAction
+	)
+
+	// restore the job level contexts after the composite action is executed
+	defer func() {
+		ctx.Steps = parent
+		ctx.Env = env
+		ctx.Inputs = workflow
+		ctx.Execution.StepRun = sr
+		ctx.SetAction(action)
+	}()
+
+	ctx.Steps = steps
+	ctx.Inputs = context.InputsContext(inputs)
+
+	if sr.Environment == nil {
+		sr.Environment = make(map[string]string)
+	}
+
+	for idx, step := range s.Action.Meta.Runs.Steps {
+		if step.ID == "" {
+			step.ID = fmt.Sprintf("%d", idx)
+		}
+
+		// each step has its own environment on top of the action environment and the variables exported by the
+		// previous steps
+		ctx.Env = make(context.EnvContext)
+
+		for k, v := range env {
+			ctx.Env[k] = v
+		}
+
+		for k, v := range sr.Environment {
+			ctx.Env[k] = v
+		}
+
+		run, conclusion, err := evalCondition(step.If, ctx)
+		if err != nil {
+			return core.ConclusionFailure, err
+		}
+
+		if !run {
+			steps[step.ID] = context.StepContext{Conclusion: conclusion, Outcome: conclusion}
+			continue
+		}
+
+		for k, v := range ctx.EvalEnv(step.Environment) {
+			ctx.Env[k] = v
+		}
+
+		// the step run is nested under the composite step, so the files of the steps don't collide with the job steps
+		nested := step
+		nested.ID = fmt.Sprintf("%s-%s", sr.Step.ID, step.ID)
+
+		ctx.Execution.StepRun = &core.StepRun{
+			Step:        nested,
+			Stage:       core.StepStageMain,
+			Outputs:     make(map[string]string),
+			State:       make(map[string]string),
+			Environment: make(map[string]string),
+		}
+
+		conclusion, err = s.compositeStep(ctx, step, action)
+
+		steps[step.ID] = context.StepContext{
+			Conclusion: conclusion,
+			Outcome:    ctx.Execution.StepRun.Outcome,
+			Outputs:    ctx.Execution.StepRun.Outputs,
+		}
+
+		for k, v := range ctx.Execution.StepRun.Environment {
+			sr.Environment[k] = v
+		}
+
+		sr.Path = append(sr.Path, ctx.Execution.StepRun.Path...)
+
+		ctx.Execution.StepRun = sr
+
+		if err != nil {
+			if s.Step.ContinueOnError {
+				ctx.SetStepResults(core.ConclusionSuccess, core.ConclusionFailure)
+
+				return core.ConclusionSuccess, nil
+			}
+
+			ctx.SetStepResults(core.ConclusionFailure, core.ConclusionFailure)
+
+			return core.ConclusionFailure, err
+		}
+	}
+
+	// map the action outputs from the outputs of the composite steps
+	for k, v := range s.Action.Meta.Outputs {
+		sr.Outputs[k] = expression.NewString(v.Value).Eval(ctx)
+	}
+
+	ctx.SetStepResults(core.ConclusionSuccess, core.ConclusionSuccess)
+
+	return core.ConclusionSuccess, nil
+}
+
+// compositeStep executes the main stage of the given step of the composite action. Run steps run without the current
+// action, so they don't get the inputs of the composite action as INPUT_ variables, like GitHub does, but they keep
+// the action path to access the files of the action.
+func (s *StepAction) compositeStep(ctx *context.Context, step core.Step, composite *core.CustomAction) (core.Conclusion, error) {
+	switch step.Type() {
+	case core.StepTypeRun:
+		ctx.Execution.CurrentAction = nil
+
+		return (&StepRun{Step: step}).main()(ctx)
+	case core.StepTypeAction:
+		sa := &StepAction{Step: step}
+
+		if _, err := sa.setup()(ctx); err != nil {
+			return core.ConclusionFailure, err
+		}
+
+		ctx.SetAction(&sa.Action)
+
+		// restore the composite action path for the subsequent steps
+		defer ctx.SetAction(composite)
+
+		return sa.main()(ctx)
+	default:
+		return core.ConclusionFailure, fmt.Errorf("unsupported step type %s in composite action %s", step.Type(), s.Step.Uses)
+	}
+}
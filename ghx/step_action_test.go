@@ -0,0 +1,95 @@
+package ghx
+
+import (
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestStepAction_Composite(t *testing.T) {
+	action := core.CustomAction{
+		Path: t.TempDir(),
+		Meta: core.CustomActionMeta{
+			Inputs: map[string]core.CustomActionInput{"name": {Default: "world"}},
+			Outputs: map[string]core.CustomActionOutput{
+				"greeting": {Value: "${{ steps.greet.outputs.greeting }}"},
+				"seen":     {Value: "${{ steps.check.outputs.seen }}"},
+			},
+			Runs: core.CustomActionRuns{
+				Using: core.ActionRunsUsingComposite,
+				Steps: []core.Step{
+					{
+						ID:  "greet",
+						Run: `echo "greeting=hello ${{ inputs.name }}" >> "$GITHUB_OUTPUT"; echo "EXPORTED=yes" >> "$GITHUB_ENV"`,
+					},
+					{
+						ID:          "check",
+						Environment: map[string]string{"FROM_INPUT": "${{ inputs.name }}"},
+						Run:         `echo "seen=$EXPORTED-$FROM_INPUT" >> "$GITHUB_OUTPUT"`,
+					},
+					{
+						ID:  "fail",
+						If:  "${{ inputs.name == 'fail' }}",
+						Run: `exit 1`,
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		with     map[string]string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{name: "step inputs", with: map[string]string{"name": "gale"}, expected: map[string]string{"greeting": "hello gale", "seen": "yes-gale"}},
+		{name: "default inputs", expected: map[string]string{"greeting": "hello world", "seen": "yes-world"}},
+		{name: "failing step", with: map[string]string{"name": "fail"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, &core.JobRun{RunID: "1", Job: core.Job{ID: "build"}})
+			ctx.Env = make(context.EnvContext)
+			ctx.Inputs = context.InputsContext{"name": "workflow"}
+			ctx.Job = context.JobContext{Status: core.ConclusionSuccess}
+
+			step := core.Step{ID: "composite", Uses: "./composite", With: tt.with}
+			sr := ctx.Execution.StepRun
+			sr.Step = step
+
+			sa := &StepAction{Step: step, Action: action}
+
+			ctx.SetAction(&sa.Action)
+
+			conclusion, err := sa.composite(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("composite() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				if conclusion != core.ConclusionFailure || sr.Conclusion != core.ConclusionFailure {
+					t.Errorf("expected the composite step to fail, got %s", conclusion)
+				}
+
+				return
+			}
+
+			for k, v := range tt.expected {
+				if got := sr.Outputs[k]; got != v {
+					t.Errorf("expected output %s to be %q, got %q", k, v, got)
+				}
+			}
+
+			if got := sr.Environment["EXPORTED"]; got != "yes" {
+				t.Errorf("expected the exported variable to be kept in the composite step, got %q", got)
+			}
+
+			if ctx.Execution.StepRun != sr || ctx.Inputs["name"] != "workflow" || ctx.Execution.CurrentAction != &sa.Action {
+				t.Errorf("expected the job level contexts to be restored")
+			}
+		})
+	}
+}
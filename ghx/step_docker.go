@@ -1,4 +1,4 @@
-package main
+package ghx
 
 import (
 	"fmt"
@@ -24,6 +24,10 @@ type StepDocker struct {
 
 func (s *StepDocker) setup() task.RunFn {
 	return func(ctx *context.Context) (core.Conclusion, error) {
+		if ctx.Dagger.Client == nil {
+			return core.ConclusionFailure, errNoEngine
+		}
+
 		var (
 			image        = strings.TrimPrefix(s.Step.Uses, "docker://")
 			workspace    = ctx.Github.Workspace
@@ -33,9 +37,11 @@ func (s *StepDocker) setup() task.RunFn {
 		// configure the step container
 		s.container = ctx.Dagger.Client.
 			Container().
-			From(image).
+			From(resolveImage(ctx, image)).
 			WithMountedDirectory(workspace, workspaceDir).
-			WithWorkdir(workspace)
+			WithWorkdir(workspace).
+			With(withProxy(ctx)).
+			With(withActionsServices(ctx))
 
 		// TODO: This will be print same log line if the image used multiple times. However, this scenario is not really common and no benefit to fix this scenario for now.
 		log.Info(fmt.Sprintf("Pull '%s'", image))
@@ -1,10 +1,12 @@
-package main
+package ghx
 
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
 
 	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/log"
 	"github.com/aweris/gale/ghx/context"
 	"github.com/aweris/gale/ghx/core"
 	"github.com/aweris/gale/ghx/expression"
@@ -14,6 +16,9 @@ import (
 // Step is
 var _ Step = new(StepRun)
 
+// dockerRe matches the docker cli invocations in the run scripts.
+var dockerRe = regexp.MustCompile(`(^|[\s;&|(])docker(-compose)?\s`)
+
 // StepRun is a step that runs a job.
 type StepRun struct {
 	Step      core.Step
@@ -97,10 +102,15 @@ func (s *StepRun) main() task.RunFn {
 		s.ShellArgs = args
 		s.Path = path
 
-		executor := NewCmdExecutorFromStepRun(s)
+		executor := withRetries(ctx, NewCmdExecutorFromStepRun(s))
 
 		// execute the step
 		if err := executor.Execute(ctx); err != nil {
+			// docker is a common reason of failures, so giving a hint to the user if it's not enabled
+			if !ctx.GhxConfig.DockerEnabled && dockerRe.MatchString(run) {
+				log.Error("Step uses docker but docker is not enabled for this run. Use the enable docker option to bind a docker engine to the runner.")
+			}
+
 			if s.Step.ContinueOnError {
 				// execution failed and the step is configured to continue on error. So, fail the outcome but succeed the
 				// conclusion.
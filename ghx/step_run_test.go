@@ -0,0 +1,27 @@
+package ghx
+
+import "testing"
+
+func TestDockerRe(t *testing.T) {
+	tests := []struct {
+		run      string
+		expected bool
+	}{
+		{run: "docker build -t app .", expected: true},
+		{run: "make build && docker run --rm app", expected: true},
+		{run: "docker-compose up -d", expected: true},
+		{run: "(docker ps)", expected: true},
+		{run: "echo building\ndocker push app", expected: true},
+		{run: "go test ./...", expected: false},
+		{run: "cat Dockerfile", expected: false},
+		{run: "./scripts/mydocker build", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.run, func(t *testing.T) {
+			if got := dockerRe.MatchString(tt.run); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -98,6 +98,8 @@ func (t *Runner) Run(ctx *context.Context) (Result, error) {
 		startedAt = time.Now()
 	)
 
+	result.StartedAt = startedAt
+
 	t.Status = StatusInProgress
 
 	// run preFn if any
@@ -105,6 +107,7 @@ func (t *Runner) Run(ctx *context.Context) (Result, error) {
 		if err := t.preFn(ctx); err != nil {
 			result.Conclusion = core.ConclusionFailure
 			result.Duration = time.Since(startedAt)
+			result.CompletedAt = startedAt.Add(result.Duration)
 
 			return result, err
 		}
@@ -123,6 +126,7 @@ func (t *Runner) Run(ctx *context.Context) (Result, error) {
 		if err != nil {
 			result.Conclusion = core.ConclusionFailure
 			result.Duration = time.Since(startedAt)
+			result.CompletedAt = startedAt.Add(result.Duration)
 
 			return result, err
 		}
@@ -144,6 +148,7 @@ func (t *Runner) Run(ctx *context.Context) (Result, error) {
 		if err != nil {
 			result.Conclusion = core.ConclusionFailure
 			result.Duration = time.Since(startedAt)
+			result.CompletedAt = startedAt.Add(result.Duration)
 
 			return result, err
 		}
@@ -154,6 +159,7 @@ func (t *Runner) Run(ctx *context.Context) (Result, error) {
 
 	// update the duration
 	result.Duration = time.Since(startedAt)
+	result.CompletedAt = startedAt.Add(result.Duration)
 
 	// set the task completion
 	t.Status = StatusCompleted
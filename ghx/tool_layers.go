@@ -0,0 +1,166 @@
+package ghx
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"dagger.io/dagger"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+)
+
+// semverRegex matches the fully pinned versions, e.g. 1.21.5. Version ranges and partial versions can't be mapped to
+// a single image.
+var semverRegex = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+
+// toolLayer describes how the pinned version of a setup action is satisfied from the official image of the tool. The
+// tool is copied to the tool cache in the layout of the action, so the action finds the version in the tool cache and
+// skips the download while setting up the path and the outputs as usual.
+type toolLayer struct {
+	action  string                       // action is the repository of the setup action
+	input   string                       // input is the version input of the action
+	tool    string                       // tool is the name of the tool in the tool cache
+	source  string                       // source is the path of the tool in the image
+	pinned  *regexp.Regexp               // pinned matches the versions that could be satisfied from an image
+	image   func(version string) string  // image returns the image of the version
+	version func(version string) string  // version returns the version directory in the tool cache, defaults to the version
+	accepts func(map[string]string) bool // accepts returns false if the other inputs require a download, optional
+}
+
+// toolLayers is the list of the setup actions that could be satisfied from the prebuilt tool layers.
+var toolLayers = []toolLayer{
+	{
+		action: "actions/setup-go",
+		input:  "go-version",
+		tool:   "go",
+		source: "/usr/local/go",
+		pinned: semverRegex,
+		image:  func(version string) string { return fmt.Sprintf("golang:%s", version) },
+	},
+	{
+		action: "actions/setup-node",
+		input:  "node-version",
+		tool:   "node",
+		source: "/usr/local",
+		pinned: semverRegex,
+		image:  func(version string) string { return fmt.Sprintf("node:%s", version) },
+	},
+	{
+		// bullseye images are used since the interpreter is built against the glibc of the image, it should be older
+		// than the glibc of the runner images
+		action: "actions/setup-python",
+		input:  "python-version",
+		tool:   "Python",
+		source: "/usr/local",
+		pinned: semverRegex,
+		image:  func(version string) string { return fmt.Sprintf("python:%s-slim-bullseye", version) },
+	},
+	{
+		// only the temurin builds pinned with the build number, e.g. 17.0.9+9, could be mapped to an image tag
+		action: "actions/setup-java",
+		input:  "java-version",
+		tool:   "Java_Temurin-Hotspot_jdk",
+		source: "/opt/java/openjdk",
+		pinned: regexp.MustCompile(`^\d+\.\d+\.\d+\+\d+$`),
+		image: func(version string) string {
+			return fmt.Sprintf("eclipse-temurin:%s-jdk", strings.ReplaceAll(version, "+", "_"))
+		},
+		version: func(version string) string { return strings.ReplaceAll(version, "+", "-") },
+		accepts: func(inputs map[string]string) bool {
+			return actionInput(inputs, "distribution") == "temurin" && actionInput(inputs, "java-package") == "jdk"
+		},
+	},
+}
+
+// plan returns the image of the pinned version in the inputs and the directory of the version in the tool cache. It
+// returns false if the inputs don't pin a version the layer supports.
+func (l toolLayer) plan(inputs map[string]string, toolCache, arch string) (string, string, bool) {
+	version := actionInput(inputs, l.input)
+
+	if !l.pinned.MatchString(version) || (l.accepts != nil && !l.accepts(inputs)) {
+		return "", "", false
+	}
+
+	dir := version
+
+	if l.version != nil {
+		dir = l.version(version)
+	}
+
+	return l.image(version), filepath.Join(toolCache, l.tool, dir, arch), true
+}
+
+// toolCacheArch returns the architecture name used in the tool cache for the architecture of the runner.
+func toolCacheArch() string {
+	if runtime.GOARCH == "amd64" {
+		return "x64"
+	}
+
+	return runtime.GOARCH
+}
+
+// prepareToolLayer copies the tool requested by the current setup action step from its image to the tool cache if the
+// tool layers are enabled and the version is pinned. Image layers are cached by dagger, so only the copy is repeated
+// when the tool cache is empty. Failures are only logged, the action downloads the tool as usual then.
+func prepareToolLayer(ctx *context.Context) {
+	// layers are pulled with the engine, so the actions download the tools themselves without it
+	if !ctx.GhxConfig.ToolLayers || ctx.Dagger.Client == nil {
+		return
+	}
+
+	var layer *toolLayer
+
+	for idx := range toolLayers {
+		if strings.EqualFold(ctx.Github.ActionRepository, toolLayers[idx].action) {
+			layer = &toolLayers[idx]
+			break
+		}
+	}
+
+	if layer == nil {
+		return
+	}
+
+	inputs, err := ctx.ActionInputs()
+	if err != nil {
+		return
+	}
+
+	image, dir, ok := layer.plan(inputs, ctx.Runner.ToolCache, toolCacheArch())
+	if !ok {
+		log.Debugf("Version is not pinned, skipping tool layer", "action", layer.action, "version", actionInput(inputs, layer.input))
+		return
+	}
+
+	complete := dir + ".complete"
+
+	if exists, _ := fs.Exists(complete); exists {
+		log.Debugf("Tool is already in the tool cache", "tool", layer.tool, "path", dir)
+		return
+	}
+
+	image = resolveImage(ctx, image)
+
+	source := ctx.Dagger.Client.
+		Container(dagger.ContainerOpts{Platform: dagger.Platform("linux/" + runtime.GOARCH)}).
+		From(image).
+		Directory(layer.source)
+
+	if _, err := source.Export(ctx.Context, dir); err != nil {
+		log.Warnf("Failed to prepare tool layer, the action downloads the tool instead", "image", image, "error", err)
+		return
+	}
+
+	// the marker file tells the tool cache lookups of the actions that the tool is ready to use
+	if err := fs.WriteFile(complete, nil, 0644); err != nil {
+		log.Warnf("Failed to mark tool layer complete", "path", complete, "error", err)
+		return
+	}
+
+	log.Infof("Tool is prepared from the prebuilt layer", "tool", layer.tool, "image", image, "path", dir)
+}
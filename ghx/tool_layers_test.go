@@ -0,0 +1,51 @@
+package ghx
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestToolLayer_Plan(t *testing.T) {
+	layers := make(map[string]toolLayer)
+
+	for _, layer := range toolLayers {
+		layers[layer.action] = layer
+	}
+
+	tests := []struct {
+		action string
+		inputs map[string]string
+		image  string
+		dir    string
+	}{
+		{action: "actions/setup-go", inputs: map[string]string{"go-version": "1.21.5"}, image: "golang:1.21.5", dir: "go/1.21.5/x64"},
+		{action: "actions/setup-go", inputs: map[string]string{"go-version": "1.21"}},
+		{action: "actions/setup-go", inputs: map[string]string{"go-version": "^1.21.0"}},
+		{action: "actions/setup-node", inputs: map[string]string{"node-version": "20.10.0"}, image: "node:20.10.0", dir: "node/20.10.0/x64"},
+		{action: "actions/setup-python", inputs: map[string]string{"python-version": "3.11.7"}, image: "python:3.11.7-slim-bullseye", dir: "Python/3.11.7/x64"},
+		{
+			action: "actions/setup-java",
+			inputs: map[string]string{"java-version": "17.0.9+9", "distribution": "temurin", "java-package": "jdk"},
+			image:  "eclipse-temurin:17.0.9_9-jdk",
+			dir:    "Java_Temurin-Hotspot_jdk/17.0.9-9/x64",
+		},
+		{action: "actions/setup-java", inputs: map[string]string{"java-version": "17.0.9+9", "distribution": "zulu", "java-package": "jdk"}},
+	}
+
+	for _, tt := range tests {
+		image, dir, ok := layers[tt.action].plan(tt.inputs, "/cache", "x64")
+
+		if ok != (tt.image != "") {
+			t.Errorf("%s %v: expected planned %t, got %t", tt.action, tt.inputs, tt.image != "", ok)
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if image != tt.image || dir != filepath.Join("/cache", tt.dir) {
+			t.Errorf("%s %v: expected %s in %s, got %s in %s", tt.action, tt.inputs, tt.image, tt.dir, image, dir)
+		}
+	}
+}
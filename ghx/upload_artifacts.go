@@ -0,0 +1,312 @@
+package ghx
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aweris/gale/common/log"
+)
+
+// Environment variables with the actions runtime credentials of the GitHub Actions job running gale. They're separate
+// from the ACTIONS_* variables, since those point to the local artifact service during the run.
+const (
+	uploadRuntimeTokenEnv = "GHX_UPLOAD_RUNTIME_TOKEN"
+	uploadResultsURLEnv   = "GHX_UPLOAD_RESULTS_URL"
+)
+
+// artifactServicePath is the path of the twirp artifact service in the results service of GitHub Actions.
+const artifactServicePath = "twirp/github.actions.results.api.v1.ArtifactService/"
+
+// UploadArtifacts uploads the artifacts collected by the local artifact service for a workflow run to the artifacts of
+// the GitHub Actions run gale is running in, so the downstream jobs of the GitHub workflow could download them with
+// actions/download-artifact. Each directory in the artifacts directory is an artifact.
+//
+// Usage: ghx upload-artifacts <artifacts-dir>
+func UploadArtifacts(args []string) error {
+	if len(args) < 1 {
+		return errors.New("artifacts directory is required, usage: ghx upload-artifacts <artifacts-dir>")
+	}
+
+	token, resultsURL := os.Getenv(uploadRuntimeTokenEnv), os.Getenv(uploadResultsURLEnv)
+	if token == "" || resultsURL == "" {
+		return fmt.Errorf("%s and %s are required to upload artifacts", uploadRuntimeTokenEnv, uploadResultsURLEnv)
+	}
+
+	uploader, err := newArtifactUploader(resultsURL, token)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(args[0])
+	if errors.Is(err, os.ErrNotExist) {
+		log.Info("No artifacts to upload")
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		if err := uploader.upload(entry.Name(), filepath.Join(args[0], entry.Name())); err != nil {
+			return fmt.Errorf("failed to upload artifact %s: %w", entry.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// artifactUploader uploads the artifacts to the results service of GitHub Actions with the artifacts v4 protocol.
+//
+// See: https://github.com/actions/toolkit/tree/main/packages/artifact
+type artifactUploader struct {
+	client          *http.Client
+	resultsURL      string
+	token           string
+	runBackendID    string
+	jobRunBackendID string
+}
+
+// newArtifactUploader creates an uploader for the results service. The backend ids of the workflow run and the job are
+// read from the runtime token.
+func newArtifactUploader(resultsURL, token string) (*artifactUploader, error) {
+	runBackendID, jobRunBackendID, err := parseBackendIDs(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &artifactUploader{
+		client:          &http.Client{Timeout: 10 * time.Minute},
+		resultsURL:      strings.TrimSuffix(resultsURL, "/") + "/",
+		token:           token,
+		runBackendID:    runBackendID,
+		jobRunBackendID: jobRunBackendID,
+	}, nil
+}
+
+// parseBackendIDs returns the backend ids of the workflow run and the job from the Actions.Results scope of the runtime
+// token. The token is a JWT, only its claims are read without verifying it.
+func parseBackendIDs(token string) (string, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", "", errors.New("invalid runtime token, expected a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid runtime token: %w", err)
+	}
+
+	var claims struct {
+		Scp string `json:"scp"`
+	}
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", "", fmt.Errorf("invalid runtime token: %w", err)
+	}
+
+	for _, scope := range strings.Fields(claims.Scp) {
+		ids := strings.Split(scope, ":")
+		if len(ids) == 3 && ids[0] == "Actions.Results" {
+			return ids[1], ids[2], nil
+		}
+	}
+
+	return "", "", errors.New("runtime token has no Actions.Results scope")
+}
+
+// upload zips the files in the directory and uploads them as the artifact with the given name.
+func (u *artifactUploader) upload(name, dir string) error {
+	archive, err := os.CreateTemp("", "artifact-*.zip")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	hash := sha256.New()
+
+	if err := zipArtifact(io.MultiWriter(archive, hash), dir); err != nil {
+		return fmt.Errorf("failed to archive artifact: %w", err)
+	}
+
+	size, err := archive.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	var created struct {
+		OK              bool   `json:"ok"`
+		SignedUploadURL string `json:"signed_upload_url"`
+	}
+
+	request := map[string]interface{}{
+		"workflow_run_backend_id":     u.runBackendID,
+		"workflow_job_run_backend_id": u.jobRunBackendID,
+		"name":                        name,
+		"version":                     4,
+	}
+
+	if err := u.call("CreateArtifact", request, &created); err != nil {
+		return err
+	}
+
+	if !created.OK || created.SignedUploadURL == "" {
+		return errors.New("artifact is not created")
+	}
+
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := u.uploadBlob(created.SignedUploadURL, archive, size); err != nil {
+		return err
+	}
+
+	var finalized struct {
+		OK         bool        `json:"ok"`
+		ArtifactID json.Number `json:"artifact_id"`
+	}
+
+	request = map[string]interface{}{
+		"workflow_run_backend_id":     u.runBackendID,
+		"workflow_job_run_backend_id": u.jobRunBackendID,
+		"name":                        name,
+		"size":                        fmt.Sprintf("%d", size),
+		"hash":                        map[string]string{"value": "sha256:" + hex.EncodeToString(hash.Sum(nil))},
+	}
+
+	if err := u.call("FinalizeArtifact", request, &finalized); err != nil {
+		return err
+	}
+
+	if !finalized.OK {
+		return errors.New("artifact is not finalized")
+	}
+
+	log.Infof("Artifact uploaded to GitHub", "name", name, "id", finalized.ArtifactID, "size", size)
+
+	return nil
+}
+
+// call calls the method of the twirp artifact service with the request and decodes the response.
+func (u *artifactUploader) call(method string, request, response interface{}) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u.resultsURL+artifactServicePath+method, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", u.token))
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s failed with status %s: %s", method, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// uploadBlob uploads the archive to the signed blob storage URL returned by the artifact service.
+func (u *artifactUploader) uploadBlob(url string, body io.Reader, size int64) error {
+	req, err := http.NewRequest(http.MethodPut, url, body)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/zip")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		// signed url is a credential, so it isn't included in the error
+		return errors.New("failed to upload artifact archive")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to upload artifact archive, unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// zipArtifact writes the files in the directory to the zip archive. The local artifact service keeps the files uploaded
+// with gzip encoding with the .gz extension, so they're decompressed to their original names.
+func zipArtifact(w io.Writer, dir string) error {
+	zw := zip.NewWriter(w)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		var reader io.Reader = file
+
+		if name, ok := strings.CutSuffix(rel, ".gz"); ok {
+			gr, err := gzip.NewReader(file)
+			if err != nil {
+				return fmt.Errorf("failed to decompress %s: %w", rel, err)
+			}
+			defer gr.Close()
+
+			reader, rel = gr, name
+		}
+
+		entry, err := zw.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(entry, reader)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,143 @@
+package ghx
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseBackendIDs(t *testing.T) {
+	run, job, err := parseBackendIDs(testRuntimeToken("Actions.ExampleScope Actions.Results:run-id:job-id"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if run != "run-id" || job != "job-id" {
+		t.Errorf("expected run-id and job-id, got %s and %s", run, job)
+	}
+
+	if _, _, err := parseBackendIDs(testRuntimeToken("Actions.ExampleScope")); err == nil {
+		t.Error("expected error for token without results scope")
+	}
+
+	if _, _, err := parseBackendIDs("token"); err == nil {
+		t.Error("expected error for invalid token")
+	}
+}
+
+func TestUploadArtifacts(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(dir, "dist", "app.txt"), []byte("app"))
+
+	// files uploaded with gzip encoding are kept with the .gz extension by the local artifact service
+	var gz bytes.Buffer
+
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write([]byte("log"))
+	_ = gw.Close()
+
+	writeTestFile(t, filepath.Join(dir, "dist", "logs", "build.log.gz"), gz.Bytes())
+
+	var (
+		mu       sync.Mutex
+		methods  []string
+		archive  []byte
+		finalize map[string]interface{}
+		server   *httptest.Server
+	)
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Method == http.MethodPut {
+			archive, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+
+		var body map[string]interface{}
+
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		method := filepath.Base(r.URL.Path)
+		methods = append(methods, method)
+
+		if method == "CreateArtifact" {
+			_, _ = w.Write([]byte(`{"ok": true, "signed_upload_url": "` + server.URL + `/blob"}`))
+			return
+		}
+
+		finalize = body
+
+		_, _ = w.Write([]byte(`{"ok": true, "artifact_id": "42"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv(uploadRuntimeTokenEnv, testRuntimeToken("Actions.Results:run-id:job-id"))
+	t.Setenv(uploadResultsURLEnv, server.URL)
+
+	if err := UploadArtifacts([]string{dir}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Join(methods, ",") != "CreateArtifact,FinalizeArtifact" {
+		t.Errorf("expected create and finalize calls, got %v", methods)
+	}
+
+	if finalize["name"] != "dist" || finalize["workflow_job_run_backend_id"] != "job-id" {
+		t.Errorf("unexpected finalize request %v", finalize)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("invalid archive: %v", err)
+	}
+
+	files := make(map[string]string)
+
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+
+		data, _ := io.ReadAll(rc)
+		_ = rc.Close()
+
+		files[f.Name] = string(data)
+	}
+
+	if files["app.txt"] != "app" || files["logs/build.log"] != "log" || len(files) != 2 {
+		t.Errorf("unexpected archive contents %v", files)
+	}
+}
+
+func testRuntimeToken(scp string) string {
+	payload, _ := json.Marshal(map[string]string{"scp": scp})
+
+	return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".signature"
+}
+
+func writeTestFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
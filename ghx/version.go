@@ -0,0 +1,5 @@
+package ghx
+
+// Version is the version of ghx. It's set at build time with -ldflags "-X github.com/aweris/gale/ghx.Version=<version>"
+// for the published images, binaries built from the source report dev.
+var Version = "dev"
@@ -1,13 +1,12 @@
-package main
+package ghx
 
 import (
-	"fmt"
-	"os"
 	"regexp"
 	"strings"
 
 	"github.com/aweris/gale/common/log"
 	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
 )
 
 var (
@@ -86,6 +85,14 @@ func (p *CommandProcessor) ProcessOutput(ctx *context.Context, output string) er
 		return nil
 	}
 
+	// logging and processing the output of the same step could be handled by different processors, so annotations are
+	// collected by the processor having the context even if logging commands are excluded.
+	if ctx != nil {
+		if err := addAnnotation(ctx, cmd); err != nil {
+			return err
+		}
+	}
+
 	if p.exclude[CommandName(cmd.Name)] {
 		return nil
 	}
@@ -105,10 +112,6 @@ func (p *CommandProcessor) ProcessOutput(ctx *context.Context, output string) er
 	case CommandNameNotice:
 		log.Noticef(cmd.Value, "file", cmd.Parameters["file"], "line", cmd.Parameters["line"], "col", cmd.Parameters["col"], "endLine", cmd.Parameters["endLine"], "endCol", cmd.Parameters["endCol"], "title", cmd.Parameters["title"])
 	case CommandNameSetEnv:
-		if err := os.Setenv(cmd.Parameters["name"], cmd.Value); err != nil {
-			return err
-		}
-		// FIXME: for now it's just reporting but we should use this as source of truth for step extra env
 		if err := ctx.SetStepEnv(cmd.Parameters["name"], cmd.Value); err != nil {
 			return err
 		}
@@ -121,24 +124,30 @@ func (p *CommandProcessor) ProcessOutput(ctx *context.Context, output string) er
 			return err
 		}
 	case CommandNameAddMask:
-		log.Info(cmd.Value)
+		log.AddMask(cmd.Value)
 	case CommandNameAddMatcher:
 		log.Info(cmd.Value)
 	case CommandNameAddPath:
-		// FIXME: for now it's just reporting but we should use this as source of truth for step path
 		if err := ctx.AddStepPath(cmd.Value); err != nil {
 			return err
 		}
-		path := os.Getenv("PATH")
-		path = fmt.Sprintf("%s:%s", path, cmd.Value)
-		if err := os.Setenv("PATH", path); err != nil {
-			return err
-		}
 	}
 
 	return nil
 }
 
+// addAnnotation adds the error and warning commands as annotations of the workflow run. Other commands are ignored.
+func addAnnotation(ctx *context.Context, cmd *WorkflowCommand) error {
+	switch CommandName(cmd.Name) {
+	case CommandNameError:
+		return ctx.AddAnnotation(core.AnnotationLevelError, cmd.Value, cmd.Parameters)
+	case CommandNameWarning:
+		return ctx.AddAnnotation(core.AnnotationLevelWarning, cmd.Value, cmd.Parameters)
+	default:
+		return nil
+	}
+}
+
 // parseCommand parses a Workflow command string and returns a Command object. If the string is not a valid Workflow
 // command, it returns false.
 func parseCommand(str string) (bool, *WorkflowCommand) {
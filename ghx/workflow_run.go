@@ -1,9 +1,11 @@
-package main
+package ghx
 
 import (
 	"errors"
 	"fmt"
+	"strconv"
 
+	"github.com/aweris/gale/common/fs"
 	"github.com/aweris/gale/common/log"
 	"github.com/aweris/gale/ghx/context"
 	"github.com/aweris/gale/ghx/core"
@@ -11,6 +13,99 @@ import (
 	"github.com/aweris/gale/ghx/task"
 )
 
+// Run runs the workflow, or the job of the configuration with its dependencies, and writes the result of the run to the
+// ghx home directory. Actions are checked and the caches are verified in offline mode before running the workflow. The
+// conclusion of the workflow doesn't fail the run, it's reported in the result and the reports of the run instead.
+func Run(ctx *context.Context, wf core.Workflow) error {
+	cfg := ctx.GhxConfig
+
+	// fail fast before running the workflow if anything requiring the network is missing in offline mode
+	if cfg.Offline {
+		if err := verifyCached(ctx, wf); err != nil {
+			return fmt.Errorf("failed to verify caches: %w", err)
+		}
+	}
+
+	// warn about the mutable, denied or archived action refs before running the workflow
+	if err := checkActionRefs(ctx, wf); err != nil {
+		return fmt.Errorf("failed to check action refs: %w", err)
+	}
+
+	// route the GitHub API calls of the steps through the audit proxy to record them in the workflow run report
+	if cfg.APIAudit {
+		if err := startAPIAudit(ctx); err != nil {
+			return fmt.Errorf("failed to start api audit: %w", err)
+		}
+	}
+
+	runner, err := planWorkflow(wf, cfg.Job)
+	if err != nil {
+		return fmt.Errorf("failed to plan workflow: %w", err)
+	}
+
+	// errors of the jobs are reported in the result with the failure conclusion, same as the failed steps
+	result, err := runner.Run(ctx)
+	if err != nil {
+		log.Errorf("Workflow run failed", "error", err)
+	}
+
+	ctx.Events.Close()
+
+	if err := fs.WriteJSONFile("/home/runner/_temp/ghx/result.json", &result); err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+
+	var annotations []core.Annotation
+
+	if ctx.Execution.WorkflowRun != nil {
+		annotations = ctx.Execution.WorkflowRun.Annotations
+	}
+
+	return checkFailOn(cfg.FailOn, result.Conclusion, annotations)
+}
+
+// checkFailOn returns an error if the workflow run result violates the given fail on policy, so ghx exits with a
+// non-zero exit code.
+func checkFailOn(policy string, conclusion core.Conclusion, annotations []core.Annotation) error {
+	counts := annotationCounts(annotations)
+
+	switch policy {
+	case "", "none":
+		return nil
+	case "error":
+		if conclusion == core.ConclusionFailure || counts[core.AnnotationLevelError] > 0 {
+			return fmt.Errorf("workflow run failed with %d error annotation(s)", counts[core.AnnotationLevelError])
+		}
+	case "warning":
+		if conclusion == core.ConclusionFailure || counts[core.AnnotationLevelError] > 0 || counts[core.AnnotationLevelWarning] > 0 {
+			return fmt.Errorf("workflow run failed with %d error and %d warning annotation(s)", counts[core.AnnotationLevelError], counts[core.AnnotationLevelWarning])
+		}
+	default:
+		return fmt.Errorf("unsupported fail on policy %s, possible values are: error, warning, none", policy)
+	}
+
+	return nil
+}
+
+// annotationCounts returns the number of the unique annotations per level.
+func annotationCounts(annotations []core.Annotation) map[core.AnnotationLevel]int {
+	var (
+		counts = make(map[core.AnnotationLevel]int)
+		seen   = make(map[core.Annotation]bool)
+	)
+
+	for _, annotation := range annotations {
+		if seen[annotation] {
+			continue
+		}
+
+		seen[annotation] = true
+		counts[annotation.Level]++
+	}
+
+	return counts
+}
+
 // planWorkflow plans the workflow and returns the workflow runner.
 func planWorkflow(workflow core.Workflow, job string) (*task.Runner, error) {
 	var (
@@ -67,41 +162,41 @@ func planWorkflow(workflow core.Workflow, job string) (*task.Runner, error) {
 
 	// runFn is the function that runs the workflow
 	runFn := func(ctx *context.Context) (core.Conclusion, error) {
-		conclusion := core.ConclusionSuccess
+		var (
+			runners  []*task.Runner
+			restored = core.ConclusionSuccess
+		)
 
 		for _, job := range order {
+			// jobs completed in the resumed workflow run are not run again, their results are restored instead
+			if jr, ok := ctx.Execution.WorkflowRun.Jobs[job]; ok {
+				restored = workflowConclusion(restored, jr.Conclusion)
+				continue
+			}
+
 			jm, ok := workflow.Jobs[job]
 			if !ok {
 				return core.ConclusionFailure, fmt.Errorf("job %s not found", job)
 			}
 
-			runners, err := planJob(jm)
+			jobRunners, err := planJob(jm, ctx.GhxConfig.Matrix)
 			if err != nil {
 				return core.ConclusionFailure, err
 			}
 
-			// FIXME: ignoring fail-fast for now. it is always true for now. Fix this later.
-			// FIXME: run all runners sequentially for now. Ignoring parallelism. Fix this later.
-
-			for _, runner := range runners {
-				result, err := runner.Run(ctx)
-				if err != nil {
-					return core.ConclusionFailure, err
-				}
-
-				if conclusion == core.ConclusionSuccess && result.Conclusion != conclusion {
-					conclusion = result.Conclusion
-				}
-			}
+			runners = append(runners, jobRunners...)
 		}
 
-		return conclusion, nil
+		conclusion, err := runJobs(ctx, runners)
+
+		return workflowConclusion(conclusion, restored), err
 	}
 
 	// workflow task options
 	opt := task.Opts{
-		PreRunFn:  newTaskPreRunFnForWorkflow(workflow),
-		PostRunFn: newTaskPostRunFnForWorkflow(),
+		ConditionalFn: newTaskConditionalFnForWorkflow(workflow),
+		PreRunFn:      newTaskPreRunFnForWorkflow(workflow),
+		PostRunFn:     newTaskPostRunFnForWorkflow(),
 	}
 
 	// create the workflow task runner from the runFn and options
@@ -110,23 +205,157 @@ func planWorkflow(workflow core.Workflow, job string) (*task.Runner, error) {
 	return &runner, nil
 }
 
+// runJobs runs the job runners in order and returns the conclusion of the workflow. Remaining jobs run after a failed
+// job, so the jobs with always() or failure() conditions can clean up, and the errors of the jobs are returned after all
+// jobs run. A stopped workflow, by a breakpoint or the user, returns immediately without running the remaining jobs.
+func runJobs(ctx *context.Context, runners []*task.Runner) (core.Conclusion, error) {
+	var errs []error
+
+	conclusion := core.ConclusionSuccess
+
+	// FIXME: ignoring fail-fast for now. it is always true for now. Fix this later.
+	// FIXME: run all runners sequentially for now. Ignoring parallelism. Fix this later.
+
+	for _, runner := range runners {
+		result, err := runner.Run(ctx)
+
+		if errors.Is(err, errStopped) {
+			return core.ConclusionCancelled, err
+		}
+
+		if err != nil {
+			log.Errorf("Job failed", "name", runner.Name, "error", err)
+
+			errs = append(errs, fmt.Errorf("%s: %w", runner.Name, err))
+		}
+
+		conclusion = workflowConclusion(conclusion, result.Conclusion)
+
+		// stop the workflow to keep the state of the failed step for the debug shell
+		if ctx.GhxConfig.DebugShell && conclusion == core.ConclusionFailure {
+			return conclusion, errors.Join(errs...)
+		}
+	}
+
+	return conclusion, errors.Join(errs...)
+}
+
+// workflowConclusion returns the conclusion of the workflow after a job with the given conclusion. The first conclusion
+// other than success is kept and skipped jobs don't change the conclusion of the workflow like GitHub.
+func workflowConclusion(workflow, job core.Conclusion) core.Conclusion {
+	if workflow == core.ConclusionSuccess && job != core.ConclusionSuccess && job != core.ConclusionSkipped {
+		return job
+	}
+
+	return workflow
+}
+
+// newTaskConditionalFnForWorkflow returns a task conditional function that skips the workflow if none of the changed
+// paths match the path filters of the event.
+func newTaskConditionalFnForWorkflow(wf core.Workflow) task.ConditionalFn {
+	return func(ctx *context.Context) (bool, core.Conclusion, error) {
+		changed := ctx.GhxConfig.ChangedPaths
+
+		if len(changed) == 0 || isAffected(wf, ctx.Github.EventName, changed) {
+			return true, "", nil
+		}
+
+		log.Infof("Changed paths don't match the path filters of the workflow", "event", ctx.Github.EventName, "paths", changed)
+
+		return false, core.ConclusionSkipped, nil
+	}
+}
+
 func newTaskPreRunFnForWorkflow(wf core.Workflow) task.PreRunFn {
 	return func(ctx *context.Context) error {
-		runID, err := idgen.GenerateWorkflowRunID(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to generate workflow run id: %w", err)
-		}
-
-		return ctx.SetWorkflow(
-			&core.WorkflowRun{
-				RunID:         runID,
-				RunNumber:     "1",
-				RunAttempt:    "1",
-				RetentionDays: "0",
-				Workflow:      wf,
-				Jobs:          make(map[string]core.JobRun),
-			},
+		wr := &core.WorkflowRun{
+			RunNumber:     "1",
+			RunAttempt:    "1",
+			RetentionDays: strconv.Itoa(ctx.GhxConfig.RetentionDays),
+			Workflow:      wf,
+			Jobs:          make(map[string]core.JobRun),
+		}
+
+		// reruns keep the run id and the run number of the previous attempt, only the attempt number is bumped
+		var (
+			previous *previousAttempt
+			resumed  *resumedRun
 		)
+
+		if dir := ctx.GhxConfig.PreviousRun; dir != "" {
+			var err error
+
+			previous, err = loadPreviousAttempt(dir)
+			if err != nil {
+				return err
+			}
+
+			wr.RunID, wr.RunNumber, wr.RunAttempt = previous.RunID, previous.RunNumber, previous.next()
+		} else if dir := ctx.GhxConfig.ResumeRun; dir != "" {
+			var err error
+
+			// resumed runs continue the same attempt of the workflow run
+			resumed, err = loadResumedRun(dir)
+			if err != nil {
+				return err
+			}
+
+			wr.RunID, wr.RunNumber, wr.RunAttempt = resumed.RunID, resumed.RunNumber, resumed.RunAttempt
+			wr.Annotations = resumed.Annotations
+		} else {
+			runID, err := idgen.GenerateWorkflowRunID(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to generate workflow run id: %w", err)
+			}
+
+			wr.RunID = runID
+		}
+
+		if err := ctx.SetWorkflow(wr); err != nil {
+			return err
+		}
+
+		if previous != nil {
+			dir, err := ctx.GetWorkflowRunPath()
+			if err != nil {
+				return err
+			}
+
+			if err := previous.keep(dir); err != nil {
+				return fmt.Errorf("failed to keep previous attempts: %w", err)
+			}
+		}
+
+		if resumed != nil {
+			dir, err := ctx.GetWorkflowRunPath()
+			if err != nil {
+				return err
+			}
+
+			if err := resumed.keep(dir); err != nil {
+				return fmt.Errorf("failed to keep resumed workflow run: %w", err)
+			}
+
+			runs, err := resumed.jobRuns(wf)
+			if err != nil {
+				return err
+			}
+
+			for _, jr := range runs {
+				if err := ctx.RestoreJobRun(jr); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := snapshotWorkspace(ctx); err != nil {
+			return fmt.Errorf("failed to snapshot workspace: %w", err)
+		}
+
+		// run id is printed to follow the logs of the run while it's running
+		log.Infof("Workflow run started", "run-id", wr.RunID, "attempt", wr.RunAttempt)
+
+		return nil
 	}
 }
 
@@ -134,6 +363,49 @@ func newTaskPostRunFnForWorkflow() task.PostRunFn {
 	return func(ctx *context.Context, result task.Result) {
 		log.Infof("Complete", "workflow", ctx.Execution.WorkflowRun.Workflow.Name, "conclusion", result.Conclusion)
 
+		printDurationSummary(ctx.Execution.WorkflowRun, result.Duration)
+
+		printAnnotationsSummary(ctx.Execution.WorkflowRun)
+
+		for _, format := range ctx.GhxConfig.Report {
+			switch format {
+			case reportFormatHTML:
+				if err := writeHTMLReport(ctx, result.Duration); err != nil {
+					log.Errorf("failed to write html report", "error", err)
+				}
+			default:
+				log.Warnf("Unsupported report format", "format", format)
+			}
+		}
+
 		ctx.UnsetWorkflow(context.RunResult(result))
 	}
 }
+
+// printAnnotationsSummary prints the error and warning annotations created by the steps of the workflow run.
+func printAnnotationsSummary(wr *core.WorkflowRun) {
+	if len(wr.Annotations) == 0 {
+		return
+	}
+
+	counts := annotationCounts(wr.Annotations)
+
+	log.Info(fmt.Sprintf("Annotations: %d error(s), %d warning(s)", counts[core.AnnotationLevelError], counts[core.AnnotationLevelWarning]))
+	log.StartGroup()
+
+	for _, annotation := range wr.Annotations {
+		location := fmt.Sprintf("%s/%s", annotation.Job, annotation.Step)
+
+		if annotation.File != "" {
+			location = fmt.Sprintf("%s %s", location, annotation.File)
+
+			if annotation.Line != "" {
+				location = fmt.Sprintf("%s:%s", location, annotation.Line)
+			}
+		}
+
+		log.Info(fmt.Sprintf("%s: [%s] %s", annotation.Level, location, annotation.Message))
+	}
+
+	log.EndGroup()
+}
@@ -0,0 +1,159 @@
+package ghx
+
+import (
+	stdContext "context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+	"github.com/aweris/gale/ghx/task"
+)
+
+func TestRunJobs(t *testing.T) {
+	errJob := errors.New("job error")
+
+	type job struct {
+		name       string
+		conclusion core.Conclusion
+		err        error
+	}
+
+	tests := []struct {
+		name       string
+		jobs       []job
+		debugShell bool
+		conclusion core.Conclusion
+		err        error
+		ran        []string
+	}{
+		{
+			name:       "all succeed",
+			jobs:       []job{{name: "build", conclusion: core.ConclusionSuccess}, {name: "test", conclusion: core.ConclusionSuccess}},
+			conclusion: core.ConclusionSuccess,
+			ran:        []string{"build", "test"},
+		},
+		{
+			name:       "skipped job keeps the conclusion",
+			jobs:       []job{{name: "build", conclusion: core.ConclusionSkipped}, {name: "test", conclusion: core.ConclusionSuccess}},
+			conclusion: core.ConclusionSuccess,
+			ran:        []string{"build", "test"},
+		},
+		{
+			name:       "remaining jobs run after a failure",
+			jobs:       []job{{name: "build", conclusion: core.ConclusionFailure}, {name: "cleanup", conclusion: core.ConclusionSuccess}},
+			conclusion: core.ConclusionFailure,
+			ran:        []string{"build", "cleanup"},
+		},
+		{
+			name:       "job errors are returned after the remaining jobs",
+			jobs:       []job{{name: "build", err: errJob}, {name: "cleanup", conclusion: core.ConclusionSuccess}},
+			conclusion: core.ConclusionFailure,
+			err:        errJob,
+			ran:        []string{"build", "cleanup"},
+		},
+		{
+			name:       "stopped workflow returns immediately",
+			jobs:       []job{{name: "build", err: errStopped}, {name: "cleanup", conclusion: core.ConclusionSuccess}},
+			conclusion: core.ConclusionCancelled,
+			err:        errStopped,
+			ran:        []string{"build"},
+		},
+		{
+			name:       "debug shell stops at the failed job",
+			jobs:       []job{{name: "build", conclusion: core.ConclusionFailure}, {name: "cleanup", conclusion: core.ConclusionSuccess}},
+			debugShell: true,
+			conclusion: core.ConclusionFailure,
+			ran:        []string{"build"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran []string
+
+			runners := make([]*task.Runner, 0, len(tt.jobs))
+
+			for _, j := range tt.jobs {
+				j := j
+
+				runner := task.New(j.name, func(ctx *context.Context) (core.Conclusion, error) {
+					ran = append(ran, j.name)
+
+					return j.conclusion, j.err
+				})
+
+				runners = append(runners, &runner)
+			}
+
+			ctx := &context.Context{Context: stdContext.Background(), GhxConfig: context.GhxConfig{DebugShell: tt.debugShell}}
+
+			conclusion, err := runJobs(ctx, runners)
+
+			if conclusion != tt.conclusion {
+				t.Errorf("expected conclusion %s, got %s", tt.conclusion, conclusion)
+			}
+
+			if tt.err == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("expected error %v, got %v", tt.err, err)
+			}
+
+			if !reflect.DeepEqual(ran, tt.ran) {
+				t.Errorf("expected jobs %v to run, got %v", tt.ran, ran)
+			}
+		})
+	}
+}
+
+func TestCheckFailOn(t *testing.T) {
+	var (
+		errorAnnotation   = core.Annotation{Level: core.AnnotationLevelError, Message: "broken", Job: "build", Step: "test"}
+		warningAnnotation = core.Annotation{Level: core.AnnotationLevelWarning, Message: "deprecated", Job: "build", Step: "test"}
+	)
+
+	tests := []struct {
+		name        string
+		policy      string
+		conclusion  core.Conclusion
+		annotations []core.Annotation
+		wantErr     bool
+	}{
+		{name: "none ignores failures", policy: "none", conclusion: core.ConclusionFailure, annotations: []core.Annotation{errorAnnotation}},
+		{name: "default ignores failures", conclusion: core.ConclusionFailure},
+		{name: "error fails on failed workflow", policy: "error", conclusion: core.ConclusionFailure, wantErr: true},
+		{name: "error fails on error annotations", policy: "error", conclusion: core.ConclusionSuccess, annotations: []core.Annotation{errorAnnotation}, wantErr: true},
+		{name: "error ignores warnings", policy: "error", conclusion: core.ConclusionSuccess, annotations: []core.Annotation{warningAnnotation}},
+		{name: "warning fails on warnings", policy: "warning", conclusion: core.ConclusionSuccess, annotations: []core.Annotation{warningAnnotation}, wantErr: true},
+		{name: "warning passes clean runs", policy: "warning", conclusion: core.ConclusionSuccess},
+		{name: "unsupported policy", policy: "notice", conclusion: core.ConclusionSuccess, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkFailOn(tt.policy, tt.conclusion, tt.annotations)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkFailOn() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAnnotationCounts(t *testing.T) {
+	annotations := []core.Annotation{
+		{Level: core.AnnotationLevelError, Message: "broken", Job: "build", Step: "test"},
+		{Level: core.AnnotationLevelError, Message: "broken", Job: "build", Step: "test"},
+		{Level: core.AnnotationLevelError, Message: "broken", Job: "build", Step: "lint"},
+		{Level: core.AnnotationLevelWarning, Message: "deprecated", Job: "build", Step: "test"},
+	}
+
+	expected := map[core.AnnotationLevel]int{core.AnnotationLevelError: 2, core.AnnotationLevelWarning: 1}
+
+	if got := annotationCounts(annotations); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
@@ -1,15 +1,54 @@
-package main
+package ghx
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/ghx/context"
 	"github.com/aweris/gale/ghx/core"
 )
 
+// stdinWorkflow is the workflow name to read the workflow from stdin or the workflow file instead of the workflows
+// directory.
+const stdinWorkflow = "-"
+
+// LoadWorkflow loads the workflow to run with the given configuration. If the workflow file is set, the workflow is
+// loaded from the file. Otherwise, workflow - is read from the given reader, e.g. ghx < generated.yml, and the other
+// workflows are looked up in the workflows directory with their names or paths.
+func LoadWorkflow(cfg context.GhxConfig, stdin io.Reader) (core.Workflow, error) {
+	switch {
+	case cfg.WorkflowFile != "":
+		data, err := os.ReadFile(cfg.WorkflowFile)
+		if err != nil {
+			return core.Workflow{}, fmt.Errorf("failed to read workflow file: %w", err)
+		}
+
+		return core.ParseWorkflow(data, cfg.WorkflowFile)
+	case cfg.Workflow == stdinWorkflow:
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return core.Workflow{}, fmt.Errorf("failed to read workflow from stdin: %w", err)
+		}
+
+		return core.ParseWorkflow(data, "stdin")
+	}
+
+	workflows, err := LoadWorkflows(cfg.WorkflowsDir)
+	if err != nil {
+		return core.Workflow{}, fmt.Errorf("failed to load workflows: %w", err)
+	}
+
+	wf, ok := workflows[cfg.Workflow]
+	if !ok {
+		return core.Workflow{}, fmt.Errorf("workflow %s not found", cfg.Workflow)
+	}
+
+	return wf, nil
+}
+
 func LoadWorkflows(path string) (map[string]core.Workflow, error) {
 	workflows := make(map[string]core.Workflow)
 
@@ -23,38 +62,14 @@ func LoadWorkflows(path string) (map[string]core.Workflow, error) {
 		}
 
 		if strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
-			var workflow core.Workflow
-
-			if err := fs.ReadYAMLFile(path, &workflow); err != nil {
+			data, err := os.ReadFile(path)
+			if err != nil {
 				return err
 			}
 
-			// set workflow path
-			workflow.Path = path
-
-			// if the workflow name is not provided, use the relative path to the workflow file.
-			if workflow.Name == "" {
-				workflow.Name = path
-			}
-
-			// update job ID and names
-			for idj, job := range workflow.Jobs {
-				job.ID = idj
-
-				if job.Name == "" {
-					job.Name = idj
-				}
-
-				// update step IDs if not provided
-				for ids, step := range job.Steps {
-					if step.ID == "" {
-						step.ID = fmt.Sprintf("%d", ids)
-					}
-
-					job.Steps[ids] = step
-				}
-
-				workflow.Jobs[idj] = job
+			workflow, err := core.ParseWorkflow(data, path)
+			if err != nil {
+				return err
 			}
 
 			workflows[workflow.Name] = workflow
@@ -0,0 +1,78 @@
+package ghx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+)
+
+func TestLoadWorkflow(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, content string) string {
+		t.Helper()
+
+		path := filepath.Join(dir, name)
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		return path
+	}
+
+	write("ci.yml", "name: CI\non: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n")
+	generated := write("generated.txt", "name: Generated\non: push\njobs:\n  test:\n    runs-on: ubuntu-latest\n")
+
+	tests := []struct {
+		name    string
+		cfg     context.GhxConfig
+		stdin   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "workflows directory",
+			cfg:  context.GhxConfig{Workflow: "CI", WorkflowsDir: dir},
+			want: "CI",
+		},
+		{
+			name: "workflow file",
+			cfg:  context.GhxConfig{Workflow: "-", WorkflowFile: generated, WorkflowsDir: dir},
+			want: "Generated",
+		},
+		{
+			name:  "stdin",
+			cfg:   context.GhxConfig{Workflow: "-", WorkflowsDir: dir},
+			stdin: "on: push\njobs:\n  lint:\n    runs-on: ubuntu-latest\n",
+			want:  "stdin",
+		},
+		{
+			name:    "missing workflow",
+			cfg:     context.GhxConfig{Workflow: "Release", WorkflowsDir: dir},
+			wantErr: true,
+		},
+		{
+			name:    "invalid stdin",
+			cfg:     context.GhxConfig{Workflow: "-", WorkflowsDir: dir},
+			stdin:   "jobs: [",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf, err := LoadWorkflow(tt.cfg, strings.NewReader(tt.stdin))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadWorkflow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && wf.Name != tt.want {
+				t.Errorf("LoadWorkflow() name = %s, want %s", wf.Name, tt.want)
+			}
+		})
+	}
+}
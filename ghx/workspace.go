@@ -0,0 +1,136 @@
+package ghx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/aweris/gale/common/fs"
+	"github.com/aweris/gale/common/log"
+	"github.com/aweris/gale/ghx/context"
+)
+
+// workspaceSnapshot returns the directory of the workspace snapshot of the workflow run. Isolated jobs start with the
+// workspace restored from the snapshot.
+func workspaceSnapshot(ctx *context.Context) string {
+	return filepath.Join(ctx.Runner.Temp, "workspace-snapshot", ctx.Execution.WorkflowRun.RunID)
+}
+
+// snapshotWorkspace copies the workspace at the start of the workflow run to restore it for the isolated jobs.
+func snapshotWorkspace(ctx *context.Context) error {
+	if ctx.GhxConfig.WorkspaceMode != context.WorkspaceModeIsolated {
+		return nil
+	}
+
+	return copyDir(ctx.Github.Workspace, workspaceSnapshot(ctx))
+}
+
+// preservedWorkspace returns the directory keeping the workspace of the current job between the workflow runs. It
+// returns false if the job doesn't preserve its workspace.
+func preservedWorkspace(ctx *context.Context) (string, bool) {
+	jr := ctx.Execution.JobRun
+
+	if jr == nil || !slices.Contains(ctx.GhxConfig.PreserveWorkspaces, jr.Job.ID) {
+		return "", false
+	}
+
+	if ctx.GhxConfig.WorkspacesDir == "" {
+		log.Warnf("Workspace of the job can't be preserved, no workspaces directory is configured", "job", jr.Job.ID)
+		return "", false
+	}
+
+	name := jr.Job.ID
+
+	// matrix combinations of the job keep their own workspaces
+	if jr.JobTotal > 1 {
+		name = fmt.Sprintf("%s-%d", jr.Job.ID, jr.JobIndex)
+	}
+
+	return filepath.Join(ctx.GhxConfig.WorkspacesDir, name), true
+}
+
+// prepareWorkspace prepares the workspace of the current job. Jobs preserving their workspace start with the workspace
+// of their previous run and isolated jobs start with the workspace snapshot of the workflow run. Otherwise, the job
+// continues with the workspace left by the previous jobs.
+func prepareWorkspace(ctx *context.Context) error {
+	if dir, ok := preservedWorkspace(ctx); ok {
+		exists, err := fs.Exists(dir)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			log.Infof("Restoring preserved workspace", "job", ctx.Execution.JobRun.Job.ID)
+
+			return replaceDir(ctx.Github.Workspace, dir)
+		}
+	}
+
+	if ctx.GhxConfig.WorkspaceMode != context.WorkspaceModeIsolated {
+		return nil
+	}
+
+	return replaceDir(ctx.Github.Workspace, workspaceSnapshot(ctx))
+}
+
+// saveWorkspace keeps the workspace of the current job for its next run if the job preserves its workspace.
+func saveWorkspace(ctx *context.Context) error {
+	dir, ok := preservedWorkspace(ctx)
+	if !ok {
+		return nil
+	}
+
+	if err := fs.EnsureDir(dir); err != nil {
+		return err
+	}
+
+	return replaceDir(dir, ctx.Github.Workspace)
+}
+
+// replaceDir replaces the contents of the destination directory with the contents of the source directory. The
+// destination directory itself is kept, since the workspace is a mount point in the runner.
+func replaceDir(dst, src string) error {
+	entries, err := os.ReadDir(dst)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return copyDir(src, dst)
+}
+
+// copyDir copies the source directory to the destination directory recursively. Symlinks are copied as they are.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case d.IsDir():
+			return fs.EnsureDir(target)
+		case d.Type()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(link, target)
+		default:
+			return fs.CopyFile(path, target)
+		}
+	})
+}
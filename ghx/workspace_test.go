@@ -0,0 +1,83 @@
+package ghx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aweris/gale/ghx/context"
+	"github.com/aweris/gale/ghx/core"
+)
+
+func TestPrepareWorkspace_Isolated(t *testing.T) {
+	ctx := newWorkspaceTestContext(t)
+	ctx.GhxConfig.WorkspaceMode = context.WorkspaceModeIsolated
+
+	writeTestFile(t, filepath.Join(ctx.Github.Workspace, "main.go"), []byte("package main"))
+
+	if err := snapshotWorkspace(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// changes of the previous job are dropped for the next job
+	writeTestFile(t, filepath.Join(ctx.Github.Workspace, "bin", "app"), []byte("app"))
+
+	if err := prepareWorkspace(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(ctx.Github.Workspace, "bin")); !os.IsNotExist(err) {
+		t.Errorf("expected changes of the previous job to be removed, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(ctx.Github.Workspace, "main.go")); err != nil {
+		t.Errorf("expected workspace to be restored, got %v", err)
+	}
+}
+
+func TestPrepareWorkspace_Preserved(t *testing.T) {
+	ctx := newWorkspaceTestContext(t)
+	ctx.GhxConfig.PreserveWorkspaces = []string{"build"}
+	ctx.GhxConfig.WorkspacesDir = t.TempDir()
+
+	writeTestFile(t, filepath.Join(ctx.Github.Workspace, "cache", "state"), []byte("state"))
+
+	if err := saveWorkspace(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// next run starts with a fresh workspace
+	if err := replaceDir(ctx.Github.Workspace, t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := prepareWorkspace(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(ctx.Github.Workspace, "cache", "state")); err != nil || string(data) != "state" {
+		t.Errorf("expected preserved workspace to be restored, got %q with error %v", data, err)
+	}
+
+	// other jobs don't preserve their workspace
+	ctx.Execution.JobRun = &core.JobRun{Job: core.Job{ID: "test"}}
+
+	if _, ok := preservedWorkspace(ctx); ok {
+		t.Error("expected workspace of test job not to be preserved")
+	}
+}
+
+func newWorkspaceTestContext(t *testing.T) *context.Context {
+	ctx := &context.Context{
+		Execution: context.ExecutionContext{
+			WorkflowRun: &core.WorkflowRun{RunID: "1"},
+			JobRun:      &core.JobRun{Job: core.Job{ID: "build"}},
+		},
+	}
+
+	ctx.Github.Workspace = t.TempDir()
+	ctx.Runner.Temp = t.TempDir()
+	ctx.GhxConfig.WorkspaceMode = context.WorkspaceModeShared
+
+	return ctx
+}
@@ -0,0 +1,91 @@
+#!/usr/bin/env bash
+#
+# Runs gale on a remote dagger engine. The workflow runs on the engine while the dagger CLI stays local. Engines are
+# checked in order and the first healthy one is used, so a list of engines can be used as a simple engine pool.
+#
+# Usage: scripts/remote.sh [--engine <host>]... [--engine <job>=<host>]... <workflows run options>
+#
+# Engine hosts are dagger runner hosts, e.g. tcp://build-box:8080 or kube-pod://dagger-engine?namespace=dagger. An
+# engine given in <job>=<host> format is only used when the job is selected with the --job option.
+#
+# Examples:
+#   scripts/remote.sh --engine tcp://build-box:8080 --workflow ci --job test
+#   scripts/remote.sh --engine test=tcp://gpu-box:8080 --engine tcp://build-box:8080 --workflow ci --job test
+#
+# Environment:
+#   GALE_MODULE          gale module to call, defaults to github.com/jpadams/gale/daggerverse/gale@main
+#   GALE_ENGINES         comma separated engine hosts used when no engine is given with the --engine option
+#   GALE_ENGINE_TIMEOUT  timeout of the engine health check in seconds, defaults to 10
+
+set -euo pipefail
+
+module="${GALE_MODULE:-github.com/jpadams/gale/daggerverse/gale@main}"
+timeout="${GALE_ENGINE_TIMEOUT:-10}"
+
+engines=()
+job_engines=()
+args=()
+job=""
+
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --engine)
+      value="${2:?--engine requires a value}"
+
+      # only urls have ://, so a job mapping is the value with = before the scheme
+      if [[ "$value" == *=* && "${value%%=*}" != *://* ]]; then
+        job_engines+=("$value")
+      else
+        engines+=("$value")
+      fi
+
+      shift 2
+      ;;
+    --job)
+      job="${2:?--job requires a value}"
+      args+=("$1" "$2")
+      shift 2
+      ;;
+    *)
+      args+=("$1")
+      shift
+      ;;
+  esac
+done
+
+if [ ${#engines[@]} -eq 0 ] && [ -n "${GALE_ENGINES:-}" ]; then
+  IFS=',' read -r -a engines <<< "$GALE_ENGINES"
+fi
+
+# engine selected for the job has precedence over the engine pool
+if [ -n "$job" ]; then
+  for mapping in "${job_engines[@]+"${job_engines[@]}"}"; do
+    if [ "${mapping%%=*}" == "$job" ]; then
+      engines=("${mapping#*=}" "${engines[@]+"${engines[@]}"}")
+    fi
+  done
+fi
+
+if [ ${#engines[@]} -eq 0 ]; then
+  echo "no engine given, use the --engine option or the GALE_ENGINES environment variable" >&2
+  exit 1
+fi
+
+# healthy checks the engine by running a query with a timeout
+healthy() {
+  _EXPERIMENTAL_DAGGER_RUNNER_HOST="$1" timeout "$timeout" dagger query --silent <<< '{ defaultPlatform }' > /dev/null 2>&1
+}
+
+for engine in "${engines[@]}"; do
+  if ! healthy "$engine"; then
+    echo "Engine $engine is not reachable, trying the next one" >&2
+    continue
+  fi
+
+  echo "Running on engine $engine" >&2
+
+  _EXPERIMENTAL_DAGGER_RUNNER_HOST="$engine" exec dagger call -m "$module" workflows run --source . "${args[@]}" result
+done
+
+echo "none of the engines is reachable: ${engines[*]}" >&2
+exit 1
@@ -0,0 +1,59 @@
+#!/usr/bin/env bash
+#
+# Tests the engine selection of scripts/remote.sh with a fake dagger CLI. The fake CLI reports the engines listed in
+# HEALTHY_ENGINES as healthy and prints the engine the workflow runs on instead of calling the module.
+#
+# Usage: scripts/remote_test.sh
+
+set -euo pipefail
+
+script="$(cd "$(dirname "$0")" && pwd)/remote.sh"
+
+bin="$(mktemp -d)"
+trap 'rm -rf "$bin"' EXIT
+
+cat > "$bin/dagger" <<'EOF'
+#!/usr/bin/env bash
+case "$1" in
+  query)
+    [[ ",${HEALTHY_ENGINES:-}," == *",$_EXPERIMENTAL_DAGGER_RUNNER_HOST,"* ]]
+    ;;
+  call)
+    echo "$_EXPERIMENTAL_DAGGER_RUNNER_HOST"
+    ;;
+esac
+EOF
+chmod +x "$bin/dagger"
+
+# each case is "<name>|<healthy engines>|<GALE_ENGINES>|<args>|<expected engine>", an empty expected engine means failure
+tests=(
+  "first healthy engine|tcp://b:8080||--engine tcp://a:8080 --engine tcp://b:8080 --workflow ci|tcp://b:8080"
+  "engine pool from environment|tcp://a:8080|tcp://a:8080,tcp://b:8080|--workflow ci|tcp://a:8080"
+  "engine option over environment|tcp://a:8080,tcp://b:8080|tcp://a:8080|--engine tcp://b:8080 --workflow ci|tcp://b:8080"
+  "job engine first|tcp://a:8080,tcp://gpu:8080||--engine tcp://a:8080 --engine test=tcp://gpu:8080 --job test|tcp://gpu:8080"
+  "job engine of another job|tcp://a:8080,tcp://gpu:8080||--engine tcp://a:8080 --engine test=tcp://gpu:8080 --job lint|tcp://a:8080"
+  "job engine without job|tcp://gpu:8080||--engine test=tcp://gpu:8080 --workflow ci|"
+  "url with equal sign|kube-pod://engine?namespace=dagger||--engine kube-pod://engine?namespace=dagger|kube-pod://engine?namespace=dagger"
+  "no healthy engine|||--engine tcp://a:8080|"
+  "no engine||||"
+)
+
+failed=0
+
+for tt in "${tests[@]}"; do
+  IFS='|' read -r name healthy pool args expected <<< "$tt"
+
+  # shellcheck disable=SC2086 # args are split into the options on purpose
+  got="$(PATH="$bin:$PATH" HEALTHY_ENGINES="$healthy" GALE_ENGINES="$pool" "$script" $args 2> /dev/null)" || got=""
+
+  if [ "$got" != "$expected" ]; then
+    echo "FAIL: $name: expected \"$expected\", got \"$got\"" >&2
+    failed=1
+  fi
+done
+
+if [ "$failed" -ne 0 ]; then
+  exit 1
+fi
+
+echo "PASS"
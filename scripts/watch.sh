@@ -0,0 +1,58 @@
+#!/usr/bin/env bash
+#
+# Watches the repository working tree and re-runs the workflow with gale when files change. Changed paths are passed to
+# the run, so the workflow is skipped if the changes don't match the path filters of the event.
+#
+# Usage: scripts/watch.sh <workflow> [additional workflows run options]
+#
+# Environment:
+#   GALE_MODULE          gale module to call, defaults to github.com/jpadams/gale/daggerverse/gale@main
+#   GALE_WATCH_INTERVAL  polling interval in seconds, defaults to 2
+
+set -euo pipefail
+
+workflow="${1:?usage: $0 <workflow> [additional workflows run options]}"
+shift
+
+module="${GALE_MODULE:-github.com/jpadams/gale/daggerverse/gale@main}"
+interval="${GALE_WATCH_INTERVAL:-2}"
+
+# snapshot prints the content hash and path of the modified and untracked files in the working tree
+snapshot() {
+  git ls-files -m -o --exclude-standard -z | while IFS= read -r -d '' file; do
+    printf '%s\t%s\n' "$(git hash-object -- "$file" 2>/dev/null || echo deleted)" "$file"
+  done | sort
+}
+
+# run runs the workflow with the given comma separated changed paths
+run() {
+  local args=(workflows run --source . --workflow "$workflow" "$@")
+
+  if [ -n "$changed" ]; then
+    args+=(--changed-paths "$changed")
+  fi
+
+  dagger call -m "$module" "${args[@]}" result || true
+}
+
+changed=""
+previous="$(snapshot)"
+
+run "$@"
+
+while true; do
+  sleep "$interval"
+
+  current="$(snapshot)"
+
+  if [ "$current" == "$previous" ]; then
+    continue
+  fi
+
+  changed="$(diff <(echo "$previous") <(echo "$current") | grep '^[<>]' | cut -c3- | cut -f2- | sort -u | paste -sd, -)"
+  previous="$current"
+
+  echo "Changes detected: $changed"
+
+  run "$@"
+done
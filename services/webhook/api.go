@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// logsPollInterval is the interval to check the run log for the new entries while streaming the logs of a run.
+const logsPollInterval = 500 * time.Millisecond
+
+// RunRequest represents the request to trigger a workflow run with the API.
+type RunRequest struct {
+	Repo     string          `json:"repo"`     // Repo is the repository in owner/name format.
+	Ref      string          `json:"ref"`      // Ref is the git ref to checkout. If empty, the default branch is used.
+	Commit   string          `json:"commit"`   // Commit is the commit SHA to checkout. If set, it has precedence over the ref.
+	Workflow string          `json:"workflow"` // Workflow is the name or the path of the workflow to run. If empty, all triggered workflows run.
+	Event    string          `json:"event"`    // Event is the name of the event triggering the workflows. Defaults to workflow_dispatch.
+	Payload  json.RawMessage `json:"payload"`  // Payload is the event payload. If empty, a default payload is generated.
+}
+
+// RunArtifact represents an artifact uploaded by a workflow run of a trigger.
+type RunArtifact struct {
+	RunID string `json:"run_id"`
+	Name  string `json:"name"`
+}
+
+// authorize wraps the API handlers to require the API token as a bearer token. API is disabled if the token is not
+// configured, since it could trigger runs with arbitrary payloads and expose the logs and the artifacts of the runs.
+func (h *handler) authorize(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if h.apiToken == "" {
+			http.Error(w, "api is disabled, API_TOKEN is not configured", http.StatusForbidden)
+			return
+		}
+
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.apiToken)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r, ps)
+	}
+}
+
+func (h *handler) HandleCreateRun(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req RunRequest
+
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxPayloadSize)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid run request: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if owner, name, ok := strings.Cut(req.Repo, "/"); !ok || owner == "" || name == "" {
+		http.Error(w, "invalid repo, expected owner/name format", http.StatusBadRequest)
+		return
+	}
+
+	if req.Event == "" {
+		req.Event = "workflow_dispatch"
+	}
+
+	// values are passed to the dagger cli as arguments, so they shouldn't look like flags
+	for _, value := range []string{req.Repo, req.Ref, req.Commit, req.Workflow, req.Event} {
+		if strings.HasPrefix(value, "-") {
+			http.Error(w, fmt.Sprintf("invalid value %q", value), http.StatusBadRequest)
+			return
+		}
+	}
+
+	trigger := Trigger{
+		ID:       fmt.Sprintf("api-%d", time.Now().UnixNano()),
+		Event:    req.Event,
+		Repo:     req.Repo,
+		Ref:      req.Ref,
+		Commit:   req.Commit,
+		Workflow: req.Workflow,
+	}
+
+	if len(req.Payload) > 0 && string(req.Payload) != "null" {
+		trigger.EventFile = filepath.Join(h.eventsDir, fmt.Sprintf("%s.json", trigger.ID))
+
+		if err := os.WriteFile(trigger.EventFile, req.Payload, 0600); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := h.queue.Push(trigger); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	status, _ := h.queue.Get(trigger.ID)
+
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+func (h *handler) HandleGetRun(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	status, ok := h.queue.Get(ps.ByName("id"))
+	if !ok {
+		http.Error(w, "run not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// HandleGetRunLogs streams the log entries of the run as newline delimited JSON. The response follows the log until
+// the run is finished or the client disconnects.
+func (h *handler) HandleGetRunLogs(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	if _, ok := h.queue.Get(id); !ok {
+		http.Error(w, "run not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+
+	var (
+		reader  *bufio.Reader
+		partial []byte
+	)
+
+	for {
+		// status is checked before reading, so the entries written before the run is finished are not missed
+		status, ok := h.queue.Get(id)
+		finished := !ok || status.Finished()
+
+		if reader == nil {
+			// log file is created when the run starts
+			file, err := os.Open(logFile(h.eventsDir, id))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return
+			}
+
+			if file != nil {
+				defer file.Close()
+
+				reader = bufio.NewReader(file)
+			}
+		}
+
+		for reader != nil {
+			chunk, err := reader.ReadBytes('\n')
+
+			partial = append(partial, chunk...)
+
+			if err != nil {
+				break
+			}
+
+			if _, err := w.Write(partial); err != nil {
+				return
+			}
+
+			partial = nil
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if finished {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(logsPollInterval):
+		}
+	}
+}
+
+func (h *handler) HandleGetRunArtifacts(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	status, ok := h.queue.Get(ps.ByName("id"))
+	if !ok {
+		http.Error(w, "run not found", http.StatusNotFound)
+		return
+	}
+
+	artifacts := make([]RunArtifact, 0)
+
+	for _, runID := range status.RunIDs {
+		names, err := h.runner.Artifacts(runID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for _, name := range names {
+			artifacts = append(artifacts, RunArtifact{RunID: runID, Name: name})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, artifacts)
+}
+
+// writeJSON writes the value as the JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	// headers are already written, so the encoding errors can't be reported to the client
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// fakeRunner is a runner reporting a successful workflow run for the triggers.
+type fakeRunner struct{}
+
+func (r *fakeRunner) Run(trigger Trigger, output io.Writer) ([]WorkflowResult, error) {
+	fmt.Fprintf(output, "running %s\ncompleted %s", trigger.Workflow, trigger.Workflow)
+	return []WorkflowResult{{Name: trigger.Workflow, RunID: "42", Conclusion: "success", Duration: "1s"}}, nil
+}
+
+func (r *fakeRunner) Schedules(_, _ string) ([]Schedule, error) {
+	return nil, nil
+}
+
+func (r *fakeRunner) Artifacts(runID string) ([]string, error) {
+	return []string{"dist-" + runID}, nil
+}
+
+func TestRunsAPI(t *testing.T) {
+	dir := t.TempDir()
+
+	queue, err := NewQueue(new(fakeRunner), dir, 1, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	h := &handler{apiToken: "token", eventsDir: dir, runner: new(fakeRunner), queue: queue}
+
+	router := httprouter.New()
+	router.POST("/runs", h.authorize(h.HandleCreateRun))
+	router.GET("/runs/:id", h.authorize(h.HandleGetRun))
+	router.GET("/runs/:id/logs", h.authorize(h.HandleGetRunLogs))
+	router.GET("/runs/:id/artifacts", h.authorize(h.HandleGetRunArtifacts))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	// api is disabled without a token
+	disabled := &handler{eventsDir: dir, runner: new(fakeRunner), queue: queue}
+
+	rr := httptest.NewRecorder()
+	disabled.authorize(disabled.HandleGetRun)(rr, httptest.NewRequest(http.MethodGet, "/runs/1", nil), nil)
+
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d without a token, got %d", http.StatusForbidden, rr.Code)
+	}
+
+	// unauthorized requests are rejected
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/runs/1", nil))
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+	}
+
+	if rr := do(http.MethodPost, "/runs", `{"repo":"gale"}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for invalid repo, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	rr = do(http.MethodPost, "/runs", `{"repo":"aweris/gale","workflow":"CI","payload":{"inputs":{"a":"b"}}}`)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, rr.Code, rr.Body.String())
+	}
+
+	var created TriggerStatus
+
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if created.Event != "workflow_dispatch" || created.Status != StatusPending {
+		t.Fatalf("unexpected run %+v", created)
+	}
+
+	data, err := os.ReadFile(queue.Status().Pending[0].trigger.EventFile)
+	if err != nil || string(data) != `{"inputs":{"a":"b"}}` {
+		t.Fatalf("expected event file with the payload, got %q, %v", string(data), err)
+	}
+
+	queue.Start()
+
+	// logs are streamed until the run is finished
+	rr = do(http.MethodGet, "/runs/"+created.ID+"/logs", "")
+
+	var messages []string
+
+	scanner := bufio.NewScanner(rr.Body)
+	for scanner.Scan() {
+		var entry LogEntry
+
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		messages = append(messages, entry.Message)
+	}
+
+	if len(messages) != 2 || messages[0] != "running CI" {
+		t.Fatalf("unexpected log messages %q", messages)
+	}
+
+	var run TriggerStatus
+
+	if err := json.Unmarshal(do(http.MethodGet, "/runs/"+created.ID, "").Body.Bytes(), &run); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if run.Status != StatusSucceeded || len(run.RunIDs) != 1 || run.RunIDs[0] != "42" {
+		t.Fatalf("unexpected run %+v", run)
+	}
+
+	if len(run.Workflows) != 1 || run.Workflows[0] != (WorkflowResult{Name: "CI", RunID: "42", Conclusion: "success", Duration: "1s"}) {
+		t.Fatalf("unexpected workflow results %+v", run.Workflows)
+	}
+
+	var artifacts []RunArtifact
+
+	if err := json.Unmarshal(do(http.MethodGet, "/runs/"+created.ID+"/artifacts", "").Body.Bytes(), &artifacts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(artifacts) != 1 || artifacts[0] != (RunArtifact{RunID: "42", Name: "dist-42"}) {
+		t.Fatalf("unexpected artifacts %+v", artifacts)
+	}
+
+	if rr := do(http.MethodGet, "/runs/unknown", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
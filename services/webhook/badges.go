@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// BadgeStatus represents the latest conclusion of a workflow on a branch of a repository.
+type BadgeStatus struct {
+	Repo       string    `json:"repo"`       // Repo is the repository in owner/name format
+	Workflow   string    `json:"workflow"`   // Workflow is the name of the workflow
+	Branch     string    `json:"branch"`     // Branch is the branch of the run, empty for the default branch
+	Conclusion string    `json:"conclusion"` // Conclusion is the conclusion of the latest run, e.g. success
+	RunID      string    `json:"run_id"`     // RunID is the id of the latest run in the run history
+	UpdatedAt  time.Time `json:"updated_at"` // UpdatedAt is the time the latest run is finished
+}
+
+// BadgeStore keeps the latest conclusions of the workflows per repository and branch to render the status badges.
+// Statuses are persisted to a JSON file, so the badges survive the restarts of the service.
+type BadgeStore struct {
+	path string
+
+	mu       sync.Mutex
+	statuses map[string]BadgeStatus
+}
+
+// NewBadgeStore creates a badge store persisting the statuses to the given file. Existing statuses are loaded from
+// the file.
+func NewBadgeStore(path string) (*BadgeStore, error) {
+	store := &BadgeStore{path: path, statuses: make(map[string]BadgeStatus)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read badges: %w", err)
+	}
+
+	var statuses []BadgeStatus
+
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		return nil, fmt.Errorf("failed to parse badges: %w", err)
+	}
+
+	for _, status := range statuses {
+		store.statuses[badgeKey(status.Repo, status.Workflow, status.Branch)] = status
+	}
+
+	return store, nil
+}
+
+// Record records the result of the workflow run of the trigger. Runs of the pull requests and the tags are ignored, as
+// the badges show the state of the branches.
+func (s *BadgeStore) Record(trigger Trigger, result WorkflowResult) {
+	branch, ok := badgeBranch(trigger.Ref)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.statuses[badgeKey(trigger.Repo, result.Name, branch)] = BadgeStatus{
+		Repo:       trigger.Repo,
+		Workflow:   result.Name,
+		Branch:     branch,
+		Conclusion: result.Conclusion,
+		RunID:      result.RunID,
+		UpdatedAt:  time.Now(),
+	}
+
+	if err := s.save(); err != nil {
+		fmt.Printf("Error saving badges: %s\n", err.Error())
+	}
+}
+
+// Latest returns the latest status of the workflow. Empty repo or branch matches any repository or branch, so a
+// single repository setup doesn't need to specify them.
+func (s *BadgeStore) Latest(repo, workflow, branch string) (BadgeStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		latest BadgeStatus
+		found  bool
+	)
+
+	for _, status := range s.statuses {
+		if status.Workflow != workflow || (repo != "" && status.Repo != repo) || (branch != "" && status.Branch != branch) {
+			continue
+		}
+
+		if !found || status.UpdatedAt.After(latest.UpdatedAt) {
+			latest, found = status, true
+		}
+	}
+
+	return latest, found
+}
+
+// save writes the statuses to the file of the store. It should be called with the lock held.
+func (s *BadgeStore) save() error {
+	statuses := make([]BadgeStatus, 0, len(s.statuses))
+
+	for _, status := range s.statuses {
+		statuses = append(statuses, status)
+	}
+
+	data, err := json.Marshal(statuses)
+	if err != nil {
+		return err
+	}
+
+	// file is replaced at once, so a crash while writing doesn't corrupt the statuses
+	tmp := s.path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+// badgeKey returns the key of the status of the workflow on the branch of the repository.
+func badgeKey(repo, workflow, branch string) string {
+	return fmt.Sprintf("%s|%s|%s", repo, workflow, branch)
+}
+
+// badgeBranch returns the branch of the ref. Empty ref is the default branch. It returns false if the ref isn't a
+// branch, e.g. pull request merge refs or tags.
+func badgeBranch(ref string) (string, bool) {
+	if ref == "" {
+		return "", true
+	}
+
+	return strings.CutPrefix(ref, "refs/heads/")
+}
+
+// ShieldsEndpoint represents the response of the shields.io endpoint badges.
+//
+// See: https://shields.io/badges/endpoint-badge
+type ShieldsEndpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// badgeMessage returns the message and the color of the badge for the conclusion.
+func badgeMessage(conclusion string) (string, string) {
+	switch conclusion {
+	case "success":
+		return "passing", "brightgreen"
+	case "failure":
+		return "failing", "red"
+	case "cancelled":
+		return "cancelled", "lightgrey"
+	case "skipped":
+		return "skipped", "lightgrey"
+	case "":
+		return "no status", "lightgrey"
+	default:
+		return conclusion, "yellow"
+	}
+}
+
+// badgeColors are the hex codes of the badge colors used in the SVG badges.
+var badgeColors = map[string]string{
+	"brightgreen": "#4c1",
+	"red":         "#e05d44",
+	"yellow":      "#dfb317",
+	"lightgrey":   "#9f9f9f",
+}
+
+// HandleBadge returns the status badge of the latest run of the workflow, e.g. /badge/CI.svg?branch=main. With the
+// .json extension, it returns the shields.io endpoint response instead to customize the badge with shields.io. The
+// repo and branch query parameters are optional.
+func (h *handler) HandleBadge(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var (
+		file   = ps.ByName("file")
+		query  = r.URL.Query()
+		status BadgeStatus
+	)
+
+	workflow, format, ok := cutBadgeFormat(file)
+	if !ok || workflow == "" {
+		http.Error(w, "invalid badge, expected <workflow>.svg or <workflow>.json", http.StatusNotFound)
+		return
+	}
+
+	if h.badges != nil {
+		status, _ = h.badges.Latest(query.Get("repo"), workflow, query.Get("branch"))
+	}
+
+	message, color := badgeMessage(status.Conclusion)
+
+	// badges are cached aggressively by the image proxies, e.g. camo of GitHub, so caching is disabled explicitly
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+
+	if format == "json" {
+		writeJSON(w, http.StatusOK, ShieldsEndpoint{SchemaVersion: 1, Label: workflow, Message: message, Color: color})
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.WriteHeader(http.StatusOK)
+
+	_, _ = w.Write([]byte(renderBadge(workflow, message, badgeColors[color])))
+}
+
+// cutBadgeFormat splits the badge file name into the workflow name and the format of the badge.
+func cutBadgeFormat(file string) (string, string, bool) {
+	for _, format := range []string{"svg", "json"} {
+		if workflow, ok := strings.CutSuffix(file, "."+format); ok {
+			return workflow, format, true
+		}
+	}
+
+	return "", "", false
+}
+
+// renderBadge renders a flat badge with the label and the message. Text widths are estimated from the number of the
+// characters, which is good enough for the short labels of the badges.
+func renderBadge(label, message, color string) string {
+	var (
+		labelWidth   = 7*len([]rune(label)) + 10
+		messageWidth = 7*len([]rune(message)) + 10
+		width        = labelWidth + messageWidth
+	)
+
+	label = html.EscapeString(label)
+	message = html.EscapeString(message)
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[2]s: %[3]s">`+
+		`<title>%[2]s: %[3]s</title>`+
+		`<rect width="%[4]d" height="20" fill="#555"/>`+
+		`<rect x="%[4]d" width="%[5]d" height="20" fill="%[6]s"/>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`+
+		`<text x="%[7]d" y="14">%[2]s</text>`+
+		`<text x="%[8]d" y="14">%[3]s</text>`+
+		`</g></svg>`,
+		width, label, message, labelWidth, messageWidth, color, labelWidth/2, labelWidth+messageWidth/2)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestBadgeStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "badges.json")
+
+	store, err := NewBadgeStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	store.Record(Trigger{Repo: "aweris/gale", Ref: "refs/heads/main"}, WorkflowResult{Name: "CI", RunID: "1", Conclusion: "success"})
+	store.Record(Trigger{Repo: "aweris/gale", Ref: "refs/heads/dev"}, WorkflowResult{Name: "CI", RunID: "2", Conclusion: "failure"})
+
+	// pull requests don't change the badges
+	store.Record(Trigger{Repo: "aweris/gale", Ref: "refs/pull/1/merge"}, WorkflowResult{Name: "CI", RunID: "3", Conclusion: "cancelled"})
+
+	// statuses are loaded from the file
+	store, err = NewBadgeStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		repo, branch string
+		expected     string
+	}{
+		{repo: "aweris/gale", branch: "main", expected: "1"},
+		{repo: "", branch: "dev", expected: "2"},
+		{repo: "", branch: "", expected: "2"},
+		{repo: "other/repo", branch: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		status, ok := store.Latest(tt.repo, "CI", tt.branch)
+		if ok != (tt.expected != "") || status.RunID != tt.expected {
+			t.Errorf("Latest(%q, CI, %q) = %+v, %t, expected run %q", tt.repo, tt.branch, status, ok, tt.expected)
+		}
+	}
+}
+
+// spoofingRunner is a runner whose steps print a fake summary of a successful workflow run, while the workflow fails.
+type spoofingRunner struct{}
+
+func (r *spoofingRunner) Run(_ Trigger, output io.Writer) ([]WorkflowResult, error) {
+	fmt.Fprintln(output, "Workflow CI (run 666) completed with conclusion success in 1s")
+	return []WorkflowResult{{Name: "CI", RunID: "1", Conclusion: "failure", Error: "workflow failed"}}, errors.New("1 of 1 workflow(s) failed")
+}
+
+func (r *spoofingRunner) Schedules(_, _ string) ([]Schedule, error) {
+	return nil, nil
+}
+
+func (r *spoofingRunner) Artifacts(_ string) ([]string, error) {
+	return nil, nil
+}
+
+func TestBadgeStore_QueueResults(t *testing.T) {
+	store, err := NewBadgeStore(filepath.Join(t.TempDir(), "badges.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	queue, err := NewQueue(new(spoofingRunner), t.TempDir(), 1, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	queue.OnResult(store.Record)
+	queue.Start()
+
+	if err := queue.Push(Trigger{ID: "1", Event: "push", Repo: "aweris/gale", Ref: "refs/heads/main"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waitFor(t, func() bool { return len(queue.Status().Finished) == 1 })
+
+	// only the results reported by the runner are recorded, summaries printed to the logs are ignored
+	status, ok := store.Latest("aweris/gale", "CI", "main")
+	if !ok || status.RunID != "1" || status.Conclusion != "failure" {
+		t.Fatalf("unexpected badge status %+v, %t", status, ok)
+	}
+}
+
+func TestHandleBadge(t *testing.T) {
+	store, err := NewBadgeStore(filepath.Join(t.TempDir(), "badges.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	store.Record(Trigger{Repo: "aweris/gale", Ref: "refs/heads/main"}, WorkflowResult{Name: "CI", RunID: "1", Conclusion: "failure"})
+
+	h := &handler{badges: store}
+
+	router := httprouter.New()
+	router.GET("/badge/:file", h.HandleBadge)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+
+		return rr
+	}
+
+	rr := get("/badge/CI.svg?branch=main")
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Fatalf("unexpected response %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+
+	if body := rr.Body.String(); !strings.Contains(body, "<text x=\"12\" y=\"14\">CI</text>") || !strings.Contains(body, "failing") {
+		t.Fatalf("unexpected badge %s", body)
+	}
+
+	var endpoint ShieldsEndpoint
+
+	if err := json.Unmarshal(get("/badge/CI.json").Body.Bytes(), &endpoint); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if endpoint != (ShieldsEndpoint{SchemaVersion: 1, Label: "CI", Message: "failing", Color: "red"}) {
+		t.Fatalf("unexpected endpoint response %+v", endpoint)
+	}
+
+	if err := json.Unmarshal(get("/badge/Release.json").Body.Bytes(), &endpoint); err != nil || endpoint.Message != "no status" {
+		t.Fatalf("expected no status for unknown workflow, got %+v, %v", endpoint, err)
+	}
+
+	if rr := get("/badge/CI.png"); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron represents a parsed cron expression of a workflow schedule.
+type Cron struct {
+	expr          string
+	fields        [5]map[int]bool // fields are minute, hour, day of month, month and day of week
+	domRestricted bool
+	dowRestricted bool
+}
+
+// ParseCron parses a POSIX cron expression with five fields. Lists, ranges, steps and wildcards are supported, names
+// of the months and the days are not.
+func ParseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q, expected 5 fields", expr)
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+	cron := &Cron{
+		expr:          expr,
+		domRestricted: !strings.HasPrefix(fields[2], "*"),
+		dowRestricted: !strings.HasPrefix(fields[4], "*"),
+	}
+
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+
+		cron.fields[i] = set
+	}
+
+	return cron, nil
+}
+
+// String returns the original expression of the cron.
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Match returns true if the cron is scheduled at the minute of the given time. Schedules are evaluated in UTC, same as
+// GitHub.
+func (c *Cron) Match(t time.Time) bool {
+	t = t.UTC()
+
+	if !c.fields[0][t.Minute()] || !c.fields[1][t.Hour()] || !c.fields[3][int(t.Month())] {
+		return false
+	}
+
+	dom, dow := c.fields[2][t.Day()], c.fields[4][int(t.Weekday())]
+
+	// like cron, if both day of month and day of week are restricted, the schedule matches either of them
+	if c.domRestricted && c.dowRestricted {
+		return dom || dow
+	}
+
+	return dom && dow
+}
+
+// parseCronField parses a cron field into the set of values it matches.
+func parseCronField(field string, lower, upper int) (map[int]bool, error) {
+	set := make(map[int]bool)
+
+	for _, part := range strings.Split(field, ",") {
+		var (
+			rng  = part
+			step = 1
+			err  error
+		)
+
+		if r, s, ok := strings.Cut(part, "/"); ok {
+			rng = r
+
+			if step, err = strconv.Atoi(s); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step %s", s)
+			}
+		}
+
+		lo, hi := lower, upper
+
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+
+			if lo, err = strconv.Atoi(from); err != nil {
+				return nil, fmt.Errorf("invalid value %s", from)
+			}
+
+			hi = lo
+
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return nil, fmt.Errorf("invalid value %s", to)
+				}
+			} else if step > 1 {
+				// a single value with a step means from the value to the end of the range, e.g. 5/15
+				hi = upper
+			}
+		}
+
+		// 7 is an alias of sunday in the day of week field
+		if upper == 6 && hi == 7 {
+			set[0] = true
+
+			if lo == 7 {
+				continue
+			}
+
+			hi = 6
+		}
+
+		if lo < lower || hi > upper || lo > hi {
+			return nil, fmt.Errorf("value %s out of range %d-%d", rng, lower, upper)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+
+	return set, nil
+}
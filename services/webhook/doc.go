@@ -0,0 +1,3 @@
+// Webhook service receives the GitHub webhooks and runs the workflows triggered by the push and pull request events
+// with gale. It's a minimal self-hosted CI server for running the workflows without GitHub Actions.
+package main
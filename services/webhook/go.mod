@@ -0,0 +1,8 @@
+module github.com/aweris/gale/services/webhook
+
+go 1.21
+
+require (
+	github.com/caarlos0/env/v9 v9.0.0
+	github.com/julienschmidt/httprouter v1.3.0
+)
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LogEntry represents a line of the output of a run in the run logs. Run logs are stored as newline delimited JSON.
+type LogEntry struct {
+	ID      int       `json:"id"`      // ID is the sequence number of the line in the run output
+	Time    time.Time `json:"time"`    // Time is the time the line is written
+	Message string    `json:"message"` // Message is the line without the trailing newline
+}
+
+// logFile returns the path of the log file of the trigger with the given id.
+func logFile(dir, id string) string {
+	return filepath.Join(dir, fmt.Sprintf("%s.ndjson", id))
+}
+
+var _ io.WriteCloser = new(runLog)
+
+// runLog writes the output of a run into the run log line by line. The output is only stored, results of the workflow
+// runs are reported by the runner, since the steps could print anything to the output.
+type runLog struct {
+	mu    sync.Mutex
+	file  *os.File
+	buf   []byte
+	count int
+}
+
+// newRunLog creates the log file of the trigger with the given id in the directory.
+func newRunLog(dir, id string) (*runLog, error) {
+	file, err := os.OpenFile(logFile(dir, id), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create run log: %w", err)
+	}
+
+	return &runLog{file: file}, nil
+}
+
+func (l *runLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.buf = append(l.buf, p...)
+
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		if err := l.writeLine(string(l.buf[:i])); err != nil {
+			return 0, err
+		}
+
+		l.buf = l.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Close writes the remaining partial line and closes the log file.
+func (l *runLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.buf) > 0 {
+		if err := l.writeLine(string(l.buf)); err != nil {
+			return err
+		}
+
+		l.buf = nil
+	}
+
+	return l.file.Close()
+}
+
+// writeLine writes the line as a log entry. It should be called with the lock held.
+func (l *runLog) writeLine(line string) error {
+	l.count++
+
+	// entry only contains basic types, so it can't fail to marshal
+	data, _ := json.Marshal(LogEntry{ID: l.count, Time: time.Now(), Message: line})
+
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write run log: %w", err)
+	}
+
+	return nil
+}
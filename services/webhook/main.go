@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/caarlos0/env/v9"
+)
+
+type ServiceConfig struct {
+	Port      string `env:"PORT" envDefault:"8080"`
+	Secret    string `env:"WEBHOOK_SECRET"`
+	EventsDir string `env:"EVENTS_DIR" envDefault:"/events"`
+	Module    string `env:"GALE_MODULE" envDefault:"github.com/jpadams/gale/daggerverse/gale@main"`
+	Token     string `env:"GITHUB_TOKEN"`
+	APIToken  string `env:"API_TOKEN"`
+
+	Workers         int `env:"WORKERS" envDefault:"1"`
+	RepoConcurrency int `env:"REPO_CONCURRENCY" envDefault:"0"`
+	QueueSize       int `env:"QUEUE_SIZE" envDefault:"100"`
+
+	JournalSinks []string `env:"JOURNAL_SINKS" envSeparator:";"`
+
+	ScheduleRepos   []string      `env:"SCHEDULE_REPOS"`
+	ScheduleCatchUp string        `env:"SCHEDULE_CATCH_UP" envDefault:"skip"`
+	ScheduleJitter  time.Duration `env:"SCHEDULE_JITTER" envDefault:"0s"`
+	ScheduleRefresh time.Duration `env:"SCHEDULE_REFRESH" envDefault:"1h"`
+}
+
+func main() {
+	var config ServiceConfig
+
+	if err := env.Parse(&config); err != nil {
+		fmt.Printf("Error parsing environment variables: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	if config.Secret == "" {
+		fmt.Println("WEBHOOK_SECRET is not set, webhooks are rejected")
+	}
+
+	if config.APIToken == "" {
+		fmt.Println("API_TOKEN is not set, /runs api is disabled")
+	}
+
+	runner := NewDaggerRunner(config.Module, config.Token != "", config.JournalSinks)
+
+	queue, err := NewQueue(runner, config.EventsDir, config.Workers, config.RepoConcurrency, config.QueueSize)
+	if err != nil {
+		fmt.Printf("Error creating queue: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	var scheduler *Scheduler
+
+	if len(config.ScheduleRepos) > 0 {
+		s, err := NewScheduler(config.ScheduleRepos, config.EventsDir, config.ScheduleCatchUp, config.ScheduleJitter, config.ScheduleRefresh, runner)
+		if err != nil {
+			fmt.Printf("Error creating scheduler: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+		scheduler = s
+	}
+
+	if err := Serve(config, runner, queue, scheduler); err != nil {
+		fmt.Printf("Error starting webhook service: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
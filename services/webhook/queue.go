@@ -0,0 +1,334 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxFinished is the number of the finished runs kept in the queue status.
+const maxFinished = 100
+
+// ErrQueueFull is returned when the queue has no capacity for a new trigger.
+var ErrQueueFull = errors.New("too many pending events")
+
+// Statuses of the triggers in the queue.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// TriggerStatus represents the status of a trigger in the queue.
+type TriggerStatus struct {
+	ID         string           `json:"id"`
+	Event      string           `json:"event"`
+	Repo       string           `json:"repo"`
+	Ref        string           `json:"ref,omitempty"`
+	Commit     string           `json:"commit,omitempty"`
+	Workflow   string           `json:"workflow,omitempty"`
+	Status     string           `json:"status"`
+	Error      string           `json:"error,omitempty"`
+	RunIDs     []string         `json:"run_ids,omitempty"`   // RunIDs are the ids of the workflow runs executed for the trigger.
+	Workflows  []WorkflowResult `json:"workflows,omitempty"` // Workflows are the results of the workflow runs executed for the trigger.
+	QueuedAt   time.Time        `json:"queued_at"`
+	StartedAt  *time.Time       `json:"started_at,omitempty"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+
+	trigger Trigger
+}
+
+// snapshot returns a copy of the status safe to use without the lock of the queue.
+func (s *TriggerStatus) snapshot() TriggerStatus {
+	copied := *s
+	copied.RunIDs = append([]string(nil), s.RunIDs...)
+	copied.Workflows = append([]WorkflowResult(nil), s.Workflows...)
+
+	return copied
+}
+
+// Finished returns true if the run of the trigger is completed.
+func (s *TriggerStatus) Finished() bool {
+	return s.Status == StatusSucceeded || s.Status == StatusFailed
+}
+
+// QueueStatus represents the state of the queue.
+type QueueStatus struct {
+	Workers         int             `json:"workers"`
+	RepoConcurrency int             `json:"repo_concurrency"`
+	Pending         []TriggerStatus `json:"pending"`
+	Running         []TriggerStatus `json:"running"`
+	Finished        []TriggerStatus `json:"finished"` // Finished is the latest finished runs, latest first.
+}
+
+// Queue runs the triggers with a pool of workers in the order they are received. Runs of the same repository are
+// limited with the repository concurrency, and the runs of the same workflow and ref don't run concurrently. A pending
+// trigger is replaced with the newer trigger of the same workflow and ref, since only the latest state is worth running.
+type Queue struct {
+	runner          Runner
+	logsDir         string
+	workers         int
+	repoConcurrency int // repoConcurrency is the maximum number of concurrent runs of a repository. Zero means unlimited.
+	size            int
+	onResult        []func(trigger Trigger, result WorkflowResult)
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	pending  []*TriggerStatus
+	running  []*TriggerStatus
+	finished []*TriggerStatus
+}
+
+// NewQueue creates a new queue running the triggers with the given runner. Output of the runs is kept in the logs
+// directory.
+func NewQueue(runner Runner, logsDir string, workers, repoConcurrency, size int) (*Queue, error) {
+	if workers < 1 {
+		return nil, fmt.Errorf("invalid number of workers %d, expected at least 1", workers)
+	}
+
+	if repoConcurrency < 0 {
+		return nil, fmt.Errorf("invalid repository concurrency %d, expected a positive number or zero for unlimited", repoConcurrency)
+	}
+
+	if size < 1 {
+		return nil, fmt.Errorf("invalid queue size %d, expected at least 1", size)
+	}
+
+	q := &Queue{runner: runner, logsDir: logsDir, workers: workers, repoConcurrency: repoConcurrency, size: size}
+	q.cond = sync.NewCond(&q.mu)
+
+	return q, nil
+}
+
+// OnResult registers the function to call with the result of each workflow run of the triggers. Functions are called
+// from the workers, so they should be safe for concurrent use. It should be called before the queue is started.
+func (q *Queue) OnResult(fn func(trigger Trigger, result WorkflowResult)) {
+	q.onResult = append(q.onResult, fn)
+}
+
+// Start starts the workers of the queue.
+func (q *Queue) Start() {
+	for i := 0; i < q.workers; i++ {
+		go q.work()
+	}
+}
+
+// Push adds the trigger to the queue. If a pending trigger has the same workflow and ref, it's replaced with the
+// trigger. It returns ErrQueueFull if the queue has no capacity.
+func (q *Queue) Push(trigger Trigger) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	status := &TriggerStatus{
+		ID:       trigger.ID,
+		Event:    trigger.Event,
+		Repo:     trigger.Repo,
+		Ref:      trigger.Ref,
+		Commit:   trigger.Commit,
+		Workflow: trigger.Workflow,
+		Status:   StatusPending,
+		QueuedAt: time.Now(),
+		trigger:  trigger,
+	}
+
+	for i, pending := range q.pending {
+		if dedupKey(pending.trigger) != dedupKey(trigger) {
+			continue
+		}
+
+		fmt.Printf("Replacing pending %s event %s of %s with %s\n", pending.Event, pending.ID, pending.Repo, trigger.ID)
+
+		// payload of the replaced trigger isn't needed anymore
+		_ = os.Remove(pending.trigger.EventFile)
+
+		q.pending[i] = status
+		q.cond.Broadcast()
+
+		return nil
+	}
+
+	if len(q.pending) >= q.size {
+		return ErrQueueFull
+	}
+
+	q.pending = append(q.pending, status)
+	q.cond.Broadcast()
+
+	return nil
+}
+
+// Status returns the current state of the queue.
+func (q *Queue) Status() QueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	copyAll := func(statuses []*TriggerStatus) []TriggerStatus {
+		result := make([]TriggerStatus, 0, len(statuses))
+
+		for _, status := range statuses {
+			result = append(result, status.snapshot())
+		}
+
+		return result
+	}
+
+	return QueueStatus{
+		Workers:         q.workers,
+		RepoConcurrency: q.repoConcurrency,
+		Pending:         copyAll(q.pending),
+		Running:         copyAll(q.running),
+		Finished:        copyAll(q.finished),
+	}
+}
+
+// Get returns the status of the trigger with the given id. Finished triggers are only kept for the latest runs.
+func (q *Queue) Get(id string) (TriggerStatus, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, statuses := range [][]*TriggerStatus{q.pending, q.running, q.finished} {
+		for _, status := range statuses {
+			if status.ID == id {
+				return status.snapshot(), true
+			}
+		}
+	}
+
+	return TriggerStatus{}, false
+}
+
+// work runs the triggers of the queue one by one until the process exits.
+func (q *Queue) work() {
+	for {
+		status := q.next()
+
+		fmt.Printf("Running workflows for %s event %s of %s\n", status.Event, status.ID, status.Repo)
+
+		err := q.run(status)
+		if err != nil {
+			fmt.Printf("Workflows of %s event %s failed: %s\n", status.Event, status.ID, err.Error())
+		}
+
+		q.done(status, err)
+	}
+}
+
+// run runs the trigger and writes the output of the run to the run log.
+func (q *Queue) run(status *TriggerStatus) error {
+	output, err := newRunLog(q.logsDir, status.ID)
+	if err != nil {
+		return err
+	}
+
+	defer output.Close()
+
+	results, err := q.runner.Run(status.trigger, output)
+
+	for _, result := range results {
+		q.mu.Lock()
+		status.RunIDs = append(status.RunIDs, result.RunID)
+		status.Workflows = append(status.Workflows, result)
+		q.mu.Unlock()
+
+		for _, fn := range q.onResult {
+			fn(status.trigger, result)
+		}
+	}
+
+	return err
+}
+
+// next removes the first runnable trigger from the pending triggers and marks it as running. It blocks until a trigger
+// is runnable.
+func (q *Queue) next() *TriggerStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if i := q.runnable(); i >= 0 {
+			status := q.pending[i]
+
+			now := time.Now()
+
+			status.Status = StatusRunning
+			status.StartedAt = &now
+
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			q.running = append(q.running, status)
+
+			return status
+		}
+
+		q.cond.Wait()
+	}
+}
+
+// runnable returns the index of the first pending trigger that doesn't exceed the repository concurrency and doesn't
+// have a running trigger with the same workflow and ref. It returns -1 if there is no runnable trigger. It should be
+// called with the lock held.
+func (q *Queue) runnable() int {
+	for i, pending := range q.pending {
+		var (
+			repoRunning = 0
+			conflict    = false
+		)
+
+		for _, running := range q.running {
+			if running.Repo == pending.Repo {
+				repoRunning++
+			}
+
+			if dedupKey(running.trigger) == dedupKey(pending.trigger) {
+				conflict = true
+			}
+		}
+
+		if conflict || (q.repoConcurrency > 0 && repoRunning >= q.repoConcurrency) {
+			continue
+		}
+
+		return i
+	}
+
+	return -1
+}
+
+// done marks the running trigger as finished with the result of the run.
+func (q *Queue) done(status *TriggerStatus, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+
+	status.FinishedAt = &now
+	status.Status = StatusSucceeded
+
+	if err != nil {
+		status.Status = StatusFailed
+		status.Error = err.Error()
+	}
+
+	for i, running := range q.running {
+		if running == status {
+			q.running = append(q.running[:i], q.running[i+1:]...)
+			break
+		}
+	}
+
+	q.finished = append([]*TriggerStatus{status}, q.finished...)
+
+	if len(q.finished) > maxFinished {
+		q.finished = q.finished[:maxFinished]
+	}
+
+	// finished run could unblock the pending triggers of the same repository or the same workflow and ref
+	q.cond.Broadcast()
+}
+
+// dedupKey returns the key identifying the runs of the same workflow and ref of a repository.
+func dedupKey(trigger Trigger) string {
+	return fmt.Sprintf("%s|%s|%s", trigger.Repo, trigger.Workflow, trigger.Ref)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingRunner is a runner blocking the runs until they are released.
+type blockingRunner struct {
+	mu      sync.Mutex
+	started []string
+	release chan struct{}
+}
+
+func (r *blockingRunner) Run(trigger Trigger, _ io.Writer) ([]WorkflowResult, error) {
+	r.mu.Lock()
+	r.started = append(r.started, trigger.ID)
+	r.mu.Unlock()
+
+	<-r.release
+
+	if trigger.Event == "fail" {
+		return nil, errors.New("failed")
+	}
+
+	return nil, nil
+}
+
+func (r *blockingRunner) Schedules(_, _ string) ([]Schedule, error) {
+	return nil, nil
+}
+
+func (r *blockingRunner) Artifacts(_ string) ([]string, error) {
+	return nil, nil
+}
+
+func (r *blockingRunner) Started() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.started...)
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(time.Second); !condition(); {
+		if time.Now().After(deadline) {
+			t.Fatal("condition is not met in time")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestQueueDeduplication(t *testing.T) {
+	queue, err := NewQueue(nil, t.TempDir(), 1, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, trigger := range []Trigger{
+		{ID: "1", Repo: "aweris/gale", Ref: "refs/heads/main"},
+		{ID: "2", Repo: "aweris/gale", Ref: "refs/heads/feature"},
+		{ID: "3", Repo: "aweris/gale", Ref: "refs/heads/main"},
+	} {
+		if err := queue.Push(trigger); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	pending := queue.Status().Pending
+
+	if len(pending) != 2 || pending[0].ID != "3" || pending[1].ID != "2" {
+		t.Errorf("unexpected pending triggers %+v", pending)
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	queue, err := NewQueue(nil, t.TempDir(), 1, 0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := queue.Push(Trigger{ID: "1", Repo: "aweris/gale", Ref: "refs/heads/main"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := queue.Push(Trigger{ID: "2", Repo: "aweris/gale", Ref: "refs/heads/feature"}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+}
+
+func TestQueueConcurrency(t *testing.T) {
+	runner := &blockingRunner{release: make(chan struct{})}
+
+	queue, err := NewQueue(runner, t.TempDir(), 3, 1, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	queue.Start()
+
+	for _, trigger := range []Trigger{
+		{ID: "1", Event: "fail", Repo: "aweris/gale", Ref: "refs/heads/main"},
+		{ID: "2", Event: "push", Repo: "aweris/gale", Ref: "refs/heads/feature"},
+		{ID: "3", Event: "push", Repo: "aweris/other", Ref: "refs/heads/main"},
+	} {
+		if err := queue.Push(trigger); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// second trigger of aweris/gale waits for the first one because of the repository concurrency
+	waitFor(t, func() bool { return len(runner.Started()) == 2 })
+
+	status := queue.Status()
+
+	if len(status.Running) != 2 || len(status.Pending) != 1 || status.Pending[0].ID != "2" {
+		t.Fatalf("unexpected queue status %+v", status)
+	}
+
+	close(runner.release)
+
+	waitFor(t, func() bool { return len(queue.Status().Finished) == 3 })
+
+	for _, finished := range queue.Status().Finished {
+		expected := StatusSucceeded
+		if finished.ID == "1" {
+			expected = StatusFailed
+		}
+
+		if finished.Status != expected {
+			t.Errorf("expected status %s for %s, got %s", expected, finished.ID, finished.Status)
+		}
+	}
+}
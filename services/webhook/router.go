@@ -0,0 +1,230 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// maxPayloadSize is the maximum size of the webhook payloads GitHub sends.
+const maxPayloadSize = 25 << 20
+
+// PushEvent represents the fields of the push event payload used to trigger the workflows.
+type PushEvent struct {
+	Ref        string     `json:"ref"`
+	After      string     `json:"after"`
+	Deleted    bool       `json:"deleted"`
+	Repository Repository `json:"repository"`
+}
+
+// PullRequestEvent represents the fields of the pull request event payload used to trigger the workflows.
+type PullRequestEvent struct {
+	Action      string      `json:"action"`
+	Number      int         `json:"number"`
+	PullRequest PullRequest `json:"pull_request"`
+	Repository  Repository  `json:"repository"`
+}
+
+// PullRequest represents the fields of the pull request in the pull request event payload.
+type PullRequest struct {
+	Head struct {
+		Repo *Repository `json:"repo"`
+	} `json:"head"`
+}
+
+// Repository represents the repository of the event payload.
+type Repository struct {
+	FullName string `json:"full_name"`
+}
+
+// pullRequestActions are the activity types of the pull request event triggering the workflows by default.
+var pullRequestActions = map[string]bool{"opened": true, "synchronize": true, "reopened": true}
+
+// Serve starts the webhook service router on the port of the config. Workflows of the received events and the runs
+// requested with the API are run with the queue. If the scheduler is provided, workflows triggered by the schedules
+// are run with the same queue. Latest conclusions of the workflows are kept for the status badges.
+func Serve(config ServiceConfig, runner Runner, queue *Queue, scheduler *Scheduler) error {
+	if err := os.MkdirAll(config.EventsDir, 0700); err != nil {
+		return fmt.Errorf("failed to create events directory: %w", err)
+	}
+
+	badges, err := NewBadgeStore(filepath.Join(config.EventsDir, "badges.json"))
+	if err != nil {
+		return err
+	}
+
+	queue.OnResult(badges.Record)
+	queue.Start()
+
+	if scheduler != nil {
+		go scheduler.Start(queue)
+	}
+
+	router := httprouter.New()
+
+	handler := &handler{secret: config.Secret, apiToken: config.APIToken, eventsDir: config.EventsDir, runner: runner, queue: queue, badges: badges}
+
+	router.POST("/webhook", handler.HandleWebhook)
+	router.GET("/healthz", handler.HandleHealthz)
+	router.GET("/status", handler.HandleStatus)
+	router.GET("/badge/:file", handler.HandleBadge)
+
+	// runs api is only served with a token
+	if config.APIToken != "" {
+		router.POST("/runs", handler.authorize(handler.HandleCreateRun))
+		router.GET("/runs/:id", handler.authorize(handler.HandleGetRun))
+		router.GET("/runs/:id/logs", handler.authorize(handler.HandleGetRunLogs))
+		router.GET("/runs/:id/artifacts", handler.authorize(handler.HandleGetRunArtifacts))
+	}
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%s", config.Port),
+		Handler:           router,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	return server.ListenAndServe()
+}
+
+type handler struct {
+	secret    string
+	apiToken  string
+	eventsDir string
+	runner    Runner
+	queue     *Queue
+	badges    *BadgeStore
+}
+
+func (h *handler) HandleWebhook(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// unsigned webhooks could trigger runs of any repository with the token of the service, so they're never accepted
+	if h.secret == "" {
+		http.Error(w, "webhooks are disabled, WEBHOOK_SECRET is not configured", http.StatusForbidden)
+		return
+	}
+
+	if !validSignature(h.secret, r.Header.Get("X-Hub-Signature-256"), payload) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var (
+		event = r.Header.Get("X-GitHub-Event")
+		id    = r.Header.Get("X-GitHub-Delivery")
+	)
+
+	if event == "ping" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// delivery id is used as the file name, so it shouldn't contain path separators
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		http.Error(w, "invalid delivery id", http.StatusBadRequest)
+		return
+	}
+
+	trigger, ok, err := parseTrigger(event, payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	trigger.ID = id
+	trigger.EventFile = filepath.Join(h.eventsDir, fmt.Sprintf("%s.json", id))
+
+	if err := os.WriteFile(trigger.EventFile, payload, 0600); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.queue.Push(trigger); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *handler) HandleHealthz(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *handler) HandleStatus(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	writeJSON(w, http.StatusOK, h.queue.Status())
+}
+
+// parseTrigger returns the workflow trigger of the event payload. It returns false if the event doesn't trigger any
+// workflows, e.g. unsupported events, deleted branches or closed pull requests.
+func parseTrigger(event string, payload []byte) (Trigger, bool, error) {
+	switch event {
+	case "push":
+		var push PushEvent
+
+		if err := json.Unmarshal(payload, &push); err != nil {
+			return Trigger{}, false, fmt.Errorf("invalid push event: %w", err)
+		}
+
+		if push.Deleted {
+			return Trigger{}, false, nil
+		}
+
+		return Trigger{Event: event, Repo: push.Repository.FullName, Ref: push.Ref, Commit: push.After}, true, nil
+	case "pull_request":
+		var pr PullRequestEvent
+
+		if err := json.Unmarshal(payload, &pr); err != nil {
+			return Trigger{}, false, fmt.Errorf("invalid pull request event: %w", err)
+		}
+
+		if !pullRequestActions[pr.Action] {
+			return Trigger{}, false, nil
+		}
+
+		// pull requests from forks run code of the fork author, head repo is null if the fork is deleted
+		fork := pr.PullRequest.Head.Repo == nil || pr.PullRequest.Head.Repo.FullName != pr.Repository.FullName
+
+		// like GitHub, pull request workflows run on the merge commit of the pull request
+		return Trigger{Event: event, Repo: pr.Repository.FullName, Ref: fmt.Sprintf("refs/pull/%d/merge", pr.Number), Untrusted: fork}, true, nil
+	default:
+		return Trigger{}, false, nil
+	}
+}
+
+// validSignature checks the HMAC SHA256 signature of the payload created with the webhook secret.
+func validSignature(secret, signature string, payload []byte) bool {
+	sig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+
+	expected, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHandleWebhook(t *testing.T) {
+	const (
+		secret  = "secret"
+		payload = `{"ref":"refs/heads/main","after":"abc123","repository":{"full_name":"aweris/gale"}}`
+	)
+
+	tests := []struct {
+		name      string
+		secret    string
+		event     string
+		signature string
+		payload   string
+		status    int
+		queued    bool
+	}{
+		{name: "push", secret: secret, event: "push", signature: sign(secret, payload), payload: payload, status: http.StatusAccepted, queued: true},
+		{name: "no secret configured", event: "push", signature: sign("", payload), payload: payload, status: http.StatusForbidden},
+		{name: "unsigned", secret: secret, event: "push", payload: payload, status: http.StatusUnauthorized},
+		{name: "invalid signature", secret: secret, event: "push", signature: sign("other", payload), payload: payload, status: http.StatusUnauthorized},
+		{name: "ping", secret: secret, event: "ping", signature: sign(secret, "{}"), payload: "{}", status: http.StatusOK},
+		{name: "unsupported event", secret: secret, event: "issues", signature: sign(secret, "{}"), payload: "{}", status: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue, err := NewQueue(nil, t.TempDir(), 1, 0, 1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			h := &handler{secret: tt.secret, eventsDir: t.TempDir(), queue: queue}
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tt.payload))
+			req.Header.Set("X-GitHub-Event", tt.event)
+			req.Header.Set("X-GitHub-Delivery", "delivery-1")
+			req.Header.Set("X-Hub-Signature-256", tt.signature)
+
+			rr := httptest.NewRecorder()
+
+			h.HandleWebhook(rr, req, nil)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rr.Code)
+			}
+
+			pending := queue.Status().Pending
+
+			if !tt.queued {
+				if len(pending) != 0 {
+					t.Fatal("expected no trigger to be queued")
+				}
+
+				return
+			}
+
+			if len(pending) != 1 {
+				t.Fatalf("expected a trigger to be queued, got %d", len(pending))
+			}
+
+			data, err := os.ReadFile(pending[0].trigger.EventFile)
+			if err != nil || string(data) != tt.payload {
+				t.Fatalf("expected event file with the payload, got %q, %v", string(data), err)
+			}
+		})
+	}
+}
+
+func TestParseTrigger(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		payload  string
+		expected Trigger
+		ok       bool
+	}{
+		{
+			name:     "push",
+			event:    "push",
+			payload:  `{"ref":"refs/heads/main","after":"abc123","repository":{"full_name":"aweris/gale"}}`,
+			expected: Trigger{Event: "push", Repo: "aweris/gale", Ref: "refs/heads/main", Commit: "abc123"},
+			ok:       true,
+		},
+		{
+			name:    "deleted branch",
+			event:   "push",
+			payload: `{"ref":"refs/heads/feature","deleted":true,"repository":{"full_name":"aweris/gale"}}`,
+		},
+		{
+			name:     "opened pull request",
+			event:    "pull_request",
+			payload:  `{"action":"opened","number":42,"pull_request":{"head":{"repo":{"full_name":"aweris/gale"}}},"repository":{"full_name":"aweris/gale"}}`,
+			expected: Trigger{Event: "pull_request", Repo: "aweris/gale", Ref: "refs/pull/42/merge"},
+			ok:       true,
+		},
+		{
+			name:     "pull request from fork",
+			event:    "pull_request",
+			payload:  `{"action":"synchronize","number":42,"pull_request":{"head":{"repo":{"full_name":"someone/gale"}}},"repository":{"full_name":"aweris/gale"}}`,
+			expected: Trigger{Event: "pull_request", Repo: "aweris/gale", Ref: "refs/pull/42/merge", Untrusted: true},
+			ok:       true,
+		},
+		{
+			name:     "pull request from deleted fork",
+			event:    "pull_request",
+			payload:  `{"action":"reopened","number":42,"pull_request":{"head":{"repo":null}},"repository":{"full_name":"aweris/gale"}}`,
+			expected: Trigger{Event: "pull_request", Repo: "aweris/gale", Ref: "refs/pull/42/merge", Untrusted: true},
+			ok:       true,
+		},
+		{
+			name:    "closed pull request",
+			event:   "pull_request",
+			payload: `{"action":"closed","number":42,"repository":{"full_name":"aweris/gale"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trigger, ok, err := parseTrigger(tt.event, []byte(tt.payload))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ok != tt.ok || !reflect.DeepEqual(trigger, tt.expected) {
+				t.Errorf("parseTrigger() = %+v, %v, want %+v, %v", trigger, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDaggerRunnerArgs(t *testing.T) {
+	runner := NewDaggerRunner("github.com/jpadams/gale/daggerverse/gale@main", true, []string{"syslog=udp://logs:514"})
+
+	args := runner.args(Trigger{Event: "push", Repo: "aweris/gale", Ref: "refs/heads/main", Commit: "abc123", EventFile: "/events/1.json"})
+
+	expected := []string{
+		"call", "-m", "github.com/jpadams/gale/daggerverse/gale@main", "workflows", "trigger",
+		"--repo", "aweris/gale", "--event", "push", "--output", "ndjson", "--event-file", "/events/1.json",
+		"--commit", "abc123", "--token", "env:GITHUB_TOKEN", "--journal-sinks", "syslog=udp://logs:514",
+	}
+
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("args() = %v, want %v", args, expected)
+	}
+}
+
+func TestDaggerRunnerArgsUntrusted(t *testing.T) {
+	runner := NewDaggerRunner("github.com/jpadams/gale/daggerverse/gale@main", true, nil)
+
+	args := runner.args(Trigger{Event: "pull_request", Repo: "aweris/gale", Ref: "refs/pull/42/merge", EventFile: "/events/2.json", Untrusted: true})
+
+	expected := []string{
+		"call", "-m", "github.com/jpadams/gale/daggerverse/gale@main", "workflows", "trigger",
+		"--repo", "aweris/gale", "--event", "pull_request", "--output", "ndjson", "--event-file", "/events/2.json",
+		"--ref", "refs/pull/42/merge", "--untrusted",
+	}
+
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("args() = %v, want %v", args, expected)
+	}
+}
+
+func TestDaggerRunnerArgsSchedule(t *testing.T) {
+	runner := NewDaggerRunner("github.com/jpadams/gale/daggerverse/gale@main", false, nil)
+
+	args := runner.args(Trigger{Event: "schedule", Repo: "aweris/gale", Workflow: "Nightly", EventFile: "/events/schedule-1-1.json"})
+
+	expected := []string{
+		"call", "-m", "github.com/jpadams/gale/daggerverse/gale@main", "workflows", "trigger",
+		"--repo", "aweris/gale", "--event", "schedule", "--output", "ndjson", "--event-file", "/events/schedule-1-1.json",
+		"--workflow", "Nightly",
+	}
+
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("args() = %v, want %v", args, expected)
+	}
+}
+
+func TestParseResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		out      string
+		expected []WorkflowResult
+		err      bool
+	}{
+		{name: "no workflows", out: ""},
+		{
+			name: "workflow results",
+			out:  "{\"name\":\"CI\",\"run_id\":\"1\",\"conclusion\":\"success\",\"duration\":\"1m2s\"}\n{\"name\":\"Lint\",\"conclusion\":\"\",\"error\":\"failed to run workflow\"}\n",
+			expected: []WorkflowResult{
+				{Name: "CI", RunID: "1", Conclusion: "success", Duration: "1m2s"},
+				{Name: "Lint", Error: "failed to run workflow"},
+			},
+		},
+		{name: "invalid result", out: "Workflow CI (run 1) completed with conclusion success\n", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := parseResults(tt.out)
+			if (err != nil) != tt.err {
+				t.Fatalf("parseResults() error = %v, want error %t", err, tt.err)
+			}
+
+			if !reflect.DeepEqual(results, tt.expected) {
+				t.Errorf("parseResults() = %v, want %v", results, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Trigger represents a workflow trigger received with a webhook.
+type Trigger struct {
+	ID        string // ID is the delivery id of the webhook.
+	Event     string // Event is the name of the event triggered the workflows, e.g. push.
+	Repo      string // Repo is the repository the event is triggered for in owner/name format.
+	Ref       string // Ref is the git ref to checkout, e.g. refs/heads/main or refs/pull/1/merge.
+	Commit    string // Commit is the commit SHA to checkout. If set, it has precedence over the ref.
+	EventFile string // EventFile is the path of the file with the webhook payload.
+	Workflow  string // Workflow is the name or the path of the workflow to run. If empty, all triggered workflows run.
+	Schedule  string // Schedule is the cron expression of the schedule triggered the workflow, for schedule events.
+	Untrusted bool   // Untrusted indicates the workflows run code from outside the repository, e.g. a pull request from a fork.
+}
+
+// WorkflowResult represents the result of a workflow run reported by the runner.
+type WorkflowResult struct {
+	Name       string `json:"name"`               // Name is the name of the workflow
+	RunID      string `json:"run_id"`             // RunID is the id of the workflow run in the run history
+	Conclusion string `json:"conclusion"`         // Conclusion is the conclusion of the workflow run, e.g. success
+	Duration   string `json:"duration,omitempty"` // Duration is the duration of the workflow run, e.g. 1m2s
+	Error      string `json:"error,omitempty"`    // Error is the error failing the workflow run, e.g. the fail on policy
+}
+
+// Runner runs the workflows of the given trigger.
+type Runner interface {
+	// Run runs the workflows of the trigger, writes the output of the run to the given writer and returns the results
+	// of the workflow runs. It returns the results with an error if any of the workflow runs fails.
+	Run(trigger Trigger, output io.Writer) ([]WorkflowResult, error)
+
+	// Schedules returns the cron schedules of the workflows of the repository at the given ref. If the ref is empty,
+	// the default branch is used.
+	Schedules(repo, ref string) ([]Schedule, error)
+
+	// Artifacts returns the names of the artifacts uploaded by the workflow run with the given id.
+	Artifacts(runID string) ([]string, error)
+}
+
+var _ Runner = new(DaggerRunner)
+
+// DaggerRunner runs the workflows with the gale module using the dagger cli.
+type DaggerRunner struct {
+	module       string   // module is the reference of the gale module
+	token        bool     // token indicates the GitHub token is available in the GITHUB_TOKEN environment variable
+	journalSinks []string // journalSinks is the sinks to ship the console output of the workflow runs to
+}
+
+// NewDaggerRunner creates a new dagger runner running the workflows with the given gale module. Console output of the
+// workflow runs is shipped to the given journal sinks as well.
+func NewDaggerRunner(module string, token bool, journalSinks []string) *DaggerRunner {
+	return &DaggerRunner{module: module, token: token, journalSinks: journalSinks}
+}
+
+// Run runs the workflows triggered by the event with the gale module. The module prints the results of the workflow runs
+// to stdout as newline delimited JSON, so only the logs on stderr are written to the output.
+func (r *DaggerRunner) Run(trigger Trigger, output io.Writer) ([]WorkflowResult, error) {
+	var stdout bytes.Buffer
+
+	//nolint:gosec // arguments are built from the validated webhook payload
+	cmd := exec.Command(r.cli(), r.args(trigger)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = output
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run workflows: %w", err)
+	}
+
+	results, err := parseResults(stdout.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var failed int
+
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d workflow(s) failed", failed, len(results))
+	}
+
+	return results, nil
+}
+
+// args returns the arguments of the dagger cli to run the workflows of the trigger.
+func (r *DaggerRunner) args(trigger Trigger) []string {
+	args := []string{"call", "-m", r.module, "workflows", "trigger", "--repo", trigger.Repo, "--event", trigger.Event, "--output", "ndjson"}
+
+	if trigger.EventFile != "" {
+		args = append(args, "--event-file", trigger.EventFile)
+	}
+
+	if trigger.Commit != "" {
+		args = append(args, "--commit", trigger.Commit)
+	} else if trigger.Ref != "" {
+		args = append(args, "--ref", trigger.Ref)
+	}
+
+	if trigger.Workflow != "" {
+		args = append(args, "--workflow", trigger.Workflow)
+	}
+
+	// untrusted workflows run in the sandbox of the module and never get the token of the service
+	if trigger.Untrusted {
+		args = append(args, "--untrusted")
+	} else if r.token {
+		args = append(args, "--token", "env:GITHUB_TOKEN")
+	}
+
+	for _, sink := range r.journalSinks {
+		args = append(args, "--journal-sinks", sink)
+	}
+
+	return args
+}
+
+// Schedules returns the cron schedules of the workflows of the repository with the gale module.
+func (r *DaggerRunner) Schedules(repo, ref string) ([]Schedule, error) {
+	args := []string{"call", "-m", r.module, "workflows", "schedules", "--repo", repo}
+
+	if ref != "" {
+		args = append(args, "--ref", ref)
+	}
+
+	if r.token {
+		args = append(args, "--token", "env:GITHUB_TOKEN")
+	}
+
+	//nolint:gosec // arguments are built from the service configuration
+	cmd := exec.Command(r.cli(), args...)
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+
+	return parseSchedules(string(out))
+}
+
+// Artifacts returns the names of the artifacts uploaded by the workflow run with the given id with the gale module.
+func (r *DaggerRunner) Artifacts(runID string) ([]string, error) {
+	//nolint:gosec // run id is validated by the caller
+	cmd := exec.Command(r.cli(), "call", "-m", r.module, "artifacts", "list", "--run-id", runID)
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+
+	var names []string
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// cli returns the path of the dagger cli binary.
+func (r *DaggerRunner) cli() string {
+	// nested executions get the cli binary connected to the same engine
+	if cli := os.Getenv("_EXPERIMENTAL_DAGGER_CLI_BIN"); cli != "" {
+		return cli
+	}
+
+	return "dagger"
+}
+
+// parseSchedules parses the schedules listed by the gale module. Each line is the cron expression and the workflow
+// separated with a tab.
+func parseSchedules(out string) ([]Schedule, error) {
+	var schedules []Schedule
+
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		expr, workflow, ok := strings.Cut(line, "\t")
+		if !ok {
+			return nil, fmt.Errorf("invalid schedule %q", line)
+		}
+
+		cron, err := ParseCron(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, Schedule{Workflow: workflow, Cron: cron})
+	}
+
+	return schedules, nil
+}
+
+// parseResults parses the results of the workflow runs printed by the gale module as newline delimited JSON.
+func parseResults(out string) ([]WorkflowResult, error) {
+	var results []WorkflowResult
+
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		var result WorkflowResult
+
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			return nil, fmt.Errorf("invalid workflow result %q: %w", line, err)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
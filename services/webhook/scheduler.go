@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Catch-up policies of the scheduler for the schedules missed while the scheduler wasn't able to tick, e.g. the host
+// was suspended or the schedules were being refreshed.
+const (
+	CatchUpSkip = "skip" // CatchUpSkip skips the missed schedules and only runs the schedules of the current minute.
+	CatchUpOnce = "once" // CatchUpOnce runs each workflow once if any of its schedules is missed.
+	CatchUpAll  = "all"  // CatchUpAll runs each workflow for every missed schedule.
+)
+
+// Schedule represents a cron schedule of a workflow.
+type Schedule struct {
+	Workflow string // Workflow is the name of the workflow, or its path if it doesn't have a name.
+	Cron     *Cron  // Cron is the parsed cron expression of the schedule.
+}
+
+// ScheduledRepo represents a repository the scheduler runs the scheduled workflows of.
+type ScheduledRepo struct {
+	Repo      string     // Repo is the repository in owner/name format.
+	Ref       string     // Ref is the git ref of the branch to run the workflows on. If empty, the default branch is used.
+	Schedules []Schedule // Schedules are the cron schedules of the workflows of the repository.
+}
+
+// Scheduler triggers the workflows with the schedule event at the times of their cron schedules, like GitHub.
+type Scheduler struct {
+	eventsDir string
+	catchUp   string
+	jitter    time.Duration
+	refresh   time.Duration
+	runner    Runner
+
+	mu    sync.Mutex
+	repos []*ScheduledRepo
+	last  time.Time // last is the last minute the schedules are checked for
+	seq   atomic.Int64
+}
+
+// NewScheduler creates a new scheduler for the given repositories. Repositories are in owner/name format, optionally
+// with the branch to run the workflows on, e.g. aweris/gale@develop.
+func NewScheduler(repos []string, eventsDir, catchUp string, jitter, refresh time.Duration, runner Runner) (*Scheduler, error) {
+	switch catchUp {
+	case CatchUpSkip, CatchUpOnce, CatchUpAll:
+	default:
+		return nil, fmt.Errorf("invalid catch-up policy %q, expected one of %s, %s or %s", catchUp, CatchUpSkip, CatchUpOnce, CatchUpAll)
+	}
+
+	if jitter < 0 {
+		return nil, fmt.Errorf("invalid jitter %s, expected a positive duration", jitter)
+	}
+
+	scheduler := &Scheduler{eventsDir: eventsDir, catchUp: catchUp, jitter: jitter, refresh: refresh, runner: runner}
+
+	for _, repo := range repos {
+		name, branch, _ := strings.Cut(repo, "@")
+
+		if owner, n, ok := strings.Cut(name, "/"); !ok || owner == "" || n == "" {
+			return nil, fmt.Errorf("invalid repository %q, expected owner/name format", repo)
+		}
+
+		sr := &ScheduledRepo{Repo: name}
+
+		if branch != "" {
+			sr.Ref = fmt.Sprintf("refs/heads/%s", branch)
+		}
+
+		scheduler.repos = append(scheduler.repos, sr)
+	}
+
+	return scheduler, nil
+}
+
+// Start loads the schedules of the repositories and sends the triggers of the schedules to the queue every minute.
+// Schedules are reloaded periodically to pick up the changes in the workflows. It blocks forever.
+func (s *Scheduler) Start(queue *Queue) {
+	s.loadSchedules()
+
+	s.mu.Lock()
+	s.last = time.Now().UTC().Truncate(time.Minute)
+	s.mu.Unlock()
+
+	refreshed := time.Now()
+
+	for {
+		// ticks are aligned to the start of the minutes
+		now := time.Now().UTC()
+		time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+
+		for _, trigger := range s.due(time.Now()) {
+			s.enqueue(trigger, queue)
+		}
+
+		if s.refresh > 0 && time.Since(refreshed) >= s.refresh {
+			refreshed = time.Now()
+
+			// loading the schedules takes a while, so it shouldn't delay the next tick
+			go s.loadSchedules()
+		}
+	}
+}
+
+// loadSchedules loads the cron schedules of the workflows of the repositories. Repositories failed to load keep their
+// previous schedules.
+func (s *Scheduler) loadSchedules() {
+	for _, repo := range s.repos {
+		schedules, err := s.runner.Schedules(repo.Repo, repo.Ref)
+		if err != nil {
+			fmt.Printf("Failed to load schedules of %s: %s\n", repo.Repo, err.Error())
+			continue
+		}
+
+		fmt.Printf("Loaded %d schedule(s) of %s\n", len(schedules), repo.Repo)
+
+		s.mu.Lock()
+		repo.Schedules = schedules
+		s.mu.Unlock()
+	}
+}
+
+// due returns the triggers of the schedules between the last checked minute and the given time. Missed schedules are
+// handled with the catch-up policy of the scheduler.
+func (s *Scheduler) due(now time.Time) []Trigger {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := now.UTC().Truncate(time.Minute)
+
+	var triggers []Trigger
+
+	for _, repo := range s.repos {
+		for _, schedule := range repo.Schedules {
+			var missed []time.Time
+
+			for t := s.last.Add(time.Minute); t.Before(current); t = t.Add(time.Minute) {
+				if schedule.Cron.Match(t) {
+					missed = append(missed, t)
+				}
+			}
+
+			var times []time.Time
+
+			switch {
+			case schedule.Cron.Match(current):
+				times = []time.Time{current}
+
+				if s.catchUp == CatchUpAll {
+					times = append(missed, current)
+				}
+			case len(missed) > 0 && s.catchUp == CatchUpOnce:
+				times = missed[len(missed)-1:]
+			case len(missed) > 0 && s.catchUp == CatchUpAll:
+				times = missed
+			}
+
+			for _, t := range times {
+				triggers = append(triggers, s.trigger(repo, schedule, t))
+			}
+		}
+	}
+
+	if current.After(s.last) {
+		s.last = current
+	}
+
+	return triggers
+}
+
+// trigger returns the trigger of the workflow for the schedule at the given time.
+func (s *Scheduler) trigger(repo *ScheduledRepo, schedule Schedule, at time.Time) Trigger {
+	return Trigger{
+		ID:       fmt.Sprintf("schedule-%d-%d", at.Unix(), s.seq.Add(1)),
+		Event:    "schedule",
+		Repo:     repo.Repo,
+		Ref:      repo.Ref,
+		Workflow: schedule.Workflow,
+		Schedule: schedule.Cron.String(),
+	}
+}
+
+// enqueue writes the schedule event payload of the trigger and sends it to the queue after a random delay up to the
+// jitter of the scheduler, to spread the runs scheduled at the same time.
+func (s *Scheduler) enqueue(trigger Trigger, queue *Queue) {
+	trigger.EventFile = filepath.Join(s.eventsDir, fmt.Sprintf("%s.json", trigger.ID))
+
+	if err := os.WriteFile(trigger.EventFile, schedulePayload(trigger), 0600); err != nil {
+		fmt.Printf("Failed to write schedule event %s of %s: %s\n", trigger.ID, trigger.Repo, err.Error())
+		return
+	}
+
+	var delay time.Duration
+
+	if s.jitter > 0 {
+		//nolint:gosec // jitter doesn't need a secure random number
+		delay = time.Duration(rand.Int63n(int64(s.jitter)))
+	}
+
+	time.AfterFunc(delay, func() {
+		if err := queue.Push(trigger); err != nil {
+			fmt.Printf("Skipping schedule event %s of %s: %s\n", trigger.ID, trigger.Repo, err.Error())
+		}
+	})
+}
+
+// schedulePayload returns the schedule event payload of the trigger. Like GitHub, the payload contains the cron
+// expression of the schedule triggered the workflow.
+func schedulePayload(trigger Trigger) []byte {
+	owner, name, _ := strings.Cut(trigger.Repo, "/")
+
+	payload := map[string]interface{}{
+		"schedule": trigger.Schedule,
+		"repository": map[string]interface{}{
+			"name":      name,
+			"full_name": trigger.Repo,
+			"owner":     map[string]interface{}{"login": owner},
+			"html_url":  fmt.Sprintf("https://github.com/%s", trigger.Repo),
+		},
+	}
+
+	// payload only contains strings and maps, so it can't fail to marshal
+	data, _ := json.Marshal(payload)
+
+	return data
+}
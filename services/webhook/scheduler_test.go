@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCronMatch(t *testing.T) {
+	tests := []struct {
+		expr     string
+		at       string
+		expected bool
+	}{
+		{expr: "*/15 * * * *", at: "2023-05-01T10:30:00Z", expected: true},
+		{expr: "*/15 * * * *", at: "2023-05-01T10:31:00Z", expected: false},
+		{expr: "0 9 * * 1-5", at: "2023-05-01T09:00:00Z", expected: true},  // monday
+		{expr: "0 9 * * 1-5", at: "2023-05-06T09:00:00Z", expected: false}, // saturday
+		{expr: "0 0 * * 7", at: "2023-05-07T00:00:00Z", expected: true},    // sunday
+		{expr: "0 0 1 * 1", at: "2023-05-08T00:00:00Z", expected: true},    // monday, day of month or day of week
+		{expr: "0 0 1 * *", at: "2023-05-08T00:00:00Z", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr+" "+tt.at, func(t *testing.T) {
+			cron, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			at, err := time.Parse(time.RFC3339, tt.at)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := cron.Match(at); got != tt.expected {
+				t.Errorf("Match() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * * * 8", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) expected error", expr)
+		}
+	}
+}
+
+func TestSchedulerDue(t *testing.T) {
+	cron, err := ParseCron("*/10 * * * *")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	last := time.Date(2023, time.May, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		catchUp  string
+		now      time.Time
+		expected []time.Time
+	}{
+		{name: "on time", catchUp: CatchUpSkip, now: last.Add(10 * time.Minute), expected: []time.Time{last.Add(10 * time.Minute)}},
+		{name: "not scheduled", catchUp: CatchUpAll, now: last.Add(time.Minute)},
+		{name: "skip missed", catchUp: CatchUpSkip, now: last.Add(35 * time.Minute)},
+		{name: "run missed once", catchUp: CatchUpOnce, now: last.Add(35 * time.Minute), expected: []time.Time{last.Add(30 * time.Minute)}},
+		{name: "run all missed", catchUp: CatchUpAll, now: last.Add(30 * time.Minute), expected: []time.Time{last.Add(10 * time.Minute), last.Add(20 * time.Minute), last.Add(30 * time.Minute)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScheduler([]string{"aweris/gale@main"}, t.TempDir(), tt.catchUp, 0, 0, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			s.last = last
+			s.repos[0].Schedules = []Schedule{{Workflow: "nightly", Cron: cron}}
+
+			var times []time.Time
+
+			for _, trigger := range s.due(tt.now) {
+				if trigger.Event != "schedule" || trigger.Workflow != "nightly" || trigger.Ref != "refs/heads/main" {
+					t.Errorf("unexpected trigger %+v", trigger)
+				}
+
+				var at int64
+				if _, err := fmt.Sscanf(trigger.ID, "schedule-%d-", &at); err != nil {
+					t.Fatalf("unexpected trigger id %s", trigger.ID)
+				}
+
+				times = append(times, time.Unix(at, 0).UTC())
+			}
+
+			if !reflect.DeepEqual(times, tt.expected) {
+				t.Errorf("due() = %v, want %v", times, tt.expected)
+			}
+
+			if !s.last.Equal(tt.now) {
+				t.Errorf("last = %v, want %v", s.last, tt.now)
+			}
+		})
+	}
+}
+
+func TestNewSchedulerInvalid(t *testing.T) {
+	if _, err := NewScheduler([]string{"gale"}, "", CatchUpSkip, 0, 0, nil); err == nil {
+		t.Error("expected error for invalid repository")
+	}
+
+	if _, err := NewScheduler(nil, "", "sometimes", 0, 0, nil); err == nil {
+		t.Error("expected error for invalid catch-up policy")
+	}
+}
+
+func TestSchedulerEnqueue(t *testing.T) {
+	s, err := NewScheduler(nil, t.TempDir(), CatchUpSkip, 0, 0, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	queue, err := NewQueue(nil, t.TempDir(), 1, 0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s.enqueue(Trigger{ID: "schedule-1-1", Event: "schedule", Repo: "aweris/gale", Schedule: "0 0 * * *"}, queue)
+
+	var pending []TriggerStatus
+
+	for deadline := time.Now().Add(time.Second); len(pending) == 0; pending = queue.Status().Pending {
+		if time.Now().After(deadline) {
+			t.Fatal("trigger is not queued")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	data, err := os.ReadFile(pending[0].trigger.EventFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var payload struct {
+		Schedule   string     `json:"schedule"`
+		Repository Repository `json:"repository"`
+	}
+
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if payload.Schedule != "0 0 * * *" || payload.Repository.FullName != "aweris/gale" {
+		t.Errorf("unexpected payload %s", data)
+	}
+}
+
+func TestParseSchedules(t *testing.T) {
+	schedules, err := parseSchedules("0 0 * * *\tNightly\n*/5 * * * 1-5\t.github/workflows/poll.yaml\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(schedules) != 2 || schedules[0].Workflow != "Nightly" || schedules[1].Cron.String() != "*/5 * * * 1-5" {
+		t.Errorf("unexpected schedules %+v", schedules)
+	}
+}